@@ -7,6 +7,7 @@ package hubble
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"math"
@@ -40,6 +41,7 @@ import (
 	"github.com/microsoft/retina/pkg/managers/servermanager"
 	sharedconfig "github.com/microsoft/retina/pkg/shared/config"
 	"github.com/microsoft/retina/pkg/telemetry"
+	"github.com/microsoft/retina/pkg/utils"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -306,10 +308,25 @@ func Execute(cobraCmd *cobra.Command, h *hive.Hive) {
 		logger.Fatal("failed to remove memlock", zap.Error(err))
 	}
 
+	// Fail fast on kernels the eBPF plugins can't run on, rather than running without them.
+	// A kernel release that can't be read is only warned about, as it may be a custom build.
+	kernel, err := telemetry.KernelVersion(context.Background())
+	if err == nil {
+		err = utils.CheckKernelRelease(kernel)
+	}
+	switch {
+	case errors.Is(err, utils.ErrUnsupportedKernel):
+		logger.WithField("kernel", kernel).WithError(err).Fatal("unsupported kernel")
+	case err != nil:
+		logger.WithField("kernel", kernel).WithError(err).Warn("failed to check kernel version")
+	default:
+		logger.WithField("kernel", kernel).Info("detected kernel version")
+	}
+
 	//nolint:gocritic // without granular commits this commented-out code may be lost
 	// initEnv(h.Viper())
 
-	if err := h.Run(); err != nil {
+	if err = h.Run(); err != nil {
 		logger.Fatal(err)
 	}
 }
@@ -3,6 +3,7 @@
 package legacy
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -45,6 +46,7 @@ import (
 	mm "github.com/microsoft/retina/pkg/module/metrics"
 	"github.com/microsoft/retina/pkg/pubsub"
 	"github.com/microsoft/retina/pkg/telemetry"
+	"github.com/microsoft/retina/pkg/utils"
 )
 
 const (
@@ -139,6 +141,19 @@ func (d *Daemon) Start() error {
 		mainLogger.Fatal("failed to remove memlock", zap.Error(err))
 	}
 
+	// Fail fast on kernels the eBPF plugins can't run on, rather than running without them.
+	// A kernel release that can't be read is only warned about, as it may be a custom build.
+	// This is a no-op on Windows.
+	kernel, err := d.DetectKernel()
+	switch {
+	case errors.Is(err, utils.ErrUnsupportedKernel):
+		mainLogger.Fatalw("unsupported kernel", "kernel", kernel, zap.Error(err))
+	case err != nil:
+		mainLogger.Warnw("failed to check kernel version", "kernel", kernel, zap.Error(err))
+	case kernel != "":
+		mainLogger.Infow("detected kernel version", "kernel", kernel)
+	}
+
 	metrics.InitializeMetrics()
 
 	mainLogger.Info(zap.String("data aggregation level", daemonConfig.DataAggregationLevel.String()))
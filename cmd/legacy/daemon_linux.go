@@ -1,7 +1,23 @@
 package legacy
 
-import "github.com/cilium/ebpf/rlimit"
+import (
+	"context"
+
+	"github.com/cilium/ebpf/rlimit"
+	"github.com/microsoft/retina/pkg/telemetry"
+	"github.com/microsoft/retina/pkg/utils"
+)
 
 func (d *Daemon) RemoveMemlock() error {
 	return rlimit.RemoveMemlock()
 }
+
+// DetectKernel returns the release of the node's kernel, failing with utils.ErrUnsupportedKernel
+// when it's older than Retina supports.
+func (d *Daemon) DetectKernel() (string, error) {
+	kernel, err := telemetry.KernelVersion(context.Background())
+	if err != nil {
+		return "", err //nolint:wrapcheck // already wrapped
+	}
+	return kernel, utils.CheckKernelRelease(kernel)
+}
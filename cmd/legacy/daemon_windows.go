@@ -4,3 +4,8 @@ func (d *Daemon) RemoveMemlock() error {
 	// This function is a no-op on Windows.
 	return nil
 }
+
+func (d *Daemon) DetectKernel() (string, error) {
+	// This function is a no-op on Windows.
+	return "", nil
+}
@@ -35,11 +35,11 @@ type MetricsConfigurationReconciler struct {
 	client.Client
 	Scheme        *runtime.Scheme
 	mcCache       map[string]*retinav1alpha1.MetricsConfiguration
-	metricsModule *mm.Module
+	metricsModule mm.IModule
 	l             *log.ZapLogger
 }
 
-func New(client client.Client, scheme *runtime.Scheme, metricsModule *mm.Module) *MetricsConfigurationReconciler {
+func New(client client.Client, scheme *runtime.Scheme, metricsModule mm.IModule) *MetricsConfigurationReconciler {
 	return &MetricsConfigurationReconciler{
 		Mutex:         &sync.Mutex{},
 		l:             log.Logger().Named(string("metricsconfiguration-controller")),
@@ -64,6 +64,12 @@ func (r *MetricsConfigurationReconciler) Reconcile(ctx context.Context, req ctrl
 			if _, ok := r.mcCache[req.NamespacedName.String()]; ok {
 				delete(r.mcCache, req.NamespacedName.String())
 				r.l.Info("deleted from cache", zap.String("name", req.NamespacedName.String()))
+
+				// stop exporting the metrics the deleted configuration configured
+				err := r.metricsModule.Reconcile(&retinav1alpha1.MetricsSpec{})
+				if err != nil {
+					r.l.Error("error reconciling metrics configurations", zap.String("name", req.NamespacedName.String()), zap.Error(err))
+				}
 			}
 			r.Unlock()
 			return ctrl.Result{}, nil
@@ -15,9 +15,11 @@ package metricsconfigurationcontroller
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -29,6 +31,7 @@ import (
 
 	retinav1alpha1 "github.com/microsoft/retina/crd/api/v1alpha1"
 	"github.com/microsoft/retina/pkg/log"
+	"github.com/microsoft/retina/pkg/module/metrics"
 )
 
 var fakescheme = runtime.NewScheme()
@@ -127,3 +130,48 @@ func TestMetricsConfigurationReconciler_Reconcile(t *testing.T) {
 		})
 	}
 }
+
+func TestMetricsConfigurationReconciler_ReconcileDeleted(t *testing.T) {
+	log.SetupZapLogger(log.GetDefaultLogOpts())
+
+	req := ctrl.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      "test",
+			Namespace: "default",
+		},
+	}
+
+	tests := []struct {
+		name         string
+		reconcileErr error
+	}{
+		{
+			name: "should reconcile an empty spec when the metrics configuration is deleted",
+		},
+		{
+			name:         "should drop the deleted metrics configuration even if reconciling fails",
+			reconcileErr: errors.New("reconcile failed"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mm := metrics.NewMockIModule(ctrl) //nolint:typecheck
+			mm.EXPECT().Reconcile(&retinav1alpha1.MetricsSpec{}).Return(tt.reconcileErr).Times(1)
+
+			client := fake.NewClientBuilder().WithScheme(fakescheme).Build()
+			r := New(client, fakescheme, mm)
+			r.mcCache[req.NamespacedName.String()] = &retinav1alpha1.MetricsConfiguration{}
+
+			_, err := r.Reconcile(context.TODO(), req)
+			require.NoError(t, err)
+			require.NotContains(t, r.mcCache, req.NamespacedName.String())
+
+			// the configuration is only reconciled away once
+			_, err = r.Reconcile(context.TODO(), req)
+			require.NoError(t, err)
+		})
+	}
+}
@@ -165,15 +165,26 @@ func (p *PluginManager) Start(ctx context.Context) error {
 		if err != nil {
 			// Update control plane metrics counter
 			metrics.PluginManagerFailedToReconcileCounter.WithLabelValues(plugin.Name()).Inc()
+			metrics.PluginStatusGauge.WithLabelValues(plugin.Name()).Set(0)
 			return errors.Wrapf(err, "failed to reconcile plugin %s", plugin.Name())
 		}
 
+		metrics.PluginStatusGauge.WithLabelValues(plug.Name()).Set(1)
 		g.Go(func() error {
 			p.l.Info(fmt.Sprintf("starting plugin %s", plug.Name()))
-			return errors.Wrapf(plug.Start(ctx), "failed to start plugin %s", plug.Name())
+			if err := plug.Start(ctx); err != nil {
+				metrics.PluginStatusGauge.WithLabelValues(plug.Name()).Set(0)
+				return errors.Wrapf(err, "failed to start plugin %s", plug.Name())
+			}
+			return nil
 		})
 	}
 
+	g.Go(func() error {
+		p.checkHealth(ctx)
+		return nil
+	})
+
 	p.tel.StopPerf(counter)
 	p.l.Info("successfully started pluginmanager")
 	// on cancel context wait for all plugins to exit
@@ -196,6 +207,33 @@ func (p *PluginManager) Start(ctx context.Context) error {
 	return nil
 }
 
+// checkHealth updates the status of the plugins checking their own health every metrics interval,
+// until the context is done. An unhealthy plugin keeps running, it's left to whoever watches the metric.
+func (p *PluginManager) checkHealth(ctx context.Context) {
+	ticker := time.NewTicker(p.cfg.MetricsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, plugin := range p.plugins {
+				checker, ok := plugin.(api.HealthChecker)
+				if !ok {
+					continue
+				}
+				if err := checker.Healthy(); err != nil {
+					p.l.Warn("plugin is unhealthy", zap.String("name", plugin.Name()), zap.Error(err))
+					metrics.PluginStatusGauge.WithLabelValues(plugin.Name()).Set(0)
+					continue
+				}
+				metrics.PluginStatusGauge.WithLabelValues(plugin.Name()).Set(1)
+			}
+		}
+	}
+}
+
 func (p *PluginManager) SetPlugin(name api.PluginName, plugin api.Plugin) {
 	if p == nil {
 		return
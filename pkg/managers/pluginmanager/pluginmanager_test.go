@@ -6,6 +6,7 @@ import (
 	"context"
 	"errors"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -462,3 +463,89 @@ func TestWatcherManagerFailure(t *testing.T) {
 	require.NotNil(t, err, "Expected Start err but got nil")
 	require.ErrorContains(t, err, "failed to start watcher manager", "Expected watcher manager , but got:%w", err)
 }
+
+// healthCheckingPlugin is a mock plugin that reports the health set with setHealth.
+type healthCheckingPlugin struct {
+	*pluginmock.MockPlugin
+
+	mu  sync.Mutex
+	err error
+}
+
+func (h *healthCheckingPlugin) Healthy() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.err
+}
+
+func (h *healthCheckingPlugin) setHealth(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.err = err
+}
+
+func pluginStatus(t *testing.T, pluginName string) float64 {
+	gauge, err := metrics.PluginStatusGauge.GetMetricWithLabelValues(pluginName)
+	require.NoError(t, err)
+	out := &dto.Metric{}
+	require.NoError(t, gauge.Write(out))
+	return out.GetGauge().GetValue()
+}
+
+func TestCheckHealth(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	log.SetupZapLogger(log.GetDefaultLogOpts())
+	metrics.InitializeMetrics()
+
+	checkingName := "healthcheckingplugin"
+	checking := &healthCheckingPlugin{MockPlugin: pluginmock.NewMockPlugin(ctl)}
+	checking.EXPECT().Name().Return(checkingName).AnyTimes()
+
+	// plugins without a health check keep the status they started with
+	plainName := "plainplugin"
+	plain := pluginmock.NewMockPlugin(ctl)
+	plain.EXPECT().Name().Return(plainName).AnyTimes()
+	metrics.PluginStatusGauge.WithLabelValues(plainName).Set(1)
+
+	mgr := &PluginManager{
+		cfg: &kcfg.Config{
+			MetricsInterval: 10 * time.Millisecond,
+		},
+		l: log.Logger().Named("plugin-manager"),
+		plugins: map[api.PluginName]api.Plugin{
+			api.PluginName(checkingName): checking,
+			api.PluginName(plainName):    plain,
+		},
+		tel: telemetry.NewNoopTelemetry(),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		mgr.checkHealth(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		return pluginStatus(t, checkingName) == 1
+	}, time.Second, 10*time.Millisecond, "healthy plugin not reported up")
+
+	checking.setHealth(errors.New("filter detached"))
+	require.Eventually(t, func() bool {
+		return pluginStatus(t, checkingName) == 0
+	}, time.Second, 10*time.Millisecond, "unhealthy plugin not reported down")
+	require.Equal(t, float64(1), pluginStatus(t, plainName))
+
+	checking.setHealth(nil)
+	require.Eventually(t, func() bool {
+		return pluginStatus(t, checkingName) == 1
+	}, time.Second, 10*time.Millisecond, "recovered plugin not reported up")
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("checkHealth did not return after the context was cancelled")
+	}
+}
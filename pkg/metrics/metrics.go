@@ -129,6 +129,21 @@ func InitializeMetrics() {
 		utils.Reason,
 	)
 
+	// Conntrack entries defines the size of the conntrack map, which bounds the connections being tracked
+	ConntrackEntriesGauge = exporter.CreatePrometheusGaugeVecForControlPlaneMetric(
+		exporter.DefaultRegistry,
+		conntrackEntriesGaugeName,
+		conntrackEntriesGaugeDescription,
+	)
+
+	// Plugin status defines whether each plugin is running and, for plugins checking their own health, still working
+	PluginStatusGauge = exporter.CreatePrometheusGaugeVecForControlPlaneMetric(
+		exporter.DefaultRegistry,
+		pluginStatusGaugeName,
+		pluginStatusGaugeDescription,
+		utils.Plugin,
+	)
+
 	// DNS Metrics.
 	DNSRequestCounter = exporter.CreatePrometheusCounterVecForMetric(
 		exporter.DefaultRegistry,
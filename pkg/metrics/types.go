@@ -12,6 +12,8 @@ const (
 	// Control plane metrics
 	pluginManagerFailedToReconcileCounterName = "plugin_manager_failed_to_reconcile"
 	lostEventsCounterName                     = "lost_events_counter"
+	conntrackEntriesGaugeName                 = "conntrack_entries"
+	pluginStatusGaugeName                     = "plugin_status"
 
 	// Windows
 	hnsStats            = "windows_hns_stats"
@@ -43,6 +45,8 @@ const (
 	// Control plane metrics
 	pluginManagerFailedToReconcileCounterDescription = "Number of times the plugin manager failed to reconcile the plugins"
 	lostEventsCounterDescription                     = "Number of events lost in control plane"
+	conntrackEntriesGaugeDescription                 = "Number of entries left in the conntrack map after its last garbage collection"
+	pluginStatusGaugeDescription                     = "Whether each plugin is running and healthy, 1 if it is and 0 if it failed"
 )
 
 // Metric Counters
@@ -82,6 +86,8 @@ var (
 	// Control Plane Metrics
 	PluginManagerFailedToReconcileCounter CounterVec
 	LostEventsCounter                     CounterVec
+	ConntrackEntriesGauge                 GaugeVec
+	PluginStatusGauge                     GaugeVec
 
 	// DNS Metrics.
 	DNSRequestCounter  CounterVec
@@ -40,3 +40,10 @@ type Plugin interface {
 	// This can be useful for plugins that need to send data to other components for post-processing.
 	SetupChannel(chan *v1.Event) error
 }
+
+// HealthChecker is implemented by plugins that can tell whether they are still working once started,
+// e.g. whether their eBPF programs are still attached. The plugin manager checks it every metrics interval.
+type HealthChecker interface {
+	// Healthy returns an error if the plugin stopped working.
+	Healthy() error
+}
@@ -78,6 +78,18 @@ func processMapValue(m IMap, key uint32) (uint64, uint64, error) {
 	return totalCount, totalBytes, err
 }
 
+// checkMapRead returns ErrCorruptMapRead if any total in cur is lower than in prev.
+func checkMapRead(prev, cur *PacketForwardData) error {
+	if prev == nil {
+		return nil
+	}
+	if cur.ingressCountTotal < prev.ingressCountTotal || cur.ingressBytesTotal < prev.ingressBytesTotal ||
+		cur.egressCountTotal < prev.egressCountTotal || cur.egressBytesTotal < prev.egressBytesTotal {
+		return fmt.Errorf("totals went backwards from %s: %w", prev.String(), ErrCorruptMapRead)
+	}
+	return nil
+}
+
 func updateMetrics(data *PacketForwardData) {
 	// Add the packet count metrics.
 	metrics.ForwardPacketsGauge.WithLabelValues(ingressLabel).Set(float64(data.ingressCountTotal))
@@ -219,6 +231,18 @@ func (p *packetForward) run(ctx context.Context) error {
 				p.l.Error("Error reading hash map", zap.Error(err))
 				continue
 			}
+			// the map only counts up, so a read that goes backwards is corrupt or partial
+			if err = checkMapRead(p.lastData, data); err != nil {
+				p.skippedReads++
+				if p.skippedReads < maxSkippedMapReads {
+					p.l.Error("Skipping packetforward map read", zap.Error(err), zap.String("Data", data.String()))
+					continue
+				}
+				// this many reads in a row can't all be corrupt, so the exported read was
+				p.l.Warn("Re-baselining packetforward map reads", zap.Error(err), zap.Int("SkippedReads", p.skippedReads-1), zap.String("Data", data.String()))
+			}
+			p.skippedReads = 0
+			p.lastData = data
 			p.l.Debug("Received PacketForward data", zap.String("Data", data.String()))
 			updateMetrics(data)
 		}
@@ -11,6 +11,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -19,8 +20,11 @@ import (
 	"github.com/microsoft/retina/pkg/log"
 	"github.com/microsoft/retina/pkg/metrics"
 	mocks "github.com/microsoft/retina/pkg/plugin/packetforward/mocks"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -278,3 +282,85 @@ func TestRun_ReturnError_Egress(t *testing.T) {
 	err := g.Wait()
 	require.NoError(t, err)
 }
+
+func TestCheckMapRead(t *testing.T) {
+	prev := &PacketForwardData{ingressCountTotal: 10, ingressBytesTotal: 100, egressCountTotal: 5, egressBytesTotal: 50}
+
+	require.NoError(t, checkMapRead(nil, prev))
+	require.NoError(t, checkMapRead(prev, &PacketForwardData{ingressCountTotal: 10, ingressBytesTotal: 100, egressCountTotal: 6, egressBytesTotal: 60}))
+	require.ErrorIs(t, checkMapRead(prev, &PacketForwardData{ingressCountTotal: 10, ingressBytesTotal: 100, egressCountTotal: 5, egressBytesTotal: 49}), ErrCorruptMapRead)
+	require.ErrorIs(t, checkMapRead(prev, &PacketForwardData{}), ErrCorruptMapRead)
+}
+
+func TestRun_SkipsCorruptRead(t *testing.T) {
+	metrics.InitializeMetrics()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// the second read only returns one cpu's values, every other read is good
+	var ingressReads atomic.Int32
+	mockedMap := mocks.NewMockIMap(ctrl)
+	mockedMap.EXPECT().Lookup(ingressKey, gomock.Any()).DoAndReturn(func(_, valueOut interface{}) error {
+		values := []packetforwardMetric{{Count: 3, Bytes: 30}}
+		if ingressReads.Add(1) != 2 {
+			values = append(values, packetforwardMetric{Count: 7, Bytes: 70})
+		}
+		*valueOut.(*[]packetforwardMetric) = values
+		return nil
+	}).MinTimes(2)
+	mockedMap.EXPECT().Lookup(egressKey, gomock.Any()).SetArg(1, []packetforwardMetric{{Count: 5, Bytes: 50}}).Return(nil).MinTimes(2)
+
+	core, logs := observer.New(zap.ErrorLevel)
+	p := &packetForward{
+		cfg: &kcfg.Config{
+			MetricsInterval: 100 * time.Millisecond,
+		},
+		l:           &log.ZapLogger{Logger: zap.New(core)},
+		hashmapData: mockedMap,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	require.NoError(t, p.run(ctx))
+
+	require.Equal(t, float64(10), testutil.ToFloat64(metrics.ForwardPacketsGauge.WithLabelValues(ingressLabel)))
+	require.Equal(t, float64(100), testutil.ToFloat64(metrics.ForwardBytesGauge.WithLabelValues(ingressLabel)))
+	require.Equal(t, 1, logs.FilterMessage("Skipping packetforward map read").Len())
+}
+
+func TestRun_RebaselinesAfterHighCorruptRead(t *testing.T) {
+	metrics.InitializeMetrics()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// the first read is garbage that's too high, every read after it is good
+	var ingressReads atomic.Int32
+	mockedMap := mocks.NewMockIMap(ctrl)
+	mockedMap.EXPECT().Lookup(ingressKey, gomock.Any()).DoAndReturn(func(_, valueOut interface{}) error {
+		values := []packetforwardMetric{{Count: 10, Bytes: 100}}
+		if ingressReads.Add(1) == 1 {
+			values = []packetforwardMetric{{Count: 1 << 60, Bytes: 1 << 62}}
+		}
+		*valueOut.(*[]packetforwardMetric) = values
+		return nil
+	}).MinTimes(maxSkippedMapReads + 1)
+	mockedMap.EXPECT().Lookup(egressKey, gomock.Any()).SetArg(1, []packetforwardMetric{{Count: 5, Bytes: 50}}).Return(nil).MinTimes(maxSkippedMapReads + 1)
+
+	core, logs := observer.New(zap.WarnLevel)
+	p := &packetForward{
+		cfg: &kcfg.Config{
+			MetricsInterval: 100 * time.Millisecond,
+		},
+		l:           &log.ZapLogger{Logger: zap.New(core)},
+		hashmapData: mockedMap,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	require.NoError(t, p.run(ctx))
+
+	require.Equal(t, float64(10), testutil.ToFloat64(metrics.ForwardPacketsGauge.WithLabelValues(ingressLabel)))
+	require.Equal(t, float64(100), testutil.ToFloat64(metrics.ForwardBytesGauge.WithLabelValues(ingressLabel)))
+	require.Equal(t, maxSkippedMapReads-1, logs.FilterMessage("Skipping packetforward map read").Len())
+	require.Equal(t, 1, logs.FilterMessage("Re-baselining packetforward map reads").Len())
+}
@@ -3,6 +3,7 @@
 package packetforward
 
 import (
+	"errors"
 	"fmt"
 
 	kcfg "github.com/microsoft/retina/pkg/config"
@@ -25,6 +26,13 @@ const (
 	dynamicHeaderFileName     string         = "dynamic.h"
 )
 
+// ErrCorruptMapRead is returned for a map read that is lower than the previous one.
+var ErrCorruptMapRead = errors.New("corrupt or partial packetforward map read")
+
+// maxSkippedMapReads is how many reads in a row are skipped as corrupt before the last exported read is taken to be
+// the corrupt one, e.g. a read that was too high, and the next read is exported as the new baseline.
+const maxSkippedMapReads = 3
+
 // Interface to https://pkg.go.dev/github.com/cilium/ebpf#Map.
 // Added for unit tests.
 //
@@ -40,6 +48,10 @@ type packetForward struct {
 	hashmapData IMap
 	sock        int
 	isRunning   bool
+	// lastData is the last read that was exported, used to detect corrupt reads
+	lastData *PacketForwardData
+	// skippedReads counts the reads skipped as corrupt since lastData
+	skippedReads int
 }
 
 type PacketForwardData struct {
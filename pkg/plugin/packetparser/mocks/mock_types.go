@@ -105,6 +105,21 @@ func (mr *MockIFilterMockRecorder) Add(info any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockIFilter)(nil).Add), info)
 }
 
+// Get mocks base method.
+func (m *MockIFilter) Get(info *tc.Msg) ([]tc.Object, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", info)
+	ret0, _ := ret[0].([]tc.Object)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockIFilterMockRecorder) Get(info any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockIFilter)(nil).Get), info)
+}
+
 // MockITc is a mock of ITc interface.
 type MockITc struct {
 	ctrl     *gomock.Controller
@@ -48,7 +48,10 @@ import (
 )
 
 //go:generate go run github.com/cilium/ebpf/cmd/bpf2go@master -cflags "-g -O2 -Wall -D__TARGET_ARCH_${GOARCH} -Wall" -target ${GOARCH} -type packet packetparser ./_cprog/packetparser.c -- -I../lib/_${GOARCH} -I../lib/common/libbpf/_src -I../lib/common/libbpf/_include/linux -I../lib/common/libbpf/_include/uapi/linux -I../lib/common/libbpf/_include/asm -I../filter/_cprog/ -I../conntrack/_cprog/
-var errNoOutgoingLinks = errors.New("could not determine any outgoing links")
+var (
+	errNoOutgoingLinks = errors.New("could not determine any outgoing links")
+	errFilterDetached  = errors.New("bpf filter is no longer attached")
+)
 
 // New creates a packetparser plugin.
 func New(cfg *kcfg.Config) api.Plugin {
@@ -298,28 +301,94 @@ func (p *packetParser) SetupChannel(ch chan *v1.Event) error {
 	return nil
 }
 
-// cleanAll is NOT thread safe.
-// Not required for now.
-func (p *packetParser) cleanAll() error {
-	// Delete tunnel and qdiscs.
+// Healthy checks the bpf filters attached to every interface are still there, since anything with
+// access to the node's tc can remove them and the plugin would then silently stop seeing packets.
+// Interfaces deleted since they were attached are skipped, the endpoint watcher cleans them up.
+func (p *packetParser) Healthy() error {
 	if p.tcMap == nil {
 		return nil
 	}
 
-	p.tcMap.Range(func(key, value interface{}) bool {
+	var err error
+	p.tcMap.Range(func(k, _ interface{}) bool {
+		ifaceKey := k.(key)
+		// Hold the interface's lock so its rtnetlink socket isn't closed while in use,
+		// and skip it if it was cleaned up in the meantime.
+		mu := p.lockInterface(ifaceKey)
+		defer mu.Unlock()
+		value, ok := p.tcMap.Load(ifaceKey)
+		if !ok {
+			return true
+		}
+
 		v := value.(*val)
-		p.clean(v.tcnl, v.tcIngressObj, v.tcEgressObj)
+		ifindex := v.tcIngressObj.Msg.Ifindex
+		for _, parent := range []uint32{ingressFilterParent, egressFilterParent} {
+			attached, getErr := hasBPFFilter(v.tcnl, ifindex, parent)
+			if errors.Is(getErr, unix.ENODEV) {
+				return true
+			}
+			if getErr != nil {
+				err = errors.Wrapf(getErr, "could not get filters of interface %s", ifaceKey.name)
+				return false
+			}
+			if !attached {
+				err = errors.Wrapf(errFilterDetached, "interface %s, parent %x", ifaceKey.name, parent)
+				return false
+			}
+		}
 		return true
 	})
+	return err
+}
 
-	// Reset map.
-	// It is OK to do this without a lock because
-	// cleanAll is only invoked from Stop(), and Stop can
-	// only be called from PluginManager (which is single threaded).
-	p.tcMap = &sync.Map{}
+func hasBPFFilter(tcnl ITc, ifindex, parent uint32) (bool, error) {
+	filters, err := getFilter(tcnl).Get(&tc.Msg{
+		Family:  unix.AF_UNSPEC,
+		Ifindex: ifindex,
+		Parent:  parent,
+	})
+	if err != nil {
+		return false, err //nolint:wrapcheck // wrapped by the caller
+	}
+	for i := range filters {
+		if filters[i].Attribute.Kind == "bpf" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// cleanAll cleans up every interface under its lock, like the endpoint watcher does
+// when an endpoint is deleted, so it's safe against Healthy and the watcher.
+func (p *packetParser) cleanAll() error {
+	// Delete tunnel and qdiscs.
+	if p.tcMap == nil {
+		return nil
+	}
+
+	p.tcMap.Range(func(k, _ interface{}) bool {
+		ifaceKey := k.(key)
+		mu := p.lockInterface(ifaceKey)
+		defer mu.Unlock()
+		if value, ok := p.tcMap.Load(ifaceKey); ok {
+			v := value.(*val)
+			p.clean(v.tcnl, v.tcIngressObj, v.tcEgressObj)
+			p.tcMap.Delete(ifaceKey)
+		}
+		return true
+	})
 	return nil
 }
 
+// lockInterface locks and returns the lock of an interface, held while attaching to or cleaning it up.
+func (p *packetParser) lockInterface(ifaceKey key) *sync.Mutex {
+	lockMapVal, _ := p.interfaceLockMap.LoadOrStore(ifaceKey, &sync.Mutex{})
+	mu := lockMapVal.(*sync.Mutex)
+	lockMutex(mu)
+	return mu
+}
+
 func (p *packetParser) clean(tcnl ITc, tcIngressObj *tc.Object, tcEgressObj *tc.Object) {
 	// Warning, not error. Clean is best effort.
 	if tcnl != nil {
@@ -345,9 +414,7 @@ func (p *packetParser) endpointWatcherCallbackFn(obj interface{}) {
 	iface := event.Obj.(netlink.LinkAttrs)
 
 	ifaceKey := ifaceToKey(iface)
-	lockMapVal, _ := p.interfaceLockMap.LoadOrStore(ifaceKey, &sync.Mutex{})
-	mu := lockMapVal.(*sync.Mutex)
-	mu.Lock()
+	mu := p.lockInterface(ifaceKey)
 	defer mu.Unlock()
 
 	switch event.Type {
@@ -438,7 +505,7 @@ func (p *packetParser) createQdiscAndAttach(iface netlink.LinkAttrs, ifaceType s
 			Family:  unix.AF_UNSPEC,
 			Ifindex: uint32(iface.Index),
 			Handle:  0,
-			Parent:  0xFFFFFFF2,
+			Parent:  ingressFilterParent,
 			Info:    0x10300,
 		},
 		Attribute: tc.Attribute{
@@ -482,7 +549,7 @@ func (p *packetParser) createQdiscAndAttach(iface netlink.LinkAttrs, ifaceType s
 			Ifindex: uint32(iface.Index),
 			Handle:  1,
 			Info:    TC_H_MAKE(1<<16, uint32(utils.HostToNetShort(0x0003))),
-			Parent:  TC_H_MAKE(0xFFFFFFF1, 0xFFF3),
+			Parent:  egressFilterParent,
 		},
 		Attribute: tc.Attribute{
 			Kind: "bpf",
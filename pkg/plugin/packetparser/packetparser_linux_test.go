@@ -30,6 +30,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/vishvananda/netlink"
 	"go.uber.org/mock/gomock"
+	"golang.org/x/sys/unix"
 )
 
 var (
@@ -55,8 +56,9 @@ func TestCleanAll(t *testing.T) {
 	log.SetupZapLogger(opts)
 
 	p := &packetParser{
-		cfg: cfgPodLevelEnabled,
-		l:   log.Logger().Named("test"),
+		cfg:              cfgPodLevelEnabled,
+		l:                log.Logger().Named("test"),
+		interfaceLockMap: &sync.Map{},
 	}
 	assert.Nil(t, p.cleanAll())
 
@@ -162,6 +164,72 @@ func TestCleanWithErrors(t *testing.T) {
 	p.clean(mtcnl, &tc.Object{}, &tc.Object{})
 }
 
+func TestHealthy(t *testing.T) {
+	log.SetupZapLogger(log.GetDefaultLogOpts())
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	p := &packetParser{
+		cfg:              cfgPodLevelEnabled,
+		l:                log.Logger().Named("test"),
+		interfaceLockMap: &sync.Map{},
+	}
+	require.NoError(t, p.Healthy())
+
+	mf := mocks.NewMockIFilter(ctrl)
+	getFilter = func(tcnl ITc) IFilter {
+		return mf
+	}
+
+	eth0 := key{
+		name:         "eth0",
+		hardwareAddr: "test",
+		netNs:        0,
+	}
+	p.tcMap = &sync.Map{}
+	p.tcMap.Store(eth0, &val{
+		tcIngressObj: &tc.Object{Msg: tc.Msg{Ifindex: 2}},
+		tcEgressObj:  &tc.Object{Msg: tc.Msg{Ifindex: 2}},
+	})
+
+	// Both filters attached.
+	bpfFilter := []tc.Object{{Attribute: tc.Attribute{Kind: "bpf"}}}
+	mf.EXPECT().Get(gomock.Any()).Return(bpfFilter, nil).Times(2)
+	require.NoError(t, p.Healthy())
+
+	// Egress filter removed.
+	mf.EXPECT().Get(gomock.Any()).Return(bpfFilter, nil).Times(1)
+	mf.EXPECT().Get(gomock.Any()).Return([]tc.Object{}, nil).Times(1)
+	require.ErrorIs(t, p.Healthy(), errFilterDetached)
+
+	// Interface deleted, left to the endpoint watcher.
+	mf.EXPECT().Get(gomock.Any()).Return(nil, unix.ENODEV).Times(1)
+	require.NoError(t, p.Healthy())
+
+	// Interface cleaned up while Healthy waits for its lock, its closed socket isn't used:
+	// no filters are expected to be read.
+	mu := p.lockInterface(eth0)
+	waiting := make(chan struct{})
+	defer func(lock func(*sync.Mutex)) { lockMutex = lock }(lockMutex)
+	lockMutex = func(mu *sync.Mutex) {
+		close(waiting)
+		mu.Lock()
+	}
+	var healthyErr error
+	done := make(chan struct{})
+	go func() {
+		// an unexpected Get ends the goroutine early
+		defer close(done)
+		healthyErr = p.Healthy()
+	}()
+	<-waiting
+	p.tcMap.Delete(eth0)
+	mu.Unlock()
+	<-done
+	require.NoError(t, healthyErr)
+}
+
 func TestEndpointWatcherCallbackFn_EndpointDeleted(t *testing.T) {
 	log.SetupZapLogger(log.GetDefaultLogOpts())
 	ctrl := gomock.NewController(t)
@@ -57,6 +57,9 @@ var (
 	getFD = func(e *ebpf.Program) int {
 		return e.FD()
 	}
+	lockMutex = func(mu *sync.Mutex) {
+		mu.Lock()
+	}
 	// Determined via testing on a large cluster.
 	// Actual buffer size will be 32 * pagesize.
 	perCPUBuffer = 32
@@ -78,6 +81,7 @@ type IQdisc interface {
 
 type IFilter interface {
 	Add(info *tc.Object) error
+	Get(info *tc.Msg) ([]tc.Object, error)
 }
 
 type ITc interface {
@@ -130,6 +134,13 @@ const (
 	handleMinMask uint32 = 0x0000FFFF
 )
 
+// Parents of the bpf filters attached to the clsact qdisc of an interface.
+const (
+	ingressFilterParent uint32 = 0xFFFFFFF2
+	// TC_H_MAKE(0xFFFFFFF1, 0xFFF3)
+	egressFilterParent uint32 = (0xFFFFFFF1 & handleMajMask) | (0xFFF3 & handleMinMask)
+)
+
 func TC_H_MAKE(maj, min uint32) uint32 {
 	return (((maj) & handleMajMask) | (min & handleMinMask))
 }
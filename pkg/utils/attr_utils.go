@@ -49,6 +49,7 @@ var (
 	AclRule        = "aclrule"
 	Active         = "ACTIVE"
 	Device         = "device"
+	Plugin         = "plugin"
 
 	// TCP Connection Statistic Names
 	ResetCount           = "ResetCount"
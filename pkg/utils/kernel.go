@@ -0,0 +1,56 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Oldest kernel Retina's eBPF plugins are supported on.
+const (
+	MinimumKernelMajor = 5
+	MinimumKernelMinor = 4
+)
+
+var (
+	ErrUnsupportedKernel    = errors.New("unsupported kernel")
+	ErrInvalidKernelRelease = errors.New("invalid kernel release")
+)
+
+// CheckKernelRelease returns an error wrapping ErrUnsupportedKernel when release, as reported by uname -r,
+// is older than the minimum Retina supports, or ErrInvalidKernelRelease when its version can't be read.
+func CheckKernelRelease(release string) error {
+	major, minor, err := parseKernelRelease(release)
+	if err != nil {
+		return err
+	}
+	if major < MinimumKernelMajor || (major == MinimumKernelMajor && minor < MinimumKernelMinor) {
+		return fmt.Errorf("kernel %s is older than %d.%d: %w", release, MinimumKernelMajor, MinimumKernelMinor, ErrUnsupportedKernel)
+	}
+	return nil
+}
+
+func parseKernelRelease(release string) (major, minor int, err error) {
+	parts := strings.SplitN(release, ".", 3)
+	if len(parts) < 2 {
+		return 0, 0, fmt.Errorf("%q: %w", release, ErrInvalidKernelRelease)
+	}
+	major, err = strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("%q: %w", release, ErrInvalidKernelRelease)
+	}
+	// the minor version carries the suffix when there is no patch version, e.g. "6.8-rc1"
+	digits := strings.IndexFunc(parts[1], func(r rune) bool { return r < '0' || r > '9' })
+	if digits == -1 {
+		digits = len(parts[1])
+	}
+	minor, err = strconv.Atoi(parts[1][:digits])
+	if err != nil {
+		return 0, 0, fmt.Errorf("%q: %w", release, ErrInvalidKernelRelease)
+	}
+	return major, minor, nil
+}
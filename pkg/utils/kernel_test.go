@@ -0,0 +1,38 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckKernelRelease(t *testing.T) {
+	tests := []struct {
+		release string
+		wantErr error
+	}{
+		{release: "5.4.0-1109-azure"},
+		{release: "5.15.0-1057-azure"},
+		{release: "6.8-rc1"},
+		{release: "10.0.0"},
+		{release: "5.3.18-150300.59-default", wantErr: ErrUnsupportedKernel},
+		{release: "4.19.128-microsoft-standard", wantErr: ErrUnsupportedKernel},
+		{release: "5", wantErr: ErrInvalidKernelRelease},
+		{release: "v5.4", wantErr: ErrInvalidKernelRelease},
+		{release: "5.x", wantErr: ErrInvalidKernelRelease},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.release, func(t *testing.T) {
+			err := CheckKernelRelease(tt.release)
+			if tt.wantErr == nil {
+				require.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
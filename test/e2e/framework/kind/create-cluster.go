@@ -0,0 +1,61 @@
+package kind
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"time"
+)
+
+const (
+	kindBinary    = "kind"
+	createTimeout = 10 * time.Minute
+	deleteTimeout = 5 * time.Minute
+)
+
+var ErrKindNotFound = fmt.Errorf("kind binary not found in PATH")
+
+// CreateKindCluster provisions a local kind cluster and writes its kubeconfig to KubeConfigFilePath
+type CreateKindCluster struct {
+	ClusterName        string
+	KubeConfigFilePath string
+	KindConfigFilePath string
+}
+
+func (c *CreateKindCluster) Run() error {
+	ctx, cancel := context.WithTimeout(context.Background(), createTimeout)
+	defer cancel()
+
+	log.Printf("creating kind cluster %s...", c.ClusterName)
+	//nolint:gosec // arguments are test parameters, not user input
+	cmd := exec.CommandContext(ctx, kindBinary, "create", "cluster",
+		"--name", c.ClusterName,
+		"--kubeconfig", c.KubeConfigFilePath,
+		"--config", c.KindConfigFilePath,
+		"--wait", createTimeout.String())
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to create kind cluster %s: %s: %w", c.ClusterName, string(output), err)
+	}
+
+	log.Printf("created kind cluster %s, kubeconfig written to %s", c.ClusterName, c.KubeConfigFilePath)
+	return nil
+}
+
+func (c *CreateKindCluster) Prevalidate() error {
+	if _, err := exec.LookPath(kindBinary); err != nil {
+		return fmt.Errorf("%w: %w", ErrKindNotFound, err)
+	}
+
+	if _, err := os.Stat(c.KindConfigFilePath); err != nil {
+		return fmt.Errorf("kind config not found at %s: %w", c.KindConfigFilePath, err)
+	}
+
+	return nil
+}
+
+func (c *CreateKindCluster) Stop() error {
+	return nil
+}
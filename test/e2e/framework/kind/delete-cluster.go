@@ -0,0 +1,34 @@
+package kind
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os/exec"
+)
+
+type DeleteKindCluster struct {
+	ClusterName string
+}
+
+func (d *DeleteKindCluster) Run() error {
+	ctx, cancel := context.WithTimeout(context.Background(), deleteTimeout)
+	defer cancel()
+
+	log.Printf("deleting kind cluster %s...", d.ClusterName)
+	//nolint:gosec // arguments are test parameters, not user input
+	cmd := exec.CommandContext(ctx, kindBinary, "delete", "cluster", "--name", d.ClusterName)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to delete kind cluster %s: %s: %w", d.ClusterName, string(output), err)
+	}
+	return nil
+}
+
+func (d *DeleteKindCluster) Prevalidate() error {
+	return nil
+}
+
+func (d *DeleteKindCluster) Stop() error {
+	return nil
+}
@@ -0,0 +1,150 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+var (
+	ErrInvalidManifest   = fmt.Errorf("manifest needs exactly one of a file path or inline yaml")
+	ErrNoAppliedManifest = fmt.Errorf("no ApplyManifest to delete the objects of")
+)
+
+// Manifest is a multi-document YAML manifest of any kinds the cluster serves, read from FilePath or given inline
+// as YAML. Namespace, when set, overrides the namespace of its namespaced objects
+type Manifest struct {
+	FilePath  string
+	YAML      string
+	Namespace string
+}
+
+func (m *Manifest) source() string {
+	if m.FilePath != "" {
+		return fmt.Sprintf("yaml file \"%s\"", m.FilePath)
+	}
+	return "inline yaml"
+}
+
+func (m *Manifest) objects() ([]*unstructured.Unstructured, error) {
+	var input io.Reader = strings.NewReader(m.YAML)
+	if m.FilePath != "" {
+		file, err := os.Open(m.FilePath)
+		if err != nil {
+			return nil, fmt.Errorf("error opening yaml file \"%s\": %w", m.FilePath, err)
+		}
+		defer file.Close()
+		input = file
+	}
+
+	objects, err := decodeYAMLObjects(input)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding %s: %w", m.source(), err)
+	}
+	return objects, nil
+}
+
+func (m *Manifest) validate() error {
+	if (m.FilePath == "") == (m.YAML == "") {
+		return ErrInvalidManifest
+	}
+	if m.FilePath != "" {
+		_, err := os.Stat(m.FilePath)
+		if err != nil {
+			return fmt.Errorf("error reading yaml file \"%s\": %w", m.FilePath, err)
+		}
+	}
+	return nil
+}
+
+type appliedObject struct {
+	client dynamic.ResourceInterface
+	kind   string
+	name   string
+}
+
+// ApplyManifest creates, or updates if they already exist, every object of Manifest through the dynamic client,
+// e.g. ConfigMaps, Services or NetworkPolicies a scenario needs and has no purpose-built step for. It keeps track
+// of the objects it created, for a DeleteManifest to remove
+type ApplyManifest struct {
+	KubeConfigFilePath string
+	Manifest           Manifest
+
+	created []appliedObject
+}
+
+func (a *ApplyManifest) Run() error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeoutSeconds*time.Second)
+	defer cancel()
+
+	objects, err := a.Manifest.objects()
+	if err != nil {
+		return err
+	}
+
+	return forEachObject(ctx, a.KubeConfigFilePath, objects, a.Manifest.Namespace, func(client dynamic.ResourceInterface, obj *unstructured.Unstructured) error {
+		created, err := createOrUpdate(ctx, client, obj)
+		if err != nil {
+			return fmt.Errorf("error applying %s: %w", a.Manifest.source(), err)
+		}
+		if created {
+			a.created = append(a.created, appliedObject{client: client, kind: obj.GetKind(), name: obj.GetName()})
+		}
+		return nil
+	})
+}
+
+func (a *ApplyManifest) Prevalidate() error {
+	return a.Manifest.validate()
+}
+
+func (a *ApplyManifest) Stop() error {
+	return nil
+}
+
+// DeleteManifest deletes the objects Applied created, in the reverse order, leaving those it only updated.
+// Objects already gone are skipped, so it can run as cleanup whether or not Applied ran to completion
+type DeleteManifest struct {
+	KubeConfigFilePath string
+	Applied            *ApplyManifest
+}
+
+func (d *DeleteManifest) Run() error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeoutSeconds*time.Second)
+	defer cancel()
+
+	for i := len(d.Applied.created) - 1; i >= 0; i-- {
+		obj := d.Applied.created[i]
+		log.Printf("Deleting %s \"%s\"...\n", obj.kind, obj.name)
+		err := obj.client.Delete(ctx, obj.name, metaV1.DeleteOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				log.Printf("%s \"%s\" does not exist\n", obj.kind, obj.name)
+				continue
+			}
+			return fmt.Errorf("failed to delete %s \"%s\": %w", obj.kind, obj.name, err)
+		}
+	}
+	d.Applied.created = nil
+	return nil
+}
+
+func (d *DeleteManifest) Prevalidate() error {
+	if d.Applied == nil {
+		return ErrNoAppliedManifest
+	}
+	return nil
+}
+
+func (d *DeleteManifest) Stop() error {
+	return nil
+}
@@ -0,0 +1,48 @@
+package kubernetes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testManifest = `---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: first
+  namespace: retina-test
+---
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: second
+spec:
+  podSelector: {}
+`
+
+func TestManifestObjects(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "manifest.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testManifest), 0o600))
+
+	for _, manifest := range []Manifest{{YAML: testManifest}, {FilePath: path}} {
+		require.NoError(t, manifest.validate())
+
+		objects, err := manifest.objects()
+		require.NoError(t, err)
+		require.Len(t, objects, 2, "the empty document is skipped")
+		require.Equal(t, "ConfigMap", objects[0].GetKind())
+		require.Equal(t, "retina-test", objects[0].GetNamespace())
+		require.Equal(t, "NetworkPolicy", objects[1].GetKind())
+		require.Equal(t, "second", objects[1].GetName())
+	}
+}
+
+func TestManifestValidate(t *testing.T) {
+	require.ErrorIs(t, (&Manifest{}).validate(), ErrInvalidManifest)
+	require.ErrorIs(t, (&Manifest{FilePath: "manifest.yaml", YAML: testManifest}).validate(), ErrInvalidManifest)
+	require.ErrorIs(t, (&Manifest{FilePath: filepath.Join(t.TempDir(), "missing.yaml")}).validate(), os.ErrNotExist)
+}
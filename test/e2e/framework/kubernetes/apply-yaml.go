@@ -0,0 +1,191 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+)
+
+// ApplyYAML creates, or updates if they already exist, every object in a multi-document YAML file.
+// Any kind the cluster serves can be applied, including custom resources such as MetricsConfiguration.
+type ApplyYAML struct {
+	KubeConfigFilePath string
+	YAMLFilePath       string
+}
+
+func (a *ApplyYAML) Run() error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeoutSeconds*time.Second)
+	defer cancel()
+
+	return forEachYAMLObject(ctx, a.KubeConfigFilePath, a.YAMLFilePath, func(client dynamic.ResourceInterface, obj *unstructured.Unstructured) error {
+		_, err := createOrUpdate(ctx, client, obj)
+		return err
+	})
+}
+
+// createOrUpdate creates obj, or updates it if it already exists, and reports whether it was created
+func createOrUpdate(ctx context.Context, client dynamic.ResourceInterface, obj *unstructured.Unstructured) (bool, error) {
+	log.Printf("Creating/Updating %s \"%s\"...\n", obj.GetKind(), obj.GetName())
+	existing, err := client.Get(ctx, obj.GetName(), metaV1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = client.Create(ctx, obj, metaV1.CreateOptions{})
+		if err != nil {
+			return false, fmt.Errorf("failed to create %s \"%s\": %w", obj.GetKind(), obj.GetName(), err)
+		}
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get %s \"%s\": %w", obj.GetKind(), obj.GetName(), err)
+	}
+
+	obj.SetResourceVersion(existing.GetResourceVersion())
+	_, err = client.Update(ctx, obj, metaV1.UpdateOptions{})
+	if err != nil {
+		return false, fmt.Errorf("failed to create/update %s \"%s\": %w", obj.GetKind(), obj.GetName(), err)
+	}
+	return false, nil
+}
+
+func (a *ApplyYAML) Prevalidate() error {
+	_, err := os.Stat(a.YAMLFilePath)
+	if err != nil {
+		return fmt.Errorf("error reading yaml file \"%s\": %w", a.YAMLFilePath, err)
+	}
+	return nil
+}
+
+func (a *ApplyYAML) Stop() error {
+	return nil
+}
+
+// DeleteYAML deletes every object in a multi-document YAML file, objects that don't exist are skipped
+type DeleteYAML struct {
+	KubeConfigFilePath string
+	YAMLFilePath       string
+}
+
+func (d *DeleteYAML) Run() error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeoutSeconds*time.Second)
+	defer cancel()
+
+	return forEachYAMLObject(ctx, d.KubeConfigFilePath, d.YAMLFilePath, func(client dynamic.ResourceInterface, obj *unstructured.Unstructured) error {
+		log.Printf("Deleting %s \"%s\"...\n", obj.GetKind(), obj.GetName())
+		err := client.Delete(ctx, obj.GetName(), metaV1.DeleteOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				log.Printf("%s \"%s\" does not exist\n", obj.GetKind(), obj.GetName())
+				return nil
+			}
+			return fmt.Errorf("failed to delete %s \"%s\": %w", obj.GetKind(), obj.GetName(), err)
+		}
+		return nil
+	})
+}
+
+func (d *DeleteYAML) Prevalidate() error {
+	_, err := os.Stat(d.YAMLFilePath)
+	if err != nil {
+		return fmt.Errorf("error reading yaml file \"%s\": %w", d.YAMLFilePath, err)
+	}
+	return nil
+}
+
+func (d *DeleteYAML) Stop() error {
+	return nil
+}
+
+// forEachYAMLObject decodes each object in the file and calls fn with a client for its resource
+func forEachYAMLObject(ctx context.Context, kubeConfigFilePath, yamlFilePath string, fn func(dynamic.ResourceInterface, *unstructured.Unstructured) error) error {
+	file, err := os.Open(yamlFilePath)
+	if err != nil {
+		return fmt.Errorf("error opening yaml file \"%s\": %w", yamlFilePath, err)
+	}
+	defer file.Close()
+
+	objects, err := decodeYAMLObjects(file)
+	if err != nil {
+		return fmt.Errorf("error decoding yaml file \"%s\": %w", yamlFilePath, err)
+	}
+
+	return forEachObject(ctx, kubeConfigFilePath, objects, "", fn)
+}
+
+// decodeYAMLObjects decodes every object of a multi-document YAML or JSON stream, skipping empty documents
+func decodeYAMLObjects(input io.Reader) ([]*unstructured.Unstructured, error) {
+	objects := []*unstructured.Unstructured{}
+	decoder := yaml.NewYAMLOrJSONDecoder(input, 4096) //nolint:gomnd // buffer size for peeking at the document
+	for {
+		obj := &unstructured.Unstructured{}
+		err := decoder.Decode(&obj.Object)
+		if errors.Is(err, io.EOF) {
+			return objects, nil
+		}
+		if err != nil {
+			return nil, err //nolint:wrapcheck // callers name the input
+		}
+		if len(obj.Object) == 0 {
+			// empty document between separators
+			continue
+		}
+		objects = append(objects, obj)
+	}
+}
+
+// forEachObject calls fn with a client for the resource of each object in turn. Namespaced objects go to
+// namespace when it is set, else to their own, or the default namespace if they have none
+func forEachObject(ctx context.Context, kubeConfigFilePath string, objects []*unstructured.Unstructured, namespace string, fn func(dynamic.ResourceInterface, *unstructured.Unstructured) error) error {
+	config, err := BuildConfig(kubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating dynamic client: %w", err)
+	}
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating discovery client: %w", err)
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
+
+	for _, obj := range objects {
+		gvk := obj.GroupVersionKind()
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return fmt.Errorf("error mapping %s to a resource: %w", gvk.String(), err)
+		}
+
+		var client dynamic.ResourceInterface = dynamicClient.Resource(mapping.Resource)
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			if namespace != "" {
+				obj.SetNamespace(namespace)
+			}
+			if obj.GetNamespace() == "" {
+				obj.SetNamespace(metaV1.NamespaceDefault)
+			}
+			client = dynamicClient.Resource(mapping.Resource).Namespace(obj.GetNamespace())
+		}
+
+		err = fn(client, obj)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
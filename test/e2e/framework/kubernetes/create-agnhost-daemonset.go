@@ -0,0 +1,104 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+// CreateAgnhostDaemonSet runs an agnhost pod on every schedulable Linux node, for steps that generate traffic
+// from each node at once, and waits for all of them to be ready. The pods are those of CreateAgnhostStatefulSet
+type CreateAgnhostDaemonSet struct {
+	AgnhostName        string
+	AgnhostNamespace   string
+	KubeConfigFilePath string
+}
+
+func (c *CreateAgnhostDaemonSet) Run() error {
+	config, err := BuildConfig(c.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeoutSeconds*time.Second)
+	defer cancel()
+
+	agnhostDaemonSet := c.getAgnhostDaemonSet()
+	err = CreateResource(ctx, agnhostDaemonSet, clientset)
+	if err != nil {
+		return fmt.Errorf("error creating agnhost daemonset: %w", err)
+	}
+
+	err = wait.PollUntilContextCancel(ctx, RetryIntervalPodsReady, true, func(ctx context.Context) (bool, error) {
+		daemonSet, getErr := clientset.AppsV1().DaemonSets(c.AgnhostNamespace).Get(ctx, c.AgnhostName, metaV1.GetOptions{})
+		if getErr != nil {
+			return false, fmt.Errorf("error getting agnhost daemonset: %w", getErr)
+		}
+
+		status := daemonSet.Status
+		if status.ObservedGeneration < daemonSet.Generation || status.DesiredNumberScheduled == 0 ||
+			status.UpdatedNumberScheduled != status.DesiredNumberScheduled || status.NumberReady != status.DesiredNumberScheduled {
+			log.Printf("agnhost daemonset \"%s\" has %d of %d pods ready. Waiting...\n", c.AgnhostName, status.NumberReady, status.DesiredNumberScheduled)
+			return false, nil
+		}
+
+		log.Printf("agnhost daemonset \"%s\" has all %d pods ready\n", c.AgnhostName, status.NumberReady)
+		return true, nil
+	})
+	if err != nil {
+		labelSelector := fmt.Sprintf("app=%s", c.AgnhostName)
+		PrintPodLogs(ctx, clientset, c.AgnhostNamespace, labelSelector)
+		return fmt.Errorf("error waiting for agnhost daemonset pods to be ready: %w", err)
+	}
+
+	return nil
+}
+
+func (c *CreateAgnhostDaemonSet) Prevalidate() error {
+	// the name is the prefix of the pods' names and the value of their app label
+	if err := checkPodName(c.AgnhostNamespace, c.AgnhostName); err != nil {
+		return err
+	}
+	return checkLabelSelector("AgnhostName", "app="+c.AgnhostName)
+}
+
+func (c *CreateAgnhostDaemonSet) Stop() error {
+	return nil
+}
+
+func (c *CreateAgnhostDaemonSet) getAgnhostDaemonSet() *appsv1.DaemonSet {
+	statefulSet := (&CreateAgnhostStatefulSet{
+		AgnhostName:      c.AgnhostName,
+		AgnhostNamespace: c.AgnhostNamespace,
+	}).getAgnhostDeployment()
+
+	// one pod per node already, there's nothing left to spread
+	template := statefulSet.Spec.Template
+	template.Spec.Affinity = nil
+
+	return &appsv1.DaemonSet{
+		TypeMeta: metaV1.TypeMeta{
+			Kind:       "DaemonSet",
+			APIVersion: "apps/v1",
+		},
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      c.AgnhostName,
+			Namespace: c.AgnhostNamespace,
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: statefulSet.Spec.Selector,
+			Template: template,
+		},
+	}
+}
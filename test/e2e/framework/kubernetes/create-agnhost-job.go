@@ -0,0 +1,144 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+)
+
+// CreateAgnhostJob runs Command to completion in an agnhost Job, and waits for its pod to be running.
+// The pod is labelled app=JobName, as its name is generated
+type CreateAgnhostJob struct {
+	KubeConfigFilePath string
+	JobName            string
+	JobNamespace       string
+	Command            []string
+}
+
+func (c *CreateAgnhostJob) Run() error {
+	backoffLimit := int32(0)
+	job := &batchv1.Job{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      c.JobName,
+			Namespace: c.JobNamespace,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template:     agnhostJobPodTemplate(c.JobName, c.Command),
+		},
+	}
+	return createAndWaitForJobPod(c.KubeConfigFilePath, job, c.JobNamespace, c.JobName)
+}
+
+func (c *CreateAgnhostJob) Prevalidate() error {
+	return nil
+}
+
+func (c *CreateAgnhostJob) Stop() error {
+	return nil
+}
+
+// CreateAgnhostCronJob runs Command in an agnhost Job created by a CronJob on Schedule, one Job at a time,
+// and waits for the pod of the first one to be running. The pods are labelled app=CronJobName
+type CreateAgnhostCronJob struct {
+	KubeConfigFilePath string
+	CronJobName        string
+	CronJobNamespace   string
+	Schedule           string
+	Command            []string
+}
+
+func (c *CreateAgnhostCronJob) Run() error {
+	backoffLimit := int32(0)
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      c.CronJobName,
+			Namespace: c.CronJobNamespace,
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule:          c.Schedule,
+			ConcurrencyPolicy: batchv1.ForbidConcurrent,
+			JobTemplate: batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					BackoffLimit: &backoffLimit,
+					Template:     agnhostJobPodTemplate(c.CronJobName, c.Command),
+				},
+			},
+		},
+	}
+	return createAndWaitForJobPod(c.KubeConfigFilePath, cronJob, c.CronJobNamespace, c.CronJobName)
+}
+
+func (c *CreateAgnhostCronJob) Prevalidate() error {
+	return nil
+}
+
+func (c *CreateAgnhostCronJob) Stop() error {
+	return nil
+}
+
+// createAndWaitForJobPod creates the Job or CronJob obj, named app, and waits for a pod labelled app=app to be running
+func createAndWaitForJobPod(kubeConfigFilePath string, obj runtime.Object, namespace, app string) error {
+	config, err := BuildConfig(kubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	// a CronJob only starts its first Job on the next minute of its schedule
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeoutSeconds*time.Second+time.Minute)
+	defer cancel()
+
+	err = CreateResource(ctx, obj, clientset)
+	if err != nil {
+		return fmt.Errorf("error creating %s: %w", app, err)
+	}
+
+	err = WaitForPodsRunning(ctx, clientset, namespace, "app="+app)
+	if err != nil {
+		return fmt.Errorf("error waiting for pod of %s to be running: %w", app, err)
+	}
+	return nil
+}
+
+func agnhostJobPodTemplate(app string, command []string) v1.PodTemplateSpec {
+	return v1.PodTemplateSpec{
+		ObjectMeta: metaV1.ObjectMeta{
+			Labels: map[string]string{
+				"app": app,
+			},
+		},
+		Spec: v1.PodSpec{
+			RestartPolicy: v1.RestartPolicyNever,
+			NodeSelector: map[string]string{
+				"kubernetes.io/os": "linux",
+			},
+			Containers: []v1.Container{
+				{
+					Name:    app,
+					Image:   "acnpublic.azurecr.io/agnhost:2.40",
+					Command: command,
+					Resources: v1.ResourceRequirements{
+						Requests: v1.ResourceList{
+							"memory": resource.MustParse("20Mi"),
+						},
+						Limits: v1.ResourceList{
+							"memory": resource.MustParse("20Mi"),
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,98 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	policyv1 "k8s.io/api/policy/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+var ErrInvalidMaxUnavailable = fmt.Errorf("max unavailable must be positive")
+
+// CreateAgnhostPodDisruptionBudget bounds voluntary disruptions of the pods of an agnhost StatefulSet, such as
+// evictions by a drain, to MaxUnavailable pods at a time. It waits for the budget to be computed, as evictions
+// are refused until it is
+type CreateAgnhostPodDisruptionBudget struct {
+	PodDisruptionBudgetName      string
+	PodDisruptionBudgetNamespace string
+	AgnhostName                  string
+	MaxUnavailable               int
+	KubeConfigFilePath           string
+}
+
+func (c *CreateAgnhostPodDisruptionBudget) Run() error {
+	config, err := BuildConfig(c.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeoutSeconds*time.Second)
+	defer cancel()
+
+	err = CreateResource(ctx, c.getAgnhostPodDisruptionBudget(), clientset)
+	if err != nil {
+		return fmt.Errorf("error creating agnhost pod disruption budget: %w", err)
+	}
+
+	err = wait.PollUntilContextCancel(ctx, RetryIntervalPodsReady, true, func(ctx context.Context) (bool, error) {
+		pdb, getErr := clientset.PolicyV1().PodDisruptionBudgets(c.PodDisruptionBudgetNamespace).Get(ctx, c.PodDisruptionBudgetName, metaV1.GetOptions{})
+		if getErr != nil {
+			return false, fmt.Errorf("error getting pod disruption budget: %w", getErr)
+		}
+		if pdb.Status.ObservedGeneration < pdb.Generation {
+			return false, nil
+		}
+
+		log.Printf("pod disruption budget \"%s\" covers %d pods, %d disruptions allowed\n", c.PodDisruptionBudgetName, pdb.Status.ExpectedPods, pdb.Status.DisruptionsAllowed)
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("error waiting for pod disruption budget \"%s\" to be computed: %w", c.PodDisruptionBudgetName, err)
+	}
+
+	return nil
+}
+
+func (c *CreateAgnhostPodDisruptionBudget) Prevalidate() error {
+	if c.MaxUnavailable <= 0 {
+		return fmt.Errorf("%d: %w", c.MaxUnavailable, ErrInvalidMaxUnavailable)
+	}
+	return nil
+}
+
+func (c *CreateAgnhostPodDisruptionBudget) Stop() error {
+	return nil
+}
+
+func (c *CreateAgnhostPodDisruptionBudget) getAgnhostPodDisruptionBudget() *policyv1.PodDisruptionBudget {
+	maxUnavailable := intstr.FromInt(c.MaxUnavailable)
+	return &policyv1.PodDisruptionBudget{
+		TypeMeta: metaV1.TypeMeta{
+			Kind:       "PodDisruptionBudget",
+			APIVersion: "policy/v1",
+		},
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      c.PodDisruptionBudgetName,
+			Namespace: c.PodDisruptionBudgetNamespace,
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MaxUnavailable: &maxUnavailable,
+			Selector: &metaV1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app": c.AgnhostName,
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,95 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+// CreateAgnhostService exposes the pods of an agnhost StatefulSet on a ClusterIP Service, or a NodePort Service
+// when NodePort is set. Several Services may select the same agnhost, so it has its own name rather than the agnhost's
+type CreateAgnhostService struct {
+	ServiceName        string
+	ServiceNamespace   string
+	AgnhostName        string
+	KubeConfigFilePath string
+
+	// DualStack requires the Service to get both an IPv4 and an IPv6 ClusterIP, which fails on single-stack clusters
+	DualStack bool
+
+	// NodePort also exposes the Service on this port of every node, which has to be in the cluster's node port range
+	NodePort int
+}
+
+func (c *CreateAgnhostService) Run() error {
+	config, err := BuildConfig(c.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeoutSeconds*time.Second)
+	defer cancel()
+
+	err = CreateResource(ctx, c.getAgnhostService(), clientset)
+	if err != nil {
+		return fmt.Errorf("error creating agnhost service: %w", err)
+	}
+
+	return nil
+}
+
+func (c *CreateAgnhostService) Prevalidate() error {
+	return nil
+}
+
+func (c *CreateAgnhostService) Stop() error {
+	return nil
+}
+
+func (c *CreateAgnhostService) getAgnhostService() *v1.Service {
+	svc := &v1.Service{
+		TypeMeta: metaV1.TypeMeta{
+			Kind:       "Service",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      c.ServiceName,
+			Namespace: c.ServiceNamespace,
+		},
+		Spec: v1.ServiceSpec{
+			Selector: map[string]string{
+				"app": c.AgnhostName,
+			},
+			Ports: []v1.ServicePort{
+				{
+					Port:       AgnhostHTTPPort,
+					Protocol:   v1.ProtocolTCP,
+					TargetPort: intstr.FromInt(AgnhostHTTPPort),
+				},
+			},
+		},
+	}
+
+	if c.NodePort > 0 {
+		svc.Spec.Type = v1.ServiceTypeNodePort
+		svc.Spec.Ports[0].NodePort = int32(c.NodePort)
+	}
+
+	if c.DualStack {
+		policy := v1.IPFamilyPolicyRequireDualStack
+		svc.Spec.IPFamilyPolicy = &policy
+		svc.Spec.IPFamilies = []v1.IPFamily{v1.IPv4Protocol, v1.IPv6Protocol}
+	}
+
+	return svc
+}
@@ -3,7 +3,9 @@ package kubernetes
 import (
 	"context"
 	"fmt"
+	"net"
 	"strconv"
+	"strings"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -11,10 +13,12 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
 )
 
-var ErrLabelMissingFromPod = fmt.Errorf("label missing from pod")
+var (
+	ErrLabelMissingFromPod = fmt.Errorf("label missing from pod")
+	ErrInvalidNameserver   = fmt.Errorf("invalid nameserver")
+)
 
 const (
 	AgnhostHTTPPort = 80
@@ -25,10 +29,39 @@ type CreateAgnhostStatefulSet struct {
 	AgnhostName        string
 	AgnhostNamespace   string
 	KubeConfigFilePath string
+
+	// AddCapabilities grants the agnhost container extra capabilities,
+	// e.g. NET_ADMIN for steps that manipulate the pod's routes
+	AddCapabilities []v1.Capability
+
+	// DNSConfig switches the pod to dnsPolicy None with this config. A nameserver
+	// given as "namespace/name" is resolved to that Service's ClusterIP, since
+	// in-cluster DNS servers only get an address once they are created
+	DNSConfig *v1.PodDNSConfig
+
+	// Replicas defaults to AgnhostReplicas when unset
+	Replicas int
+
+	// SingleNode schedules every replica on the node of the first one instead of spreading them across the cluster
+	SingleNode bool
+
+	// Args replaces the default arguments to agnhost, which serve the hostname over HTTP on AgnhostHTTPPort
+	Args []string
+
+	// InitCommand is run in an agnhost init container, which has to exit before the agnhost container starts
+	InitCommand []string
+
+	// SidecarArgs runs a second agnhost container with these arguments in the pod, sharing its network namespace.
+	// The sidecar is named after the agnhost with a "-sidecar" suffix
+	SidecarArgs []string
+
+	// Sysctls are set in the pod's namespaces. Sysctls outside the kubelet's safe set, e.g. net.ipv4.tcp_sack,
+	// have to be allowed on the kubelet or the pod is rejected
+	Sysctls []v1.Sysctl
 }
 
 func (c *CreateAgnhostStatefulSet) Run() error {
-	config, err := clientcmd.BuildConfigFromFlags("", c.KubeConfigFilePath)
+	config, err := BuildConfig(c.KubeConfigFilePath)
 	if err != nil {
 		return fmt.Errorf("error building kubeconfig: %w", err)
 	}
@@ -43,6 +76,65 @@ func (c *CreateAgnhostStatefulSet) Run() error {
 
 	agnhostStatefulest := c.getAgnhostDeployment()
 
+	if len(c.Args) > 0 {
+		agnhostStatefulest.Spec.Template.Spec.Containers[0].Args = c.Args
+	}
+
+	if len(c.InitCommand) > 0 {
+		agnhostStatefulest.Spec.Template.Spec.InitContainers = []v1.Container{
+			{
+				Name:    c.AgnhostName + "-init",
+				Image:   agnhostStatefulest.Spec.Template.Spec.Containers[0].Image,
+				Command: c.InitCommand,
+			},
+		}
+	}
+
+	if len(c.SidecarArgs) > 0 {
+		sidecar := *agnhostStatefulest.Spec.Template.Spec.Containers[0].DeepCopy()
+		sidecar.Name = c.AgnhostName + "-sidecar"
+		sidecar.Args = c.SidecarArgs
+		sidecar.Ports = nil
+		agnhostStatefulest.Spec.Template.Spec.Containers = append(agnhostStatefulest.Spec.Template.Spec.Containers, sidecar)
+	}
+
+	if len(c.Sysctls) > 0 {
+		agnhostStatefulest.Spec.Template.Spec.SecurityContext = &v1.PodSecurityContext{
+			Sysctls: c.Sysctls,
+		}
+	}
+
+	if c.SingleNode {
+		agnhostStatefulest.Spec.Template.Spec.Affinity = &v1.Affinity{
+			PodAffinity: &v1.PodAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: []v1.PodAffinityTerm{
+					{
+						TopologyKey: "kubernetes.io/hostname",
+						LabelSelector: &metaV1.LabelSelector{
+							MatchLabels: map[string]string{
+								"app": c.AgnhostName,
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	// start multiple replicas at once rather than one after another
+	if c.Replicas > 1 {
+		agnhostStatefulest.Spec.PodManagementPolicy = appsv1.ParallelPodManagement
+	}
+
+	if c.DNSConfig != nil {
+		dnsConfig, resolveErr := resolveNameserverServices(ctx, clientset, c.DNSConfig)
+		if resolveErr != nil {
+			return fmt.Errorf("error resolving agnhost dns config: %w", resolveErr)
+		}
+		agnhostStatefulest.Spec.Template.Spec.DNSPolicy = v1.DNSNone
+		agnhostStatefulest.Spec.Template.Spec.DNSConfig = dnsConfig
+	}
+
 	err = CreateResource(ctx, agnhostStatefulest, clientset)
 	if err != nil {
 		return fmt.Errorf("error agnhost component: %w", err)
@@ -54,11 +146,20 @@ func (c *CreateAgnhostStatefulSet) Run() error {
 	}
 
 	labelSelector := fmt.Sprintf("app=%s", selector)
-	err = WaitForPodReady(ctx, clientset, c.AgnhostNamespace, labelSelector)
+	err = WaitForPodsRunning(ctx, clientset, c.AgnhostNamespace, labelSelector)
 	if err != nil {
 		return fmt.Errorf("error waiting for agnhost pod to be ready: %w", err)
 	}
 
+	// the pods may not all exist yet when the first ones are ready, so wait on each by name
+	for i := 1; i < int(*agnhostStatefulest.Spec.Replicas); i++ {
+		podSelector := fmt.Sprintf("statefulset.kubernetes.io/pod-name=%s-%d", c.AgnhostName, i)
+		err = WaitForPodsRunning(ctx, clientset, c.AgnhostNamespace, podSelector)
+		if err != nil {
+			return fmt.Errorf("error waiting for agnhost pod %d to be ready: %w", i, err)
+		}
+	}
+
 	return nil
 }
 
@@ -70,8 +171,33 @@ func (c *CreateAgnhostStatefulSet) Stop() error {
 	return nil
 }
 
+// resolveNameserverServices returns a copy of dnsConfig with "namespace/name" nameservers replaced by the Service ClusterIP
+func resolveNameserverServices(ctx context.Context, clientset *kubernetes.Clientset, dnsConfig *v1.PodDNSConfig) (*v1.PodDNSConfig, error) {
+	resolved := dnsConfig.DeepCopy()
+	for i, nameserver := range resolved.Nameservers {
+		if net.ParseIP(nameserver) != nil {
+			continue
+		}
+
+		namespace, name, found := strings.Cut(nameserver, "/")
+		if !found {
+			return nil, fmt.Errorf("nameserver \"%s\" is neither an IP nor namespace/name: %w", nameserver, ErrInvalidNameserver)
+		}
+
+		svc, err := clientset.CoreV1().Services(namespace).Get(ctx, name, metaV1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("error getting nameserver service \"%s\": %w", nameserver, err)
+		}
+		resolved.Nameservers[i] = svc.Spec.ClusterIP
+	}
+	return resolved, nil
+}
+
 func (c *CreateAgnhostStatefulSet) getAgnhostDeployment() *appsv1.StatefulSet {
 	reps := int32(AgnhostReplicas)
+	if c.Replicas > 0 {
+		reps = int32(c.Replicas)
+	}
 
 	return &appsv1.StatefulSet{
 		TypeMeta: metaV1.TypeMeta{
@@ -148,6 +274,11 @@ func (c *CreateAgnhostStatefulSet) getAgnhostDeployment() *appsv1.StatefulSet {
 								},
 							},
 							Env: []v1.EnvVar{},
+							SecurityContext: &v1.SecurityContext{
+								Capabilities: &v1.Capabilities{
+									Add: c.AddCapabilities,
+								},
+							},
 						},
 					},
 				},
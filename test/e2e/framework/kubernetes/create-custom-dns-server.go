@@ -0,0 +1,294 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	CustomDNSServerPort  = 53
+	customDNSServerImage = "mcr.microsoft.com/oss/kubernetes/coredns:v1.9.4"
+	customDNSHostsFile   = "zone.hosts"
+)
+
+// CreateCustomDNSServer deploys a CoreDNS instance authoritative for Zone, serving Records,
+// reachable in-cluster at <DNSServerName>.<DNSServerNamespace>.svc.cluster.local
+type CreateCustomDNSServer struct {
+	DNSServerName      string
+	DNSServerNamespace string
+	Zone               string
+	KubeConfigFilePath string
+
+	// Records maps a fully qualified name within Zone to the A/AAAA addresses it resolves to
+	Records map[string][]string
+	// FailingNames are fully qualified names within Zone answered with SERVFAIL, for any query type
+	FailingNames []string
+}
+
+func (c *CreateCustomDNSServer) Run() error {
+	config, err := BuildConfig(c.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeoutSeconds*time.Second)
+	defer cancel()
+
+	resources := []runtime.Object{
+		c.getConfigMap(),
+		c.getDeployment(),
+		c.getService(),
+	}
+
+	for i := range resources {
+		err = CreateResource(ctx, resources[i], clientset)
+		if err != nil {
+			return fmt.Errorf("error creating custom dns server component: %w", err)
+		}
+	}
+
+	err = WaitForPodsRunning(ctx, clientset, c.DNSServerNamespace, "app="+c.DNSServerName)
+	if err != nil {
+		return fmt.Errorf("error waiting for custom dns server pod to be ready: %w", err)
+	}
+
+	return nil
+}
+
+func (c *CreateCustomDNSServer) Prevalidate() error {
+	for name := range c.Records {
+		if !strings.HasSuffix(strings.TrimSuffix(name, "."), strings.TrimSuffix(c.Zone, ".")) {
+			return fmt.Errorf("record \"%s\" is not in zone \"%s\": %w", name, c.Zone, ErrRecordOutsideZone)
+		}
+	}
+	for _, name := range c.FailingNames {
+		if !strings.HasSuffix(strings.TrimSuffix(name, "."), strings.TrimSuffix(c.Zone, ".")) {
+			return fmt.Errorf("failing name \"%s\" is not in zone \"%s\": %w", name, c.Zone, ErrRecordOutsideZone)
+		}
+	}
+	return nil
+}
+
+func (c *CreateCustomDNSServer) Stop() error {
+	return nil
+}
+
+var ErrRecordOutsideZone = fmt.Errorf("record outside of zone")
+
+func (c *CreateCustomDNSServer) getConfigMap() *v1.ConfigMap {
+	// sort for a stable hosts file, the order of addresses within a name is preserved
+	names := make([]string, 0, len(c.Records))
+	for name := range c.Records {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var hosts strings.Builder
+	for _, name := range names {
+		for _, address := range c.Records[name] {
+			fmt.Fprintf(&hosts, "%s %s\n", address, name)
+		}
+	}
+
+	// template answers before hosts in CoreDNS' plugin order
+	var failing strings.Builder
+	for _, name := range c.FailingNames {
+		fmt.Fprintf(&failing, "    template IN ANY %s {\n        rcode SERVFAIL\n    }\n", name)
+	}
+
+	corefile := fmt.Sprintf(`%s:%d {
+    errors
+    log
+%s    hosts /etc/coredns/%s
+}
+`, c.Zone, CustomDNSServerPort, failing.String(), customDNSHostsFile)
+
+	return &v1.ConfigMap{
+		TypeMeta: metaV1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      c.DNSServerName,
+			Namespace: c.DNSServerNamespace,
+		},
+		Data: map[string]string{
+			"Corefile":         corefile,
+			customDNSHostsFile: hosts.String(),
+		},
+	}
+}
+
+func (c *CreateCustomDNSServer) getDeployment() *appsv1.Deployment {
+	reps := int32(1)
+
+	return &appsv1.Deployment{
+		TypeMeta: metaV1.TypeMeta{
+			Kind:       "Deployment",
+			APIVersion: "apps/v1",
+		},
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      c.DNSServerName,
+			Namespace: c.DNSServerNamespace,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &reps,
+			Selector: &metaV1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app": c.DNSServerName,
+				},
+			},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metaV1.ObjectMeta{
+					Labels: map[string]string{
+						"app": c.DNSServerName,
+					},
+				},
+				Spec: v1.PodSpec{
+					NodeSelector: map[string]string{
+						"kubernetes.io/os": "linux",
+					},
+					Containers: []v1.Container{
+						{
+							Name:  "coredns",
+							Image: customDNSServerImage,
+							Args:  []string{"-conf", "/etc/coredns/Corefile"},
+							Resources: v1.ResourceRequirements{
+								Requests: v1.ResourceList{
+									"memory": resource.MustParse("50Mi"),
+								},
+								Limits: v1.ResourceList{
+									"memory": resource.MustParse("50Mi"),
+								},
+							},
+							Ports: []v1.ContainerPort{
+								{
+									ContainerPort: CustomDNSServerPort,
+									Protocol:      v1.ProtocolUDP,
+								},
+								{
+									ContainerPort: CustomDNSServerPort,
+									Protocol:      v1.ProtocolTCP,
+								},
+							},
+							VolumeMounts: []v1.VolumeMount{
+								{
+									Name:      "config",
+									MountPath: "/etc/coredns",
+								},
+							},
+						},
+					},
+					Volumes: []v1.Volume{
+						{
+							Name: "config",
+							VolumeSource: v1.VolumeSource{
+								ConfigMap: &v1.ConfigMapVolumeSource{
+									LocalObjectReference: v1.LocalObjectReference{
+										Name: c.DNSServerName,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (c *CreateCustomDNSServer) getService() *v1.Service {
+	return &v1.Service{
+		TypeMeta: metaV1.TypeMeta{
+			Kind:       "Service",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      c.DNSServerName,
+			Namespace: c.DNSServerNamespace,
+		},
+		Spec: v1.ServiceSpec{
+			Selector: map[string]string{
+				"app": c.DNSServerName,
+			},
+			Ports: []v1.ServicePort{
+				{
+					Name:       "dns",
+					Port:       CustomDNSServerPort,
+					Protocol:   v1.ProtocolUDP,
+					TargetPort: intstr.FromInt(CustomDNSServerPort),
+				},
+				{
+					Name:       "dns-tcp",
+					Port:       CustomDNSServerPort,
+					Protocol:   v1.ProtocolTCP,
+					TargetPort: intstr.FromInt(CustomDNSServerPort),
+				},
+			},
+		},
+	}
+}
+
+type DeleteCustomDNSServer struct {
+	DNSServerName      string
+	DNSServerNamespace string
+	KubeConfigFilePath string
+}
+
+func (d *DeleteCustomDNSServer) Run() error {
+	config, err := BuildConfig(d.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeoutSeconds*time.Second)
+	defer cancel()
+
+	meta := metaV1.ObjectMeta{
+		Name:      d.DNSServerName,
+		Namespace: d.DNSServerNamespace,
+	}
+	resources := []runtime.Object{
+		&v1.Service{ObjectMeta: meta},
+		&appsv1.Deployment{ObjectMeta: meta},
+		&v1.ConfigMap{ObjectMeta: meta},
+	}
+
+	for i := range resources {
+		err = DeleteResource(ctx, resources[i], clientset)
+		if err != nil {
+			return fmt.Errorf("error deleting custom dns server component: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (d *DeleteCustomDNSServer) Prevalidate() error {
+	return nil
+}
+
+func (d *DeleteCustomDNSServer) Stop() error {
+	return nil
+}
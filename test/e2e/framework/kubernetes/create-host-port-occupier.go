@@ -0,0 +1,130 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// CreateHostPortOccupier runs a single host network pod, as a Deployment, that listens on Port of a linux node, so a
+// workload scheduled there afterwards can't bind it. The listener speaks raw TCP rather than
+// HTTP so it can't answer HTTP probes on behalf of the workload.
+type CreateHostPortOccupier struct {
+	OccupierName       string
+	OccupierNamespace  string
+	KubeConfigFilePath string
+	Port               int
+}
+
+func (c *CreateHostPortOccupier) Run() error {
+	config, err := BuildConfig(c.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeoutSeconds*time.Second)
+	defer cancel()
+
+	err = CreateResource(ctx, c.getOccupierDeployment(), clientset)
+	if err != nil {
+		return fmt.Errorf("error creating host port occupier pod: %w", err)
+	}
+
+	err = WaitForPodsRunning(ctx, clientset, c.OccupierNamespace, "app="+c.OccupierName)
+	if err != nil {
+		return fmt.Errorf("error waiting for host port occupier pod to be ready: %w", err)
+	}
+
+	return nil
+}
+
+func (c *CreateHostPortOccupier) Prevalidate() error {
+	if c.Port <= 0 || c.Port > 65535 {
+		return fmt.Errorf("port %d: %w", c.Port, ErrInvalidPort)
+	}
+	return nil
+}
+
+func (c *CreateHostPortOccupier) Stop() error {
+	return nil
+}
+
+var ErrInvalidPort = fmt.Errorf("invalid port")
+
+func (c *CreateHostPortOccupier) getOccupierDeployment() *appsv1.Deployment {
+	reps := int32(1)
+
+	return &appsv1.Deployment{
+		TypeMeta: metaV1.TypeMeta{
+			Kind:       "Deployment",
+			APIVersion: "apps/v1",
+		},
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      c.OccupierName,
+			Namespace: c.OccupierNamespace,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &reps,
+			Selector: &metaV1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app": c.OccupierName,
+				},
+			},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metaV1.ObjectMeta{
+					Labels: map[string]string{
+						"app": c.OccupierName,
+					},
+				},
+				Spec: v1.PodSpec{
+					HostNetwork: true,
+					NodeSelector: map[string]string{
+						"kubernetes.io/os": "linux",
+					},
+					Containers: []v1.Container{
+						{
+							Name:  c.OccupierName,
+							Image: "acnpublic.azurecr.io/agnhost:2.40",
+							Resources: v1.ResourceRequirements{
+								Requests: v1.ResourceList{
+									"memory": resource.MustParse("20Mi"),
+								},
+								Limits: v1.ResourceList{
+									"memory": resource.MustParse("20Mi"),
+								},
+							},
+							Command: []string{
+								"/agnhost",
+							},
+							Args: []string{
+								"serve-hostname",
+								"--tcp",
+								"--http=false",
+								"--port",
+								strconv.Itoa(c.Port),
+							},
+							Ports: []v1.ContainerPort{
+								{
+									ContainerPort: int32(c.Port),
+									HostPort:      int32(c.Port),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
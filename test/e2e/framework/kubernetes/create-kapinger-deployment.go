@@ -13,7 +13,6 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
 )
 
 const (
@@ -35,7 +34,7 @@ func (c *CreateKapingerDeployment) Run() error {
 		return fmt.Errorf("error converting replicas to int for Kapinger replicas: %w", err)
 	}
 
-	config, err := clientcmd.BuildConfigFromFlags("", c.KubeConfigFilePath)
+	config, err := BuildConfig(c.KubeConfigFilePath)
 	if err != nil {
 		return fmt.Errorf("error building kubeconfig: %w", err)
 	}
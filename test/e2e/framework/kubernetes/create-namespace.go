@@ -0,0 +1,60 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/microsoft/retina/pkg/common"
+	v1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+type CreateNamespace struct {
+	NamespaceName      string
+	KubeConfigFilePath string
+
+	// Observe annotates the namespace to opt its pods into Retina's advanced pod level metrics, which the agent
+	// honors when it runs with annotations enabled
+	Observe bool
+}
+
+func (c *CreateNamespace) Run() error {
+	config, err := BuildConfig(c.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeoutSeconds*time.Second)
+	defer cancel()
+
+	namespace := &v1.Namespace{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name: c.NamespaceName,
+		},
+	}
+	if c.Observe {
+		namespace.Annotations = map[string]string{common.RetinaPodAnnotation: common.RetinaPodAnnotationValue}
+	}
+
+	err = CreateResource(ctx, namespace, clientset)
+	if err != nil {
+		return fmt.Errorf("error creating namespace: %w", err)
+	}
+
+	return nil
+}
+
+func (c *CreateNamespace) Prevalidate() error {
+	return nil
+}
+
+func (c *CreateNamespace) Stop() error {
+	return nil
+}
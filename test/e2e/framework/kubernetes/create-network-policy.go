@@ -8,7 +8,6 @@ import (
 	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
 )
 
 const (
@@ -23,7 +22,7 @@ type CreateDenyAllNetworkPolicy struct {
 }
 
 func (c *CreateDenyAllNetworkPolicy) Run() error {
-	config, err := clientcmd.BuildConfigFromFlags("", c.KubeConfigFilePath)
+	config, err := BuildConfig(c.KubeConfigFilePath)
 	if err != nil {
 		return fmt.Errorf("error building kubeconfig: %w", err)
 	}
@@ -83,7 +82,7 @@ type DeleteDenyAllNetworkPolicy struct {
 }
 
 func (d *DeleteDenyAllNetworkPolicy) Run() error {
-	config, err := clientcmd.BuildConfigFromFlags("", d.KubeConfigFilePath)
+	config, err := BuildConfig(d.KubeConfigFilePath)
 	if err != nil {
 		return fmt.Errorf("error building kubeconfig: %w", err)
 	}
@@ -108,3 +107,68 @@ func (d *DeleteDenyAllNetworkPolicy) Run() error {
 func (d *DeleteDenyAllNetworkPolicy) Prevalidate() error {
 	return nil
 }
+
+// CreateAllowFromNamespaceNetworkPolicy only allows ingress to the pods of NetworkPolicyNamespace
+// from pods in AllowFromNamespace
+type CreateAllowFromNamespaceNetworkPolicy struct {
+	NetworkPolicyName      string
+	NetworkPolicyNamespace string
+	AllowFromNamespace     string
+	KubeConfigFilePath     string
+}
+
+func (c *CreateAllowFromNamespaceNetworkPolicy) Run() error {
+	config, err := BuildConfig(c.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	policy := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      c.NetworkPolicyName,
+			Namespace: c.NetworkPolicyNamespace,
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{},
+			PolicyTypes: []networkingv1.PolicyType{
+				networkingv1.PolicyTypeIngress,
+			},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{
+				{
+					From: []networkingv1.NetworkPolicyPeer{
+						{
+							NamespaceSelector: &metav1.LabelSelector{
+								MatchLabels: map[string]string{
+									"kubernetes.io/metadata.name": c.AllowFromNamespace,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	err = CreateResource(ctx, policy, clientset)
+	if err != nil {
+		return fmt.Errorf("error creating allow-from-namespace network policy: %w", err)
+	}
+
+	return nil
+}
+
+func (c *CreateAllowFromNamespaceNetworkPolicy) Prevalidate() error {
+	return nil
+}
+
+func (c *CreateAllowFromNamespaceNetworkPolicy) Stop() error {
+	return nil
+}
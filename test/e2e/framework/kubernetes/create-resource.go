@@ -6,8 +6,10 @@ import (
 	"log"
 
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -202,6 +204,22 @@ func CreateResource(ctx context.Context, obj runtime.Object, clientset *kubernet
 			return fmt.Errorf("failed to create/update NetworkPolicy \"%s\" in namespace \"%s\": %w", o.Name, o.Namespace, err)
 		}
 
+	case *policyv1.PodDisruptionBudget:
+		log.Printf("Creating/Updating PodDisruptionBudget \"%s\" in namespace \"%s\"...\n", o.Name, o.Namespace)
+		client := clientset.PolicyV1().PodDisruptionBudgets(o.Namespace)
+		_, err := client.Get(ctx, o.Name, metaV1.GetOptions{})
+		if errors.IsNotFound(err) {
+			_, err = client.Create(ctx, o, metaV1.CreateOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to create PodDisruptionBudget \"%s\" in namespace \"%s\": %w", o.Name, o.Namespace, err)
+			}
+			return nil
+		}
+		_, err = client.Update(ctx, o, metaV1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to create/update PodDisruptionBudget \"%s\" in namespace \"%s\": %w", o.Name, o.Namespace, err)
+		}
+
 	case *v1.Secret:
 		log.Printf("Creating/Updating Secret \"%s\" in namespace \"%s\"...\n", o.Name, o.Namespace)
 		client := clientset.CoreV1().Secrets(o.Namespace)
@@ -218,6 +236,52 @@ func CreateResource(ctx context.Context, obj runtime.Object, clientset *kubernet
 			return fmt.Errorf("failed to create/update Secret \"%s\" in namespace \"%s\": %w", o.Name, o.Namespace, err)
 		}
 
+	case *v1.Namespace:
+		log.Printf("Creating/Updating Namespace \"%s\"...\n", o.Name)
+		client := clientset.CoreV1().Namespaces()
+		_, err := client.Get(ctx, o.Name, metaV1.GetOptions{})
+		if errors.IsNotFound(err) {
+			_, err = client.Create(ctx, o, metaV1.CreateOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to create Namespace \"%s\": %w", o.Name, err)
+			}
+			return nil
+		}
+		_, err = client.Update(ctx, o, metaV1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to create/update Namespace \"%s\": %w", o.Name, err)
+		}
+
+	case *batchv1.Job:
+		log.Printf("Creating Job \"%s\" in namespace \"%s\"...\n", o.Name, o.Namespace)
+		client := clientset.BatchV1().Jobs(o.Namespace)
+		_, err := client.Get(ctx, o.Name, metaV1.GetOptions{})
+		if errors.IsNotFound(err) {
+			_, err = client.Create(ctx, o, metaV1.CreateOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to create Job \"%s\" in namespace \"%s\": %w", o.Name, o.Namespace, err)
+			}
+			return nil
+		}
+		// a Job's pod template is immutable, so an existing Job is left as it is
+		log.Printf("Job \"%s\" in namespace \"%s\" already exists\n", o.Name, o.Namespace)
+
+	case *batchv1.CronJob:
+		log.Printf("Creating/Updating CronJob \"%s\" in namespace \"%s\"...\n", o.Name, o.Namespace)
+		client := clientset.BatchV1().CronJobs(o.Namespace)
+		_, err := client.Get(ctx, o.Name, metaV1.GetOptions{})
+		if errors.IsNotFound(err) {
+			_, err = client.Create(ctx, o, metaV1.CreateOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to create CronJob \"%s\" in namespace \"%s\": %w", o.Name, o.Namespace, err)
+			}
+			return nil
+		}
+		_, err = client.Update(ctx, o, metaV1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to create/update CronJob \"%s\" in namespace \"%s\": %w", o.Name, o.Namespace, err)
+		}
+
 	default:
 		return fmt.Errorf("unknown object type: %T, err: %w", obj, ErrUnknownResourceType)
 	}
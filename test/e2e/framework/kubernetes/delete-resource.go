@@ -14,7 +14,6 @@ import (
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
 )
 
 var ErrDeleteNilResource = fmt.Errorf("cannot create nil resource")
@@ -34,6 +33,7 @@ const (
 	ConfigMap          ResourceType = "ConfigMap"
 	NetworkPolicy      ResourceType = "NetworkPolicy"
 	Secret             ResourceType = "Secret"
+	Namespace          ResourceType = "Namespace"
 	Unknown            ResourceType = "Unknown"
 )
 
@@ -52,6 +52,7 @@ func TypeString(resourceType ResourceType) string {
 		ConfigMap:          "ConfigMap",
 		NetworkPolicy:      "NetworkPolicy",
 		Secret:             "Secret",
+		Namespace:          "Namespace",
 		Unknown:            "Unknown",
 	}
 	str, ok := ResourceTypes[resourceType]
@@ -69,7 +70,7 @@ type DeleteKubernetesResource struct {
 }
 
 func (d *DeleteKubernetesResource) Run() error {
-	config, err := clientcmd.BuildConfigFromFlags("", d.KubeConfigFilePath)
+	config, err := BuildConfig(d.KubeConfigFilePath)
 	if err != nil {
 		return fmt.Errorf("error building kubeconfig: %w", err)
 	}
@@ -169,6 +170,12 @@ func (d *DeleteKubernetesResource) Run() error {
 				Namespace: d.ResourceNamespace,
 			},
 		}
+	case Namespace:
+		resource = &v1.Namespace{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name: d.ResourceName,
+			},
+		}
 	case Unknown:
 		return fmt.Errorf("unknown resource type: %s: %w", d.ResourceType, ErrUnknownResourceType)
 	default:
@@ -346,6 +353,18 @@ func DeleteResource(ctx context.Context, obj runtime.Object, clientset *kubernet
 			return fmt.Errorf("failed to delete Secret \"%s\" in namespace \"%s\": %w", o.Name, o.Namespace, err)
 		}
 
+	case *v1.Namespace:
+		log.Printf("Deleting Namespace \"%s\"...\n", o.Name)
+		client := clientset.CoreV1().Namespaces()
+		err := client.Delete(ctx, o.Name, metaV1.DeleteOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				log.Printf("Namespace \"%s\" does not exist\n", o.Name)
+				return nil
+			}
+			return fmt.Errorf("failed to delete Namespace \"%s\": %w", o.Name, err)
+		}
+
 	default:
 		return fmt.Errorf("unknown object type: %T, err: %w", obj, ErrUnknownResourceType)
 	}
@@ -0,0 +1,108 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	evictionTimeout       = 10 * time.Minute
+	evictionRetryInterval = 2 * time.Second
+)
+
+// EvictAgnhostPods evicts every pod of an agnhost StatefulSet in turn through the Eviction API, the way a node
+// drain does, so the pods' disruption budget decides when each one may go. An eviction the budget refuses is
+// retried until the StatefulSet's replacement of the pods evicted before it is ready. It returns once the
+// StatefulSet has all of its replicas ready again
+type EvictAgnhostPods struct {
+	AgnhostName        string
+	AgnhostNamespace   string
+	KubeConfigFilePath string
+}
+
+func (e *EvictAgnhostPods) Run() error {
+	config, err := BuildConfig(e.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), evictionTimeout)
+	defer cancel()
+
+	pods, err := clientset.CoreV1().Pods(e.AgnhostNamespace).List(ctx, metaV1.ListOptions{
+		LabelSelector: "app=" + e.AgnhostName,
+	})
+	if err != nil {
+		return fmt.Errorf("error listing pods of agnhost \"%s\": %w", e.AgnhostName, err)
+	}
+
+	names := make([]string, 0, len(pods.Items))
+	for i := range pods.Items {
+		names = append(names, pods.Items[i].Name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		refused := 0
+		err = wait.PollUntilContextCancel(ctx, evictionRetryInterval, true, func(ctx context.Context) (bool, error) {
+			evictErr := clientset.PolicyV1().Evictions(e.AgnhostNamespace).Evict(ctx, &policyv1.Eviction{
+				ObjectMeta: metaV1.ObjectMeta{
+					Name:      name,
+					Namespace: e.AgnhostNamespace,
+				},
+			})
+			// the budget refuses evictions with too many requests
+			if errors.IsTooManyRequests(evictErr) {
+				refused++
+				return false, nil
+			}
+			if evictErr != nil {
+				return false, fmt.Errorf("error evicting pod \"%s\": %w", name, evictErr)
+			}
+			return true, nil
+		})
+		if err != nil {
+			return fmt.Errorf("error waiting for the disruption budget to allow evicting pod \"%s\": %w", name, err)
+		}
+		log.Printf("evicted pod \"%s\" after its disruption budget refused %d times\n", name, refused)
+	}
+
+	err = wait.PollUntilContextCancel(ctx, RetryIntervalPodsReady, true, func(ctx context.Context) (bool, error) {
+		statefulSet, getErr := clientset.AppsV1().StatefulSets(e.AgnhostNamespace).Get(ctx, e.AgnhostName, metaV1.GetOptions{})
+		if getErr != nil {
+			return false, fmt.Errorf("error getting agnhost statefulset: %w", getErr)
+		}
+		if statefulSet.Spec.Replicas == nil || statefulSet.Status.ReadyReplicas < *statefulSet.Spec.Replicas {
+			log.Printf("agnhost statefulset \"%s\" has %d pods ready. Waiting...\n", e.AgnhostName, statefulSet.Status.ReadyReplicas)
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("error waiting for the evicted pods of agnhost \"%s\" to be replaced: %w", e.AgnhostName, err)
+	}
+
+	return nil
+}
+
+func (e *EvictAgnhostPods) Prevalidate() error {
+	return nil
+}
+
+func (e *EvictAgnhostPods) Stop() error {
+	return nil
+}
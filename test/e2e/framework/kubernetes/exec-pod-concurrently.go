@@ -0,0 +1,66 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+var ErrNoCommands = fmt.Errorf("no commands to execute")
+
+// ExecInPodConcurrently starts every command in Commands against the same pod at once,
+// for clients that race requests against each other such as parallel A and AAAA lookups
+type ExecInPodConcurrently struct {
+	PodNamespace       string
+	KubeConfigFilePath string
+	PodName            string
+	Commands           []string
+}
+
+func (e *ExecInPodConcurrently) Run() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config, err := BuildConfig(e.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	errs := make([]error, len(e.Commands))
+	var wg sync.WaitGroup
+	for i, command := range e.Commands {
+		wg.Add(1)
+		go func(i int, command string) {
+			defer wg.Done()
+			output, execErr := ExecPod(ctx, clientset, config, e.PodNamespace, e.PodName, command)
+			if execErr != nil {
+				errs[i] = fmt.Errorf("error executing command [%s]: %w", command, execErr)
+				return
+			}
+			log.Printf("command [%s] output: %s", command, string(output))
+		}(i, command)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+func (e *ExecInPodConcurrently) Prevalidate() error {
+	if len(e.Commands) == 0 {
+		return ErrNoCommands
+	}
+	return nil
+}
+
+func (e *ExecInPodConcurrently) Stop() error {
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"strings"
@@ -11,25 +12,31 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/remotecommand"
 	"k8s.io/kubectl/pkg/scheme"
 )
 
 const ExecSubResources = "exec"
 
+// ExecInPod runs Command in the pod. What the command wrote to stdout and stderr in its last run is kept, even when
+// it failed, for a later step given this one to read through Stdout and Stderr
 type ExecInPod struct {
 	PodNamespace       string
 	KubeConfigFilePath string
 	PodName            string
 	Command            string
+
+	stdout []byte
+	stderr []byte
 }
 
 func (e *ExecInPod) Run() error {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	return e.RunContext(context.Background())
+}
 
-	config, err := clientcmd.BuildConfigFromFlags("", e.KubeConfigFilePath)
+// RunContext runs Command like Run, giving up on it once ctx is cancelled, e.g. by a failing step of its scenario
+func (e *ExecInPod) RunContext(ctx context.Context) error {
+	config, err := BuildConfig(e.KubeConfigFilePath)
 	if err != nil {
 		return fmt.Errorf("error building kubeconfig: %w", err)
 	}
@@ -39,7 +46,7 @@ func (e *ExecInPod) Run() error {
 		return fmt.Errorf("error creating Kubernetes client: %w", err)
 	}
 
-	_, err = ExecPod(ctx, clientset, config, e.PodNamespace, e.PodName, e.Command)
+	e.stdout, e.stderr, err = ExecPodContainerStreams(ctx, clientset, config, e.PodNamespace, e.PodName, "", e.Command)
 	if err != nil {
 		return fmt.Errorf("error executing command [%s]: %w", e.Command, err)
 	}
@@ -47,8 +54,21 @@ func (e *ExecInPod) Run() error {
 	return nil
 }
 
+// Stdout is what the command wrote to stdout, empty until the step ran
+func (e *ExecInPod) Stdout() string {
+	return string(e.stdout)
+}
+
+// Stderr is what the command wrote to stderr, empty until the step ran
+func (e *ExecInPod) Stderr() string {
+	return string(e.stderr)
+}
+
 func (e *ExecInPod) Prevalidate() error {
-	return nil
+	if strings.TrimSpace(e.Command) == "" {
+		return fmt.Errorf("in pod \"%s\": %w", e.PodName, ErrEmptyCommand)
+	}
+	return checkPodName(e.PodNamespace, e.PodName)
 }
 
 func (e *ExecInPod) Stop() error {
@@ -56,15 +76,34 @@ func (e *ExecInPod) Stop() error {
 }
 
 func ExecPod(ctx context.Context, clientset *kubernetes.Clientset, config *rest.Config, namespace, podName, command string) ([]byte, error) {
+	return ExecPodContainer(ctx, clientset, config, namespace, podName, "", command)
+}
+
+// ExecPodContainer is ExecPod in one container of the pod, which has to be named when the pod has more than one
+func ExecPodContainer(ctx context.Context, clientset *kubernetes.Clientset, config *rest.Config, namespace, podName, container, command string) ([]byte, error) {
+	var buf bytes.Buffer
+	err := execPodContainer(ctx, clientset, config, namespace, podName, container, command, &buf, &buf)
+	return buf.Bytes(), err
+}
+
+// ExecPodContainerStreams is ExecPodContainer keeping stdout and stderr apart
+func ExecPodContainerStreams(ctx context.Context, clientset *kubernetes.Clientset, config *rest.Config, namespace, podName, container, command string) (stdout, stderr []byte, err error) {
+	var outBuf, errBuf bytes.Buffer
+	err = execPodContainer(ctx, clientset, config, namespace, podName, container, command, &outBuf, &errBuf)
+	return outBuf.Bytes(), errBuf.Bytes(), err
+}
+
+func execPodContainer(ctx context.Context, clientset *kubernetes.Clientset, config *rest.Config, namespace, podName, container, command string, stdout, stderr io.Writer) error {
 	log.Printf("executing command \"%s\" on pod \"%s\" in namespace \"%s\"...", command, podName, namespace)
 	req := clientset.CoreV1().RESTClient().Post().Resource("pods").Name(podName).
 		Namespace(namespace).SubResource(ExecSubResources)
 	option := &v1.PodExecOptions{
-		Command: strings.Fields(command),
-		Stdin:   true,
-		Stdout:  true,
-		Stderr:  true,
-		TTY:     false,
+		Container: container,
+		Command:   strings.Fields(command),
+		Stdin:     true,
+		Stdout:    true,
+		Stderr:    true,
+		TTY:       false,
 	}
 
 	req.VersionedParams(
@@ -72,21 +111,19 @@ func ExecPod(ctx context.Context, clientset *kubernetes.Clientset, config *rest.
 		scheme.ParameterCodec,
 	)
 
-	var buf bytes.Buffer
 	exec, err := remotecommand.NewSPDYExecutor(config, "POST", req.URL())
 	if err != nil {
-		return buf.Bytes(), fmt.Errorf("error creating executor: %w", err)
+		return fmt.Errorf("error creating executor: %w", err)
 	}
 
 	err = exec.StreamWithContext(ctx, remotecommand.StreamOptions{
 		Stdin:  os.Stdin,
-		Stdout: &buf,
-		Stderr: &buf,
+		Stdout: stdout,
+		Stderr: stderr,
 	})
 	if err != nil {
-		return buf.Bytes(), fmt.Errorf("error executing command: %w", err)
+		return fmt.Errorf("error executing command: %w", err)
 	}
 
-	res := buf.Bytes()
-	return res, nil
+	return nil
 }
@@ -6,37 +6,81 @@ import (
 	"io"
 	"log"
 
+	"github.com/microsoft/retina/test/e2e/framework/types"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
 )
 
 type GetPodLogs struct {
 	KubeConfigFilePath string
-	Namespace          string
-	LabelSelector      string
+	PodNamespace       string
+	PodLabelSelector   string
+
+	// artifactDir gets a <pod>.log file per pod, when the job has an artifacts directory
+	artifactDir string
+}
+
+func (p *GetPodLogs) SetArtifactDir(dir string) {
+	p.artifactDir = dir
 }
 
 func (p *GetPodLogs) Run() error {
-	fmt.Printf("printing pod logs for namespace: %s, labelselector: %s\n", p.Namespace, p.LabelSelector)
+	fmt.Printf("printing pod logs for namespace: %s, labelselector: %s\n", p.PodNamespace, p.PodLabelSelector)
 	// Load the kubeconfig file to get the configuration to access the cluster
-	config, err := clientcmd.BuildConfigFromFlags("", p.KubeConfigFilePath)
+	config, err := BuildConfig(p.KubeConfigFilePath)
 	if err != nil {
-		log.Printf("error building kubeconfig: %s\n", err)
+		return fmt.Errorf("error building kubeconfig: %w", err)
 	}
 
 	// Create a new clientset to interact with the cluster
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
-		log.Printf("error creating clientset: %s\n", err)
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
 	}
 
-	PrintPodLogs(context.Background(), clientset, p.Namespace, p.LabelSelector)
+	PrintPodLogs(context.Background(), clientset, p.PodNamespace, p.PodLabelSelector)
+
+	if p.artifactDir != "" {
+		SavePodLogs(context.Background(), clientset, p.PodNamespace, p.PodLabelSelector, p.artifactDir)
+	}
+
+	return nil
+}
+
+func (p *GetPodLogs) Prevalidate() error {
+	return nil
+}
 
+func (p *GetPodLogs) Stop() error {
 	return nil
 }
 
+// SavePodLogs writes the logs of each pod matching labelSelector to <pod>.log in dir. Like PrintPodLogs it only
+// logs errors, as it's used to diagnose failures that are already being reported
+func SavePodLogs(ctx context.Context, clientset *kubernetes.Clientset, namespace, labelSelector, dir string) {
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labelSelector,
+	})
+	if err != nil {
+		log.Printf("error listing pods: %s\n", err)
+		return
+	}
+
+	for i := range pods.Items {
+		podName := pods.Items[i].Name
+		buf, readErr := ReadPodLogs(ctx, clientset, namespace, podName)
+		if readErr != nil {
+			log.Printf("error saving logs: %s\n", readErr)
+			continue
+		}
+		writeErr := types.WriteArtifact(dir, podName+".log", buf)
+		if writeErr != nil {
+			log.Printf("error saving logs for pod %s: %s\n", podName, writeErr)
+		}
+	}
+}
+
 func PrintPodLogs(ctx context.Context, clientset *kubernetes.Clientset, namespace, labelSelector string) {
 	// List all the pods in the namespace
 	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
@@ -71,3 +115,28 @@ func PrintPodLogs(ctx context.Context, clientset *kubernetes.Clientset, namespac
 		fmt.Printf("#######################################################\n")
 	}
 }
+
+// ReadPodLogs returns the logs of the given pod, for steps that need to inspect them rather than print them
+func ReadPodLogs(ctx context.Context, clientset *kubernetes.Clientset, namespace, podName string) ([]byte, error) {
+	return readPodLogs(ctx, clientset, namespace, podName, &corev1.PodLogOptions{})
+}
+
+// ReadPreviousPodLogs returns the logs of the last terminated run of a container, where a crashing container logged why it exited
+func ReadPreviousPodLogs(ctx context.Context, clientset *kubernetes.Clientset, namespace, podName, containerName string) ([]byte, error) {
+	return readPodLogs(ctx, clientset, namespace, podName, &corev1.PodLogOptions{Container: containerName, Previous: true})
+}
+
+func readPodLogs(ctx context.Context, clientset *kubernetes.Clientset, namespace, podName string, opts *corev1.PodLogOptions) ([]byte, error) {
+	req := clientset.CoreV1().Pods(namespace).GetLogs(podName, opts)
+	podLogs, err := req.Stream(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting logs for pod %s: %w", podName, err)
+	}
+	defer podLogs.Close()
+
+	buf, err := io.ReadAll(podLogs)
+	if err != nil {
+		return nil, fmt.Errorf("error reading logs for pod %s: %w", podName, err)
+	}
+	return buf, nil
+}
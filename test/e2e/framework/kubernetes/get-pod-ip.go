@@ -6,11 +6,10 @@ import (
 	"github.com/pkg/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
 )
 
 func GetPodIP(kubeConfigFilePath, namespace, podName string) (string, error) {
-	config, err := clientcmd.BuildConfigFromFlags("", kubeConfigFilePath)
+	config, err := BuildConfig(kubeConfigFilePath)
 	if err != nil {
 		return "", errors.Wrapf(err, "error building kubeconfig")
 	}
@@ -13,7 +13,6 @@ import (
 	"helm.sh/helm/v3/pkg/chart/loader"
 	"helm.sh/helm/v3/pkg/cli"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
 )
 
 const (
@@ -39,6 +38,7 @@ func (i *InstallHelmChart) Run() error {
 	defer cancel()
 	settings := cli.New()
 	settings.KubeConfig = i.KubeConfigFilePath
+	settings.KubeContext = KubeConfigContext(i.KubeConfigFilePath)
 	actionConfig := new(action.Configuration)
 
 	err := actionConfig.Init(settings.RESTClientGetter(), i.Namespace, os.Getenv("HELM_DRIVER"), log.Printf)
@@ -112,7 +112,7 @@ func (i *InstallHelmChart) Run() error {
 	log.Printf("chart values: %v\n", rel.Config)
 
 	// ensure all pods are running, since helm doesn't care about windows
-	config, err := clientcmd.BuildConfigFromFlags("", i.KubeConfigFilePath)
+	config, err := BuildConfig(i.KubeConfigFilePath)
 	if err != nil {
 		return fmt.Errorf("error building kubeconfig: %w", err)
 	}
@@ -123,7 +123,7 @@ func (i *InstallHelmChart) Run() error {
 	}
 
 	labelSelector := "k8s-app=retina"
-	err = WaitForPodReady(ctx, clientset, "kube-system", labelSelector)
+	err = WaitForPodsRunning(ctx, clientset, "kube-system", labelSelector)
 	if err != nil {
 		return fmt.Errorf("error waiting for retina pods to be ready: %w", err)
 	}
@@ -0,0 +1,51 @@
+package kubernetes
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// contexts holds the context selected for each kubeconfig file by UseKubeConfigContext, keyed by the file's absolute path
+var contexts sync.Map
+
+func kubeConfigKey(kubeConfigFilePath string) string {
+	path, err := filepath.Abs(kubeConfigFilePath)
+	if err != nil {
+		return filepath.Clean(kubeConfigFilePath)
+	}
+	return path
+}
+
+func selectContext(kubeConfigFilePath, contextName string) {
+	contexts.Store(kubeConfigKey(kubeConfigFilePath), contextName)
+}
+
+// KubeConfigContext returns the context selected for the kubeconfig file, or "" when steps use its current context
+func KubeConfigContext(kubeConfigFilePath string) string {
+	contextName, ok := contexts.Load(kubeConfigKey(kubeConfigFilePath))
+	if !ok {
+		return ""
+	}
+	return contextName.(string) //nolint:forcetypeassert // only strings are stored
+}
+
+// BuildConfig builds the client config of steps reaching the cluster through the kubeconfig file, using the
+// context UseKubeConfigContext selected for the file, or the file's current context if none was
+func BuildConfig(kubeConfigFilePath string) (*rest.Config, error) {
+	return buildConfig(kubeConfigFilePath, KubeConfigContext(kubeConfigFilePath))
+}
+
+func buildConfig(kubeConfigFilePath, contextName string) (*rest.Config, error) {
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeConfigFilePath},
+		&clientcmd.ConfigOverrides{CurrentContext: contextName},
+	).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error loading kubeconfig \"%s\": %w", kubeConfigFilePath, err)
+	}
+	return config, nil
+}
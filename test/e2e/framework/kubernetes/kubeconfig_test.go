@@ -0,0 +1,74 @@
+package kubernetes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testKubeConfig = `apiVersion: v1
+kind: Config
+current-context: dev
+clusters:
+- name: dev-cluster
+  cluster:
+    server: https://dev.example.com
+- name: prod-cluster
+  cluster:
+    server: https://prod.example.com
+contexts:
+- name: dev
+  context:
+    cluster: dev-cluster
+    user: admin
+- name: prod
+  context:
+    cluster: prod-cluster
+    user: admin
+users:
+- name: admin
+  user:
+    token: token
+`
+
+func writeTestKubeConfig(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	require.NoError(t, os.WriteFile(path, []byte(testKubeConfig), 0o600))
+	return path
+}
+
+func TestBuildConfigDefaultsToCurrentContext(t *testing.T) {
+	path := writeTestKubeConfig(t)
+
+	config, err := BuildConfig(path)
+	require.NoError(t, err)
+	require.Equal(t, "https://dev.example.com", config.Host)
+}
+
+func TestBuildConfigUsesSelectedContext(t *testing.T) {
+	path := writeTestKubeConfig(t)
+	selectContext(path, "prod")
+
+	config, err := BuildConfig(path)
+	require.NoError(t, err)
+	require.Equal(t, "https://prod.example.com", config.Host)
+
+	// the selection is per file, whichever way its path is spelled
+	other := writeTestKubeConfig(t)
+	config, err = BuildConfig(other)
+	require.NoError(t, err)
+	require.Equal(t, "https://dev.example.com", config.Host)
+
+	require.Equal(t, "prod", KubeConfigContext(filepath.Dir(path)+"/./kubeconfig"))
+}
+
+func TestUseKubeConfigContextRejectsUnknownContext(t *testing.T) {
+	path := writeTestKubeConfig(t)
+
+	step := &UseKubeConfigContext{KubeConfigFilePath: path, ContextName: "staging"}
+	require.ErrorIs(t, step.Run(), ErrContextNotFound)
+	require.Equal(t, "", KubeConfigContext(path))
+}
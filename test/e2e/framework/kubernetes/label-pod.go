@@ -0,0 +1,62 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// LabelPod sets labels on a running pod, overwriting existing values for the same keys. Labels are only
+// changed on the pod, its controller keeps the template's labels for pods it creates later
+type LabelPod struct {
+	PodNamespace       string
+	PodName            string
+	KubeConfigFilePath string
+	Labels             map[string]string
+}
+
+func (l *LabelPod) Run() error {
+	config, err := BuildConfig(l.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeoutSeconds*time.Second)
+	defer cancel()
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": l.Labels,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error creating label patch: %w", err)
+	}
+
+	_, err = clientset.CoreV1().Pods(l.PodNamespace).Patch(ctx, l.PodName, types.StrategicMergePatchType, patch, metaV1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("error labeling pod \"%s\" in namespace \"%s\": %w", l.PodName, l.PodNamespace, err)
+	}
+
+	log.Printf("labeled pod \"%s\" in namespace \"%s\" with %v\n", l.PodName, l.PodNamespace, l.Labels)
+	return nil
+}
+
+func (l *LabelPod) Prevalidate() error {
+	return nil
+}
+
+func (l *LabelPod) Stop() error {
+	return nil
+}
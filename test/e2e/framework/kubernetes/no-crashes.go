@@ -4,8 +4,8 @@ import (
 	"context"
 	"fmt"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
 )
 
 var ErrPodCrashed = fmt.Errorf("pod has crashes")
@@ -17,7 +17,7 @@ type EnsureStableCluster struct {
 }
 
 func (n *EnsureStableCluster) Run() error {
-	config, err := clientcmd.BuildConfigFromFlags("", n.KubeConfigFilePath)
+	config, err := BuildConfig(n.KubeConfigFilePath)
 	if err != nil {
 		return fmt.Errorf("error building kubeconfig: %w", err)
 	}
@@ -27,7 +27,7 @@ func (n *EnsureStableCluster) Run() error {
 		return fmt.Errorf("error creating Kubernetes client: %w", err)
 	}
 
-	err = WaitForPodReady(context.TODO(), clientset, n.PodNamespace, n.LabelSelector)
+	err = WaitForPodsRunning(context.TODO(), clientset, n.PodNamespace, n.LabelSelector)
 	if err != nil {
 		return fmt.Errorf("error waiting for retina pods to be ready: %w", err)
 	}
@@ -41,3 +41,47 @@ func (n *EnsureStableCluster) Prevalidate() error {
 func (n *EnsureStableCluster) Stop() error {
 	return nil
 }
+
+var ErrPodRestarted = fmt.Errorf("pod has restarted")
+
+// EnsureNoRestarts fails if any container of the pods matching LabelSelector has restarted,
+// for catching a component that crashed and recovered between steps
+type EnsureNoRestarts struct {
+	LabelSelector      string
+	PodNamespace       string
+	KubeConfigFilePath string
+}
+
+func (n *EnsureNoRestarts) Run() error {
+	config, err := BuildConfig(n.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	pods, err := clientset.CoreV1().Pods(n.PodNamespace).List(context.TODO(), metav1.ListOptions{LabelSelector: n.LabelSelector})
+	if err != nil {
+		return fmt.Errorf("error listing pods with label %s: %w", n.LabelSelector, err)
+	}
+
+	for i := range pods.Items {
+		for _, status := range pods.Items[i].Status.ContainerStatuses {
+			if status.RestartCount > 0 {
+				return fmt.Errorf("container %s of pod %s restarted %d times: %w", status.Name, pods.Items[i].Name, status.RestartCount, ErrPodRestarted)
+			}
+		}
+	}
+	return nil
+}
+
+func (n *EnsureNoRestarts) Prevalidate() error {
+	return nil
+}
+
+func (n *EnsureNoRestarts) Stop() error {
+	return nil
+}
@@ -14,7 +14,6 @@ import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
 )
 
 const (
@@ -26,32 +25,60 @@ const (
 
 var (
 	ErrNoPodWithLabelFound = fmt.Errorf("no pod with label found with matching pod affinity")
+	ErrPodNotRunning       = fmt.Errorf("pod is not running")
+	ErrEndpointUnavailable = fmt.Errorf("port forwarded endpoint returned an error status")
+	ErrPortForwardFailed   = fmt.Errorf("port forward failed")
 
 	defaultRetrier = retry.Retrier{Attempts: defaultRetryAttempts, Delay: defaultRetryDelay}
 )
 
 type PortForward struct {
-	Namespace             string
-	LabelSelector         string
-	LocalPort             string
-	RemotePort            string
+	Namespace     string
+	LabelSelector string
+	LocalPort     string
+	RemotePort    string
+
+	// Endpoint is the path requested to check the port forward works, e.g. "metrics", "healthz" or
+	// "debug/pprof/", with or without a leading slash and optionally with a query. Any HTTP response
+	// means the forward works
 	Endpoint              string
 	KubeConfigFilePath    string
 	OptionalLabelAffinity string
 
+	// OptionalLabelAffinityAllNamespaces looks for the affinity pod in every namespace
+	// rather than only Namespace, for workloads that don't run alongside the target pods
+	OptionalLabelAffinityAllNamespaces bool
+
+	// CheckEndpointStatus also requires Endpoint to answer with a status below 400, e.g. for a readiness
+	// check on "healthz", rather than any response
+	CheckEndpointStatus bool
+
+	// TargetPod, when its Name is set, is the pod in Namespace to port forward to, taking precedence
+	// over LabelSelector and OptionalLabelAffinity. It has to be running
+	TargetPod TargetPod
+
 	// local properties
-	pf *PortForwarder
+	pf              *PortForwarder
+	ctx             context.Context
+	cancelKeepAlive context.CancelFunc
+	keepAliveDone   chan struct{}
 }
 
 func (p *PortForward) Run() error {
+	return p.RunContext(context.Background())
+}
+
+// RunContext starts the port forward, which keeps going until the step is stopped or ctx is cancelled, e.g. by
+// a failing step of its scenario, so an aborted run doesn't leave it behind
+func (p *PortForward) RunContext(ctx context.Context) error {
 	lport, _ := strconv.Atoi(p.LocalPort)
 	rport, _ := strconv.Atoi(p.RemotePort)
 
-	pctx := context.Background()
-	portForwardCtx, cancel := context.WithTimeout(pctx, defaultTimeoutSeconds*time.Second)
+	p.ctx = ctx
+	portForwardCtx, cancel := context.WithTimeout(ctx, defaultTimeoutSeconds*time.Second)
 	defer cancel()
 
-	config, err := clientcmd.BuildConfigFromFlags("", p.KubeConfigFilePath)
+	config, err := BuildConfig(p.KubeConfigFilePath)
 	if err != nil {
 		return fmt.Errorf("error building kubeconfig: %w", err)
 	}
@@ -61,12 +88,19 @@ func (p *PortForward) Run() error {
 		return fmt.Errorf("could not create clientset: %w", err)
 	}
 
-	// if we have an optional label affinity, find a pod with that label, on the same node as a pod with the label selector
+	// a named target pod goes first, else if we have an optional label affinity, find a pod with that label,
+	// on the same node as a pod with the label selector
 	targetPodName := ""
-	if p.OptionalLabelAffinity != "" {
+	if p.TargetPod.Name != "" {
+		err = checkPodRunning(ctx, clientset, p.Namespace, p.TargetPod.Name)
+		if err != nil {
+			return err
+		}
+		targetPodName = p.TargetPod.Name
+	} else if p.OptionalLabelAffinity != "" {
 		// get all pods with label
 		log.Printf("attempting to find pod with label \"%s\", on a node with a pod with label \"%s\"\n", p.LabelSelector, p.OptionalLabelAffinity)
-		targetPodName, err = p.findPodsWithAffinity(pctx, clientset)
+		targetPodName, err = p.findPodsWithAffinity(ctx, clientset)
 		if err != nil {
 			return fmt.Errorf("could not find pod with affinity: %w", err)
 		}
@@ -91,42 +125,115 @@ func (p *PortForward) Run() error {
 		if err != nil {
 			return fmt.Errorf("could not create port forwarder: %w", err)
 		}
-		err = p.pf.Forward(pctx)
+		err = p.pf.Forward(ctx)
 		if err != nil {
 			return fmt.Errorf("could not start port forward: %w", err)
 		}
 
 		// verify port forward succeeded
-		client := http.Client{
-			Timeout: defaultHTTPClientTimeout,
-		}
-		resp, err := client.Get(p.pf.Address() + "/" + p.Endpoint) //nolint
+		err = probeEndpoint(p.pf.URL(p.Endpoint), p.CheckEndpointStatus)
 		if err != nil {
-			log.Printf("port forward validation HTTP request to %s failed: %v\n", p.pf.Address(), err)
+			log.Printf("port forward validation failed: %v\n", err)
 			p.pf.Stop()
-			return fmt.Errorf("port forward validation HTTP request to %s failed: %w", p.pf.Address(), err)
+			return fmt.Errorf("port forward validation failed: %w", err)
 		}
-		defer resp.Body.Close()
-
-		log.Printf("port forward validation HTTP request to \"%s\" succeeded, response: %s\n", p.pf.Address(), resp.Status)
 
 		return nil
 	}
 
 	if err = defaultRetrier.Do(portForwardCtx, portForwardFn); err != nil {
-		return fmt.Errorf("could not start port forward within %ds: %w", defaultTimeoutSeconds, err)
+		// unlike not finding the pod above, a failed port forward is worth retrying
+		return fmt.Errorf("could not start port forward within %ds: %w: %w", defaultTimeoutSeconds, ErrPortForwardFailed, err)
 	}
 	log.Printf("successfully port forwarded to \"%s\"\n", p.pf.Address())
+
+	// reconnect if the session drops, e.g. on an API server hiccup, until the step is stopped or ctx is cancelled
+	keepAliveCtx, cancelKeepAlive := context.WithCancel(ctx)
+	p.cancelKeepAlive = cancelKeepAlive
+	p.keepAliveDone = make(chan struct{})
+	pf, keepAliveDone := p.pf, p.keepAliveDone
+	go func() {
+		defer close(keepAliveDone)
+		pf.KeepAlive(keepAliveCtx)
+		if ctx.Err() != nil {
+			log.Printf("stopping port forward to \"%s\": %v\n", pf.Address(), context.Cause(ctx))
+			pf.Stop()
+		}
+	}()
 	return nil
 }
 
+// probeEndpoint requests url through a port forward, failing if it can't be reached or, with checkStatus, answers
+// with an error status
+func probeEndpoint(url string, checkStatus bool) error {
+	client := http.Client{
+		Timeout: defaultHTTPClientTimeout,
+	}
+	resp, err := client.Get(url) //nolint
+	if err != nil {
+		return fmt.Errorf("HTTP request to %s failed: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if checkStatus && resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("HTTP request to %s returned %s: %w", url, resp.Status, ErrEndpointUnavailable)
+	}
+	log.Printf("port forward validation HTTP request to \"%s\" succeeded, response: %s\n", url, resp.Status)
+	return nil
+}
+
+// restart starts the port forward again after it was stopped, with the context it last ran with, finding the pod
+// it forwards to anew
+func (p *PortForward) restart() error {
+	ctx := p.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return p.RunContext(ctx)
+}
+
+// TargetPod names the pod a PortForward goes to. It is a struct rather than a string field of PortForward,
+// so the job neither requires a value for it nor saves one for every other port forward
+type TargetPod struct {
+	Name string
+}
+
+// checkPodRunning fails with the pod's phase, and the reasons of its containers that aren't running, unless it is running
+func checkPodRunning(ctx context.Context, clientset *kubernetes.Clientset, namespace, podName string) error {
+	pod, err := clientset.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("could not get pod \"%s\" in namespace \"%s\" to port forward to: %w", podName, namespace, err)
+	}
+	if pod.Status.Phase == v1.PodRunning {
+		return nil
+	}
+
+	reasons := []string{}
+	for i := range pod.Status.ContainerStatuses {
+		status := pod.Status.ContainerStatuses[i]
+		switch {
+		case status.State.Waiting != nil:
+			reasons = append(reasons, fmt.Sprintf("%s waiting: %s", status.Name, status.State.Waiting.Reason))
+		case status.State.Terminated != nil:
+			reasons = append(reasons, fmt.Sprintf("%s terminated: %s", status.Name, status.State.Terminated.Reason))
+		}
+	}
+	return fmt.Errorf("pod \"%s\" in namespace \"%s\" is %s %v: %w", podName, namespace, pod.Status.Phase, reasons, ErrPodNotRunning)
+}
+
 func (p *PortForward) findPodsWithAffinity(ctx context.Context, clientset *kubernetes.Clientset) (string, error) {
-	targetPodsAll, errAffinity := clientset.CoreV1().Pods(p.Namespace).List(ctx, metav1.ListOptions{
-		LabelSelector: p.LabelSelector,
+	return FindPodWithAffinity(ctx, clientset, p.Namespace, p.LabelSelector, p.OptionalLabelAffinity, p.OptionalLabelAffinityAllNamespaces)
+}
+
+// FindPodWithAffinity returns a running Linux pod in namespace with labelSelector, on a node that also runs a pod
+// with affinityLabelSelector, looked for in every namespace with affinityAllNamespaces rather than only namespace
+func FindPodWithAffinity(ctx context.Context, clientset *kubernetes.Clientset, namespace, labelSelector, affinityLabelSelector string, affinityAllNamespaces bool) (string, error) {
+	targetPodsAll, errAffinity := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labelSelector,
 		FieldSelector: "status.phase=Running",
 	})
 	if errAffinity != nil {
-		return "", fmt.Errorf("could not list pods in %q with label %q: %w", p.Namespace, p.LabelSelector, errAffinity)
+		return "", fmt.Errorf("could not list pods in %q with label %q: %w", namespace, labelSelector, errAffinity)
 	}
 
 	// omit windows pods because we can't port-forward to them
@@ -138,12 +245,16 @@ func (p *PortForward) findPodsWithAffinity(ctx context.Context, clientset *kuber
 	}
 
 	// get all pods with optional label affinity
-	affinityPods, errAffinity := clientset.CoreV1().Pods(p.Namespace).List(ctx, metav1.ListOptions{
-		LabelSelector: p.OptionalLabelAffinity,
+	affinityNamespace := namespace
+	if affinityAllNamespaces {
+		affinityNamespace = metav1.NamespaceAll
+	}
+	affinityPods, errAffinity := clientset.CoreV1().Pods(affinityNamespace).List(ctx, metav1.ListOptions{
+		LabelSelector: affinityLabelSelector,
 		FieldSelector: "status.phase=Running",
 	})
 	if errAffinity != nil {
-		return "", fmt.Errorf("could not list affinity pods in %q with label %q: %w", p.Namespace, p.OptionalLabelAffinity, errAffinity)
+		return "", fmt.Errorf("could not list affinity pods in %q with label %q: %w", affinityNamespace, affinityLabelSelector, errAffinity)
 	}
 
 	// keep track of where the affinity pods are scheduled
@@ -160,14 +271,33 @@ func (p *PortForward) findPodsWithAffinity(ctx context.Context, clientset *kuber
 		}
 	}
 
-	return "", fmt.Errorf("could not find a pod with label \"%s\", on a node that also has a pod with label \"%s\": %w", p.LabelSelector, p.OptionalLabelAffinity, ErrNoPodWithLabelFound)
+	return "", fmt.Errorf("could not find a pod with label \"%s\", on a node that also has a pod with label \"%s\": %w", labelSelector, affinityLabelSelector, ErrNoPodWithLabelFound)
 }
 
 func (p *PortForward) Prevalidate() error {
-	return nil
+	err := checkPort("LocalPort", p.LocalPort)
+	if err != nil {
+		return err
+	}
+	err = checkPort("RemotePort", p.RemotePort)
+	if err != nil {
+		return err
+	}
+	if p.TargetPod.Name != "" {
+		return checkPodName(p.Namespace, p.TargetPod.Name)
+	}
+	err = checkLabelSelector("LabelSelector", p.LabelSelector)
+	if err != nil {
+		return err
+	}
+	return checkLabelSelector("OptionalLabelAffinity", p.OptionalLabelAffinity)
 }
 
 func (p *PortForward) Stop() error {
+	if p.cancelKeepAlive != nil {
+		p.cancelKeepAlive()
+		<-p.keepAliveDone
+	}
 	p.pf.Stop()
 	return nil
 }
@@ -6,6 +6,7 @@ import (
 	"io"
 	"math/rand"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -17,6 +18,13 @@ import (
 	"k8s.io/client-go/transport/spdy"
 )
 
+const (
+	defaultReconnectAttempts = 10
+	defaultReconnectDelay    = time.Second
+
+	localAddressPrefix = "http://localhost:"
+)
+
 // PortForwarder can manage a port forwarding session.
 type PortForwarder struct {
 	clientset *kubernetes.Clientset
@@ -26,10 +34,17 @@ type PortForwarder struct {
 
 	opts PortForwardingOpts
 
+	// stopChan belongs to the current session, each session started by Forward gets its own
+	stopMu      sync.Mutex
 	stopChan    chan struct{}
 	errChan     chan error
 	address     string
 	lazyAddress sync.Once
+
+	// forward starts a session, it's Forward outside of tests
+	forward           func(ctx context.Context) error
+	reconnectAttempts int
+	reconnectDelay    time.Duration
 }
 
 type PortForwardingOpts struct {
@@ -52,14 +67,17 @@ func NewPortForwarder(restConfig *rest.Config, logger logger, opts PortForwardin
 		return nil, fmt.Errorf("could not create spdy roundtripper: %w", err)
 	}
 
-	return &PortForwarder{
-		clientset: clientset,
-		transport: transport,
-		upgrader:  upgrader,
-		logger:    logger,
-		opts:      opts,
-		stopChan:  make(chan struct{}, 1),
-	}, nil
+	p := &PortForwarder{
+		clientset:         clientset,
+		transport:         transport,
+		upgrader:          upgrader,
+		logger:            logger,
+		opts:              opts,
+		reconnectAttempts: defaultReconnectAttempts,
+		reconnectDelay:    defaultReconnectDelay,
+	}
+	p.forward = p.Forward
+	return p, nil
 }
 
 // todo: can be made more flexible to allow a service to be specified
@@ -102,10 +120,15 @@ func (p *PortForwarder) Forward(ctx context.Context) error {
 		Name(podName).
 		SubResource("portforward").URL()
 
+	stopChan := make(chan struct{})
+	p.stopMu.Lock()
+	p.stopChan = stopChan
+	p.stopMu.Unlock()
+
 	readyChan := make(chan struct{}, 1)
 	dialer := spdy.NewDialer(p.upgrader, &http.Client{Transport: p.transport}, http.MethodPost, portForwardURL)
 	ports := []string{fmt.Sprintf("%d:%d", p.opts.LocalPort, p.opts.DestPort)}
-	pf, err := portforward.New(dialer, ports, p.stopChan, readyChan, io.Discard, io.Discard)
+	pf, err := portforward.New(dialer, ports, stopChan, readyChan, io.Discard, io.Discard)
 	if err != nil {
 		return fmt.Errorf("could not create portforwarder: %w", err)
 	}
@@ -143,7 +166,7 @@ func (p *PortForwarder) Forward(ctx context.Context) error {
 	// since the address could be read at the same time as the session is renewed, it's appropriate to initialize
 	// lazily.
 	p.lazyAddress.Do(func() {
-		p.address = fmt.Sprintf("http://localhost:%d", portForwardPort)
+		p.address = fmt.Sprintf("%s%d", localAddressPrefix, portForwardPort)
 	})
 
 	p.errChan = errChan
@@ -156,15 +179,55 @@ func (p *PortForwarder) Address() string {
 	return p.address
 }
 
-// Stop terminates a port forwarding session.
+// LocalPort returns the local port the pod is forwarded to.
+func (p *PortForwarder) LocalPort() string {
+	return strings.TrimPrefix(p.address, localAddressPrefix)
+}
+
+// URL returns the URL of path on the local end of the session, see LocalURL.
+func (p *PortForwarder) URL(path string) string {
+	return LocalURL(p.LocalPort(), path)
+}
+
+// LocalURL returns the URL of path, such as "metrics", "/healthz" or "debug/pprof/heap?debug=1", on a pod port
+// forwarded to localPort. path may start with a slash or not, and keeps its query.
+func LocalURL(localPort, path string) string {
+	return localAddressPrefix + localPort + "/" + strings.TrimLeft(path, "/")
+}
+
+// ForwardToPod forwards a free local port to port of podName, one of the pods with labelSelector in namespace,
+// for a caller talking to several pods at once. The caller stops the session once done.
+func ForwardToPod(ctx context.Context, config *rest.Config, namespace, labelSelector, podName string, port int) (*PortForwarder, error) {
+	pf, err := NewPortForwarder(config, logger{}, PortForwardingOpts{
+		Namespace:     namespace,
+		LabelSelector: labelSelector,
+		PodName:       podName,
+		DestPort:      port,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = pf.Forward(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not forward to pod %s/%s: %w", namespace, podName, err)
+	}
+	return pf, nil
+}
+
+// Stop terminates the current port forwarding session.
 func (p *PortForwarder) Stop() {
-	select {
-	case p.stopChan <- struct{}{}:
-	default:
+	p.stopMu.Lock()
+	defer p.stopMu.Unlock()
+	if p.stopChan != nil {
+		close(p.stopChan)
+		p.stopChan = nil
 	}
 }
 
-// KeepAlive can be used to restart the port forwarding session in the background.
+// KeepAlive restarts the port forwarding session in the background whenever it drops, on the same local port,
+// until ctx is cancelled. It gives up when the session can't be restarted within reconnectAttempts tries.
+// Cancel ctx and wait for KeepAlive to return before calling Stop, or the stopped session is restarted.
 func (p *PortForwarder) KeepAlive(ctx context.Context) {
 	for {
 		select {
@@ -172,25 +235,32 @@ func (p *PortForwarder) KeepAlive(ctx context.Context) {
 			p.logger.Logf("port forwarder: keep alive cancelled: %v", ctx.Err())
 			return
 		case pfErr := <-p.errChan:
-			// as of client-go v0.26.1, if the connection is successful at first but then fails,
-			// an error is logged but only a nil error is sent to this channel. this will be fixed
-			// in v0.27.x, which at the time of writing has not been released.
-			//
-			// see https://github.com/kubernetes/client-go/commit/d0842249d3b92ea67c446fe273f84fe74ebaed9f
-			// for the relevant change.
-			p.logger.Logf("port forwarder: received error signal: %v. restarting session", pfErr)
-			p.Stop()
-			if err := p.Forward(ctx); err != nil {
-				p.logger.Logf("port forwarder: could not restart session: %v. retrying", err)
-
-				select {
-				case <-ctx.Done():
-					p.logger.Logf("port forwarder: keep alive cancelled: %v", ctx.Err())
-					return
-				case <-time.After(time.Second): // todo: make configurable?
-					continue
-				}
+			p.logger.Logf("port forwarder: session dropped: %v. restarting session", pfErr)
+			if !p.reconnect(ctx) {
+				return
 			}
 		}
 	}
 }
+
+func (p *PortForwarder) reconnect(ctx context.Context) bool {
+	for attempt := 1; attempt <= p.reconnectAttempts; attempt++ {
+		p.Stop()
+		err := p.forward(ctx)
+		if err == nil {
+			p.logger.Logf("port forwarder: restarted session on %s", p.Address())
+			return true
+		}
+		p.logger.Logf("port forwarder: could not restart session (attempt %d/%d): %v", attempt, p.reconnectAttempts, err)
+
+		select {
+		case <-ctx.Done():
+			p.logger.Logf("port forwarder: keep alive cancelled: %v", ctx.Err())
+			return false
+		case <-time.After(p.reconnectDelay):
+		}
+	}
+
+	p.logger.Logf("port forwarder: giving up restarting session after %d attempts", p.reconnectAttempts)
+	return false
+}
@@ -0,0 +1,129 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var errSessionDropped = fmt.Errorf("lost connection to pod")
+
+func newTestPortForwarder(forward func(p *PortForwarder) error) *PortForwarder {
+	p := &PortForwarder{
+		address:           "http://localhost:10093",
+		errChan:           make(chan error, 1),
+		reconnectAttempts: 3,
+		reconnectDelay:    time.Millisecond,
+	}
+	p.forward = func(context.Context) error {
+		return forward(p)
+	}
+	return p
+}
+
+func TestKeepAliveReconnectsDroppedSession(t *testing.T) {
+	var attempts atomic.Int32
+	reconnected := make(chan struct{})
+	p := newTestPortForwarder(func(p *PortForwarder) error {
+		// the API server is still unavailable on the first attempt
+		if attempts.Add(1) == 1 {
+			return errSessionDropped
+		}
+		p.errChan = make(chan error, 1)
+		close(reconnected)
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.KeepAlive(ctx)
+	}()
+
+	p.errChan <- errSessionDropped
+	select {
+	case <-reconnected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("session was not restarted after it dropped")
+	}
+	require.Equal(t, int32(2), attempts.Load())
+
+	cancel()
+	<-done
+}
+
+func TestKeepAliveGivesUpAfterReconnectAttempts(t *testing.T) {
+	var attempts atomic.Int32
+	p := newTestPortForwarder(func(*PortForwarder) error {
+		attempts.Add(1)
+		return errSessionDropped
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.KeepAlive(context.Background())
+	}()
+
+	p.errChan <- errSessionDropped
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("keep alive did not give up restarting the session")
+	}
+	require.Equal(t, int32(p.reconnectAttempts), attempts.Load())
+}
+
+func TestLocalURL(t *testing.T) {
+	p := newTestPortForwarder(func(*PortForwarder) error { return nil })
+
+	require.Equal(t, "http://localhost:10093/metrics", LocalURL("10093", "metrics"))
+	require.Equal(t, "http://localhost:10093/healthz", LocalURL("10093", "/healthz"))
+	require.Equal(t, "http://localhost:10093/debug/pprof/heap?debug=1", LocalURL("10093", "debug/pprof/heap?debug=1"))
+	require.Equal(t, "http://localhost:10093/", LocalURL("10093", ""))
+	require.Equal(t, "http://localhost:10093/debug/pprof/", p.URL("/debug/pprof/"))
+}
+
+func TestProbeEndpoint(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/debug/pprof/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("debug") != "1" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	port := strings.TrimPrefix(server.URL, "http://127.0.0.1:")
+
+	require.NoError(t, probeEndpoint(LocalURL(port, "healthz"), true))
+	require.NoError(t, probeEndpoint(LocalURL(port, "/debug/pprof/?debug=1"), true))
+	require.ErrorIs(t, probeEndpoint(LocalURL(port, "debug/pprof/"), true), ErrEndpointUnavailable)
+	require.ErrorIs(t, probeEndpoint(LocalURL(port, "metrics"), true), ErrEndpointUnavailable)
+}
+
+func TestProbeEndpointAcceptsAnyResponseByDefault(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+	}))
+	port := strings.TrimPrefix(server.URL, "http://127.0.0.1:")
+
+	// an agent still starting up may answer metrics with an error, which the forward works for all the same
+	require.NoError(t, probeEndpoint(LocalURL(port, "metrics"), false))
+	status = http.StatusNotFound
+	require.NoError(t, probeEndpoint(LocalURL(port, "metrics"), false))
+
+	server.Close()
+	require.Error(t, probeEndpoint(LocalURL(port, "metrics"), false))
+}
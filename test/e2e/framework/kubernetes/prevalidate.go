@@ -0,0 +1,48 @@
+package kubernetes
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+var (
+	ErrInvalidName          = fmt.Errorf("invalid kubernetes object name")
+	ErrInvalidLabelSelector = fmt.Errorf("invalid label selector")
+	ErrEmptyCommand         = fmt.Errorf("command is empty")
+)
+
+// checkPort checks a port parameter of a step, such as PortForward's RemotePort, is a usable port number
+func checkPort(parameter, port string) error {
+	value, err := strconv.Atoi(port)
+	if err != nil || value < 1 || value > 65535 {
+		return fmt.Errorf("%s \"%s\": %w", parameter, port, ErrInvalidPort)
+	}
+	return nil
+}
+
+// checkPodName checks namespace and pod are names the API server accepts, so a typo fails before the step runs
+func checkPodName(namespace, pod string) error {
+	if problems := validation.IsDNS1123Label(namespace); len(problems) > 0 {
+		return fmt.Errorf("namespace \"%s\" %s: %w", namespace, strings.Join(problems, ", "), ErrInvalidName)
+	}
+	if problems := validation.IsDNS1123Subdomain(pod); len(problems) > 0 {
+		return fmt.Errorf("pod \"%s\" %s: %w", pod, strings.Join(problems, ", "), ErrInvalidName)
+	}
+	return nil
+}
+
+// checkLabelSelector checks selector, when set, parses the way the API server parses it
+func checkLabelSelector(parameter, selector string) error {
+	if selector == "" {
+		return nil
+	}
+	_, err := labels.Parse(selector)
+	if err != nil {
+		return fmt.Errorf("%s \"%s\": %w: %w", parameter, selector, ErrInvalidLabelSelector, err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package kubernetes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPortForwardPrevalidate(t *testing.T) {
+	valid := PortForward{
+		Namespace:             "kube-system",
+		LabelSelector:         "k8s-app=retina",
+		LocalPort:             "10093",
+		RemotePort:            "10093",
+		Endpoint:              "metrics",
+		OptionalLabelAffinity: "app=agnhost-a",
+	}
+	require.NoError(t, valid.Prevalidate())
+
+	for _, port := range []string{"0", "65536", "-1", "metrics"} {
+		p := valid
+		p.RemotePort = port
+		require.ErrorIs(t, p.Prevalidate(), ErrInvalidPort, "remote port %s", port)
+	}
+
+	p := valid
+	p.LocalPort = "0"
+	require.ErrorIs(t, p.Prevalidate(), ErrInvalidPort)
+
+	p = valid
+	p.OptionalLabelAffinity = "app in agnhost"
+	require.ErrorIs(t, p.Prevalidate(), ErrInvalidLabelSelector)
+
+	p = valid
+	p.TargetPod = TargetPod{Name: "Agnhost_0"}
+	require.ErrorIs(t, p.Prevalidate(), ErrInvalidName)
+}
+
+func TestExecInPodPrevalidate(t *testing.T) {
+	require.NoError(t, (&ExecInPod{PodNamespace: "kube-system", PodName: "agnhost-a-0", Command: "nslookup bing.com"}).Prevalidate())
+	require.ErrorIs(t, (&ExecInPod{PodNamespace: "kube-system", PodName: "agnhost-a-0", Command: " "}).Prevalidate(), ErrEmptyCommand)
+	require.ErrorIs(t, (&ExecInPod{PodNamespace: "kube system", PodName: "agnhost-a-0", Command: "ls"}).Prevalidate(), ErrInvalidName)
+	require.ErrorIs(t, (&ExecInPod{PodNamespace: "kube-system", PodName: "agnhost_a_0", Command: "ls"}).Prevalidate(), ErrInvalidName)
+}
+
+func TestCreateAgnhostDaemonSetPrevalidate(t *testing.T) {
+	require.NoError(t, (&CreateAgnhostDaemonSet{AgnhostNamespace: "kube-system", AgnhostName: "agnhost-per-node"}).Prevalidate())
+	require.ErrorIs(t, (&CreateAgnhostDaemonSet{AgnhostNamespace: "kube system", AgnhostName: "agnhost-per-node"}).Prevalidate(), ErrInvalidName)
+	require.ErrorIs(t, (&CreateAgnhostDaemonSet{AgnhostNamespace: "kube-system", AgnhostName: "agnhost_per_node"}).Prevalidate(), ErrInvalidName)
+	require.ErrorIs(t, (&CreateAgnhostDaemonSet{AgnhostNamespace: "kube-system", AgnhostName: ""}).Prevalidate(), ErrInvalidName)
+	// a valid object name that is too long for the app label
+	require.ErrorIs(t, (&CreateAgnhostDaemonSet{AgnhostNamespace: "kube-system", AgnhostName: strings.Repeat("a", 64)}).Prevalidate(), ErrInvalidLabelSelector)
+}
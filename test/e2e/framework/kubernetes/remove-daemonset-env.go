@@ -0,0 +1,116 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const daemonSetUpdateTimeout = time.Minute
+
+var (
+	ErrContainerNotFound = fmt.Errorf("container not found")
+	ErrEnvNotFound       = fmt.Errorf("env var not found")
+)
+
+// RemoveDaemonSetEnv removes the EnvName env var from a container of a DaemonSet, which rolls out pods
+// without it. Run it in the background so Stop puts the env var back where it was
+type RemoveDaemonSetEnv struct {
+	KubeConfigFilePath string
+	DaemonSetNamespace string
+	DaemonSetName      string
+	ContainerName      string
+	EnvName            string
+
+	// local properties
+	removed  *v1.EnvVar
+	position int
+}
+
+func (r *RemoveDaemonSetEnv) Run() error {
+	err := r.update(func(container *v1.Container) error {
+		for i := range container.Env {
+			if container.Env[i].Name == r.EnvName {
+				r.removed = container.Env[i].DeepCopy()
+				r.position = i
+				container.Env = append(container.Env[:i], container.Env[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("env var \"%s\" of container \"%s\": %w", r.EnvName, r.ContainerName, ErrEnvNotFound)
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("removed env var \"%s\" from container \"%s\" of daemonset \"%s\"\n", r.EnvName, r.ContainerName, r.DaemonSetName)
+	return nil
+}
+
+// update applies change to the container of the DaemonSet and updates it
+func (r *RemoveDaemonSetEnv) update(change func(container *v1.Container) error) error {
+	config, err := BuildConfig(r.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), daemonSetUpdateTimeout)
+	defer cancel()
+
+	daemonSets := clientset.AppsV1().DaemonSets(r.DaemonSetNamespace)
+	daemonSet, err := daemonSets.Get(ctx, r.DaemonSetName, metaV1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting daemonset \"%s\": %w", r.DaemonSetName, err)
+	}
+
+	containers := daemonSet.Spec.Template.Spec.Containers
+	for i := range containers {
+		if containers[i].Name != r.ContainerName {
+			continue
+		}
+
+		err = change(&containers[i])
+		if err != nil {
+			return err
+		}
+
+		_, err = daemonSets.Update(ctx, daemonSet, metaV1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("error updating daemonset \"%s\": %w", r.DaemonSetName, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("container \"%s\" of daemonset \"%s\": %w", r.ContainerName, r.DaemonSetName, ErrContainerNotFound)
+}
+
+func (r *RemoveDaemonSetEnv) Prevalidate() error {
+	return nil
+}
+
+func (r *RemoveDaemonSetEnv) Stop() error {
+	if r.removed == nil {
+		return nil
+	}
+
+	err := r.update(func(container *v1.Container) error {
+		position := min(r.position, len(container.Env))
+		container.Env = append(container.Env[:position], append([]v1.EnvVar{*r.removed}, container.Env[position:]...)...)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error restoring env var \"%s\": %w", r.EnvName, err)
+	}
+
+	log.Printf("restored env var \"%s\" of container \"%s\" of daemonset \"%s\"\n", r.EnvName, r.ContainerName, r.DaemonSetName)
+	return nil
+}
@@ -0,0 +1,117 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	ErrPodRecreated        = fmt.Errorf("pod was recreated instead of restarting its container")
+	ErrPodIPChangedRestart = fmt.Errorf("pod IP changed across container restart")
+)
+
+// RestartAgnhostContainer restarts the agnhost container of PodName in place, without recreating the pod, and
+// waits for the container to be ready again. The container has to run agnhost netexec on AgnhostHTTPPort, as it's
+// restarted by asking netexec to exit. The pod has to keep its UID and IP across the restart
+type RestartAgnhostContainer struct {
+	KubeConfigFilePath string
+	PodNamespace       string
+	PodName            string
+}
+
+func (r *RestartAgnhostContainer) Run() error {
+	config, err := BuildConfig(r.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), RetryTimeoutPodsReady)
+	defer cancel()
+
+	pod, err := clientset.CoreV1().Pods(r.PodNamespace).Get(ctx, r.PodName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting pod \"%s\": %w", r.PodName, err)
+	}
+	if len(pod.Spec.Containers) == 0 {
+		return fmt.Errorf("pod \"%s\": %w", r.PodName, ErrContainerNotFound)
+	}
+	container := pod.Spec.Containers[0].Name
+
+	restarts, err := restartCount(pod, container)
+	if err != nil {
+		return err
+	}
+
+	// the exec'd curl goes down with the container, so its error only means the exit was too quick to answer
+	_, err = ExecPodContainer(ctx, clientset, config, r.PodNamespace, r.PodName, container,
+		fmt.Sprintf("curl -s -m 3 http://localhost:%d/exit?code=1", AgnhostHTTPPort))
+	if err != nil {
+		log.Printf("exit request to container \"%s\" of pod \"%s\" ended with: %v\n", container, r.PodName, err)
+	}
+
+	err = wait.PollUntilContextCancel(ctx, RetryIntervalPodsReady, true, func(ctx context.Context) (bool, error) {
+		restarted, getErr := clientset.CoreV1().Pods(r.PodNamespace).Get(ctx, r.PodName, metav1.GetOptions{})
+		if getErr != nil {
+			return false, fmt.Errorf("error getting pod \"%s\": %w", r.PodName, getErr)
+		}
+		if restarted.UID != pod.UID {
+			return false, fmt.Errorf("pod \"%s\": %w", r.PodName, ErrPodRecreated)
+		}
+
+		current, countErr := restartCount(restarted, container)
+		if countErr != nil {
+			return false, countErr
+		}
+		if current <= restarts || !isPodReady(restarted) {
+			return false, nil
+		}
+
+		if restarted.Status.PodIP != pod.Status.PodIP {
+			return false, fmt.Errorf("pod \"%s\" went from %s to %s: %w", r.PodName, pod.Status.PodIP, restarted.Status.PodIP, ErrPodIPChangedRestart)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("error waiting for container \"%s\" of pod \"%s\" to restart: %w", container, r.PodName, err)
+	}
+
+	log.Printf("restarted container \"%s\" of pod \"%s\" in place, pod IP still %s\n", container, r.PodName, pod.Status.PodIP)
+	return nil
+}
+
+func (r *RestartAgnhostContainer) Prevalidate() error {
+	return nil
+}
+
+func (r *RestartAgnhostContainer) Stop() error {
+	return nil
+}
+
+func restartCount(pod *corev1.Pod, container string) (int32, error) {
+	for i := range pod.Status.ContainerStatuses {
+		if pod.Status.ContainerStatuses[i].Name == container {
+			return pod.Status.ContainerStatuses[i].RestartCount, nil
+		}
+	}
+	return 0, fmt.Errorf("no status for container \"%s\" of pod \"%s\": %w", container, pod.Name, ErrContainerNotFound)
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+var ErrNoRetinaAgentOnNode = fmt.Errorf("no retina agent pod on node")
+
+// RestartRetinaAgent gracefully deletes the Retina agent pod on the node of PodName, as a rolling restart, an OOM
+// kill or a crash would, and waits for the deleted pod to be gone and the DaemonSet's replacement on that node
+// to be ready. A port forward to the deleted pod doesn't follow it on its own: when PortForward is set, it is
+// stopped before the restart and started again once the replacement is ready, finding the pod it forwards to anew
+type RestartRetinaAgent struct {
+	KubeConfigFilePath       string
+	RetinaDaemonSetNamespace string
+	PodNamespace             string
+	PodName                  string
+	PortForward              *PortForward
+}
+
+func (r *RestartRetinaAgent) Run() error {
+	config, err := BuildConfig(r.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), RetryTimeoutPodsReady)
+	defer cancel()
+
+	pod, err := clientset.CoreV1().Pods(r.PodNamespace).Get(ctx, r.PodName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting pod \"%s\": %w", r.PodName, err)
+	}
+	nodeName := pod.Spec.NodeName
+
+	agents, err := r.agentsOnNode(ctx, clientset, nodeName)
+	if err != nil {
+		return err
+	}
+	if len(agents) == 0 {
+		return fmt.Errorf("node \"%s\": %w", nodeName, ErrNoRetinaAgentOnNode)
+	}
+	agent := agents[0]
+
+	if r.PortForward != nil {
+		err = r.PortForward.Stop()
+		if err != nil {
+			return fmt.Errorf("error stopping port forward to retina agent pod \"%s\": %w", agent.Name, err)
+		}
+	}
+
+	err = clientset.CoreV1().Pods(r.RetinaDaemonSetNamespace).Delete(ctx, agent.Name, metav1.DeleteOptions{})
+	if err != nil {
+		return fmt.Errorf("error deleting retina agent pod \"%s\": %w", agent.Name, err)
+	}
+	log.Printf("deleted retina agent pod \"%s\" on node \"%s\"\n", agent.Name, nodeName)
+
+	err = wait.PollUntilContextCancel(ctx, RetryIntervalPodsReady, true, func(ctx context.Context) (bool, error) {
+		replacements, getErr := r.agentsOnNode(ctx, clientset, nodeName)
+		if getErr != nil {
+			return false, getErr
+		}
+		// the deleted pod is still listed while it terminates
+		if len(replacements) != 1 || replacements[0].UID == agent.UID || !isPodReady(&replacements[0]) {
+			return false, nil
+		}
+		log.Printf("ready retina agent pod \"%s\" replaced \"%s\" on node \"%s\"\n", replacements[0].Name, agent.Name, nodeName)
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("error waiting for retina agent on node \"%s\" to be replaced by a ready pod: %w", nodeName, err)
+	}
+
+	if r.PortForward != nil {
+		err = r.PortForward.restart()
+		if err != nil {
+			return fmt.Errorf("error port forwarding to the replacement of retina agent pod \"%s\": %w", agent.Name, err)
+		}
+	}
+	return nil
+}
+
+// agentsOnNode returns the Retina agent pods on the node, terminating ones included
+func (r *RestartRetinaAgent) agentsOnNode(ctx context.Context, clientset *kubernetes.Clientset, nodeName string) ([]corev1.Pod, error) {
+	pods, err := clientset.CoreV1().Pods(r.RetinaDaemonSetNamespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "k8s-app=retina",
+		FieldSelector: "spec.nodeName=" + nodeName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing retina agent pods on node \"%s\": %w", nodeName, err)
+	}
+	return pods.Items, nil
+}
+
+func (r *RestartRetinaAgent) Prevalidate() error {
+	return nil
+}
+
+func (r *RestartRetinaAgent) Stop() error {
+	return nil
+}
@@ -0,0 +1,150 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	scaleTimeout      = 5 * time.Minute
+	scalePollInterval = time.Second
+)
+
+var (
+	ErrWorkloadNotScalable = fmt.Errorf("workload kind can't be scaled")
+	ErrInvalidReplicaCount = fmt.Errorf("replica count must not be negative")
+)
+
+// ScaleWorkload sets the replicas of a StatefulSet or Deployment, up or down. With WaitForReady it waits until
+// the workload has exactly Replicas pods and all of them are ready, so pods scaled down are gone as well
+type ScaleWorkload struct {
+	ResourceType       string // TypeString of StatefulSet or Deployment
+	ResourceName       string
+	ResourceNamespace  string
+	KubeConfigFilePath string
+	Replicas           int
+	WaitForReady       bool
+}
+
+func (s *ScaleWorkload) Run() error {
+	config, err := BuildConfig(s.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), scaleTimeout)
+	defer cancel()
+
+	scale, err := s.getScale(ctx, clientset)
+	if err != nil {
+		return fmt.Errorf("error getting scale of %s \"%s\": %w", s.ResourceType, s.ResourceName, err)
+	}
+
+	previous := scale.Spec.Replicas
+	scale.Spec.Replicas = int32(s.Replicas)
+	err = s.updateScale(ctx, clientset, scale)
+	if err != nil {
+		return fmt.Errorf("error scaling %s \"%s\" to %d replicas: %w", s.ResourceType, s.ResourceName, s.Replicas, err)
+	}
+	log.Printf("scaled %s \"%s\" from %d to %d replicas\n", s.ResourceType, s.ResourceName, previous, s.Replicas)
+
+	if !s.WaitForReady {
+		return nil
+	}
+
+	err = wait.PollUntilContextCancel(ctx, scalePollInterval, true, func(ctx context.Context) (bool, error) {
+		ready, selector, statusErr := s.status(ctx, clientset)
+		if statusErr != nil {
+			return false, statusErr
+		}
+		labelSelector, selectorErr := metaV1.LabelSelectorAsSelector(selector)
+		if selectorErr != nil {
+			return false, fmt.Errorf("error parsing selector of %s \"%s\": %w", s.ResourceType, s.ResourceName, selectorErr)
+		}
+		// terminating pods are listed too, so scaling down only completes once they are gone
+		pods, listErr := clientset.CoreV1().Pods(s.ResourceNamespace).List(ctx, metaV1.ListOptions{LabelSelector: labelSelector.String()})
+		if listErr != nil {
+			return false, fmt.Errorf("error listing pods of %s \"%s\": %w", s.ResourceType, s.ResourceName, listErr)
+		}
+		replicas := len(pods.Items)
+		if replicas != s.Replicas || ready != int32(s.Replicas) {
+			log.Printf("%s \"%s\" has %d pods, %d ready, waiting for %d...\n", s.ResourceType, s.ResourceName, replicas, ready, s.Replicas)
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("error waiting for %s \"%s\" to have %d ready replicas: %w", s.ResourceType, s.ResourceName, s.Replicas, err)
+	}
+	log.Printf("%s \"%s\" has %d ready replicas\n", s.ResourceType, s.ResourceName, s.Replicas)
+
+	return nil
+}
+
+func (s *ScaleWorkload) getScale(ctx context.Context, clientset *kubernetes.Clientset) (*autoscalingv1.Scale, error) {
+	if ResourceType(s.ResourceType) == StatefulSet {
+		return clientset.AppsV1().StatefulSets(s.ResourceNamespace).GetScale(ctx, s.ResourceName, metaV1.GetOptions{}) //nolint:wrapcheck // wrapped by the caller
+	}
+	return clientset.AppsV1().Deployments(s.ResourceNamespace).GetScale(ctx, s.ResourceName, metaV1.GetOptions{}) //nolint:wrapcheck // wrapped by the caller
+}
+
+func (s *ScaleWorkload) updateScale(ctx context.Context, clientset *kubernetes.Clientset, scale *autoscalingv1.Scale) error {
+	var err error
+	if ResourceType(s.ResourceType) == StatefulSet {
+		_, err = clientset.AppsV1().StatefulSets(s.ResourceNamespace).UpdateScale(ctx, s.ResourceName, scale, metaV1.UpdateOptions{})
+	} else {
+		_, err = clientset.AppsV1().Deployments(s.ResourceNamespace).UpdateScale(ctx, s.ResourceName, scale, metaV1.UpdateOptions{})
+	}
+	return err //nolint:wrapcheck // wrapped by the caller
+}
+
+// status returns how many pods of the workload's current spec are ready, and the selector of all of its pods
+func (s *ScaleWorkload) status(ctx context.Context, clientset *kubernetes.Clientset) (ready int32, selector *metaV1.LabelSelector, err error) {
+	if ResourceType(s.ResourceType) == StatefulSet {
+		statefulSet, getErr := clientset.AppsV1().StatefulSets(s.ResourceNamespace).Get(ctx, s.ResourceName, metaV1.GetOptions{})
+		if getErr != nil {
+			return 0, nil, fmt.Errorf("error getting statefulset: %w", getErr)
+		}
+		if statefulSet.Status.ObservedGeneration < statefulSet.Generation {
+			return -1, statefulSet.Spec.Selector, nil
+		}
+		return statefulSet.Status.ReadyReplicas, statefulSet.Spec.Selector, nil
+	}
+
+	deployment, getErr := clientset.AppsV1().Deployments(s.ResourceNamespace).Get(ctx, s.ResourceName, metaV1.GetOptions{})
+	if getErr != nil {
+		return 0, nil, fmt.Errorf("error getting deployment: %w", getErr)
+	}
+	if deployment.Status.ObservedGeneration < deployment.Generation {
+		return -1, deployment.Spec.Selector, nil
+	}
+	return deployment.Status.ReadyReplicas, deployment.Spec.Selector, nil
+}
+
+func (s *ScaleWorkload) Prevalidate() error {
+	switch ResourceType(s.ResourceType) {
+	case StatefulSet, Deployment:
+	default:
+		return fmt.Errorf("%s: %w", s.ResourceType, ErrWorkloadNotScalable)
+	}
+	if s.Replicas < 0 {
+		return fmt.Errorf("%d replicas: %w", s.Replicas, ErrInvalidReplicaCount)
+	}
+	return nil
+}
+
+func (s *ScaleWorkload) Stop() error {
+	return nil
+}
@@ -0,0 +1,94 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"time"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// time allowed on top of PacketCount/PacketsPerSecond for the exec round trip and the last replies
+const icmpEchoGracePeriod = 30 * time.Second
+
+var (
+	ErrInvalidPacketRate     = fmt.Errorf("packet count and rate must be positive")
+	ErrUnexpectedPacketsSent = fmt.Errorf("unexpected number of packets transmitted")
+	ErrMissingPingStatistics = fmt.Errorf("ping statistics missing from output")
+)
+
+var pingTransmittedRegexp = regexp.MustCompile(`(\d+) packets transmitted`)
+
+// SendICMPEchoRequests sends exactly PacketCount echo requests from the pod to the destination pod's IP,
+// paced at PacketsPerSecond with iputils ping, for counter checks that need a known number of packets.
+// Lost replies don't fail the step, only a transmitted count other than PacketCount does
+type SendICMPEchoRequests struct {
+	KubeConfigFilePath   string
+	PodNamespace         string
+	PodName              string
+	DestinationNamespace string
+	DestinationPodName   string
+	PacketCount          int
+	PacketsPerSecond     int
+}
+
+func (s *SendICMPEchoRequests) Run() error {
+	config, err := BuildConfig(s.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	duration := time.Duration(s.PacketCount) * time.Second / time.Duration(s.PacketsPerSecond)
+	ctx, cancel := context.WithTimeout(context.Background(), duration+icmpEchoGracePeriod)
+	defer cancel()
+
+	destination, err := clientset.CoreV1().Pods(s.DestinationNamespace).Get(ctx, s.DestinationPodName, metaV1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting destination pod \"%s\": %w", s.DestinationPodName, err)
+	}
+
+	interval := strconv.FormatFloat(1/float64(s.PacketsPerSecond), 'f', 3, 64)
+	command := fmt.Sprintf("ping -q -n -W 1 -c %d -i %s %s", s.PacketCount, interval, destination.Status.PodIP)
+
+	// ping exits non-zero when replies are lost, which still leaves its statistics in the output
+	output, execErr := ExecPod(ctx, clientset, config, s.PodNamespace, s.PodName, command)
+
+	matches := pingTransmittedRegexp.FindSubmatch(output)
+	if matches == nil {
+		if execErr != nil {
+			return fmt.Errorf("error sending echo requests from pod \"%s\": %w", s.PodName, execErr)
+		}
+		return fmt.Errorf("output of [%s]: %w", command, ErrMissingPingStatistics)
+	}
+
+	transmitted, err := strconv.Atoi(string(matches[1]))
+	if err != nil {
+		return fmt.Errorf("error parsing transmitted packets: %w", err)
+	}
+	if transmitted != s.PacketCount {
+		return fmt.Errorf("pod \"%s\" transmitted %d of %d echo requests: %w", s.PodName, transmitted, s.PacketCount, ErrUnexpectedPacketsSent)
+	}
+
+	log.Printf("pod \"%s\" sent %d echo requests to %s over %s\n", s.PodName, transmitted, destination.Status.PodIP, duration)
+	return nil
+}
+
+func (s *SendICMPEchoRequests) Prevalidate() error {
+	if s.PacketCount <= 0 || s.PacketsPerSecond <= 0 {
+		return fmt.Errorf("%d packets at %d per second: %w", s.PacketCount, s.PacketsPerSecond, ErrInvalidPacketRate)
+	}
+	return nil
+}
+
+func (s *SendICMPEchoRequests) Stop() error {
+	return nil
+}
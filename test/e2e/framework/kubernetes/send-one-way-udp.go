@@ -0,0 +1,98 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// AgnhostNetexecHTTPPort is the port netexec serves its HTTP endpoints, including /dial, on by default
+	AgnhostNetexecHTTPPort = 8080
+
+	// netexec gives up on a UDP try after waiting at most this long for an answer
+	udpDialTimeoutPerTry = 10 * time.Second
+	udpDialGracePeriod   = 30 * time.Second
+)
+
+var (
+	ErrInvalidDatagramCount  = fmt.Errorf("datagram count must be positive")
+	ErrUnexpectedUDPResponse = fmt.Errorf("one way udp datagram was answered")
+)
+
+// netexecDialResult is the body of agnhost netexec's /dial endpoint
+type netexecDialResult struct {
+	Responses []string `json:"responses"`
+	Errors    []string `json:"errors"`
+}
+
+// SendOneWayUDP sends Datagrams UDP datagrams from the pod to DestinationPort of the destination pod, which is
+// expected to receive them without answering, e.g. agnhost netexec with --udp-port, which ignores unknown
+// payloads. The pod must run agnhost netexec, whose /dial endpoint sends the datagrams. The step fails if any
+// datagram is answered
+type SendOneWayUDP struct {
+	KubeConfigFilePath   string
+	PodNamespace         string
+	PodName              string
+	DestinationNamespace string
+	DestinationPodName   string
+	DestinationPort      int
+	Datagrams            int
+}
+
+func (s *SendOneWayUDP) Run() error {
+	config, err := BuildConfig(s.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	timeout := time.Duration(s.Datagrams) * udpDialTimeoutPerTry
+	ctx, cancel := context.WithTimeout(context.Background(), timeout+udpDialGracePeriod)
+	defer cancel()
+
+	destination, err := clientset.CoreV1().Pods(s.DestinationNamespace).Get(ctx, s.DestinationPodName, metaV1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting destination pod \"%s\": %w", s.DestinationPodName, err)
+	}
+
+	// every try sends the payload once and waits for an answer that never comes
+	command := fmt.Sprintf("curl -s -m %d http://localhost:%d/dial?request=one-way&protocol=udp&host=%s&port=%d&tries=%d",
+		int(timeout.Seconds()), AgnhostNetexecHTTPPort, destination.Status.PodIP, s.DestinationPort, s.Datagrams)
+	output, err := ExecPod(ctx, clientset, config, s.PodNamespace, s.PodName, command)
+	if err != nil {
+		return fmt.Errorf("error sending udp datagrams from pod \"%s\": %w", s.PodName, err)
+	}
+
+	result := netexecDialResult{}
+	err = json.Unmarshal(output, &result)
+	if err != nil {
+		return fmt.Errorf("error parsing dial result \"%s\": %w", string(output), err)
+	}
+	if len(result.Responses) > 0 {
+		return fmt.Errorf("pod \"%s\" got %v from %s:%d: %w", s.PodName, result.Responses, destination.Status.PodIP, s.DestinationPort, ErrUnexpectedUDPResponse)
+	}
+
+	log.Printf("pod \"%s\" sent %d udp datagrams to %s:%d without an answer\n", s.PodName, s.Datagrams, destination.Status.PodIP, s.DestinationPort)
+	return nil
+}
+
+func (s *SendOneWayUDP) Prevalidate() error {
+	if s.Datagrams <= 0 {
+		return fmt.Errorf("%d datagrams: %w", s.Datagrams, ErrInvalidDatagramCount)
+	}
+	return nil
+}
+
+func (s *SendOneWayUDP) Stop() error {
+	return nil
+}
@@ -0,0 +1,202 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+
+	"github.com/microsoft/retina/test/e2e/framework/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	retinaConfigKey = "config.yaml"
+	nodeOSLabel     = "kubernetes.io/os"
+)
+
+var ErrInvalidRetinaConfig = fmt.Errorf("retina config can't be read")
+
+// SkipUnlessNodeOS is a scenario precondition skipping the scenario unless one of the cluster's nodes runs OS,
+// e.g. "linux" or "windows", going by their kubernetes.io/os label
+type SkipUnlessNodeOS struct {
+	OS                 string
+	KubeConfigFilePath string
+}
+
+func (s *SkipUnlessNodeOS) Run() error {
+	config, err := BuildConfig(s.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	nodes, err := clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{LabelSelector: nodeOSLabel + "=" + s.OS})
+	if err != nil {
+		return fmt.Errorf("error listing %s nodes: %w", s.OS, err)
+	}
+	if len(nodes.Items) == 0 {
+		return types.SkipScenario(fmt.Sprintf("no %s nodes in the cluster", s.OS))
+	}
+
+	log.Printf("cluster has %d %s nodes\n", len(nodes.Items), s.OS)
+	return nil
+}
+
+func (s *SkipUnlessNodeOS) Prevalidate() error {
+	return nil
+}
+
+func (s *SkipUnlessNodeOS) Stop() error {
+	return nil
+}
+
+// SkipUnlessRetinaFeature is a scenario precondition skipping the scenario unless Feature, a boolean of the
+// agent's config such as "enablePodLevel" for advanced metrics, is enabled in the config map Retina was installed
+// with, e.g. retina-config in kube-system. It fails when the config map can't be read, as Retina is then likely not
+// installed at all
+type SkipUnlessRetinaFeature struct {
+	Feature            string
+	ConfigMapName      string
+	ConfigMapNamespace string
+	KubeConfigFilePath string
+}
+
+func (s *SkipUnlessRetinaFeature) Run() error {
+	config, err := BuildConfig(s.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	configMap, err := clientset.CoreV1().ConfigMaps(s.ConfigMapNamespace).Get(context.TODO(), s.ConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting config map \"%s\" in namespace \"%s\": %w", s.ConfigMapName, s.ConfigMapNamespace, err)
+	}
+
+	agentConfig := map[string]any{}
+	err = yaml.Unmarshal([]byte(configMap.Data[retinaConfigKey]), &agentConfig)
+	if err != nil {
+		return fmt.Errorf("config map \"%s\" in namespace \"%s\": %w: %w", s.ConfigMapName, s.ConfigMapNamespace, ErrInvalidRetinaConfig, err)
+	}
+
+	if enabled, _ := agentConfig[s.Feature].(bool); !enabled {
+		return types.SkipScenario(fmt.Sprintf("retina has %s disabled", s.Feature))
+	}
+
+	log.Printf("retina has %s enabled\n", s.Feature)
+	return nil
+}
+
+func (s *SkipUnlessRetinaFeature) Prevalidate() error {
+	return nil
+}
+
+func (s *SkipUnlessRetinaFeature) Stop() error {
+	return nil
+}
+
+// SkipUnlessPods is a scenario precondition skipping the scenario unless PodNamespace has pods matching
+// LabelSelector, e.g. Cilium agents labeled k8s-app=cilium in kube-system for scenarios of the Cilium dataplane,
+// or the Retina operator for those needing it
+type SkipUnlessPods struct {
+	PodNamespace       string
+	LabelSelector      string
+	KubeConfigFilePath string
+}
+
+func (s *SkipUnlessPods) Run() error {
+	config, err := BuildConfig(s.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	pods, err := clientset.CoreV1().Pods(s.PodNamespace).List(context.TODO(), metav1.ListOptions{LabelSelector: s.LabelSelector})
+	if err != nil {
+		return fmt.Errorf("error listing pods with label \"%s\" in namespace \"%s\": %w", s.LabelSelector, s.PodNamespace, err)
+	}
+	if len(pods.Items) == 0 {
+		return types.SkipScenario(fmt.Sprintf("no pods with label %s in namespace %s", s.LabelSelector, s.PodNamespace))
+	}
+
+	log.Printf("namespace %s has %d pods with label %s\n", s.PodNamespace, len(pods.Items), s.LabelSelector)
+	return nil
+}
+
+func (s *SkipUnlessPods) Prevalidate() error {
+	return checkLabelSelector("LabelSelector", s.LabelSelector)
+}
+
+func (s *SkipUnlessPods) Stop() error {
+	return nil
+}
+
+// SkipUnlessDualStack is a scenario precondition skipping the scenario unless one of the cluster's nodes has both
+// an IPv4 and an IPv6 pod CIDR, as nodes of a dual-stack cluster do
+type SkipUnlessDualStack struct {
+	KubeConfigFilePath string
+}
+
+func (s *SkipUnlessDualStack) Run() error {
+	config, err := BuildConfig(s.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	nodes, err := clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("error listing nodes: %w", err)
+	}
+	for i := range nodes.Items {
+		if dualStackCIDRs(nodes.Items[i].Spec.PodCIDRs) {
+			log.Printf("node %s has dual-stack pod CIDRs %v\n", nodes.Items[i].Name, nodes.Items[i].Spec.PodCIDRs)
+			return nil
+		}
+	}
+	return types.SkipScenario("no node has both IPv4 and IPv6 pod CIDRs")
+}
+
+// dualStackCIDRs reports whether cidrs has both an IPv4 and an IPv6 CIDR
+func dualStackCIDRs(cidrs []string) bool {
+	var v4, v6 bool
+	for _, cidr := range cidrs {
+		ip, _, err := net.ParseCIDR(cidr)
+		if err != nil {
+			continue
+		}
+		if ip.To4() != nil {
+			v4 = true
+		} else {
+			v6 = true
+		}
+	}
+	return v4 && v6
+}
+
+func (s *SkipUnlessDualStack) Prevalidate() error {
+	return nil
+}
+
+func (s *SkipUnlessDualStack) Stop() error {
+	return nil
+}
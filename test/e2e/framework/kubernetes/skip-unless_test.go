@@ -0,0 +1,16 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDualStackCIDRs(t *testing.T) {
+	require.True(t, dualStackCIDRs([]string{"10.244.0.0/24", "fd00:10:244::/64"}))
+	require.True(t, dualStackCIDRs([]string{"fd00:10:244::/64", "10.244.0.0/24"}))
+	require.False(t, dualStackCIDRs([]string{"10.244.0.0/24"}))
+	require.False(t, dualStackCIDRs([]string{"fd00:10:244::/64"}))
+	require.False(t, dualStackCIDRs([]string{"10.244.0.0/24", "not a cidr"}))
+	require.False(t, dualStackCIDRs(nil))
+}
@@ -23,11 +23,20 @@ type UpgradeRetinaHelmChart struct {
 	ChartPath          string
 	TagEnv             string
 	ValuesFile         string
+
+	// SetValues are applied on top of ValuesFile, using the same
+	// "key=value" syntax as helm's --set flag
+	SetValues []string
+
+	// SkipWait returns as soon as the release is upgraded instead of waiting
+	// for the rollout, for upgrades that are expected to leave pods unready
+	SkipWait bool
 }
 
 func (u *UpgradeRetinaHelmChart) Run() error {
 	settings := cli.New()
 	settings.KubeConfig = u.KubeConfigFilePath
+	settings.KubeContext = KubeConfigContext(u.KubeConfigFilePath)
 	actionConfig := new(action.Configuration)
 
 	err := actionConfig.Init(settings.RESTClientGetter(), u.Namespace, os.Getenv("HELM_DRIVER"), log.Printf)
@@ -36,8 +45,8 @@ func (u *UpgradeRetinaHelmChart) Run() error {
 	}
 
 	client := action.NewUpgrade(actionConfig)
-	client.Wait = true
-	client.WaitForJobs = true
+	client.Wait = !u.SkipWait
+	client.WaitForJobs = !u.SkipWait
 	client.Timeout = upgradeTimeout
 
 	// Create a new Get action
@@ -55,6 +64,7 @@ func (u *UpgradeRetinaHelmChart) Run() error {
 	// enable advanced metrics profile
 	options := helmValues.Options{
 		ValueFiles: []string{u.ValuesFile},
+		Values:     u.SetValues,
 	}
 	provider := getter.All(settings)
 	values, err := options.MergeValues(provider)
@@ -0,0 +1,57 @@
+package kubernetes
+
+import (
+	"fmt"
+	"log"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+var ErrContextNotFound = fmt.Errorf("context not found in kubeconfig")
+
+// UseKubeConfigContext has every step after it that uses the kubeconfig file reach the cluster of its ContextName
+// context rather than the file's current context, once it validated the context exists and its cluster answers
+type UseKubeConfigContext struct {
+	KubeConfigFilePath string
+	ContextName        string
+}
+
+func (u *UseKubeConfigContext) Run() error {
+	kubeConfig, err := clientcmd.LoadFromFile(u.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error loading kubeconfig \"%s\": %w", u.KubeConfigFilePath, err)
+	}
+
+	kubeContext, exists := kubeConfig.Contexts[u.ContextName]
+	if !exists {
+		return fmt.Errorf("context \"%s\" in \"%s\": %w", u.ContextName, u.KubeConfigFilePath, ErrContextNotFound)
+	}
+
+	config, err := buildConfig(u.KubeConfigFilePath, u.ContextName)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	version, err := clientset.Discovery().ServerVersion()
+	if err != nil {
+		return fmt.Errorf("error reaching cluster \"%s\" of context \"%s\": %w", kubeContext.Cluster, u.ContextName, err)
+	}
+
+	selectContext(u.KubeConfigFilePath, u.ContextName)
+	log.Printf("steps using \"%s\" now run against cluster \"%s\" of context \"%s\", running Kubernetes %s\n", u.KubeConfigFilePath, kubeContext.Cluster, u.ContextName, version.GitVersion)
+	return nil
+}
+
+func (u *UseKubeConfigContext) Prevalidate() error {
+	return nil
+}
+
+func (u *UseKubeConfigContext) Stop() error {
+	return nil
+}
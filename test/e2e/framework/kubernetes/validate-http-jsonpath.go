@@ -0,0 +1,108 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"k8s.io/client-go/util/jsonpath"
+)
+
+var (
+	ErrUnexpectedStatusCode = fmt.Errorf("unexpected status code")
+	ErrJSONPathMismatch     = fmt.Errorf("jsonpath result does not match expected value")
+)
+
+// ValidateHTTPJSONPath fetches an arbitrary JSON endpoint on a port-forwarded pod, such as an agent
+// debug endpoint, and asserts the JSONPath expression evaluates to ExpectedValue. Multiple results are
+// joined by a space, the same as kubectl's -o jsonpath output.
+type ValidateHTTPJSONPath struct {
+	LocalPort     string
+	Endpoint      string
+	JSONPath      string
+	ExpectedValue string
+}
+
+func (v *ValidateHTTPJSONPath) Run() error {
+	url := LocalURL(v.LocalPort, v.Endpoint)
+
+	parser := jsonpath.New("validate-http-jsonpath")
+	err := parser.Parse(v.JSONPath)
+	if err != nil {
+		return fmt.Errorf("error parsing jsonpath \"%s\": %w", v.JSONPath, err)
+	}
+
+	err = defaultRetrier.Do(context.Background(), func() error {
+		return v.check(url, parser)
+	})
+	if err != nil {
+		return fmt.Errorf("jsonpath %s on %s never matched: %w", v.JSONPath, url, err)
+	}
+	log.Printf("jsonpath %s on %s matched \"%s\"", v.JSONPath, url, v.ExpectedValue)
+
+	return nil
+}
+
+// check fetches url once and compares the result of the JSONPath expression to ExpectedValue
+func (v *ValidateHTTPJSONPath) check(url string, parser *jsonpath.JSONPath) error {
+	got, err := evaluateJSONPath(url, parser)
+	if err != nil {
+		log.Printf("failed to evaluate jsonpath %s on %s: %v", v.JSONPath, url, err)
+		return err
+	}
+
+	if got != v.ExpectedValue {
+		log.Printf("jsonpath %s on %s returned \"%s\", expected \"%s\"", v.JSONPath, url, got, v.ExpectedValue)
+		return fmt.Errorf("got \"%s\", expected \"%s\": %w", got, v.ExpectedValue, ErrJSONPathMismatch)
+	}
+	return nil
+}
+
+func evaluateJSONPath(url string, parser *jsonpath.JSONPath) (string, error) {
+	client := http.Client{Timeout: defaultHTTPClientTimeout}
+	resp, err := client.Get(url) //nolint
+	if err != nil {
+		return "", fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP request returned %s: %w", resp.Status, ErrUnexpectedStatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("error reading response body: %w", err)
+	}
+
+	var data interface{}
+	err = json.Unmarshal(body, &data)
+	if err != nil {
+		return "", fmt.Errorf("error decoding JSON response: %w", err)
+	}
+
+	var buf bytes.Buffer
+	err = parser.Execute(&buf, data)
+	if err != nil {
+		return "", fmt.Errorf("error executing jsonpath: %w", err)
+	}
+
+	return strings.TrimSpace(buf.String()), nil
+}
+
+func (v *ValidateHTTPJSONPath) Prevalidate() error {
+	err := jsonpath.New("validate-http-jsonpath").Parse(v.JSONPath)
+	if err != nil {
+		return fmt.Errorf("error parsing jsonpath \"%s\": %w", v.JSONPath, err)
+	}
+	return nil
+}
+
+func (v *ValidateHTTPJSONPath) Stop() error {
+	return nil
+}
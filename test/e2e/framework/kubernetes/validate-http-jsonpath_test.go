@@ -0,0 +1,130 @@
+package kubernetes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+const pluginStatusBody = `{"plugins":[{"name":"packetparser","attached":true},{"name":"dropreason","attached":false}]}`
+
+func newJSONServer(t *testing.T, status int, body string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestValidateHTTPJSONPathCheck(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		body          string
+		jsonPath      string
+		expectedValue string
+		wantErr       bool
+		wantErrIs     error
+	}{
+		{
+			name:          "match",
+			status:        http.StatusOK,
+			body:          pluginStatusBody,
+			jsonPath:      `{.plugins[?(@.name=="packetparser")].attached}`,
+			expectedValue: "true",
+		},
+		{
+			name:          "multiple results are joined by a space",
+			status:        http.StatusOK,
+			body:          pluginStatusBody,
+			jsonPath:      `{.plugins[*].name}`,
+			expectedValue: "packetparser dropreason",
+		},
+		{
+			name:          "mismatch",
+			status:        http.StatusOK,
+			body:          pluginStatusBody,
+			jsonPath:      `{.plugins[?(@.name=="dropreason")].attached}`,
+			expectedValue: "true",
+			wantErr:       true,
+			wantErrIs:     ErrJSONPathMismatch,
+		},
+		{
+			name:          "missing path",
+			status:        http.StatusOK,
+			body:          pluginStatusBody,
+			jsonPath:      `{.maps}`,
+			expectedValue: "true",
+			wantErr:       true,
+		},
+		{
+			name:          "not JSON",
+			status:        http.StatusOK,
+			body:          "# HELP networkobservability_drop_count Total dropped packets",
+			jsonPath:      `{.plugins}`,
+			expectedValue: "true",
+			wantErr:       true,
+		},
+		{
+			name:          "error status",
+			status:        http.StatusNotFound,
+			body:          pluginStatusBody,
+			jsonPath:      `{.plugins}`,
+			expectedValue: "true",
+			wantErr:       true,
+			wantErrIs:     ErrUnexpectedStatusCode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newJSONServer(t, tt.status, tt.body)
+			v := &ValidateHTTPJSONPath{
+				JSONPath:      tt.jsonPath,
+				ExpectedValue: tt.expectedValue,
+			}
+			require.NoError(t, v.Prevalidate())
+
+			parser := jsonpath.New(tt.name)
+			require.NoError(t, parser.Parse(tt.jsonPath))
+
+			err := v.check(srv.URL, parser)
+			if !tt.wantErr {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			if tt.wantErrIs != nil {
+				require.ErrorIs(t, err, tt.wantErrIs)
+			} else {
+				require.NotErrorIs(t, err, ErrJSONPathMismatch)
+			}
+		})
+	}
+}
+
+func TestValidateHTTPJSONPathRun(t *testing.T) {
+	srv := newJSONServer(t, http.StatusOK, pluginStatusBody)
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	v := &ValidateHTTPJSONPath{
+		LocalPort:     u.Port(),
+		Endpoint:      "/debug/plugins",
+		JSONPath:      `{.plugins[?(@.name=="packetparser")].attached}`,
+		ExpectedValue: "true",
+	}
+	require.NoError(t, v.Prevalidate())
+	require.NoError(t, v.Run())
+}
+
+func TestValidateHTTPJSONPathPrevalidate(t *testing.T) {
+	v := &ValidateHTTPJSONPath{JSONPath: "{.plugins["}
+	require.Error(t, v.Prevalidate())
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
@@ -19,7 +21,9 @@ const (
 	printInterval = 5 // print to stdout every 5 iterations
 )
 
-func WaitForPodReady(ctx context.Context, clientset *kubernetes.Clientset, namespace, labelSelector string) error {
+// WaitForPodsRunning waits for every pod matching labelSelector to be in the Running phase, which a pod reaches once
+// its containers started, whether or not they're ready yet. It fails as soon as one of them restarts
+func WaitForPodsRunning(ctx context.Context, clientset *kubernetes.Clientset, namespace, labelSelector string) error {
 	podReadyMap := make(map[string]bool)
 
 	printIterator := 0
@@ -77,3 +81,99 @@ func WaitForPodReady(ctx context.Context, clientset *kubernetes.Clientset, names
 	}
 	return nil
 }
+
+var ErrPodNotReady = fmt.Errorf("pod is not ready")
+
+// WaitForPodReady waits for pods to have their Ready condition true, for steps that need a pod to serve or run
+// commands rather than sleeping a while after creating it. PodSelector is either a pod's name, like the StatefulSet
+// pod "agnhost-0" by its ordinal, or a label selector like "app=agnhost", in which case every pod matching it must
+// be ready. Pod names can't contain "=", "!", "," or parentheses, so a selector is told apart by having one of them.
+// The step fails once Timeout is up, RetryTimeoutPodsReady if it's zero
+type WaitForPodReady struct {
+	KubeConfigFilePath string
+	PodNamespace       string
+	PodSelector        string
+	Timeout            time.Duration
+}
+
+func (w *WaitForPodReady) Run() error {
+	return w.RunContext(context.Background())
+}
+
+// RunContext waits like Run, giving up once ctx is cancelled
+func (w *WaitForPodReady) RunContext(ctx context.Context) error {
+	config, err := BuildConfig(w.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	timeout := w.Timeout
+	if timeout == 0 {
+		timeout = RetryTimeoutPodsReady
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var notReady []string
+	err = wait.PollUntilContextCancel(ctx, RetryIntervalPodsReady, true, func(ctx context.Context) (bool, error) {
+		pods, listErr := w.pods(ctx, clientset)
+		if listErr != nil {
+			return false, listErr
+		}
+
+		notReady = notReady[:0]
+		for i := range pods {
+			if !isPodReady(&pods[i]) {
+				notReady = append(notReady, pods[i].Name)
+			}
+		}
+		if len(pods) == 0 || len(notReady) > 0 {
+			log.Printf("%d of %d pods \"%s\" in namespace \"%s\" are ready. Waiting...\n", len(pods)-len(notReady), len(pods), w.PodSelector, w.PodNamespace)
+			return false, nil
+		}
+
+		log.Printf("all %d pods \"%s\" in namespace \"%s\" are ready\n", len(pods), w.PodSelector, w.PodNamespace)
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("pods %v of \"%s\" in namespace \"%s\" not ready within %s: %w: %w", notReady, w.PodSelector, w.PodNamespace, timeout, ErrPodNotReady, err)
+	}
+	return nil
+}
+
+// pods returns the pod named PodSelector, or none while it doesn't exist yet, or the pods it selects by label
+func (w *WaitForPodReady) pods(ctx context.Context, clientset *kubernetes.Clientset) ([]corev1.Pod, error) {
+	if !isLabelSelector(w.PodSelector) {
+		pod, err := clientset.CoreV1().Pods(w.PodNamespace).Get(ctx, w.PodSelector, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error getting pod \"%s\": %w", w.PodSelector, err)
+		}
+		return []corev1.Pod{*pod}, nil
+	}
+
+	podList, err := clientset.CoreV1().Pods(w.PodNamespace).List(ctx, metav1.ListOptions{LabelSelector: w.PodSelector})
+	if err != nil {
+		return nil, fmt.Errorf("error listing pods \"%s\": %w", w.PodSelector, err)
+	}
+	return podList.Items, nil
+}
+
+func (w *WaitForPodReady) Prevalidate() error {
+	return nil
+}
+
+func (w *WaitForPodReady) Stop() error {
+	return nil
+}
+
+func isLabelSelector(podSelector string) bool {
+	return strings.ContainsAny(podSelector, "=!,()")
+}
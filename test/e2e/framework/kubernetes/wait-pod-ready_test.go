@@ -0,0 +1,16 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsLabelSelector(t *testing.T) {
+	for _, name := range []string{"agnhost-0", "agnhost-basic-dns-1", "coredns.kube-system"} {
+		require.False(t, isLabelSelector(name), name)
+	}
+	for _, selector := range []string{"app=agnhost", "app!=agnhost", "app=agnhost,tier=backend", "app in (agnhost)", "!app"} {
+		require.True(t, isLabelSelector(selector), selector)
+	}
+}
@@ -0,0 +1,107 @@
+package prom
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	promclient "github.com/prometheus/client_model/go"
+)
+
+const defaultValidateAbsentInterval = 5 * time.Second
+
+var (
+	ErrMetricPresent          = fmt.Errorf("metric expected to be absent is present")
+	ErrMetricPresentWithValue = fmt.Errorf("metric expected to be absent is present with a value")
+	ErrMetricPresentAsZero    = fmt.Errorf("metric expected to be absent is present with a zero value")
+)
+
+// ValidateMetricAbsent scrapes the port forwarded agent's metrics endpoint and fails if MetricName has a series
+// with all of Labels, e.g. for traffic a policy drops before the agent sees it. A series with a zero value counts
+// as present unless AllowZeroValue is set, and the error tells either case apart: ErrMetricPresentAsZero for a
+// series the agent registered without counting anything, ErrMetricPresentWithValue for one that counted. With For
+// set, it keeps scraping every Interval, 5 seconds by default, until For has passed, for series that might only
+// show up some time after what should not have produced them
+type ValidateMetricAbsent struct {
+	PortForwardedRetinaPort string
+	MetricName              string
+	Labels                  map[string]string
+	AllowZeroValue          bool
+	For                     time.Duration
+	Interval                time.Duration
+}
+
+func (v *ValidateMetricAbsent) Run() error {
+	interval := v.Interval
+	if interval == 0 {
+		interval = defaultValidateAbsentInterval
+	}
+
+	promAddress := kubernetes.LocalURL(v.PortForwardedRetinaPort, "metrics")
+	deadline := time.Now().Add(v.For)
+	scrapes := 0
+	for {
+		series, err := GetMetricsMatchingLabels(promAddress, v.MetricName, v.Labels)
+		if err != nil {
+			return fmt.Errorf("failed to scrape %s: %w", v.MetricName, err)
+		}
+		scrapes++
+
+		err = v.checkAbsent(series)
+		if err != nil {
+			return err
+		}
+
+		if !time.Now().Add(interval).Before(deadline) {
+			break
+		}
+		time.Sleep(interval)
+	}
+
+	log.Printf("found no %s series with %s over %d scrapes\n", v.MetricName, formatLabels(v.Labels), scrapes)
+	return nil
+}
+
+func (v *ValidateMetricAbsent) checkAbsent(series []*promclient.Metric) error {
+	for _, metric := range series {
+		labels := map[string]string{}
+		for _, label := range metric.GetLabel() {
+			labels[label.GetName()] = label.GetValue()
+		}
+
+		if !isZero(metric) {
+			return fmt.Errorf("%s %s = %s: %w: %w", v.MetricName, formatLabels(labels), formatValue(metric), ErrMetricPresent, ErrMetricPresentWithValue)
+		}
+		if !v.AllowZeroValue {
+			return fmt.Errorf("%s %s = %s: %w: %w", v.MetricName, formatLabels(labels), formatValue(metric), ErrMetricPresent, ErrMetricPresentAsZero)
+		}
+		log.Printf("allowing %s %s with a zero value\n", v.MetricName, formatLabels(labels))
+	}
+	return nil
+}
+
+func isZero(metric *promclient.Metric) bool {
+	switch {
+	case metric.GetCounter() != nil:
+		return metric.GetCounter().GetValue() == 0
+	case metric.GetGauge() != nil:
+		return metric.GetGauge().GetValue() == 0
+	case metric.GetUntyped() != nil:
+		return metric.GetUntyped().GetValue() == 0
+	case metric.GetHistogram() != nil:
+		return metric.GetHistogram().GetSampleCount() == 0
+	case metric.GetSummary() != nil:
+		return metric.GetSummary().GetSampleCount() == 0
+	default:
+		return false
+	}
+}
+
+func (v *ValidateMetricAbsent) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateMetricAbsent) Stop() error {
+	return nil
+}
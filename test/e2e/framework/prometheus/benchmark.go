@@ -0,0 +1,121 @@
+package prom
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	promclient "github.com/prometheus/client_model/go"
+)
+
+const defaultBenchmarkIterations = 10
+
+var (
+	ErrScrapeTooSlow    = fmt.Errorf("metrics scrape latency is above the threshold")
+	ErrScrapeTooLarge   = fmt.Errorf("metrics payload is above the threshold")
+	ErrInvalidBenchmark = fmt.Errorf("invalid benchmark")
+)
+
+// BenchmarkMetricsScrape scrapes the port forwarded agent's metrics endpoint Iterations times, 10 when unset, one
+// after the other, and fails if the p50 or p95 latency of a scrape is above MaxP50 or MaxP95, or the largest
+// payload above MaxBytes, so CI catches the endpoint slowing down as the agent exports more series. Any threshold
+// left unset isn't checked. With Warmup, a first scrape runs before the measured ones, as the first is often
+// slower. The measurements are logged, and reported whether the step passes or not
+type BenchmarkMetricsScrape struct {
+	PortForwardedRetinaPort string
+	Iterations              int
+	Warmup                  bool
+	MaxP50                  time.Duration
+	MaxP95                  time.Duration
+	MaxBytes                int
+
+	measurements map[string]float64
+}
+
+func (b *BenchmarkMetricsScrape) Run() error {
+	iterations := b.Iterations
+	if iterations == 0 {
+		iterations = defaultBenchmarkIterations
+	}
+
+	url := kubernetes.LocalURL(b.PortForwardedRetinaPort, "metrics")
+	if b.Warmup {
+		_, _, err := scrapeURL(context.Background(), url)
+		if err != nil {
+			return fmt.Errorf("warmup scrape of %s failed: %w", url, err)
+		}
+	}
+
+	latencies := make([]time.Duration, 0, iterations)
+	maxBytes, series := 0, 0
+	for i := range iterations {
+		start := time.Now()
+		families, size, err := scrapeURL(context.Background(), url)
+		if err != nil {
+			return fmt.Errorf("scrape %d of %s failed: %w", i+1, url, err)
+		}
+		latencies = append(latencies, time.Since(start))
+		maxBytes = max(maxBytes, size)
+		series = countSeries(families)
+	}
+
+	p50, p95 := percentile(latencies, 0.5), percentile(latencies, 0.95)
+	b.measurements = map[string]float64{
+		"iterations":  float64(iterations),
+		"p50_seconds": p50.Seconds(),
+		"p95_seconds": p95.Seconds(),
+		"max_bytes":   float64(maxBytes),
+		"series":      float64(series),
+	}
+	log.Printf("%d scrapes of %s with %d series: p50 %s, p95 %s, up to %d bytes\n", iterations, url, series, p50, p95, maxBytes)
+
+	if b.MaxP50 > 0 && p50 > b.MaxP50 {
+		return fmt.Errorf("p50 %s, expected at most %s: %w", p50, b.MaxP50, ErrScrapeTooSlow)
+	}
+	if b.MaxP95 > 0 && p95 > b.MaxP95 {
+		return fmt.Errorf("p95 %s, expected at most %s: %w", p95, b.MaxP95, ErrScrapeTooSlow)
+	}
+	if b.MaxBytes > 0 && maxBytes > b.MaxBytes {
+		return fmt.Errorf("%d bytes, expected at most %d: %w", maxBytes, b.MaxBytes, ErrScrapeTooLarge)
+	}
+	return nil
+}
+
+// Measurements are the latencies, payload size and series count of the last run, for the job's report
+func (b *BenchmarkMetricsScrape) Measurements() map[string]float64 {
+	return b.measurements
+}
+
+func countSeries(families map[string]*promclient.MetricFamily) int {
+	series := 0
+	for _, family := range families {
+		series += len(family.GetMetric())
+	}
+	return series
+}
+
+// percentile is the nearest-rank p-th percentile of latencies, e.g. 0.95 for p95
+func percentile(latencies []time.Duration, p float64) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(latencies)
+	slices.Sort(sorted)
+	rank := int(math.Ceil(p * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+func (b *BenchmarkMetricsScrape) Prevalidate() error {
+	if b.Iterations < 0 {
+		return fmt.Errorf("iterations %d: %w", b.Iterations, ErrInvalidBenchmark)
+	}
+	return nil
+}
+
+func (b *BenchmarkMetricsScrape) Stop() error {
+	return nil
+}
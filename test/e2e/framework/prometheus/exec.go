@@ -0,0 +1,107 @@
+package prom
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	promclient "github.com/prometheus/client_model/go"
+	"k8s.io/apimachinery/pkg/util/wait"
+	k8s "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// ScrapeMetricsByExec fetches the metrics of an agent pod by running curl against its metrics port inside the pod,
+// for clusters whose RBAC allows exec but not port-forward
+func ScrapeMetricsByExec(ctx context.Context, clientset *k8s.Clientset, config *rest.Config, namespace, podName string, port int) (map[string]*promclient.MetricFamily, error) {
+	output, err := kubernetes.ExecPod(ctx, clientset, config, namespace, podName, fmt.Sprintf("curl -s -f http://localhost:%d/metrics", port))
+	if err != nil {
+		return nil, fmt.Errorf("failed to curl metrics in pod %s/%s: %w", namespace, podName, err)
+	}
+
+	metrics, err := getAllPrometheusMetricsFromBuffer(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse metrics from pod %s/%s: %w", namespace, podName, err)
+	}
+	return metrics, nil
+}
+
+// WaitForMetricByExec is WaitForMetric without a port forward. It scrapes the agent pod with LabelSelector in
+// Namespace on the node of a pod with OptionalLabelAffinity, found in every namespace with
+// OptionalLabelAffinityAllNamespaces, through ScrapeMetricsByExec on RetinaPort
+type WaitForMetricByExec struct {
+	KubeConfigFilePath                 string
+	Namespace                          string
+	LabelSelector                      string
+	OptionalLabelAffinity              string
+	OptionalLabelAffinityAllNamespaces bool
+	RetinaPort                         int
+	MetricName                         string
+	Labels                             map[string]string
+	Timeout                            time.Duration
+	Interval                           time.Duration
+}
+
+func (w *WaitForMetricByExec) Run() error {
+	return w.RunContext(context.Background())
+}
+
+// RunContext polls like Run, giving up once ctx is cancelled
+func (w *WaitForMetricByExec) RunContext(ctx context.Context) error {
+	timeout := w.Timeout
+	if timeout == 0 {
+		timeout = defaultWaitForMetricTimeout
+	}
+	interval := w.Interval
+	if interval == 0 {
+		interval = defaultWaitForMetricInterval
+	}
+
+	config, err := kubernetes.BuildConfig(w.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := k8s.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	podName, err := kubernetes.FindPodWithAffinity(ctx, clientset, w.Namespace, w.LabelSelector, w.OptionalLabelAffinity, w.OptionalLabelAffinityAllNamespaces)
+	if err != nil {
+		return fmt.Errorf("could not find pod with affinity: %w", err)
+	}
+
+	start := time.Now()
+	var lastErr error
+	err = wait.PollUntilContextCancel(ctx, interval, true, func(ctx context.Context) (bool, error) {
+		metrics, scrapeErr := ScrapeMetricsByExec(ctx, clientset, config, w.Namespace, podName, w.RetinaPort)
+		if scrapeErr != nil {
+			lastErr = scrapeErr
+			return false, nil
+		}
+
+		lastErr = verifyMetricWithLabels(w.MetricName, metrics, w.Labels)
+		return lastErr == nil, nil
+	})
+	if err != nil {
+		return fmt.Errorf("%w after %s: %w", ErrMetricTimeout, time.Since(start).Round(time.Second), errors.Join(err, lastErr))
+	}
+
+	log.Printf("found %s series matching %s in pod %s/%s after %s\n", w.MetricName, formatLabels(w.Labels), w.Namespace, podName, time.Since(start).Round(time.Second))
+	return nil
+}
+
+func (w *WaitForMetricByExec) Prevalidate() error {
+	return nil
+}
+
+func (w *WaitForMetricByExec) Stop() error {
+	return nil
+}
@@ -0,0 +1,250 @@
+package prom
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	promclient "github.com/prometheus/client_model/go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	k8s "k8s.io/client-go/kubernetes"
+)
+
+var (
+	ErrNoAgentPods       = fmt.Errorf("no running agent pods")
+	ErrAgentsUnreachable = fmt.Errorf("agent pods couldn't be scraped")
+	ErrFleetSumTooLow    = fmt.Errorf("metric summed over the agents is below the expected value")
+)
+
+// AgentMetrics are the metric families scraped from each agent pod by name, and the error of each pod that
+// couldn't be scraped
+type AgentMetrics struct {
+	Families    map[string]map[string]*promclient.MetricFamily
+	Unreachable map[string]error
+}
+
+// ScrapeAgents scrapes endpoint, e.g. "metrics", of each pod forwarded to, with ScrapeMetrics, so a caller can
+// look at the metrics of every agent of the cluster rather than the one a PortForward picked
+func ScrapeAgents(forwards map[string]*kubernetes.PortForwarder, endpoint string) *AgentMetrics {
+	metrics := &AgentMetrics{
+		Families:    make(map[string]map[string]*promclient.MetricFamily),
+		Unreachable: make(map[string]error),
+	}
+	for pod, forward := range forwards {
+		families, err := ScrapeMetrics(forward.LocalPort(), endpoint)
+		if err != nil {
+			metrics.Unreachable[pod] = err
+			continue
+		}
+		metrics.Families[pod] = families
+	}
+	return metrics
+}
+
+// Sum adds up the values of the series of metricName with all of labels over the pods scraped, returning the
+// total and each pod's share of it. A pod without such a series contributes 0
+func (a *AgentMetrics) Sum(metricName string, labels map[string]string) (total float64, byPod map[string]float64) {
+	byPod = make(map[string]float64)
+	for pod, families := range a.Families {
+		byPod[pod] = sumSeries(families, metricName, labels)
+		total += byPod[pod]
+	}
+	return total, byPod
+}
+
+// sumSeries adds up the values of the series of metricName with all of labels, 0 when there is none
+func sumSeries(families map[string]*promclient.MetricFamily, metricName string, labels map[string]string) float64 {
+	var total float64
+	for _, metric := range families[metricName].GetMetric() {
+		if hasLabels(metric, labels) {
+			total += seriesValue(metric)
+		}
+	}
+	return total
+}
+
+func hasLabels(metric *promclient.Metric, labels map[string]string) bool {
+	metricLabels := map[string]string{}
+	for _, label := range metric.GetLabel() {
+		metricLabels[label.GetName()] = label.GetValue()
+	}
+	for name, value := range labels {
+		if metricLabels[name] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// seriesValue is the value of a counter, gauge or untyped series, or the observation count of a histogram or summary
+func seriesValue(metric *promclient.Metric) float64 {
+	switch {
+	case metric.GetCounter() != nil:
+		return metric.GetCounter().GetValue()
+	case metric.GetGauge() != nil:
+		return metric.GetGauge().GetValue()
+	case metric.GetUntyped() != nil:
+		return metric.GetUntyped().GetValue()
+	case metric.GetHistogram() != nil:
+		return float64(metric.GetHistogram().GetSampleCount())
+	case metric.GetSummary() != nil:
+		return float64(metric.GetSummary().GetSampleCount())
+	default:
+		return 0
+	}
+}
+
+// ValidateFleetMetric port forwards to every running agent pod with LabelSelector in Namespace, and waits until
+// the series of MetricName with all of Labels sum to at least AtLeast over all of them, e.g. the DNS requests a
+// scenario sent, which are counted by the agents of whichever nodes saw them. Every pod has to be scraped, unless
+// AllowUnreachablePods is set, in which case pods that can't be forwarded to or scraped are left out as long as
+// one is. The pods that contributed to the sum and those left out are logged, and listed when it fails
+type ValidateFleetMetric struct {
+	KubeConfigFilePath   string
+	Namespace            string
+	LabelSelector        string
+	RetinaPort           int
+	MetricName           string
+	Labels               map[string]string
+	AtLeast              float64
+	AllowUnreachablePods bool
+	Timeout              time.Duration
+	Interval             time.Duration
+}
+
+func (v *ValidateFleetMetric) Run() error {
+	timeout := v.Timeout
+	if timeout == 0 {
+		timeout = defaultWaitForMetricTimeout
+	}
+	interval := v.Interval
+	if interval == 0 {
+		interval = defaultWaitForMetricInterval
+	}
+
+	config, err := kubernetes.BuildConfig(v.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := k8s.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	pods, err := clientset.CoreV1().Pods(v.Namespace).List(ctx, metav1.ListOptions{LabelSelector: v.LabelSelector, FieldSelector: "status.phase=Running"})
+	if err != nil {
+		return fmt.Errorf("error listing pods with %s in %s: %w", v.LabelSelector, v.Namespace, err)
+	}
+	if len(pods.Items) == 0 {
+		return fmt.Errorf("pods with %s in %s: %w", v.LabelSelector, v.Namespace, ErrNoAgentPods)
+	}
+
+	forwards := make(map[string]*kubernetes.PortForwarder)
+	unforwarded := make(map[string]error)
+	defer func() {
+		for _, forward := range forwards {
+			forward.Stop()
+		}
+	}()
+	for i := range pods.Items {
+		pod := pods.Items[i].Name
+		forward, forwardErr := kubernetes.ForwardToPod(ctx, config, v.Namespace, v.LabelSelector, pod, v.RetinaPort)
+		if forwardErr != nil {
+			unforwarded[pod] = forwardErr
+			continue
+		}
+		forwards[pod] = forward
+	}
+	if err = v.checkReachable(len(pods.Items), unforwarded); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	var total float64
+	var byPod map[string]float64
+	var lastErr error
+	err = wait.PollUntilContextCancel(ctx, interval, true, func(context.Context) (bool, error) {
+		metrics := ScrapeAgents(forwards, "metrics")
+		for pod, forwardErr := range unforwarded {
+			metrics.Unreachable[pod] = forwardErr
+		}
+
+		lastErr = v.checkReachable(len(pods.Items), metrics.Unreachable)
+		if lastErr != nil {
+			log.Printf("%v", lastErr)
+			return false, nil
+		}
+		total, byPod = metrics.Sum(v.MetricName, v.Labels)
+		if total < v.AtLeast {
+			lastErr = fmt.Errorf("%s with %s sums to %v over %s, expected at least %v: %w", v.MetricName, formatLabels(v.Labels), total, formatShares(byPod), v.AtLeast, ErrFleetSumTooLow)
+			log.Printf("%v", lastErr)
+			return false, nil
+		}
+		if len(metrics.Unreachable) > 0 {
+			log.Printf("left out unreachable agent pods %s\n", formatUnreachable(metrics.Unreachable))
+		}
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("%w after %s: %w", ErrMetricTimeout, time.Since(start).Round(time.Second), errors.Join(err, lastErr))
+	}
+
+	log.Printf("%s with %s sums to %v over %s after %s\n", v.MetricName, formatLabels(v.Labels), total, formatShares(byPod), time.Since(start).Round(time.Second))
+	return nil
+}
+
+// checkReachable applies the step's policy to the agent pods of total that couldn't be scraped: none may be
+// unless AllowUnreachablePods is set, and then at least one has to be
+func (v *ValidateFleetMetric) checkReachable(total int, unreachable map[string]error) error {
+	if len(unreachable) == 0 || (v.AllowUnreachablePods && len(unreachable) < total) {
+		return nil
+	}
+	return fmt.Errorf("%d of %d agent pods %s: %w", len(unreachable), total, formatUnreachable(unreachable), ErrAgentsUnreachable)
+}
+
+// formatShares lists each pod's share of a sum, e.g. "retina-agent-1=3, retina-agent-2=0"
+func formatShares(byPod map[string]float64) string {
+	pods := make([]string, 0, len(byPod))
+	for pod := range byPod {
+		pods = append(pods, pod)
+	}
+	slices.Sort(pods)
+
+	shares := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		shares = append(shares, fmt.Sprintf("%s=%v", pod, byPod[pod]))
+	}
+	return strings.Join(shares, ", ")
+}
+
+func formatUnreachable(unreachable map[string]error) string {
+	pods := make([]string, 0, len(unreachable))
+	for pod := range unreachable {
+		pods = append(pods, pod)
+	}
+	slices.Sort(pods)
+
+	failures := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		failures = append(failures, fmt.Sprintf("%s (%v)", pod, unreachable[pod]))
+	}
+	return strings.Join(failures, ", ")
+}
+
+func (v *ValidateFleetMetric) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateFleetMetric) Stop() error {
+	return nil
+}
@@ -0,0 +1,123 @@
+package prom
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+var (
+	ErrStaleMetric      = fmt.Errorf("metric didn't advance past its baseline")
+	ErrNoBaseline       = fmt.Errorf("no RecordMetricBaseline to compare against")
+	ErrBaselineNotTaken = fmt.Errorf("metric baseline wasn't recorded")
+)
+
+// RecordMetricBaseline scrapes the port forwarded agent's metrics endpoint and records the sum of the series of
+// MetricName with all of Labels, 0 while there is none, for a later ValidateMetricAdvanced to compare against.
+// Together they guard a scenario's key metric against a stuck exporter still serving what it counted before the
+// scenario ran, which would otherwise pass a check that a series exists
+type RecordMetricBaseline struct {
+	PortForwardedRetinaPort string
+	MetricName              string
+	Labels                  map[string]string
+
+	value    float64
+	recorded bool
+}
+
+func (r *RecordMetricBaseline) Run() error {
+	families, err := ScrapeMetrics(r.PortForwardedRetinaPort, "metrics")
+	if err != nil {
+		return fmt.Errorf("failed to scrape baseline of %s: %w", r.MetricName, err)
+	}
+
+	r.value = sumSeries(families, r.MetricName, r.Labels)
+	r.recorded = true
+	log.Printf("baseline of %s with %s is %v\n", r.MetricName, formatLabels(r.Labels), r.value)
+	return nil
+}
+
+func (r *RecordMetricBaseline) Prevalidate() error {
+	return nil
+}
+
+func (r *RecordMetricBaseline) Stop() error {
+	return nil
+}
+
+// ValidateMetricAdvanced polls the port forwarded agent until the metric Baseline recorded, summed over the same
+// series, is above the baseline, i.e. the agent counted what the steps in between did. Timeout and Interval
+// default to 2 minutes and 2 seconds. A counter that was reset, e.g. by an agent restart, fails as stale too
+type ValidateMetricAdvanced struct {
+	Baseline *RecordMetricBaseline
+	Timeout  time.Duration
+	Interval time.Duration
+}
+
+func (v *ValidateMetricAdvanced) Run() error {
+	return v.RunContext(context.Background())
+}
+
+// RunContext polls like Run, giving up once ctx is cancelled
+func (v *ValidateMetricAdvanced) RunContext(ctx context.Context) error {
+	if !v.Baseline.recorded {
+		return ErrBaselineNotTaken
+	}
+
+	timeout := v.Timeout
+	if timeout == 0 {
+		timeout = defaultWaitForMetricTimeout
+	}
+	interval := v.Interval
+	if interval == 0 {
+		interval = defaultWaitForMetricInterval
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	b := v.Baseline
+	promAddress := kubernetes.LocalURL(b.PortForwardedRetinaPort, "metrics")
+	start := time.Now()
+	var value float64
+	var lastErr error
+	err := wait.PollUntilContextCancel(ctx, interval, true, func(ctx context.Context) (bool, error) {
+		families, scrapeErr := getAllPrometheusMetricsFromURL(ctx, promAddress)
+		if scrapeErr != nil {
+			// a scrape cut short by the deadline says nothing new about the metric
+			if ctx.Err() == nil {
+				lastErr = scrapeErr
+			}
+			return false, nil
+		}
+
+		value = sumSeries(families, b.MetricName, b.Labels)
+		if value <= b.value {
+			lastErr = fmt.Errorf("%s with %s is %v, baseline %v: %w", b.MetricName, formatLabels(b.Labels), value, b.value, ErrStaleMetric)
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("%w after %s: %w", ErrMetricTimeout, time.Since(start).Round(time.Second), errors.Join(err, lastErr))
+	}
+
+	log.Printf("%s with %s advanced from %v to %v after %s\n", b.MetricName, formatLabels(b.Labels), b.value, value, time.Since(start).Round(time.Second))
+	return nil
+}
+
+func (v *ValidateMetricAdvanced) Prevalidate() error {
+	if v.Baseline == nil {
+		return ErrNoBaseline
+	}
+	return nil
+}
+
+func (v *ValidateMetricAdvanced) Stop() error {
+	return nil
+}
@@ -0,0 +1,188 @@
+package prom
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"slices"
+
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	promclient "github.com/prometheus/client_model/go"
+)
+
+var (
+	ErrNotAHistogram          = fmt.Errorf("metric is not a histogram")
+	ErrMismatchedBuckets      = fmt.Errorf("histogram buckets don't match")
+	ErrNotABucketBound        = fmt.Errorf("value is not an upper bound of the histogram's buckets")
+	ErrUnexpectedCount        = fmt.Errorf("unexpected histogram observation count")
+	ErrObservationsAboveBound = fmt.Errorf("histogram observations above the expected bucket")
+	ErrUnexpectedSum          = fmt.Errorf("unexpected histogram sum")
+)
+
+// A Bucket is the cumulative count of a histogram's observations at most UpperBound
+type Bucket struct {
+	UpperBound float64
+	Count      uint64
+}
+
+// A Histogram is a histogram series as its _bucket, _sum and _count series expose it. Its buckets are ordered
+// by upper bound and always end with the +Inf one, whose count is the histogram's _count, as scrapes in the
+// protobuf format leave it out
+type Histogram struct {
+	Buckets []Bucket
+	Sum     float64
+	Count   uint64
+}
+
+// ParseHistogram reads the histogram of a scraped series
+func ParseHistogram(metric *promclient.Metric) (*Histogram, error) {
+	histogram := metric.GetHistogram()
+	if histogram == nil {
+		return nil, ErrNotAHistogram
+	}
+
+	parsed := &Histogram{
+		Sum:   histogram.GetSampleSum(),
+		Count: histogram.GetSampleCount(),
+	}
+	for _, bucket := range histogram.GetBucket() {
+		parsed.Buckets = append(parsed.Buckets, Bucket{UpperBound: bucket.GetUpperBound(), Count: bucket.GetCumulativeCount()})
+	}
+	slices.SortFunc(parsed.Buckets, func(a, b Bucket) int {
+		switch {
+		case a.UpperBound < b.UpperBound:
+			return -1
+		case a.UpperBound > b.UpperBound:
+			return 1
+		default:
+			return 0
+		}
+	})
+	if len(parsed.Buckets) == 0 || !math.IsInf(parsed.Buckets[len(parsed.Buckets)-1].UpperBound, 1) {
+		parsed.Buckets = append(parsed.Buckets, Bucket{UpperBound: math.Inf(1), Count: parsed.Count})
+	}
+	return parsed, nil
+}
+
+// Bounds returns the upper bounds of the histogram's buckets, without the +Inf one
+func (h *Histogram) Bounds() []float64 {
+	bounds := make([]float64, 0, len(h.Buckets))
+	for _, bucket := range h.Buckets {
+		if !math.IsInf(bucket.UpperBound, 1) {
+			bounds = append(bounds, bucket.UpperBound)
+		}
+	}
+	return bounds
+}
+
+// CountAtMost returns how many observations are at most bound, which has to be one of the bucket upper bounds,
+// +Inf included: a histogram can't tell where observations fall between two of them
+func (h *Histogram) CountAtMost(bound float64) (uint64, error) {
+	for _, bucket := range h.Buckets {
+		if bucket.UpperBound == bound {
+			return bucket.Count, nil
+		}
+	}
+	return 0, fmt.Errorf("%v not in %v: %w", bound, h.Bounds(), ErrNotABucketBound)
+}
+
+// add adds the observations of other, which must have the same buckets, to the histogram
+func (h *Histogram) add(other *Histogram) error {
+	if !slices.Equal(h.Bounds(), other.Bounds()) {
+		return fmt.Errorf("buckets %v and %v: %w", h.Bounds(), other.Bounds(), ErrMismatchedBuckets)
+	}
+	for i := range h.Buckets {
+		h.Buckets[i].Count += other.Buckets[i].Count
+	}
+	h.Sum += other.Sum
+	h.Count += other.Count
+	return nil
+}
+
+// ValidateHistogram scrapes the port forwarded agent's metrics endpoint once and checks the histogram MetricName,
+// without its _bucket, _sum or _count suffix, summed over every series with all of Labels. Each assertion is only
+// made when set:
+//   - Bounds are the upper bounds its buckets must have, without the implicit +Inf one
+//   - Count is the number of observations it must have, e.g. the number of queries a scenario sent
+//   - AtMost is a bucket upper bound every observation must fall within, e.g. 0.1 for requests answered within 100ms
+//   - MaxSum is the most the observations may add up to
+type ValidateHistogram struct {
+	PortForwardedRetinaPort string
+	MetricName              string
+	Labels                  map[string]string
+	Bounds                  []float64
+	Count                   uint64
+	AtMost                  float64
+	MaxSum                  float64
+}
+
+func (v *ValidateHistogram) Run() error {
+	promAddress := kubernetes.LocalURL(v.PortForwardedRetinaPort, "metrics")
+	series, err := GetMetricsMatchingLabels(promAddress, v.MetricName, v.Labels)
+	if err != nil {
+		return fmt.Errorf("failed to scrape %s: %w", v.MetricName, err)
+	}
+	if len(series) == 0 {
+		return fmt.Errorf("no %s series with %s: %w", v.MetricName, formatLabels(v.Labels), ErrNoMetricFound)
+	}
+
+	var histogram *Histogram
+	for _, metric := range series {
+		parsed, parseErr := ParseHistogram(metric)
+		if parseErr != nil {
+			return fmt.Errorf("%s: %w", v.MetricName, parseErr)
+		}
+		if histogram == nil {
+			histogram = parsed
+			continue
+		}
+		err = histogram.add(parsed)
+		if err != nil {
+			return fmt.Errorf("summing %s series with %s: %w", v.MetricName, formatLabels(v.Labels), err)
+		}
+	}
+
+	err = v.check(histogram)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", v.MetricName, formatLabels(v.Labels), err)
+	}
+
+	log.Printf("%s with %s has %d observations summing to %v over buckets %v\n", v.MetricName, formatLabels(v.Labels), histogram.Count, histogram.Sum, histogram.Bounds())
+	return nil
+}
+
+func (v *ValidateHistogram) check(histogram *Histogram) error {
+	if len(v.Bounds) > 0 && !slices.Equal(histogram.Bounds(), v.Bounds) {
+		return fmt.Errorf("buckets %v, expected %v: %w", histogram.Bounds(), v.Bounds, ErrMismatchedBuckets)
+	}
+
+	if v.Count > 0 && histogram.Count != v.Count {
+		return fmt.Errorf("%d observations, expected %d: %w", histogram.Count, v.Count, ErrUnexpectedCount)
+	}
+
+	if v.AtMost > 0 {
+		within, err := histogram.CountAtMost(v.AtMost)
+		if err != nil {
+			return err
+		}
+		if within != histogram.Count {
+			return fmt.Errorf("%d of %d observations above %v: %w", histogram.Count-within, histogram.Count, v.AtMost, ErrObservationsAboveBound)
+		}
+	}
+
+	if v.MaxSum > 0 && histogram.Sum > v.MaxSum {
+		return fmt.Errorf("observations sum to %v, expected at most %v: %w", histogram.Sum, v.MaxSum, ErrUnexpectedSum)
+	}
+	return nil
+}
+
+func (v *ValidateHistogram) Prevalidate() error {
+	if len(v.Bounds) > 0 && v.AtMost > 0 && !math.IsInf(v.AtMost, 1) && !slices.Contains(v.Bounds, v.AtMost) {
+		return fmt.Errorf("%v not in %v: %w", v.AtMost, v.Bounds, ErrNotABucketBound)
+	}
+	return nil
+}
+
+func (v *ValidateHistogram) Stop() error {
+	return nil
+}
@@ -1,15 +1,22 @@
 package prom
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
+	"mime"
 	"net/http"
+	"net/url"
 	"reflect"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
 	"github.com/microsoft/retina/test/retry"
 	promclient "github.com/prometheus/client_model/go"
 	"github.com/prometheus/common/expfmt"
@@ -17,11 +24,21 @@ import (
 
 var (
 	ErrNoMetricFound     = fmt.Errorf("no metric found")
+	ErrUnexpectedHead    = fmt.Errorf("unexpected response to HEAD request")
+	ErrUnexpectedFormat  = fmt.Errorf("unexpected exposition format")
+	ErrUnexpectedStatus  = fmt.Errorf("unexpected metrics endpoint status")
+	ErrSeriesQuery       = fmt.Errorf("series query failed")
 	defaultTimeout       = 300 * time.Second
 	defaultRetryDelay    = 5 * time.Second
 	defaultRetryAttempts = 60
+
+	// metricsClient bounds every request to an agent, so one accepting the connection without answering fails the
+	// request rather than hanging its step
+	metricsClient = &http.Client{Timeout: defaultRequestTimeout}
 )
 
+const defaultRequestTimeout = 30 * time.Second
+
 func CheckMetric(promAddress, metricName string, validMetric map[string]string) error {
 	defaultRetrier := retry.Retrier{Attempts: defaultRetryAttempts, Delay: defaultRetryDelay}
 
@@ -35,7 +52,7 @@ func CheckMetric(promAddress, metricName string, validMetric map[string]string)
 		var err error
 
 		// obtain a full dump of all metrics on the endpoint
-		metrics, err = getAllPrometheusMetricsFromURL(promAddress)
+		metrics, err = getAllPrometheusMetricsFromURL(pctx, promAddress)
 		if err != nil {
 			return fmt.Errorf("could not start port forward within %ds: %w	", defaultTimeout, err)
 		}
@@ -44,8 +61,8 @@ func CheckMetric(promAddress, metricName string, validMetric map[string]string)
 		// if none is found then log and return an error which will trigger a retry
 		err = verifyValidMetricPresent(metricName, metrics, validMetric)
 		if err != nil {
-			log.Printf("failed to find metric matching %s: %+v\n", metricName, validMetric)
-			return ErrNoMetricFound
+			log.Printf("%v", err)
+			return err
 		}
 
 		return nil
@@ -66,51 +83,347 @@ func CheckMetricFromBuffer(prometheusMetricData []byte, metricName string, valid
 
 	err = verifyValidMetricPresent(metricName, metrics, validMetric)
 	if err != nil {
-		log.Printf("failed to find metric matching %s: %+v\n", metricName, validMetric)
-		return ErrNoMetricFound
+		log.Printf("%v", err)
+		return err
 	}
 
 	return nil
 }
 
+// verifyValidMetricPresent looks for a series of metricName with exactly the labels of validMetric. When none
+// matches, the error lists every series of metricName that was found, its value, and the labels it was rejected on
 func verifyValidMetricPresent(metricName string, data map[string]*promclient.MetricFamily, validMetric map[string]string) error {
-	for _, metric := range data {
-		if metric.GetName() == metricName {
-			for _, metric := range metric.GetMetric() {
-
-				// get all labels and values on the metric
-				metricLabels := map[string]string{}
-				for _, label := range metric.GetLabel() {
-					metricLabels[label.GetName()] = label.GetValue()
-				}
-				if reflect.DeepEqual(metricLabels, validMetric) {
-					return nil
-				}
+	family, ok := data[metricName]
+	if !ok || len(family.GetMetric()) == 0 {
+		return fmt.Errorf("failed to find metric matching %s: no series of %s found: %w", formatLabels(validMetric), metricName, ErrNoMetricFound)
+	}
+
+	rejected := []string{}
+	for _, metric := range family.GetMetric() {
+		// get all labels and values on the metric
+		metricLabels := map[string]string{}
+		for _, label := range metric.GetLabel() {
+			metricLabels[label.GetName()] = label.GetValue()
+		}
+		if reflect.DeepEqual(metricLabels, validMetric) {
+			return nil
+		}
+
+		rejected = append(rejected, fmt.Sprintf("\t%s = %s: %s", formatLabels(metricLabels), formatValue(metric), strings.Join(labelMismatches(validMetric, metricLabels), ", ")))
+	}
+
+	return fmt.Errorf("failed to find metric matching %s among %d series of %s: %w\n%s",
+		formatLabels(validMetric), len(rejected), metricName, ErrNoMetricFound, strings.Join(rejected, "\n"))
+}
+
+// labelMismatches describes each label where actual differs from expected, in label name order
+func labelMismatches(expected, actual map[string]string) []string {
+	names := map[string]struct{}{}
+	for name := range expected {
+		names[name] = struct{}{}
+	}
+	for name := range actual {
+		names[name] = struct{}{}
+	}
+
+	mismatches := []string{}
+	for _, name := range sortedKeys(names) {
+		expectedValue, inExpected := expected[name]
+		actualValue, inActual := actual[name]
+		switch {
+		case !inActual:
+			mismatches = append(mismatches, fmt.Sprintf("%s missing, expected %q", name, expectedValue))
+		case !inExpected:
+			mismatches = append(mismatches, fmt.Sprintf("%s=%q not expected", name, actualValue))
+		case expectedValue != actualValue:
+			mismatches = append(mismatches, fmt.Sprintf("%s expected %q, got %q", name, expectedValue, actualValue))
+		}
+	}
+	return mismatches
+}
+
+// formatLabels prints labels in name order so failures read the same on every scrape
+func formatLabels(labels map[string]string) string {
+	names := map[string]struct{}{}
+	for name := range labels {
+		names[name] = struct{}{}
+	}
+
+	pairs := []string{}
+	for _, name := range sortedKeys(names) {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", name, labels[name]))
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+func formatValue(metric *promclient.Metric) string {
+	switch {
+	case metric.GetCounter() != nil:
+		return fmt.Sprintf("%v", metric.GetCounter().GetValue())
+	case metric.GetGauge() != nil:
+		return fmt.Sprintf("%v", metric.GetGauge().GetValue())
+	case metric.GetUntyped() != nil:
+		return fmt.Sprintf("%v", metric.GetUntyped().GetValue())
+	case metric.GetHistogram() != nil:
+		return fmt.Sprintf("count %d", metric.GetHistogram().GetSampleCount())
+	case metric.GetSummary() != nil:
+		return fmt.Sprintf("count %d", metric.GetSummary().GetSampleCount())
+	default:
+		return "unknown"
+	}
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// GetMetricsMatchingLabels scrapes promAddress once and returns every series of metricName
+// whose labels include all of matchLabels, for checks that can't pin the full label set
+func GetMetricsMatchingLabels(promAddress, metricName string, matchLabels map[string]string) ([]*promclient.Metric, error) {
+	metrics, err := getAllPrometheusMetricsFromURL(context.Background(), promAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prometheus metrics: %w", err)
+	}
+
+	matches := []*promclient.Metric{}
+	family, ok := metrics[metricName]
+	if !ok {
+		return matches, nil
+	}
+
+	for _, metric := range family.GetMetric() {
+		metricLabels := map[string]string{}
+		for _, label := range metric.GetLabel() {
+			metricLabels[label.GetName()] = label.GetValue()
+		}
+
+		matched := true
+		for name, value := range matchLabels {
+			if metricLabels[name] != value {
+				matched = false
+				break
 			}
 		}
+		if matched {
+			matches = append(matches, metric)
+		}
 	}
 
-	return fmt.Errorf("failed to find metric matching: %+v: %w", validMetric, ErrNoMetricFound)
+	return matches, nil
 }
 
-func getAllPrometheusMetricsFromURL(url string) (map[string]*promclient.MetricFamily, error) {
-	client := http.Client{}
-	resp, err := client.Get(url) //nolint
+// CheckMetricsEndpointHead sends a HEAD request to promAddress, as some monitoring systems probe with,
+// and checks it is answered like a GET for the text format would be, minus the body
+func CheckMetricsEndpointHead(promAddress string) error {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodHead, promAddress, http.NoBody)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+
+	resp, err := metricsClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("HTTP request failed: %w", err)
+		return fmt.Errorf("HTTP request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP request failed with status: %v", resp.Status) //nolint:goerr113,gocritic
+		return fmt.Errorf("status %s: %w", resp.Status, ErrUnexpectedHead)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if expfmt.ResponseFormat(resp.Header).FormatType() == expfmt.TypeUnknown {
+		return fmt.Errorf("content type \"%s\" is not a prometheus exposition format: %w", contentType, ErrUnexpectedHead)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	if len(body) > 0 {
+		return fmt.Errorf("%d byte body: %w", len(body), ErrUnexpectedHead)
+	}
+
+	log.Printf("HEAD %s answered %s with content type \"%s\"\n", promAddress, resp.Status, contentType)
+	return nil
+}
+
+// openMetricsEOF ends every OpenMetrics exposition, and no text format one
+const openMetricsEOF = "# EOF"
+
+// CheckMetricsEndpointFormat sends a GET with the accept header to promAddress and checks the response is one of
+// the formats a scraper sending that header accepts, and reads as that format: an OpenMetrics body ends with
+// its "# EOF" line, a text format body doesn't have one, and either parses into metric families. It returns
+// the format negotiated
+func CheckMetricsEndpointFormat(promAddress, accept string, accepted ...expfmt.FormatType) (expfmt.FormatType, error) {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, promAddress, http.NoBody)
+	if err != nil {
+		return expfmt.TypeUnknown, fmt.Errorf("failed to build request: %w", err)
 	}
+	req.Header.Set("Accept", accept)
 
-	metrics, err := ParseReaderPrometheusMetrics(resp.Body)
+	resp, err := metricsClient.Do(req)
 	if err != nil {
-		return nil, err
+		return expfmt.TypeUnknown, fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return expfmt.TypeUnknown, fmt.Errorf("status %s: %w", resp.Status, ErrUnexpectedFormat)
 	}
 
-	return metrics, nil
+	contentType := resp.Header.Get("Content-Type")
+	format := expfmt.ResponseFormat(resp.Header).FormatType()
+	// ResponseFormat only knows the formats it can decode, which OpenMetrics isn't
+	if mediaType, _, parseErr := mime.ParseMediaType(contentType); parseErr == nil && mediaType == expfmt.OpenMetricsType {
+		format = expfmt.TypeOpenMetrics
+	}
+	found := false
+	for _, formatType := range accepted {
+		found = found || formatType == format
+	}
+	if !found {
+		return format, fmt.Errorf("content type \"%s\" for accept header \"%s\": %w", contentType, accept, ErrUnexpectedFormat)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return format, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(body), "\n"), "\n")
+	eofLines := 0
+	for _, line := range lines {
+		if line == openMetricsEOF {
+			eofLines++
+		}
+	}
+
+	switch {
+	case format == expfmt.TypeOpenMetrics && (eofLines != 1 || lines[len(lines)-1] != openMetricsEOF):
+		return format, fmt.Errorf("content type \"%s\" body doesn't end with a single \"%s\" line: %w", contentType, openMetricsEOF, ErrUnexpectedFormat)
+	case format != expfmt.TypeOpenMetrics && eofLines > 0:
+		return format, fmt.Errorf("content type \"%s\" body has an OpenMetrics \"%s\" line: %w", contentType, openMetricsEOF, ErrUnexpectedFormat)
+	}
+
+	// the samples of an OpenMetrics body read as the text format, the EOF line aside and with its unknown type
+	// spelled the text format's way
+	if format == expfmt.TypeOpenMetrics {
+		lines = lines[:len(lines)-1]
+		for i, line := range lines {
+			if strings.HasPrefix(line, "# TYPE ") && strings.HasSuffix(line, " unknown") {
+				lines[i] = strings.TrimSuffix(line, " unknown") + " untyped"
+			}
+		}
+		body = []byte(strings.Join(lines, "\n") + "\n")
+	}
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(bytes.NewReader(body))
+	if err != nil {
+		return format, fmt.Errorf("content type \"%s\" body doesn't parse: %w: %w", contentType, ErrUnexpectedFormat, err)
+	}
+	if len(families) == 0 {
+		return format, fmt.Errorf("content type \"%s\" body has no metric families: %w", contentType, ErrUnexpectedFormat)
+	}
+
+	log.Printf("GET %s with accept header \"%s\" answered with content type \"%s\", %d metric families\n", promAddress, accept, contentType, len(families))
+	return format, nil
+}
+
+// SeriesQueryURL is the Prometheus HTTP API request at apiAddress, e.g. a remote write receiver, for
+// the label sets of every stored series of metricName with all of matchLabels
+func SeriesQueryURL(apiAddress, metricName string, matchLabels map[string]string) string {
+	selector := metricName + formatLabels(matchLabels)
+	return apiAddress + "/api/v1/series?" + url.Values{"match[]": {selector}}.Encode()
+}
+
+// ParseSeriesResponse returns the label sets in the body of a response to a SeriesQueryURL request
+func ParseSeriesResponse(body []byte) ([]map[string]string, error) {
+	var result struct {
+		Status string              `json:"status"`
+		Error  string              `json:"error"`
+		Data   []map[string]string `json:"data"`
+	}
+	err := json.Unmarshal(body, &result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode series response: %w", err)
+	}
+	if result.Status != "success" {
+		return nil, fmt.Errorf("%s: %w", result.Error, ErrSeriesQuery)
+	}
+	return result.Data, nil
+}
+
+// ScrapeMetrics scrapes endpoint, e.g. "metrics", on a port forwarded to localPort and returns its metric families
+// by name, for validators that check more than whether a series is present
+func ScrapeMetrics(localPort, endpoint string) (map[string]*promclient.MetricFamily, error) {
+	return getAllPrometheusMetricsFromURL(context.Background(), kubernetes.LocalURL(localPort, endpoint))
+}
+
+// CheckMetricFamilies looks for a series of metricName with exactly the labels of validMetric among families
+// from ScrapeMetrics. When none matches, the error lists every series of metricName with the labels it was rejected on
+func CheckMetricFamilies(families map[string]*promclient.MetricFamily, metricName string, validMetric map[string]string) error {
+	return verifyValidMetricPresent(metricName, families, validMetric)
+}
+
+func getAllPrometheusMetricsFromURL(ctx context.Context, url string) (map[string]*promclient.MetricFamily, error) {
+	metrics, _, err := scrapeURL(ctx, url)
+	return metrics, err
+}
+
+// scrapeURL scrapes the metrics endpoint at url, returning its metric families by name and the size of the
+// exposition in bytes, after any gzip encoding is undone. The request is given up once ctx is cancelled
+func scrapeURL(ctx context.Context, url string) (map[string]*promclient.MetricFamily, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to build request: %w", err)
+	}
+	// asking for gzip ourselves keeps the transport from decoding it, so a server compressing unasked is read too
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	resp, err := metricsClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("GET %s returned %s: %w", url, resp.Status, ErrUnexpectedStatus)
+	}
+
+	body := io.Reader(resp.Body)
+	if resp.Header.Get("Content-Encoding") == "gzip" {
+		gzipReader, gzipErr := gzip.NewReader(resp.Body)
+		if gzipErr != nil {
+			return nil, 0, fmt.Errorf("failed to read gzip response from %s: %w", url, gzipErr)
+		}
+		defer gzipReader.Close()
+		body = gzipReader
+	}
+
+	counted := &countingReader{reader: body}
+	metrics, err := ParseReaderPrometheusMetrics(counted)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to parse metrics from %s: %w", url, err)
+	}
+
+	return metrics, counted.n, nil
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	reader io.Reader
+	n      int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	c.n += n
+	return n, err //nolint:wrapcheck // io.EOF has to be returned as is
 }
 
 func getAllPrometheusMetricsFromBuffer(buf []byte) (map[string]*promclient.MetricFamily, error) {
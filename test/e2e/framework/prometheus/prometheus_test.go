@@ -0,0 +1,549 @@
+package prom
+
+import (
+	"compress/gzip"
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	promclient "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+const dnsResponseMetrics = `# HELP networkobservability_dns_response_count DNS responses
+# TYPE networkobservability_dns_response_count counter
+networkobservability_dns_response_count{num_response="0",query="kubernetes.default.kube-system.svc.cluster.local.",query_type="A",response="",return_code="Non-Existent Domain"} 2
+networkobservability_dns_response_count{num_response="1",query="bing.com.",query_type="A",response="1.2.3.4",return_code="No Error"} 4
+`
+
+func TestCheckMetricFromBufferMatches(t *testing.T) {
+	err := CheckMetricFromBuffer([]byte(dnsResponseMetrics), "networkobservability_dns_response_count", map[string]string{
+		"num_response": "1",
+		"query":        "bing.com.",
+		"query_type":   "A",
+		"response":     "1.2.3.4",
+		"return_code":  "No Error",
+	})
+	require.NoError(t, err)
+}
+
+func TestCheckMetricFromBufferListsRejectedSeries(t *testing.T) {
+	err := CheckMetricFromBuffer([]byte(dnsResponseMetrics), "networkobservability_dns_response_count", map[string]string{
+		"num_response": "1",
+		"query":        "bing.com.",
+		"query_type":   "AAAA",
+		"return_code":  "No Error",
+	})
+	require.ErrorIs(t, err, ErrNoMetricFound)
+	require.Contains(t, err.Error(), "among 2 series")
+	require.Contains(t, err.Error(), `query_type expected "AAAA", got "A", response="1.2.3.4" not expected`)
+	require.Contains(t, err.Error(), `= 4:`)
+	require.Contains(t, err.Error(), `query expected "bing.com.", got "kubernetes.default.kube-system.svc.cluster.local."`)
+}
+
+func TestCheckMetricFromBufferMissingMetric(t *testing.T) {
+	err := CheckMetricFromBuffer([]byte(dnsResponseMetrics), "networkobservability_dns_request_count", map[string]string{
+		"query": "bing.com.",
+	})
+	require.ErrorIs(t, err, ErrNoMetricFound)
+	require.Contains(t, err.Error(), "no series of networkobservability_dns_request_count found")
+}
+
+func TestCheckMetricsEndpointHead(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(prometheus.NewCounter(prometheus.CounterOpts{Name: "networkobservability_forward_count"}))
+	server := httptest.NewServer(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	defer server.Close()
+
+	require.NoError(t, CheckMetricsEndpointHead(server.URL+"/metrics"))
+}
+
+func TestCheckMetricsEndpointHeadGetOnly(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	}))
+	defer server.Close()
+
+	require.ErrorIs(t, CheckMetricsEndpointHead(server.URL+"/metrics"), ErrUnexpectedHead)
+}
+
+func TestSeriesQueryURL(t *testing.T) {
+	query, err := url.Parse(SeriesQueryURL("http://remote-write-receiver:9090", "networkobservability_forward_count", map[string]string{
+		"job":       "retina",
+		"direction": "egress",
+	}))
+	require.NoError(t, err)
+	require.Equal(t, "/api/v1/series", query.Path)
+	require.Equal(t, `networkobservability_forward_count{direction="egress", job="retina"}`, query.Query().Get("match[]"))
+	require.NotContains(t, query.String(), " ")
+}
+
+func TestParseSeriesResponse(t *testing.T) {
+	series, err := ParseSeriesResponse([]byte(`{"status":"success","data":[{"__name__":"networkobservability_forward_count","direction":"egress","job":"retina","instance":"10.0.0.4:10093"}]}`))
+	require.NoError(t, err)
+	require.Len(t, series, 1)
+	require.Equal(t, "10.0.0.4:10093", series[0]["instance"])
+
+	_, err = ParseSeriesResponse([]byte(`{"status":"error","errorType":"bad_data","error":"parse error"}`))
+	require.ErrorIs(t, err, ErrSeriesQuery)
+}
+
+func TestSaveMetricsSnapshot(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(prometheus.NewCounter(prometheus.CounterOpts{Name: "networkobservability_forward_count"}))
+	server := httptest.NewServer(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	snapshot := &SaveMetricsSnapshot{PortForwardedRetinaPort: serverURL.Port(), SnapshotName: "before"}
+	snapshot.SetArtifactDir(dir)
+	require.NoError(t, snapshot.Run())
+
+	data, err := os.ReadFile(filepath.Join(dir, "before.prom"))
+	require.NoError(t, err)
+	require.Contains(t, string(data), "networkobservability_forward_count 0")
+}
+
+func TestWaitForMetric(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "networkobservability_dns_request_count"}, []string{"query", "query_type"})
+	registry.MustRegister(requests)
+	server := httptest.NewServer(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	// the series only shows up once the poll has started
+	time.AfterFunc(100*time.Millisecond, func() {
+		requests.WithLabelValues("bing.com.", "A").Inc()
+	})
+
+	require.NoError(t, (&WaitForMetric{
+		PortForwardedRetinaPort: serverURL.Port(),
+		MetricName:              "networkobservability_dns_request_count",
+		Labels:                  map[string]string{"query": "bing.com."},
+		Timeout:                 5 * time.Second,
+		Interval:                50 * time.Millisecond,
+	}).Run())
+}
+
+func TestWaitForMetricTimeoutListsSeries(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "networkobservability_dns_request_count"}, []string{"query", "query_type"})
+	registry.MustRegister(requests)
+	requests.WithLabelValues("bing.com.", "A").Inc()
+	server := httptest.NewServer(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	err = (&WaitForMetric{
+		PortForwardedRetinaPort: serverURL.Port(),
+		MetricName:              "networkobservability_dns_request_count",
+		Labels:                  map[string]string{"query": "bing.com.", "query_type": "AAAA"},
+		Timeout:                 200 * time.Millisecond,
+		Interval:                50 * time.Millisecond,
+	}).Run()
+	require.ErrorIs(t, err, ErrMetricTimeout)
+	require.ErrorIs(t, err, ErrNoMetricFound)
+	require.Contains(t, err.Error(), `query_type expected "AAAA", got "A"`)
+	require.NotContains(t, err.Error(), "not expected")
+}
+
+func TestWaitForMetricCancelledWithContext(t *testing.T) {
+	server := httptest.NewServer(promhttp.HandlerFor(prometheus.NewRegistry(), promhttp.HandlerOpts{}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = (&WaitForMetric{
+		PortForwardedRetinaPort: serverURL.Port(),
+		MetricName:              "networkobservability_dns_request_count",
+		Interval:                50 * time.Millisecond,
+	}).RunContext(ctx)
+	require.ErrorIs(t, err, ErrMetricTimeout)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(start), time.Second)
+}
+
+// newHangingServer accepts requests without ever answering them, like a wedged agent
+func newHangingServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+}
+
+func TestWaitForMetricCancelledWhileScraping(t *testing.T) {
+	server := newHangingServer()
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := (&WaitForMetric{
+		PortForwardedRetinaPort: serverPort(t, server),
+		MetricName:              "networkobservability_dns_request_count",
+	}).RunContext(ctx)
+	require.ErrorIs(t, err, ErrMetricTimeout)
+	require.Less(t, time.Since(start), time.Second)
+}
+
+func TestRequestsToHangingAgentTimeOut(t *testing.T) {
+	server := newHangingServer()
+	defer server.Close()
+
+	client := metricsClient
+	metricsClient = &http.Client{Timeout: 50 * time.Millisecond}
+	defer func() { metricsClient = client }()
+
+	start := time.Now()
+	_, err := ScrapeMetrics(serverPort(t, server), "metrics")
+	require.Error(t, err)
+	_, err = CheckMetricsEndpointFormat(server.URL+"/metrics", textAccept, expfmt.TypeTextPlain)
+	require.Error(t, err)
+	require.Error(t, CheckMetricsEndpointHead(server.URL+"/metrics"))
+	require.Less(t, time.Since(start), time.Second)
+}
+
+const (
+	textAccept        = "text/plain;version=0.0.4"
+	openMetricsAccept = "application/openmetrics-text;version=1.0.0,text/plain;version=0.0.4;q=0.5"
+)
+
+func newFormatServer(enableOpenMetrics bool) *httptest.Server {
+	registry := prometheus.NewRegistry()
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "networkobservability_forward_count"})
+	counter.Inc()
+	registry.MustRegister(counter)
+	return httptest.NewServer(promhttp.HandlerFor(registry, promhttp.HandlerOpts{EnableOpenMetrics: enableOpenMetrics}))
+}
+
+func TestCheckMetricsEndpointFormat(t *testing.T) {
+	server := newFormatServer(true)
+	defer server.Close()
+
+	format, err := CheckMetricsEndpointFormat(server.URL+"/metrics", textAccept, expfmt.TypeTextPlain)
+	require.NoError(t, err)
+	require.Equal(t, expfmt.TypeTextPlain, format)
+
+	format, err = CheckMetricsEndpointFormat(server.URL+"/metrics", openMetricsAccept, expfmt.TypeOpenMetrics)
+	require.NoError(t, err)
+	require.Equal(t, expfmt.TypeOpenMetrics, format)
+}
+
+func TestCheckMetricsEndpointFormatTextFallback(t *testing.T) {
+	server := newFormatServer(false)
+	defer server.Close()
+
+	format, err := CheckMetricsEndpointFormat(server.URL+"/metrics", openMetricsAccept, expfmt.TypeOpenMetrics, expfmt.TypeTextPlain)
+	require.NoError(t, err)
+	require.Equal(t, expfmt.TypeTextPlain, format)
+
+	_, err = CheckMetricsEndpointFormat(server.URL+"/metrics", openMetricsAccept, expfmt.TypeOpenMetrics)
+	require.ErrorIs(t, err, ErrUnexpectedFormat)
+}
+
+func TestCheckMetricsEndpointFormatMismatchedBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", string(expfmt.NewFormat(expfmt.TypeTextPlain)))
+		_, _ = w.Write([]byte("networkobservability_forward_count 1\n# EOF\n"))
+	}))
+	defer server.Close()
+
+	_, err := CheckMetricsEndpointFormat(server.URL+"/metrics", textAccept, expfmt.TypeTextPlain)
+	require.ErrorIs(t, err, ErrUnexpectedFormat)
+	require.Contains(t, err.Error(), "has an OpenMetrics")
+}
+
+func serverPort(t *testing.T, server *httptest.Server) string {
+	t.Helper()
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	return serverURL.Port()
+}
+
+func TestScrapeMetricsGzip(t *testing.T) {
+	// promhttp compresses when the scrape accepts gzip
+	server := newFormatServer(false)
+	defer server.Close()
+
+	families, err := ScrapeMetrics(serverPort(t, server), "metrics")
+	require.NoError(t, err)
+	require.NoError(t, CheckMetricFamilies(families, "networkobservability_forward_count", map[string]string{}))
+}
+
+func TestScrapeMetricsUnaskedGzip(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		writer := gzip.NewWriter(w)
+		_, _ = writer.Write([]byte(dnsResponseMetrics))
+		_ = writer.Close()
+	}))
+	defer server.Close()
+
+	families, err := ScrapeMetrics(serverPort(t, server), "metrics")
+	require.NoError(t, err)
+	require.Len(t, families["networkobservability_dns_response_count"].GetMetric(), 2)
+}
+
+func TestScrapeMetricsUnexpectedStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	_, err := ScrapeMetrics(serverPort(t, server), "metrics")
+	require.ErrorIs(t, err, ErrUnexpectedStatus)
+	require.Contains(t, err.Error(), "503")
+}
+
+func TestValidateMetricAbsent(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "networkobservability_dns_request_count"}, []string{"query", "query_type"})
+	registry.MustRegister(requests)
+	requests.WithLabelValues("bing.com.", "A").Inc()
+	// registered without being counted
+	requests.WithLabelValues("zero.com.", "A")
+	server := httptest.NewServer(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	absent := func(query string, allowZero bool) error {
+		return (&ValidateMetricAbsent{
+			PortForwardedRetinaPort: serverURL.Port(),
+			MetricName:              "networkobservability_dns_request_count",
+			Labels:                  map[string]string{"query": query},
+			AllowZeroValue:          allowZero,
+			For:                     100 * time.Millisecond,
+			Interval:                50 * time.Millisecond,
+		}).Run()
+	}
+
+	require.NoError(t, absent("missing.com.", false))
+
+	err = absent("bing.com.", true)
+	require.ErrorIs(t, err, ErrMetricPresent)
+	require.ErrorIs(t, err, ErrMetricPresentWithValue)
+
+	err = absent("zero.com.", false)
+	require.ErrorIs(t, err, ErrMetricPresent)
+	require.ErrorIs(t, err, ErrMetricPresentAsZero)
+	require.NoError(t, absent("zero.com.", true))
+}
+
+func TestParseHistogramAddsInfBucket(t *testing.T) {
+	// scrapes in the protobuf format leave the +Inf bucket out
+	histogram, err := ParseHistogram(&promclient.Metric{Histogram: &promclient.Histogram{
+		SampleCount: proto.Uint64(3),
+		SampleSum:   proto.Float64(0.6),
+		Bucket: []*promclient.Bucket{
+			{UpperBound: proto.Float64(0.5), CumulativeCount: proto.Uint64(2)},
+			{UpperBound: proto.Float64(0.1), CumulativeCount: proto.Uint64(1)},
+		},
+	}})
+	require.NoError(t, err)
+	require.Equal(t, []float64{0.1, 0.5}, histogram.Bounds())
+	require.Len(t, histogram.Buckets, 3)
+
+	count, err := histogram.CountAtMost(math.Inf(1))
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), count)
+
+	_, err = histogram.CountAtMost(0.2)
+	require.ErrorIs(t, err, ErrNotABucketBound)
+
+	_, err = ParseHistogram(&promclient.Metric{Counter: &promclient.Counter{Value: proto.Float64(1)}})
+	require.ErrorIs(t, err, ErrNotAHistogram)
+}
+
+func TestValidateHistogram(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	durations := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "networkobservability_dns_request_duration_seconds",
+		Buckets: []float64{0.01, 0.1, 1},
+	}, []string{"query", "pod"})
+	registry.MustRegister(durations)
+	durations.WithLabelValues("bing.com.", "a").Observe(0.005)
+	durations.WithLabelValues("bing.com.", "b").Observe(0.05)
+	durations.WithLabelValues("slow.com.", "a").Observe(2)
+	server := httptest.NewServer(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	validate := func(query string, v ValidateHistogram) error {
+		v.PortForwardedRetinaPort = serverURL.Port()
+		v.MetricName = "networkobservability_dns_request_duration_seconds"
+		v.Labels = map[string]string{"query": query}
+		return v.Run()
+	}
+
+	// the series of both pods are summed
+	require.NoError(t, validate("bing.com.", ValidateHistogram{Bounds: []float64{0.01, 0.1, 1}, Count: 2, AtMost: 0.1, MaxSum: 0.1}))
+	require.ErrorIs(t, validate("bing.com.", ValidateHistogram{Bounds: []float64{0.1, 1}}), ErrMismatchedBuckets)
+	require.ErrorIs(t, validate("bing.com.", ValidateHistogram{Count: 3}), ErrUnexpectedCount)
+	require.ErrorIs(t, validate("bing.com.", ValidateHistogram{AtMost: 0.01}), ErrObservationsAboveBound)
+	require.ErrorIs(t, validate("bing.com.", ValidateHistogram{AtMost: 0.05}), ErrNotABucketBound)
+	require.ErrorIs(t, validate("bing.com.", ValidateHistogram{MaxSum: 0.01}), ErrUnexpectedSum)
+
+	// an observation above the last bound only lands in the +Inf bucket
+	require.ErrorIs(t, validate("slow.com.", ValidateHistogram{AtMost: 1}), ErrObservationsAboveBound)
+	require.NoError(t, validate("slow.com.", ValidateHistogram{AtMost: math.Inf(1)}))
+
+	require.ErrorIs(t, validate("missing.com.", ValidateHistogram{}), ErrNoMetricFound)
+	require.ErrorIs(t, (&ValidateHistogram{Bounds: []float64{0.01, 0.1}, AtMost: 0.05}).Prevalidate(), ErrNotABucketBound)
+}
+
+func TestAgentMetricsSum(t *testing.T) {
+	first, err := getAllPrometheusMetricsFromBuffer([]byte(dnsResponseMetrics))
+	require.NoError(t, err)
+	second, err := getAllPrometheusMetricsFromBuffer([]byte(dnsResponseMetrics))
+	require.NoError(t, err)
+	metrics := &AgentMetrics{
+		Families: map[string]map[string]*promclient.MetricFamily{
+			"retina-agent-1": first,
+			"retina-agent-2": second,
+			"retina-agent-3": {},
+		},
+	}
+
+	total, byPod := metrics.Sum("networkobservability_dns_response_count", map[string]string{"query_type": "A"})
+	require.InDelta(t, 12, total, 0)
+	require.Equal(t, map[string]float64{"retina-agent-1": 6, "retina-agent-2": 6, "retina-agent-3": 0}, byPod)
+
+	total, _ = metrics.Sum("networkobservability_dns_response_count", map[string]string{"query": "bing.com."})
+	require.InDelta(t, 8, total, 0)
+	require.Equal(t, "retina-agent-1=6, retina-agent-2=6, retina-agent-3=0", formatShares(byPod))
+}
+
+func TestValidateFleetMetricUnreachablePods(t *testing.T) {
+	unreachable := map[string]error{"retina-agent-2": ErrUnexpectedStatus}
+
+	strict := &ValidateFleetMetric{}
+	err := strict.checkReachable(2, unreachable)
+	require.ErrorIs(t, err, ErrAgentsUnreachable)
+	require.Contains(t, err.Error(), "retina-agent-2")
+	require.NoError(t, strict.checkReachable(2, map[string]error{}))
+
+	// a partial scrape is enough with AllowUnreachablePods, but not no scrape at all
+	lenient := &ValidateFleetMetric{AllowUnreachablePods: true}
+	require.NoError(t, lenient.checkReachable(2, unreachable))
+	require.ErrorIs(t, lenient.checkReachable(1, unreachable), ErrAgentsUnreachable)
+}
+
+func TestPercentile(t *testing.T) {
+	latencies := []time.Duration{}
+	for i := 20; i > 0; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	require.Equal(t, 10*time.Millisecond, percentile(latencies, 0.5))
+	require.Equal(t, 19*time.Millisecond, percentile(latencies, 0.95))
+	require.Equal(t, 20*time.Millisecond, latencies[0], "the latencies are left unsorted")
+	require.Equal(t, 5*time.Millisecond, percentile([]time.Duration{5 * time.Millisecond}, 0.95))
+}
+
+func TestBenchmarkMetricsScrape(t *testing.T) {
+	var scrapes int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		scrapes++
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		_, _ = w.Write([]byte(dnsResponseMetrics))
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	benchmark := &BenchmarkMetricsScrape{
+		PortForwardedRetinaPort: serverURL.Port(),
+		Iterations:              5,
+		Warmup:                  true,
+		MaxP95:                  time.Minute,
+		MaxBytes:                len(dnsResponseMetrics),
+	}
+	require.NoError(t, benchmark.Run())
+	require.Equal(t, 6, scrapes, "the warmup scrape isn't measured")
+	measurements := benchmark.Measurements()
+	require.Equal(t, float64(5), measurements["iterations"])
+	require.Equal(t, float64(len(dnsResponseMetrics)), measurements["max_bytes"])
+	require.Equal(t, float64(2), measurements["series"])
+	require.Positive(t, measurements["p95_seconds"])
+
+	benchmark.MaxBytes = 100
+	require.ErrorIs(t, benchmark.Run(), ErrScrapeTooLarge)
+	require.NotEmpty(t, benchmark.Measurements(), "the measurements are kept when the step fails")
+
+	benchmark.MaxBytes = 0
+	benchmark.MaxP50 = time.Nanosecond
+	require.ErrorIs(t, benchmark.Run(), ErrScrapeTooSlow)
+
+	require.ErrorIs(t, (&BenchmarkMetricsScrape{Iterations: -1}).Prevalidate(), ErrInvalidBenchmark)
+}
+
+func TestValidateMetricAdvanced(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "networkobservability_dns_request_count"}, []string{"query", "query_type"})
+	registry.MustRegister(requests)
+	// pre-existing traffic a stuck exporter would keep serving
+	requests.WithLabelValues("bing.com.", "A").Add(3)
+	server := httptest.NewServer(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	labels := map[string]string{"query": "bing.com.", "query_type": "A"}
+	baseline := &RecordMetricBaseline{PortForwardedRetinaPort: serverURL.Port(), MetricName: "networkobservability_dns_request_count", Labels: labels}
+	advanced := &ValidateMetricAdvanced{Baseline: baseline, Timeout: 100 * time.Millisecond, Interval: 10 * time.Millisecond}
+	require.ErrorIs(t, advanced.Run(), ErrBaselineNotTaken)
+
+	require.NoError(t, baseline.Run())
+	require.InDelta(t, 3, baseline.value, 0)
+	err = advanced.Run()
+	require.ErrorIs(t, err, ErrStaleMetric)
+	require.ErrorIs(t, err, ErrMetricTimeout)
+
+	requests.WithLabelValues("bing.com.", "AAAA").Inc()
+	require.ErrorIs(t, advanced.Run(), ErrStaleMetric, "a series without all of the labels doesn't count")
+
+	requests.WithLabelValues("bing.com.", "A").Inc()
+	require.NoError(t, advanced.Run())
+
+	require.ErrorIs(t, (&ValidateMetricAdvanced{}).Prevalidate(), ErrNoBaseline)
+}
+
+func TestRecordMetricBaselineWithoutSeries(t *testing.T) {
+	server := httptest.NewServer(promhttp.HandlerFor(prometheus.NewRegistry(), promhttp.HandlerOpts{}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	baseline := &RecordMetricBaseline{PortForwardedRetinaPort: serverURL.Port(), MetricName: "networkobservability_dns_request_count"}
+	require.NoError(t, baseline.Run())
+	require.Zero(t, baseline.value)
+	require.True(t, baseline.recorded)
+}
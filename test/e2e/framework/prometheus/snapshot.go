@@ -0,0 +1,71 @@
+package prom
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+)
+
+var ErrSnapshotFailed = fmt.Errorf("metrics snapshot failed")
+
+// SaveMetricsSnapshot writes what the port forwarded agent's metrics endpoint serves to <SnapshotName>.prom
+// in the step's artifact directory, so the state of the metrics at that point of a run can be looked at,
+// or compared with another snapshot, after CI collects it. Without an artifacts directory it does nothing
+type SaveMetricsSnapshot struct {
+	PortForwardedRetinaPort string
+	SnapshotName            string
+
+	artifactDir string
+}
+
+func (s *SaveMetricsSnapshot) SetArtifactDir(dir string) {
+	s.artifactDir = dir
+}
+
+func (s *SaveMetricsSnapshot) Run() error {
+	if s.artifactDir == "" {
+		return nil
+	}
+
+	promAddress := kubernetes.LocalURL(s.PortForwardedRetinaPort, "metrics")
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, promAddress, http.NoBody)
+	if err != nil {
+		return fmt.Errorf("error creating request to %s: %w", promAddress, err)
+	}
+
+	resp, err := metricsClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error getting %s: %w", promAddress, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s answered %s: %w", promAddress, resp.Status, ErrSnapshotFailed)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", promAddress, err)
+	}
+
+	err = types.WriteArtifact(s.artifactDir, s.SnapshotName+".prom", data)
+	if err != nil {
+		return err //nolint:wrapcheck // already names the artifact
+	}
+
+	log.Printf("saved metrics snapshot %s to %s\n", s.SnapshotName, s.artifactDir)
+	return nil
+}
+
+func (s *SaveMetricsSnapshot) Prevalidate() error {
+	return nil
+}
+
+func (s *SaveMetricsSnapshot) Stop() error {
+	return nil
+}
@@ -0,0 +1,117 @@
+package prom
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	promclient "github.com/prometheus/client_model/go"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	defaultWaitForMetricTimeout  = 2 * time.Minute
+	defaultWaitForMetricInterval = 2 * time.Second
+)
+
+var ErrMetricTimeout = fmt.Errorf("timed out waiting for metric")
+
+// WaitForMetric polls the port forwarded agent's metrics endpoint until MetricName has a series with all of Labels,
+// for steps that have to wait on the agent to export what a previous step generated. Timeout and Interval default
+// to 2 minutes and 2 seconds. On timeout, the error lists the series of MetricName that were found and how their
+// labels differed from Labels
+type WaitForMetric struct {
+	PortForwardedRetinaPort string
+	MetricName              string
+	Labels                  map[string]string
+	Timeout                 time.Duration
+	Interval                time.Duration
+}
+
+func (w *WaitForMetric) Run() error {
+	return w.RunContext(context.Background())
+}
+
+// RunContext polls like Run, giving up once ctx is cancelled
+func (w *WaitForMetric) RunContext(ctx context.Context) error {
+	timeout := w.Timeout
+	if timeout == 0 {
+		timeout = defaultWaitForMetricTimeout
+	}
+	interval := w.Interval
+	if interval == 0 {
+		interval = defaultWaitForMetricInterval
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	promAddress := kubernetes.LocalURL(w.PortForwardedRetinaPort, "metrics")
+	start := time.Now()
+	var lastErr error
+	err := wait.PollUntilContextCancel(ctx, interval, true, func(ctx context.Context) (bool, error) {
+		metrics, scrapeErr := getAllPrometheusMetricsFromURL(ctx, promAddress)
+		if scrapeErr != nil {
+			// a scrape cut short by the deadline says nothing new about the metric
+			if ctx.Err() == nil {
+				lastErr = scrapeErr
+			}
+			return false, nil
+		}
+
+		lastErr = verifyMetricWithLabels(w.MetricName, metrics, w.Labels)
+		return lastErr == nil, nil
+	})
+	if err != nil {
+		return fmt.Errorf("%w after %s: %w", ErrMetricTimeout, time.Since(start).Round(time.Second), errors.Join(err, lastErr))
+	}
+
+	log.Printf("found %s series matching %s after %s\n", w.MetricName, formatLabels(w.Labels), time.Since(start).Round(time.Second))
+	return nil
+}
+
+// verifyMetricWithLabels looks for a series of metricName with all of labels, and any others. When none matches,
+// the error lists every series of metricName that was found with the labels it was rejected on
+func verifyMetricWithLabels(metricName string, data map[string]*promclient.MetricFamily, labels map[string]string) error {
+	family, ok := data[metricName]
+	if !ok || len(family.GetMetric()) == 0 {
+		return fmt.Errorf("no series of %s found with %s: %w", metricName, formatLabels(labels), ErrNoMetricFound)
+	}
+
+	rejected := []string{}
+	for _, metric := range family.GetMetric() {
+		metricLabels := map[string]string{}
+		for _, label := range metric.GetLabel() {
+			metricLabels[label.GetName()] = label.GetValue()
+		}
+
+		// only the expected labels have to match, the series may have others
+		present := map[string]string{}
+		for name := range labels {
+			if value, exists := metricLabels[name]; exists {
+				present[name] = value
+			}
+		}
+		mismatches := labelMismatches(labels, present)
+		if len(mismatches) == 0 {
+			return nil
+		}
+
+		rejected = append(rejected, fmt.Sprintf("\t%s = %s: %s", formatLabels(metricLabels), formatValue(metric), strings.Join(mismatches, ", ")))
+	}
+
+	return fmt.Errorf("no series with %s among %d series of %s: %w\n%s",
+		formatLabels(labels), len(rejected), metricName, ErrNoMetricFound, strings.Join(rejected, "\n"))
+}
+
+func (w *WaitForMetric) Prevalidate() error {
+	return nil
+}
+
+func (w *WaitForMetric) Stop() error {
+	return nil
+}
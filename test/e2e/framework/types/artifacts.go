@@ -0,0 +1,116 @@
+package types
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+const (
+	artifactDirPerms  = 0o755
+	artifactFilePerms = 0o644
+
+	// recordingArtifact is where the job's recording is written in the artifacts directory, unless RecordTo says otherwise
+	recordingArtifact = "recording.json"
+
+	// jobArtifactDir holds the artifacts of steps outside of any scenario or suite
+	jobArtifactDir = "job"
+
+	// failureArtifactDir holds the artifacts of a scenario's failure diagnostics, within the scenario's directory
+	failureArtifactDir = "failure"
+)
+
+var artifactsDir = flag.String("artifacts-dir", "", "directory diagnostic steps write logs, captures and metric snapshots into, one directory per scenario and step")
+
+// An ArtifactWriter is a step writing diagnostics, such as pod logs or metric snapshots, for CI to collect.
+// When the job has an artifacts directory, it gives each such step its own directory in it before running it;
+// otherwise the directory is left empty and the step writes nothing
+type ArtifactWriter interface {
+	SetArtifactDir(dir string)
+}
+
+// SetArtifactsDir makes the job give its ArtifactWriter steps a directory under dir, at
+// <scenario>/<step index>-<step type>, and write its recording there too
+func (j *Job) SetArtifactsDir(dir string) {
+	j.artifactsDir = dir
+}
+
+func (j *Job) assignArtifactDirs() {
+	if j.artifactsDir == "" {
+		return
+	}
+
+	for i, wrapper := range j.Steps {
+		writer, ok := wrapper.Step.(ArtifactWriter)
+		if !ok {
+			continue
+		}
+		writer.SetArtifactDir(j.artifactDir(i, wrapper))
+	}
+
+	diagnostics := make(map[*Scenario]int)
+	for _, wrapper := range j.diagnosticSteps() {
+		scenario := j.Scenarios[wrapper]
+		index := diagnostics[scenario]
+		diagnostics[scenario]++
+
+		writer, ok := wrapper.Step.(ArtifactWriter)
+		if !ok {
+			continue
+		}
+		step := fmt.Sprintf("%03d-%s", index, reflect.TypeOf(wrapper.Step).Elem().Name())
+		writer.SetArtifactDir(filepath.Join(j.artifactsDir, artifactDirName(scenario.name), failureArtifactDir, step))
+	}
+}
+
+func (j *Job) artifactDir(index int, wrapper *StepWrapper) string {
+	group := jobArtifactDir
+	if scenario, exists := j.Scenarios[wrapper]; exists {
+		group = scenario.name
+	} else if suite, exists := j.Suites[wrapper]; exists {
+		group = suite.name
+	}
+
+	step := fmt.Sprintf("%03d-%s", index, reflect.TypeOf(wrapper.Step).Elem().Name())
+	return filepath.Join(j.artifactsDir, artifactDirName(group), step)
+}
+
+// artifactDirName turns a scenario name like "Drop Metrics" into a directory name like "drop-metrics"
+func artifactDirName(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteRune('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// WriteArtifact writes data to name in dir, creating dir if needed. It does nothing when dir is empty,
+// so steps can call it whether or not the job has an artifacts directory
+func WriteArtifact(dir, name string, data []byte) error {
+	if dir == "" {
+		return nil
+	}
+
+	err := os.MkdirAll(dir, artifactDirPerms)
+	if err != nil {
+		return fmt.Errorf("error creating artifact directory \"%s\": %w", dir, err)
+	}
+
+	path := filepath.Join(dir, name)
+	err = os.WriteFile(path, data, artifactFilePerms)
+	if err != nil {
+		return fmt.Errorf("error writing artifact \"%s\": %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package types
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type ArtifactStep struct {
+	Name string
+
+	artifactDir string
+}
+
+func (a *ArtifactStep) Run() error {
+	return WriteArtifact(a.artifactDir, "output.txt", []byte(a.Name))
+}
+
+func (a *ArtifactStep) Prevalidate() error {
+	return nil
+}
+
+func (a *ArtifactStep) Stop() error {
+	return nil
+}
+
+func (a *ArtifactStep) SetArtifactDir(dir string) {
+	a.artifactDir = dir
+}
+
+func TestArtifactsDir(t *testing.T) {
+	dir := t.TempDir()
+
+	job := NewJob("Validate steps write artifacts per scenario and step")
+	job.AddStep(&ArtifactStep{Name: "top level"}, &StepOptions{SkipSavingParametersToJob: true})
+	job.AddScenario(NewScenario("Drop Metrics (IPv4)",
+		&StepWrapper{Step: &ArtifactStep{Name: "in scenario"}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+	))
+	job.SetArtifactsDir(dir)
+	require.NoError(t, job.Run())
+
+	data, err := os.ReadFile(filepath.Join(dir, "job", "000-ArtifactStep", "output.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "top level", string(data))
+
+	data, err = os.ReadFile(filepath.Join(dir, "drop-metrics-ipv4", "001-ArtifactStep", "output.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "in scenario", string(data))
+
+	recording, err := LoadRecording(filepath.Join(dir, recordingArtifact))
+	require.NoError(t, err)
+	require.Len(t, recording.Steps, 2)
+}
+
+func TestNoArtifactsDir(t *testing.T) {
+	step := &ArtifactStep{Name: "no artifacts"}
+	job := NewJob("Validate steps write nothing without an artifacts directory")
+	job.AddStep(step, &StepOptions{SkipSavingParametersToJob: true})
+	require.NoError(t, job.Run())
+	require.Empty(t, step.artifactDir)
+}
@@ -0,0 +1,139 @@
+package types
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+var ErrStepCancelled = fmt.Errorf("step cancelled")
+
+// A ContextStep is a step whose Run takes a context, cancelled when the step should give up: once another step of
+// its scenario fails, once its scenario ends, or once the job is interrupted. A background step started with it
+// should shut down when it is cancelled, so a failure or an aborted run doesn't leave it behind, e.g. a port forward
+// to a cluster that is torn down. Steps that only implement Step keep running with Run, and are abandoned
+// like a step past its timeout when their context is cancelled
+type ContextStep interface {
+	Step
+	RunContext(ctx context.Context) error
+}
+
+// scenarioContexts are the contexts of the scenarios running, each cancelled when one of the scenario's steps fails
+// or the scenario ends
+type scenarioContexts struct {
+	mu       sync.Mutex
+	contexts map[*Scenario]context.Context
+	cancels  map[*Scenario]context.CancelFunc
+}
+
+// RunContext runs the job like Run, with its steps given contexts derived from ctx, so cancelling ctx cancels
+// the steps running and fails the job. Cleanup, failure diagnostics and suite teardown steps still run once ctx
+// is cancelled, as they clean up after the steps that didn't get to
+func (j *Job) RunContext(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	j.ctx = ctx
+	j.scenarioContexts = &scenarioContexts{
+		contexts: make(map[*Scenario]context.Context),
+		cancels:  make(map[*Scenario]context.CancelFunc),
+	}
+	return j.run()
+}
+
+// Run runs the job until it's done, or until the process is interrupted or terminated, after which a second
+// signal kills it as usual
+func (j *Job) Run() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return j.RunContext(ctx)
+}
+
+// stepContext returns the context a step runs with: its scenario's for the scenario's own steps, unless it outlives
+// the scenario, otherwise the job's, or one that isn't cancelled with it for Stop steps and steps cleaning up
+func (j *Job) stepContext(wrapper *StepWrapper) context.Context {
+	if j.ctx == nil {
+		return context.Background()
+	}
+
+	if _, isStop := wrapper.Step.(*Stop); isStop {
+		return context.WithoutCancel(j.ctx)
+	}
+	if scenario, exists := j.Scenarios[wrapper]; exists && !wrapper.Opts.OutlivesScenario {
+		if scenario.isCleanup(wrapper) || scenario.isDiagnostic(wrapper) {
+			return context.WithoutCancel(j.ctx)
+		}
+		return j.scenarioContext(scenario)
+	}
+	if suite, exists := j.Suites[wrapper]; exists && suite.isTeardown(wrapper) {
+		return context.WithoutCancel(j.ctx)
+	}
+	return j.ctx
+}
+
+// scenarioContext returns the context of the scenario's current run, starting one if it isn't running
+func (j *Job) scenarioContext(scenario *Scenario) context.Context {
+	s := j.scenarioContexts
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ctx, exists := s.contexts[scenario]; exists {
+		return ctx
+	}
+	ctx, cancel := context.WithCancel(j.ctx)
+	s.contexts[scenario] = ctx
+	s.cancels[scenario] = cancel
+	return ctx
+}
+
+// cancelScenario cancels the context of the scenario's current run, after one of its steps failed, so its other
+// steps still running and its background steps give up
+func (j *Job) cancelScenario(scenario *Scenario) {
+	if j.scenarioContexts == nil {
+		return
+	}
+	s := j.scenarioContexts
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cancel, exists := s.cancels[scenario]; exists {
+		cancel()
+	}
+}
+
+// scenarioCancelled reports whether the scenario's current run was cancelled
+func (j *Job) scenarioCancelled(scenario *Scenario) bool {
+	if j.scenarioContexts == nil {
+		return false
+	}
+	s := j.scenarioContexts
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx, exists := s.contexts[scenario]
+	return exists && ctx.Err() != nil
+}
+
+// endScenario cancels the context of the scenario's run once it's over, so the scenario gets a new one if it runs
+// again, as in a soak
+func (j *Job) endScenario(scenario *Scenario) {
+	if j.scenarioContexts == nil {
+		return
+	}
+	s := j.scenarioContexts
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cancel, exists := s.cancels[scenario]; exists {
+		cancel()
+		delete(s.contexts, scenario)
+		delete(s.cancels, scenario)
+	}
+}
@@ -0,0 +1,190 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// ContextBackground is a background step shutting down when its context is cancelled
+type ContextBackground struct {
+	cancelled *atomic.Bool
+}
+
+func (c *ContextBackground) Run() error {
+	return c.RunContext(context.Background())
+}
+
+func (c *ContextBackground) RunContext(ctx context.Context) error {
+	go func() {
+		<-ctx.Done()
+		c.cancelled.Store(true)
+	}()
+	return nil
+}
+
+func (c *ContextBackground) Stop() error {
+	return nil
+}
+
+func (c *ContextBackground) Prevalidate() error {
+	return nil
+}
+
+// BlockingStep is a step without a context, running until it's released
+type BlockingStep struct {
+	release chan struct{}
+}
+
+func (b *BlockingStep) Run() error {
+	<-b.release
+	return nil
+}
+
+func (b *BlockingStep) Stop() error {
+	return nil
+}
+
+func (b *BlockingStep) Prevalidate() error {
+	return nil
+}
+
+// errGaveUp is what GivingUpStep returns once its context is cancelled
+var errGaveUp = errors.New("gave up")
+
+// GivingUpStep is a step returning its own error once its context is cancelled
+type GivingUpStep struct{}
+
+func (g *GivingUpStep) Run() error {
+	return g.RunContext(context.Background())
+}
+
+func (g *GivingUpStep) RunContext(ctx context.Context) error {
+	<-ctx.Done()
+	return errGaveUp
+}
+
+func (g *GivingUpStep) Stop() error {
+	return nil
+}
+
+func (g *GivingUpStep) Prevalidate() error {
+	return nil
+}
+
+func TestScenarioFailureCancelsBackgroundSteps(t *testing.T) {
+	var calls []string
+	cancelled := &atomic.Bool{}
+	job := NewJob("Validate a failing step cancels the background steps of its scenario")
+	job.AddScenario(NewScenario("Failing Scenario",
+		&StepWrapper{Step: &ContextBackground{cancelled: cancelled}, Opts: &StepOptions{RunInBackgroundWithID: "background"}},
+		&StepWrapper{Step: &RecordStep{Name: "failing", Fail: true, calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+		&StepWrapper{Step: &Stop{BackgroundID: "background"}},
+	))
+
+	err := job.Run()
+	require.ErrorIs(t, err, errFailingStep)
+	// it shut down with its scenario, so didn't leak
+	require.NotErrorIs(t, err, ErrLeakedBackgroundSteps)
+	require.Eventually(t, cancelled.Load, time.Second, 10*time.Millisecond)
+}
+
+func TestScenarioEndCancelsBackgroundSteps(t *testing.T) {
+	cancelled := &atomic.Bool{}
+	job := NewJob("Validate a scenario's background steps are cancelled once it ends")
+	job.AddScenario(NewScenario("Passing Scenario",
+		&StepWrapper{Step: &ContextBackground{cancelled: cancelled}, Opts: &StepOptions{RunInBackgroundWithID: "background"}},
+		&StepWrapper{Step: &Stop{BackgroundID: "background"}},
+	))
+	job.AddStep(&Sleep{Duration: 100 * time.Millisecond}, nil)
+
+	require.NoError(t, job.Run())
+	require.True(t, cancelled.Load())
+}
+
+func TestRunContextCancelsRunningSteps(t *testing.T) {
+	var calls []string
+	release := make(chan struct{})
+	defer close(release)
+
+	job := NewJob("Validate cancelling a job's context cancels its running step and still cleans up")
+	job.AddScenario(NewScenario("Cancelled Scenario",
+		&StepWrapper{Step: &Sleep{Duration: time.Minute}},
+	).WithCleanup(
+		&StepWrapper{Step: &RecordStep{Name: "cleanup", calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+	))
+	job.AddStep(&BlockingStep{release: release}, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := job.RunContext(ctx)
+	require.ErrorIs(t, err, ErrStepCancelled)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(start), time.Second)
+	require.Equal(t, []string{"cleanup"}, calls)
+}
+
+func TestRunContextAbandonsStepsWithoutContext(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	job := NewJob("Validate a step without a context is abandoned once the job's context is cancelled")
+	job.AddStep(&BlockingStep{release: release}, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, job.RunContext(ctx), ErrStepCancelled)
+}
+
+func TestParallelFailureCancelsOtherSteps(t *testing.T) {
+	var calls []string
+	job := NewJob("Validate a failing step of a parallel group cancels the others")
+	job.AddScenario(NewScenario("Parallel Scenario",
+		&StepWrapper{Step: NewParallel(
+			&StepWrapper{Step: &RecordStep{Name: "failing", Fail: true, calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+			&StepWrapper{Step: &Sleep{Duration: time.Minute}},
+		)},
+	))
+
+	start := time.Now()
+	err := job.Run()
+	require.ErrorIs(t, err, errFailingStep)
+	require.ErrorIs(t, err, ErrStepCancelled)
+	require.Less(t, time.Since(start), time.Second)
+}
+
+type PanickingStep struct{}
+
+func (p *PanickingStep) Run() error {
+	panic("step panicked")
+}
+
+func (p *PanickingStep) Stop() error {
+	return nil
+}
+
+func (p *PanickingStep) Prevalidate() error {
+	return nil
+}
+
+func TestPanickingStepPanicsJob(t *testing.T) {
+	job := NewJob("Validate a panicking step panics the job's caller")
+	job.AddStep(&PanickingStep{}, nil)
+	require.PanicsWithValue(t, "step panicked", func() { _ = job.Run() })
+}
+
+func TestCancelledContextStepFailsWithItsError(t *testing.T) {
+	job := NewJob("Validate a step giving up on its cancelled context fails as cancelled, even when expected to error")
+	job.AddStep(&GivingUpStep{}, &StepOptions{ExpectError: true})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := job.RunContext(ctx)
+	require.ErrorIs(t, err, ErrStepCancelled)
+	require.ErrorIs(t, err, errGaveUp)
+}
@@ -0,0 +1,46 @@
+package types
+
+import (
+	"fmt"
+)
+
+// StepError is the error a job fails with when one of its steps does: the type of the step, its index among the
+// job's steps, -1 for a failure diagnostic, soak or parallel step, and the name of its scenario, if any, around why
+// it failed. The message is the one of the failure, prefixed with the scenario, while the cause is left to
+// errors.Is and errors.As, e.g. to retry on a kubernetes.ErrPortForwardFailed but not on a prom.ErrNoMetricFound
+type StepError struct {
+	Type     string
+	Index    int
+	Scenario string
+	Err      error
+}
+
+func (e *StepError) Error() string {
+	if e.Scenario == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("scenario \"%s\": %s", e.Scenario, e.Err)
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// stepError wraps the failure of a step in a StepError
+func (j *Job) stepError(wrapper *StepWrapper, err error) error {
+	stepErr := &StepError{Type: stepTypeName(wrapper), Index: j.stepIndex(wrapper), Err: err}
+	if scenario, exists := j.Scenarios[wrapper]; exists {
+		stepErr.Scenario = scenario.name
+	}
+	return stepErr
+}
+
+// stepIndex is the index of the step among the job's steps, -1 if it isn't one of them
+func (j *Job) stepIndex(wrapper *StepWrapper) int {
+	for i, step := range j.Steps {
+		if step == wrapper {
+			return i
+		}
+	}
+	return -1
+}
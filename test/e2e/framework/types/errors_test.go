@@ -0,0 +1,37 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepError(t *testing.T) {
+	runs := 0
+	job := NewJob("Validate a failing step fails the job with its type, index and scenario")
+	job.AddStep(&Sleep{Duration: time.Millisecond}, nil)
+	job.AddScenario(NewScenario("Failing Scenario", &StepWrapper{Step: &FlakyStep{Failures: 1, runs: &runs}}))
+
+	err := job.Run()
+	var stepErr *StepError
+	require.ErrorAs(t, err, &stepErr)
+	require.Equal(t, "FlakyStep", stepErr.Type)
+	require.Equal(t, 1, stepErr.Index)
+	require.Equal(t, "Failing Scenario", stepErr.Scenario)
+	require.ErrorIs(t, err, ErrNonNilError)
+	require.Contains(t, err.Error(), "scenario \"Failing Scenario\": did not expect error from step FlakyStep but got error")
+}
+
+func TestStepErrorExpectingError(t *testing.T) {
+	job := NewJob("Validate a step expected to error and succeeding fails the job with a StepError")
+	job.AddStep(&Sleep{Duration: time.Millisecond}, &StepOptions{ExpectError: true})
+
+	err := job.Run()
+	var stepErr *StepError
+	require.ErrorAs(t, err, &stepErr)
+	require.Equal(t, "Sleep", stepErr.Type)
+	require.Equal(t, 0, stepErr.Index)
+	require.Empty(t, stepErr.Scenario)
+	require.ErrorIs(t, err, ErrNilError)
+}
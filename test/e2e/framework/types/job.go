@@ -1,23 +1,35 @@
 package types
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
+	"path/filepath"
 	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 )
 
 var (
-	ErrEmptyDescription    = fmt.Errorf("job description is empty")
-	ErrNonNilError         = fmt.Errorf("expected error to be non-nil")
-	ErrNilError            = fmt.Errorf("expected error to be nil")
-	ErrMissingParameter    = fmt.Errorf("missing parameter")
-	ErrParameterAlreadySet = fmt.Errorf("parameter already set")
-	ErrOrphanSteps         = fmt.Errorf("background steps with no corresponding stop")
-	ErrCannotStopStep      = fmt.Errorf("cannot stop step")
-	ErrMissingBackroundID  = fmt.Errorf("missing background id")
-	ErrNoValue             = fmt.Errorf("empty parameter not found saved in values")
-	ErrEmptyScenarioName   = fmt.Errorf("scenario name is empty")
+	ErrEmptyDescription      = fmt.Errorf("job description is empty")
+	ErrNonNilError           = fmt.Errorf("expected error to be non-nil")
+	ErrNilError              = fmt.Errorf("expected error to be nil")
+	ErrInvalidStep           = fmt.Errorf("step failed prevalidation")
+	ErrMissingParameter      = fmt.Errorf("missing parameter")
+	ErrParameterAlreadySet   = fmt.Errorf("parameter already set")
+	ErrOrphanSteps           = fmt.Errorf("background steps with no corresponding stop")
+	ErrCannotStopStep        = fmt.Errorf("cannot stop step")
+	ErrMissingBackroundID    = fmt.Errorf("missing background id")
+	ErrNoValue               = fmt.Errorf("empty parameter not found saved in values")
+	ErrEmptyScenarioName     = fmt.Errorf("scenario name is empty")
+	ErrStepTimeout           = fmt.Errorf("step timed out")
+	ErrInvalidRetry          = fmt.Errorf("retry needs at least one attempt")
+	ErrRetriesExhausted      = fmt.Errorf("step failed on every attempt")
+	ErrBackgroundDiagnostics = fmt.Errorf("failure diagnostics can't start or stop background steps")
+	ErrLeakedBackgroundSteps = fmt.Errorf("background steps not stopped by the end of their scenario")
 )
 
 // A Job is a logical grouping of steps, options and values
@@ -27,6 +39,29 @@ type Job struct {
 	Steps           []*StepWrapper
 	BackgroundSteps map[string]*StepWrapper
 	Scenarios       map[*StepWrapper]*Scenario
+	Suites          map[*StepWrapper]*Suite
+
+	recordPath string
+	recording  *Recording
+	replay     *Recording
+
+	reportPath      string
+	junitReportPath string
+	report          *Report
+
+	includeTags []string
+	excludeTags []string
+
+	artifactsDir string
+
+	soaks []*Soak
+
+	// the background steps started and not stopped yet by their ID, as a background step's Stop only works once it started
+	runningBackgroundSteps sync.Map
+
+	// the context of the run, and those of the scenarios running, see RunContext
+	ctx              context.Context
+	scenarioContexts *scenarioContexts
 }
 
 // A StepWrapper is a coupling of a step and it's options
@@ -38,9 +73,14 @@ type StepWrapper struct {
 // A Scenario is a logical grouping of steps, used to describe a scenario such as "test drop metrics"
 // which will require port forwarding, exec'ing, scraping, etc.
 type Scenario struct {
-	name   string
-	steps  []*StepWrapper
-	values *JobValues
+	name          string
+	tags          []string
+	preconditions []*StepWrapper
+	setup         []*StepWrapper
+	steps         []*StepWrapper
+	cleanup       []*StepWrapper
+	diagnostics   []*StepWrapper
+	values        *JobValues
 }
 
 func NewScenario(name string, steps ...*StepWrapper) *Scenario {
@@ -55,10 +95,98 @@ func NewScenario(name string, steps ...*StepWrapper) *Scenario {
 	}
 }
 
+// WithTags labels the scenario, e.g. "dns" or "reinstall", so a run can include or exclude it by tag
+func (s *Scenario) WithTags(tags ...string) *Scenario {
+	s.tags = append(s.tags, tags...)
+	return s
+}
+
+// WithSetup adds steps run before the scenario's steps, after its preconditions, such as creating its workloads or
+// labelling its namespace, keeping the steps to what the scenario validates. A failing setup step fails the scenario
+// like any of its steps, and its cleanup steps still run, so they should also undo a setup that only partly ran
+func (s *Scenario) WithSetup(steps ...*StepWrapper) *Scenario {
+	s.setup = append(s.setup, steps...)
+	return s
+}
+
+// WithCleanup adds steps run after the scenario's steps, such as stopping its port forward and deleting its
+// workloads, which also run when one of its steps fails. A failing cleanup step is logged, and the remaining ones
+// still run, without masking the failure of the scenario. Stop steps of background steps that never started are skipped
+func (s *Scenario) WithCleanup(steps ...*StepWrapper) *Scenario {
+	s.cleanup = append(s.cleanup, steps...)
+	return s
+}
+
+// WithFailureDiagnostics adds steps run only when one of the scenario's steps fails, before its cleanup steps, such
+// as printing the logs of the agent and workload pods involved. They aren't run when the scenario passes, and a
+// failing diagnostic step is logged without masking the failure of the scenario
+func (s *Scenario) WithFailureDiagnostics(steps ...*StepWrapper) *Scenario {
+	s.diagnostics = append(s.diagnostics, steps...)
+	return s
+}
+
+// allSteps returns the scenario's preconditions, then its setup steps, then its steps, then its cleanup steps
+func (s *Scenario) allSteps() []*StepWrapper {
+	return slices.Concat(s.preconditions, s.setup, s.steps, s.cleanup)
+}
+
+// validateBackgroundStops checks the scenario stops every background step it starts, within its steps or cleanup,
+// except those set to outlive it
+func (s *Scenario) validateBackgroundStops() error {
+	var started []string
+	stopped := make(map[string]bool)
+	for _, stepw := range flattenSteps(s.allSteps()) {
+		if stop, isStop := stepw.Step.(*Stop); isStop {
+			stopped[stop.BackgroundID] = true
+		} else if stepw.Opts.RunInBackgroundWithID != "" && !stepw.Opts.OutlivesScenario {
+			started = append(started, stepw.Opts.RunInBackgroundWithID)
+		}
+	}
+
+	var leaked []string
+	for _, id := range started {
+		if !stopped[id] {
+			leaked = append(leaked, id)
+		}
+	}
+	if len(leaked) > 0 {
+		return fmt.Errorf("scenario %s doesn't stop background steps %s: %w", s.name, strings.Join(leaked, ", "), ErrLeakedBackgroundSteps)
+	}
+	return nil
+}
+
+func (s *Scenario) isCleanup(stepw *StepWrapper) bool {
+	return slices.Contains(s.cleanup, stepw)
+}
+
+func (s *Scenario) isDiagnostic(stepw *StepWrapper) bool {
+	return slices.Contains(s.diagnostics, stepw)
+}
+
+// selected reports whether the scenario has one of the included tags, if any are given, and none of the excluded ones
+func (s *Scenario) selected(include, exclude []string) bool {
+	for _, tag := range s.tags {
+		if slices.Contains(exclude, tag) {
+			return false
+		}
+	}
+	if len(include) == 0 {
+		return true
+	}
+	for _, tag := range s.tags {
+		if slices.Contains(include, tag) {
+			return true
+		}
+	}
+	return false
+}
+
 func (j *Job) GetPrettyStepName(step *StepWrapper) string {
 	prettyname := reflect.TypeOf(step.Step).Elem().Name()
 	if j.Scenarios[step] != nil {
 		prettyname = fmt.Sprintf("%s (scenario: %s)", prettyname, j.Scenarios[step].name)
+	} else if j.Suites[step] != nil {
+		prettyname = fmt.Sprintf("%s (suite: %s)", prettyname, j.Suites[step].name)
 	}
 	return prettyname
 }
@@ -87,14 +215,68 @@ func NewJob(description string) *Job {
 		},
 		BackgroundSteps: make(map[string]*StepWrapper),
 		Scenarios:       make(map[*StepWrapper]*Scenario),
+		Suites:          make(map[*StepWrapper]*Suite),
 		Description:     description,
 	}
 }
 
 func (j *Job) AddScenario(scenario *Scenario) {
-	for i, step := range scenario.steps {
+	for _, step := range scenario.allSteps() {
+		j.Steps = append(j.Steps, step)
+		j.Scenarios[step] = scenario
+	}
+	for _, step := range scenario.diagnostics {
+		j.Scenarios[step] = scenario
+	}
+}
+
+// AddSuite adds the suite's setup steps, then its scenarios, then its teardown steps
+func (j *Job) AddSuite(suite *Suite) {
+	steps := append([]*StepWrapper{}, suite.setup...)
+	for _, scenario := range suite.scenarios {
+		for _, step := range append(scenario.allSteps(), scenario.diagnostics...) {
+			j.Scenarios[step] = scenario
+		}
+		steps = append(steps, scenario.allSteps()...)
+	}
+	steps = append(steps, suite.teardown...)
+
+	for _, step := range steps {
 		j.Steps = append(j.Steps, step)
-		j.Scenarios[scenario.steps[i]] = scenario
+		j.Suites[step] = suite
+	}
+}
+
+// FilterTags restricts the scenarios run by the job to those tagged with one of include, when it is non-empty,
+// and drops scenarios tagged with any of exclude. Steps outside of scenarios, such as installing Retina or
+// a suite's setup and teardown, always run
+func (j *Job) FilterTags(include, exclude []string) {
+	j.includeTags = include
+	j.excludeTags = exclude
+}
+
+// applyTagFilter removes the steps of scenarios deselected by FilterTags
+func (j *Job) applyTagFilter() {
+	if len(j.includeTags) == 0 && len(j.excludeTags) == 0 {
+		return
+	}
+
+	steps := make([]*StepWrapper, 0, len(j.Steps))
+	skipped := make(map[*Scenario]bool)
+	for _, step := range j.Steps {
+		if scenario, exists := j.Scenarios[step]; exists && !scenario.selected(j.includeTags, j.excludeTags) {
+			if !skipped[scenario] {
+				log.Printf("skipping scenario %s, deselected by tags %v", scenario.name, scenario.tags)
+				skipped[scenario] = true
+			}
+			continue
+		}
+		steps = append(steps, step)
+	}
+	j.Steps = steps
+
+	for _, soak := range j.soaks {
+		soak.filterTags(j.includeTags, j.excludeTags)
 	}
 }
 
@@ -156,37 +338,392 @@ func (j *Job) GetValues(stepw *StepWrapper, key string) string {
 	return j.values.Get(key)
 }
 
-func (j *Job) Run() error {
+func (j *Job) run() (err error) {
 	if j.Description == "" {
 		return ErrEmptyDescription
 	}
 
+	j.applyTagFilter()
+
 	// validate all steps in the job, making sure parameters are set/validated etc.
-	err := j.Validate()
+	err = j.Validate()
 	if err != nil {
 		return err // nolint:wrapcheck // don't wrap error, wouldn't provide any more context than the error itself
 	}
 
-	for _, wrapper := range j.Steps {
+	if j.replay != nil {
+		err = j.applyRecording()
+		if err != nil {
+			return err
+		}
+	}
+
+	j.assignArtifactDirs()
+	if j.recordPath == "" && j.artifactsDir != "" {
+		j.recordPath = filepath.Join(j.artifactsDir, recordingArtifact)
+	}
+
+	if j.recordPath != "" {
+		j.recording = j.newRecording()
+		defer func() {
+			err = errors.Join(err, j.recording.write(j.recordPath))
+		}()
+	}
+
+	if j.artifactsDir != "" {
+		if j.reportPath == "" {
+			j.reportPath = filepath.Join(j.artifactsDir, reportArtifact)
+		}
+		if j.junitReportPath == "" {
+			j.junitReportPath = filepath.Join(j.artifactsDir, junitArtifact)
+		}
+	}
+
+	if j.reportPath != "" || j.junitReportPath != "" {
+		j.report = j.newReport()
+		defer func() {
+			j.report.finish(j)
+			if j.reportPath != "" {
+				err = errors.Join(err, j.report.write(j.reportPath))
+			}
+			if j.junitReportPath != "" {
+				err = errors.Join(err, j.report.writeJUnit(j.junitReportPath))
+			}
+		}()
+	}
+
+	// check every step's parameters before running any, so a misconfigured step fails the job before the
+	// earlier steps create resources
+	for i, wrapper := range append(j.Steps, j.diagnosticSteps()...) {
 		err := wrapper.Step.Prevalidate()
 		if err != nil {
-			return err //nolint:wrapcheck // don't wrap error, wouldn't provide any more context than the error itself
+			return fmt.Errorf("step %d \"%s\": %w: %w", i, j.GetPrettyStepName(wrapper), ErrInvalidStep, err)
 		}
 	}
 
-	for _, wrapper := range j.Steps {
-		j.responseDivider(wrapper)
-		err := wrapper.Step.Run()
-		if wrapper.Opts.ExpectError && err == nil {
-			return fmt.Errorf("expected error from step %s but got nil: %w", reflect.TypeOf(wrapper.Step).Elem().Name(), ErrNilError)
-		} else if !wrapper.Opts.ExpectError && err != nil {
-			return fmt.Errorf("did not expect error from step %s but got error: %w", reflect.TypeOf(wrapper.Step).Elem().Name(), err)
+	// suites and scenarios with a step that already ran, whose teardown or cleanup must run if a later step fails
+	startedSuites := make(map[*Suite]bool)
+	startedScenarios := make(map[*Scenario]bool)
+
+	// the scenario a precondition skipped, whose remaining steps are reported as skipped
+	var skipped *Scenario
+	var skippedFor string
+
+	for i, wrapper := range j.Steps {
+		if scenario, exists := j.Scenarios[wrapper]; exists && scenario == skipped {
+			if j.report != nil {
+				j.report.add(j, wrapper, false, time.Time{}, nil, skippedFor)
+			}
+			continue
+		}
+
+		if suite, exists := j.Suites[wrapper]; exists {
+			startedSuites[suite] = true
+		}
+		if scenario, exists := j.Scenarios[wrapper]; exists {
+			startedScenarios[scenario] = true
+		}
+
+		err := j.runStep(wrapper)
+		if scenario, exists := j.Scenarios[wrapper]; exists && err != nil {
+			if reason, skips := scenario.skips(wrapper, err); skips {
+				log.Printf("skipping scenario %s: %s", scenario.name, reason)
+				skipped, skippedFor = scenario, reason
+				delete(startedScenarios, scenario)
+				j.endScenario(scenario)
+				continue
+			}
+		}
+		if err != nil {
+			if scenario, exists := j.Scenarios[wrapper]; exists {
+				j.runDiagnostics(scenario)
+			}
+			err = errors.Join(err, j.runPendingTeardowns(j.Steps[i+1:], startedSuites, startedScenarios))
+			return errors.Join(err, j.checkStartedScenariosLeaks(startedScenarios))
+		}
+
+		// the scenario ends once the next step isn't one of its own
+		if scenario, exists := j.Scenarios[wrapper]; exists && (i+1 == len(j.Steps) || j.Scenarios[j.Steps[i+1]] != scenario) {
+			err = j.checkBackgroundLeaks(scenario)
+			j.endScenario(scenario)
+			if err != nil {
+				return errors.Join(err, j.runPendingTeardowns(j.Steps[i+1:], startedSuites, startedScenarios))
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkBackgroundLeaks fails with the IDs of the background steps the scenario started and didn't stop, such as
+// a port forward whose Stop was skipped by a failing step, unless they are set to outlive the scenario. A
+// ContextStep whose scenario was cancelled shut down with it, so doesn't count
+func (j *Job) checkBackgroundLeaks(scenario *Scenario) error {
+	cancelled := j.scenarioCancelled(scenario)
+	var leaked []string
+	j.runningBackgroundSteps.Range(func(id, value any) bool {
+		wrapper := value.(*StepWrapper)
+		if j.Scenarios[wrapper] != scenario || wrapper.Opts.OutlivesScenario {
+			return true
+		}
+		if _, isContextStep := wrapper.Step.(ContextStep); !isContextStep || !cancelled {
+			leaked = append(leaked, id.(string))
+		}
+		return true
+	})
+	if len(leaked) == 0 {
+		return nil
+	}
+
+	slices.Sort(leaked)
+	return fmt.Errorf("scenario %s ended with background steps %s still running: %w", scenario.name, strings.Join(leaked, ", "), ErrLeakedBackgroundSteps)
+}
+
+// checkStartedScenariosLeaks checks the started scenarios for leaked background steps once a failure ended the run,
+// in the order they ran
+func (j *Job) checkStartedScenariosLeaks(startedScenarios map[*Scenario]bool) error {
+	var errs []error
+	for _, scenario := range j.scenarios() {
+		if startedScenarios[scenario] {
+			errs = append(errs, j.checkBackgroundLeaks(scenario))
 		}
 	}
+	return errors.Join(errs...)
+}
+
+func (j *Job) runStep(wrapper *StepWrapper) error {
+	return j.runStepContext(j.stepContext(wrapper), wrapper)
+}
+
+// runStepContext runs the step with ctx rather than the context the job gives it, e.g. one also cancelled with the
+// group of steps it runs in
+func (j *Job) runStepContext(ctx context.Context, wrapper *StepWrapper) (outcome error) {
+	j.responseDivider(wrapper)
+	start := time.Now()
+	if j.report != nil {
+		defer func() {
+			j.report.add(j, wrapper, true, start, outcome, "")
+		}()
+	}
+
+	err := runWithRetry(ctx, wrapper, start)
+	if j.recording != nil {
+		j.recording.record(wrapper, start, err)
+	}
+	if errors.Is(err, ErrStepTimeout) || errors.Is(err, ErrStepCancelled) {
+		// a hung or cancelled step fails even when it's expected to error
+		j.cancelStepScenario(wrapper)
+		return j.stepError(wrapper, err)
+	}
+	if wrapper.Opts.ExpectError && err == nil {
+		j.cancelStepScenario(wrapper)
+		return j.stepError(wrapper, fmt.Errorf("expected error from step %s but got nil: %w", stepTypeName(wrapper), ErrNilError))
+	} else if !wrapper.Opts.ExpectError && err != nil {
+		j.cancelStepScenario(wrapper)
+		return j.stepError(wrapper, fmt.Errorf("did not expect error from step %s but got error: %w", stepTypeName(wrapper), err))
+	}
 
+	if stop, ok := wrapper.Step.(*Stop); ok {
+		j.runningBackgroundSteps.Delete(stop.BackgroundID)
+	} else if wrapper.Opts.RunInBackgroundWithID != "" && err == nil {
+		j.runningBackgroundSteps.Store(wrapper.Opts.RunInBackgroundWithID, wrapper)
+	}
 	return nil
 }
 
+// cancelStepScenario cancels the scenario of a failing step, unless the step is cleaning up after the scenario
+func (j *Job) cancelStepScenario(wrapper *StepWrapper) {
+	scenario, exists := j.Scenarios[wrapper]
+	if !exists || scenario.isCleanup(wrapper) || scenario.isDiagnostic(wrapper) {
+		return
+	}
+	j.cancelScenario(scenario)
+}
+
+// runCleanupStep runs a cleanup step after a failure, skipping the Stop of a background step that never started
+func (j *Job) runCleanupStep(wrapper *StepWrapper) error {
+	if stop, ok := wrapper.Step.(*Stop); ok {
+		if _, running := j.runningBackgroundSteps.Load(stop.BackgroundID); !running {
+			log.Printf("skipping stop of background step \"%s\", it never started", stop.BackgroundID)
+			if j.report != nil {
+				j.report.add(j, wrapper, false, time.Time{}, nil, skipNotStarted)
+			}
+			return nil
+		}
+	}
+	return j.runStep(wrapper)
+}
+
+// runWithRetry runs the step until its Run succeeds, as many times as its Retry allows. A step expected
+// to error is retried until it does instead, as its failure is the outcome it's after. A step that timed out
+// isn't retried, since it's still running, and neither is one that was cancelled, or a precondition skipping
+// its scenario. Once every attempt failed, the step fails with the errors of all of them
+func runWithRetry(ctx context.Context, wrapper *StepWrapper, start time.Time) error {
+	retry := wrapper.Opts.Retry
+	if retry == nil {
+		return runWithTimeout(ctx, wrapper, start)
+	}
+
+	delay := retry.Delay
+	var errs []error
+	for attempt := 1; attempt <= retry.Attempts; attempt++ {
+		err := runWithTimeout(ctx, wrapper, time.Now())
+		if errors.Is(err, ErrStepTimeout) || errors.Is(err, ErrStepCancelled) || errors.Is(err, ErrScenarioSkipped) {
+			return err
+		}
+		if wrapper.Opts.ExpectError == (err != nil) {
+			return err
+		}
+		if attempt == retry.Attempts {
+			if wrapper.Opts.ExpectError {
+				// left to runStep to fail as it didn't error
+				return nil
+			}
+			errs = append(errs, fmt.Errorf("attempt %d: %w", attempt, err))
+			break
+		}
+
+		if wrapper.Opts.ExpectError {
+			log.Printf("attempt %d of %d of step %s expected to error succeeded, retrying in %s", attempt, retry.Attempts, reflect.TypeOf(wrapper.Step).Elem().Name(), delay)
+		} else {
+			errs = append(errs, fmt.Errorf("attempt %d: %w", attempt, err))
+			log.Printf("attempt %d of %d of step %s failed, retrying in %s: %v", attempt, retry.Attempts, reflect.TypeOf(wrapper.Step).Elem().Name(), delay, err)
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("step %s between attempts: %w: %w", reflect.TypeOf(wrapper.Step).Elem().Name(), ErrStepCancelled, context.Cause(ctx))
+		case <-time.After(delay):
+		}
+		if retry.ExpBackoff {
+			delay *= 2
+		}
+	}
+
+	return fmt.Errorf("%d attempts in %s: %w: %w", retry.Attempts, time.Since(start).Round(time.Millisecond), ErrRetriesExhausted, errors.Join(errs...))
+}
+
+// runWithTimeout runs the step, giving up on it once its Timeout is up, or once ctx is cancelled unless it's a
+// ContextStep, which is waited for as it returns on its own. A step given up on keeps running in the background,
+// but the job moves on without it. A panicking step panics the caller, as if it ran on its goroutine
+func runWithTimeout(ctx context.Context, wrapper *StepWrapper, start time.Time) error {
+	if ctx.Err() != nil {
+		return fmt.Errorf("step %s not started: %w: %w", reflect.TypeOf(wrapper.Step).Elem().Name(), ErrStepCancelled, context.Cause(ctx))
+	}
+
+	type result struct {
+		err       error
+		panicking any
+	}
+	done := make(chan result, 1)
+	// a ContextStep returns once cancelled, so it's waited for, while other steps are abandoned
+	step, isContextStep := wrapper.Step.(ContextStep)
+	cancelled := ctx.Done()
+	if isContextStep {
+		cancelled = nil
+	}
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- result{panicking: r}
+			}
+		}()
+		if isContextStep {
+			done <- result{err: step.RunContext(ctx)}
+			return
+		}
+		done <- result{err: wrapper.Step.Run()}
+	}()
+
+	var timeout <-chan time.Time
+	if wrapper.Opts.Timeout > 0 {
+		timer := time.NewTimer(wrapper.Opts.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case r := <-done:
+		if r.panicking != nil {
+			panic(r.panicking)
+		}
+		// a ContextStep giving up on its cancelled context fails like an abandoned step, rather than with its own error
+		if r.err != nil && isContextStep && ctx.Err() != nil && !errors.Is(r.err, ErrStepCancelled) {
+			return fmt.Errorf("step %s cancelled after %s: %w: %w",
+				reflect.TypeOf(wrapper.Step).Elem().Name(), time.Since(start).Round(time.Millisecond), ErrStepCancelled, r.err)
+		}
+		return r.err
+	case <-timeout:
+		return fmt.Errorf("step %s still running after %s, past its %s timeout: %w",
+			reflect.TypeOf(wrapper.Step).Elem().Name(), time.Since(start).Round(time.Millisecond), wrapper.Opts.Timeout, ErrStepTimeout)
+	case <-cancelled:
+		return fmt.Errorf("step %s cancelled after %s: %w: %w",
+			reflect.TypeOf(wrapper.Step).Elem().Name(), time.Since(start).Round(time.Millisecond), ErrStepCancelled, context.Cause(ctx))
+	}
+}
+
+// runDiagnostics runs the failure diagnostics of a scenario one of whose steps failed, only logging their errors
+func (j *Job) runDiagnostics(scenario *Scenario) {
+	for _, wrapper := range scenario.diagnostics {
+		err := j.runStep(wrapper)
+		if err != nil {
+			log.Printf("failure diagnostics of scenario %s failed: %v", scenario.name, err)
+		}
+	}
+}
+
+// scenarios returns the job's scenarios, in the order they run
+func (j *Job) scenarios() []*Scenario {
+	var scenarios []*Scenario
+	for _, wrapper := range j.Steps {
+		if scenario, exists := j.Scenarios[wrapper]; exists && !slices.Contains(scenarios, scenario) {
+			scenarios = append(scenarios, scenario)
+		}
+	}
+	return scenarios
+}
+
+// diagnosticSteps returns the failure diagnostics of the job's scenarios, in the order the scenarios run
+func (j *Job) diagnosticSteps() []*StepWrapper {
+	var steps []*StepWrapper
+	seen := make(map[*Scenario]bool)
+	for _, wrapper := range j.Steps {
+		scenario, exists := j.Scenarios[wrapper]
+		if !exists || seen[scenario] {
+			continue
+		}
+		seen[scenario] = true
+		steps = append(steps, scenario.diagnostics...)
+	}
+	return steps
+}
+
+// runPendingTeardowns runs the remaining cleanup steps of scenarios and teardown steps of suites that were started,
+// skipping everything else. Both keep going past failing steps so as much as possible is cleaned up. Failing
+// cleanup steps are only logged, while failing teardown steps are returned
+func (j *Job) runPendingTeardowns(remaining []*StepWrapper, startedSuites map[*Suite]bool, startedScenarios map[*Scenario]bool) error {
+	var errs []error
+	for _, wrapper := range remaining {
+		if scenario, exists := j.Scenarios[wrapper]; exists && startedScenarios[scenario] && scenario.isCleanup(wrapper) {
+			err := j.runCleanupStep(wrapper)
+			if err != nil {
+				log.Printf("cleanup of scenario %s failed: %v", scenario.name, err)
+			}
+			continue
+		}
+
+		suite, exists := j.Suites[wrapper]
+		if !exists || !startedSuites[suite] || !suite.isTeardown(wrapper) {
+			continue
+		}
+
+		err := j.runStep(wrapper)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("teardown of suite %s failed: %w", suite.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 func (j *Job) Validate() error {
 	// ensure that there are no background steps left after running
 
@@ -198,25 +735,66 @@ func (j *Job) Validate() error {
 
 	}
 
-	err := j.validateBackgroundSteps()
+	err := validateBackgroundSteps(j.Steps, j.BackgroundSteps)
 	if err != nil {
 		return err
 	}
 
+	for _, scenario := range j.scenarios() {
+		err = scenario.validateBackgroundStops()
+		if err != nil {
+			return err
+		}
+		err = j.validatePreconditions(scenario)
+		if err != nil {
+			return err
+		}
+	}
+
+	// failure diagnostics run at most once, after any step of their scenario, so can't start or stop background steps
+	for _, wrapper := range j.diagnosticSteps() {
+		err = j.validateDiagnosticStep(wrapper)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, soak := range j.soaks {
+		err = soak.validate()
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-func (j *Job) validateBackgroundSteps() error {
+// flattenSteps expands the steps of each Parallel in place, for checks that only care about the order steps start in
+func flattenSteps(steps []*StepWrapper) []*StepWrapper {
+	flat := make([]*StepWrapper, 0, len(steps))
+	for _, stepw := range steps {
+		if parallel, ok := stepw.Step.(*Parallel); ok {
+			flat = append(flat, flattenSteps(parallel.steps)...)
+			continue
+		}
+		flat = append(flat, stepw)
+	}
+	return flat
+}
+
+// validateBackgroundSteps checks every background step in steps is stopped once after it started, and points
+// each Stop step at the step it stops. The background steps are saved to backgroundSteps by their ID
+func validateBackgroundSteps(steps []*StepWrapper, backgroundSteps map[string]*StepWrapper) error {
 	stoppedBackgroundSteps := make(map[string]bool)
 
-	for _, stepw := range j.Steps {
+	for _, stepw := range flattenSteps(steps) {
 		switch s := stepw.Step.(type) {
 		case *Stop:
 			if s.BackgroundID == "" {
 				return fmt.Errorf("cannot stop step with empty background id; %w", ErrMissingBackroundID)
 			}
 
-			if j.BackgroundSteps[s.BackgroundID] == nil {
+			if backgroundSteps[s.BackgroundID] == nil {
 				return fmt.Errorf("cannot stop step \"%s\", as it won't be started by this time; %w", s.BackgroundID, ErrCannotStopStep)
 			}
 			if stopped := stoppedBackgroundSteps[s.BackgroundID]; stopped {
@@ -227,14 +805,14 @@ func (j *Job) validateBackgroundSteps() error {
 			stoppedBackgroundSteps[s.BackgroundID] = true
 
 			// set the stop step within the step
-			s.Step = j.BackgroundSteps[s.BackgroundID].Step
+			s.Step = backgroundSteps[s.BackgroundID].Step
 
 		default:
 			if stepw.Opts.RunInBackgroundWithID != "" {
-				if _, exists := j.BackgroundSteps[stepw.Opts.RunInBackgroundWithID]; exists {
+				if _, exists := backgroundSteps[stepw.Opts.RunInBackgroundWithID]; exists {
 					log.Fatalf("step with id \"%s\" already exists", stepw.Opts.RunInBackgroundWithID)
 				}
-				j.BackgroundSteps[stepw.Opts.RunInBackgroundWithID] = stepw
+				backgroundSteps[stepw.Opts.RunInBackgroundWithID] = stepw
 				stoppedBackgroundSteps[stepw.Opts.RunInBackgroundWithID] = false
 			}
 		}
@@ -249,6 +827,17 @@ func (j *Job) validateBackgroundSteps() error {
 	return nil
 }
 
+func (j *Job) validateDiagnosticStep(step *StepWrapper) error {
+	err := j.validateStep(step)
+	if err != nil {
+		return err
+	}
+	if _, isStop := step.Step.(*Stop); isStop || step.Opts.RunInBackgroundWithID != "" {
+		return fmt.Errorf("step \"%s\": %w", j.GetPrettyStepName(step), ErrBackgroundDiagnostics)
+	}
+	return nil
+}
+
 func (j *Job) validateStep(step *StepWrapper) error {
 	val := reflect.ValueOf(step.Step).Elem()
 
@@ -257,7 +846,11 @@ func (j *Job) validateStep(step *StepWrapper) error {
 		step.Opts = &DefaultOpts
 	}
 
-	switch step.Step.(type) {
+	if step.Opts.Retry != nil && step.Opts.Retry.Attempts < 1 {
+		return fmt.Errorf("step \"%s\" retries %d attempts: %w", j.GetPrettyStepName(step), step.Opts.Retry.Attempts, ErrInvalidRetry)
+	}
+
+	switch s := step.Step.(type) {
 	case *Stop:
 		// don't validate stop steps
 		return nil
@@ -266,6 +859,14 @@ func (j *Job) validateStep(step *StepWrapper) error {
 		// don't validate sleep steps
 		return nil
 
+	case *Parallel:
+		// the group has no parameters of its own, its steps do
+		return s.validate(j, step)
+
+	case *RetryStep:
+		// the retry has no parameters of its own, its step does
+		return s.validate(j, step)
+
 	default:
 		for i, f := range reflect.VisibleFields(val.Type()) {
 
@@ -0,0 +1,164 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+)
+
+var (
+	ErrRecordingMismatch = fmt.Errorf("job steps don't match the recording")
+	ErrNoRecordedInputs  = fmt.Errorf("recorded step has no inputs")
+)
+
+// A Recording is the step sequence of a job run, with the parameters each step ran with and how it went,
+// so a failing run can be replayed with the same inputs against a fresh cluster
+type Recording struct {
+	Description string          `json:"description"`
+	Steps       []*RecordedStep `json:"steps"`
+	stepIndex   map[*StepWrapper]int
+}
+
+// A RecordedStep is one step of a recorded run. Parameters holds the step's exported fields once the job's
+// saved values were applied, Ran is false for steps the run never reached
+type RecordedStep struct {
+	Type       string          `json:"type"`
+	Scenario   string          `json:"scenario,omitempty"`
+	Parameters json.RawMessage `json:"parameters,omitempty"`
+	Options    StepOptions     `json:"options"`
+	Ran        bool            `json:"ran"`
+	Start      time.Time       `json:"start,omitempty"`
+	Duration   time.Duration   `json:"duration,omitempty"`
+	Error      string          `json:"error,omitempty"`
+}
+
+// RecordTo makes the job write a Recording of its next run to path, whether the run passes or fails
+func (j *Job) RecordTo(path string) {
+	j.recordPath = path
+}
+
+// ReplayFrom makes the job's next run use the recorded inputs. The job must be built with the same steps,
+// in the same order, as the recorded one; each step's parameters are then overwritten with the recorded ones
+func (j *Job) ReplayFrom(recording *Recording) {
+	j.replay = recording
+}
+
+func LoadRecording(path string) (*Recording, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading recording \"%s\": %w", path, err)
+	}
+
+	recording := &Recording{}
+	err = json.Unmarshal(data, recording)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing recording \"%s\": %w", path, err)
+	}
+	return recording, nil
+}
+
+func (j *Job) newRecording() *Recording {
+	recording := &Recording{
+		Description: j.Description,
+		stepIndex:   make(map[*StepWrapper]int),
+	}
+
+	for i, wrapper := range j.Steps {
+		step := &RecordedStep{
+			Type:    stepTypeName(wrapper),
+			Options: *wrapper.Opts,
+		}
+		if scenario, exists := j.Scenarios[wrapper]; exists {
+			step.Scenario = scenario.name
+		}
+
+		// a background step's inputs are recorded with the step itself, not the stop
+		if _, isStop := wrapper.Step.(*Stop); !isStop {
+			parameters, err := json.Marshal(wrapper.Step)
+			if err != nil {
+				log.Printf("not recording parameters of step %s: %v\n", j.GetPrettyStepName(wrapper), err)
+			} else {
+				step.Parameters = parameters
+			}
+		}
+
+		recording.Steps = append(recording.Steps, step)
+		recording.stepIndex[wrapper] = i
+	}
+
+	return recording
+}
+
+// record saves how a step ran, started at start and ending with err
+func (r *Recording) record(wrapper *StepWrapper, start time.Time, err error) {
+	// the scenarios of a soak are picked at run time, so their steps aren't part of the recording
+	index, exists := r.stepIndex[wrapper]
+	if !exists {
+		return
+	}
+	step := r.Steps[index]
+	step.Ran = true
+	step.Start = start
+	step.Duration = time.Since(start)
+	if err != nil {
+		step.Error = err.Error()
+	}
+}
+
+func (r *Recording) write(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializing recording: %w", err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), artifactDirPerms)
+	if err != nil {
+		return fmt.Errorf("error creating directory of recording \"%s\": %w", path, err)
+	}
+
+	err = os.WriteFile(path, data, 0o600)
+	if err != nil {
+		return fmt.Errorf("error writing recording \"%s\": %w", path, err)
+	}
+
+	log.Printf("recorded %d steps to %s\n", len(r.Steps), path)
+	return nil
+}
+
+// applyRecording checks the job has the recorded step sequence and overwrites each step's parameters with
+// the recorded ones
+func (j *Job) applyRecording() error {
+	if len(j.Steps) != len(j.replay.Steps) {
+		return fmt.Errorf("job has %d steps, recording has %d: %w", len(j.Steps), len(j.replay.Steps), ErrRecordingMismatch)
+	}
+
+	for i, wrapper := range j.Steps {
+		recorded := j.replay.Steps[i]
+		if stepTypeName(wrapper) != recorded.Type {
+			return fmt.Errorf("step %d is %s, recording has %s: %w", i, j.GetPrettyStepName(wrapper), recorded.Type, ErrRecordingMismatch)
+		}
+
+		if _, isStop := wrapper.Step.(*Stop); isStop {
+			continue
+		}
+		if len(recorded.Parameters) == 0 {
+			return fmt.Errorf("step %d %s: %w", i, j.GetPrettyStepName(wrapper), ErrNoRecordedInputs)
+		}
+
+		err := json.Unmarshal(recorded.Parameters, wrapper.Step)
+		if err != nil {
+			return fmt.Errorf("error replaying parameters of step %d %s: %w", i, j.GetPrettyStepName(wrapper), err)
+		}
+		fmt.Printf("%s replaying recorded parameters %s\n", j.GetPrettyStepName(wrapper), string(recorded.Parameters))
+	}
+
+	return nil
+}
+
+func stepTypeName(wrapper *StepWrapper) string {
+	return reflect.TypeOf(wrapper.Step).Elem().Name()
+}
@@ -0,0 +1,75 @@
+package types
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recording.json")
+
+	recorded := NewJob("Validate a run is recorded with its inputs")
+	recorded.AddStep(&DummyStep{
+		Parameter1: "Recorded 1",
+		Parameter2: "Recorded 2",
+	}, nil)
+	recorded.AddScenario(NewDummyScenarioWithSkipSave())
+	recorded.AddStep(&Sleep{
+		Duration: time.Millisecond,
+	}, nil)
+	recorded.RecordTo(path)
+	require.NoError(t, recorded.Run())
+
+	recording, err := LoadRecording(path)
+	require.NoError(t, err)
+	require.Len(t, recording.Steps, 3)
+	require.Equal(t, "DummyStep", recording.Steps[1].Type)
+	require.Equal(t, "Dummy Scenario", recording.Steps[1].Scenario)
+	for _, step := range recording.Steps {
+		require.True(t, step.Ran)
+	}
+
+	// the same steps built with other inputs run with the recorded ones
+	replayed := NewJob("Validate a recorded run is replayed with its inputs")
+	first := &DummyStep{
+		Parameter1: "Other 1",
+		Parameter2: "Other 2",
+	}
+	replayed.AddStep(first, nil)
+	replayed.AddScenario(NewDummyScenarioWithSkipSave())
+	sleep := &Sleep{
+		Duration: time.Hour,
+	}
+	replayed.AddStep(sleep, &StepOptions{SkipSavingParametersToJob: true})
+	replayed.ReplayFrom(recording)
+	require.NoError(t, replayed.Run())
+
+	require.Equal(t, "Recorded 1", first.Parameter1)
+	require.Equal(t, "Recorded 2", first.Parameter2)
+	require.Equal(t, time.Millisecond, sleep.Duration)
+}
+
+func TestReplayMismatchedSteps(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recording.json")
+
+	recorded := NewJob("Validate a run is recorded")
+	recorded.AddStep(&Sleep{
+		Duration: time.Millisecond,
+	}, nil)
+	recorded.RecordTo(path)
+	require.NoError(t, recorded.Run())
+
+	recording, err := LoadRecording(path)
+	require.NoError(t, err)
+
+	replayed := NewJob("Validate a replay with other steps is rejected")
+	replayed.AddStep(&DummyStep{
+		Parameter1: "Other 1",
+		Parameter2: "Other 2",
+	}, nil)
+	replayed.ReplayFrom(recording)
+	require.ErrorIs(t, replayed.Run(), ErrRecordingMismatch)
+}
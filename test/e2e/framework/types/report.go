@@ -0,0 +1,349 @@
+package types
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// reportArtifact and junitArtifact are where the job's reports are written in the artifacts directory,
+	// unless ReportTo or JUnitReportTo say otherwise
+	reportArtifact = "report.json"
+	junitArtifact  = "junit.xml"
+
+	// skipNotReached is why steps after a failing one didn't run
+	skipNotReached = "not reached, an earlier step failed"
+
+	// skipNotStarted is why the Stop of a background step that never started didn't run
+	skipNotStarted = "background step never started"
+)
+
+var (
+	reportPath      = flag.String("report", "", "file the job writes a JSON report of the status, duration and error of each step it ran to")
+	junitReportPath = flag.String("junit-report", "", "file the job writes a JUnit XML report of its steps to, one test suite per scenario")
+)
+
+type StepStatus string
+
+const (
+	StepPassed  StepStatus = "passed"
+	StepFailed  StepStatus = "failed"
+	StepSkipped StepStatus = "skipped"
+)
+
+// A Report is how each step of a job run went, in the order they ran, for CI and dashboards to ingest. Unlike a
+// Recording it covers every step that ran, failure diagnostics and the steps of soaks included, and a step passes
+// when it did what its options expect of it, so a step expected to error passes when it does
+type Report struct {
+	Description string          `json:"description"`
+	Start       time.Time       `json:"start"`
+	Duration    time.Duration   `json:"duration"`
+	Steps       []*ReportedStep `json:"steps"`
+
+	mu sync.Mutex
+
+	// the index of the last step that started each background ID, for the Stop step stopping it
+	background map[string]int
+	reported   map[*StepWrapper]bool
+}
+
+// A ReportedStep is one step of a reported run. A background step and the Stop step stopping it share their
+// BackgroundID, and the Stop's StartedBy is the index in the report of the step it stopped. Phase tells the
+// steps a scenario or suite runs around its own apart, e.g. "cleanup" or "diagnostics"
+type ReportedStep struct {
+	Type         string        `json:"type"`
+	Scenario     string        `json:"scenario,omitempty"`
+	Suite        string        `json:"suite,omitempty"`
+	Phase        string        `json:"phase,omitempty"`
+	BackgroundID string        `json:"backgroundID,omitempty"`
+	StartedBy    *int          `json:"startedBy,omitempty"`
+	Status       StepStatus    `json:"status"`
+	Start        *time.Time    `json:"start,omitempty"`
+	Duration     time.Duration `json:"duration,omitempty"`
+	Error        string        `json:"error,omitempty"`
+	SkipReason   string        `json:"skipReason,omitempty"`
+
+	// Measurements are the numbers a Measurer step measured, e.g. the scrape latency of a benchmark
+	Measurements map[string]float64 `json:"measurements,omitempty"`
+}
+
+// A Measurer is a step measuring the cluster, such as a benchmark timing scrapes of the agent's metrics endpoint,
+// whose measurements the report records along with the step once it ran, whether it passed or not, so CI can
+// track them across runs
+type Measurer interface {
+	Measurements() map[string]float64
+}
+
+// ReportTo makes the job write a JSON Report of its next run to path, whether the run passes or fails
+func (j *Job) ReportTo(path string) {
+	j.reportPath = path
+}
+
+// JUnitReportTo makes the job write a JUnit XML report of its next run to path, with a test suite per scenario
+// or suite and a test case per step, whether the run passes or fails
+func (j *Job) JUnitReportTo(path string) {
+	j.junitReportPath = path
+}
+
+func (j *Job) newReport() *Report {
+	return &Report{
+		Description: j.Description,
+		Start:       time.Now(),
+		background:  make(map[string]int),
+		reported:    make(map[*StepWrapper]bool),
+	}
+}
+
+// add reports a step of the job, which ran from start when ran is set, with err being its outcome once
+// its options are accounted for, or was skipped for skippedFor
+func (r *Report) add(j *Job, wrapper *StepWrapper, ran bool, start time.Time, err error, skippedFor string) {
+	step := &ReportedStep{
+		Type:         stepTypeName(wrapper),
+		Phase:        j.stepPhase(wrapper),
+		BackgroundID: wrapper.Opts.RunInBackgroundWithID,
+		Status:       StepPassed,
+	}
+	if scenario, exists := j.Scenarios[wrapper]; exists {
+		step.Scenario = scenario.name
+	}
+	if suite, exists := j.Suites[wrapper]; exists {
+		step.Suite = suite.name
+	}
+	if stop, isStop := wrapper.Step.(*Stop); isStop {
+		step.BackgroundID = stop.BackgroundID
+	}
+
+	reason, skips := skipReason(err)
+	switch {
+	case !ran:
+		step.Status = StepSkipped
+		step.SkipReason = skippedFor
+	case skips:
+		// a precondition skipping its scenario
+		step.Status = StepSkipped
+		step.SkipReason = reason
+	case err != nil:
+		step.Status = StepFailed
+		step.Error = err.Error()
+	}
+	if ran {
+		step.Start = &start
+		step.Duration = time.Since(start)
+		if measurer, ok := wrapper.Step.(Measurer); ok {
+			step.Measurements = measurer.Measurements()
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, isStop := wrapper.Step.(*Stop); isStop {
+		if index, started := r.background[step.BackgroundID]; started {
+			step.StartedBy = &index
+		}
+	} else if step.BackgroundID != "" && ran {
+		r.background[step.BackgroundID] = len(r.Steps)
+	}
+
+	r.Steps = append(r.Steps, step)
+	r.reported[wrapper] = true
+}
+
+// finish reports the steps of the job that never ran as skipped, and how long the run took
+func (r *Report) finish(j *Job) {
+	for _, wrapper := range j.Steps {
+		if !r.reported[wrapper] {
+			r.add(j, wrapper, false, time.Time{}, nil, skipNotReached)
+		}
+	}
+	r.Duration = time.Since(r.Start)
+}
+
+// stepPhase returns "setup" or "teardown" for the steps a suite runs around its scenarios, "setup" for those a
+// scenario runs before its own and "cleanup" or "diagnostics" for those it runs after, and an empty string for any
+// other step
+func (j *Job) stepPhase(wrapper *StepWrapper) string {
+	if scenario, exists := j.Scenarios[wrapper]; exists {
+		switch {
+		case slices.Contains(scenario.setup, wrapper):
+			return "setup"
+		case scenario.isCleanup(wrapper):
+			return "cleanup"
+		case slices.Contains(scenario.diagnostics, wrapper):
+			return "diagnostics"
+		}
+		return ""
+	}
+	if suite, exists := j.Suites[wrapper]; exists {
+		switch {
+		case slices.Contains(suite.setup, wrapper):
+			return "setup"
+		case suite.isTeardown(wrapper):
+			return "teardown"
+		}
+	}
+	return ""
+}
+
+func (r *Report) write(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializing report: %w", err)
+	}
+	return writeReportFile(path, data, "report")
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name          `xml:"testsuites"`
+	Name     string            `xml:"name,attr"`
+	Tests    int               `xml:"tests,attr"`
+	Failures int               `xml:"failures,attr"`
+	Skipped  int               `xml:"skipped,attr"`
+	Time     string            `xml:"time,attr"`
+	Suites   []*junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string           `xml:"name,attr"`
+	Tests     int              `xml:"tests,attr"`
+	Failures  int              `xml:"failures,attr"`
+	Skipped   int              `xml:"skipped,attr"`
+	Time      string           `xml:"time,attr"`
+	Timestamp string           `xml:"timestamp,attr,omitempty"`
+	Cases     []*junitTestCase `xml:"testcase"`
+
+	duration time.Duration
+}
+
+type junitTestCase struct {
+	Name       string           `xml:"name,attr"`
+	ClassName  string           `xml:"classname,attr"`
+	Time       string           `xml:"time,attr"`
+	Properties *junitProperties `xml:"properties,omitempty"`
+	Failure    *junitMessage    `xml:"failure,omitempty"`
+	Skipped    *junitMessage    `xml:"skipped,omitempty"`
+}
+
+type junitProperties struct {
+	Properties []junitProperty `xml:"property"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// junitMeasurements lists a step's measurements as test case properties, sorted by name
+func junitMeasurements(measurements map[string]float64) *junitProperties {
+	if len(measurements) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(measurements))
+	for name := range measurements {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	properties := &junitProperties{}
+	for _, name := range names {
+		properties.Properties = append(properties.Properties, junitProperty{Name: name, Value: strconv.FormatFloat(measurements[name], 'g', -1, 64)})
+	}
+	return properties
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// junit groups the reported steps into a test suite per scenario, or per suite for the steps of suites outside
+// of their scenarios, with the remaining steps under "job". Test cases are named after the step's position in the
+// report and its type, with its phase and background ID, e.g. "004-Stop (cleanup) [port-forward]"
+func (r *Report) junit() *junitTestSuites {
+	suites := &junitTestSuites{Name: r.Description, Time: junitSeconds(r.Duration)}
+	groups := make(map[string]*junitTestSuite)
+
+	for i, step := range r.Steps {
+		group := jobArtifactDir
+		if step.Scenario != "" {
+			group = step.Scenario
+		} else if step.Suite != "" {
+			group = step.Suite
+		}
+
+		suite, exists := groups[group]
+		if !exists {
+			suite = &junitTestSuite{Name: group}
+			if step.Start != nil {
+				suite.Timestamp = step.Start.Format(time.RFC3339)
+			}
+			groups[group] = suite
+			suites.Suites = append(suites.Suites, suite)
+		}
+
+		name := fmt.Sprintf("%03d-%s", i, step.Type)
+		if step.Phase != "" {
+			name = fmt.Sprintf("%s (%s)", name, step.Phase)
+		}
+		if step.BackgroundID != "" {
+			name = fmt.Sprintf("%s [%s]", name, step.BackgroundID)
+		}
+		testCase := &junitTestCase{Name: name, ClassName: group, Time: junitSeconds(step.Duration), Properties: junitMeasurements(step.Measurements)}
+
+		switch step.Status {
+		case StepFailed:
+			testCase.Failure = &junitMessage{Message: step.Error, Text: step.Error}
+			suite.Failures++
+			suites.Failures++
+		case StepSkipped:
+			testCase.Skipped = &junitMessage{Message: step.SkipReason}
+			suite.Skipped++
+			suites.Skipped++
+		case StepPassed:
+		}
+
+		suite.Cases = append(suite.Cases, testCase)
+		suite.Tests++
+		suite.duration += step.Duration
+		suite.Time = junitSeconds(suite.duration)
+		suites.Tests++
+	}
+
+	return suites
+}
+
+func (r *Report) writeJUnit(path string) error {
+	data, err := xml.MarshalIndent(r.junit(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializing junit report: %w", err)
+	}
+	return writeReportFile(path, append([]byte(xml.Header), data...), "junit report")
+}
+
+func writeReportFile(path string, data []byte, kind string) error {
+	err := os.MkdirAll(filepath.Dir(path), artifactDirPerms)
+	if err != nil {
+		return fmt.Errorf("error creating directory of %s \"%s\": %w", kind, path, err)
+	}
+
+	err = os.WriteFile(path, data, artifactFilePerms)
+	if err != nil {
+		return fmt.Errorf("error writing %s \"%s\": %w", kind, path, err)
+	}
+
+	log.Printf("wrote %s to %s\n", kind, path)
+	return nil
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
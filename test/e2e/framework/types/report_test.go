@@ -0,0 +1,156 @@
+package types
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newReportedJob(calls *[]string) *Job {
+	job := NewJob("Validate a run is reported step by step")
+	job.AddScenario(NewScenario("Failing Scenario",
+		&StepWrapper{Step: &RecordStep{Name: "expected failure", Fail: true, calls: calls}, Opts: &StepOptions{SkipSavingParametersToJob: true, ExpectError: true}},
+		&StepWrapper{Step: &RecordStep{Name: "failing", Fail: true, calls: calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+		&StepWrapper{Step: &Sleep{Duration: time.Millisecond}, Opts: &StepOptions{RunInBackgroundWithID: "unstarted"}},
+	).WithSetup(
+		&StepWrapper{Step: &TestBackground{CounterName: "Counter"}, Opts: &StepOptions{RunInBackgroundWithID: "counter"}},
+	).WithCleanup(
+		&StepWrapper{Step: &Stop{BackgroundID: "counter"}},
+		&StepWrapper{Step: &Stop{BackgroundID: "unstarted"}},
+	))
+	job.AddStep(&RecordStep{Name: "after scenario", calls: calls}, &StepOptions{SkipSavingParametersToJob: true})
+	return job
+}
+
+func TestReport(t *testing.T) {
+	dir := t.TempDir()
+	var calls []string
+	job := newReportedJob(&calls)
+	job.ReportTo(filepath.Join(dir, "report.json"))
+	job.JUnitReportTo(filepath.Join(dir, "junit.xml"))
+	require.ErrorIs(t, job.Run(), errFailingStep)
+
+	data, err := os.ReadFile(filepath.Join(dir, "report.json"))
+	require.NoError(t, err)
+	report := &Report{}
+	require.NoError(t, json.Unmarshal(data, report))
+
+	statuses := make([]StepStatus, 0, len(report.Steps))
+	for _, step := range report.Steps {
+		statuses = append(statuses, step.Status)
+	}
+	// the steps that ran in order, then those never reached
+	require.Equal(t, []StepStatus{StepPassed, StepPassed, StepFailed, StepPassed, StepSkipped, StepSkipped, StepSkipped}, statuses)
+
+	require.Equal(t, "setup", report.Steps[0].Phase)
+	require.Empty(t, report.Steps[1].Phase)
+	require.Equal(t, "Failing Scenario", report.Steps[2].Scenario)
+	require.Contains(t, report.Steps[2].Error, errFailingStep.Error())
+
+	stop := report.Steps[3]
+	require.Equal(t, "Stop", stop.Type)
+	require.Equal(t, "cleanup", stop.Phase)
+	require.Equal(t, "counter", stop.BackgroundID)
+	require.NotNil(t, stop.StartedBy)
+	require.Equal(t, "counter", report.Steps[*stop.StartedBy].BackgroundID)
+
+	require.Equal(t, "unstarted", report.Steps[4].BackgroundID)
+	require.Nil(t, report.Steps[4].StartedBy)
+	require.Equal(t, skipNotStarted, report.Steps[4].SkipReason)
+	require.Equal(t, skipNotReached, report.Steps[6].SkipReason)
+	require.Equal(t, "RecordStep", report.Steps[6].Type)
+	for _, step := range report.Steps[:4] {
+		require.NotNil(t, step.Start)
+	}
+	// skipped steps never started, so they carry no start
+	raw := struct {
+		Steps []map[string]json.RawMessage `json:"steps"`
+	}{}
+	require.NoError(t, json.Unmarshal(data, &raw))
+	for _, step := range raw.Steps[4:] {
+		require.NotContains(t, step, "start")
+	}
+
+	data, err = os.ReadFile(filepath.Join(dir, "junit.xml"))
+	require.NoError(t, err)
+	junit := &junitTestSuites{}
+	require.NoError(t, xml.Unmarshal(data, junit))
+	require.Equal(t, 7, junit.Tests)
+	require.Equal(t, 1, junit.Failures)
+	require.Equal(t, 3, junit.Skipped)
+	require.Len(t, junit.Suites, 2)
+	require.Equal(t, "Failing Scenario", junit.Suites[0].Name)
+	require.Equal(t, "003-Stop (cleanup) [counter]", junit.Suites[0].Cases[3].Name)
+	require.NotNil(t, junit.Suites[0].Cases[2].Failure)
+	require.Equal(t, jobArtifactDir, junit.Suites[1].Name)
+}
+
+func TestReportInArtifactsDir(t *testing.T) {
+	dir := t.TempDir()
+	var calls []string
+	job := NewJob("Validate a run's reports are written to the artifacts directory")
+	job.AddStep(&RecordStep{Name: "step", calls: &calls}, &StepOptions{SkipSavingParametersToJob: true})
+	job.SetArtifactsDir(dir)
+	require.NoError(t, job.Run())
+
+	require.FileExists(t, filepath.Join(dir, reportArtifact))
+	require.FileExists(t, filepath.Join(dir, junitArtifact))
+}
+
+// MeasuringStep is a step measuring a scrape latency, which it fails to keep under its threshold when Fail is set
+type MeasuringStep struct {
+	Fail bool
+}
+
+func (m *MeasuringStep) Run() error {
+	if m.Fail {
+		return errFailingStep
+	}
+	return nil
+}
+
+func (m *MeasuringStep) Stop() error {
+	return nil
+}
+
+func (m *MeasuringStep) Prevalidate() error {
+	return nil
+}
+
+func (m *MeasuringStep) Measurements() map[string]float64 {
+	return map[string]float64{"p95_seconds": 0.25, "bytes": 1024}
+}
+
+func TestReportMeasurements(t *testing.T) {
+	dir := t.TempDir()
+	job := NewJob("Validate a step's measurements are reported whether it passes or fails")
+	job.AddStep(&MeasuringStep{}, nil)
+	job.AddStep(&MeasuringStep{Fail: true}, &StepOptions{SkipSavingParametersToJob: true})
+	job.AddStep(&MeasuringStep{}, &StepOptions{SkipSavingParametersToJob: true})
+	job.ReportTo(filepath.Join(dir, "report.json"))
+	job.JUnitReportTo(filepath.Join(dir, "junit.xml"))
+	require.ErrorIs(t, job.Run(), errFailingStep)
+
+	data, err := os.ReadFile(filepath.Join(dir, "report.json"))
+	require.NoError(t, err)
+	report := &Report{}
+	require.NoError(t, json.Unmarshal(data, report))
+	require.Len(t, report.Steps, 3)
+	require.Equal(t, map[string]float64{"p95_seconds": 0.25, "bytes": 1024}, report.Steps[0].Measurements)
+	require.Equal(t, StepFailed, report.Steps[1].Status)
+	require.Equal(t, map[string]float64{"p95_seconds": 0.25, "bytes": 1024}, report.Steps[1].Measurements)
+	require.Nil(t, report.Steps[2].Measurements, "a step that never ran measured nothing")
+
+	data, err = os.ReadFile(filepath.Join(dir, "junit.xml"))
+	require.NoError(t, err)
+	junit := &junitTestSuites{}
+	require.NoError(t, xml.Unmarshal(data, junit))
+	properties := junit.Suites[0].Cases[1].Properties
+	require.NotNil(t, properties)
+	require.Equal(t, []junitProperty{{Name: "bytes", Value: "1024"}, {Name: "p95_seconds", Value: "0.25"}}, properties.Properties)
+}
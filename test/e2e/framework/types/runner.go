@@ -1,11 +1,18 @@
 package types
 
 import (
+	"flag"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 )
 
+var (
+	scenarioTags     = flag.String("scenario-tags", "", "comma separated tags, only scenarios with one of them are run")
+	skipScenarioTags = flag.String("skip-scenario-tags", "", "comma separated tags, scenarios with any of them are skipped")
+)
+
 // A wrapper around a job, so that internal job components don't require things like *testing.T
 // and can be reused elsewhere
 type Runner struct {
@@ -24,5 +31,30 @@ func (r *Runner) Run() {
 	if r.t.Failed() {
 		return
 	}
+	if len(r.Job.includeTags) == 0 && len(r.Job.excludeTags) == 0 {
+		r.Job.FilterTags(splitTags(*scenarioTags), splitTags(*skipScenarioTags))
+	}
+	if r.Job.artifactsDir == "" {
+		r.Job.SetArtifactsDir(*artifactsDir)
+	}
+	if r.Job.reportPath == "" {
+		r.Job.ReportTo(*reportPath)
+	}
+	if r.Job.junitReportPath == "" {
+		r.Job.JUnitReportTo(*junitReportPath)
+	}
+	if *soakDuration > 0 {
+		r.Job.SetSoakDuration(*soakDuration)
+	}
 	require.NoError(r.t, r.Job.Run())
 }
+
+func splitTags(tags string) []string {
+	var split []string
+	for _, tag := range strings.Split(tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			split = append(split, tag)
+		}
+	}
+	return split
+}
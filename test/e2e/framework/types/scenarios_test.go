@@ -3,6 +3,8 @@ package types
 import (
 	"fmt"
 	"testing"
+
+	"github.com/stretchr/testify/require"
 )
 
 // Test against a BYO cluster with Cilium and Hubble enabled,
@@ -124,3 +126,167 @@ func (d *DummyStep) Stop() error {
 func (d *DummyStep) Prevalidate() error {
 	return nil
 }
+
+func TestScenarioCleanupRunsAfterSteps(t *testing.T) {
+	var calls []string
+	job := NewJob("Validate scenario cleanup steps run after its steps")
+	job.AddScenario(NewScenario("Dummy Scenario",
+		&StepWrapper{Step: &RecordStep{Name: "step", calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+	).WithCleanup(
+		&StepWrapper{Step: &RecordStep{Name: "cleanup", calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+	))
+	job.AddStep(&RecordStep{Name: "after scenario", calls: &calls}, &StepOptions{SkipSavingParametersToJob: true})
+
+	require.NoError(t, job.Run())
+	require.Equal(t, []string{"step", "cleanup", "after scenario"}, calls)
+}
+
+func TestScenarioCleanupRunsOnFailure(t *testing.T) {
+	var calls []string
+	job := NewJob("Validate scenario cleanup steps run when a step fails")
+	job.AddScenario(NewScenario("Failing Scenario",
+		&StepWrapper{Step: &TestBackground{CounterName: "Counter"}, Opts: &StepOptions{RunInBackgroundWithID: "counter"}},
+		&StepWrapper{Step: &RecordStep{Name: "failing", Fail: true, calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+		&StepWrapper{Step: &RecordStep{Name: "skipped", calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+	).WithCleanup(
+		&StepWrapper{Step: &Stop{BackgroundID: "counter"}},
+		&StepWrapper{Step: &RecordStep{Name: "cleanup 1", Fail: true, calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+		&StepWrapper{Step: &RecordStep{Name: "cleanup 2", calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+	))
+	job.AddScenario(NewScenario("Unstarted Scenario").WithCleanup(
+		&StepWrapper{Step: &RecordStep{Name: "unstarted cleanup", calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+	))
+
+	err := job.Run()
+	require.ErrorIs(t, err, errFailingStep)
+	require.NotContains(t, err.Error(), "cleanup")
+	require.Equal(t, []string{"failing", "cleanup 1", "cleanup 2"}, calls)
+}
+
+func TestScenarioSetupRunsBeforeSteps(t *testing.T) {
+	var calls []string
+	job := NewJob("Validate scenario setup steps run after its preconditions and before its steps")
+	job.AddScenario(NewScenario("Dummy Scenario",
+		&StepWrapper{Step: &RecordStep{Name: "step", calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+	).WithSetup(
+		&StepWrapper{Step: &RecordStep{Name: "setup", calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+	).WithPreconditions(
+		&StepWrapper{Step: &RecordStep{Name: "precondition", calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+	).WithCleanup(
+		&StepWrapper{Step: &RecordStep{Name: "cleanup", calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+	))
+
+	require.NoError(t, job.Run())
+	require.Equal(t, []string{"precondition", "setup", "step", "cleanup"}, calls)
+}
+
+func TestScenarioSetupFailureRunsCleanup(t *testing.T) {
+	var calls []string
+	job := NewJob("Validate a failing scenario setup step skips its steps and runs its cleanup")
+	job.AddScenario(NewScenario("Failing Scenario",
+		&StepWrapper{Step: &RecordStep{Name: "step", calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+	).WithSetup(
+		&StepWrapper{Step: &TestBackground{CounterName: "Counter"}, Opts: &StepOptions{RunInBackgroundWithID: "counter"}},
+		&StepWrapper{Step: &RecordStep{Name: "setup", Fail: true, calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+	).WithCleanup(
+		&StepWrapper{Step: &Stop{BackgroundID: "counter"}},
+		&StepWrapper{Step: &RecordStep{Name: "cleanup", calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+	))
+
+	require.ErrorIs(t, job.Run(), errFailingStep)
+	require.Equal(t, []string{"setup", "cleanup"}, calls)
+}
+
+func TestScenarioCleanupSkipsUnstartedBackgroundSteps(t *testing.T) {
+	var calls []string
+	job := NewJob("Validate scenario cleanup doesn't stop background steps that never started")
+	job.AddScenario(NewScenario("Failing Scenario",
+		&StepWrapper{Step: &RecordStep{Name: "failing", Fail: true, calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+		&StepWrapper{Step: &TestBackground{CounterName: "Counter"}, Opts: &StepOptions{RunInBackgroundWithID: "counter"}},
+	).WithCleanup(
+		&StepWrapper{Step: &Stop{BackgroundID: "counter"}},
+		&StepWrapper{Step: &RecordStep{Name: "cleanup", calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+	))
+
+	require.ErrorIs(t, job.Run(), errFailingStep)
+	require.Equal(t, []string{"failing", "cleanup"}, calls)
+}
+
+func TestScenarioMustStopBackgroundSteps(t *testing.T) {
+	job := NewJob("Validate a scenario can't leave its background steps running")
+	job.AddScenario(NewScenario("Leaking Scenario",
+		&StepWrapper{Step: &TestBackground{CounterName: "Counter"}, Opts: &StepOptions{RunInBackgroundWithID: "counter"}},
+	))
+	job.AddStep(&Stop{BackgroundID: "counter"}, nil)
+
+	err := job.Run()
+	require.ErrorIs(t, err, ErrLeakedBackgroundSteps)
+	require.Contains(t, err.Error(), "counter")
+}
+
+func TestScenarioBackgroundStepOutlivesScenario(t *testing.T) {
+	job := NewJob("Validate a background step can be set to outlive its scenario")
+	job.AddScenario(NewScenario("Long Lived Scenario",
+		&StepWrapper{Step: &TestBackground{CounterName: "Counter"}, Opts: &StepOptions{RunInBackgroundWithID: "counter", OutlivesScenario: true}},
+	))
+	job.AddStep(&Stop{BackgroundID: "counter"}, nil)
+
+	require.NoError(t, job.Run())
+}
+
+func TestScenarioFailureReportsLeakedBackgroundSteps(t *testing.T) {
+	var calls []string
+	job := NewJob("Validate a failing scenario lists the background steps its failure left running")
+	job.AddScenario(NewScenario("Failing Scenario",
+		&StepWrapper{Step: &TestBackground{CounterName: "Counter"}, Opts: &StepOptions{RunInBackgroundWithID: "counter"}},
+		&StepWrapper{Step: &RecordStep{Name: "failing", Fail: true, calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+		&StepWrapper{Step: &Stop{BackgroundID: "counter"}},
+	))
+
+	err := job.Run()
+	require.ErrorIs(t, err, errFailingStep)
+	require.ErrorIs(t, err, ErrLeakedBackgroundSteps)
+	require.Contains(t, err.Error(), "counter")
+}
+
+func TestScenarioFailureDiagnosticsRunOnFailure(t *testing.T) {
+	var calls []string
+	job := NewJob("Validate scenario failure diagnostics run before its cleanup when a step fails")
+	job.AddScenario(NewScenario("Failing Scenario",
+		&StepWrapper{Step: &RecordStep{Name: "failing", Fail: true, calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+	).WithCleanup(
+		&StepWrapper{Step: &RecordStep{Name: "cleanup", calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+	).WithFailureDiagnostics(
+		&StepWrapper{Step: &RecordStep{Name: "diagnostics 1", Fail: true, calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+		&StepWrapper{Step: &RecordStep{Name: "diagnostics 2", calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+	))
+
+	err := job.Run()
+	require.ErrorIs(t, err, errFailingStep)
+	require.NotContains(t, err.Error(), "diagnostics")
+	require.Equal(t, []string{"failing", "diagnostics 1", "diagnostics 2", "cleanup"}, calls)
+}
+
+func TestScenarioFailureDiagnosticsSkippedOnSuccess(t *testing.T) {
+	var calls []string
+	job := NewJob("Validate scenario failure diagnostics don't run when the scenario passes")
+	job.AddScenario(NewScenario("Passing Scenario",
+		&StepWrapper{Step: &RecordStep{Name: "step", calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+	).WithFailureDiagnostics(
+		&StepWrapper{Step: &RecordStep{Name: "diagnostics", calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+	))
+
+	require.NoError(t, job.Run())
+	require.Equal(t, []string{"step"}, calls)
+}
+
+func TestScenarioFailureDiagnosticsRejectBackgroundSteps(t *testing.T) {
+	job := NewJob("Validate scenario failure diagnostics can't run in the background")
+	job.AddScenario(NewScenario("Dummy Scenario",
+		&StepWrapper{Step: &RecordStep{Name: "step", calls: &[]string{}}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+	).WithFailureDiagnostics(
+		&StepWrapper{Step: &TestBackground{CounterName: "Counter"}, Opts: &StepOptions{RunInBackgroundWithID: "counter"}},
+	))
+
+	require.ErrorIs(t, job.Run(), ErrBackgroundDiagnostics)
+}
@@ -0,0 +1,72 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var (
+	ErrScenarioSkipped         = fmt.Errorf("scenario skipped")
+	ErrBackgroundPreconditions = fmt.Errorf("preconditions can't start or stop background steps")
+)
+
+// skipError is the error of a precondition skipping its scenario, see SkipScenario
+type skipError struct {
+	reason string
+}
+
+func (e *skipError) Error() string {
+	return fmt.Sprintf("%s: %s", e.reason, ErrScenarioSkipped)
+}
+
+func (e *skipError) Unwrap() error {
+	return ErrScenarioSkipped
+}
+
+// SkipScenario is the error a precondition returns to skip its scenario for reason, e.g. "advanced metrics
+// aren't enabled", rather than fail it
+func SkipScenario(reason string) error {
+	return &skipError{reason: reason}
+}
+
+// skipReason returns the reason a precondition skipped its scenario for, if err skips it
+func skipReason(err error) (string, bool) {
+	var skip *skipError
+	if errors.As(err, &skip) {
+		return skip.reason, true
+	}
+	return "", false
+}
+
+// WithPreconditions adds steps run before the scenario's steps, checking the cluster supports the scenario, such
+// as its nodes running Linux or Retina having advanced metrics enabled. A precondition returning SkipScenario
+// skips the rest of the scenario, its cleanup included, which is reported as skipped with the reason, and the job
+// goes on with the steps after it. Any other error fails the scenario as usual
+func (s *Scenario) WithPreconditions(steps ...*StepWrapper) *Scenario {
+	s.preconditions = append(s.preconditions, steps...)
+	return s
+}
+
+func (s *Scenario) isPrecondition(stepw *StepWrapper) bool {
+	return slices.Contains(s.preconditions, stepw)
+}
+
+// skips reports whether err of the scenario's step skips the scenario, and the reason it does
+func (s *Scenario) skips(stepw *StepWrapper, err error) (string, bool) {
+	if !s.isPrecondition(stepw) {
+		return "", false
+	}
+	return skipReason(err)
+}
+
+// validatePreconditions checks the scenario's preconditions don't start or stop background steps, as skipping
+// the scenario skips its Stop steps along with the rest of it
+func (j *Job) validatePreconditions(scenario *Scenario) error {
+	for _, step := range scenario.preconditions {
+		if _, isStop := step.Step.(*Stop); isStop || step.Opts.RunInBackgroundWithID != "" {
+			return fmt.Errorf("step \"%s\": %w", j.GetPrettyStepName(step), ErrBackgroundPreconditions)
+		}
+	}
+	return nil
+}
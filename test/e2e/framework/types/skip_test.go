@@ -0,0 +1,118 @@
+package types
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// SkipStep is a precondition that skips its scenario for reason, or passes without one
+type SkipStep struct {
+	reason string
+	calls  *[]string
+}
+
+func (s *SkipStep) Run() error {
+	*s.calls = append(*s.calls, "precondition")
+	if s.reason != "" {
+		return SkipScenario(s.reason)
+	}
+	return nil
+}
+
+func (s *SkipStep) Stop() error {
+	return nil
+}
+
+func (s *SkipStep) Prevalidate() error {
+	return nil
+}
+
+func TestScenarioPreconditionSkipsScenario(t *testing.T) {
+	dir := t.TempDir()
+	var calls []string
+	job := NewJob("Validate a precondition skips its scenario and the job goes on")
+	job.AddScenario(NewScenario("Skipped Scenario",
+		&StepWrapper{Step: &RecordStep{Name: "step", calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+		&StepWrapper{Step: &Sleep{}, Opts: &StepOptions{RunInBackgroundWithID: "background"}},
+	).WithPreconditions(
+		&StepWrapper{Step: &SkipStep{reason: "advanced metrics aren't enabled", calls: &calls}},
+	).WithCleanup(
+		&StepWrapper{Step: &Stop{BackgroundID: "background"}},
+		&StepWrapper{Step: &RecordStep{Name: "cleanup", calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+	))
+	job.AddStep(&RecordStep{Name: "after scenario", calls: &calls}, &StepOptions{SkipSavingParametersToJob: true})
+	job.ReportTo(filepath.Join(dir, "report.json"))
+
+	require.NoError(t, job.Run())
+	require.Equal(t, []string{"precondition", "after scenario"}, calls)
+
+	data, err := os.ReadFile(filepath.Join(dir, "report.json"))
+	require.NoError(t, err)
+	report := &Report{}
+	require.NoError(t, json.Unmarshal(data, report))
+
+	require.Len(t, report.Steps, 6)
+	for _, step := range report.Steps[:5] {
+		require.Equal(t, StepSkipped, step.Status)
+		require.Equal(t, "advanced metrics aren't enabled", step.SkipReason)
+	}
+	require.Equal(t, "SkipStep", report.Steps[0].Type)
+	require.NotNil(t, report.Steps[0].Start)
+	for _, step := range report.Steps[1:5] {
+		require.Nil(t, step.Start)
+	}
+	require.Equal(t, StepPassed, report.Steps[5].Status)
+}
+
+func TestScenarioPreconditionPasses(t *testing.T) {
+	var calls []string
+	job := NewJob("Validate a scenario whose preconditions pass runs")
+	job.AddScenario(NewScenario("Supported Scenario",
+		&StepWrapper{Step: &RecordStep{Name: "step", calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+	).WithPreconditions(
+		&StepWrapper{Step: &SkipStep{calls: &calls}},
+	))
+
+	require.NoError(t, job.Run())
+	require.Equal(t, []string{"precondition", "step"}, calls)
+}
+
+func TestScenarioPreconditionFailureFails(t *testing.T) {
+	var calls []string
+	job := NewJob("Validate a precondition failing without skipping fails its scenario")
+	job.AddScenario(NewScenario("Failing Scenario",
+		&StepWrapper{Step: &RecordStep{Name: "step", calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+	).WithPreconditions(
+		&StepWrapper{Step: &RecordStep{Name: "precondition", Fail: true, calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+	).WithCleanup(
+		&StepWrapper{Step: &RecordStep{Name: "cleanup", calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+	))
+
+	require.ErrorIs(t, job.Run(), errFailingStep)
+	require.Equal(t, []string{"precondition", "cleanup"}, calls)
+}
+
+func TestSkipOutsidePreconditionsFails(t *testing.T) {
+	var calls []string
+	job := NewJob("Validate only preconditions skip their scenario")
+	job.AddScenario(NewScenario("Failing Scenario",
+		&StepWrapper{Step: &SkipStep{reason: "too late", calls: &calls}},
+	))
+
+	require.ErrorIs(t, job.Run(), ErrScenarioSkipped)
+}
+
+func TestScenarioPreconditionsRejectBackgroundSteps(t *testing.T) {
+	job := NewJob("Validate scenario preconditions can't run in the background")
+	job.AddScenario(NewScenario("Dummy Scenario",
+		&StepWrapper{Step: &Stop{BackgroundID: "counter"}},
+	).WithPreconditions(
+		&StepWrapper{Step: &TestBackground{CounterName: "Counter"}, Opts: &StepOptions{RunInBackgroundWithID: "counter"}},
+	))
+
+	require.ErrorIs(t, job.Run(), ErrBackgroundPreconditions)
+}
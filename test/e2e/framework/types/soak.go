@@ -0,0 +1,252 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+var soakDuration = flag.Duration("soak-duration", 0, "overrides how long each soak of the job keeps picking scenarios")
+
+var (
+	ErrEmptySoakPool     = fmt.Errorf("soak has no scenarios to pick from")
+	ErrInvalidSoakWeight = fmt.Errorf("soak scenario weight must be positive")
+	ErrInvalidSoakLength = fmt.Errorf("soak duration must be positive")
+	ErrSoakRunsFailed    = fmt.Errorf("soak scenario runs failed")
+)
+
+// A Soak runs scenarios picked at random from a weighted pool back to back until its duration is up, for soak
+// and chaos testing. A scenario with twice the weight of another is picked about twice as often. A failing
+// scenario doesn't end the soak: its remaining Stop steps run so its background steps don't leak, and the next
+// scenario is picked. The soak fails at the end if any run did, with a tally of the runs of each scenario
+type Soak struct {
+	name     string
+	duration time.Duration
+	seed     int64
+	pool     []*weightedScenario
+
+	job *Job
+}
+
+type weightedScenario struct {
+	scenario *Scenario
+	weight   int
+
+	runs     int
+	failures int
+}
+
+func NewSoak(name string, duration time.Duration) *Soak {
+	if name == "" {
+		log.Printf("soak name is empty")
+	}
+
+	return &Soak{
+		name:     name,
+		duration: duration,
+		seed:     time.Now().UnixNano(),
+	}
+}
+
+// AddScenario adds a scenario to the pool, picked with the given weight relative to the other scenarios
+func (s *Soak) AddScenario(scenario *Scenario, weight int) *Soak {
+	s.pool = append(s.pool, &weightedScenario{scenario: scenario, weight: weight})
+	return s
+}
+
+// WithSeed makes the soak pick the same sequence of scenarios as an earlier run, which logs its seed
+func (s *Soak) WithSeed(seed int64) *Soak {
+	s.seed = seed
+	return s
+}
+
+// AddSoak adds the soak as a single step of the job. Its scenarios take their parameters from the job like any other
+func (j *Job) AddSoak(soak *Soak) {
+	soak.job = j
+	for _, weighted := range soak.pool {
+		for _, step := range append(weighted.scenario.allSteps(), weighted.scenario.diagnostics...) {
+			j.Scenarios[step] = weighted.scenario
+		}
+	}
+	j.soaks = append(j.soaks, soak)
+	j.AddStep(soak, nil)
+}
+
+// SetSoakDuration overrides the duration of every soak of the job
+func (j *Job) SetSoakDuration(duration time.Duration) {
+	for _, soak := range j.soaks {
+		soak.duration = duration
+	}
+}
+
+// filterTags drops the scenarios deselected by the job's tags from the pool
+func (s *Soak) filterTags(include, exclude []string) {
+	pool := make([]*weightedScenario, 0, len(s.pool))
+	for _, weighted := range s.pool {
+		if !weighted.scenario.selected(include, exclude) {
+			log.Printf("dropping scenario %s from soak %s, deselected by tags %v", weighted.scenario.name, s.name, weighted.scenario.tags)
+			continue
+		}
+		pool = append(pool, weighted)
+	}
+	s.pool = pool
+}
+
+// validate validates the steps of each scenario in the pool, which aren't part of the job's steps.
+// Each scenario's background steps are validated on their own, as the scenarios run in any order
+func (s *Soak) validate() error {
+	for _, weighted := range s.pool {
+		for _, step := range weighted.scenario.allSteps() {
+			err := s.job.validateStep(step)
+			if err != nil {
+				return err
+			}
+		}
+		for _, step := range weighted.scenario.diagnostics {
+			err := s.job.validateDiagnosticStep(step)
+			if err != nil {
+				return err
+			}
+		}
+
+		err := validateBackgroundSteps(weighted.scenario.allSteps(), make(map[string]*StepWrapper))
+		if err != nil {
+			return fmt.Errorf("scenario %s of soak %s: %w", weighted.scenario.name, s.name, err)
+		}
+		err = s.job.validatePreconditions(weighted.scenario)
+		if err != nil {
+			return fmt.Errorf("scenario %s of soak %s: %w", weighted.scenario.name, s.name, err)
+		}
+	}
+	return nil
+}
+
+func (s *Soak) Prevalidate() error {
+	if s.duration <= 0 {
+		return fmt.Errorf("soak %s: %w", s.name, ErrInvalidSoakLength)
+	}
+	if len(s.pool) == 0 {
+		return fmt.Errorf("soak %s: %w", s.name, ErrEmptySoakPool)
+	}
+
+	for _, weighted := range s.pool {
+		if weighted.weight <= 0 {
+			return fmt.Errorf("scenario %s of soak %s has weight %d: %w", weighted.scenario.name, s.name, weighted.weight, ErrInvalidSoakWeight)
+		}
+		for _, step := range append(weighted.scenario.allSteps(), weighted.scenario.diagnostics...) {
+			err := step.Step.Prevalidate()
+			if err != nil {
+				return err //nolint:wrapcheck // don't wrap error, wouldn't provide any more context than the error itself
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Soak) Run() error {
+	return s.RunContext(context.Background())
+}
+
+// RunContext soaks until the duration is up or ctx is cancelled, in which case the soak fails once the scenario
+// running is cleaned up
+func (s *Soak) RunContext(ctx context.Context) error {
+	log.Printf("soaking %s for %s with seed %d", s.name, s.duration, s.seed)
+	random := rand.New(rand.NewSource(s.seed)) //nolint:gosec // picking scenarios doesn't need a secure source
+
+	deadline := time.Now().Add(s.duration)
+	for time.Now().Before(deadline) && ctx.Err() == nil {
+		weighted := s.pick(random)
+		weighted.runs++
+
+		err := s.runScenario(weighted.scenario)
+		if err != nil {
+			weighted.failures++
+			log.Printf("run %d of scenario %s failed: %v", weighted.runs, weighted.scenario.name, err)
+		}
+	}
+
+	err := s.report()
+	if ctx.Err() != nil {
+		return errors.Join(fmt.Errorf("soak %s: %w: %w", s.name, ErrStepCancelled, context.Cause(ctx)), err)
+	}
+	return err
+}
+
+func (s *Soak) pick(random *rand.Rand) *weightedScenario {
+	total := 0
+	for _, weighted := range s.pool {
+		total += weighted.weight
+	}
+
+	n := random.Intn(total)
+	for _, weighted := range s.pool {
+		if n < weighted.weight {
+			return weighted
+		}
+		n -= weighted.weight
+	}
+	return s.pool[len(s.pool)-1]
+}
+
+// runScenario runs the scenario's steps in order, then its cleanup steps. When one fails, the scenario's failure
+// diagnostics run, then only its remaining Stop steps and cleanup steps. A precondition skipping the scenario
+// ends the run without failing it
+func (s *Soak) runScenario(scenario *Scenario) error {
+	defer s.job.endScenario(scenario)
+
+	steps := scenario.allSteps()
+	for i, wrapper := range steps {
+		err := s.job.runStep(wrapper)
+		if err == nil {
+			continue
+		}
+		if reason, skips := scenario.skips(wrapper, err); skips {
+			log.Printf("skipping run of scenario %s: %s", scenario.name, reason)
+			return nil
+		}
+
+		s.job.runDiagnostics(scenario)
+
+		for _, remaining := range steps[i+1:] {
+			if _, ok := remaining.Step.(*Stop); !ok && !scenario.isCleanup(remaining) {
+				continue
+			}
+			cleanupErr := s.job.runCleanupStep(remaining)
+			if cleanupErr != nil {
+				log.Printf("cleanup of scenario %s failed: %v", scenario.name, cleanupErr)
+			}
+		}
+		return errors.Join(err, s.job.checkBackgroundLeaks(scenario))
+	}
+	return s.job.checkBackgroundLeaks(scenario)
+}
+
+// report logs how often each scenario ran and failed, most run first, and fails if any run did
+func (s *Soak) report() error {
+	pool := append([]*weightedScenario{}, s.pool...)
+	sort.SliceStable(pool, func(i, k int) bool {
+		return pool[i].runs > pool[k].runs
+	})
+
+	runs, failures := 0, 0
+	for _, weighted := range pool {
+		log.Printf("soak %s: scenario %s passed %d of %d runs", s.name, weighted.scenario.name, weighted.runs-weighted.failures, weighted.runs)
+		runs += weighted.runs
+		failures += weighted.failures
+	}
+	log.Printf("soak %s: %d of %d scenario runs passed, seed %d", s.name, runs-failures, runs, s.seed)
+
+	if failures > 0 {
+		return fmt.Errorf("soak %s: %d of %d runs: %w", s.name, failures, runs, ErrSoakRunsFailed)
+	}
+	return nil
+}
+
+func (s *Soak) Stop() error {
+	return nil
+}
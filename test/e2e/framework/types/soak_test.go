@@ -0,0 +1,62 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newSoakJob(calls *[]string, failing bool) *Job {
+	job := NewJob("Validate a soak picks scenarios from its pool until its duration is up")
+	job.AddSoak(NewSoak("Dummy Soak", 50*time.Millisecond).WithSeed(1).
+		AddScenario(NewScenario("Frequent Scenario",
+			&StepWrapper{Step: &RecordStep{Name: "frequent", calls: calls}},
+			&StepWrapper{Step: &Sleep{Duration: time.Millisecond}},
+		), 3).
+		AddScenario(NewScenario("Rare Scenario",
+			&StepWrapper{Step: &TestBackground{CounterName: "rare"}, Opts: &StepOptions{RunInBackgroundWithID: "rare-counter"}},
+			&StepWrapper{Step: &RecordStep{Name: "rare", Fail: failing, calls: calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+			&StepWrapper{Step: &RecordStep{Name: "rare cleanup", calls: calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+			&StepWrapper{Step: &Stop{BackgroundID: "rare-counter"}},
+		), 1))
+	job.AddStep(&RecordStep{Name: "after soak", calls: calls}, &StepOptions{SkipSavingParametersToJob: true})
+	return job
+}
+
+func TestSoakRunsWeightedScenarios(t *testing.T) {
+	var calls []string
+	require.NoError(t, newSoakJob(&calls, false).Run())
+
+	counts := map[string]int{}
+	for _, call := range calls {
+		counts[call]++
+	}
+	require.Equal(t, "after soak", calls[len(calls)-1])
+	require.Positive(t, counts["rare"])
+	require.Equal(t, counts["rare"], counts["rare cleanup"])
+	require.Greater(t, counts["frequent"], counts["rare"])
+}
+
+func TestSoakReportsFailedRuns(t *testing.T) {
+	var calls []string
+	err := newSoakJob(&calls, true).Run()
+	require.ErrorIs(t, err, ErrSoakRunsFailed)
+
+	// a failed run skips the rest of its scenario, but still stops its background steps
+	require.NotContains(t, calls, "rare cleanup")
+	require.NotContains(t, calls, "after soak")
+	require.Contains(t, calls, "frequent")
+}
+
+func TestSoakValidatesPool(t *testing.T) {
+	job := NewJob("Validate a soak needs a pool of positively weighted scenarios")
+	job.AddSoak(NewSoak("Empty Soak", time.Second))
+	require.ErrorIs(t, job.Run(), ErrEmptySoakPool)
+
+	job = NewJob("Validate a soak needs a pool of positively weighted scenarios")
+	job.AddSoak(NewSoak("Unweighted Soak", time.Second).AddScenario(NewScenario("Dummy Scenario",
+		&StepWrapper{Step: &RecordStep{Name: "dummy", calls: &[]string{}}},
+	), 0))
+	require.ErrorIs(t, job.Run(), ErrInvalidSoakWeight)
+}
@@ -1,5 +1,7 @@
 package types
 
+import "time"
+
 var DefaultOpts = StepOptions{
 	// when wanting to expect an error, set to true
 	ExpectError: false,
@@ -14,7 +16,10 @@ type Step interface {
 	// Useful when wanting to do parameter checking, for example
 	// if a parameter length is known to be required less than 80 characters,
 	// do this here so we don't find out later on when we run the step
-	// when possible, try to avoid making external calls, this should be fast and simple
+	// when possible, try to avoid making external calls, this should be fast and simple.
+	// The job prevalidates every step, with its parameters resolved, before running any of them,
+	// and fails with the index of the first step that returns an error, wrapped in ErrInvalidStep.
+	// Steps that have nothing to check return nil
 	Prevalidate() error
 
 	// Primary step where test logic is executed
@@ -38,4 +43,33 @@ type StepOptions struct {
 	// and then later on when Stop is called with job name,
 	// it will call Stop() on the step
 	RunInBackgroundWithID string
+
+	// Lets a background step started in a scenario keep running after
+	// the scenario ends, to be stopped by a later step of the job.
+	// Otherwise a scenario has to stop every background step it
+	// starts, and fails listing those still running once it ends
+	OutlivesScenario bool
+
+	// Fails the step if its Run hasn't returned after this long, for steps
+	// that can hang on a wedged cluster. Zero means no timeout. A background
+	// step's Run returns once it is started, so this only bounds the start;
+	// it keeps running until its Stop step regardless
+	Timeout time.Duration
+
+	// Re-runs the step while its Run fails, for steps that flake
+	// transiently, e.g. a PortForward right after its pod comes up
+	Retry *Retry
+}
+
+// Retry is how a step is re-run when it fails, or when it succeeds for a step expected to error, so any
+// step such as a flaky port forward or exec can be retried without changing it. Each attempt gets the
+// whole Timeout of the step, and one running past it fails the step without being retried, as it may
+// still be running. A step that bounds its own retries, like a PortForward, is retried once they fail
+type Retry struct {
+	// Attempts is how many times the step runs at most, the first included
+	Attempts int
+
+	// Delay between attempts, doubled after each one with ExpBackoff
+	Delay      time.Duration
+	ExpBackoff bool
 }
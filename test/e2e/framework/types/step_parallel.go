@@ -0,0 +1,153 @@
+package types
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"reflect"
+	"sync"
+)
+
+var (
+	ErrParallelStepsFailed = fmt.Errorf("parallel steps failed")
+	ErrStopInParallel      = fmt.Errorf("stop steps can't run in parallel")
+)
+
+// Parallel runs its steps concurrently and waits for all of them, failing with every error if any of them
+// fails. Each step keeps its own options, so it can expect an error, time out or retry on its own, and
+// take its parameters from the job like it would on its own. A background step started in parallel is
+// stopped by a Stop step after the group, as Stop steps can't run within one
+type Parallel struct {
+	steps []*StepWrapper
+
+	job *Job
+}
+
+func NewParallel(steps ...*StepWrapper) *Parallel {
+	return &Parallel{
+		steps: steps,
+	}
+}
+
+// validate points the steps at the scenario or suite of the group, then validates them like any other step
+func (p *Parallel) validate(j *Job, group *StepWrapper) error {
+	p.job = j
+	for _, step := range p.steps {
+		if stop, isStop := step.Step.(*Stop); isStop {
+			return fmt.Errorf("stop of \"%s\": %w", stop.BackgroundID, ErrStopInParallel)
+		}
+
+		if scenario, exists := j.Scenarios[group]; exists {
+			j.Scenarios[step] = scenario
+		}
+		if suite, exists := j.Suites[group]; exists {
+			j.Suites[step] = suite
+		}
+
+		err := j.validateStep(step)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Parallel) Prevalidate() error {
+	for _, step := range p.steps {
+		err := step.Step.Prevalidate()
+		if err != nil {
+			return err //nolint:wrapcheck // don't wrap error, wouldn't provide any more context than the error itself
+		}
+	}
+	return nil
+}
+
+func (p *Parallel) Run() error {
+	return p.RunContext(context.Background())
+}
+
+// RunContext runs the steps like Run. Each of them runs with a context of its own, cancelled along with ctx, so
+// the group returns once they did. A background step started in the group keeps running once the group returns,
+// until it's stopped or the context the job gives it is cancelled
+func (p *Parallel) RunContext(ctx context.Context) error {
+	log.Printf("running %d steps in parallel", len(p.steps))
+
+	errs := make([]error, len(p.steps))
+	var wg sync.WaitGroup
+	for i, step := range p.steps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stepCtx, cancel := context.WithCancelCause(p.job.stepContext(step))
+			stopCancelling := context.AfterFunc(ctx, func() {
+				cancel(context.Cause(ctx))
+			})
+			errs[i] = p.job.runStepContext(stepCtx, step)
+			stopCancelling()
+			if step.Opts.RunInBackgroundWithID == "" {
+				cancel(nil)
+			}
+		}()
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d steps: %w: %w", failed, len(p.steps), ErrParallelStepsFailed, errors.Join(errs...))
+	}
+	return nil
+}
+
+func (p *Parallel) Stop() error {
+	return nil
+}
+
+// SetArtifactDir gives each step writing artifacts a directory of its own within the group's
+func (p *Parallel) SetArtifactDir(dir string) {
+	for i, step := range p.steps {
+		if writer, ok := step.Step.(ArtifactWriter); ok {
+			writer.SetArtifactDir(filepath.Join(dir, fmt.Sprintf("%03d-%s", i, reflect.TypeOf(step.Step).Elem().Name())))
+		}
+	}
+}
+
+// MarshalJSON records the parameters of each step of the group, in order
+func (p *Parallel) MarshalJSON() ([]byte, error) {
+	parameters := make([]json.RawMessage, 0, len(p.steps))
+	for _, step := range p.steps {
+		data, err := json.Marshal(step.Step)
+		if err != nil {
+			return nil, fmt.Errorf("error serializing parameters of step %s: %w", reflect.TypeOf(step.Step).Elem().Name(), err)
+		}
+		parameters = append(parameters, data)
+	}
+	return json.Marshal(parameters) //nolint:wrapcheck // don't wrap error, wouldn't provide any more context than the error itself
+}
+
+// UnmarshalJSON replays recorded parameters onto the steps of the group, which must match them in number
+func (p *Parallel) UnmarshalJSON(data []byte) error {
+	var parameters []json.RawMessage
+	err := json.Unmarshal(data, &parameters)
+	if err != nil {
+		return fmt.Errorf("error parsing parameters of parallel steps: %w", err)
+	}
+	if len(parameters) != len(p.steps) {
+		return fmt.Errorf("parallel group has %d steps, recording has %d: %w", len(p.steps), len(parameters), ErrRecordingMismatch)
+	}
+
+	for i, step := range p.steps {
+		err = json.Unmarshal(parameters[i], step.Step)
+		if err != nil {
+			return fmt.Errorf("error replaying parameters of step %s: %w", reflect.TypeOf(step.Step).Elem().Name(), err)
+		}
+	}
+	return nil
+}
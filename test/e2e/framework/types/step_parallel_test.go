@@ -0,0 +1,127 @@
+package types
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParallelRunsConcurrently(t *testing.T) {
+	job := NewJob("Validate parallel steps run at the same time")
+	job.AddStep(NewParallel(
+		&StepWrapper{Step: &Sleep{Duration: 200 * time.Millisecond}},
+		&StepWrapper{Step: &Sleep{Duration: 200 * time.Millisecond}},
+		&StepWrapper{Step: &Sleep{Duration: 200 * time.Millisecond}},
+	), nil)
+
+	start := time.Now()
+	require.NoError(t, job.Run())
+	require.Less(t, time.Since(start), 400*time.Millisecond)
+}
+
+func TestParallelCollectsErrors(t *testing.T) {
+	first, second, third := 0, 0, 0
+	job := NewJob("Validate every failing parallel step is reported")
+	job.AddStep(NewParallel(
+		&StepWrapper{Step: &FlakyStep{Failures: 1, runs: &first}},
+		&StepWrapper{Step: &FlakyStep{Failures: 0, runs: &second}},
+		&StepWrapper{Step: &FlakyStep{Failures: 1, runs: &third}},
+	), nil)
+
+	err := job.Run()
+	require.ErrorIs(t, err, ErrParallelStepsFailed)
+	require.ErrorIs(t, err, ErrNonNilError)
+	require.Contains(t, err.Error(), "2 of 3 steps")
+	require.Equal(t, []int{1, 1, 1}, []int{first, second, third})
+}
+
+func TestParallelKeepsStepOptions(t *testing.T) {
+	failing, flaky := 0, 0
+	job := NewJob("Validate parallel steps keep their own options")
+	job.AddStep(NewParallel(
+		&StepWrapper{Step: &FlakyStep{Failures: 1, runs: &failing}, Opts: &StepOptions{ExpectError: true}},
+		&StepWrapper{Step: &FlakyStep{Failures: 2, runs: &flaky}, Opts: &StepOptions{Retry: &Retry{Attempts: 3, Delay: time.Millisecond}}},
+	), nil)
+
+	require.NoError(t, job.Run())
+	require.Equal(t, 1, failing)
+	require.Equal(t, 3, flaky)
+}
+
+func TestParallelBackgroundSteps(t *testing.T) {
+	job := NewJob("Validate background steps started in parallel are stopped after the group")
+	job.AddScenario(NewScenario("Parallel Counters",
+		&StepWrapper{Step: NewParallel(
+			&StepWrapper{Step: &TestBackground{CounterName: "First Counter"}, Opts: &StepOptions{RunInBackgroundWithID: "first-counter", SkipSavingParametersToJob: true}},
+			&StepWrapper{Step: &TestBackground{CounterName: "Second Counter"}, Opts: &StepOptions{RunInBackgroundWithID: "second-counter", SkipSavingParametersToJob: true}},
+		)},
+		&StepWrapper{Step: &Sleep{Duration: 100 * time.Millisecond}},
+		&StepWrapper{Step: &Stop{BackgroundID: "first-counter"}},
+		&StepWrapper{Step: &Stop{BackgroundID: "second-counter"}},
+	))
+	require.NoError(t, job.Run())
+}
+
+func TestParallelBackgroundStepNeedsStop(t *testing.T) {
+	job := NewJob("Validate a background step started in parallel must be stopped")
+	job.AddStep(NewParallel(
+		&StepWrapper{Step: &TestBackground{CounterName: "Orphan Counter"}, Opts: &StepOptions{RunInBackgroundWithID: "orphan-counter"}},
+	), nil)
+	require.ErrorIs(t, job.Run(), ErrOrphanSteps)
+}
+
+func TestParallelRejectsStop(t *testing.T) {
+	job := NewJob("Validate a stop step can't run in parallel")
+	job.AddStep(&TestBackground{CounterName: "Counter"}, &StepOptions{RunInBackgroundWithID: "counter"})
+	job.AddStep(NewParallel(
+		&StepWrapper{Step: &Stop{BackgroundID: "counter"}},
+	), nil)
+	require.ErrorIs(t, job.Run(), ErrStopInParallel)
+}
+
+func TestParallelRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recording.json")
+
+	recorded := NewJob("Validate the steps of a parallel group are recorded with their inputs")
+	recorded.AddStep(NewParallel(
+		&StepWrapper{Step: &Sleep{Duration: time.Millisecond}},
+		&StepWrapper{Step: &Sleep{Duration: 2 * time.Millisecond}},
+	), nil)
+	recorded.RecordTo(path)
+	require.NoError(t, recorded.Run())
+
+	recording, err := LoadRecording(path)
+	require.NoError(t, err)
+	require.Len(t, recording.Steps, 1)
+	require.Equal(t, "Parallel", recording.Steps[0].Type)
+
+	first, second := &Sleep{Duration: time.Hour}, &Sleep{Duration: time.Hour}
+	replayed := NewJob("Validate the steps of a parallel group are replayed with their inputs")
+	replayed.AddStep(NewParallel(&StepWrapper{Step: first}, &StepWrapper{Step: second}), nil)
+	replayed.ReplayFrom(recording)
+	require.NoError(t, replayed.Run())
+
+	require.Equal(t, time.Millisecond, first.Duration)
+	require.Equal(t, 2*time.Millisecond, second.Duration)
+}
+
+func TestParallelCancelledWithContext(t *testing.T) {
+	parallel := NewParallel(
+		&StepWrapper{Step: &Sleep{Duration: time.Minute}},
+		&StepWrapper{Step: &Sleep{Duration: time.Minute}},
+	)
+	job := NewJob("Validate parallel steps are cancelled along with the group's context")
+	job.AddStep(parallel, nil)
+	require.NoError(t, job.Validate())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := parallel.RunContext(ctx)
+	require.ErrorIs(t, err, ErrParallelStepsFailed)
+	require.ErrorIs(t, err, ErrStepCancelled)
+	require.Less(t, time.Since(start), time.Second)
+}
@@ -0,0 +1,102 @@
+package types
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrRetryBackground = fmt.Errorf("background and stop steps can't be wrapped in a retry")
+	ErrNestedRetry     = fmt.Errorf("a step wrapped in a retry can't retry on its own")
+)
+
+// RetryStep re-runs its step while it fails, up to Attempts times with Delay between attempts, doubled after each
+// one with ExpBackoff, for wrapping a flaky step where it's added rather than setting its StepOptions.Retry. The
+// step keeps its own options: one expected to error is re-run while it succeeds, and passes once it errors, and
+// each attempt gets its whole Timeout. Once every attempt failed, the retry fails with the error of each attempt
+type RetryStep struct {
+	step  *StepWrapper
+	retry Retry
+}
+
+func NewRetryStep(step *StepWrapper, retry Retry) *RetryStep {
+	return &RetryStep{
+		step:  step,
+		retry: retry,
+	}
+}
+
+// validate points the step at the scenario or suite of the retry, then validates it like any other step
+func (r *RetryStep) validate(j *Job, retry *StepWrapper) error {
+	if r.retry.Attempts < 1 {
+		return fmt.Errorf("retry of step \"%s\" has %d attempts: %w", j.GetPrettyStepName(r.step), r.retry.Attempts, ErrInvalidRetry)
+	}
+	if _, isStop := r.step.Step.(*Stop); isStop || (r.step.Opts != nil && r.step.Opts.RunInBackgroundWithID != "") {
+		return fmt.Errorf("step \"%s\": %w", j.GetPrettyStepName(r.step), ErrRetryBackground)
+	}
+	if r.step.Opts != nil && r.step.Opts.Retry != nil {
+		return fmt.Errorf("step \"%s\": %w", j.GetPrettyStepName(r.step), ErrNestedRetry)
+	}
+
+	if scenario, exists := j.Scenarios[retry]; exists {
+		j.Scenarios[r.step] = scenario
+	}
+	if suite, exists := j.Suites[retry]; exists {
+		j.Suites[r.step] = suite
+	}
+	return j.validateStep(r.step)
+}
+
+func (r *RetryStep) Prevalidate() error {
+	return r.step.Step.Prevalidate() //nolint:wrapcheck // don't wrap error, wouldn't provide any more context than the error itself
+}
+
+func (r *RetryStep) Run() error {
+	return r.RunContext(context.Background())
+}
+
+// RunContext runs the attempts like Run, giving up on them once ctx is cancelled
+func (r *RetryStep) RunContext(ctx context.Context) error {
+	opts := DefaultOpts
+	if r.step.Opts != nil {
+		opts = *r.step.Opts
+	}
+	opts.Retry = &r.retry
+	step := &StepWrapper{Step: r.step.Step, Opts: &opts}
+
+	err := runWithRetry(ctx, step, time.Now())
+	if errors.Is(err, ErrStepTimeout) || errors.Is(err, ErrStepCancelled) || errors.Is(err, ErrScenarioSkipped) {
+		return err
+	}
+	if opts.ExpectError {
+		if err == nil {
+			return fmt.Errorf("expected error from step %s on one of %d attempts but got nil: %w", stepTypeName(step), r.retry.Attempts, ErrNilError)
+		}
+		return nil
+	}
+	return err
+}
+
+func (r *RetryStep) Stop() error {
+	return nil
+}
+
+// SetArtifactDir gives the step the retry's directory when it writes artifacts
+func (r *RetryStep) SetArtifactDir(dir string) {
+	if writer, ok := r.step.Step.(ArtifactWriter); ok {
+		writer.SetArtifactDir(dir)
+	}
+}
+
+// MarshalJSON records the parameters of the step
+func (r *RetryStep) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.step.Step) //nolint:wrapcheck // don't wrap error, wouldn't provide any more context than the error itself
+}
+
+// UnmarshalJSON replays recorded parameters onto the step
+func (r *RetryStep) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, r.step.Step) //nolint:wrapcheck // don't wrap error, wouldn't provide any more context than the error itself
+}
@@ -0,0 +1,77 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryStepSucceedsOnLastAttempt(t *testing.T) {
+	runs := 0
+	job := NewJob("Validate a step wrapped in a retry passes once an attempt succeeds")
+	job.AddStep(NewRetryStep(&StepWrapper{Step: &FlakyStep{Failures: 2, runs: &runs}}, Retry{Attempts: 3, Delay: time.Millisecond, ExpBackoff: true}), nil)
+	require.NoError(t, job.Run())
+	require.Equal(t, 3, runs)
+}
+
+func TestRetryStepExhausted(t *testing.T) {
+	runs := 0
+	job := NewJob("Validate a step wrapped in a retry fails with the error of each attempt")
+	job.AddStep(NewRetryStep(&StepWrapper{Step: &FlakyStep{Failures: 5, runs: &runs}}, Retry{Attempts: 3, Delay: time.Millisecond}), nil)
+
+	err := job.Run()
+	require.ErrorIs(t, err, ErrRetriesExhausted)
+	require.ErrorIs(t, err, ErrNonNilError)
+	for _, attempt := range []string{"attempt 1", "attempt 2", "attempt 3"} {
+		require.Contains(t, err.Error(), attempt)
+	}
+	require.Equal(t, 3, runs)
+}
+
+func TestRetryStepExpectingError(t *testing.T) {
+	runs := 0
+	job := NewJob("Validate a step expected to error wrapped in a retry passes once it errors")
+	job.AddStep(NewRetryStep(&StepWrapper{Step: &EventuallyFailingStep{Successes: 2, runs: &runs}, Opts: &StepOptions{ExpectError: true}}, Retry{Attempts: 3, Delay: time.Millisecond}), nil)
+	require.NoError(t, job.Run())
+	require.Equal(t, 3, runs)
+}
+
+func TestRetryStepExpectingErrorNeverErrors(t *testing.T) {
+	runs := 0
+	job := NewJob("Validate a step expected to error wrapped in a retry fails when no attempt errors")
+	job.AddStep(NewRetryStep(&StepWrapper{Step: &EventuallyFailingStep{Successes: 5, runs: &runs}, Opts: &StepOptions{ExpectError: true}}, Retry{Attempts: 3, Delay: time.Millisecond}), nil)
+	require.ErrorIs(t, job.Run(), ErrNilError)
+	require.Equal(t, 3, runs)
+}
+
+func TestRetryStepRejectsInvalidSteps(t *testing.T) {
+	runs := 0
+	for name, tc := range map[string]struct {
+		step  *StepWrapper
+		retry Retry
+		err   error
+	}{
+		"no attempts": {
+			step: &StepWrapper{Step: &FlakyStep{runs: &runs}},
+			err:  ErrInvalidRetry,
+		},
+		"background step": {
+			step:  &StepWrapper{Step: &TestBackground{CounterName: "Counter"}, Opts: &StepOptions{RunInBackgroundWithID: "counter"}},
+			retry: Retry{Attempts: 2},
+			err:   ErrRetryBackground,
+		},
+		"step retrying on its own": {
+			step:  &StepWrapper{Step: &FlakyStep{runs: &runs}, Opts: &StepOptions{Retry: &Retry{Attempts: 2}}},
+			retry: Retry{Attempts: 2},
+			err:   ErrNestedRetry,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			job := NewJob("Validate a retry of " + name + " is rejected")
+			job.AddStep(NewRetryStep(tc.step, tc.retry), nil)
+			require.ErrorIs(t, job.Run(), tc.err)
+		})
+	}
+	require.Zero(t, runs)
+}
@@ -1,6 +1,8 @@
 package types
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"time"
 )
@@ -10,9 +12,18 @@ type Sleep struct {
 }
 
 func (c *Sleep) Run() error {
+	return c.RunContext(context.Background())
+}
+
+// RunContext sleeps for Duration, or until ctx is cancelled
+func (c *Sleep) RunContext(ctx context.Context) error {
 	log.Printf("sleeping for %s...\n", c.Duration.String())
-	time.Sleep(c.Duration)
-	return nil
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("sleep: %w: %w", ErrStepCancelled, context.Cause(ctx))
+	case <-time.After(c.Duration):
+		return nil
+	}
 }
 
 func (c *Sleep) Stop() error {
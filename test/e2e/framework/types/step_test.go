@@ -0,0 +1,204 @@
+package types
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepTimeout(t *testing.T) {
+	job := NewJob("Validate a step running past its timeout fails")
+	job.AddStep(&Sleep{Duration: time.Second}, &StepOptions{Timeout: 50 * time.Millisecond})
+
+	start := time.Now()
+	err := job.Run()
+	require.ErrorIs(t, err, ErrStepTimeout)
+	require.Contains(t, err.Error(), "step Sleep still running after")
+	require.Less(t, time.Since(start), time.Second)
+}
+
+func TestStepTimeoutWithExpectError(t *testing.T) {
+	job := NewJob("Validate a step running past its timeout fails even when it's expected to error")
+	job.AddStep(&Sleep{Duration: time.Second}, &StepOptions{Timeout: 50 * time.Millisecond, ExpectError: true})
+	require.ErrorIs(t, job.Run(), ErrStepTimeout)
+}
+
+func TestStepTimeoutBoundsBackgroundStart(t *testing.T) {
+	job := NewJob("Validate a background step keeps running past its timeout once started")
+	job.AddStep(&TestBackground{CounterName: "Timed Counter"}, &StepOptions{
+		RunInBackgroundWithID: "timed-counter",
+		Timeout:               50 * time.Millisecond,
+	})
+	job.AddStep(&Sleep{Duration: 100 * time.Millisecond}, &StepOptions{Timeout: time.Second})
+	job.AddStep(&Stop{BackgroundID: "timed-counter"}, nil)
+	require.NoError(t, job.Run())
+}
+
+type FlakyStep struct {
+	Failures int
+	runs     *int
+}
+
+func (f *FlakyStep) Run() error {
+	*f.runs++
+	if *f.runs <= f.Failures {
+		return ErrNonNilError
+	}
+	return nil
+}
+
+func (f *FlakyStep) Prevalidate() error {
+	return nil
+}
+
+func (f *FlakyStep) Stop() error {
+	return nil
+}
+
+func TestStepRetry(t *testing.T) {
+	runs := 0
+	job := NewJob("Validate a flaky step is retried until it succeeds")
+	job.AddStep(&FlakyStep{Failures: 2, runs: &runs}, &StepOptions{Retry: &Retry{Attempts: 3, Delay: time.Millisecond, ExpBackoff: true}})
+	require.NoError(t, job.Run())
+	require.Equal(t, 3, runs)
+}
+
+func TestStepRetriesExhausted(t *testing.T) {
+	runs := 0
+	job := NewJob("Validate a step failing on every attempt reports its attempts")
+	job.AddStep(&FlakyStep{Failures: 5, runs: &runs}, &StepOptions{Retry: &Retry{Attempts: 3, Delay: time.Millisecond}})
+
+	err := job.Run()
+	require.ErrorIs(t, err, ErrRetriesExhausted)
+	require.ErrorIs(t, err, ErrNonNilError)
+	require.Contains(t, err.Error(), "3 attempts")
+	// every attempt's error is reported, not only the last one
+	for _, attempt := range []string{"attempt 1: ", "attempt 2: ", "attempt 3: "} {
+		require.Contains(t, err.Error(), attempt+ErrNonNilError.Error())
+	}
+	require.Equal(t, 3, runs)
+}
+
+// EventuallyFailingStep succeeds Successes times, then fails
+type EventuallyFailingStep struct {
+	Successes int
+	runs      *int
+}
+
+func (e *EventuallyFailingStep) Run() error {
+	*e.runs++
+	if *e.runs <= e.Successes {
+		return nil
+	}
+	return ErrNonNilError
+}
+
+func (e *EventuallyFailingStep) Prevalidate() error {
+	return nil
+}
+
+func (e *EventuallyFailingStep) Stop() error {
+	return nil
+}
+
+func TestStepRetryExpectingError(t *testing.T) {
+	runs := 0
+	job := NewJob("Validate a step expected to error is retried until it does")
+	job.AddStep(&EventuallyFailingStep{Successes: 2, runs: &runs}, &StepOptions{ExpectError: true, Retry: &Retry{Attempts: 3, Delay: time.Millisecond}})
+	require.NoError(t, job.Run())
+	require.Equal(t, 3, runs)
+}
+
+func TestStepRetryExpectingErrorStopsOnError(t *testing.T) {
+	runs := 0
+	job := NewJob("Validate a step expected to error isn't retried once it errors")
+	job.AddStep(&FlakyStep{Failures: 5, runs: &runs}, &StepOptions{ExpectError: true, Retry: &Retry{Attempts: 3, Delay: time.Millisecond}})
+	require.NoError(t, job.Run())
+	require.Equal(t, 1, runs)
+}
+
+func TestStepRetryExpectingErrorNeverErrors(t *testing.T) {
+	runs := 0
+	job := NewJob("Validate a step expected to error fails when no attempt errors")
+	job.AddStep(&EventuallyFailingStep{Successes: 5, runs: &runs}, &StepOptions{ExpectError: true, Retry: &Retry{Attempts: 3, Delay: time.Millisecond}})
+	require.ErrorIs(t, job.Run(), ErrNilError)
+	require.Equal(t, 3, runs)
+}
+
+// HangingStep counts its runs and never returns before it's released
+type HangingStep struct {
+	release chan struct{}
+	runs    *atomic.Int32
+}
+
+func (h *HangingStep) Run() error {
+	h.runs.Add(1)
+	<-h.release
+	return nil
+}
+
+func (h *HangingStep) Prevalidate() error {
+	return nil
+}
+
+func (h *HangingStep) Stop() error {
+	return nil
+}
+
+func TestStepRetryStopsOnTimeout(t *testing.T) {
+	runs := &atomic.Int32{}
+	release := make(chan struct{})
+	defer close(release)
+
+	job := NewJob("Validate an attempt running past its timeout fails the step without being retried")
+	job.AddStep(&HangingStep{release: release, runs: runs}, &StepOptions{
+		Timeout: 50 * time.Millisecond,
+		Retry:   &Retry{Attempts: 3, Delay: time.Millisecond},
+	})
+	require.ErrorIs(t, job.Run(), ErrStepTimeout)
+	require.Equal(t, int32(1), runs.Load())
+}
+
+func TestStepRetryNeedsAttempts(t *testing.T) {
+	runs := 0
+	job := NewJob("Validate a retry without attempts is rejected")
+	job.AddStep(&FlakyStep{runs: &runs}, &StepOptions{Retry: &Retry{Delay: time.Millisecond}})
+	require.ErrorIs(t, job.Run(), ErrInvalidRetry)
+	require.Zero(t, runs)
+}
+
+var errInvalidParameter = fmt.Errorf("invalid parameter")
+
+// InvalidStep is a step failing prevalidation
+type InvalidStep struct {
+	calls *[]string
+}
+
+func (s *InvalidStep) Run() error {
+	*s.calls = append(*s.calls, "invalid")
+	return nil
+}
+
+func (s *InvalidStep) Stop() error {
+	return nil
+}
+
+func (s *InvalidStep) Prevalidate() error {
+	return errInvalidParameter
+}
+
+func TestStepPrevalidationFailsBeforeRunning(t *testing.T) {
+	var calls []string
+	job := NewJob("Validate a step failing prevalidation fails the job before any step runs")
+	job.AddStep(&RecordStep{Name: "first", calls: &calls}, &StepOptions{SkipSavingParametersToJob: true})
+	job.AddStep(&InvalidStep{calls: &calls}, nil)
+
+	err := job.Run()
+	require.ErrorIs(t, err, ErrInvalidStep)
+	require.ErrorIs(t, err, errInvalidParameter)
+	require.Contains(t, err.Error(), "step 1 \"InvalidStep\"")
+	require.Empty(t, calls)
+}
@@ -0,0 +1,50 @@
+package types
+
+import "log"
+
+// A Suite is a group of scenarios sharing setup and teardown steps, such as installing Retina or creating
+// a namespace, which run once around the whole group instead of once per scenario. Teardown steps run
+// even when a setup or scenario step fails, so the cluster is left clean for whatever runs next
+type Suite struct {
+	name      string
+	setup     []*StepWrapper
+	scenarios []*Scenario
+	teardown  []*StepWrapper
+}
+
+func NewSuite(name string, scenarios ...*Scenario) *Suite {
+	if name == "" {
+		log.Printf("suite name is empty")
+	}
+
+	return &Suite{
+		name:      name,
+		scenarios: scenarios,
+	}
+}
+
+// BeforeAll adds steps run once before the suite's first scenario
+func (s *Suite) BeforeAll(steps ...*StepWrapper) *Suite {
+	s.setup = append(s.setup, steps...)
+	return s
+}
+
+// AfterAll adds steps run once after the suite's last scenario, or as soon as one of its steps fails
+func (s *Suite) AfterAll(steps ...*StepWrapper) *Suite {
+	s.teardown = append(s.teardown, steps...)
+	return s
+}
+
+func (s *Suite) AddScenario(scenario *Scenario) *Suite {
+	s.scenarios = append(s.scenarios, scenario)
+	return s
+}
+
+func (s *Suite) isTeardown(stepw *StepWrapper) bool {
+	for _, step := range s.teardown {
+		if step == stepw {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package types
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var errFailingStep = fmt.Errorf("failing step")
+
+func TestSuiteHooksRunOnce(t *testing.T) {
+	var calls []string
+
+	job := NewJob("Validate suite setup and teardown run once around its scenarios")
+	job.AddSuite(NewSuite("Dummy Suite",
+		NewScenario("Dummy Scenario 1", &StepWrapper{Step: &RecordStep{Name: "scenario 1", calls: &calls}}),
+		NewScenario("Dummy Scenario 2", &StepWrapper{Step: &RecordStep{Name: "scenario 2", calls: &calls}}),
+	).BeforeAll(
+		&StepWrapper{Step: &RecordStep{Name: "setup", calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+	).AfterAll(
+		&StepWrapper{Step: &RecordStep{Name: "teardown", calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+	))
+
+	require.NoError(t, job.Run())
+	require.Equal(t, []string{"setup", "scenario 1", "scenario 2", "teardown"}, calls)
+}
+
+func TestSuiteTeardownRunsOnFailure(t *testing.T) {
+	var calls []string
+
+	job := NewJob("Validate suite teardown runs when a scenario fails")
+	job.AddSuite(NewSuite("Dummy Suite",
+		NewScenario("Failing Scenario", &StepWrapper{Step: &RecordStep{Name: "failing", Fail: true, calls: &calls}}),
+		NewScenario("Skipped Scenario", &StepWrapper{Step: &RecordStep{Name: "skipped", calls: &calls}}),
+	).BeforeAll(
+		&StepWrapper{Step: &RecordStep{Name: "setup", calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+	).AfterAll(
+		&StepWrapper{Step: &RecordStep{Name: "teardown 1", Fail: true, calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+		&StepWrapper{Step: &RecordStep{Name: "teardown 2", calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+	))
+	job.AddSuite(NewSuite("Unstarted Suite").AfterAll(
+		&StepWrapper{Step: &RecordStep{Name: "unstarted teardown", calls: &calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+	))
+
+	err := job.Run()
+	require.ErrorIs(t, err, errFailingStep)
+	require.Equal(t, []string{"setup", "failing", "teardown 1", "teardown 2"}, calls)
+}
+
+type RecordStep struct {
+	Name  string
+	Fail  bool
+	calls *[]string
+}
+
+func (r *RecordStep) Run() error {
+	*r.calls = append(*r.calls, r.Name)
+	if r.Fail {
+		return errFailingStep
+	}
+	return nil
+}
+
+func (r *RecordStep) Stop() error {
+	return nil
+}
+
+func (r *RecordStep) Prevalidate() error {
+	return nil
+}
@@ -0,0 +1,73 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTaggedJob(calls *[]string) *Job {
+	job := NewJob("Validate scenarios are selected by tag")
+	job.AddStep(&RecordStep{Name: "setup", calls: calls}, &StepOptions{SkipSavingParametersToJob: true})
+	job.AddScenario(NewScenario("DNS Scenario",
+		&StepWrapper{Step: &RecordStep{Name: "dns", calls: calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+	).WithTags("dns"))
+	job.AddScenario(NewScenario("Reinstall Scenario",
+		&StepWrapper{Step: &RecordStep{Name: "reinstall", calls: calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+	).WithTags("dns", "reinstall"))
+	job.AddScenario(NewScenario("Untagged Scenario",
+		&StepWrapper{Step: &RecordStep{Name: "untagged", calls: calls}, Opts: &StepOptions{SkipSavingParametersToJob: true}},
+	))
+	return job
+}
+
+func TestFilterTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		expected []string
+	}{
+		{
+			name:     "no filter",
+			expected: []string{"setup", "dns", "reinstall", "untagged"},
+		},
+		{
+			name:     "include",
+			include:  []string{"dns"},
+			expected: []string{"setup", "dns", "reinstall"},
+		},
+		{
+			name:     "exclude",
+			exclude:  []string{"reinstall"},
+			expected: []string{"setup", "dns", "untagged"},
+		},
+		{
+			name:     "exclude wins over include",
+			include:  []string{"dns"},
+			exclude:  []string{"reinstall"},
+			expected: []string{"setup", "dns"},
+		},
+		{
+			name:     "no match",
+			include:  []string{"latency"},
+			expected: []string{"setup"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			job := newTaggedJob(&calls)
+			job.FilterTags(tt.include, tt.exclude)
+
+			require.NoError(t, job.Run())
+			require.Equal(t, tt.expected, calls)
+		})
+	}
+}
+
+func TestSplitTags(t *testing.T) {
+	require.Equal(t, []string{"dns", "reinstall"}, splitTags(" dns,,reinstall "))
+	require.Empty(t, splitTags(""))
+}
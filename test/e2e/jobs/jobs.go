@@ -1,17 +1,64 @@
 package retina
 
 import (
+	"flag"
+	"path/filepath"
+
 	"github.com/microsoft/retina/test/e2e/framework/azure"
 	"github.com/microsoft/retina/test/e2e/framework/generic"
+	"github.com/microsoft/retina/test/e2e/framework/kind"
 	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
 	"github.com/microsoft/retina/test/e2e/framework/types"
+	"github.com/microsoft/retina/test/e2e/scenarios/accuracy"
+	"github.com/microsoft/retina/test/e2e/scenarios/apiserver"
+	"github.com/microsoft/retina/test/e2e/scenarios/asymmetric"
+	"github.com/microsoft/retina/test/e2e/scenarios/batch"
+	"github.com/microsoft/retina/test/e2e/scenarios/bpffs"
+	"github.com/microsoft/retina/test/e2e/scenarios/capture"
+	"github.com/microsoft/retina/test/e2e/scenarios/cardinality"
+	"github.com/microsoft/retina/test/e2e/scenarios/cgroupdriver"
+	"github.com/microsoft/retina/test/e2e/scenarios/ciliumidentity"
+	"github.com/microsoft/retina/test/e2e/scenarios/connstorm"
+	"github.com/microsoft/retina/test/e2e/scenarios/containerrestart"
+	"github.com/microsoft/retina/test/e2e/scenarios/crossnamespace"
+	"github.com/microsoft/retina/test/e2e/scenarios/disruption"
 	"github.com/microsoft/retina/test/e2e/scenarios/dns"
 	"github.com/microsoft/retina/test/e2e/scenarios/drop"
+	"github.com/microsoft/retina/test/e2e/scenarios/dualstack"
+	"github.com/microsoft/retina/test/e2e/scenarios/encryption"
+	"github.com/microsoft/retina/test/e2e/scenarios/initcontainer"
+	"github.com/microsoft/retina/test/e2e/scenarios/kernel"
 	"github.com/microsoft/retina/test/e2e/scenarios/latency"
+	"github.com/microsoft/retina/test/e2e/scenarios/longnames"
+	"github.com/microsoft/retina/test/e2e/scenarios/lostevents"
+	"github.com/microsoft/retina/test/e2e/scenarios/manyinterfaces"
+	"github.com/microsoft/retina/test/e2e/scenarios/metricsconfig"
+	"github.com/microsoft/retina/test/e2e/scenarios/metricsendpoint"
+	"github.com/microsoft/retina/test/e2e/scenarios/missingenv"
+	"github.com/microsoft/retina/test/e2e/scenarios/multiservice"
+	"github.com/microsoft/retina/test/e2e/scenarios/nodeport"
+	"github.com/microsoft/retina/test/e2e/scenarios/offload"
+	"github.com/microsoft/retina/test/e2e/scenarios/pluginstatus"
+	"github.com/microsoft/retina/test/e2e/scenarios/pmtud"
+	"github.com/microsoft/retina/test/e2e/scenarios/policyflip"
+	"github.com/microsoft/retina/test/e2e/scenarios/pooling"
+	"github.com/microsoft/retina/test/e2e/scenarios/portconflict"
+	"github.com/microsoft/retina/test/e2e/scenarios/remotewrite"
+	"github.com/microsoft/retina/test/e2e/scenarios/scaledown"
+	"github.com/microsoft/retina/test/e2e/scenarios/scrapeboundary"
+	"github.com/microsoft/retina/test/e2e/scenarios/sidecar"
+	"github.com/microsoft/retina/test/e2e/scenarios/spoofing"
 	tcp "github.com/microsoft/retina/test/e2e/scenarios/tcp"
+	"github.com/microsoft/retina/test/e2e/scenarios/tcpoptions"
+	"github.com/microsoft/retina/test/e2e/scenarios/transparentproxy"
+	"github.com/microsoft/retina/test/e2e/scenarios/udp"
 	"github.com/microsoft/retina/test/e2e/scenarios/windows"
 )
 
+// scrapeByExec has the basic DNS scenarios read the agent's metrics through curl in the agent pod, for clusters
+// whose RBAC doesn't permit port forwards
+var scrapeByExec = flag.Bool("scrape-by-exec", false, "scrape the agent's metrics in the basic DNS scenarios by exec instead of a port forward")
+
 func CreateTestInfra(subID, clusterName, location, kubeConfigFilePath string, createInfra bool) *types.Job {
 	job := types.NewJob("Create e2e test infrastructure")
 
@@ -74,6 +121,63 @@ func DeleteTestInfra(subID, clusterName, location string) *types.Job {
 	return job
 }
 
+func CreateKindTestInfra(clusterName, kubeConfigFilePath, kindConfigFilePath string) *types.Job {
+	job := types.NewJob("Create kind e2e test infrastructure")
+
+	job.AddStep(&kind.CreateKindCluster{
+		ClusterName:        clusterName,
+		KubeConfigFilePath: kubeConfigFilePath,
+		KindConfigFilePath: kindConfigFilePath,
+	}, nil)
+
+	job.AddStep(&generic.LoadFlags{
+		TagEnv:            generic.DefaultTagEnv,
+		ImageNamespaceEnv: generic.DefaultImageNamespace,
+		ImageRegistryEnv:  generic.DefaultImageRegistry,
+	}, nil)
+
+	return job
+}
+
+func DeleteKindTestInfra(clusterName string) *types.Job {
+	job := types.NewJob("Delete kind e2e test infrastructure")
+
+	job.AddStep(&kind.DeleteKindCluster{
+		ClusterName: clusterName,
+	}, nil)
+
+	return job
+}
+
+// InstallAndTestRetinaOnKind runs the subset of basic metrics scenarios that don't depend on AKS,
+// such as windows nodes or network policy enforcement
+func InstallAndTestRetinaOnKind(kubeConfigFilePath, chartPath string) *types.Job {
+	job := types.NewJob("Install and test Retina with basic metrics on kind")
+
+	job.AddStep(&kubernetes.InstallHelmChart{
+		Namespace:          "kube-system",
+		ReleaseName:        "retina",
+		KubeConfigFilePath: kubeConfigFilePath,
+		ChartPath:          chartPath,
+		TagEnv:             generic.DefaultTagEnv,
+	}, nil)
+
+	job.AddScenario(tcp.ValidateTCPMetrics())
+
+	job.AddScenario(kernel.ValidateKernelVersionDetection())
+
+	job.AddScenario(metricsendpoint.ValidateMetricsEndpointHead())
+
+	job.AddScenario(metricsendpoint.ValidateMetricsEndpointContentNegotiation())
+
+	job.AddStep(&kubernetes.EnsureStableCluster{
+		PodNamespace:  "kube-system",
+		LabelSelector: "k8s-app=retina",
+	}, nil)
+
+	return job
+}
+
 func InstallAndTestRetinaBasicMetrics(kubeConfigFilePath, chartPath string) *types.Job {
 	job := types.NewJob("Install and test Retina with basic metrics")
 
@@ -87,10 +191,28 @@ func InstallAndTestRetinaBasicMetrics(kubeConfigFilePath, chartPath string) *typ
 
 	job.AddScenario(drop.ValidateDropMetric())
 
+	job.AddScenario(drop.ValidateDroppedDNSMetric())
+
+	job.AddScenario(spoofing.ValidateSpoofedSourceDropMetric())
+
 	job.AddScenario(tcp.ValidateTCPMetrics())
 
+	job.AddScenario(asymmetric.ValidateAsymmetricRoutingMetrics())
+
 	job.AddScenario(windows.ValidateWindowsBasicMetric())
 
+	job.AddScenario(kernel.ValidateKernelVersionDetection())
+
+	job.AddScenario(kernel.ValidateUnsupportedKernelError())
+
+	job.AddScenario(metricsendpoint.ValidateMetricsEndpointHead())
+
+	job.AddScenario(metricsendpoint.ValidateMetricsEndpointContentNegotiation())
+
+	job.AddScenario(metricsendpoint.ValidateMetricsScrapePerformance())
+
+	job.AddScenario(remotewrite.ValidateRemoteWriteMetrics())
+
 	dnsScenarios := []struct {
 		name string
 		req  *dns.RequestValidationParams
@@ -121,6 +243,7 @@ func InstallAndTestRetinaBasicMetrics(kubeConfigFilePath, chartPath string) *typ
 				QueryType:   "A",
 				Command:     "nslookup some.non.existent.domain",
 				ExpectError: true,
+				ReturnCode:  "NXDOMAIN",
 			},
 			resp: &dns.ResponseValidationParams{
 				NumResponse: "0",
@@ -133,9 +256,20 @@ func InstallAndTestRetinaBasicMetrics(kubeConfigFilePath, chartPath string) *typ
 	}
 
 	for _, scenario := range dnsScenarios {
-		job.AddScenario(dns.ValidateBasicDNSMetrics(scenario.name, scenario.req, scenario.resp))
+		scenario.req.ScrapeByExec = *scrapeByExec
+		job.AddScenario(dns.ValidateBasicDNSMetrics(scenario.name, scenario.req, scenario.resp).WithTags("dns"))
 	}
 
+	job.AddScenario(dns.ValidateLargeRRSetDNSMetrics().WithTags("dns"))
+
+	job.AddScenario(dns.ValidateTCPFallbackDNSMetrics().WithTags("dns"))
+
+	job.AddScenario(dns.ValidateParallelDualStackDNSMetrics().WithTags("dns"))
+
+	job.AddScenario(dns.ValidateDNSBurstCounterStability().WithTags("dns"))
+
+	job.AddScenario(dns.ValidateSearchDomainExpansionDNSMetrics().WithTags("dns"))
+
 	job.AddStep(&kubernetes.EnsureStableCluster{
 		PodNamespace:  "kube-system",
 		LabelSelector: "k8s-app=retina",
@@ -186,6 +320,7 @@ func UpgradeAndTestRetinaAdvancedMetrics(kubeConfigFilePath, chartPath, valuesFi
 				QueryType:   "A",
 				Command:     "nslookup some.non.existent.domain.",
 				ExpectError: true,
+				ReturnCode:  "NXDOMAIN",
 			},
 			resp: &dns.ResponseValidationParams{
 				NumResponse: "0",
@@ -198,11 +333,99 @@ func UpgradeAndTestRetinaAdvancedMetrics(kubeConfigFilePath, chartPath, valuesFi
 	}
 
 	for _, scenario := range dnsScenarios {
-		job.AddScenario(dns.ValidateAdvancedDNSMetrics(scenario.name, scenario.req, scenario.resp, kubeConfigFilePath))
+		job.AddScenario(dns.ValidateAdvancedDNSMetrics(scenario.name, scenario.req, scenario.resp, kubeConfigFilePath).WithTags("dns"))
 	}
 
+	job.AddScenario(batch.ValidateBatchWorkloadDNSMetrics().WithTags("dns"))
+
+	job.AddScenario(dns.ValidateCustomDNSPolicyMetrics(kubeConfigFilePath).WithTags("dns"))
+
+	job.AddScenario(dns.ValidateServFailDNSMetrics(kubeConfigFilePath).WithTags("dns"))
+
+	job.AddScenario(dns.ValidateRepeatedQueryDNSCount().WithTags("dns"))
+
+	job.AddScenario(dns.ValidateDNSMetricsAfterAgentRestart().WithTags("dns"))
+
+	job.AddScenario(dns.ValidateScaledWorkloadDNSMetrics().WithTags("dns"))
+
+	job.AddScenario(dns.ValidatePerNodeDNSMetrics().WithTags("dns"))
+
+	job.AddScenario(longnames.ValidateLongNameMetrics())
+
+	job.AddScenario(crossnamespace.ValidateCrossNamespaceFlowMetrics())
+
+	job.AddScenario(cgroupdriver.ValidateCgroupDriverAttribution().WithTags("cgroup-driver"))
+
+	job.AddScenario(policyflip.ValidatePolicyFlipMetrics())
+
+	job.AddScenario(multiservice.ValidateMultiServiceMetrics())
+
+	job.AddScenario(nodeport.ValidateNodePortMetrics())
+
+	job.AddScenario(containerrestart.ValidateContainerRestartMetrics())
+
+	job.AddScenario(scrapeboundary.ValidateScrapeBoundaryMetrics())
+
+	job.AddScenario(offload.ValidateOffloadedFlowMetrics())
+
+	job.AddScenario(cardinality.ValidateHighCardinalityMetrics().WithTags("scale"))
+
+	job.AddScenario(manyinterfaces.ValidateManyInterfacesMetrics().WithTags("scale"))
+
+	job.AddScenario(pooling.ValidatePooledBackendFlowMetrics())
+
+	job.AddScenario(disruption.ValidateDisruptionBudgetFlowMetrics())
+
+	job.AddScenario(apiserver.ValidateAPIServerFlowMetrics())
+
+	job.AddScenario(encryption.ValidateWireGuardFlowMetrics())
+
+	job.AddScenario(udp.ValidateOneWayUDPMetrics())
+
+	job.AddScenario(scaledown.ValidateScaleDownFlowMetrics())
+
+	job.AddScenario(transparentproxy.ValidateTransparentProxyFlowMetrics())
+
+	job.AddScenario(initcontainer.ValidateInitContainerTrafficMetrics())
+
+	job.AddScenario(sidecar.ValidateIntraPodTrafficMetrics())
+
 	job.AddScenario(latency.ValidateLatencyMetric())
 
+	job.AddScenario(connstorm.ValidateConnectionStormMetrics().WithTags("scale"))
+
+	job.AddScenario(accuracy.ValidateExactPacketCountMetrics())
+
+	job.AddScenario(ciliumidentity.ValidateIdentityChangeFlowMetrics())
+
+	job.AddScenario(capture.ValidateConcurrentCaptures().WithTags("capture"))
+
+	job.AddScenario(capture.ValidateCaptureAcrossAgentRestart().WithTags("capture"))
+
+	job.AddScenario(dualstack.ValidateDualStackServiceMetrics())
+
+	job.AddScenario(pmtud.ValidatePMTUDMetrics())
+
+	job.AddScenario(tcpoptions.ValidateTCPOptionsFlowMetrics())
+
+	job.AddScenario(bpffs.ValidateCustomBPFFSMount(kubeConfigFilePath, chartPath, valuesFilePath).WithTags("reinstall"))
+
+	job.AddScenario(pluginstatus.ValidatePluginStatusMetrics(kubeConfigFilePath, chartPath, valuesFilePath).WithTags("reinstall"))
+
+	job.AddScenario(portconflict.ValidateMetricsPortInUse(kubeConfigFilePath, chartPath, valuesFilePath).WithTags("reinstall"))
+
+	job.AddScenario(missingenv.ValidateMissingNodeNameEnv().WithTags("reinstall"))
+
+	job.AddScenario(accuracy.ValidatePacketCountUnderCPULimit(kubeConfigFilePath, chartPath, valuesFilePath).WithTags("reinstall"))
+
+	metricsConfigFilePath := filepath.Join(filepath.Dir(valuesFilePath), "crd", "metrics_config_crd.yaml")
+	job.AddScenario(metricsconfig.ValidateMetricsConfigurationDeletion(kubeConfigFilePath, chartPath, valuesFilePath, metricsConfigFilePath).WithTags("reinstall"))
+
+	scopedMetricsConfigFilePath := filepath.Join(filepath.Dir(valuesFilePath), "crd", "metrics_config_namespace_crd.yaml")
+	job.AddScenario(metricsconfig.ValidateNamespaceScopedMetricsConfiguration(kubeConfigFilePath, chartPath, valuesFilePath, scopedMetricsConfigFilePath).WithTags("reinstall"))
+
+	job.AddScenario(lostevents.ValidateLostEventsUnderOverload(kubeConfigFilePath, chartPath, valuesFilePath).WithTags("reinstall"))
+
 	job.AddStep(&kubernetes.EnsureStableCluster{
 		PodNamespace:  "kube-system",
 		LabelSelector: "k8s-app=retina",
@@ -21,6 +21,7 @@ var (
 	locations   = []string{"eastus2", "centralus", "southcentralus", "uksouth", "centralindia", "westus2"}
 	createInfra = flag.Bool("create-infra", true, "create a Resource group, vNET and AKS cluster for testing")
 	deleteInfra = flag.Bool("delete-infra", true, "delete a Resource group, vNET and AKS cluster for testing")
+	keepKind    = flag.Bool("keep-kind-cluster", false, "keep the kind cluster created for testing, even if the test fails or panics")
 )
 
 // TestE2ERetina tests all e2e scenarios for retina
@@ -80,3 +81,46 @@ func TestE2ERetina(t *testing.T) {
 	advanceMetricsE2E := types.NewRunner(t, jobs.UpgradeAndTestRetinaAdvancedMetrics(kubeConfigFilePath, chartPath, profilePath))
 	advanceMetricsE2E.Run()
 }
+
+// TestE2ERetinaKind tests the scenarios that can run on a local kind cluster
+func TestE2ERetinaKind(t *testing.T) {
+	flag.Parse()
+
+	clusterName := os.Getenv("CLUSTER_NAME")
+	if clusterName == "" {
+		clusterName = "retina" + common.NetObsRGtag + strconv.FormatInt(time.Now().Unix(), 10)
+		t.Logf("CLUSTER_NAME is not set, generating a kind cluster name: %s", clusterName)
+	}
+
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+
+	// Get to root of the repo by going up two directories
+	rootDir := filepath.Dir(filepath.Dir(cwd))
+
+	chartPath := filepath.Join(rootDir, "deploy", "legacy", "manifests", "controller", "helm", "retina")
+	kindConfigFilePath := filepath.Join(rootDir, "test", "kind", "kind.yaml")
+	kubeConfigFilePath := filepath.Join(rootDir, "test", "e2e", "kind.kubeconfig")
+
+	// register the cleanup before creating the cluster, so a partially created cluster
+	// is also torn down, and recover so that a panicking scenario doesn't skip it
+	defer func() {
+		r := recover()
+		if r != nil {
+			t.Errorf("Recovered in TestE2ERetinaKind, %v", r)
+		}
+		if *keepKind {
+			t.Logf("keeping kind cluster %s, kubeconfig at %s", clusterName, kubeConfigFilePath)
+			return
+		}
+		if deleteErr := jobs.DeleteKindTestInfra(clusterName).Run(); deleteErr != nil {
+			t.Errorf("failed to delete kind cluster %s: %v", clusterName, deleteErr)
+		}
+	}()
+
+	createTestInfra := types.NewRunner(t, jobs.CreateKindTestInfra(clusterName, kubeConfigFilePath, kindConfigFilePath))
+	createTestInfra.Run()
+
+	basicMetricsE2E := types.NewRunner(t, jobs.InstallAndTestRetinaOnKind(kubeConfigFilePath, chartPath))
+	basicMetricsE2E.Run()
+}
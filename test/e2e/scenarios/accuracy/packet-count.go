@@ -0,0 +1,160 @@
+package accuracy
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/microsoft/retina/test/retry"
+)
+
+const (
+	advForwardCountMetricName = "networkobservability_adv_forward_count"
+	lostEventsMetricName      = "controlplane_networkobservability_lost_events_counter"
+
+	metricRetryAttempts = 12
+	metricRetryDelay    = 5 * time.Second
+)
+
+var (
+	ErrBaselineNotCaptured   = fmt.Errorf("packet count baseline was not captured")
+	ErrInaccuratePacketCount = fmt.Errorf("packet count outside tolerance")
+	ErrUnboundedEventLoss    = fmt.Errorf("agent lost more events than allowed")
+)
+
+// packetCountBaseline carries the counter values read before the traffic to the step validating them
+type packetCountBaseline struct {
+	value      float64
+	lostEvents float64
+	captured   bool
+}
+
+// sumPacketCount adds up the pod's forward count series in direction, one per local context label set
+func sumPacketCount(portForwardedRetinaPort, podName, direction string) (float64, error) {
+	labels := map[string]string{
+		"podname":   podName,
+		"direction": direction,
+	}
+	return sumSeries(portForwardedRetinaPort, advForwardCountMetricName, labels)
+}
+
+// sumLostEvents adds up the events the agent reported losing, across its plugins and the reasons
+func sumLostEvents(portForwardedRetinaPort string) (float64, error) {
+	return sumSeries(portForwardedRetinaPort, lostEventsMetricName, map[string]string{})
+}
+
+func sumSeries(portForwardedRetinaPort, metricName string, labels map[string]string) (float64, error) {
+	promAddress := fmt.Sprintf("http://localhost:%s/metrics", portForwardedRetinaPort)
+	series, err := prom.GetMetricsMatchingLabels(promAddress, metricName, labels)
+	if err != nil {
+		return 0, fmt.Errorf("failed to scrape %s: %w", metricName, err)
+	}
+
+	total := 0.0
+	for _, metric := range series {
+		total += metric.GetGauge().GetValue() + metric.GetCounter().GetValue()
+	}
+	return total, nil
+}
+
+// CapturePacketCount records the pod's forward count in Direction before the traffic is sent
+type CapturePacketCount struct {
+	PortForwardedRetinaPort string
+	PodName                 string
+	Direction               string
+	baseline                *packetCountBaseline
+}
+
+func (c *CapturePacketCount) Run() error {
+	value, err := sumPacketCount(c.PortForwardedRetinaPort, c.PodName, c.Direction)
+	if err != nil {
+		return err
+	}
+
+	lostEvents, err := sumLostEvents(c.PortForwardedRetinaPort)
+	if err != nil {
+		return err
+	}
+
+	c.baseline.value = value
+	c.baseline.lostEvents = lostEvents
+	c.baseline.captured = true
+	log.Printf("%s %s count for pod %s before traffic is %.0f, %.0f events lost so far\n",
+		advForwardCountMetricName, c.Direction, c.PodName, value, lostEvents)
+	return nil
+}
+
+func (c *CapturePacketCount) Prevalidate() error {
+	return nil
+}
+
+func (c *CapturePacketCount) Stop() error {
+	return nil
+}
+
+// ValidateExactPacketCount waits for the pod's forward count in Direction to grow by ExpectedPackets since
+// the baseline, give or take ToleratedPackets. With MaxReportedLoss set, the count may also fall short by as
+// many events as the agent reported losing since the baseline, as long as that is at most MaxReportedLoss
+type ValidateExactPacketCount struct {
+	PortForwardedRetinaPort string
+	PodName                 string
+	Direction               string
+	ExpectedPackets         int
+	ToleratedPackets        int
+	MaxReportedLoss         int
+	baseline                *packetCountBaseline
+}
+
+func (v *ValidateExactPacketCount) Run() error {
+	if !v.baseline.captured {
+		return ErrBaselineNotCaptured
+	}
+
+	checkFn := func() error {
+		value, err := sumPacketCount(v.PortForwardedRetinaPort, v.PodName, v.Direction)
+		if err != nil {
+			return err
+		}
+
+		minimum := v.ExpectedPackets - v.ToleratedPackets
+		if v.MaxReportedLoss > 0 {
+			lostEvents, lostErr := sumLostEvents(v.PortForwardedRetinaPort)
+			if lostErr != nil {
+				return lostErr
+			}
+
+			lost := int(lostEvents - v.baseline.lostEvents)
+			log.Printf("agent reported %d lost events since the baseline, at most %d allowed\n", lost, v.MaxReportedLoss)
+			if lost > v.MaxReportedLoss {
+				return fmt.Errorf("lost %d events, %d allowed: %w", lost, v.MaxReportedLoss, ErrUnboundedEventLoss)
+			}
+			minimum -= lost
+		}
+
+		delta := int(value - v.baseline.value)
+		log.Printf("%s %s count for pod %s grew by %d, expected %d±%d\n",
+			advForwardCountMetricName, v.Direction, v.PodName, delta, v.ExpectedPackets, v.ToleratedPackets)
+		maximum := v.ExpectedPackets + v.ToleratedPackets
+		if delta < minimum || delta > maximum {
+			return fmt.Errorf("counted %d packets, expected %d to %d: %w", delta, minimum, maximum, ErrInaccuratePacketCount)
+		}
+		return nil
+	}
+
+	retrier := retry.Retrier{Attempts: metricRetryAttempts, Delay: metricRetryDelay}
+	err := retrier.Do(context.Background(), checkFn)
+	if err != nil {
+		return fmt.Errorf("failed to verify prometheus metrics %s: %w", advForwardCountMetricName, err)
+	}
+	return nil
+}
+
+func (v *ValidateExactPacketCount) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateExactPacketCount) Stop() error {
+	return nil
+}
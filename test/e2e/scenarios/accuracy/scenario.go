@@ -0,0 +1,196 @@
+package accuracy
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	"github.com/microsoft/retina/test/e2e/framework/generic"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+)
+
+const (
+	workloadNamespace = "retina-accuracy"
+
+	packetCount      = 1000
+	packetsPerSecond = 100
+
+	// CPU limit the agent is throttled to, well below the chart's default
+	ThrottledAgentCPU = "50m"
+
+	throttledPacketCount      = 6000
+	throttledPacketsPerSecond = 300
+	// a throttled agent may lose up to 10% of the events, as long as it reports them lost
+	maxReportedLoss = throttledPacketCount / 10
+
+	// lets the flows of the pods' setup reach the metrics before the baseline is read
+	settleDelay = 15 * time.Second
+)
+
+// ValidateExactPacketCountMetrics sends a known number of echo requests at a fixed rate between two pods
+// and validates the sender's egress forward count grows by exactly that number, within a 1% tolerance.
+func ValidateExactPacketCountMetrics() *types.Scenario {
+	name := "Exact Packet Count Metrics"
+	return types.NewScenario(name, packetCountSteps("accuracy", packetCount, packetsPerSecond, 0)...)
+}
+
+// ValidatePacketCountUnderCPULimit upgrades Retina with the agent limited to ThrottledAgentCPU, sends a
+// sustained stream of echo requests and validates the sender's egress forward count eventually matches it,
+// short of at most the events the agent reported losing, which must stay within 10% of the stream.
+// Retina is restored to the values file alone afterwards, even if the scenario fails.
+func ValidatePacketCountUnderCPULimit(kubeConfigFilePath, chartPath, valuesFilePath string) *types.Scenario {
+	name := "Packet Count Under CPU Limit"
+
+	cpuLimit := []string{
+		fmt.Sprintf("resources.limits.cpu=%s", ThrottledAgentCPU),
+		fmt.Sprintf("resources.requests.cpu=%s", ThrottledAgentCPU),
+	}
+
+	return types.NewScenario(name, packetCountSteps("accuracy-throttled", throttledPacketCount, throttledPacketsPerSecond, maxReportedLoss)...).
+		WithSetup(upgradeRetina(kubeConfigFilePath, chartPath, valuesFilePath, cpuLimit)...).
+		WithCleanup(upgradeRetina(kubeConfigFilePath, chartPath, valuesFilePath, nil)...)
+}
+
+// packetCountSteps sends count echo requests at rate between two pods and validates the count, allowing
+// for up to maxReportedLoss events the agent reports losing. idPrefix keeps the IDs of its background steps
+// apart from those of another scenario built with it
+func packetCountSteps(idPrefix string, count, rate, maxReportedLoss int) []*types.StepWrapper {
+	senderName := "agnhost-accuracy-sender"
+	receiverName := "agnhost-accuracy-receiver"
+	baseline := &packetCountBaseline{}
+
+	return []*types.StepWrapper{
+		{
+			Step: &kubernetes.CreateNamespace{
+				NamespaceName: workloadNamespace,
+				Observe:       true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      senderName,
+				AgnhostNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      receiverName,
+				AgnhostNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.PortForward{
+				Namespace:                          "kube-system",
+				LabelSelector:                      "k8s-app=retina",
+				LocalPort:                          strconv.Itoa(common.RetinaPort),
+				RemotePort:                         strconv.Itoa(common.RetinaPort),
+				Endpoint:                           "metrics",
+				OptionalLabelAffinity:              "app=" + senderName,
+				OptionalLabelAffinityAllNamespaces: true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     idPrefix + "-port-forward",
+			},
+		},
+		{
+			Step: &types.Sleep{
+				Duration: settleDelay,
+			},
+		},
+		{
+			Step: &CapturePacketCount{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				PodName:                 senderName + "-0",
+				Direction:               "egress",
+				baseline:                baseline,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.SendICMPEchoRequests{
+				PodNamespace:         workloadNamespace,
+				PodName:              senderName + "-0",
+				DestinationNamespace: workloadNamespace,
+				DestinationPodName:   receiverName + "-0",
+				PacketCount:          count,
+				PacketsPerSecond:     rate,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &ValidateExactPacketCount{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				PodName:                 senderName + "-0",
+				Direction:               "egress",
+				ExpectedPackets:         count,
+				// perf buffer losses under load are the only accepted source of error
+				ToleratedPackets: count / 100,
+				MaxReportedLoss:  maxReportedLoss,
+				baseline:         baseline,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &types.Stop{
+				BackgroundID: idPrefix + "-port-forward",
+			},
+		},
+		// deleting the namespace removes both workloads with it
+		{
+			Step: &kubernetes.DeleteKubernetesResource{
+				ResourceType:      kubernetes.TypeString(kubernetes.Namespace),
+				ResourceName:      workloadNamespace,
+				ResourceNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+}
+
+func upgradeRetina(kubeConfigFilePath, chartPath, valuesFilePath string, setValues []string) []*types.StepWrapper {
+	return []*types.StepWrapper{
+		{
+			Step: &kubernetes.UpgradeRetinaHelmChart{
+				Namespace:          "kube-system",
+				ReleaseName:        "retina",
+				KubeConfigFilePath: kubeConfigFilePath,
+				ChartPath:          chartPath,
+				TagEnv:             generic.DefaultTagEnv,
+				ValuesFile:         valuesFilePath,
+				SetValues:          setValues,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.EnsureStableCluster{
+				PodNamespace:  "kube-system",
+				LabelSelector: "k8s-app=retina",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+}
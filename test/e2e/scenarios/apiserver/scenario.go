@@ -0,0 +1,112 @@
+package apiserver
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+)
+
+const (
+	workloadNamespace = "retina-apiserver"
+
+	sleepDelay = 5 * time.Second
+	requests   = 3
+)
+
+// ValidateAPIServerFlowMetrics sends requests from a pod to the API server through the kubernetes.default
+// Service and validates the flows are attributed to the pod, with the API server recognized as such
+func ValidateAPIServerFlowMetrics() *types.Scenario {
+	name := "API Server Flow Metrics"
+	agnhostName := "agnhost-apiserver"
+	podName := agnhostName + "-0"
+
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.CreateNamespace{
+				NamespaceName: workloadNamespace,
+				Observe:       true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      agnhostName,
+				AgnhostNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	// unauthenticated requests are rejected by the API server, but their flows are what is validated
+	for i := 0; i < requests; i++ {
+		steps = append(steps,
+			&types.StepWrapper{
+				Step: &kubernetes.ExecInPod{
+					PodName:      podName,
+					PodNamespace: workloadNamespace,
+					Command:      "curl -sk -m 5 https://kubernetes.default.svc/version",
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+				},
+			},
+			&types.StepWrapper{
+				Step: &types.Sleep{
+					Duration: sleepDelay,
+				},
+			},
+		)
+	}
+
+	steps = append(steps,
+		&types.StepWrapper{
+			Step: &kubernetes.PortForward{
+				Namespace:                          "kube-system",
+				LabelSelector:                      "k8s-app=retina",
+				LocalPort:                          strconv.Itoa(common.RetinaPort),
+				RemotePort:                         strconv.Itoa(common.RetinaPort),
+				Endpoint:                           "metrics",
+				OptionalLabelAffinity:              "app=" + agnhostName,
+				OptionalLabelAffinityAllNamespaces: true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "apiserver-port-forward",
+			},
+		},
+		&types.StepWrapper{
+			Step: &ValidateAPIServerFlowAttribution{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				NamespaceName:           workloadNamespace,
+				PodName:                 podName,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		&types.StepWrapper{
+			Step: &types.Stop{
+				BackgroundID: "apiserver-port-forward",
+			},
+		},
+		&types.StepWrapper{
+			Step: &kubernetes.DeleteKubernetesResource{
+				ResourceType:      kubernetes.TypeString(kubernetes.Namespace),
+				ResourceName:      workloadNamespace,
+				ResourceNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	)
+
+	return types.NewScenario(name, steps...)
+}
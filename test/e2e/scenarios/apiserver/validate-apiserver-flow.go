@@ -0,0 +1,89 @@
+package apiserver
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/microsoft/retina/test/retry"
+)
+
+const (
+	advForwardCountMetricName = "networkobservability_adv_forward_count"
+
+	// namespace and pod name the agent gives the API server endpoint, see pkg/common.APIServerEndpointName
+	apiServerEndpointName = "kubernetes-apiserver"
+
+	metricRetryAttempts = 20
+	metricRetryDelay    = 5 * time.Second
+)
+
+var (
+	ErrNoClientFlow        = fmt.Errorf("no flow series attributed to the client pod")
+	ErrAPIServerAsLocalPod = fmt.Errorf("api server endpoint attributed as a local pod")
+)
+
+// requests and their responses are both attributed to the client
+var attributedDirections = []string{"egress", "ingress"}
+
+// ValidateAPIServerFlowAttribution checks the client's traffic to the API server is attributed to the client
+// pod in both directions, and that the agent recognized the other end as the API server endpoint rather than
+// reporting it as a pod of its own
+type ValidateAPIServerFlowAttribution struct {
+	PortForwardedRetinaPort string
+	NamespaceName           string
+	PodName                 string
+}
+
+func (v *ValidateAPIServerFlowAttribution) Run() error {
+	promAddress := fmt.Sprintf("http://localhost:%s/metrics", v.PortForwardedRetinaPort)
+
+	checkFn := func() error {
+		series, err := prom.GetMetricsMatchingLabels(promAddress, advForwardCountMetricName, map[string]string{})
+		if err != nil {
+			return fmt.Errorf("failed to scrape %s: %w", advForwardCountMetricName, err)
+		}
+
+		directions := map[string]bool{}
+		for _, metric := range series {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+
+			if labels["podname"] == apiServerEndpointName || labels["namespace"] == apiServerEndpointName {
+				return fmt.Errorf("series %v: %w", labels, ErrAPIServerAsLocalPod)
+			}
+			if labels["namespace"] == v.NamespaceName && labels["podname"] == v.PodName {
+				directions[labels["direction"]] = true
+			}
+		}
+
+		for _, direction := range attributedDirections {
+			if !directions[direction] {
+				log.Printf("no %s %s series for %s/%s yet\n", direction, advForwardCountMetricName, v.NamespaceName, v.PodName)
+				return ErrNoClientFlow
+			}
+		}
+		return nil
+	}
+
+	retrier := retry.Retrier{Attempts: metricRetryAttempts, Delay: metricRetryDelay}
+	err := retrier.Do(context.Background(), checkFn)
+	if err != nil {
+		return fmt.Errorf("failed to verify prometheus metrics %s: %w", advForwardCountMetricName, err)
+	}
+
+	log.Printf("api server traffic of %s/%s is attributed to the pod only\n", v.NamespaceName, v.PodName)
+	return nil
+}
+
+func (v *ValidateAPIServerFlowAttribution) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateAPIServerFlowAttribution) Stop() error {
+	return nil
+}
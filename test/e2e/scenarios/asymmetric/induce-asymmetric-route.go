@@ -0,0 +1,76 @@
+package asymmetric
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const defaultTimeout = 2 * time.Minute
+
+// InduceAsymmetricRoute routes the server pod's replies to the client pod through the server's node,
+// rather than back along the path the requests arrived on, then generates traffic from client to server.
+// The server pod requires NET_ADMIN.
+type InduceAsymmetricRoute struct {
+	KubeConfigFilePath string
+	PodNamespace       string
+	ServerPodName      string
+	ClientPodName      string
+}
+
+func (i *InduceAsymmetricRoute) Run() error {
+	config, err := k8s.BuildConfig(i.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	server, err := clientset.CoreV1().Pods(i.PodNamespace).Get(ctx, i.ServerPodName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting server pod \"%s\": %w", i.ServerPodName, err)
+	}
+
+	client, err := clientset.CoreV1().Pods(i.PodNamespace).Get(ctx, i.ClientPodName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting client pod \"%s\": %w", i.ClientPodName, err)
+	}
+
+	// replies to the client leave via the node's address as next hop instead of the pod's default gateway
+	route := fmt.Sprintf("ip route replace %s/32 via %s dev eth0 onlink", client.Status.PodIP, server.Status.HostIP)
+	_, err = k8s.ExecPod(ctx, clientset, config, i.PodNamespace, i.ServerPodName, route)
+	if err != nil {
+		return fmt.Errorf("error adding asymmetric return route on server pod \"%s\": %w", i.ServerPodName, err)
+	}
+	log.Printf("routed replies from \"%s\" to \"%s\" via node address %s\n", i.ServerPodName, client.Status.PodIP, server.Status.HostIP)
+
+	// generate traffic over the asymmetric path, the response may or may not make it back
+	request := fmt.Sprintf("curl -s -m 5 http://%s:%d", server.Status.PodIP, k8s.AgnhostHTTPPort)
+	for attempt := 0; attempt < 3; attempt++ {
+		_, err = k8s.ExecPod(ctx, clientset, config, i.PodNamespace, i.ClientPodName, request)
+		if err != nil {
+			log.Printf("request from \"%s\" to \"%s\" over asymmetric path failed: %v\n", i.ClientPodName, i.ServerPodName, err)
+		}
+	}
+
+	return nil
+}
+
+func (i *InduceAsymmetricRoute) Prevalidate() error {
+	return nil
+}
+
+func (i *InduceAsymmetricRoute) Stop() error {
+	return nil
+}
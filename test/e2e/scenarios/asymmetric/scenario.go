@@ -0,0 +1,97 @@
+package asymmetric
+
+import (
+	"strconv"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+	v1 "k8s.io/api/core/v1"
+)
+
+// ValidateAsymmetricRoutingMetrics sends traffic to a server pod whose replies are routed back over a
+// different path than the requests arrived on, and validates the agent still records both directions of the flow
+func ValidateAsymmetricRoutingMetrics() *types.Scenario {
+	name := "Asymmetric Routing Metrics"
+	serverName := "agnhost-asym-server"
+	clientName := "agnhost-asym-client"
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      serverName,
+				AgnhostNamespace: "kube-system",
+				AddCapabilities:  []v1.Capability{"NET_ADMIN"},
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      clientName,
+				AgnhostNamespace: "kube-system",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &InduceAsymmetricRoute{
+				PodNamespace:  "kube-system",
+				ServerPodName: serverName + "-0",
+				ClientPodName: clientName + "-0",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.PortForward{
+				Namespace:             "kube-system",
+				LabelSelector:         "k8s-app=retina",
+				LocalPort:             strconv.Itoa(common.RetinaPort),
+				RemotePort:            strconv.Itoa(common.RetinaPort),
+				Endpoint:              "metrics",
+				OptionalLabelAffinity: "app=" + serverName, // port forward to a pod on a node that also has this pod with this label, assuming same namespace
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "asymmetric-port-forward",
+			},
+		},
+		{
+			Step: &ValidateAsymmetricFlowMetric{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &types.Stop{
+				BackgroundID: "asymmetric-port-forward",
+			},
+		},
+		{
+			Step: &kubernetes.DeleteKubernetesResource{
+				ResourceType:      kubernetes.TypeString(kubernetes.StatefulSet),
+				ResourceName:      serverName,
+				ResourceNamespace: "kube-system",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.DeleteKubernetesResource{
+				ResourceType:      kubernetes.TypeString(kubernetes.StatefulSet),
+				ResourceName:      clientName,
+				ResourceNamespace: "kube-system",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+	return types.NewScenario(name, steps...)
+}
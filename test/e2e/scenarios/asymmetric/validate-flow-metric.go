@@ -0,0 +1,45 @@
+package asymmetric
+
+import (
+	"fmt"
+	"log"
+
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+)
+
+var forwardCountMetricName = "networkobservability_forward_count"
+
+const directionKey = "direction"
+
+// ValidateAsymmetricFlowMetric validates that both directions of the asymmetric flow were observed,
+// so the flow isn't silently recorded as one-sided
+type ValidateAsymmetricFlowMetric struct {
+	PortForwardedRetinaPort string
+}
+
+func (v *ValidateAsymmetricFlowMetric) Run() error {
+	promAddress := fmt.Sprintf("http://localhost:%s/metrics", v.PortForwardedRetinaPort)
+
+	validMetrics := []map[string]string{
+		{directionKey: "ingress"},
+		{directionKey: "egress"},
+	}
+
+	for _, metric := range validMetrics {
+		err := prom.CheckMetric(promAddress, forwardCountMetricName, metric)
+		if err != nil {
+			return fmt.Errorf("failed to verify prometheus metrics %s for %+v: %w", forwardCountMetricName, metric, err)
+		}
+	}
+
+	log.Printf("found metrics matching %+v\n", validMetrics)
+	return nil
+}
+
+func (v *ValidateAsymmetricFlowMetric) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateAsymmetricFlowMetric) Stop() error {
+	return nil
+}
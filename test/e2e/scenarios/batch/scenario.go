@@ -0,0 +1,118 @@
+package batch
+
+import (
+	"strconv"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+)
+
+const (
+	workloadNamespace = "retina-batch"
+
+	// the pods look up the name every 5s for 5 minutes, long enough to be validated before they complete
+	lookupCommand = "for i in $(seq 1 60); do nslookup kubernetes.default; sleep 5; done"
+	lookupQuery   = "kubernetes.default.svc.cluster.local."
+
+	everyMinute = "* * * * *"
+)
+
+// ValidateBatchWorkloadDNSMetrics runs a Job and a CronJob whose pods make DNS requests, and validates each
+// pod's requests are attributed to the Job owning it while the pod is still running
+func ValidateBatchWorkloadDNSMetrics() *types.Scenario {
+	name := "Batch Workload DNS Metrics"
+	jobName := "agnhost-batch-job"
+	cronJobName := "agnhost-batch-cronjob"
+	command := []string{"sh", "-c", lookupCommand}
+
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.CreateNamespace{
+				NamespaceName: workloadNamespace,
+				Observe:       true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostJob{
+				JobName:      jobName,
+				JobNamespace: workloadNamespace,
+				Command:      command,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+	steps = append(steps, validateAttributionSteps(jobName)...)
+
+	steps = append(steps, &types.StepWrapper{
+		Step: &kubernetes.CreateAgnhostCronJob{
+			CronJobName:      cronJobName,
+			CronJobNamespace: workloadNamespace,
+			Schedule:         everyMinute,
+			Command:          command,
+		},
+		Opts: &types.StepOptions{
+			SkipSavingParametersToJob: true,
+		},
+	})
+	steps = append(steps, validateAttributionSteps(cronJobName)...)
+
+	// deleting the namespace removes the Job and the CronJob with their pods
+	steps = append(steps, &types.StepWrapper{
+		Step: &kubernetes.DeleteKubernetesResource{
+			ResourceType:      kubernetes.TypeString(kubernetes.Namespace),
+			ResourceName:      workloadNamespace,
+			ResourceNamespace: workloadNamespace,
+		},
+		Opts: &types.StepOptions{
+			SkipSavingParametersToJob: true,
+		},
+	})
+
+	return types.NewScenario(name, steps...)
+}
+
+// validateAttributionSteps validates the DNS requests of the running pod labelled app=appLabel through
+// the agent on the pod's node
+func validateAttributionSteps(appLabel string) []*types.StepWrapper {
+	return []*types.StepWrapper{
+		{
+			Step: &kubernetes.PortForward{
+				Namespace:                          "kube-system",
+				LabelSelector:                      "k8s-app=retina",
+				LocalPort:                          strconv.Itoa(common.RetinaPort),
+				RemotePort:                         strconv.Itoa(common.RetinaPort),
+				Endpoint:                           "metrics",
+				OptionalLabelAffinity:              "app=" + appLabel,
+				OptionalLabelAffinityAllNamespaces: true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     appLabel + "-port-forward",
+			},
+		},
+		{
+			Step: &ValidateBatchDNSAttribution{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				PodNamespace:            workloadNamespace,
+				AppLabel:                appLabel,
+				WorkloadKind:            "Job",
+				Query:                   lookupQuery,
+				QueryType:               "A",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &types.Stop{
+				BackgroundID: appLabel + "-port-forward",
+			},
+		},
+	}
+}
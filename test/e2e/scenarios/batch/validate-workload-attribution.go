@@ -0,0 +1,116 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	advDNSRequestCountMetricName = "networkobservability_adv_dns_request_count"
+
+	defaultTimeout = 2 * time.Minute
+)
+
+var (
+	ErrNoRunningPod = fmt.Errorf("no running pod for the workload")
+	ErrNoOwner      = fmt.Errorf("pod has no owner of the workload kind")
+	ErrPodCompleted = fmt.Errorf("pod completed before its metrics were validated")
+)
+
+// ValidateBatchDNSAttribution checks the DNS requests of the running pod labelled app=AppLabel are attributed
+// to the pod's owner of WorkloadKind, and that the pod is still running once they are, so the attribution
+// doesn't rely on the pod having completed. The agent attributes a pod to its direct owner, so the pods of
+// a CronJob are attributed to the Job it created for them
+type ValidateBatchDNSAttribution struct {
+	KubeConfigFilePath      string
+	PortForwardedRetinaPort string
+	PodNamespace            string
+	AppLabel                string
+	WorkloadKind            string
+	Query                   string
+	QueryType               string
+}
+
+func (v *ValidateBatchDNSAttribution) Run() error {
+	config, err := k8s.BuildConfig(v.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	pod, err := v.runningPod(ctx, clientset)
+	if err != nil {
+		return err
+	}
+
+	workloadName := ""
+	for _, owner := range pod.OwnerReferences {
+		if owner.Kind == v.WorkloadKind {
+			workloadName = owner.Name
+		}
+	}
+	if workloadName == "" {
+		return fmt.Errorf("pod %s, kind %s: %w", pod.Name, v.WorkloadKind, ErrNoOwner)
+	}
+
+	labels := map[string]string{
+		"namespace":     v.PodNamespace,
+		"podname":       pod.Name,
+		"query":         v.Query,
+		"query_type":    v.QueryType,
+		"workload_kind": v.WorkloadKind,
+		"workload_name": workloadName,
+	}
+	promAddress := fmt.Sprintf("http://localhost:%s/metrics", v.PortForwardedRetinaPort)
+	err = prom.CheckMetric(promAddress, advDNSRequestCountMetricName, labels)
+	if err != nil {
+		return fmt.Errorf("failed to verify %s for pod %s: %w", advDNSRequestCountMetricName, pod.Name, err)
+	}
+
+	current, err := clientset.CoreV1().Pods(v.PodNamespace).Get(ctx, pod.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting pod \"%s\": %w", pod.Name, err)
+	}
+	if current.Status.Phase != corev1.PodRunning {
+		return fmt.Errorf("pod %s is %s: %w", pod.Name, current.Status.Phase, ErrPodCompleted)
+	}
+
+	log.Printf("found metrics matching %+v while pod %s is running\n", labels, pod.Name)
+	return nil
+}
+
+func (v *ValidateBatchDNSAttribution) runningPod(ctx context.Context, clientset *kubernetes.Clientset) (*corev1.Pod, error) {
+	pods, err := clientset.CoreV1().Pods(v.PodNamespace).List(ctx, metav1.ListOptions{LabelSelector: "app=" + v.AppLabel})
+	if err != nil {
+		return nil, fmt.Errorf("error listing pods with label app=%s: %w", v.AppLabel, err)
+	}
+	for i := range pods.Items {
+		if pods.Items[i].Status.Phase == corev1.PodRunning {
+			return &pods.Items[i], nil
+		}
+	}
+	return nil, fmt.Errorf("label app=%s: %w", v.AppLabel, ErrNoRunningPod)
+}
+
+func (v *ValidateBatchDNSAttribution) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateBatchDNSAttribution) Stop() error {
+	return nil
+}
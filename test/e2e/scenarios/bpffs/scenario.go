@@ -0,0 +1,150 @@
+package bpffs
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	"github.com/microsoft/retina/test/e2e/framework/generic"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+	flow "github.com/microsoft/retina/test/e2e/scenarios/tcp"
+)
+
+const (
+	sleepDelay = 5 * time.Second
+
+	// CustomBPFFSPath is a non-default host path for the bpf filesystem mount
+	CustomBPFFSPath = "/var/run/retina/bpffs"
+)
+
+// ValidateCustomBPFFSMount upgrades Retina to mount the bpf filesystem from a non-default path,
+// validates the agent is healthy and still produces metrics, then restores the default mount
+func ValidateCustomBPFFSMount(kubeConfigFilePath, chartPath, valuesFilePath string) *types.Scenario {
+	name := "Custom BPFFS Mount"
+	agnhostName := "agnhost-bpffs"
+	podName := agnhostName + "-0"
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.UpgradeRetinaHelmChart{
+				Namespace:          "kube-system",
+				ReleaseName:        "retina",
+				KubeConfigFilePath: kubeConfigFilePath,
+				ChartPath:          chartPath,
+				TagEnv:             generic.DefaultTagEnv,
+				ValuesFile:         valuesFilePath,
+				SetValues:          []string{"volumeMounts.bpf=" + CustomBPFFSPath},
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.EnsureStableCluster{
+				PodNamespace:  "kube-system",
+				LabelSelector: "k8s-app=retina",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &ValidateRetinaBPFFSMount{
+				RetinaDaemonSetNamespace: "kube-system",
+				BPFFSPath:                CustomBPFFSPath,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      agnhostName,
+				AgnhostNamespace: "kube-system",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.ExecInPod{
+				PodName:      podName,
+				PodNamespace: "kube-system",
+				Command:      "curl -s -m 5 bing.com",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &types.Sleep{
+				Duration: sleepDelay,
+			},
+		},
+		{
+			Step: &kubernetes.PortForward{
+				Namespace:             "kube-system",
+				LabelSelector:         "k8s-app=retina",
+				LocalPort:             strconv.Itoa(common.RetinaPort),
+				RemotePort:            strconv.Itoa(common.RetinaPort),
+				Endpoint:              "metrics",
+				OptionalLabelAffinity: "app=" + agnhostName, // port forward to a pod on a node that also has this pod with this label, assuming same namespace
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "bpffs-port-forward",
+			},
+		},
+		{
+			Step: &flow.ValidateRetinaTCPStateMetric{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &types.Stop{
+				BackgroundID: "bpffs-port-forward",
+			},
+		},
+	}
+
+	// delete the workload and restore the default bpffs mount for any scenarios that follow, even when a step fails
+	cleanup := []*types.StepWrapper{
+		{
+			Step: &kubernetes.DeleteKubernetesResource{
+				ResourceType:      kubernetes.TypeString(kubernetes.StatefulSet),
+				ResourceName:      agnhostName,
+				ResourceNamespace: "kube-system",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.UpgradeRetinaHelmChart{
+				Namespace:          "kube-system",
+				ReleaseName:        "retina",
+				KubeConfigFilePath: kubeConfigFilePath,
+				ChartPath:          chartPath,
+				TagEnv:             generic.DefaultTagEnv,
+				ValuesFile:         valuesFilePath,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.EnsureStableCluster{
+				PodNamespace:  "kube-system",
+				LabelSelector: "k8s-app=retina",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	return types.NewScenario(name, steps...).WithCleanup(cleanup...)
+}
@@ -0,0 +1,113 @@
+package bpffs
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const bpfVolumeName = "bpf"
+
+var (
+	ErrNoRetinaPodsFound      = fmt.Errorf("no linux retina pods found")
+	ErrBPFFSVolumeMissing     = fmt.Errorf("bpf volume missing from retina pod")
+	ErrBPFFSVolumeUnexpected  = fmt.Errorf("bpf volume has unexpected host path")
+	ErrBPFFSMountMissing      = fmt.Errorf("bpf volume mount missing from retina container")
+	ErrBPFFSMountUnexpected   = fmt.Errorf("bpf volume mount has unexpected mount path")
+	ErrRetinaContainerMissing = fmt.Errorf("retina container missing from retina pod")
+)
+
+// ValidateRetinaBPFFSMount checks that every linux retina pod mounts the bpf filesystem from BPFFSPath
+type ValidateRetinaBPFFSMount struct {
+	KubeConfigFilePath       string
+	RetinaDaemonSetNamespace string
+	BPFFSPath                string
+}
+
+func (v *ValidateRetinaBPFFSMount) Run() error {
+	config, err := k8s.BuildConfig(v.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	pods, err := clientset.CoreV1().Pods(v.RetinaDaemonSetNamespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: "k8s-app=retina",
+	})
+	if err != nil {
+		return fmt.Errorf("error listing retina pods: %w", err)
+	}
+
+	found := 0
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Spec.NodeSelector["kubernetes.io/os"] == "windows" {
+			continue
+		}
+		found++
+
+		err = v.validatePod(pod)
+		if err != nil {
+			return err
+		}
+		log.Printf("retina pod \"%s\" mounts bpffs from \"%s\"\n", pod.Name, v.BPFFSPath)
+	}
+
+	if found == 0 {
+		return fmt.Errorf("in namespace \"%s\": %w", v.RetinaDaemonSetNamespace, ErrNoRetinaPodsFound)
+	}
+
+	return nil
+}
+
+func (v *ValidateRetinaBPFFSMount) validatePod(pod *v1.Pod) error {
+	var volume *v1.Volume
+	for i := range pod.Spec.Volumes {
+		if pod.Spec.Volumes[i].Name == bpfVolumeName {
+			volume = &pod.Spec.Volumes[i]
+		}
+	}
+	if volume == nil || volume.HostPath == nil {
+		return fmt.Errorf("pod \"%s\": %w", pod.Name, ErrBPFFSVolumeMissing)
+	}
+	if volume.HostPath.Path != v.BPFFSPath {
+		return fmt.Errorf("pod \"%s\" has host path \"%s\", expected \"%s\": %w", pod.Name, volume.HostPath.Path, v.BPFFSPath, ErrBPFFSVolumeUnexpected)
+	}
+
+	for i := range pod.Spec.Containers {
+		container := &pod.Spec.Containers[i]
+		if container.Name != "retina" {
+			continue
+		}
+		for j := range container.VolumeMounts {
+			mount := &container.VolumeMounts[j]
+			if mount.Name != bpfVolumeName {
+				continue
+			}
+			if mount.MountPath != v.BPFFSPath {
+				return fmt.Errorf("pod \"%s\" has mount path \"%s\", expected \"%s\": %w", pod.Name, mount.MountPath, v.BPFFSPath, ErrBPFFSMountUnexpected)
+			}
+			return nil
+		}
+		return fmt.Errorf("pod \"%s\": %w", pod.Name, ErrBPFFSMountMissing)
+	}
+
+	return fmt.Errorf("pod \"%s\": %w", pod.Name, ErrRetinaContainerMissing)
+}
+
+func (v *ValidateRetinaBPFFSMount) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateRetinaBPFFSMount) Stop() error {
+	return nil
+}
@@ -0,0 +1,112 @@
+package capture
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s "k8s.io/client-go/kubernetes"
+)
+
+const (
+	artifactReaderName = "capture-artifact-reader"
+	readerTimeout      = 5 * time.Minute
+)
+
+// CreateArtifactReader runs a pod on every node with HostPath mounted read only at the same path,
+// so steps after it can list the capture artifacts written to the nodes
+type CreateArtifactReader struct {
+	KubeConfigFilePath string
+	ReaderNamespace    string
+	HostPath           string
+}
+
+func (c *CreateArtifactReader) Run() error {
+	config, err := kubernetes.BuildConfig(c.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := k8s.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), readerTimeout)
+	defer cancel()
+
+	err = kubernetes.CreateResource(ctx, c.daemonSet(), clientset)
+	if err != nil {
+		return fmt.Errorf("error creating capture artifact reader: %w", err)
+	}
+
+	err = kubernetes.WaitForPodsRunning(ctx, clientset, c.ReaderNamespace, "app="+artifactReaderName)
+	if err != nil {
+		return fmt.Errorf("error waiting for capture artifact reader to be ready: %w", err)
+	}
+	return nil
+}
+
+func (c *CreateArtifactReader) daemonSet() *appsv1.DaemonSet {
+	hostPathType := v1.HostPathDirectoryOrCreate
+	labels := map[string]string{"app": artifactReaderName}
+
+	return &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      artifactReaderName,
+			Namespace: c.ReaderNamespace,
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: v1.PodSpec{
+					NodeSelector: map[string]string{
+						"kubernetes.io/os": "linux",
+					},
+					Containers: []v1.Container{
+						{
+							Name:    artifactReaderName,
+							Image:   "acnpublic.azurecr.io/agnhost:2.40",
+							Command: []string{"/agnhost", "pause"},
+							VolumeMounts: []v1.VolumeMount{
+								{
+									Name:      "captures",
+									MountPath: c.HostPath,
+									ReadOnly:  true,
+								},
+							},
+						},
+					},
+					Volumes: []v1.Volume{
+						{
+							Name: "captures",
+							VolumeSource: v1.VolumeSource{
+								HostPath: &v1.HostPathVolumeSource{
+									Path: c.HostPath,
+									Type: &hostPathType,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (c *CreateArtifactReader) Prevalidate() error {
+	return nil
+}
+
+func (c *CreateArtifactReader) Stop() error {
+	return nil
+}
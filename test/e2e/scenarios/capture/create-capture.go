@@ -0,0 +1,101 @@
+package capture
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	retinav1alpha1 "github.com/microsoft/retina/crd/api/v1alpha1"
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+)
+
+const createTimeout = 30 * time.Second
+
+var captureResource = retinav1alpha1.GroupVersion.WithResource("captures")
+
+// CreateCapture creates a Capture of the pods labeled TargetPodLabels in TargetNamespace, writing its
+// artifacts to HostPath on each node the capture runs on
+type CreateCapture struct {
+	KubeConfigFilePath string
+	CaptureName        string
+	CaptureNamespace   string
+	TargetNamespace    string
+	TcpdumpFilter      string
+	HostPath           string
+
+	TargetPodLabels map[string]string
+	Duration        time.Duration
+}
+
+func (c *CreateCapture) Run() error {
+	config, err := k8s.BuildConfig(c.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating dynamic client: %w", err)
+	}
+
+	object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(c.capture())
+	if err != nil {
+		return fmt.Errorf("error converting capture \"%s\": %w", c.CaptureName, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), createTimeout)
+	defer cancel()
+
+	_, err = client.Resource(captureResource).Namespace(c.CaptureNamespace).Create(ctx, &unstructured.Unstructured{Object: object}, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("error creating capture \"%s\": %w", c.CaptureName, err)
+	}
+
+	log.Printf("created capture \"%s\" of pods %v in namespace \"%s\" with filter \"%s\"\n", c.CaptureName, c.TargetPodLabels, c.TargetNamespace, c.TcpdumpFilter)
+	return nil
+}
+
+func (c *CreateCapture) capture() *retinav1alpha1.Capture {
+	return &retinav1alpha1.Capture{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Capture",
+			APIVersion: retinav1alpha1.GroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      c.CaptureName,
+			Namespace: c.CaptureNamespace,
+		},
+		Spec: retinav1alpha1.CaptureSpec{
+			CaptureConfiguration: retinav1alpha1.CaptureConfiguration{
+				CaptureTarget: retinav1alpha1.CaptureTarget{
+					NamespaceSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"kubernetes.io/metadata.name": c.TargetNamespace},
+					},
+					PodSelector: &metav1.LabelSelector{
+						MatchLabels: c.TargetPodLabels,
+					},
+				},
+				TcpdumpFilter: &c.TcpdumpFilter,
+				CaptureOption: retinav1alpha1.CaptureOption{
+					Duration: &metav1.Duration{Duration: c.Duration},
+				},
+			},
+			OutputConfiguration: retinav1alpha1.OutputConfiguration{
+				HostPath: &c.HostPath,
+			},
+		},
+	}
+}
+
+func (c *CreateCapture) Prevalidate() error {
+	return nil
+}
+
+func (c *CreateCapture) Stop() error {
+	return nil
+}
@@ -0,0 +1,348 @@
+package capture
+
+import (
+	"fmt"
+	"math/rand"
+	"path"
+	"time"
+
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+)
+
+const (
+	workloadNamespace = "retina-capture"
+
+	captureDuration = 30 * time.Second
+	// long enough for the agent to be replaced before the capture would end on its own
+	restartCaptureDuration = 2 * time.Minute
+
+	requests   = 5
+	sleepDelay = 5 * time.Second
+	pingCount  = 20
+	pingRate   = 2
+)
+
+// ValidateConcurrentCaptures starts two captures at once, each of a different pod with a different filter,
+// and validates each produces its own artifact on its target's node without the other's landing beside it.
+// Both captures write under a host path unique to the run, which is left on the nodes afterwards.
+//
+// Captures need the operator, so the scenario is skipped without it. In debug mode the operator pulls the
+// capture workload image from ghcr.io at its own tag, whichever registry the agent's image was pushed to
+func ValidateConcurrentCaptures() *types.Scenario {
+	name := "Concurrent Captures"
+	hostPath := fmt.Sprintf("/mnt/retina-e2e-captures-%d", rand.Int()) // nolint:gosec // fine to use math/rand here
+	clientName := "agnhost-capture-client"
+	serverName := "agnhost-capture-server"
+	serviceName := "capture-server"
+
+	captures := []*CreateCapture{
+		{
+			CaptureName:     "capture-http",
+			TargetPodLabels: map[string]string{"app": clientName},
+			TcpdumpFilter:   fmt.Sprintf("tcp port %d", kubernetes.AgnhostHTTPPort),
+		},
+		{
+			CaptureName:     "capture-icmp",
+			TargetPodLabels: map[string]string{"app": serverName},
+			TcpdumpFilter:   "icmp",
+		},
+	}
+
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.CreateNamespace{
+				NamespaceName: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      serverName,
+				AgnhostNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      clientName,
+				AgnhostNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostService{
+				ServiceName:      serviceName,
+				ServiceNamespace: workloadNamespace,
+				AgnhostName:      serverName,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	// both captures are created before either can finish, so they run side by side
+	for _, capture := range captures {
+		capture.CaptureNamespace = workloadNamespace
+		capture.TargetNamespace = workloadNamespace
+		capture.HostPath = path.Join(hostPath, capture.CaptureName)
+		capture.Duration = captureDuration
+		steps = append(steps, &types.StepWrapper{
+			Step: capture,
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		})
+	}
+
+	// the client's HTTP requests and the server's ping replies each match only one capture's filter
+	for i := 0; i < requests; i++ {
+		steps = append(steps,
+			&types.StepWrapper{
+				Step: &kubernetes.ExecInPod{
+					PodName:      clientName + "-0",
+					PodNamespace: workloadNamespace,
+					Command:      fmt.Sprintf("curl -s -m 5 http://%s.%s.svc.cluster.local:%d", serviceName, workloadNamespace, kubernetes.AgnhostHTTPPort),
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+				},
+			},
+			&types.StepWrapper{
+				Step: &types.Sleep{
+					Duration: sleepDelay,
+				},
+			},
+		)
+	}
+	steps = append(steps, &types.StepWrapper{
+		Step: &kubernetes.SendICMPEchoRequests{
+			PodNamespace:         workloadNamespace,
+			PodName:              clientName + "-0",
+			DestinationNamespace: workloadNamespace,
+			DestinationPodName:   serverName + "-0",
+			PacketCount:          pingCount,
+			PacketsPerSecond:     pingRate,
+		},
+		Opts: &types.StepOptions{
+			SkipSavingParametersToJob: true,
+		},
+	})
+
+	for _, capture := range captures {
+		steps = append(steps, &types.StepWrapper{
+			Step: &WaitForCaptureComplete{
+				CaptureName:      capture.CaptureName,
+				CaptureNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		})
+	}
+
+	steps = append(steps, &types.StepWrapper{
+		Step: &CreateArtifactReader{
+			ReaderNamespace: workloadNamespace,
+			HostPath:        hostPath,
+		},
+		Opts: &types.StepOptions{
+			SkipSavingParametersToJob: true,
+		},
+	})
+
+	for _, capture := range captures {
+		steps = append(steps, &types.StepWrapper{
+			Step: &ValidateCaptureArtifacts{
+				CaptureName:      capture.CaptureName,
+				CaptureNamespace: workloadNamespace,
+				ReaderNamespace:  workloadNamespace,
+				ArtifactDir:      capture.HostPath,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		})
+	}
+
+	steps = append(steps, &types.StepWrapper{
+		Step: &kubernetes.DeleteKubernetesResource{
+			ResourceType:      kubernetes.TypeString(kubernetes.Namespace),
+			ResourceName:      workloadNamespace,
+			ResourceNamespace: workloadNamespace,
+		},
+		Opts: &types.StepOptions{
+			SkipSavingParametersToJob: true,
+		},
+	})
+
+	return types.NewScenario(name, steps...).WithPreconditions(operatorRunning())
+}
+
+// operatorRunning is a precondition skipping a capture scenario unless the Retina operator is installed
+func operatorRunning() *types.StepWrapper {
+	return &types.StepWrapper{
+		Step: &kubernetes.SkipUnlessPods{
+			PodNamespace:  "kube-system",
+			LabelSelector: "control-plane=retina-operator",
+		},
+		Opts: &types.StepOptions{
+			SkipSavingParametersToJob: true,
+		},
+	}
+}
+
+// ValidateCaptureAcrossAgentRestart starts a capture of a pod, gracefully restarts the Retina agent on the pod's
+// node while the capture runs, and validates the capture still ends, either complete with its artifact in place
+// or failed, rather than hanging.
+//
+// Like ValidateConcurrentCaptures, this needs the operator and is skipped without it
+func ValidateCaptureAcrossAgentRestart() *types.Scenario {
+	name := "Capture Across Agent Restart"
+	hostPath := fmt.Sprintf("/mnt/retina-e2e-captures-%d", rand.Int()) // nolint:gosec // fine to use math/rand here
+	clientName := "agnhost-capture-restart-client"
+	serverName := "agnhost-capture-restart-server"
+	serviceName := "capture-restart-server"
+	captureName := "capture-across-restart"
+	state := &terminalState{}
+
+	request := func() []*types.StepWrapper {
+		return []*types.StepWrapper{
+			{
+				Step: &kubernetes.ExecInPod{
+					PodName:      clientName + "-0",
+					PodNamespace: workloadNamespace,
+					Command:      fmt.Sprintf("curl -s -m 5 http://%s.%s.svc.cluster.local:%d", serviceName, workloadNamespace, kubernetes.AgnhostHTTPPort),
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+				},
+			},
+			{
+				Step: &types.Sleep{
+					Duration: sleepDelay,
+				},
+			},
+		}
+	}
+
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.CreateNamespace{
+				NamespaceName: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      serverName,
+				AgnhostNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      clientName,
+				AgnhostNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostService{
+				ServiceName:      serviceName,
+				ServiceNamespace: workloadNamespace,
+				AgnhostName:      serverName,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &CreateCapture{
+				CaptureName:      captureName,
+				CaptureNamespace: workloadNamespace,
+				TargetNamespace:  workloadNamespace,
+				TargetPodLabels:  map[string]string{"app": clientName},
+				TcpdumpFilter:    fmt.Sprintf("tcp port %d", kubernetes.AgnhostHTTPPort),
+				HostPath:         path.Join(hostPath, captureName),
+				Duration:         restartCaptureDuration,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	// traffic on either side of the restart, so the capture has data whichever part of it survives
+	steps = append(steps, request()...)
+	steps = append(steps, &types.StepWrapper{
+		Step: &kubernetes.RestartRetinaAgent{
+			RetinaDaemonSetNamespace: "kube-system",
+			PodNamespace:             workloadNamespace,
+			PodName:                  clientName + "-0",
+		},
+		Opts: &types.StepOptions{
+			SkipSavingParametersToJob: true,
+		},
+	})
+	steps = append(steps, request()...)
+
+	steps = append(steps,
+		&types.StepWrapper{
+			Step: &WaitForCaptureTerminal{
+				CaptureName:      captureName,
+				CaptureNamespace: workloadNamespace,
+				state:            state,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		&types.StepWrapper{
+			Step: &CreateArtifactReader{
+				ReaderNamespace: workloadNamespace,
+				HostPath:        hostPath,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		&types.StepWrapper{
+			Step: &ValidateCaptureArtifacts{
+				CaptureName:      captureName,
+				CaptureNamespace: workloadNamespace,
+				ReaderNamespace:  workloadNamespace,
+				ArtifactDir:      path.Join(hostPath, captureName),
+				onlyIfComplete:   state,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		&types.StepWrapper{
+			Step: &kubernetes.DeleteKubernetesResource{
+				ResourceType:      kubernetes.TypeString(kubernetes.Namespace),
+				ResourceName:      workloadNamespace,
+				ResourceNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	)
+
+	return types.NewScenario(name, steps...).WithPreconditions(operatorRunning())
+}
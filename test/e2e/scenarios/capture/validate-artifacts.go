@@ -0,0 +1,140 @@
+package capture
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+
+	"github.com/microsoft/retina/pkg/label"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s "k8s.io/client-go/kubernetes"
+)
+
+var (
+	ErrNoCaptureJobs       = fmt.Errorf("no capture job pods found")
+	ErrNoArtifactReader    = fmt.Errorf("no capture artifact reader on node")
+	ErrMissingArtifact     = fmt.Errorf("capture artifact missing")
+	ErrUnexpectedArtifacts = fmt.Errorf("unexpected files among capture artifacts")
+)
+
+// ValidateCaptureArtifacts checks that on every node the capture ran on, its directory under the reader's
+// host path holds exactly one artifact, named for the capture and the node, and nothing written by anything else
+type ValidateCaptureArtifacts struct {
+	KubeConfigFilePath string
+	CaptureName        string
+	CaptureNamespace   string
+	ReaderNamespace    string
+	ArtifactDir        string
+
+	// onlyIfComplete skips the check when set and the capture failed, leaving no artifacts to expect
+	onlyIfComplete *terminalState
+
+	// collectDir is where the artifacts are copied to for CI, when the job has an artifacts directory
+	collectDir string
+}
+
+func (v *ValidateCaptureArtifacts) SetArtifactDir(dir string) {
+	v.collectDir = dir
+}
+
+func (v *ValidateCaptureArtifacts) Run() error {
+	if v.onlyIfComplete != nil && !v.onlyIfComplete.complete {
+		log.Printf("capture \"%s\" did not complete, not checking its artifacts\n", v.CaptureName)
+		return nil
+	}
+
+	config, err := kubernetes.BuildConfig(v.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := k8s.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx := context.Background()
+	jobPods, err := clientset.CoreV1().Pods(v.CaptureNamespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", label.CaptureNameLabel, v.CaptureName),
+	})
+	if err != nil {
+		return fmt.Errorf("error listing job pods of capture \"%s\": %w", v.CaptureName, err)
+	}
+	if len(jobPods.Items) == 0 {
+		return fmt.Errorf("capture \"%s\": %w", v.CaptureName, ErrNoCaptureJobs)
+	}
+
+	for i := range jobPods.Items {
+		nodeName := jobPods.Items[i].Spec.NodeName
+		readers, listErr := clientset.CoreV1().Pods(v.ReaderNamespace).List(ctx, metav1.ListOptions{
+			LabelSelector: "app=" + artifactReaderName,
+			FieldSelector: "spec.nodeName=" + nodeName,
+		})
+		if listErr != nil {
+			return fmt.Errorf("error listing capture artifact readers on node \"%s\": %w", nodeName, listErr)
+		}
+		if len(readers.Items) == 0 {
+			return fmt.Errorf("node \"%s\": %w", nodeName, ErrNoArtifactReader)
+		}
+
+		output, execErr := kubernetes.ExecPod(ctx, clientset, config, v.ReaderNamespace, readers.Items[0].Name, "ls -1 "+v.ArtifactDir)
+		if execErr != nil {
+			return fmt.Errorf("error listing %s on node \"%s\": %s: %w", v.ArtifactDir, nodeName, string(output), execErr)
+		}
+
+		artifact, checkErr := v.checkArtifacts(nodeName, strings.Fields(string(output)))
+		if checkErr != nil {
+			return checkErr
+		}
+
+		if v.collectDir != "" {
+			data, catErr := kubernetes.ExecPod(ctx, clientset, config, v.ReaderNamespace, readers.Items[0].Name, "cat "+path.Join(v.ArtifactDir, artifact))
+			if catErr != nil {
+				return fmt.Errorf("error reading artifact %s on node \"%s\": %w", artifact, nodeName, catErr)
+			}
+			err = types.WriteArtifact(v.collectDir, artifact, data)
+			if err != nil {
+				return err //nolint:wrapcheck // already names the artifact
+			}
+		}
+	}
+	return nil
+}
+
+func (v *ValidateCaptureArtifacts) checkArtifacts(nodeName string, files []string) (string, error) {
+	// artifacts are named <capture>-<node>-<timestamp>.tar.gz, see pkg/capture/provider
+	prefix := v.CaptureName + "-" + nodeName + "-"
+
+	artifacts := []string{}
+	unexpected := []string{}
+	for _, file := range files {
+		if strings.HasPrefix(file, prefix) && strings.HasSuffix(file, ".tar.gz") {
+			artifacts = append(artifacts, file)
+		} else {
+			unexpected = append(unexpected, file)
+		}
+	}
+
+	if len(artifacts) == 0 {
+		return "", fmt.Errorf("no artifact of capture \"%s\" in %s on node \"%s\": %w", v.CaptureName, v.ArtifactDir, nodeName, ErrMissingArtifact)
+	}
+	if len(artifacts) > 1 || len(unexpected) > 0 {
+		return "", fmt.Errorf("%s on node \"%s\" holds %v besides the artifact of capture \"%s\": %w",
+			v.ArtifactDir, nodeName, append(artifacts[1:], unexpected...), v.CaptureName, ErrUnexpectedArtifacts)
+	}
+
+	log.Printf("found artifact %s of capture \"%s\" on node \"%s\"\n", path.Join(v.ArtifactDir, artifacts[0]), v.CaptureName, nodeName)
+	return artifacts[0], nil
+}
+
+func (v *ValidateCaptureArtifacts) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateCaptureArtifacts) Stop() error {
+	return nil
+}
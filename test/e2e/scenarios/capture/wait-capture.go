@@ -0,0 +1,112 @@
+package capture
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	retinav1alpha1 "github.com/microsoft/retina/crd/api/v1alpha1"
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/retry"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	captureRetryAttempts = 36
+	captureRetryDelay    = 5 * time.Second
+)
+
+var (
+	ErrCaptureNotComplete = fmt.Errorf("capture not complete yet")
+	ErrCaptureFailed      = fmt.Errorf("capture failed")
+)
+
+// WaitForCaptureComplete waits until the operator marks the capture complete with every one of its jobs succeeded
+type WaitForCaptureComplete struct {
+	KubeConfigFilePath string
+	CaptureName        string
+	CaptureNamespace   string
+}
+
+func (w *WaitForCaptureComplete) Run() error {
+	config, err := k8s.BuildConfig(w.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating dynamic client: %w", err)
+	}
+
+	checkFn := func() error {
+		capture, getErr := getCapture(client, w.CaptureNamespace, w.CaptureName)
+		if getErr != nil {
+			return getErr
+		}
+
+		complete, failure := captureOutcome(capture)
+		if failure != nil {
+			return fmt.Errorf("capture \"%s\": %w", w.CaptureName, failure)
+		}
+		if complete {
+			log.Printf("capture \"%s\" completed with %d jobs\n", w.CaptureName, capture.Status.Succeeded)
+			return nil
+		}
+
+		log.Printf("capture \"%s\" has %d active and %d succeeded jobs, waiting for it to complete\n", w.CaptureName, capture.Status.Active, capture.Status.Succeeded)
+		return ErrCaptureNotComplete
+	}
+
+	retrier := retry.Retrier{Attempts: captureRetryAttempts, Delay: captureRetryDelay}
+	err = retrier.Do(context.Background(), checkFn)
+	if err != nil {
+		return fmt.Errorf("failed waiting for capture \"%s\": %w", w.CaptureName, err)
+	}
+	return nil
+}
+
+func getCapture(client dynamic.Interface, namespace, name string) (*retinav1alpha1.Capture, error) {
+	object, err := client.Resource(captureResource).Namespace(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error getting capture \"%s\": %w", name, err)
+	}
+
+	capture := &retinav1alpha1.Capture{}
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(object.Object, capture)
+	if err != nil {
+		return nil, fmt.Errorf("error converting capture \"%s\": %w", name, err)
+	}
+	return capture, nil
+}
+
+// captureOutcome reports whether the operator marked the capture complete, or why it failed
+func captureOutcome(capture *retinav1alpha1.Capture) (bool, error) {
+	status := capture.Status
+	for _, condition := range status.Conditions {
+		if condition.Type == string(retinav1alpha1.CaptureError) && condition.Status == metav1.ConditionTrue {
+			return false, fmt.Errorf("%s: %w", condition.Message, ErrCaptureFailed)
+		}
+	}
+	if status.Failed > 0 {
+		return false, fmt.Errorf("%d failed jobs: %w", status.Failed, ErrCaptureFailed)
+	}
+
+	for _, condition := range status.Conditions {
+		if condition.Type == string(retinav1alpha1.CaptureComplete) && condition.Status == metav1.ConditionTrue {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (w *WaitForCaptureComplete) Prevalidate() error {
+	return nil
+}
+
+func (w *WaitForCaptureComplete) Stop() error {
+	return nil
+}
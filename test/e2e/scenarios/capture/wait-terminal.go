@@ -0,0 +1,77 @@
+package capture
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/retry"
+	"k8s.io/client-go/dynamic"
+)
+
+var ErrCaptureNotTerminal = fmt.Errorf("capture neither complete nor failed")
+
+// terminalState carries how a capture ended to the steps that only apply to complete captures
+type terminalState struct {
+	complete bool
+}
+
+// WaitForCaptureTerminal waits until the capture is either complete or failed, and only fails itself if the
+// capture is still running when it gives up, as a capture disrupted by a restart may end either way
+type WaitForCaptureTerminal struct {
+	KubeConfigFilePath string
+	CaptureName        string
+	CaptureNamespace   string
+
+	state *terminalState
+}
+
+func (w *WaitForCaptureTerminal) Run() error {
+	config, err := k8s.BuildConfig(w.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating dynamic client: %w", err)
+	}
+
+	checkFn := func() error {
+		capture, getErr := getCapture(client, w.CaptureNamespace, w.CaptureName)
+		if getErr != nil {
+			return getErr
+		}
+
+		complete, failure := captureOutcome(capture)
+		if failure != nil {
+			log.Printf("capture \"%s\" failed cleanly: %v\n", w.CaptureName, failure)
+			w.state.complete = false
+			return nil
+		}
+		if complete {
+			log.Printf("capture \"%s\" completed with %d jobs\n", w.CaptureName, capture.Status.Succeeded)
+			w.state.complete = true
+			return nil
+		}
+
+		log.Printf("capture \"%s\" has %d active and %d succeeded jobs, waiting for it to end\n", w.CaptureName, capture.Status.Active, capture.Status.Succeeded)
+		return ErrCaptureNotTerminal
+	}
+
+	retrier := retry.Retrier{Attempts: captureRetryAttempts, Delay: captureRetryDelay}
+	err = retrier.Do(context.Background(), checkFn)
+	if err != nil {
+		return fmt.Errorf("capture \"%s\" hung: %w", w.CaptureName, err)
+	}
+	return nil
+}
+
+func (w *WaitForCaptureTerminal) Prevalidate() error {
+	return nil
+}
+
+func (w *WaitForCaptureTerminal) Stop() error {
+	return nil
+}
@@ -0,0 +1,126 @@
+package cardinality
+
+import (
+	"strconv"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+)
+
+const (
+	workloadNamespace = "retina-cardinality"
+
+	// enough pods that each agent observes several, without needing a large cluster
+	serverReplicas = 20
+
+	// local context keeps one series per direction for a pod
+	maxSeriesPerPod = 2
+)
+
+// ValidateHighCardinalityMetrics has a client talk to many server pods, each of which adds series of its own
+// to the agent of its node, and validates the agent keeps the series of each pod bounded and stays up.
+// The agent has no series cap of its own, nor a metric reporting one, so there's no cap to push past and
+// see enforced: local context bounds series by the pods observed instead, which is what this checks
+func ValidateHighCardinalityMetrics() *types.Scenario {
+	name := "High Cardinality Flow Metrics"
+	clientName := "agnhost-cardinality-client"
+	serverName := "agnhost-cardinality-server"
+
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.CreateNamespace{
+				NamespaceName: workloadNamespace,
+				Observe:       true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: types.NewParallel(
+				&types.StepWrapper{
+					Step: &kubernetes.CreateAgnhostStatefulSet{
+						AgnhostName:      serverName,
+						AgnhostNamespace: workloadNamespace,
+						Replicas:         serverReplicas,
+					},
+					Opts: &types.StepOptions{
+						SkipSavingParametersToJob: true,
+					},
+				},
+				&types.StepWrapper{
+					Step: &kubernetes.CreateAgnhostStatefulSet{
+						AgnhostName:      clientName,
+						AgnhostNamespace: workloadNamespace,
+					},
+					Opts: &types.StepOptions{
+						SkipSavingParametersToJob: true,
+					},
+				},
+			),
+		},
+		{
+			Step: &kubernetes.PortForward{
+				Namespace:                          "kube-system",
+				LabelSelector:                      "k8s-app=retina",
+				LocalPort:                          strconv.Itoa(common.RetinaPort),
+				RemotePort:                         strconv.Itoa(common.RetinaPort),
+				Endpoint:                           "metrics",
+				OptionalLabelAffinity:              "app=" + serverName,
+				OptionalLabelAffinityAllNamespaces: true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "cardinality-port-forward",
+			},
+		},
+		{
+			Step: &SendRequestsToEachPod{
+				PodNamespace:        workloadNamespace,
+				ClientPodName:       clientName + "-0",
+				ServerLabelSelector: "app=" + serverName,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &ValidatePodSeriesBound{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				NamespaceName:           workloadNamespace,
+				MaxSeriesPerPod:         maxSeriesPerPod,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &types.Stop{
+				BackgroundID: "cardinality-port-forward",
+			},
+		},
+		{
+			Step: &kubernetes.EnsureNoRestarts{
+				PodNamespace:  "kube-system",
+				LabelSelector: "k8s-app=retina",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		// deleting the namespace removes both workloads with it
+		{
+			Step: &kubernetes.DeleteKubernetesResource{
+				ResourceType:      kubernetes.TypeString(kubernetes.Namespace),
+				ResourceName:      workloadNamespace,
+				ResourceNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	return types.NewScenario(name, steps...)
+}
@@ -0,0 +1,68 @@
+package cardinality
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const defaultTimeout = 10 * time.Minute
+
+var ErrNoServerPods = fmt.Errorf("no server pods found")
+
+// SendRequestsToEachPod makes an HTTP request from the client pod to every pod matching ServerLabelSelector,
+// so each of them gets series of its own on the agent of its node. Every request must be answered
+type SendRequestsToEachPod struct {
+	KubeConfigFilePath  string
+	PodNamespace        string
+	ClientPodName       string
+	ServerLabelSelector string
+}
+
+func (s *SendRequestsToEachPod) Run() error {
+	config, err := k8s.BuildConfig(s.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	servers, err := clientset.CoreV1().Pods(s.PodNamespace).List(ctx, metav1.ListOptions{LabelSelector: s.ServerLabelSelector})
+	if err != nil {
+		return fmt.Errorf("error listing server pods: %w", err)
+	}
+	if len(servers.Items) == 0 {
+		return fmt.Errorf("label \"%s\" in namespace \"%s\": %w", s.ServerLabelSelector, s.PodNamespace, ErrNoServerPods)
+	}
+
+	for i := range servers.Items {
+		server := &servers.Items[i]
+		request := fmt.Sprintf("curl -s -m 3 -o /dev/null http://%s:%d", server.Status.PodIP, k8s.AgnhostHTTPPort)
+		_, err = k8s.ExecPod(ctx, clientset, config, s.PodNamespace, s.ClientPodName, request)
+		if err != nil {
+			return fmt.Errorf("request from %s to %s: %w", s.ClientPodName, server.Name, err)
+		}
+	}
+
+	log.Printf("sent a request from %s to each of %d pods\n", s.ClientPodName, len(servers.Items))
+	return nil
+}
+
+func (s *SendRequestsToEachPod) Prevalidate() error {
+	return nil
+}
+
+func (s *SendRequestsToEachPod) Stop() error {
+	return nil
+}
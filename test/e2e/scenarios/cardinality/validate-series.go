@@ -0,0 +1,89 @@
+package cardinality
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/microsoft/retina/test/retry"
+)
+
+const (
+	advForwardCountMetricName = "networkobservability_adv_forward_count"
+
+	metricRetryAttempts = 20
+	metricRetryDelay    = 5 * time.Second
+)
+
+var (
+	ErrNoPodSeries         = fmt.Errorf("no series attributed to pods of the namespace")
+	ErrCardinalityExceeded = fmt.Errorf("pod has more series than its bound")
+	ErrUnattributedSeries  = fmt.Errorf("series in the namespace names no pod")
+)
+
+// ValidatePodSeriesBound checks the agent behind PortForwardedRetinaPort keeps at most MaxSeriesPerPod series of
+// the forward count for each pod of the namespace it recorded, however many peers the pod talked to, so the
+// series it exports grow with the pods it observes and not with their traffic
+type ValidatePodSeriesBound struct {
+	PortForwardedRetinaPort string
+	NamespaceName           string
+	MaxSeriesPerPod         int
+}
+
+func (v *ValidatePodSeriesBound) Run() error {
+	promAddress := fmt.Sprintf("http://localhost:%s/metrics", v.PortForwardedRetinaPort)
+
+	perPod := map[string]int{}
+	checkFn := func() error {
+		series, err := prom.GetMetricsMatchingLabels(promAddress, advForwardCountMetricName, map[string]string{"namespace": v.NamespaceName})
+		if err != nil {
+			return fmt.Errorf("failed to scrape %s: %w", advForwardCountMetricName, err)
+		}
+		if len(series) == 0 {
+			log.Printf("no %s series in namespace %s yet\n", advForwardCountMetricName, v.NamespaceName)
+			return ErrNoPodSeries
+		}
+
+		perPod = map[string]int{}
+		for _, metric := range series {
+			podName := ""
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "podname" {
+					podName = label.GetValue()
+				}
+			}
+			perPod[podName]++
+		}
+		return nil
+	}
+
+	retrier := retry.Retrier{Attempts: metricRetryAttempts, Delay: metricRetryDelay}
+	err := retrier.Do(context.Background(), checkFn)
+	if err != nil {
+		return fmt.Errorf("failed to verify prometheus metrics %s: %w", advForwardCountMetricName, err)
+	}
+
+	total := 0
+	for podName, count := range perPod {
+		if podName == "" {
+			return fmt.Errorf("%d series in namespace %s: %w", count, v.NamespaceName, ErrUnattributedSeries)
+		}
+		if count > v.MaxSeriesPerPod {
+			return fmt.Errorf("%s/%s has %d series, more than %d: %w", v.NamespaceName, podName, count, v.MaxSeriesPerPod, ErrCardinalityExceeded)
+		}
+		total += count
+	}
+
+	log.Printf("%d series of %s for %d pods in namespace %s, at most %d each\n", total, advForwardCountMetricName, len(perPod), v.NamespaceName, v.MaxSeriesPerPod)
+	return nil
+}
+
+func (v *ValidatePodSeriesBound) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidatePodSeriesBound) Stop() error {
+	return nil
+}
@@ -0,0 +1,95 @@
+package cgroupdriver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	SystemdDriver  = "systemd"
+	CgroupfsDriver = "cgroupfs"
+
+	defaultTimeout = 2 * time.Minute
+)
+
+var ErrUnexpectedCgroupDriver = fmt.Errorf("unexpected kubelet cgroup driver")
+
+// kubeletConfigz is the part of the kubelet's /configz response naming its cgroup driver
+type kubeletConfigz struct {
+	KubeletConfig struct {
+		CgroupDriver string `json:"cgroupDriver"`
+	} `json:"kubeletconfig"`
+}
+
+// DetectCgroupDriver reads the cgroup driver of the kubelet running PodName from its /configz endpoint, through
+// the API server's node proxy, and fails unless it's one of CgroupDrivers. This gates the scenario to clusters
+// whose nodes run one of the drivers it's meant for
+type DetectCgroupDriver struct {
+	KubeConfigFilePath string
+	PodNamespace       string
+	PodName            string
+	CgroupDrivers      []string
+}
+
+func (d *DetectCgroupDriver) Run() error {
+	config, err := k8s.BuildConfig(d.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	pod, err := clientset.CoreV1().Pods(d.PodNamespace).Get(ctx, d.PodName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting pod \"%s\": %w", d.PodName, err)
+	}
+
+	raw, err := clientset.CoreV1().RESTClient().Get().
+		AbsPath("/api/v1/nodes", pod.Spec.NodeName, "proxy", "configz").
+		DoRaw(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting kubelet config of node %s: %w", pod.Spec.NodeName, err)
+	}
+
+	driver, err := parseCgroupDriver(raw)
+	if err != nil {
+		return fmt.Errorf("error parsing kubelet config of node %s: %w", pod.Spec.NodeName, err)
+	}
+	if !slices.Contains(d.CgroupDrivers, driver) {
+		return fmt.Errorf("kubelet on node %s uses cgroup driver \"%s\", expected one of %v: %w", pod.Spec.NodeName, driver, d.CgroupDrivers, ErrUnexpectedCgroupDriver)
+	}
+
+	log.Printf("kubelet on node %s of pod %s uses cgroup driver \"%s\"\n", pod.Spec.NodeName, d.PodName, driver)
+	return nil
+}
+
+func parseCgroupDriver(raw []byte) (string, error) {
+	var configz kubeletConfigz
+	err := json.Unmarshal(raw, &configz)
+	if err != nil {
+		return "", fmt.Errorf("error unmarshalling configz: %w", err)
+	}
+	return configz.KubeletConfig.CgroupDriver, nil
+}
+
+func (d *DetectCgroupDriver) Prevalidate() error {
+	return nil
+}
+
+func (d *DetectCgroupDriver) Stop() error {
+	return nil
+}
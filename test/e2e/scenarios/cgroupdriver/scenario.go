@@ -0,0 +1,168 @@
+package cgroupdriver
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+	"github.com/microsoft/retina/test/e2e/scenarios/crossnamespace"
+	"github.com/microsoft/retina/test/e2e/scenarios/policyflip"
+)
+
+const (
+	workloadNamespace = "retina-cgroup-driver"
+
+	requests = 5
+
+	// lets the flows of the pods' setup reach the metrics before traffic is sent
+	settleDelay = 15 * time.Second
+)
+
+// ValidateCgroupDriverAttribution detects the cgroup driver of the kubelets running a client and a server pod,
+// sends requests between them and validates each end of the flow is attributed to its pod: egress for the client,
+// ingress for the server. The agent attributes flows by IP rather than cgroup, so the same holds under either driver.
+//
+// The scenario fails when the nodes use a driver outside cgroupDrivers, which defaults to both drivers;
+// run the job on a cluster per driver, or narrow it, to cover each.
+func ValidateCgroupDriverAttribution(cgroupDrivers ...string) *types.Scenario {
+	name := "Cgroup Driver Attribution"
+	clientName := "agnhost-cgroup-client"
+	serverName := "agnhost-cgroup-server"
+	if len(cgroupDrivers) == 0 {
+		cgroupDrivers = []string{SystemdDriver, CgroupfsDriver}
+	}
+
+	// the server's metrics are read through a second port forward, to the agent on the server's node
+	serverNodeLocalPort := common.RetinaPort + 2
+
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.CreateNamespace{
+				NamespaceName: workloadNamespace,
+				Observe:       true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	for _, agnhostName := range []string{clientName, serverName} {
+		steps = append(steps,
+			&types.StepWrapper{
+				Step: &kubernetes.CreateAgnhostStatefulSet{
+					AgnhostName:      agnhostName,
+					AgnhostNamespace: workloadNamespace,
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+				},
+			},
+			&types.StepWrapper{
+				Step: &DetectCgroupDriver{
+					PodNamespace:  workloadNamespace,
+					PodName:       agnhostName + "-0",
+					CgroupDrivers: cgroupDrivers,
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+				},
+			},
+		)
+	}
+
+	steps = append(steps,
+		&types.StepWrapper{
+			Step: &kubernetes.PortForward{
+				Namespace:                          "kube-system",
+				LabelSelector:                      "k8s-app=retina",
+				LocalPort:                          strconv.Itoa(common.RetinaPort),
+				RemotePort:                         strconv.Itoa(common.RetinaPort),
+				Endpoint:                           "metrics",
+				OptionalLabelAffinity:              "app=" + clientName,
+				OptionalLabelAffinityAllNamespaces: true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "cgroup-client-port-forward",
+			},
+		},
+		&types.StepWrapper{
+			Step: &kubernetes.PortForward{
+				Namespace:                          "kube-system",
+				LabelSelector:                      "k8s-app=retina",
+				LocalPort:                          strconv.Itoa(serverNodeLocalPort),
+				RemotePort:                         strconv.Itoa(common.RetinaPort),
+				Endpoint:                           "metrics",
+				OptionalLabelAffinity:              "app=" + serverName,
+				OptionalLabelAffinityAllNamespaces: true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "cgroup-server-port-forward",
+			},
+		},
+		&types.StepWrapper{
+			Step: &types.Sleep{
+				Duration: settleDelay,
+			},
+		},
+		&types.StepWrapper{
+			Step: &policyflip.SendRequests{
+				PodNamespace:  workloadNamespace,
+				ClientPodName: clientName + "-0",
+				ServerPodName: serverName + "-0",
+				Requests:      requests,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		&types.StepWrapper{
+			Step: &crossnamespace.ValidateNamespacedFlowMetric{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				Direction:               "egress",
+				NamespaceName:           workloadNamespace,
+				PodName:                 clientName + "-0",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		&types.StepWrapper{
+			Step: &crossnamespace.ValidateNamespacedFlowMetric{
+				PortForwardedRetinaPort: strconv.Itoa(serverNodeLocalPort),
+				Direction:               "ingress",
+				NamespaceName:           workloadNamespace,
+				PodName:                 serverName + "-0",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		&types.StepWrapper{
+			Step: &types.Stop{
+				BackgroundID: "cgroup-server-port-forward",
+			},
+		},
+		&types.StepWrapper{
+			Step: &types.Stop{
+				BackgroundID: "cgroup-client-port-forward",
+			},
+		},
+		&types.StepWrapper{
+			Step: &kubernetes.DeleteKubernetesResource{
+				ResourceType:      kubernetes.TypeString(kubernetes.Namespace),
+				ResourceName:      workloadNamespace,
+				ResourceNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	)
+
+	return types.NewScenario(name, steps...)
+}
@@ -0,0 +1,170 @@
+package ciliumidentity
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+)
+
+const (
+	workloadNamespace = "retina-cilium-identity"
+
+	// Cilium's default hubble metrics port
+	hubbleMetricsPort = 9965
+
+	// a label Cilium doesn't exclude from identities, so changing it changes the pod's identity
+	identityLabelKey = "retina-e2e-identity"
+
+	sleepDelay = 5 * time.Second
+)
+
+// ValidateIdentityChangeFlowMetrics relabels a server pod so Cilium gives it a new security identity, and
+// validates Hubble flow metrics for traffic to it are labeled with the identity before and after the change.
+//
+// Cilium only: it expects Cilium agents labeled k8s-app=cilium exporting Hubble metrics with
+// flow:destinationContext=identity, and is skipped on clusters without them.
+func ValidateIdentityChangeFlowMetrics() *types.Scenario {
+	name := "Cilium Identity Change Flow Metrics"
+	clientName := "agnhost-identity-client"
+	serverName := "agnhost-identity-server"
+	serviceName := "identity-server"
+
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.CreateNamespace{
+				NamespaceName: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      serverName,
+				AgnhostNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostService{
+				ServiceName:      serviceName,
+				ServiceNamespace: workloadNamespace,
+				AgnhostName:      serverName,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      clientName,
+				AgnhostNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.PortForward{
+				Namespace:                          "kube-system",
+				LabelSelector:                      "k8s-app=cilium",
+				LocalPort:                          strconv.Itoa(hubbleMetricsPort),
+				RemotePort:                         strconv.Itoa(hubbleMetricsPort),
+				Endpoint:                           "metrics",
+				OptionalLabelAffinity:              "app=" + serverName,
+				OptionalLabelAffinityAllNamespaces: true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "cilium-identity-port-forward",
+			},
+		},
+	}
+
+	for _, identity := range []string{"before", "after"} {
+		steps = append(steps,
+			&types.StepWrapper{
+				Step: &kubernetes.LabelPod{
+					PodNamespace: workloadNamespace,
+					PodName:      serverName + "-0",
+					Labels:       map[string]string{identityLabelKey: identity},
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+				},
+			},
+			&types.StepWrapper{
+				Step: &WaitForCiliumIdentityLabel{
+					PodNamespace: workloadNamespace,
+					PodName:      serverName + "-0",
+					LabelKey:     identityLabelKey,
+					LabelValue:   identity,
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+				},
+			},
+			&types.StepWrapper{
+				Step: &kubernetes.ExecInPod{
+					PodName:      clientName + "-0",
+					PodNamespace: workloadNamespace,
+					Command:      fmt.Sprintf("curl -s -m 5 http://%s.%s.svc.cluster.local:%d", serviceName, workloadNamespace, kubernetes.AgnhostHTTPPort),
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+				},
+			},
+			&types.StepWrapper{
+				Step: &types.Sleep{
+					Duration: sleepDelay,
+				},
+			},
+			&types.StepWrapper{
+				Step: &ValidateIdentityFlowLabel{
+					PortForwardedHubblePort: strconv.Itoa(hubbleMetricsPort),
+					LabelKey:                identityLabelKey,
+					LabelValue:              identity,
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+				},
+			},
+		)
+	}
+
+	steps = append(steps,
+		&types.StepWrapper{
+			Step: &types.Stop{
+				BackgroundID: "cilium-identity-port-forward",
+			},
+		},
+		// deleting the namespace removes the workloads and the service with it
+		&types.StepWrapper{
+			Step: &kubernetes.DeleteKubernetesResource{
+				ResourceType:      kubernetes.TypeString(kubernetes.Namespace),
+				ResourceName:      workloadNamespace,
+				ResourceNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	)
+
+	return types.NewScenario(name, steps...).WithPreconditions(
+		&types.StepWrapper{
+			Step: &kubernetes.SkipUnlessPods{
+				PodNamespace:  "kube-system",
+				LabelSelector: "k8s-app=cilium",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	)
+}
@@ -0,0 +1,79 @@
+package ciliumidentity
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/microsoft/retina/test/retry"
+)
+
+const (
+	hubbleFlowsMetricName = "hubble_flows_processed_total"
+
+	metricRetryAttempts = 20
+	metricRetryDelay    = 5 * time.Second
+)
+
+var ErrNoIdentityFlow = fmt.Errorf("no flow series labeled with the identity")
+
+// ValidateIdentityFlowLabel checks the Hubble flow metric has a series whose destination identity carries
+// LabelKey=LabelValue, so flows to the pod are labeled under its current identity
+type ValidateIdentityFlowLabel struct {
+	PortForwardedHubblePort string
+	LabelKey                string
+	LabelValue              string
+}
+
+func (v *ValidateIdentityFlowLabel) Run() error {
+	promAddress := fmt.Sprintf("http://localhost:%s/metrics", v.PortForwardedHubblePort)
+	want := identityLabel(v.LabelKey, v.LabelValue)
+
+	checkFn := func() error {
+		series, err := prom.GetMetricsMatchingLabels(promAddress, hubbleFlowsMetricName, map[string]string{})
+		if err != nil {
+			return fmt.Errorf("failed to scrape %s: %w", hubbleFlowsMetricName, err)
+		}
+
+		for _, metric := range series {
+			for _, label := range metric.GetLabel() {
+				// the identity context lists every identity label, comma separated
+				if label.GetName() == "destination" && containsLabel(label.GetValue(), want) {
+					return nil
+				}
+			}
+		}
+
+		log.Printf("no %s series with destination identity \"%s\" yet\n", hubbleFlowsMetricName, want)
+		return ErrNoIdentityFlow
+	}
+
+	retrier := retry.Retrier{Attempts: metricRetryAttempts, Delay: metricRetryDelay}
+	err := retrier.Do(context.Background(), checkFn)
+	if err != nil {
+		return fmt.Errorf("failed to verify prometheus metrics %s: %w", hubbleFlowsMetricName, err)
+	}
+
+	log.Printf("found %s series with destination identity \"%s\"\n", hubbleFlowsMetricName, want)
+	return nil
+}
+
+func containsLabel(identity, label string) bool {
+	for _, l := range strings.Split(identity, ",") {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *ValidateIdentityFlowLabel) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateIdentityFlowLabel) Stop() error {
+	return nil
+}
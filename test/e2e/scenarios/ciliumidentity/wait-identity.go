@@ -0,0 +1,89 @@
+package ciliumidentity
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/retry"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	identityRetryAttempts = 24
+	identityRetryDelay    = 5 * time.Second
+)
+
+var (
+	ErrIdentityNotUpdated = fmt.Errorf("cilium identity does not carry the label yet")
+
+	ciliumEndpointResource = schema.GroupVersionResource{Group: "cilium.io", Version: "v2", Resource: "ciliumendpoints"}
+)
+
+// WaitForCiliumIdentityLabel waits until the pod's CiliumEndpoint has been given a security identity whose
+// labels include LabelKey=LabelValue, i.e. until Cilium has reacted to the pod's label change
+type WaitForCiliumIdentityLabel struct {
+	KubeConfigFilePath string
+	PodNamespace       string
+	PodName            string
+	LabelKey           string
+	LabelValue         string
+}
+
+func (w *WaitForCiliumIdentityLabel) Run() error {
+	config, err := k8s.BuildConfig(w.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating dynamic client: %w", err)
+	}
+
+	// identity labels of pod labels are prefixed with their source
+	want := identityLabel(w.LabelKey, w.LabelValue)
+
+	checkFn := func() error {
+		endpoint, getErr := client.Resource(ciliumEndpointResource).Namespace(w.PodNamespace).Get(context.Background(), w.PodName, metav1.GetOptions{})
+		if getErr != nil {
+			return fmt.Errorf("error getting CiliumEndpoint \"%s\": %w", w.PodName, getErr)
+		}
+
+		id, _, _ := unstructured.NestedInt64(endpoint.Object, "status", "identity", "id")
+		labels, _, _ := unstructured.NestedStringSlice(endpoint.Object, "status", "identity", "labels")
+		for _, label := range labels {
+			if label == want {
+				log.Printf("pod \"%s\" has identity %d carrying \"%s\"\n", w.PodName, id, want)
+				return nil
+			}
+		}
+
+		log.Printf("identity %d of pod \"%s\" has labels %v, waiting for \"%s\"\n", id, w.PodName, labels, want)
+		return ErrIdentityNotUpdated
+	}
+
+	retrier := retry.Retrier{Attempts: identityRetryAttempts, Delay: identityRetryDelay}
+	err = retrier.Do(context.Background(), checkFn)
+	if err != nil {
+		return fmt.Errorf("failed waiting for identity of pod \"%s\": %w", w.PodName, err)
+	}
+	return nil
+}
+
+func identityLabel(key, value string) string {
+	return fmt.Sprintf("k8s:%s=%s", key, value)
+}
+
+func (w *WaitForCiliumIdentityLabel) Prevalidate() error {
+	return nil
+}
+
+func (w *WaitForCiliumIdentityLabel) Stop() error {
+	return nil
+}
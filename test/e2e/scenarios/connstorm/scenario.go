@@ -0,0 +1,141 @@
+package connstorm
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+)
+
+const (
+	workloadNamespace = "retina-connection-storm"
+
+	stormConnections = 5000
+	stormParallel    = 50
+
+	// lets the flows of the pods' setup reach the metrics before the baseline is read
+	settleDelay = 15 * time.Second
+)
+
+// ValidateConnectionStormMetrics opens thousands of short lived connections from a client pod to a server pod
+// and validates the client's SYN count grows by the number of connections, and that the conntrack table
+// of the agent on the client's node drains back to its size before the storm once the connections expire.
+func ValidateConnectionStormMetrics() *types.Scenario {
+	name := "Connection Storm Metrics"
+	clientName := "agnhost-storm-client"
+	serverName := "agnhost-storm-server"
+	baseline := &stormBaseline{}
+
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.CreateNamespace{
+				NamespaceName: workloadNamespace,
+				Observe:       true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      serverName,
+				AgnhostNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      clientName,
+				AgnhostNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.PortForward{
+				Namespace:                          "kube-system",
+				LabelSelector:                      "k8s-app=retina",
+				LocalPort:                          strconv.Itoa(common.RetinaPort),
+				RemotePort:                         strconv.Itoa(common.RetinaPort),
+				Endpoint:                           "metrics",
+				OptionalLabelAffinity:              "app=" + clientName,
+				OptionalLabelAffinityAllNamespaces: true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "connection-storm-port-forward",
+			},
+		},
+		{
+			Step: &types.Sleep{
+				Duration: settleDelay,
+			},
+		},
+		{
+			Step: &CaptureStormBaseline{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				PodName:                 clientName + "-0",
+				baseline:                baseline,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &SendConnectionStorm{
+				PodNamespace:         workloadNamespace,
+				PodName:              clientName + "-0",
+				DestinationNamespace: workloadNamespace,
+				DestinationPodName:   serverName + "-0",
+				Connections:          stormConnections,
+				Parallel:             stormParallel,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &ValidateStormFlowCount{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				PodName:                 clientName + "-0",
+				Connections:             stormConnections,
+				baseline:                baseline,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &ValidateConntrackDrained{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				Connections:             stormConnections,
+				baseline:                baseline,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &types.Stop{
+				BackgroundID: "connection-storm-port-forward",
+			},
+		},
+		{
+			Step: &kubernetes.DeleteKubernetesResource{
+				ResourceType:      kubernetes.TypeString(kubernetes.Namespace),
+				ResourceName:      workloadNamespace,
+				ResourceNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	return types.NewScenario(name, steps...)
+}
@@ -0,0 +1,89 @@
+package connstorm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	stormTimeout = 10 * time.Minute
+
+	// connections per curl, keeping each exec's command line a reasonable size
+	connectionsPerBatch = 500
+)
+
+var ErrStormIncomplete = fmt.Errorf("not every connection of the storm was answered")
+
+// SendConnectionStorm opens Connections short lived connections from the pod to the destination agnhost,
+// Parallel at a time. Every request asks the server to close its connection, so curl opens a new one for
+// each. The step fails unless every request is answered
+type SendConnectionStorm struct {
+	KubeConfigFilePath   string
+	PodNamespace         string
+	PodName              string
+	DestinationNamespace string
+	DestinationPodName   string
+	Connections          int
+	Parallel             int
+}
+
+func (s *SendConnectionStorm) Run() error {
+	config, err := k8s.BuildConfig(s.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), stormTimeout)
+	defer cancel()
+
+	destination, err := clientset.CoreV1().Pods(s.DestinationNamespace).Get(ctx, s.DestinationPodName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting destination pod \"%s\": %w", s.DestinationPodName, err)
+	}
+	url := fmt.Sprintf("http://%s:%d", destination.Status.PodIP, k8s.AgnhostHTTPPort)
+
+	answered := 0
+	for sent := 0; sent < s.Connections; sent += connectionsPerBatch {
+		batch := min(connectionsPerBatch, s.Connections-sent)
+		// every response is followed by a comma, as serve-hostname doesn't end the hostname with a newline
+		command := fmt.Sprintf("curl -s -m 10 -H Connection:close --parallel --parallel-max %d -w , %s",
+			s.Parallel, strings.TrimSpace(strings.Repeat(url+" ", batch)))
+		output, execErr := k8s.ExecPod(ctx, clientset, config, s.PodNamespace, s.PodName, command)
+		if execErr != nil {
+			return fmt.Errorf("error sending connection storm from pod \"%s\": %w", s.PodName, execErr)
+		}
+
+		for _, hostname := range strings.Split(string(output), ",") {
+			if strings.TrimSpace(hostname) == s.DestinationPodName {
+				answered++
+			}
+		}
+	}
+
+	if answered != s.Connections {
+		return fmt.Errorf("%d of %d connections answered: %w", answered, s.Connections, ErrStormIncomplete)
+	}
+
+	log.Printf("opened %d connections from pod %s to %s\n", s.Connections, s.PodName, url)
+	return nil
+}
+
+func (s *SendConnectionStorm) Prevalidate() error {
+	return nil
+}
+
+func (s *SendConnectionStorm) Stop() error {
+	return nil
+}
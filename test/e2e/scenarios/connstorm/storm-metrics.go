@@ -0,0 +1,191 @@
+package connstorm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/microsoft/retina/test/retry"
+)
+
+const (
+	advTCPFlagsCountMetricName = "networkobservability_adv_tcpflags_count"
+	conntrackEntriesMetricName = "controlplane_networkobservability_conntrack_entries"
+
+	// the flow count may be off by this percent of the connections, for the odd retried SYN
+	tolerancePercent = 5
+
+	metricRetryAttempts = 12
+	metricRetryDelay    = 5 * time.Second
+
+	// closed connections leave entries that only expire after the agent's TCP lifetime of 360s, and are
+	// collected every 15s after that, so the table is given a while to drain
+	drainRetryAttempts = 45
+	drainRetryDelay    = 10 * time.Second
+)
+
+var (
+	ErrBaselineNotCaptured = fmt.Errorf("connection storm baseline was not captured")
+	ErrInaccurateFlowCount = fmt.Errorf("SYN count outside tolerance of the connections opened")
+	ErrConntrackNotDrained = fmt.Errorf("conntrack entries of the storm were not collected")
+)
+
+// stormBaseline carries the counter values read before the storm to the steps validating them
+type stormBaseline struct {
+	syns             float64
+	conntrackEntries float64
+	captured         bool
+}
+
+func sumSeries(portForwardedRetinaPort, metricName string, labels map[string]string) (float64, error) {
+	promAddress := fmt.Sprintf("http://localhost:%s/metrics", portForwardedRetinaPort)
+	series, err := prom.GetMetricsMatchingLabels(promAddress, metricName, labels)
+	if err != nil {
+		return 0, fmt.Errorf("failed to scrape %s: %w", metricName, err)
+	}
+
+	total := 0.0
+	for _, metric := range series {
+		total += metric.GetGauge().GetValue() + metric.GetCounter().GetValue()
+	}
+	return total, nil
+}
+
+// sumSYNs adds up the SYNs the pod sent, one per connection it opened
+func sumSYNs(portForwardedRetinaPort, podName string) (float64, error) {
+	return sumSeries(portForwardedRetinaPort, advTCPFlagsCountMetricName, map[string]string{
+		"podname":   podName,
+		"flag":      "SYN",
+		"direction": "egress",
+	})
+}
+
+func conntrackEntries(portForwardedRetinaPort string) (float64, error) {
+	return sumSeries(portForwardedRetinaPort, conntrackEntriesMetricName, map[string]string{})
+}
+
+// CaptureStormBaseline records the pod's SYN count and the size of the conntrack table before the storm
+type CaptureStormBaseline struct {
+	PortForwardedRetinaPort string
+	PodName                 string
+
+	baseline *stormBaseline
+}
+
+func (c *CaptureStormBaseline) Run() error {
+	syns, err := sumSYNs(c.PortForwardedRetinaPort, c.PodName)
+	if err != nil {
+		return err
+	}
+
+	entries, err := conntrackEntries(c.PortForwardedRetinaPort)
+	if err != nil {
+		return err
+	}
+
+	c.baseline.syns = syns
+	c.baseline.conntrackEntries = entries
+	c.baseline.captured = true
+	log.Printf("pod %s sent %.0f SYNs before the storm, conntrack table holds %.0f entries\n", c.PodName, syns, entries)
+	return nil
+}
+
+func (c *CaptureStormBaseline) Prevalidate() error {
+	return nil
+}
+
+func (c *CaptureStormBaseline) Stop() error {
+	return nil
+}
+
+// ValidateStormFlowCount waits for the pod's egress SYN count to grow by the Connections it opened since the
+// baseline, within tolerancePercent
+type ValidateStormFlowCount struct {
+	PortForwardedRetinaPort string
+	PodName                 string
+	Connections             int
+
+	baseline *stormBaseline
+}
+
+func (v *ValidateStormFlowCount) Run() error {
+	if !v.baseline.captured {
+		return ErrBaselineNotCaptured
+	}
+
+	checkFn := func() error {
+		syns, err := sumSYNs(v.PortForwardedRetinaPort, v.PodName)
+		if err != nil {
+			return err
+		}
+
+		delta := int(syns - v.baseline.syns)
+		tolerated := v.Connections * tolerancePercent / 100
+		log.Printf("%s SYN count for pod %s grew by %d, expected %d±%d\n", advTCPFlagsCountMetricName, v.PodName, delta, v.Connections, tolerated)
+		if delta < v.Connections-tolerated || delta > v.Connections+tolerated {
+			return fmt.Errorf("counted %d SYNs for %d connections: %w", delta, v.Connections, ErrInaccurateFlowCount)
+		}
+		return nil
+	}
+
+	retrier := retry.Retrier{Attempts: metricRetryAttempts, Delay: metricRetryDelay}
+	err := retrier.Do(context.Background(), checkFn)
+	if err != nil {
+		return fmt.Errorf("failed to verify prometheus metrics %s: %w", advTCPFlagsCountMetricName, err)
+	}
+	return nil
+}
+
+func (v *ValidateStormFlowCount) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateStormFlowCount) Stop() error {
+	return nil
+}
+
+// ValidateConntrackDrained waits for the agent's conntrack table to shrink back to its baseline size, plus at most
+// tolerancePercent of the Connections of the storm, so closed connections don't leak entries
+type ValidateConntrackDrained struct {
+	PortForwardedRetinaPort string
+	Connections             int
+
+	baseline *stormBaseline
+}
+
+func (v *ValidateConntrackDrained) Run() error {
+	if !v.baseline.captured {
+		return ErrBaselineNotCaptured
+	}
+
+	bound := v.baseline.conntrackEntries + float64(v.Connections*tolerancePercent/100)
+	checkFn := func() error {
+		entries, err := conntrackEntries(v.PortForwardedRetinaPort)
+		if err != nil {
+			return err
+		}
+
+		log.Printf("conntrack table holds %.0f entries, waiting for at most %.0f\n", entries, bound)
+		if entries > bound {
+			return fmt.Errorf("%.0f entries left, %.0f allowed: %w", entries, bound, ErrConntrackNotDrained)
+		}
+		return nil
+	}
+
+	retrier := retry.Retrier{Attempts: drainRetryAttempts, Delay: drainRetryDelay}
+	err := retrier.Do(context.Background(), checkFn)
+	if err != nil {
+		return fmt.Errorf("failed to verify prometheus metrics %s: %w", conntrackEntriesMetricName, err)
+	}
+	return nil
+}
+
+func (v *ValidateConntrackDrained) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateConntrackDrained) Stop() error {
+	return nil
+}
@@ -0,0 +1,142 @@
+package containerrestart
+
+import (
+	"strconv"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+	"github.com/microsoft/retina/test/e2e/scenarios/policyflip"
+)
+
+const (
+	workloadNamespace = "retina-container-restart"
+	requestsPerPhase  = 5
+)
+
+// ValidateContainerRestartMetrics sends traffic from a client pod, restarts the client's container in place, which
+// keeps the pod and its IP, and validates the client's flow metrics keep counting under the same pod afterwards
+func ValidateContainerRestartMetrics() *types.Scenario {
+	name := "Container Restart Flow Metrics"
+	clientName := "agnhost-restart-client"
+	serverName := "agnhost-restart-server"
+	state := &continuityState{}
+
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.CreateNamespace{
+				NamespaceName: workloadNamespace,
+				Observe:       true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      serverName,
+				AgnhostNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			// netexec rather than serve-hostname, so the container can be asked to exit
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      clientName,
+				AgnhostNamespace: workloadNamespace,
+				Args:             []string{"netexec", "--http-port", strconv.Itoa(kubernetes.AgnhostHTTPPort)},
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.PortForward{
+				Namespace:                          "kube-system",
+				LabelSelector:                      "k8s-app=retina",
+				LocalPort:                          strconv.Itoa(common.RetinaPort),
+				RemotePort:                         strconv.Itoa(common.RetinaPort),
+				Endpoint:                           "metrics",
+				OptionalLabelAffinity:              "app=" + clientName,
+				OptionalLabelAffinityAllNamespaces: true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "container-restart-port-forward",
+			},
+		},
+		{
+			Step: &policyflip.SendRequests{
+				PodNamespace:  workloadNamespace,
+				ClientPodName: clientName + "-0",
+				ServerPodName: serverName + "-0",
+				Requests:      requestsPerPhase,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &CaptureEgressBaseline{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				NamespaceName:           workloadNamespace,
+				PodName:                 clientName + "-0",
+				state:                   state,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.RestartAgnhostContainer{
+				PodNamespace: workloadNamespace,
+				PodName:      clientName + "-0",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &policyflip.SendRequests{
+				PodNamespace:  workloadNamespace,
+				ClientPodName: clientName + "-0",
+				ServerPodName: serverName + "-0",
+				Requests:      requestsPerPhase,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &ValidateAttributionContinuity{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				NamespaceName:           workloadNamespace,
+				PodName:                 clientName + "-0",
+				state:                   state,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &types.Stop{
+				BackgroundID: "container-restart-port-forward",
+			},
+		},
+		// deleting the namespace removes both workloads with it
+		{
+			Step: &kubernetes.DeleteKubernetesResource{
+				ResourceType:      kubernetes.TypeString(kubernetes.Namespace),
+				ResourceName:      workloadNamespace,
+				ResourceNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	return types.NewScenario(name, steps...)
+}
@@ -0,0 +1,157 @@
+package containerrestart
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/microsoft/retina/test/retry"
+)
+
+const (
+	advForwardCountMetricName = "networkobservability_adv_forward_count"
+
+	metricRetryAttempts = 20
+	metricRetryDelay    = 5 * time.Second
+)
+
+var (
+	ErrNoEgressSeries       = fmt.Errorf("no egress series attributed to the pod")
+	ErrEgressCountStalled   = fmt.Errorf("egress count didn't grow after the container restart")
+	ErrAmbiguousAttribution = fmt.Errorf("pod IP attributed to another workload")
+)
+
+// continuityState carries the pod's egress count from before the restart to the check after it
+type continuityState struct {
+	egressBefore float64
+}
+
+// egressCount sums the pod's egress packet count across its series, failing if any series for the pod's IP
+// names another pod or namespace
+func egressCount(promAddress, podIP, namespace, podName string) (float64, error) {
+	series, err := prom.GetMetricsMatchingLabels(promAddress, advForwardCountMetricName, map[string]string{"ip": podIP})
+	if err != nil {
+		return 0, fmt.Errorf("failed to scrape %s: %w", advForwardCountMetricName, err)
+	}
+
+	total := 0.0
+	egress := 0
+	for _, metric := range series {
+		labels := map[string]string{}
+		for _, label := range metric.GetLabel() {
+			labels[label.GetName()] = label.GetValue()
+		}
+
+		if labels["namespace"] != namespace || labels["podname"] != podName {
+			return 0, fmt.Errorf("series for %s is attributed to %s/%s instead of %s/%s: %w",
+				podIP, labels["namespace"], labels["podname"], namespace, podName, ErrAmbiguousAttribution)
+		}
+		if labels["direction"] == "egress" {
+			total += metric.GetCounter().GetValue()
+			egress++
+		}
+	}
+
+	if egress == 0 {
+		return 0, ErrNoEgressSeries
+	}
+	return total, nil
+}
+
+// CaptureEgressBaseline waits for the pod's egress traffic to be attributed to it, and records its egress
+// count for ValidateAttributionContinuity to compare against after the container restarts
+type CaptureEgressBaseline struct {
+	PortForwardedRetinaPort string
+	KubeConfigFilePath      string
+	NamespaceName           string
+	PodName                 string
+
+	state *continuityState
+}
+
+func (c *CaptureEgressBaseline) Run() error {
+	podIP, err := k8s.GetPodIP(c.KubeConfigFilePath, c.NamespaceName, c.PodName)
+	if err != nil {
+		return fmt.Errorf("failed to get pod IP: %w", err)
+	}
+
+	promAddress := fmt.Sprintf("http://localhost:%s/metrics", c.PortForwardedRetinaPort)
+
+	retrier := retry.Retrier{Attempts: metricRetryAttempts, Delay: metricRetryDelay}
+	err = retrier.Do(context.Background(), func() error {
+		count, countErr := egressCount(promAddress, podIP, c.NamespaceName, c.PodName)
+		if countErr != nil {
+			log.Printf("no baseline for %s/%s (%s) yet: %v\n", c.NamespaceName, c.PodName, podIP, countErr)
+			return countErr
+		}
+		c.state.egressBefore = count
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to capture baseline of %s: %w", advForwardCountMetricName, err)
+	}
+
+	log.Printf("egress count of %s/%s before the container restart: %.0f\n", c.NamespaceName, c.PodName, c.state.egressBefore)
+	return nil
+}
+
+func (c *CaptureEgressBaseline) Prevalidate() error {
+	return nil
+}
+
+func (c *CaptureEgressBaseline) Stop() error {
+	return nil
+}
+
+// ValidateAttributionContinuity checks the pod's egress count kept growing past the baseline after its container
+// restarted in place, and that every series for its IP still names the pod, so the veth churn of the restart
+// neither lost the pod's traffic nor attributed it elsewhere
+type ValidateAttributionContinuity struct {
+	PortForwardedRetinaPort string
+	KubeConfigFilePath      string
+	NamespaceName           string
+	PodName                 string
+
+	state *continuityState
+}
+
+func (v *ValidateAttributionContinuity) Run() error {
+	podIP, err := k8s.GetPodIP(v.KubeConfigFilePath, v.NamespaceName, v.PodName)
+	if err != nil {
+		return fmt.Errorf("failed to get pod IP: %w", err)
+	}
+
+	promAddress := fmt.Sprintf("http://localhost:%s/metrics", v.PortForwardedRetinaPort)
+
+	var after float64
+	retrier := retry.Retrier{Attempts: metricRetryAttempts, Delay: metricRetryDelay}
+	err = retrier.Do(context.Background(), func() error {
+		count, countErr := egressCount(promAddress, podIP, v.NamespaceName, v.PodName)
+		if countErr != nil {
+			return countErr
+		}
+		if count <= v.state.egressBefore {
+			log.Printf("egress count of %s/%s still %.0f, not past %.0f yet\n", v.NamespaceName, v.PodName, count, v.state.egressBefore)
+			return ErrEgressCountStalled
+		}
+		after = count
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to verify prometheus metrics %s: %w", advForwardCountMetricName, err)
+	}
+
+	log.Printf("egress count of %s/%s went from %.0f to %.0f across the container restart\n", v.NamespaceName, v.PodName, v.state.egressBefore, after)
+	return nil
+}
+
+func (v *ValidateAttributionContinuity) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateAttributionContinuity) Stop() error {
+	return nil
+}
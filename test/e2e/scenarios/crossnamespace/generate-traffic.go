@@ -0,0 +1,66 @@
+package crossnamespace
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	defaultTimeout = 2 * time.Minute
+	requests       = 3
+)
+
+// GenerateCrossNamespaceTraffic sends HTTP requests from the client pod to the server pod's IP.
+// The requests must succeed, so this also checks the policy allowing the client's namespace is in effect.
+type GenerateCrossNamespaceTraffic struct {
+	KubeConfigFilePath string
+	ClientNamespace    string
+	ClientPodName      string
+	ServerNamespace    string
+	ServerPodName      string
+}
+
+func (g *GenerateCrossNamespaceTraffic) Run() error {
+	config, err := k8s.BuildConfig(g.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	server, err := clientset.CoreV1().Pods(g.ServerNamespace).Get(ctx, g.ServerPodName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting server pod \"%s\": %w", g.ServerPodName, err)
+	}
+
+	request := fmt.Sprintf("curl -s -m 5 http://%s:%d", server.Status.PodIP, k8s.AgnhostHTTPPort)
+	for attempt := 0; attempt < requests; attempt++ {
+		_, err = k8s.ExecPod(ctx, clientset, config, g.ClientNamespace, g.ClientPodName, request)
+		if err != nil {
+			return fmt.Errorf("allowed request from %s/%s to %s/%s failed: %w", g.ClientNamespace, g.ClientPodName, g.ServerNamespace, g.ServerPodName, err)
+		}
+	}
+	log.Printf("sent %d requests from %s/%s to %s/%s\n", requests, g.ClientNamespace, g.ClientPodName, g.ServerNamespace, g.ServerPodName)
+
+	return nil
+}
+
+func (g *GenerateCrossNamespaceTraffic) Prevalidate() error {
+	return nil
+}
+
+func (g *GenerateCrossNamespaceTraffic) Stop() error {
+	return nil
+}
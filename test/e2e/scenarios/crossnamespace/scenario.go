@@ -0,0 +1,159 @@
+package crossnamespace
+
+import (
+	"strconv"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+)
+
+const (
+	clientNamespace = "retina-cross-ns-client"
+	serverNamespace = "retina-cross-ns-server"
+
+	// the server's metrics are read through a second port forward, to the agent on the server's node
+	serverNodeLocalPort = common.RetinaPort + 2
+)
+
+// ValidateCrossNamespaceFlowMetrics allows traffic from one namespace into another with a NetworkPolicy,
+// sends it, and validates each end of the flow is recorded under its own namespace: egress for the client,
+// ingress for the server
+func ValidateCrossNamespaceFlowMetrics() *types.Scenario {
+	name := "Cross Namespace Flow Metrics"
+	clientName := "agnhost-cross-ns-client"
+	serverName := "agnhost-cross-ns-server"
+
+	steps := []*types.StepWrapper{}
+	for _, namespace := range []string{clientNamespace, serverNamespace} {
+		steps = append(steps, &types.StepWrapper{
+			Step: &kubernetes.CreateNamespace{
+				NamespaceName: namespace,
+				Observe:       true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		})
+	}
+
+	steps = append(steps,
+		&types.StepWrapper{
+			Step: &kubernetes.CreateAllowFromNamespaceNetworkPolicy{
+				NetworkPolicyName:      "allow-from-client-namespace",
+				NetworkPolicyNamespace: serverNamespace,
+				AllowFromNamespace:     clientNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		&types.StepWrapper{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      clientName,
+				AgnhostNamespace: clientNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		&types.StepWrapper{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      serverName,
+				AgnhostNamespace: serverNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		&types.StepWrapper{
+			Step: &GenerateCrossNamespaceTraffic{
+				ClientNamespace: clientNamespace,
+				ClientPodName:   clientName + "-0",
+				ServerNamespace: serverNamespace,
+				ServerPodName:   serverName + "-0",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		&types.StepWrapper{
+			Step: &kubernetes.PortForward{
+				Namespace:                          "kube-system",
+				LabelSelector:                      "k8s-app=retina",
+				LocalPort:                          strconv.Itoa(common.RetinaPort),
+				RemotePort:                         strconv.Itoa(common.RetinaPort),
+				Endpoint:                           "metrics",
+				OptionalLabelAffinity:              "app=" + clientName,
+				OptionalLabelAffinityAllNamespaces: true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "cross-ns-client-port-forward",
+			},
+		},
+		&types.StepWrapper{
+			Step: &kubernetes.PortForward{
+				Namespace:                          "kube-system",
+				LabelSelector:                      "k8s-app=retina",
+				LocalPort:                          strconv.Itoa(serverNodeLocalPort),
+				RemotePort:                         strconv.Itoa(common.RetinaPort),
+				Endpoint:                           "metrics",
+				OptionalLabelAffinity:              "app=" + serverName,
+				OptionalLabelAffinityAllNamespaces: true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "cross-ns-server-port-forward",
+			},
+		},
+		&types.StepWrapper{
+			Step: &ValidateNamespacedFlowMetric{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				Direction:               "egress",
+				NamespaceName:           clientNamespace,
+				PodName:                 clientName + "-0",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		&types.StepWrapper{
+			Step: &ValidateNamespacedFlowMetric{
+				PortForwardedRetinaPort: strconv.Itoa(serverNodeLocalPort),
+				Direction:               "ingress",
+				NamespaceName:           serverNamespace,
+				PodName:                 serverName + "-0",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		&types.StepWrapper{
+			Step: &types.Stop{
+				BackgroundID: "cross-ns-server-port-forward",
+			},
+		},
+		&types.StepWrapper{
+			Step: &types.Stop{
+				BackgroundID: "cross-ns-client-port-forward",
+			},
+		},
+	)
+
+	// deleting the namespaces removes the workloads and the policy with them
+	for _, namespace := range []string{clientNamespace, serverNamespace} {
+		steps = append(steps, &types.StepWrapper{
+			Step: &kubernetes.DeleteKubernetesResource{
+				ResourceType:      kubernetes.TypeString(kubernetes.Namespace),
+				ResourceName:      namespace,
+				ResourceNamespace: namespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		})
+	}
+
+	return types.NewScenario(name, steps...)
+}
@@ -0,0 +1,68 @@
+package crossnamespace
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/microsoft/retina/test/retry"
+)
+
+const (
+	advForwardCountMetricName = "networkobservability_adv_forward_count"
+
+	metricRetryAttempts = 20
+	metricRetryDelay    = 5 * time.Second
+)
+
+var ErrNoAttributedFlow = fmt.Errorf("no forward series attributed to the pod")
+
+// ValidateNamespacedFlowMetric checks the agent behind PortForwardedRetinaPort recorded the pod's side of the
+// cross namespace flow in Direction, attributed to the pod's own namespace rather than its peer's
+type ValidateNamespacedFlowMetric struct {
+	PortForwardedRetinaPort string
+	Direction               string
+	NamespaceName           string
+	PodName                 string
+}
+
+func (v *ValidateNamespacedFlowMetric) Run() error {
+	promAddress := fmt.Sprintf("http://localhost:%s/metrics", v.PortForwardedRetinaPort)
+
+	labels := map[string]string{
+		"direction": v.Direction,
+		"namespace": v.NamespaceName,
+		"podname":   v.PodName,
+	}
+
+	checkFn := func() error {
+		series, err := prom.GetMetricsMatchingLabels(promAddress, advForwardCountMetricName, labels)
+		if err != nil {
+			return fmt.Errorf("failed to scrape %s: %w", advForwardCountMetricName, err)
+		}
+		if len(series) == 0 {
+			log.Printf("no %s series matching %+v yet\n", advForwardCountMetricName, labels)
+			return ErrNoAttributedFlow
+		}
+		return nil
+	}
+
+	retrier := retry.Retrier{Attempts: metricRetryAttempts, Delay: metricRetryDelay}
+	err := retrier.Do(context.Background(), checkFn)
+	if err != nil {
+		return fmt.Errorf("failed to verify prometheus metrics %s: %w", advForwardCountMetricName, err)
+	}
+
+	log.Printf("found metrics matching %+v\n", labels)
+	return nil
+}
+
+func (v *ValidateNamespacedFlowMetric) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateNamespacedFlowMetric) Stop() error {
+	return nil
+}
@@ -0,0 +1,189 @@
+package disruption
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+)
+
+const (
+	workloadNamespace = "retina-disruption"
+
+	backendReplicas = 3
+	trafficDelay    = 10 * time.Second
+
+	// a request to an evicted pod may fail until kube-proxy catches up with the endpoints
+	maxServiceGap = 5 * time.Second
+)
+
+// ValidateDisruptionBudgetFlowMetrics sends requests to a Service while evicting its backend pods one at a time,
+// as bounded by their PodDisruptionBudget, and validates the Service kept answering from the remaining pods and
+// the flows of every backend are attributed to it by the agent on its node
+func ValidateDisruptionBudgetFlowMetrics() *types.Scenario {
+	name := "Disruption Budget Flow Metrics"
+	clientName := "agnhost-disruption-client"
+	backendName := "agnhost-disruption-backend"
+	serviceName := "disruption-backend"
+	responses := &serviceResponses{backends: make(map[string]int)}
+
+	backends := make([]string, 0, backendReplicas)
+	for i := 0; i < backendReplicas; i++ {
+		backends = append(backends, fmt.Sprintf("%s-%d", backendName, i))
+	}
+
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.CreateNamespace{
+				NamespaceName: workloadNamespace,
+				Observe:       true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      backendName,
+				AgnhostNamespace: workloadNamespace,
+				Replicas:         backendReplicas,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostService{
+				ServiceName:      serviceName,
+				ServiceNamespace: workloadNamespace,
+				AgnhostName:      backendName,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostPodDisruptionBudget{
+				PodDisruptionBudgetName:      backendName,
+				PodDisruptionBudgetNamespace: workloadNamespace,
+				AgnhostName:                  backendName,
+				MaxUnavailable:               1,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      clientName,
+				AgnhostNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &SendServiceRequestsInBackground{
+				PodNamespace: workloadNamespace,
+				PodName:      clientName + "-0",
+				URL:          fmt.Sprintf("http://%s.%s.svc.cluster.local:%d", serviceName, workloadNamespace, kubernetes.AgnhostHTTPPort),
+				responses:    responses,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "disruption-requests",
+			},
+		},
+		{
+			Step: &types.Sleep{
+				Duration: trafficDelay,
+			},
+		},
+		{
+			Step: &kubernetes.EvictAgnhostPods{
+				AgnhostName:      backendName,
+				AgnhostNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		// so every recreated backend has served some requests
+		{
+			Step: &types.Sleep{
+				Duration: trafficDelay,
+			},
+		},
+		{
+			Step: &types.Stop{
+				BackgroundID: "disruption-requests",
+			},
+		},
+		{
+			Step: &ValidateServiceAvailability{
+				Backends:  backends,
+				MaxGap:    maxServiceGap,
+				responses: responses,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	// each backend's flows are read from the agent on the node it was recreated on
+	for i, podName := range backends {
+		backgroundID := "disruption-backend-port-forward-" + strconv.Itoa(i)
+		steps = append(steps,
+			&types.StepWrapper{
+				Step: &kubernetes.PortForward{
+					Namespace:                          "kube-system",
+					LabelSelector:                      "k8s-app=retina",
+					LocalPort:                          strconv.Itoa(common.RetinaPort),
+					RemotePort:                         strconv.Itoa(common.RetinaPort),
+					Endpoint:                           "metrics",
+					OptionalLabelAffinity:              "statefulset.kubernetes.io/pod-name=" + podName,
+					OptionalLabelAffinityAllNamespaces: true,
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+					RunInBackgroundWithID:     backgroundID,
+				},
+			},
+			&types.StepWrapper{
+				Step: &ValidateBackendFlow{
+					PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+					NamespaceName:           workloadNamespace,
+					PodName:                 podName,
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+				},
+			},
+			&types.StepWrapper{
+				Step: &types.Stop{
+					BackgroundID: backgroundID,
+				},
+			},
+		)
+	}
+
+	// deleting the namespace removes the workloads, the service and the budget with it
+	cleanup := []*types.StepWrapper{
+		{
+			Step: &kubernetes.DeleteKubernetesResource{
+				ResourceType:      kubernetes.TypeString(kubernetes.Namespace),
+				ResourceName:      workloadNamespace,
+				ResourceNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	return types.NewScenario(name, steps...).WithCleanup(cleanup...)
+}
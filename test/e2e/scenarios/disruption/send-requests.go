@@ -0,0 +1,111 @@
+package disruption
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	k8s "k8s.io/client-go/kubernetes"
+)
+
+const requestInterval = 500 * time.Millisecond
+
+// serviceResponses is filled in by SendServiceRequestsInBackground with the backends that answered and the
+// longest the Service went unanswered, for the validators after it
+type serviceResponses struct {
+	mu         sync.Mutex
+	backends   map[string]int
+	unanswered int
+	longestGap time.Duration
+}
+
+func (s *serviceResponses) served(podName string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.backends[podName] > 0
+}
+
+// SendServiceRequestsInBackground sends an HTTP request from the pod to URL every requestInterval until stopped.
+// The backends are agnhost serve-hostname pods, so each response names the pod that served it
+type SendServiceRequestsInBackground struct {
+	KubeConfigFilePath string
+	PodNamespace       string
+	PodName            string
+	URL                string
+
+	responses *serviceResponses
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+func (s *SendServiceRequestsInBackground) Run() error {
+	config, err := kubernetes.BuildConfig(s.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := k8s.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	command := "curl -s -f -m 1 " + s.URL
+
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(requestInterval)
+		defer ticker.Stop()
+
+		lastAnswer := time.Now()
+		for {
+			select {
+			case <-ctx.Done():
+				s.responses.mu.Lock()
+				log.Printf("pod \"%s\" got answers from %v, %d requests unanswered, the longest for %s\n", s.PodName, s.responses.backends, s.responses.unanswered, s.responses.longestGap)
+				s.responses.mu.Unlock()
+				return
+			case <-ticker.C:
+				output, execErr := kubernetes.ExecPod(ctx, clientset, config, s.PodNamespace, s.PodName, command)
+				// stopping cancels the request in flight, which isn't the service's doing
+				if ctx.Err() != nil {
+					continue
+				}
+
+				s.responses.mu.Lock()
+				hostname := strings.TrimSpace(string(output))
+				if execErr != nil || hostname == "" {
+					s.responses.unanswered++
+				} else {
+					s.responses.backends[hostname]++
+					lastAnswer = time.Now()
+				}
+				if gap := time.Since(lastAnswer); gap > s.responses.longestGap {
+					s.responses.longestGap = gap
+				}
+				s.responses.mu.Unlock()
+			}
+		}
+	}()
+
+	log.Printf("sending requests from pod \"%s\" to %s\n", s.PodName, s.URL)
+	return nil
+}
+
+func (s *SendServiceRequestsInBackground) Prevalidate() error {
+	return nil
+}
+
+func (s *SendServiceRequestsInBackground) Stop() error {
+	if s.cancel != nil {
+		s.cancel()
+		<-s.done
+	}
+	return nil
+}
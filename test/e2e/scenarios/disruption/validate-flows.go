@@ -0,0 +1,129 @@
+package disruption
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/microsoft/retina/test/retry"
+)
+
+const (
+	advForwardCountMetricName = "networkobservability_adv_forward_count"
+
+	metricRetryAttempts = 20
+	metricRetryDelay    = 5 * time.Second
+)
+
+var (
+	ErrServiceUnavailable = fmt.Errorf("service went unanswered during the disruption")
+	ErrBackendNotServed   = fmt.Errorf("backend pod served no requests")
+	ErrNoBackendFlow      = fmt.Errorf("no ingress series attributed to the backend pod")
+	ErrBackendMisnamed    = fmt.Errorf("traffic to the backend pod attributed to another pod")
+)
+
+// ValidateServiceAvailability checks the Service kept answering within MaxGap throughout the disruption, and
+// that every pod of Backends served some of the requests, before or after it was evicted
+type ValidateServiceAvailability struct {
+	Backends []string
+	MaxGap   time.Duration
+
+	responses *serviceResponses
+}
+
+func (v *ValidateServiceAvailability) Run() error {
+	v.responses.mu.Lock()
+	longestGap := v.responses.longestGap
+	v.responses.mu.Unlock()
+
+	if longestGap > v.MaxGap {
+		return fmt.Errorf("unanswered for %s, at most %s allowed: %w", longestGap, v.MaxGap, ErrServiceUnavailable)
+	}
+	for _, podName := range v.Backends {
+		if !v.responses.served(podName) {
+			return fmt.Errorf("pod %s: %w", podName, ErrBackendNotServed)
+		}
+	}
+
+	log.Printf("service answered within %s throughout the disruption, served by all %d backends\n", longestGap, len(v.Backends))
+	return nil
+}
+
+func (v *ValidateServiceAvailability) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateServiceAvailability) Stop() error {
+	return nil
+}
+
+// ValidateBackendFlow checks the backend pod, as recreated after its eviction, has ingress flows attributed to it
+// by the agent on its node, and that no series of the pod's IP names another pod
+type ValidateBackendFlow struct {
+	KubeConfigFilePath      string
+	PortForwardedRetinaPort string
+	NamespaceName           string
+	PodName                 string
+}
+
+func (v *ValidateBackendFlow) Run() error {
+	podIP, err := kubernetes.GetPodIP(v.KubeConfigFilePath, v.NamespaceName, v.PodName)
+	if err != nil {
+		return fmt.Errorf("error getting the IP of pod %s: %w", v.PodName, err)
+	}
+
+	promAddress := fmt.Sprintf("http://localhost:%s/metrics", v.PortForwardedRetinaPort)
+
+	checkFn := func() error {
+		series, err := prom.GetMetricsMatchingLabels(promAddress, advForwardCountMetricName, map[string]string{
+			"ip": podIP,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to scrape %s: %w", advForwardCountMetricName, err)
+		}
+
+		found := false
+		for _, metric := range series {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+
+			if labels["podname"] == "" {
+				continue
+			}
+			if labels["namespace"] != v.NamespaceName || labels["podname"] != v.PodName {
+				return fmt.Errorf("series %v of %s: %w", labels, podIP, ErrBackendMisnamed)
+			}
+			if labels["direction"] == "ingress" && metric.GetCounter().GetValue() > 0 {
+				found = true
+			}
+		}
+
+		if !found {
+			log.Printf("no ingress %s series for %s/%s at %s yet\n", advForwardCountMetricName, v.NamespaceName, v.PodName, podIP)
+			return ErrNoBackendFlow
+		}
+		return nil
+	}
+
+	retrier := retry.Retrier{Attempts: metricRetryAttempts, Delay: metricRetryDelay}
+	err = retrier.Do(context.Background(), checkFn)
+	if err != nil {
+		return fmt.Errorf("failed to verify prometheus metrics %s: %w", advForwardCountMetricName, err)
+	}
+
+	log.Printf("traffic to backend %s/%s at %s is attributed to it only\n", v.NamespaceName, v.PodName, podIP)
+	return nil
+}
+
+func (v *ValidateBackendFlow) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateBackendFlow) Stop() error {
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package dns
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/netip"
+	"strings"
+
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	k8s "k8s.io/client-go/kubernetes"
+)
+
+var ErrNoIPv6Answer = fmt.Errorf("dns lookup returned no ipv6 address, the cluster may not be dual-stack")
+
+// ExpectIPv6DNSAnswer runs an AAAA lookup Command in the pod, nslookup or dig, and fails straight away unless its
+// output holds an IPv6 address. On a cluster without IPv6 the lookup still succeeds with an empty answer, which
+// would otherwise only show as the response metrics never matching
+type ExpectIPv6DNSAnswer struct {
+	PodNamespace       string
+	PodName            string
+	Command            string
+	KubeConfigFilePath string
+}
+
+func (e *ExpectIPv6DNSAnswer) Run() error {
+	config, err := kubernetes.BuildConfig(e.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := k8s.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	output, err := kubernetes.ExecPod(context.Background(), clientset, config, e.PodNamespace, e.PodName, e.Command)
+	if err != nil {
+		return fmt.Errorf("command [%s] failed with output %q: %w", e.Command, output, err)
+	}
+
+	addresses := ipv6Addresses(string(output))
+	if len(addresses) == 0 {
+		return fmt.Errorf("command [%s] output %q: %w", e.Command, output, ErrNoIPv6Answer)
+	}
+
+	log.Printf("command [%s] answered with %v\n", e.Command, addresses)
+	return nil
+}
+
+func (e *ExpectIPv6DNSAnswer) Prevalidate() error {
+	return nil
+}
+
+func (e *ExpectIPv6DNSAnswer) Stop() error {
+	return nil
+}
+
+// ipv6Addresses returns the IPv6 addresses in a lookup's output. The server nslookup and dig print is followed
+// by its port, e.g. "fd00::a#53", so it isn't mistaken for an answer
+func ipv6Addresses(output string) []string {
+	addresses := []string{}
+	for _, field := range strings.Fields(output) {
+		addr, err := netip.ParseAddr(field)
+		if err == nil && addr.Is6() && !addr.Is4In6() {
+			addresses = append(addresses, addr.String())
+		}
+	}
+	return addresses
+}
+
+// canonicalResponse writes each address of a response label the way the agent does, so an IPv6 address given
+// as "2001:0db8:0:0::10" matches the agent's "2001:db8::10". Anything that isn't an address is kept as is
+func canonicalResponse(response string) string {
+	if response == "" {
+		return response
+	}
+	addresses := strings.Split(response, ",")
+	for i, address := range addresses {
+		addr, err := netip.ParseAddr(address)
+		if err == nil {
+			addresses[i] = addr.String()
+		}
+	}
+	return strings.Join(addresses, ",")
+}
+
+// checkResponseFamily checks the addresses of an expected response label are of the family an A or AAAA query
+// asks for, as the agent only reports addresses of that family
+func checkResponseFamily(queryType, response string) error {
+	if (queryType != "A" && queryType != "AAAA") || response == "" || response == EmptyResponse {
+		return nil
+	}
+	for _, address := range strings.Split(response, ",") {
+		addr, err := netip.ParseAddr(address)
+		if err != nil {
+			continue
+		}
+		if (queryType == "A") != addr.Unmap().Is4() {
+			return fmt.Errorf("query type %s with response %s: %w", queryType, address, ErrCrossTypeDNSResponse)
+		}
+	}
+	return nil
+}
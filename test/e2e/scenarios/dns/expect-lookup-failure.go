@@ -0,0 +1,61 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package dns
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	k8s "k8s.io/client-go/kubernetes"
+)
+
+var (
+	ErrLookupSucceeded         = fmt.Errorf("dns lookup expected to fail succeeded")
+	ErrUnexpectedLookupFailure = fmt.Errorf("dns lookup failed without the expected response code")
+)
+
+// ExpectDNSLookupFailure runs an nslookup Command in the pod that has to fail with ResponseCode, e.g. NXDOMAIN
+// or SERVFAIL, as nslookup reports it. Unlike ExecInPod with ExpectError, a lookup that fails any other way,
+// such as timing out or the pod not being found, fails the step
+type ExpectDNSLookupFailure struct {
+	PodNamespace       string
+	PodName            string
+	Command            string
+	ResponseCode       string
+	KubeConfigFilePath string
+}
+
+func (e *ExpectDNSLookupFailure) Run() error {
+	config, err := kubernetes.BuildConfig(e.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := k8s.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	output, err := kubernetes.ExecPod(context.Background(), clientset, config, e.PodNamespace, e.PodName, e.Command)
+	if err == nil {
+		return fmt.Errorf("command [%s]: %w", e.Command, ErrLookupSucceeded)
+	}
+	// nslookup prints "** server can't find <name>: <code>"
+	if !strings.Contains(string(output), e.ResponseCode) {
+		return fmt.Errorf("command [%s] failed (%v) with output %q, expected %s: %w", e.Command, err, output, e.ResponseCode, ErrUnexpectedLookupFailure)
+	}
+
+	log.Printf("command [%s] failed with %s as expected\n", e.Command, e.ResponseCode)
+	return nil
+}
+
+func (e *ExpectDNSLookupFailure) Prevalidate() error {
+	return nil
+}
+
+func (e *ExpectDNSLookupFailure) Stop() error {
+	return nil
+}
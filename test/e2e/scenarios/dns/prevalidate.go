@@ -0,0 +1,58 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package dns
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+var (
+	ErrInvalidNumResponse       = fmt.Errorf("number of responses has to be a non-negative integer")
+	ErrScrapeByExecNotSupported = fmt.Errorf("scenario only reads the agent's metrics through a port forward")
+)
+
+// the query types the agent labels DNS metrics with, as a validator expects them
+var knownQueryTypes = []string{"A", "AAAA", "ANY", "CNAME", "MX", "NS", "PTR", "SOA", "SRV", "TXT"}
+
+// checkQueryType checks a validator expects a query type the agent reports, catching e.g. a lowercase "aaaa"
+// before the scenario creates its agnhost
+func checkQueryType(queryType string) error {
+	if !slices.Contains(knownQueryTypes, queryType) {
+		return fmt.Errorf("query type %s: %w", queryType, ErrUnsupportedQueryType)
+	}
+	return nil
+}
+
+// checkResponse checks the labels a response validator expects: a known query type, a count of answers, and
+// answers of the family the query asks for
+func checkResponse(queryType, numResponse, response string) error {
+	err := checkQueryType(queryType)
+	if err != nil {
+		return err
+	}
+	n, err := strconv.Atoi(numResponse)
+	if err != nil || n < 0 {
+		return fmt.Errorf("num response \"%s\": %w", numResponse, ErrInvalidNumResponse)
+	}
+	return checkResponseFamily(queryType, response)
+}
+
+// rejectScrapeByExec fails the job before it runs when a scenario whose validators only read the agent's metrics
+// through a port forward is asked to scrape by exec, rather than port forwarding on a cluster that may not permit it
+type rejectScrapeByExec struct {
+	Scenario string
+}
+
+func (r *rejectScrapeByExec) Run() error {
+	return nil
+}
+
+func (r *rejectScrapeByExec) Prevalidate() error {
+	return fmt.Errorf("scenario \"%s\" with ScrapeByExec: %w", r.Scenario, ErrScrapeByExecNotSupported)
+}
+
+func (r *rejectScrapeByExec) Stop() error {
+	return nil
+}
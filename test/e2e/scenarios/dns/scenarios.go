@@ -6,249 +6,1217 @@ import (
 	"fmt"
 	"math/rand"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/microsoft/retina/test/e2e/common"
 	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
 	"github.com/microsoft/retina/test/e2e/framework/types"
+	v1 "k8s.io/api/core/v1"
 )
 
 const (
 	sleepDelay    = 5 * time.Second
 	EmptyResponse = "emptyResponse"
+
+	largeRRSetServerName = "dns-rrset"
+	largeRRSetZone       = "retina.test."
+	largeRRSetQuery      = "many.retina.test."
+	// enough A records that the answer no longer fits a plain 512 byte UDP response
+	LargeRRSetSize = 40
+
+	dualStackServerName = "dns-dualstack"
+	dualStackQuery      = "dual.retina.test."
+	dualStackIPv4       = "192.0.2.10"
+	dualStackIPv6       = "2001:db8::10"
+
+	customPolicyServerName = "dns-custom-policy"
+	customPolicyQuery      = "custom.retina.test."
+	customPolicyResponse   = "192.0.2.20"
+
+	servFailServerName = "dns-servfail"
+	servFailQuery      = "servfail.retina.test."
+
+	fallbackServerName = "dns-tcp-fallback"
+	fallbackQuery      = "fallback.retina.test."
+
+	burstQuery       = "burst.retina.test."
+	BurstSize        = 100
+	burstIdleScrapes = 4
+	burstIdleDelay   = 30 * time.Second
+	burstScrapeDelay = 15 * time.Second
+
+	repeatedQuery       = "repeat.retina.test."
+	repeatedWarmupQuery = "warmup.retina.test."
+	RepeatedQueryCount  = 10
+	repeatedSettleDelay = 30 * time.Second
+
+	restartQuery      = "restart.retina.test."
+	RestartQueryCount = 5
+
+	perNodeQuery = "pernode.retina.test."
+
+	scaleQuery        = "scale.retina.test."
+	ScaleQueryCount   = 5
+	ScaleUpReplicas   = 3
+	scaleDownReplicas = 1
+
+	// an unqualified name is tried against each search domain of the pod's resolv.conf in turn, and with
+	// ndots:5 before the name as given. From kube-system the first expansion doesn't exist, the second does
+	searchDomainName           = "kubernetes.default"
+	searchDomainMissExpansion  = "kubernetes.default.kube-system.svc.cluster.local."
+	searchDomainMatchExpansion = "kubernetes.default.svc.cluster.local."
 )
 
+// fail a scenario on a wedged cluster instead of leaving it to the CI timeout
+const execTimeout = 60 * time.Second
+
+// the port forward can be reset while the agent settles after the agnhost comes up on its node. It has no Timeout,
+// as it bounds its own retries, and a Timeout firing before they give up would fail it without retrying
+var portForwardRetry = &types.Retry{Attempts: 3, Delay: sleepDelay, ExpBackoff: true}
+
 type RequestValidationParams struct {
 	NumResponse string
 	Query       string
 	QueryType   string
 
+	// Command is the lookup run in the agnhost, "nslookup -type=<QueryType> <Query>" when empty. A successful
+	// AAAA lookup has to answer with an IPv6 address, so a cluster without IPv6 fails the lookup rather than the
+	// metric validations
 	Command     string
 	ExpectError bool
+	// ReturnCode is the response code the lookup has to fail with when ExpectError is set, as nslookup reports
+	// it, e.g. NXDOMAIN or SERVFAIL. Any other failure of Command fails the scenario
+	ReturnCode string
+
+	// Namespace runs the agnhost in a namespace of the caller's, kube-system when empty. It has to exist
+	// already, and for the advanced metrics be one Retina observes
+	Namespace string
+	// DNSConfig switches the agnhost to dnsPolicy None with this config, e.g. to send its lookups to a custom DNS
+	// server the scenario's setup creates. A nameserver can be given as <namespace>/<service>
+	DNSConfig *v1.PodDNSConfig
+
+	// SleepDelay is how long the scenario's Sleep steps wait, 5 seconds when unset. Fast clusters can shrink
+	// it, slow CI runners may need it to grow
+	SleepDelay time.Duration
+
+	// ScrapeByExec reads the agent's metrics by running curl in the agent pod instead of through a port forward,
+	// for clusters whose RBAC permits exec but not port-forward. It needs curl in the agent image, and without a
+	// port forward there's no baseline of the request counter to guard against stale metrics. Only the validators
+	// of ValidateBasicDNSMetrics support it, any other scenario fails its Prevalidate when it's set
+	ScrapeByExec bool
 }
 
-type ResponseValidationParams struct {
-	NumResponse string
-	Query       string
-	QueryType   string
-	ReturnCode  string
-	Response    string
+func (r *RequestValidationParams) namespace() string {
+	if r.Namespace == "" {
+		return "kube-system"
+	}
+	return r.Namespace
 }
 
-// ValidateBasicDNSMetrics validates basic DNS metrics present in the metrics endpoint
-func ValidateBasicDNSMetrics(scenarioName string, req *RequestValidationParams, resp *ResponseValidationParams) *types.Scenario {
-	// generate a random ID using rand
-	id := fmt.Sprintf("basic-dns-port-forward-%d", rand.Int()) // nolint:gosec // fine to use math/rand here
-	agnhostName := "agnhost-" + id
-	podName := agnhostName + "-0"
-	steps := []*types.StepWrapper{
-		{
-			Step: &kubernetes.CreateAgnhostStatefulSet{
-				AgnhostName:      agnhostName,
-				AgnhostNamespace: "kube-system",
+func (r *RequestValidationParams) sleepDelay() time.Duration {
+	if r.SleepDelay == 0 {
+		return sleepDelay
+	}
+	return r.SleepDelay
+}
+
+func (r *RequestValidationParams) command() string {
+	if r.Command == "" {
+		return fmt.Sprintf("nslookup -type=%s %s", r.QueryType, r.Query)
+	}
+	return r.Command
+}
+
+// lookup runs the request's Command in the pod, checking a lookup expected to fail does so with ReturnCode, and
+// an AAAA lookup answers with an IPv6 address
+func (r *RequestValidationParams) lookup(podNamespace, podName string) *types.StepWrapper {
+	if !r.ExpectError && r.QueryType == "AAAA" {
+		return &types.StepWrapper{
+			Step: &ExpectIPv6DNSAnswer{
+				PodNamespace: podNamespace,
+				PodName:      podName,
+				Command:      r.command(),
 			},
-		},
-		{
-			Step: &kubernetes.ExecInPod{
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				Timeout:                   execTimeout,
+			},
+		}
+	}
+
+	if r.ExpectError {
+		return &types.StepWrapper{
+			Step: &ExpectDNSLookupFailure{
+				PodNamespace: podNamespace,
 				PodName:      podName,
-				PodNamespace: "kube-system",
-				Command:      req.Command,
+				Command:      r.command(),
+				ResponseCode: r.ReturnCode,
 			},
 			Opts: &types.StepOptions{
-				ExpectError:               req.ExpectError,
 				SkipSavingParametersToJob: true,
+				Timeout:                   execTimeout,
 			},
+		}
+	}
+
+	return &types.StepWrapper{
+		Step: &kubernetes.ExecInPod{
+			PodName:      podName,
+			PodNamespace: podNamespace,
+			Command:      r.command(),
 		},
-		{
-			Step: &types.Sleep{
-				Duration: sleepDelay,
-			},
+		Opts: &types.StepOptions{
+			SkipSavingParametersToJob: true,
+			Timeout:                   execTimeout,
 		},
-		// Ref: https://github.com/microsoft/retina/issues/415
+	}
+}
+
+// failureLogs prints the logs of the Retina agents and of the agnhost pod when a step of a DNS scenario fails,
+// so a count mismatch comes with what the agent and the pod querying DNS were doing at the time
+func failureLogs(agnhostNamespace, agnhostName string) []*types.StepWrapper {
+	return []*types.StepWrapper{
 		{
-			Step: &kubernetes.ExecInPod{
-				PodName:      podName,
-				PodNamespace: "kube-system",
-				Command:      req.Command,
+			Step: &kubernetes.GetPodLogs{
+				PodNamespace:     "kube-system",
+				PodLabelSelector: "k8s-app=retina",
 			},
 			Opts: &types.StepOptions{
-				ExpectError:               req.ExpectError,
 				SkipSavingParametersToJob: true,
 			},
 		},
 		{
-			Step: &types.Sleep{
-				Duration: sleepDelay,
+			Step: &kubernetes.GetPodLogs{
+				PodNamespace:     agnhostNamespace,
+				PodLabelSelector: "app=" + agnhostName,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
 			},
 		},
-		{
-			Step: &kubernetes.PortForward{
-				Namespace:             "kube-system",
-				LabelSelector:         "k8s-app=retina",
-				LocalPort:             strconv.Itoa(common.RetinaPort),
-				RemotePort:            strconv.Itoa(common.RetinaPort),
-				Endpoint:              "metrics",
-				OptionalLabelAffinity: "app=" + agnhostName, // port forward to a pod on a node that also has this pod with this label, assuming same namespace
+	}
+}
+
+type ResponseValidationParams struct {
+	NumResponse string
+	Query       string
+	QueryType   string
+	ReturnCode  string
+	Response    string
+
+	// NumResponseComparison loosens the match on NumResponse, e.g. to GreaterThanOrEqual where background
+	// lookups of the query get other answers. Equal when unset
+	NumResponseComparison Comparison
+}
+
+// A DNSAgnhost is the agnhost pod a scenario built by NewDNSScenario queries DNS from
+type DNSAgnhost struct {
+	Name      string
+	PodName   string
+	Namespace string
+
+	// PortForward is the scenario's port forward to the agent on the pod's node, e.g. for a RestartRetinaAgent to
+	// re-establish. Nil when scraping by exec
+	PortForward *kubernetes.PortForward
+}
+
+// NewDNSScenario builds a scenario creating an agnhost in req's namespace, port forwarding to the agent on its node
+// and running req's lookup in it, then running the steps validators returns for the agnhost. It stops the port
+// forward and deletes the agnhost once done, even when a validation fails, so a variant of the DNS scenarios only
+// supplies its validations. idPrefix names the agnhost and the port forward, followed by a random number.
+// requestCountMetric, the basic or advanced DNS request counter, has to count the lookup on top of what it had
+// counted for req's query before, so the validations can't pass on traffic from before the scenario. The advanced
+// counter is only summed for the agnhost's pod, so a restart of the agent in between doesn't leave it below the
+// baseline. Its validators are expected to read the metrics through the port forward, so req.ScrapeByExec fails
+// the scenario's Prevalidate
+func NewDNSScenario(scenarioName, idPrefix string, req *RequestValidationParams, requestCountMetric string, validators func(agnhost *DNSAgnhost) []*types.StepWrapper) *types.Scenario {
+	return newDNSScenario(scenarioName, idPrefix, req, requestCountMetric, false, validators)
+}
+
+// newDNSScenario is NewDNSScenario, whose validators scrape by exec themselves when scrapeByExec is set. With
+// req.ScrapeByExec there's no port forward or baseline then
+func newDNSScenario(scenarioName, idPrefix string, req *RequestValidationParams, requestCountMetric string, scrapeByExec bool, validators func(agnhost *DNSAgnhost) []*types.StepWrapper) *types.Scenario {
+	id := fmt.Sprintf("%s-%d", idPrefix, rand.Int()) // nolint:gosec // fine to use math/rand here
+	agnhost := &DNSAgnhost{
+		Name:      "agnhost-" + id,
+		PodName:   "agnhost-" + id + "-0",
+		Namespace: req.namespace(),
+	}
+	baseline := &prom.RecordMetricBaseline{
+		PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+		MetricName:              requestCountMetric,
+		Labels: map[string]string{
+			"query":      req.Query,
+			"query_type": req.QueryType,
+		},
+	}
+	if requestCountMetric == dnsAdvRequestCountMetricName {
+		baseline.Labels["podname"] = agnhost.PodName
+	}
+	portForward := &kubernetes.PortForward{
+		Namespace:             "kube-system",
+		LabelSelector:         "k8s-app=retina",
+		LocalPort:             strconv.Itoa(common.RetinaPort),
+		RemotePort:            strconv.Itoa(common.RetinaPort),
+		Endpoint:              "metrics",
+		OptionalLabelAffinity: "app=" + agnhost.Name, // port forward to a pod on a node that also has this pod with this label
+		// the agnhost needn't run in the agent's namespace
+		OptionalLabelAffinityAllNamespaces: true,
+	}
+	var steps []*types.StepWrapper
+	if req.ScrapeByExec && !scrapeByExec {
+		steps = append(steps, &types.StepWrapper{
+			Step: &rejectScrapeByExec{
+				Scenario: scenarioName,
 			},
 			Opts: &types.StepOptions{
 				SkipSavingParametersToJob: true,
-				RunInBackgroundWithID:     id,
+			},
+		})
+	}
+	steps = append(steps,
+		&types.StepWrapper{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      agnhost.Name,
+				AgnhostNamespace: agnhost.Namespace,
+				DNSConfig:        req.DNSConfig,
 			},
 		},
-		{
-			Step: &validateBasicDNSRequestMetrics{
-				Query:     req.Query,
-				QueryType: req.QueryType,
+		&types.StepWrapper{
+			Step: &kubernetes.WaitForPodReady{
+				PodNamespace: agnhost.Namespace,
+				PodSelector:  agnhost.PodName,
 			},
 			Opts: &types.StepOptions{
 				SkipSavingParametersToJob: true,
 			},
 		},
-		{
-			Step: &validateBasicDNSResponseMetrics{
-				NumResponse: resp.NumResponse,
-				Query:       resp.Query,
-				QueryType:   resp.QueryType,
-				ReturnCode:  resp.ReturnCode,
-				Response:    resp.Response,
+	)
+	if !req.ScrapeByExec {
+		agnhost.PortForward = portForward
+		steps = append(steps,
+			&types.StepWrapper{
+				Step: portForward,
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+					RunInBackgroundWithID:     id,
+					Retry:                     portForwardRetry,
+				},
+			},
+			&types.StepWrapper{
+				Step: baseline,
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+				},
+			},
+		)
+	}
+	steps = append(steps, req.lookup(agnhost.Namespace, agnhost.PodName))
+	steps = append(steps, validators(agnhost)...)
+
+	// runs even when a validation fails, so the port forward and agnhost don't leak into later runs
+	var cleanup []*types.StepWrapper
+	if !req.ScrapeByExec {
+		steps = append(steps, &types.StepWrapper{
+			Step: &prom.ValidateMetricAdvanced{
+				Baseline: baseline,
 			},
 			Opts: &types.StepOptions{
 				SkipSavingParametersToJob: true,
 			},
-		},
-		{
+		})
+		cleanup = append(cleanup, &types.StepWrapper{
 			Step: &types.Stop{
 				BackgroundID: id,
 			},
-		},
-		{
+		})
+	}
+	cleanup = append(cleanup,
+		&types.StepWrapper{
 			Step: &kubernetes.DeleteKubernetesResource{
 				ResourceType:      kubernetes.TypeString(kubernetes.StatefulSet),
-				ResourceName:      agnhostName,
-				ResourceNamespace: "kube-system",
+				ResourceName:      agnhost.Name,
+				ResourceNamespace: agnhost.Namespace,
 			}, Opts: &types.StepOptions{
 				SkipSavingParametersToJob: true,
 			},
 		},
-		{
+		&types.StepWrapper{
 			Step: &types.Sleep{
-				Duration: sleepDelay,
+				Duration: req.sleepDelay(),
 			},
 		},
+	)
+	return types.NewScenario(scenarioName, steps...).WithCleanup(cleanup...).
+		WithFailureDiagnostics(failureLogs(agnhost.Namespace, agnhost.Name)...)
+}
+
+// ValidateBasicDNSMetrics validates basic DNS metrics present in the metrics endpoint
+func ValidateBasicDNSMetrics(scenarioName string, req *RequestValidationParams, resp *ResponseValidationParams) *types.Scenario {
+	return newDNSScenario(scenarioName, "basic-dns-port-forward", req, dnsBasicRequestCountMetricName, true, func(agnhost *DNSAgnhost) []*types.StepWrapper {
+		if req.ScrapeByExec {
+			return basicDNSExecValidators(agnhost, req, resp)
+		}
+		return []*types.StepWrapper{
+			{
+				// the agent exports the query some time after it was made
+				Step: &prom.WaitForMetric{
+					PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+					MetricName:              dnsBasicRequestCountMetricName,
+					Labels: map[string]string{
+						"query":      req.Query,
+						"query_type": req.QueryType,
+					},
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+				},
+			},
+			{
+				Step: &validateBasicDNSRequestMetrics{
+					Query:     req.Query,
+					QueryType: req.QueryType,
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+				},
+			},
+			{
+				Step: &validateBasicDNSResponseMetrics{
+					NumResponse: resp.NumResponse,
+					Query:       resp.Query,
+					QueryType:   resp.QueryType,
+					ReturnCode:  resp.ReturnCode,
+					Response:    resp.Response,
+
+					NumResponseComparison: resp.NumResponseComparison,
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+				},
+			},
+		}
+	})
+}
+
+// basicDNSExecValidators waits for the basic request and response metrics of req and resp through curl in the
+// agent pod on the agnhost's node, for clusters that don't permit port forwards. num_response is only matched
+// when resp compares it for equality
+func basicDNSExecValidators(agnhost *DNSAgnhost, req *RequestValidationParams, resp *ResponseValidationParams) []*types.StepWrapper {
+	response := resp.Response
+	if response == EmptyResponse {
+		response = ""
+	}
+	responseLabels := map[string]string{
+		"query":       resp.Query,
+		"query_type":  resp.QueryType,
+		"return_code": resp.ReturnCode,
+		"response":    canonicalResponse(response),
 	}
-	return types.NewScenario(scenarioName, steps...)
+	if resp.NumResponseComparison == Equal {
+		responseLabels["num_response"] = resp.NumResponse
+	}
+
+	metrics := []struct {
+		name   string
+		labels map[string]string
+	}{
+		{name: dnsBasicRequestCountMetricName, labels: map[string]string{"query": req.Query, "query_type": req.QueryType}},
+		{name: dnsBasicResponseCountMetricName, labels: responseLabels},
+	}
+
+	steps := make([]*types.StepWrapper, 0, len(metrics))
+	for _, metric := range metrics {
+		steps = append(steps, &types.StepWrapper{
+			Step: &prom.WaitForMetricByExec{
+				Namespace:                          "kube-system",
+				LabelSelector:                      "k8s-app=retina",
+				OptionalLabelAffinity:              "app=" + agnhost.Name,
+				OptionalLabelAffinityAllNamespaces: true,
+				RetinaPort:                         common.RetinaPort,
+				MetricName:                         metric.name,
+				Labels:                             metric.labels,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		})
+	}
+	return steps
 }
 
-// ValidateAdvancedDNSMetrics validates the advanced DNS metrics present in the metrics endpoint
+// ValidateAdvancedDNSMetrics validates the advanced DNS metrics present in the metrics endpoint. It's skipped
+// on clusters where Retina doesn't have advanced metrics enabled
 func ValidateAdvancedDNSMetrics(scenarioName string, req *RequestValidationParams, resp *ResponseValidationParams, kubeConfigFilePath string) *types.Scenario {
-	// random ID
-	id := fmt.Sprintf("adv-dns-port-forward-%d", rand.Int()) // nolint:gosec // fine to use math/rand here
-	agnhostName := "agnhost-" + id
-	podName := agnhostName + "-0"
-	steps := []*types.StepWrapper{
-		{
-			Step: &kubernetes.CreateAgnhostStatefulSet{
-				AgnhostName:      agnhostName,
-				AgnhostNamespace: "kube-system",
+	advancedMetricsEnabled := &types.StepWrapper{
+		Step: &kubernetes.SkipUnlessRetinaFeature{
+			Feature:            "enablePodLevel",
+			ConfigMapName:      "retina-config",
+			ConfigMapNamespace: "kube-system",
+			KubeConfigFilePath: kubeConfigFilePath,
+		},
+		Opts: &types.StepOptions{
+			SkipSavingParametersToJob: true,
+		},
+	}
+
+	return NewDNSScenario(scenarioName, "adv-dns-port-forward", req, dnsAdvRequestCountMetricName, func(agnhost *DNSAgnhost) []*types.StepWrapper {
+		return []*types.StepWrapper{
+			{
+				// the agent exports the query some time after it was made
+				Step: &prom.WaitForMetric{
+					PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+					MetricName:              dnsAdvRequestCountMetricName,
+					Labels: map[string]string{
+						"podname":    agnhost.PodName,
+						"query":      req.Query,
+						"query_type": req.QueryType,
+					},
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+				},
+			},
+			{
+				Step: &ValidateAdvancedDNSRequestMetrics{
+					PodNamespace:       agnhost.Namespace,
+					PodName:            agnhost.PodName,
+					Query:              req.Query,
+					QueryType:          req.QueryType,
+					WorkloadKind:       "StatefulSet",
+					WorkloadName:       agnhost.Name,
+					KubeConfigFilePath: kubeConfigFilePath,
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+				},
 			},
+			{
+				Step: &ValidateAdvanceDNSResponseMetrics{
+					PodNamespace:       agnhost.Namespace,
+					NumResponse:        resp.NumResponse,
+					PodName:            agnhost.PodName,
+					Query:              resp.Query,
+					QueryType:          resp.QueryType,
+					Response:           resp.Response,
+					ReturnCode:         resp.ReturnCode,
+					WorkloadKind:       "StatefulSet",
+					WorkloadName:       agnhost.Name,
+					KubeConfigFilePath: kubeConfigFilePath,
+
+					NumResponseComparison: resp.NumResponseComparison,
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+				},
+			},
+		}
+	}).WithPreconditions(advancedMetricsEnabled)
+}
+
+// withCustomDNSServer runs the CoreDNS server a DNS scenario's lookups go to for the scenario, creating it in
+// kube-system ahead of the scenario's agnhost and deleting it once the scenario is done, even when it fails
+func withCustomDNSServer(scenario *types.Scenario, server *kubernetes.CreateCustomDNSServer) *types.Scenario {
+	return scenario.WithSetup(&types.StepWrapper{
+		Step: server,
+		Opts: &types.StepOptions{
+			SkipSavingParametersToJob: true,
 		},
-		{
-			Step: &kubernetes.ExecInPod{
-				PodName:      podName,
-				PodNamespace: "kube-system",
-				Command:      req.Command,
+	}).WithCleanup(&types.StepWrapper{
+		Step: &kubernetes.DeleteCustomDNSServer{
+			DNSServerName:      server.DNSServerName,
+			DNSServerNamespace: server.DNSServerNamespace,
+		},
+		Opts: &types.StepOptions{
+			SkipSavingParametersToJob: true,
+		},
+	})
+}
+
+// waitForDNSMetric waits on the port forwarded agent to export a series of a DNS metric with labels
+func waitForDNSMetric(metricName string, labels map[string]string) *types.StepWrapper {
+	return &types.StepWrapper{
+		Step: &prom.WaitForMetric{
+			PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+			MetricName:              metricName,
+			Labels:                  labels,
+		},
+		Opts: &types.StepOptions{
+			SkipSavingParametersToJob: true,
+		},
+	}
+}
+
+// ValidateLargeRRSetDNSMetrics serves a name with LargeRRSetSize A records from a dedicated
+// CoreDNS zone and validates the basic DNS response metric reports every answer in one series
+func ValidateLargeRRSetDNSMetrics() *types.Scenario {
+	addresses := make([]string, 0, LargeRRSetSize)
+	for i := 1; i <= LargeRRSetSize; i++ {
+		addresses = append(addresses, fmt.Sprintf("192.0.2.%d", i))
+	}
+
+	req := &RequestValidationParams{
+		Query:     largeRRSetQuery,
+		QueryType: "A",
+		Command:   fmt.Sprintf("nslookup %s %s.kube-system.svc.cluster.local", largeRRSetQuery, largeRRSetServerName),
+	}
+
+	scenario := NewDNSScenario("Validate basic DNS response metrics for a query with a large RRset", "large-rrset-dns-port-forward", req, dnsBasicRequestCountMetricName, func(*DNSAgnhost) []*types.StepWrapper {
+		return []*types.StepWrapper{
+			// the agent exports the response some time after it was made
+			waitForDNSMetric(dnsBasicResponseCountMetricName, map[string]string{
+				"query":      largeRRSetQuery,
+				"query_type": "A",
+			}),
+			{
+				// one series per query attempt at most, a series per answer would mean the labels exploded
+				Step: &ValidateLargeRRSetDNSResponseMetrics{
+					Query:             largeRRSetQuery,
+					QueryType:         "A",
+					ExpectedResponses: addresses,
+					MaxSeries:         2,
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+				},
 			},
-			Opts: &types.StepOptions{
-				ExpectError:               req.ExpectError,
-				SkipSavingParametersToJob: true,
+		}
+	})
+	return withCustomDNSServer(scenario, &kubernetes.CreateCustomDNSServer{
+		DNSServerName:      largeRRSetServerName,
+		DNSServerNamespace: "kube-system",
+		Zone:               largeRRSetZone,
+		Records: map[string][]string{
+			largeRRSetQuery: addresses,
+		},
+	})
+}
+
+// ValidateTCPFallbackDNSMetrics looks up a name with LargeRRSetSize A records without EDNS, so the UDP response
+// is truncated to 512 bytes and dig retries over TCP. It validates the truncated response is recorded with fewer
+// than every answer. The TCP retry isn't validated, as the agent doesn't record DNS over TCP
+func ValidateTCPFallbackDNSMetrics() *types.Scenario {
+	addresses := make([]string, 0, LargeRRSetSize)
+	for i := 1; i <= LargeRRSetSize; i++ {
+		addresses = append(addresses, fmt.Sprintf("192.0.2.%d", i))
+	}
+
+	// dig retries a truncated response over TCP unless told to +ignore it
+	req := &RequestValidationParams{
+		Query:     fallbackQuery,
+		QueryType: "A",
+		Command:   fmt.Sprintf("dig +tries=1 +noedns @%s.kube-system.svc.cluster.local %s", fallbackServerName, fallbackQuery),
+	}
+
+	scenario := NewDNSScenario("Validate basic DNS response metrics for a response truncated to fall back to TCP", "tcp-fallback-dns-port-forward", req, dnsBasicRequestCountMetricName, func(*DNSAgnhost) []*types.StepWrapper {
+		return []*types.StepWrapper{
+			// the agent exports the response some time after it was made
+			waitForDNSMetric(dnsBasicResponseCountMetricName, map[string]string{
+				"query":      fallbackQuery,
+				"query_type": "A",
+			}),
+			{
+				Step: &validateTruncatedDNSResponse{
+					Query:         fallbackQuery,
+					QueryType:     "A",
+					FullResponses: LargeRRSetSize,
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+				},
 			},
+		}
+	})
+	return withCustomDNSServer(scenario, &kubernetes.CreateCustomDNSServer{
+		DNSServerName:      fallbackServerName,
+		DNSServerNamespace: "kube-system",
+		Zone:               largeRRSetZone,
+		Records: map[string][]string{
+			fallbackQuery: addresses,
 		},
-		{
-			Step: &types.Sleep{
-				Duration: sleepDelay,
+	})
+}
+
+// ValidateParallelDualStackDNSMetrics fires A and AAAA lookups for the same name at the same time,
+// the way happy eyeballs clients do, and validates each query type is recorded on its own
+// request and response series with only its own answer
+func ValidateParallelDualStackDNSMetrics() *types.Scenario {
+	server := fmt.Sprintf("@%s.kube-system.svc.cluster.local", dualStackServerName)
+	// an MX lookup of the name warms up the agent without adding to the A and AAAA series
+	req := &RequestValidationParams{
+		Query:     dualStackQuery,
+		QueryType: "MX",
+		Command:   fmt.Sprintf("dig +tries=1 MX %s %s", dualStackQuery, server),
+	}
+
+	perType := []struct {
+		queryType string
+		response  string
+	}{
+		{queryType: "A", response: dualStackIPv4},
+		{queryType: "AAAA", response: dualStackIPv6},
+	}
+
+	scenario := NewDNSScenario("Validate basic DNS metrics for parallel A and AAAA queries", "dualstack-dns-port-forward", req, dnsBasicRequestCountMetricName, func(agnhost *DNSAgnhost) []*types.StepWrapper {
+		commands := make([]string, 0, len(perType))
+		for _, expected := range perType {
+			commands = append(commands, fmt.Sprintf("dig +tries=1 %s %s %s", expected.queryType, dualStackQuery, server))
+		}
+
+		steps := []*types.StepWrapper{
+			// the agent is tracing the pod's DNS once it exports the warm up query
+			waitForDNSMetric(dnsBasicRequestCountMetricName, map[string]string{
+				"query":      dualStackQuery,
+				"query_type": "MX",
+			}),
+			{
+				Step: &kubernetes.ExecInPodConcurrently{
+					PodName:      agnhost.PodName,
+					PodNamespace: agnhost.Namespace,
+					Commands:     commands,
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+					Timeout:                   execTimeout,
+				},
+			},
+		}
+
+		for _, expected := range perType {
+			steps = append(steps,
+				// the agent exports the responses some time after they were made
+				waitForDNSMetric(dnsBasicResponseCountMetricName, map[string]string{
+					"query":      dualStackQuery,
+					"query_type": expected.queryType,
+				}),
+				&types.StepWrapper{
+					Step: &validateBasicDNSRequestMetrics{
+						Query:     dualStackQuery,
+						QueryType: expected.queryType,
+					},
+					Opts: &types.StepOptions{
+						SkipSavingParametersToJob: true,
+					},
+				},
+				&types.StepWrapper{
+					Step: &validateBasicDNSResponseMetrics{
+						NumResponse: "1",
+						Query:       dualStackQuery,
+						QueryType:   expected.queryType,
+						ReturnCode:  "No Error",
+						Response:    expected.response,
+					},
+					Opts: &types.StepOptions{
+						SkipSavingParametersToJob: true,
+					},
+				},
+				&types.StepWrapper{
+					Step: &validateDNSResponseAddressFamily{
+						Query:     dualStackQuery,
+						QueryType: expected.queryType,
+					},
+					Opts: &types.StepOptions{
+						SkipSavingParametersToJob: true,
+					},
+				},
+			)
+		}
+		return steps
+	})
+	return withCustomDNSServer(scenario, &kubernetes.CreateCustomDNSServer{
+		DNSServerName:      dualStackServerName,
+		DNSServerNamespace: "kube-system",
+		Zone:               largeRRSetZone,
+		Records: map[string][]string{
+			dualStackQuery: {dualStackIPv4, dualStackIPv6},
+		},
+	})
+}
+
+// ValidateCustomDNSPolicyMetrics runs a pod with dnsPolicy None pointed only at a dedicated CoreDNS
+// serving a zone the cluster DNS doesn't know, so an answer proves the query went to the configured
+// nameserver, and validates the advanced DNS metrics attribute the lookup to that pod
+func ValidateCustomDNSPolicyMetrics(kubeConfigFilePath string) *types.Scenario {
+	req := &RequestValidationParams{
+		Query:     customPolicyQuery,
+		QueryType: "A",
+		// relies on the search domain below, nslookup of the short name only resolves through the custom nameserver
+		Command: "nslookup custom",
+		DNSConfig: &v1.PodDNSConfig{
+			Nameservers: []string{"kube-system/" + customPolicyServerName},
+			Searches:    []string{strings.TrimSuffix(largeRRSetZone, ".")},
+		},
+	}
+
+	scenario := NewDNSScenario("Validate advanced DNS metrics for a pod with a custom DNS policy", "custom-policy-dns-port-forward", req, dnsAdvRequestCountMetricName, func(agnhost *DNSAgnhost) []*types.StepWrapper {
+		return []*types.StepWrapper{
+			// the agent exports the query some time after it was made
+			waitForDNSMetric(dnsAdvRequestCountMetricName, map[string]string{
+				"podname":    agnhost.PodName,
+				"query":      customPolicyQuery,
+				"query_type": "A",
+			}),
+			{
+				Step: &ValidateAdvancedDNSRequestMetrics{
+					PodNamespace:       agnhost.Namespace,
+					PodName:            agnhost.PodName,
+					Query:              customPolicyQuery,
+					QueryType:          "A",
+					WorkloadKind:       "StatefulSet",
+					WorkloadName:       agnhost.Name,
+					KubeConfigFilePath: kubeConfigFilePath,
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+				},
+			},
+			{
+				Step: &ValidateAdvanceDNSResponseMetrics{
+					PodNamespace:       agnhost.Namespace,
+					NumResponse:        "1",
+					PodName:            agnhost.PodName,
+					Query:              customPolicyQuery,
+					QueryType:          "A",
+					Response:           customPolicyResponse,
+					ReturnCode:         "NOERROR",
+					WorkloadKind:       "StatefulSet",
+					WorkloadName:       agnhost.Name,
+					KubeConfigFilePath: kubeConfigFilePath,
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+				},
 			},
+		}
+	})
+	return withCustomDNSServer(scenario, &kubernetes.CreateCustomDNSServer{
+		DNSServerName:      customPolicyServerName,
+		DNSServerNamespace: "kube-system",
+		Zone:               largeRRSetZone,
+		Records: map[string][]string{
+			customPolicyQuery: {customPolicyResponse},
 		},
-		// Ref: https://github.com/microsoft/retina/issues/415
+	})
+}
+
+// ValidateServFailDNSMetrics validates the advanced DNS metrics record SERVFAIL for a lookup a custom DNS server
+// fails, and that the lookup itself failed with SERVFAIL rather than any other way
+func ValidateServFailDNSMetrics(kubeConfigFilePath string) *types.Scenario {
+	req := &RequestValidationParams{
+		Query:       servFailQuery,
+		QueryType:   "A",
+		Command:     "nslookup " + servFailQuery,
+		ExpectError: true,
+		ReturnCode:  "SERVFAIL",
+		DNSConfig: &v1.PodDNSConfig{
+			Nameservers: []string{"kube-system/" + servFailServerName},
+		},
+	}
+
+	scenario := NewDNSScenario("Validate advanced DNS metrics for a lookup failing with SERVFAIL", "servfail-dns-port-forward", req, dnsAdvRequestCountMetricName, func(agnhost *DNSAgnhost) []*types.StepWrapper {
+		return []*types.StepWrapper{
+			// the agent exports the query some time after it was made
+			waitForDNSMetric(dnsAdvRequestCountMetricName, map[string]string{
+				"podname":    agnhost.PodName,
+				"query":      servFailQuery,
+				"query_type": "A",
+			}),
+			{
+				Step: &ValidateAdvancedDNSRequestMetrics{
+					PodNamespace:       agnhost.Namespace,
+					PodName:            agnhost.PodName,
+					Query:              servFailQuery,
+					QueryType:          "A",
+					WorkloadKind:       "StatefulSet",
+					WorkloadName:       agnhost.Name,
+					KubeConfigFilePath: kubeConfigFilePath,
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+				},
+			},
+			{
+				Step: &ValidateAdvanceDNSResponseMetrics{
+					PodNamespace:       agnhost.Namespace,
+					NumResponse:        "0",
+					PodName:            agnhost.PodName,
+					Query:              servFailQuery,
+					QueryType:          "A",
+					Response:           EmptyResponse,
+					ReturnCode:         "SERVFAIL",
+					WorkloadKind:       "StatefulSet",
+					WorkloadName:       agnhost.Name,
+					KubeConfigFilePath: kubeConfigFilePath,
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+				},
+			},
+		}
+	})
+	return withCustomDNSServer(scenario, &kubernetes.CreateCustomDNSServer{
+		DNSServerName:      servFailServerName,
+		DNSServerNamespace: "kube-system",
+		Zone:               largeRRSetZone,
+		FailingNames:       []string{servFailQuery},
+	})
+}
+
+// ValidateDNSBurstCounterStability sends a burst of BurstSize DNS queries, idles, and validates
+// the request counter holds at the burst total over several scrapes instead of drifting or decaying
+func ValidateDNSBurstCounterStability() *types.Scenario {
+	// dig sends every query given on its command line one after another, no shell needed
+	burst := []string{"dig", "+tries=1"}
+	for i := 0; i < BurstSize; i++ {
+		burst = append(burst, "A", burstQuery)
+	}
+
+	// the warm up query counts towards the burst total too
+	req := &RequestValidationParams{
+		Query:     burstQuery,
+		QueryType: "A",
+		Command:   "dig +tries=1 A " + burstQuery,
+	}
+
+	return NewDNSScenario("Validate basic DNS request counter is stable after a burst", "burst-dns-port-forward", req, dnsBasicRequestCountMetricName, func(agnhost *DNSAgnhost) []*types.StepWrapper {
+		return []*types.StepWrapper{
+			// the agent is tracing the pod's DNS once it exports the warm up query
+			waitForDNSMetric(dnsBasicRequestCountMetricName, map[string]string{
+				"query":      burstQuery,
+				"query_type": "A",
+			}),
+			{
+				Step: &kubernetes.ExecInPod{
+					PodName:      agnhost.PodName,
+					PodNamespace: agnhost.Namespace,
+					Command:      strings.Join(burst, " "),
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+					Timeout:                   execTimeout,
+				},
+			},
+			{
+				Step: &types.Sleep{
+					Duration: burstIdleDelay,
+				},
+			},
+			{
+				Step: &validateDNSRequestCounterStability{
+					Query:        burstQuery,
+					QueryType:    "A",
+					MinimumCount: BurstSize,
+					Scrapes:      burstIdleScrapes,
+					Interval:     burstScrapeDelay,
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+				},
+			},
+		}
+	})
+}
+
+// warmupRequest is a lookup of a name other than the one a scenario counts, so it doesn't add to the count
+var warmupRequest = &RequestValidationParams{
+	Query:     repeatedWarmupQuery,
+	QueryType: "A",
+	Command:   "dig +tries=1 A " + repeatedWarmupQuery,
+}
+
+// countQueries waits for the agent to export the agnhost's warmupRequest, then sends count queries of query from
+// the agnhost and validates the agent's advanced request counter for the pod and query is exactly count
+func countQueries(agnhost *DNSAgnhost, query string, count int) []*types.StepWrapper {
+	return []*types.StepWrapper{
+		// the agent is tracing the pod's DNS once it exports the warm up query
+		waitForDNSMetric(dnsAdvRequestCountMetricName, map[string]string{
+			"podname":    agnhost.PodName,
+			"query":      repeatedWarmupQuery,
+			"query_type": "A",
+		}),
 		{
 			Step: &kubernetes.ExecInPod{
-				PodName:      podName,
-				PodNamespace: "kube-system",
-				Command:      req.Command,
+				PodName:      agnhost.PodName,
+				PodNamespace: agnhost.Namespace,
+				Command:      digQueries(query, count),
 			},
 			Opts: &types.StepOptions{
-				ExpectError:               req.ExpectError,
 				SkipSavingParametersToJob: true,
+				Timeout:                   execTimeout,
 			},
 		},
 		{
-			Step: &types.Sleep{
-				Duration: sleepDelay,
-			},
-		},
-		{
-			Step: &kubernetes.PortForward{
-				Namespace:             "kube-system",
-				LabelSelector:         "k8s-app=retina",
-				LocalPort:             strconv.Itoa(common.RetinaPort),
-				RemotePort:            strconv.Itoa(common.RetinaPort),
-				Endpoint:              "metrics",
-				OptionalLabelAffinity: "app=" + agnhostName, // port forward to a pod on a node that also has this pod with this label, assuming same namespace
+			Step: &validateRepeatedDNSRequestCount{
+				PodNamespace:  agnhost.Namespace,
+				PodName:       agnhost.PodName,
+				Query:         query,
+				QueryType:     "A",
+				ExpectedCount: count,
+				Interval:      repeatedSettleDelay,
 			},
 			Opts: &types.StepOptions{
 				SkipSavingParametersToJob: true,
-				RunInBackgroundWithID:     id,
 			},
 		},
-		{
-			Step: &ValidateAdvancedDNSRequestMetrics{
-				Namespace:          "kube-system",
-				PodName:            podName,
-				Query:              req.Query,
-				QueryType:          req.QueryType,
-				WorkloadKind:       "StatefulSet",
-				WorkloadName:       agnhostName,
-				KubeConfigFilePath: kubeConfigFilePath,
+	}
+}
+
+// digQueries is a dig command sending count A queries of query
+func digQueries(query string, count int) string {
+	// +tries=1 keeps dig from retransmitting a query, which would be counted as another request
+	queries := []string{"dig", "+tries=1"}
+	for i := 0; i < count; i++ {
+		queries = append(queries, "A", query)
+	}
+	return strings.Join(queries, " ")
+}
+
+// ValidateRepeatedQueryDNSCount sends the same DNS query RepeatedQueryCount times from one pod and validates
+// the pod's advanced request counter for it is exactly RepeatedQueryCount, so identical queries are neither
+// deduplicated nor counted twice
+func ValidateRepeatedQueryDNSCount() *types.Scenario {
+	return NewDNSScenario("Validate advanced DNS request counter for repeated identical queries", "repeat-dns-port-forward", warmupRequest, dnsAdvRequestCountMetricName, func(agnhost *DNSAgnhost) []*types.StepWrapper {
+		return append(countQueries(agnhost, repeatedQuery, RepeatedQueryCount), &types.StepWrapper{
+			// the agents of other nodes, e.g. CoreDNS's, may count the queries too, so over all agents there are at least as many
+			Step: &prom.ValidateFleetMetric{
+				Namespace:     "kube-system",
+				LabelSelector: "k8s-app=retina",
+				RetinaPort:    common.RetinaPort,
+				MetricName:    dnsAdvRequestCountMetricName,
+				Labels: map[string]string{
+					"query":      repeatedQuery,
+					"query_type": "A",
+				},
+				AtLeast: RepeatedQueryCount,
 			},
 			Opts: &types.StepOptions{
 				SkipSavingParametersToJob: true,
 			},
-		},
-		{
-			Step: &ValidateAdvanceDNSResponseMetrics{
-				Namespace:          "kube-system",
-				NumResponse:        resp.NumResponse,
-				PodName:            podName,
-				Query:              resp.Query,
-				QueryType:          resp.QueryType,
-				Response:           resp.Response,
-				ReturnCode:         resp.ReturnCode,
-				WorkloadKind:       "StatefulSet",
-				WorkloadName:       agnhostName,
-				KubeConfigFilePath: kubeConfigFilePath,
+		})
+	})
+}
+
+// ValidateDNSMetricsAfterAgentRestart counts queries of a name before and after restarting the Retina agent on
+// the agnhost's node, validating the agent reattaches and its advanced request counter for them starts over from
+// the queries sent since the restart, rather than missing them or carrying counts over. The port forward to the
+// agent is re-established against the replacement pod
+func ValidateDNSMetricsAfterAgentRestart() *types.Scenario {
+	return NewDNSScenario("Validate advanced DNS request counter recovers after a Retina agent restart", "restart-dns-port-forward", warmupRequest, dnsAdvRequestCountMetricName, func(agnhost *DNSAgnhost) []*types.StepWrapper {
+		steps := countQueries(agnhost, restartQuery, RestartQueryCount)
+		steps = append(steps, &types.StepWrapper{
+			Step: &kubernetes.RestartRetinaAgent{
+				RetinaDaemonSetNamespace: "kube-system",
+				PodNamespace:             agnhost.Namespace,
+				PodName:                  agnhost.PodName,
+				PortForward:              agnhost.PortForward,
 			},
 			Opts: &types.StepOptions{
 				SkipSavingParametersToJob: true,
 			},
+		})
+		// the freshly started agent is warmed up again
+		steps = append(steps, warmupRequest.lookup(agnhost.Namespace, agnhost.PodName))
+		return append(steps, countQueries(agnhost, restartQuery, RestartQueryCount)...)
+	})
+}
+
+// ValidateSearchDomainExpansionDNSMetrics looks up an unqualified name, which the resolver expands with the
+// pod's search domains, and validates every expansion tried is recorded as its own request, with the
+// NXDOMAIN answer to the first expansion kept apart from the successful answer to the second
+func ValidateSearchDomainExpansionDNSMetrics() *types.Scenario {
+	expansions := []*validateBasicDNSResponseMetrics{
+		{
+			NumResponse: "0",
+			Query:       searchDomainMissExpansion,
+			QueryType:   "A",
+			ReturnCode:  "Non-Existent Domain",
+			Response:    EmptyResponse,
 		},
 		{
-			Step: &types.Stop{
-				BackgroundID: id,
+			NumResponse: "1",
+			Query:       searchDomainMatchExpansion,
+			QueryType:   "A",
+			ReturnCode:  "No Error",
+			// the name resolves to the ClusterIP of the API server's Service
+			Response: "default/kubernetes",
+		},
+	}
+
+	// the expansion that resolves is tried last
+	req := &RequestValidationParams{
+		Query:     searchDomainMatchExpansion,
+		QueryType: "A",
+		Command:   "nslookup -type=A " + searchDomainName,
+	}
+
+	return NewDNSScenario("Validate basic DNS metrics for search domain expansion", "search-dns-port-forward", req, dnsBasicRequestCountMetricName, func(*DNSAgnhost) []*types.StepWrapper {
+		steps := []*types.StepWrapper{
+			// the agent exports the last expansion some time after it was tried
+			waitForDNSMetric(dnsBasicRequestCountMetricName, map[string]string{
+				"query":      searchDomainMatchExpansion,
+				"query_type": "A",
+			}),
+		}
+		for _, expansion := range expansions {
+			steps = append(steps,
+				&types.StepWrapper{
+					Step: &validateBasicDNSRequestMetrics{
+						Query:     expansion.Query,
+						QueryType: expansion.QueryType,
+					},
+					Opts: &types.StepOptions{
+						SkipSavingParametersToJob: true,
+					},
+				},
+				&types.StepWrapper{
+					Step: expansion,
+					Opts: &types.StepOptions{
+						SkipSavingParametersToJob: true,
+					},
+				},
+			)
+		}
+		return steps
+	})
+}
+
+// ValidateScaledWorkloadDNSMetrics scales the agnhost StatefulSet from one replica to ScaleUpReplicas, sends
+// ScaleQueryCount queries from each replica and validates the agents count them for each pod and over all of
+// them. It then scales back down to one replica and validates the remaining pod's queries are still counted
+// for it. The agents of the pods' nodes aren't known up front, so the counts are summed over all agents
+func ValidateScaledWorkloadDNSMetrics() *types.Scenario {
+	return NewDNSScenario("Validate advanced DNS request counters add up across a scaled workload", "scale-dns-port-forward", warmupRequest, dnsAdvRequestCountMetricName, func(agnhost *DNSAgnhost) []*types.StepWrapper {
+		podNames := make([]string, ScaleUpReplicas)
+		for i := range podNames {
+			podNames[i] = fmt.Sprintf("%s-%d", agnhost.Name, i)
+		}
+
+		steps := []*types.StepWrapper{scaleAgnhost(agnhost, ScaleUpReplicas)}
+		for _, podName := range podNames {
+			// the agent is tracing a new pod's DNS once it counts its warm up query
+			steps = append(steps,
+				warmupRequest.lookup(agnhost.Namespace, podName),
+				fleetDNSRequestCount(agnhost.Namespace, podName, repeatedWarmupQuery, 1),
+			)
+		}
+		for _, podName := range podNames {
+			steps = append(steps, &types.StepWrapper{
+				Step: &kubernetes.ExecInPod{
+					PodName:      podName,
+					PodNamespace: agnhost.Namespace,
+					Command:      digQueries(scaleQuery, ScaleQueryCount),
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+					Timeout:                   execTimeout,
+				},
+			})
+		}
+		for _, podName := range podNames {
+			steps = append(steps, fleetDNSRequestCount(agnhost.Namespace, podName, scaleQuery, ScaleQueryCount))
+		}
+		// the agents of other nodes, e.g. CoreDNS's, may count the queries too, so the pods add up to at least as many
+		steps = append(steps, fleetDNSRequestCount(agnhost.Namespace, "", scaleQuery, ScaleUpReplicas*ScaleQueryCount))
+
+		steps = append(steps,
+			scaleAgnhost(agnhost, scaleDownReplicas),
+			&types.StepWrapper{
+				Step: &kubernetes.ExecInPod{
+					PodName:      agnhost.PodName,
+					PodNamespace: agnhost.Namespace,
+					Command:      digQueries(scaleQuery, ScaleQueryCount),
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+					Timeout:                   execTimeout,
+				},
 			},
+		)
+		return append(steps, fleetDNSRequestCount(agnhost.Namespace, agnhost.PodName, scaleQuery, 2*ScaleQueryCount))
+	})
+}
+
+// scaleAgnhost scales the agnhost StatefulSet to replicas, waiting until it has exactly that many ready pods
+func scaleAgnhost(agnhost *DNSAgnhost, replicas int) *types.StepWrapper {
+	return &types.StepWrapper{
+		Step: &kubernetes.ScaleWorkload{
+			ResourceType:      kubernetes.TypeString(kubernetes.StatefulSet),
+			ResourceName:      agnhost.Name,
+			ResourceNamespace: agnhost.Namespace,
+			Replicas:          replicas,
+			WaitForReady:      true,
+		},
+		Opts: &types.StepOptions{
+			SkipSavingParametersToJob: true,
+		},
+	}
+}
+
+// fleetDNSRequestCount waits for the advanced request counter of query from the pod, or from every pod in the
+// namespace when podName is empty, to sum to at least count over all agents
+func fleetDNSRequestCount(namespace, podName, query string, count int) *types.StepWrapper {
+	labels := map[string]string{
+		"namespace":  namespace,
+		"query":      query,
+		"query_type": "A",
+	}
+	if podName != "" {
+		labels["podname"] = podName
+	}
+	return &types.StepWrapper{
+		Step: &prom.ValidateFleetMetric{
+			Namespace:     "kube-system",
+			LabelSelector: "k8s-app=retina",
+			RetinaPort:    common.RetinaPort,
+			MetricName:    dnsAdvRequestCountMetricName,
+			Labels:        labels,
+			AtLeast:       float64(count),
+		},
+		Opts: &types.StepOptions{
+			SkipSavingParametersToJob: true,
 		},
+	}
+}
+
+// ValidatePerNodeDNSMetrics runs an agnhost pod on every node and sends a DNS query from all of them at once,
+// validating the agent on each node counts the query of the pod on its node in its advanced request counter
+func ValidatePerNodeDNSMetrics() *types.Scenario {
+	name := "Validate advanced DNS metrics are reported by the agent of each node"
+	agnhostName := fmt.Sprintf("agnhost-per-node-%d", rand.Int()) // nolint:gosec // fine to use math/rand here
+	agnhostNamespace := "kube-system"
+
+	steps := []*types.StepWrapper{
 		{
-			Step: &kubernetes.DeleteKubernetesResource{
-				ResourceType:      kubernetes.TypeString(kubernetes.StatefulSet),
-				ResourceName:      agnhostName,
-				ResourceNamespace: "kube-system",
-			}, Opts: &types.StepOptions{
+			Step: &kubernetes.CreateAgnhostDaemonSet{
+				AgnhostName:      agnhostName,
+				AgnhostNamespace: agnhostNamespace,
+			},
+			Opts: &types.StepOptions{
 				SkipSavingParametersToJob: true,
 			},
 		},
 		{
-			Step: &types.Sleep{
-				Duration: sleepDelay,
+			Step: &validatePerNodeDNSMetrics{
+				AgnhostNamespace: agnhostNamespace,
+				AgnhostName:      agnhostName,
+				Query:            perNodeQuery,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
 			},
 		},
 	}
-	return types.NewScenario(scenarioName, steps...)
+
+	return types.NewScenario(name, steps...).WithCleanup(&types.StepWrapper{
+		Step: &kubernetes.DeleteKubernetesResource{
+			ResourceType:      kubernetes.TypeString(kubernetes.DaemonSet),
+			ResourceName:      agnhostName,
+			ResourceNamespace: agnhostNamespace,
+		},
+		Opts: &types.StepOptions{
+			SkipSavingParametersToJob: true,
+		},
+	}).WithFailureDiagnostics(failureLogs(agnhostNamespace, agnhostName)...)
 }
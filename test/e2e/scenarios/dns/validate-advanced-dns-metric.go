@@ -8,7 +8,6 @@ import (
 
 	"github.com/microsoft/retina/test/e2e/common"
 	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
-	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
 	"github.com/pkg/errors"
 )
 
@@ -18,7 +17,7 @@ var (
 )
 
 type ValidateAdvancedDNSRequestMetrics struct {
-	Namespace    string
+	PodNamespace string
 	PodName      string
 	Query        string
 	QueryType    string
@@ -29,16 +28,15 @@ type ValidateAdvancedDNSRequestMetrics struct {
 }
 
 func (v *ValidateAdvancedDNSRequestMetrics) Run() error {
-	metricsEndpoint := fmt.Sprintf("http://localhost:%d/metrics", common.RetinaPort)
 	// Get Pod IP address
-	podIP, err := kubernetes.GetPodIP(v.KubeConfigFilePath, v.Namespace, v.PodName)
+	podIP, err := kubernetes.GetPodIP(v.KubeConfigFilePath, v.PodNamespace, v.PodName)
 	if err != nil {
 		return errors.Wrapf(err, "failed to get pod IP address")
 	}
 
 	validateAdvancedDNSRequestMetrics := map[string]string{
 		"ip":            podIP,
-		"namespace":     v.Namespace,
+		"namespace":     v.PodNamespace,
 		"podname":       v.PodName,
 		"query":         v.Query,
 		"query_type":    v.QueryType,
@@ -46,7 +44,7 @@ func (v *ValidateAdvancedDNSRequestMetrics) Run() error {
 		"workload_name": v.WorkloadName,
 	}
 
-	err = prom.CheckMetric(metricsEndpoint, dnsAdvRequestCountMetricName, validateAdvancedDNSRequestMetrics)
+	err = checkScrapedMetric(dnsAdvRequestCountMetricName, validateAdvancedDNSRequestMetrics)
 	if err != nil {
 		return errors.Wrapf(err, "failed to verify advance dns request metrics %s", dnsAdvRequestCountMetricName)
 	}
@@ -56,7 +54,7 @@ func (v *ValidateAdvancedDNSRequestMetrics) Run() error {
 }
 
 func (v *ValidateAdvancedDNSRequestMetrics) Prevalidate() error {
-	return nil
+	return checkQueryType(v.QueryType)
 }
 
 func (v *ValidateAdvancedDNSRequestMetrics) Stop() error {
@@ -64,7 +62,7 @@ func (v *ValidateAdvancedDNSRequestMetrics) Stop() error {
 }
 
 type ValidateAdvanceDNSResponseMetrics struct {
-	Namespace    string
+	PodNamespace string
 	NumResponse  string
 	PodName      string
 	Query        string
@@ -74,13 +72,15 @@ type ValidateAdvanceDNSResponseMetrics struct {
 	WorkloadKind string
 	WorkloadName string
 
+	NumResponseComparison Comparison
+
 	KubeConfigFilePath string
 }
 
 func (v *ValidateAdvanceDNSResponseMetrics) Run() error {
 	metricsEndpoint := fmt.Sprintf("http://localhost:%d/metrics", common.RetinaPort)
 	// Get Pod IP address
-	podIP, err := kubernetes.GetPodIP(v.KubeConfigFilePath, v.Namespace, v.PodName)
+	podIP, err := kubernetes.GetPodIP(v.KubeConfigFilePath, v.PodNamespace, v.PodName)
 	if err != nil {
 		return errors.Wrapf(err, "failed to get pod IP address")
 	}
@@ -91,18 +91,17 @@ func (v *ValidateAdvanceDNSResponseMetrics) Run() error {
 
 	validateAdvanceDNSResponseMetrics := map[string]string{
 		"ip":            podIP,
-		"namespace":     v.Namespace,
-		"num_response":  v.NumResponse,
+		"namespace":     v.PodNamespace,
 		"podname":       v.PodName,
 		"query":         v.Query,
 		"query_type":    v.QueryType,
-		"response":      v.Response,
+		"response":      canonicalResponse(v.Response),
 		"return_code":   v.ReturnCode,
 		"workload_kind": v.WorkloadKind,
 		"workload_name": v.WorkloadName,
 	}
 
-	err = prom.CheckMetric(metricsEndpoint, dnsAdvResponseCountMetricName, validateAdvanceDNSResponseMetrics)
+	err = checkNumResponse(metricsEndpoint, dnsAdvResponseCountMetricName, validateAdvanceDNSResponseMetrics, v.NumResponse, v.NumResponseComparison)
 	if err != nil {
 		return errors.Wrapf(err, "failed to verify advance dns response metrics %s", dnsAdvRequestCountMetricName)
 	}
@@ -112,7 +111,7 @@ func (v *ValidateAdvanceDNSResponseMetrics) Run() error {
 }
 
 func (v *ValidateAdvanceDNSResponseMetrics) Prevalidate() error {
-	return nil
+	return checkResponse(v.QueryType, v.NumResponse, v.Response)
 }
 
 func (v *ValidateAdvanceDNSResponseMetrics) Stop() error {
@@ -3,12 +3,20 @@
 package dns
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/microsoft/retina/test/e2e/common"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
 	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/microsoft/retina/test/retry"
 	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s "k8s.io/client-go/kubernetes"
 )
 
 var (
@@ -16,20 +24,45 @@ var (
 	dnsBasicResponseCountMetricName = "networkobservability_dns_response_count"
 )
 
+const (
+	dnsMetricRetryAttempts = 60
+	dnsMetricRetryDelay    = 5 * time.Second
+)
+
+// checkScrapedMetric scrapes the port forwarded agent until it has a series of metricName with exactly labels
+func checkScrapedMetric(metricName string, labels map[string]string) error {
+	retrier := retry.Retrier{Attempts: dnsMetricRetryAttempts, Delay: dnsMetricRetryDelay}
+	err := retrier.Do(context.Background(), func() error {
+		families, err := prom.ScrapeMetrics(strconv.Itoa(common.RetinaPort), "metrics")
+		if err != nil {
+			return fmt.Errorf("failed to scrape metrics: %w", err)
+		}
+
+		err = prom.CheckMetricFamilies(families, metricName, labels)
+		if err != nil {
+			log.Printf("%v", err)
+			return fmt.Errorf("failed to find metric: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get prometheus metrics: %w", err)
+	}
+	return nil
+}
+
 type validateBasicDNSRequestMetrics struct {
 	Query     string
 	QueryType string
 }
 
 func (v *validateBasicDNSRequestMetrics) Run() error {
-	metricsEndpoint := fmt.Sprintf("http://localhost:%d/metrics", common.RetinaPort)
-
 	validBasicDNSRequestMetricLabels := map[string]string{
 		"query":      v.Query,
 		"query_type": v.QueryType,
 	}
 
-	err := prom.CheckMetric(metricsEndpoint, dnsBasicRequestCountMetricName, validBasicDNSRequestMetricLabels)
+	err := checkScrapedMetric(dnsBasicRequestCountMetricName, validBasicDNSRequestMetricLabels)
 	if err != nil {
 		return errors.Wrapf(err, "failed to verify basic dns request metrics %s", dnsBasicRequestCountMetricName)
 	}
@@ -39,7 +72,7 @@ func (v *validateBasicDNSRequestMetrics) Run() error {
 }
 
 func (v *validateBasicDNSRequestMetrics) Prevalidate() error {
-	return nil
+	return checkQueryType(v.QueryType)
 }
 
 func (v *validateBasicDNSRequestMetrics) Stop() error {
@@ -51,7 +84,12 @@ type validateBasicDNSResponseMetrics struct {
 	Query       string
 	QueryType   string
 	ReturnCode  string
-	Response    string
+	// Response lists the expected answers. An answer given as "namespace/name" is that Service's ClusterIP,
+	// which depends on the cluster's service CIDR, looked up when the step runs
+	Response           string
+	KubeConfigFilePath string
+
+	NumResponseComparison Comparison
 }
 
 func (v *validateBasicDNSResponseMetrics) Run() error {
@@ -60,16 +98,19 @@ func (v *validateBasicDNSResponseMetrics) Run() error {
 	if v.Response == EmptyResponse {
 		v.Response = ""
 	}
+	response, err := resolveResponseServices(v.KubeConfigFilePath, v.Response)
+	if err != nil {
+		return err
+	}
 
 	validBasicDNSResponseMetricLabels := map[string]string{
-		"num_response": v.NumResponse,
-		"query":        v.Query,
-		"query_type":   v.QueryType,
-		"return_code":  v.ReturnCode,
-		"response":     v.Response,
+		"query":       v.Query,
+		"query_type":  v.QueryType,
+		"return_code": v.ReturnCode,
+		"response":    canonicalResponse(response),
 	}
 
-	err := prom.CheckMetric(metricsEndpoint, dnsBasicResponseCountMetricName, validBasicDNSResponseMetricLabels)
+	err = checkNumResponse(metricsEndpoint, dnsBasicResponseCountMetricName, validBasicDNSResponseMetricLabels, v.NumResponse, v.NumResponseComparison)
 	if err != nil {
 		return errors.Wrapf(err, "failed to verify basic dns response metrics %s", dnsBasicResponseCountMetricName)
 	}
@@ -79,9 +120,40 @@ func (v *validateBasicDNSResponseMetrics) Run() error {
 }
 
 func (v *validateBasicDNSResponseMetrics) Prevalidate() error {
-	return nil
+	return checkResponse(v.QueryType, v.NumResponse, v.Response)
 }
 
 func (v *validateBasicDNSResponseMetrics) Stop() error {
 	return nil
 }
+
+// resolveResponseServices replaces the "namespace/name" answers of response with the ClusterIP of that Service
+func resolveResponseServices(kubeConfigFilePath, response string) (string, error) {
+	if !strings.Contains(response, "/") {
+		return response, nil
+	}
+
+	config, err := kubernetes.BuildConfig(kubeConfigFilePath)
+	if err != nil {
+		return "", fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := k8s.NewForConfig(config)
+	if err != nil {
+		return "", fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	answers := strings.Split(response, ",")
+	for i, answer := range answers {
+		namespace, name, found := strings.Cut(answer, "/")
+		if !found {
+			continue
+		}
+		svc, err := clientset.CoreV1().Services(namespace).Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			return "", fmt.Errorf("error getting service \"%s\": %w", answer, err)
+		}
+		answers[i] = svc.Spec.ClusterIP
+	}
+	return strings.Join(answers, ","), nil
+}
@@ -0,0 +1,96 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package dns
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/microsoft/retina/test/retry"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBurstNotCounted = fmt.Errorf("dns request counter is below the burst size")
+	ErrCounterDrifted  = fmt.Errorf("dns request counter changed while idle")
+)
+
+// validateDNSRequestCounterStability waits for the request counter of Query to reach at least
+// MinimumCount, then scrapes it Scrapes more times, Interval apart, with no traffic in between.
+// Every scrape must return the same total, a counter that decays or keeps growing fails.
+type validateDNSRequestCounterStability struct {
+	Query     string
+	QueryType string
+
+	MinimumCount int
+	Scrapes      int
+	Interval     time.Duration
+}
+
+func (v *validateDNSRequestCounterStability) Run() error {
+	metricsEndpoint := fmt.Sprintf("http://localhost:%d/metrics", common.RetinaPort)
+
+	var total float64
+	waitForBurstFn := func() error {
+		var err error
+		total, err = v.requestCount(metricsEndpoint)
+		if err != nil {
+			return err
+		}
+		if total < float64(v.MinimumCount) {
+			log.Printf("dns request counter for %s is %.0f, waiting for at least %d\n", v.Query, total, v.MinimumCount)
+			return ErrBurstNotCounted
+		}
+		return nil
+	}
+
+	retrier := retry.Retrier{Attempts: largeRRSetRetryAttempts, Delay: largeRRSetRetryDelay}
+	err := retrier.Do(context.Background(), waitForBurstFn)
+	if err != nil {
+		return errors.Wrapf(err, "dns request counter for %s never reached the burst size", v.Query)
+	}
+	log.Printf("dns request counter for %s settled at %.0f after the burst\n", v.Query, total)
+
+	for i := 0; i < v.Scrapes; i++ {
+		time.Sleep(v.Interval)
+
+		current, err := v.requestCount(metricsEndpoint)
+		if err != nil {
+			return err
+		}
+		if current != total {
+			return fmt.Errorf("scrape %d of %d for %s returned %.0f, expected %.0f: %w", i+1, v.Scrapes, v.Query, current, total, ErrCounterDrifted)
+		}
+	}
+	log.Printf("dns request counter for %s held at %.0f across %d idle scrapes\n", v.Query, total, v.Scrapes)
+
+	return nil
+}
+
+func (v *validateDNSRequestCounterStability) requestCount(metricsEndpoint string) (float64, error) {
+	series, err := prom.GetMetricsMatchingLabels(metricsEndpoint, dnsBasicRequestCountMetricName, map[string]string{
+		"query":      v.Query,
+		"query_type": v.QueryType,
+	})
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to scrape %s", dnsBasicRequestCountMetricName)
+	}
+
+	var total float64
+	for _, metric := range series {
+		total += metric.GetCounter().GetValue()
+	}
+	return total, nil
+}
+
+func (v *validateDNSRequestCounterStability) Prevalidate() error {
+	return nil
+}
+
+func (v *validateDNSRequestCounterStability) Stop() error {
+	return nil
+}
@@ -0,0 +1,95 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package dns
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/microsoft/retina/test/retry"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrRepeatedQueriesNotCounted = fmt.Errorf("dns request counter is below the number of queries")
+	ErrRepeatedQueriesOvercount  = fmt.Errorf("dns request counter is above the number of queries")
+)
+
+// validateRepeatedDNSRequestCount waits for the advanced request counter of PodName's Query to reach
+// ExpectedCount, then checks it stays at exactly ExpectedCount once the agent has had Interval to export
+// anything left, so identical queries are neither deduplicated nor counted twice
+type validateRepeatedDNSRequestCount struct {
+	PodNamespace string
+	PodName      string
+	Query        string
+	QueryType    string
+
+	ExpectedCount int
+	Interval      time.Duration
+}
+
+func (v *validateRepeatedDNSRequestCount) Run() error {
+	metricsEndpoint := fmt.Sprintf("http://localhost:%d/metrics", common.RetinaPort)
+
+	var total float64
+	waitForQueriesFn := func() error {
+		var err error
+		total, err = v.requestCount(metricsEndpoint)
+		if err != nil {
+			return err
+		}
+		if total < float64(v.ExpectedCount) {
+			log.Printf("dns request counter of %s for %s is %.0f, waiting for %d\n", v.PodName, v.Query, total, v.ExpectedCount)
+			return ErrRepeatedQueriesNotCounted
+		}
+		return nil
+	}
+
+	retrier := retry.Retrier{Attempts: largeRRSetRetryAttempts, Delay: largeRRSetRetryDelay}
+	err := retrier.Do(context.Background(), waitForQueriesFn)
+	if err != nil {
+		return errors.Wrapf(err, "dns request counter of %s for %s never reached %d", v.PodName, v.Query, v.ExpectedCount)
+	}
+
+	time.Sleep(v.Interval)
+	total, err = v.requestCount(metricsEndpoint)
+	if err != nil {
+		return err
+	}
+	if total != float64(v.ExpectedCount) {
+		return fmt.Errorf("dns request counter of %s for %s is %.0f after %d identical queries: %w", v.PodName, v.Query, total, v.ExpectedCount, ErrRepeatedQueriesOvercount)
+	}
+
+	log.Printf("dns request counter of %s for %s is exactly %d\n", v.PodName, v.Query, v.ExpectedCount)
+	return nil
+}
+
+func (v *validateRepeatedDNSRequestCount) requestCount(metricsEndpoint string) (float64, error) {
+	series, err := prom.GetMetricsMatchingLabels(metricsEndpoint, dnsAdvRequestCountMetricName, map[string]string{
+		"namespace":  v.PodNamespace,
+		"podname":    v.PodName,
+		"query":      v.Query,
+		"query_type": v.QueryType,
+	})
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to scrape %s", dnsAdvRequestCountMetricName)
+	}
+
+	var total float64
+	for _, metric := range series {
+		total += metric.GetCounter().GetValue()
+	}
+	return total, nil
+}
+
+func (v *validateRepeatedDNSRequestCount) Prevalidate() error {
+	return nil
+}
+
+func (v *validateRepeatedDNSRequestCount) Stop() error {
+	return nil
+}
@@ -0,0 +1,61 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package dns
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/pkg/errors"
+)
+
+var ErrTruncatedResponseAbsent = fmt.Errorf("no truncated dns response recorded")
+
+// validateTruncatedDNSResponse checks the UDP response to Query that was truncated to fit 512 bytes is recorded,
+// with fewer than FullResponses answers
+type validateTruncatedDNSResponse struct {
+	Query         string
+	QueryType     string
+	FullResponses int
+}
+
+func (v *validateTruncatedDNSResponse) Run() error {
+	metricsEndpoint := fmt.Sprintf("http://localhost:%d/metrics", common.RetinaPort)
+
+	series, err := prom.GetMetricsMatchingLabels(metricsEndpoint, dnsBasicResponseCountMetricName, map[string]string{
+		"query":       v.Query,
+		"query_type":  v.QueryType,
+		"return_code": "No Error",
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to scrape %s", dnsBasicResponseCountMetricName)
+	}
+	for _, metric := range series {
+		for _, label := range metric.GetLabel() {
+			if label.GetName() != "num_response" {
+				continue
+			}
+			numResponse, atoiErr := strconv.Atoi(label.GetValue())
+			if atoiErr != nil {
+				return fmt.Errorf("num_response %q of query %s: %w", label.GetValue(), v.Query, atoiErr)
+			}
+			if numResponse < v.FullResponses {
+				log.Printf("found the truncated response of query %s with %d of %d answers\n", v.Query, numResponse, v.FullResponses)
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("query %s has %d series, none with fewer than %d answers: %w", v.Query, len(series), v.FullResponses, ErrTruncatedResponseAbsent)
+}
+
+func (v *validateTruncatedDNSResponse) Prevalidate() error {
+	return nil
+}
+
+func (v *validateTruncatedDNSResponse) Stop() error {
+	return nil
+}
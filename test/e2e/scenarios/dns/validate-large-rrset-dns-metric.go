@@ -0,0 +1,111 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package dns
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/microsoft/retina/test/retry"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrLargeRRSetMetricNotFound = fmt.Errorf("no dns response metric found for large rrset query")
+	ErrTooManyDNSResponseSeries = fmt.Errorf("too many dns response series for a single query")
+	ErrUnexpectedNumResponse    = fmt.Errorf("unexpected num_response label")
+	ErrUnexpectedResponseLabel  = fmt.Errorf("unexpected response label")
+)
+
+const (
+	largeRRSetRetryAttempts = 20
+	largeRRSetRetryDelay    = 5 * time.Second
+)
+
+// ValidateLargeRRSetDNSResponseMetrics checks that a response carrying many answers is reported
+// as a single well formed series: num_response counts every answer and the response label
+// holds each address exactly once. The order of answers is up to the server, so the response
+// label is compared as a set, and at most MaxSeries series may exist for the query.
+type ValidateLargeRRSetDNSResponseMetrics struct {
+	Query     string
+	QueryType string
+
+	ExpectedResponses []string
+	MaxSeries         int
+}
+
+func (v *ValidateLargeRRSetDNSResponseMetrics) Run() error {
+	metricsEndpoint := fmt.Sprintf("http://localhost:%d/metrics", common.RetinaPort)
+
+	expected := append([]string{}, v.ExpectedResponses...)
+	sort.Strings(expected)
+
+	check := func() error {
+		series, err := prom.GetMetricsMatchingLabels(metricsEndpoint, dnsBasicResponseCountMetricName, map[string]string{
+			"query":       v.Query,
+			"query_type":  v.QueryType,
+			"return_code": "No Error",
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to scrape %s", dnsBasicResponseCountMetricName)
+		}
+
+		if len(series) == 0 {
+			log.Printf("no %s series found yet for query %s\n", dnsBasicResponseCountMetricName, v.Query)
+			return fmt.Errorf("%w: %w", ErrLargeRRSetMetricNotFound, prom.ErrNoMetricFound)
+		}
+
+		if len(series) > v.MaxSeries {
+			return fmt.Errorf("found %d series for query %s, expected at most %d: %w", len(series), v.Query, v.MaxSeries, ErrTooManyDNSResponseSeries)
+		}
+
+		for _, metric := range series {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+
+			if labels["num_response"] != strconv.Itoa(len(expected)) {
+				return fmt.Errorf("got num_response %q, expected %d: %w", labels["num_response"], len(expected), ErrUnexpectedNumResponse)
+			}
+
+			got := strings.Split(labels["response"], ",")
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(expected, ",") {
+				return fmt.Errorf("got response %q, expected addresses %v: %w", labels["response"], expected, ErrUnexpectedResponseLabel)
+			}
+		}
+
+		log.Printf("found %d well formed %s series for query %s with %d answers\n", len(series), dnsBasicResponseCountMetricName, v.Query, len(expected))
+		return nil
+	}
+
+	retrier := retry.Retrier{Attempts: largeRRSetRetryAttempts, Delay: largeRRSetRetryDelay}
+	err := retrier.Do(context.Background(), check)
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate large rrset dns response metrics")
+	}
+
+	return nil
+}
+
+func (v *ValidateLargeRRSetDNSResponseMetrics) Prevalidate() error {
+	if len(v.ExpectedResponses) == 0 {
+		return fmt.Errorf("no expected responses for query %s: %w", v.Query, ErrUnexpectedResponseLabel)
+	}
+	if v.MaxSeries < 1 {
+		return fmt.Errorf("max series must be positive, got %d: %w", v.MaxSeries, ErrTooManyDNSResponseSeries)
+	}
+	return nil
+}
+
+func (v *ValidateLargeRRSetDNSResponseMetrics) Stop() error {
+	return nil
+}
@@ -0,0 +1,93 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package dns
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/microsoft/retina/test/retry"
+)
+
+var ErrNumResponseMismatch = fmt.Errorf("no series with a matching num_response")
+
+// Comparison is how a response validator compares the num_response label of a series to NumResponse.
+// The zero value is Equal, which matches the label exactly
+type Comparison int
+
+const (
+	Equal Comparison = iota
+	GreaterThanOrEqual
+	LessThanOrEqual
+)
+
+func (c Comparison) String() string {
+	switch c {
+	case Equal:
+		return "=="
+	case GreaterThanOrEqual:
+		return ">="
+	case LessThanOrEqual:
+		return "<="
+	default:
+		return fmt.Sprintf("Comparison(%d)", int(c))
+	}
+}
+
+func (c Comparison) matches(observed, expected int) bool {
+	switch c {
+	case GreaterThanOrEqual:
+		return observed >= expected
+	case LessThanOrEqual:
+		return observed <= expected
+	default:
+		return observed == expected
+	}
+}
+
+// checkNumResponse checks the agent has a series of metricName with labels whose num_response compares to
+// numResponse with cmp. Equal leaves it to prom.CheckMetric, which matches the label set exactly
+func checkNumResponse(metricsEndpoint, metricName string, labels map[string]string, numResponse string, cmp Comparison) error {
+	if cmp == Equal {
+		labels["num_response"] = numResponse
+		return prom.CheckMetric(metricsEndpoint, metricName, labels) // nolint:wrapcheck // callers wrap it
+	}
+
+	expected, err := strconv.Atoi(numResponse)
+	if err != nil {
+		return fmt.Errorf("num_response %q to compare with %s: %w", numResponse, cmp, err)
+	}
+
+	retrier := retry.Retrier{Attempts: dnsMetricRetryAttempts, Delay: dnsMetricRetryDelay}
+	err = retrier.Do(context.Background(), func() error {
+		series, scrapeErr := prom.GetMetricsMatchingLabels(metricsEndpoint, metricName, labels)
+		if scrapeErr != nil {
+			return fmt.Errorf("failed to scrape %s: %w", metricName, scrapeErr)
+		}
+
+		observed := []string{}
+		for _, metric := range series {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() != "num_response" {
+					continue
+				}
+				value, atoiErr := strconv.Atoi(label.GetValue())
+				if atoiErr == nil && cmp.matches(value, expected) {
+					return nil
+				}
+				observed = append(observed, label.GetValue())
+			}
+		}
+
+		mismatch := fmt.Errorf("no %s series with num_response %s %d, observed num_response %v: %w", metricName, cmp, expected, observed, ErrNumResponseMismatch)
+		log.Printf("%v", mismatch)
+		return mismatch
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get prometheus metrics: %w", err)
+	}
+	return nil
+}
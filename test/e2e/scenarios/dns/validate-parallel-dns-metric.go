@@ -0,0 +1,73 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package dns
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"strings"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrUnsupportedQueryType  = fmt.Errorf("unsupported query type")
+	ErrCrossTypeDNSResponse  = fmt.Errorf("dns response address does not match query type")
+	ErrNoDNSResponseForQuery = fmt.Errorf("no dns response metric found for query type")
+)
+
+// validateDNSResponseAddressFamily checks every response series recorded for Query and QueryType
+// only holds addresses of the family that type asks for, so an A series never carries an AAAA
+// answer from a concurrent lookup of the same name and vice versa
+type validateDNSResponseAddressFamily struct {
+	Query     string
+	QueryType string
+}
+
+func (v *validateDNSResponseAddressFamily) Run() error {
+	metricsEndpoint := fmt.Sprintf("http://localhost:%d/metrics", common.RetinaPort)
+
+	series, err := prom.GetMetricsMatchingLabels(metricsEndpoint, dnsBasicResponseCountMetricName, map[string]string{
+		"query":      v.Query,
+		"query_type": v.QueryType,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to scrape %s", dnsBasicResponseCountMetricName)
+	}
+
+	if len(series) == 0 {
+		return fmt.Errorf("query %s type %s: %w: %w", v.Query, v.QueryType, ErrNoDNSResponseForQuery, prom.ErrNoMetricFound)
+	}
+
+	for _, metric := range series {
+		for _, label := range metric.GetLabel() {
+			if label.GetName() != "response" || label.GetValue() == "" {
+				continue
+			}
+			for _, address := range strings.Split(label.GetValue(), ",") {
+				ip := net.ParseIP(address)
+				isV4 := ip != nil && ip.To4() != nil
+				if (v.QueryType == "A") != isV4 {
+					return fmt.Errorf("query %s type %s has response %s: %w", v.Query, v.QueryType, address, ErrCrossTypeDNSResponse)
+				}
+			}
+		}
+	}
+	log.Printf("all %d %s series for query %s type %s hold matching addresses\n", len(series), dnsBasicResponseCountMetricName, v.Query, v.QueryType)
+
+	return nil
+}
+
+func (v *validateDNSResponseAddressFamily) Prevalidate() error {
+	if v.QueryType != "A" && v.QueryType != "AAAA" {
+		return fmt.Errorf("query type %s: %w", v.QueryType, ErrUnsupportedQueryType)
+	}
+	return nil
+}
+
+func (v *validateDNSResponseAddressFamily) Stop() error {
+	return nil
+}
@@ -0,0 +1,163 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT license.
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	k8s "k8s.io/client-go/kubernetes"
+)
+
+const (
+	perNodeTimeout  = 5 * time.Minute
+	perNodeInterval = 5 * time.Second
+)
+
+var (
+	ErrNoAgnhostPods        = fmt.Errorf("no running agnhost pods")
+	ErrNoAgentOnNode        = fmt.Errorf("no running retina agent on the node")
+	ErrQueryNotCountedLocal = fmt.Errorf("dns query not counted by the agent on the pod's node")
+)
+
+// validatePerNodeDNSMetrics sends Query from every pod of the agnhost DaemonSet at once, and validates the agent on
+// each pod's node counts the pod's query in its advanced request counter, so each node's agent reports the
+// traffic of its own node
+type validatePerNodeDNSMetrics struct {
+	KubeConfigFilePath string
+	AgnhostNamespace   string
+	AgnhostName        string
+	Query              string
+}
+
+func (v *validatePerNodeDNSMetrics) Run() error {
+	config, err := kubernetes.BuildConfig(v.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := k8s.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), perNodeTimeout)
+	defer cancel()
+
+	agnhosts, err := clientset.CoreV1().Pods(v.AgnhostNamespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=" + v.AgnhostName,
+		FieldSelector: "status.phase=Running",
+	})
+	if err != nil {
+		return fmt.Errorf("error listing agnhost pods: %w", err)
+	}
+	if len(agnhosts.Items) == 0 {
+		return fmt.Errorf("daemonset \"%s\": %w", v.AgnhostName, ErrNoAgnhostPods)
+	}
+
+	agents, err := clientset.CoreV1().Pods("kube-system").List(ctx, metav1.ListOptions{
+		LabelSelector: "k8s-app=retina",
+		FieldSelector: "status.phase=Running",
+	})
+	if err != nil {
+		return fmt.Errorf("error listing retina pods: %w", err)
+	}
+	agentByNode := make(map[string]string)
+	for i := range agents.Items {
+		agentByNode[agents.Items[i].Spec.NodeName] = agents.Items[i].Name
+	}
+
+	// agnhost pod name to the agent on its node
+	agentOf := make(map[string]string)
+	for i := range agnhosts.Items {
+		pod := &agnhosts.Items[i]
+		agent, ok := agentByNode[pod.Spec.NodeName]
+		if !ok {
+			return fmt.Errorf("node \"%s\" of pod \"%s\": %w", pod.Spec.NodeName, pod.Name, ErrNoAgentOnNode)
+		}
+		agentOf[pod.Name] = agent
+	}
+
+	// the queries go out from every node at about the same time
+	command := fmt.Sprintf("dig +tries=1 A %s", v.Query)
+	execErrs := make(chan error, len(agentOf))
+	for pod := range agentOf {
+		go func(pod string) {
+			_, execErr := kubernetes.ExecPod(ctx, clientset, config, v.AgnhostNamespace, pod, command)
+			if execErr != nil {
+				execErr = fmt.Errorf("error querying %s from pod \"%s\": %w", v.Query, pod, execErr)
+			}
+			execErrs <- execErr
+		}(pod)
+	}
+	var errs []error
+	for range agentOf {
+		errs = append(errs, <-execErrs)
+	}
+	if err = errors.Join(errs...); err != nil {
+		return err
+	}
+
+	forwards := make(map[string]*kubernetes.PortForwarder)
+	defer func() {
+		for _, forward := range forwards {
+			forward.Stop()
+		}
+	}()
+	for _, agent := range agentOf {
+		if _, ok := forwards[agent]; ok {
+			continue
+		}
+		forward, forwardErr := kubernetes.ForwardToPod(ctx, config, "kube-system", "k8s-app=retina", agent, common.RetinaPort)
+		if forwardErr != nil {
+			return fmt.Errorf("error port forwarding to retina pod \"%s\": %w", agent, forwardErr)
+		}
+		forwards[agent] = forward
+	}
+
+	var lastErr error
+	err = wait.PollUntilContextCancel(ctx, perNodeInterval, true, func(context.Context) (bool, error) {
+		metrics := prom.ScrapeAgents(forwards, "metrics")
+		for pod, agent := range agentOf {
+			if scrapeErr, ok := metrics.Unreachable[agent]; ok {
+				lastErr = fmt.Errorf("error scraping retina pod \"%s\": %w", agent, scrapeErr)
+				log.Printf("%v\n", lastErr)
+				return false, nil
+			}
+			_, byAgent := metrics.Sum(dnsAdvRequestCountMetricName, map[string]string{
+				"namespace":  v.AgnhostNamespace,
+				"podname":    pod,
+				"query":      v.Query,
+				"query_type": "A",
+			})
+			if byAgent[agent] < 1 {
+				lastErr = fmt.Errorf("pod \"%s\", agent \"%s\": %w", pod, agent, ErrQueryNotCountedLocal)
+				log.Printf("%v\n", lastErr)
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return errors.Join(err, lastErr)
+	}
+
+	log.Printf("the agents on %d nodes each counted the query of their node's agnhost pod\n", len(forwards))
+	return nil
+}
+
+func (v *validatePerNodeDNSMetrics) Prevalidate() error {
+	return nil
+}
+
+func (v *validatePerNodeDNSMetrics) Stop() error {
+	return nil
+}
@@ -1,8 +1,11 @@
 package drop
 
 import (
+	"fmt"
+	"strconv"
 	"time"
 
+	"github.com/microsoft/retina/test/e2e/common"
 	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
 	"github.com/microsoft/retina/test/e2e/framework/types"
 )
@@ -13,8 +16,35 @@ const (
 	UDP        = "UDP"
 
 	IPTableRuleDrop = "IPTABLE_RULE_DROP"
+	// the basic drop reason plugin can't tell the direction of a packet dropped by an iptables rule
+	UnknownDirection = "unknown"
+
+	droppedDNSAgnhost   = "agnhost-dns-drop"
+	droppedDNSPolicy    = "deny-dns-egress"
+	droppedDNSNamespace = "kube-system"
+	droppedDNSQuery     = "kubernetes.default.svc.cluster.local."
+)
+
+// the dropped DNS scenario fails on a wedged cluster instead of leaving it to the CI timeout
+const (
+	execTimeout        = 60 * time.Second
+	portForwardTimeout = 30 * time.Second
 )
 
+// denies all egress of the agnhost, the DNS lookups it makes included. Ingress is left alone
+var droppedDNSPolicyYAML = fmt.Sprintf(`apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: %s
+spec:
+  podSelector:
+    matchLabels:
+      app: %s
+  policyTypes:
+  - Egress
+  egress: []
+`, droppedDNSPolicy, droppedDNSAgnhost)
+
 func ValidateDropMetric() *types.Scenario {
 	name := "Drop Metrics"
 	steps := []*types.StepWrapper{
@@ -106,3 +136,126 @@ func ValidateDropMetric() *types.Scenario {
 	}
 	return types.NewScenario(name, steps...)
 }
+
+// ValidateDroppedDNSMetric applies a NetworkPolicy blocking the egress of an agnhost, makes a DNS lookup from it
+// that has to time out, and waits for the agent on its node to count the dropped packets with their reason and
+// direction. The policy, port forward and agnhost are removed even when a step fails
+func ValidateDroppedDNSMetric() *types.Scenario {
+	name := "Dropped DNS Metrics"
+	policy := &kubernetes.ApplyManifest{
+		Manifest: kubernetes.Manifest{
+			YAML:      droppedDNSPolicyYAML,
+			Namespace: droppedDNSNamespace,
+		},
+	}
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      droppedDNSAgnhost,
+				AgnhostNamespace: droppedDNSNamespace,
+			},
+		},
+		{
+			Step: &kubernetes.WaitForPodReady{
+				PodNamespace: droppedDNSNamespace,
+				PodSelector:  droppedDNSAgnhost + "-0",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: policy,
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			// retried until it fails, as the policy takes a moment to be enforced
+			Step: &kubernetes.ExecInPod{
+				PodName:      droppedDNSAgnhost + "-0",
+				PodNamespace: droppedDNSNamespace,
+				Command:      "dig +tries=1 +time=5 " + droppedDNSQuery,
+			},
+			Opts: &types.StepOptions{
+				ExpectError:               true,
+				SkipSavingParametersToJob: true,
+				Timeout:                   execTimeout,
+				Retry:                     &types.Retry{Attempts: 5, Delay: sleepDelay},
+			},
+		},
+		{
+			Step: &kubernetes.PortForward{
+				Namespace:             "kube-system",
+				LabelSelector:         "k8s-app=retina",
+				LocalPort:             strconv.Itoa(common.RetinaPort),
+				RemotePort:            strconv.Itoa(common.RetinaPort),
+				Endpoint:              "metrics",
+				OptionalLabelAffinity: "app=" + droppedDNSAgnhost, // port forward to a pod on a node that also has this pod with this label, assuming same namespace
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "dropped-dns-port-forward",
+				Timeout:                   portForwardTimeout,
+			},
+		},
+		{
+			Step: &WaitForRetinaDropMetric{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				Reason:                  IPTableRuleDrop,
+				Direction:               UnknownDirection,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	// runs even when a step fails, so the policy doesn't block the egress of a later run's agnhost
+	cleanup := []*types.StepWrapper{
+		{
+			Step: &types.Stop{
+				BackgroundID: "dropped-dns-port-forward",
+			},
+		},
+		{
+			Step: &kubernetes.DeleteManifest{
+				Applied: policy,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.DeleteKubernetesResource{
+				ResourceType:      kubernetes.TypeString(kubernetes.StatefulSet),
+				ResourceName:      droppedDNSAgnhost,
+				ResourceNamespace: droppedDNSNamespace,
+			}, Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	diagnostics := []*types.StepWrapper{
+		{
+			Step: &kubernetes.GetPodLogs{
+				PodNamespace:     "kube-system",
+				PodLabelSelector: "k8s-app=retina",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	return types.NewScenario(name, steps...).WithCleanup(cleanup...).WithFailureDiagnostics(diagnostics...).
+		WithPreconditions(&types.StepWrapper{
+			Step: &kubernetes.SkipUnlessNodeOS{
+				OS: "linux",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		})
+}
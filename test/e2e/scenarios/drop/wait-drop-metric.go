@@ -0,0 +1,49 @@
+package drop
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+)
+
+// WaitForRetinaDropMetric polls the port forwarded agent until both its drop count and drop bytes metrics have a
+// series with Reason and Direction. The agent exports a drop some time after the packet was dropped, so a scenario
+// waits on it with this rather than a fixed Sleep before validating. Timeout defaults to 2 minutes
+type WaitForRetinaDropMetric struct {
+	PortForwardedRetinaPort string
+	Reason                  string
+	Direction               string
+	Timeout                 time.Duration
+}
+
+func (w *WaitForRetinaDropMetric) Run() error {
+	labels := map[string]string{
+		reasonKey: w.Reason, directionKey: w.Direction,
+	}
+
+	for _, metricName := range []string{dropCountMetricName, dropBytesMetricName} {
+		wait := &prom.WaitForMetric{
+			PortForwardedRetinaPort: w.PortForwardedRetinaPort,
+			MetricName:              metricName,
+			Labels:                  labels,
+			Timeout:                 w.Timeout,
+		}
+		err := wait.Run()
+		if err != nil {
+			return fmt.Errorf("failed to verify prometheus metrics %s: %w", metricName, err)
+		}
+	}
+
+	log.Printf("found drop metrics matching %+v\n", labels)
+	return nil
+}
+
+func (w *WaitForRetinaDropMetric) Prevalidate() error {
+	return nil
+}
+
+func (w *WaitForRetinaDropMetric) Stop() error {
+	return nil
+}
@@ -0,0 +1,138 @@
+package dualstack
+
+import (
+	"strconv"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	workloadNamespace = "retina-dual-stack"
+	requestsPerFamily = 10
+)
+
+// ValidateDualStackServiceMetrics puts a server pod behind a dual-stack Service, sends traffic to its IPv4 and
+// then its IPv6 ClusterIP, and validates after each that the server's flow metrics are recorded against its
+// own address of that family. It's skipped on clusters that aren't dual-stack.
+func ValidateDualStackServiceMetrics() *types.Scenario {
+	name := "Dual Stack Service Flow Metrics"
+	clientName := "agnhost-dual-stack-client"
+	serverName := "agnhost-dual-stack-server"
+	serviceName := "dual-stack-svc"
+
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.CreateNamespace{
+				NamespaceName: workloadNamespace,
+				Observe:       true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      serverName,
+				AgnhostNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      clientName,
+				AgnhostNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostService{
+				ServiceName:      serviceName,
+				ServiceNamespace: workloadNamespace,
+				AgnhostName:      serverName,
+				DualStack:        true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.PortForward{
+				Namespace:                          "kube-system",
+				LabelSelector:                      "k8s-app=retina",
+				LocalPort:                          strconv.Itoa(common.RetinaPort),
+				RemotePort:                         strconv.Itoa(common.RetinaPort),
+				Endpoint:                           "metrics",
+				OptionalLabelAffinity:              "app=" + serverName,
+				OptionalLabelAffinityAllNamespaces: true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "dual-stack-port-forward",
+			},
+		},
+	}
+
+	for _, family := range []v1.IPFamily{v1.IPv4Protocol, v1.IPv6Protocol} {
+		steps = append(steps,
+			&types.StepWrapper{
+				Step: &SendToServiceClusterIP{
+					PodNamespace:     workloadNamespace,
+					PodName:          clientName + "-0",
+					ServiceNamespace: workloadNamespace,
+					ServiceName:      serviceName,
+					IPFamily:         string(family),
+					Requests:         requestsPerFamily,
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+				},
+			},
+			&types.StepWrapper{
+				Step: &ValidateFamilyAttribution{
+					PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+					NamespaceName:           workloadNamespace,
+					PodName:                 serverName + "-0",
+					IPFamily:                string(family),
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+				},
+			},
+		)
+	}
+
+	steps = append(steps,
+		&types.StepWrapper{
+			Step: &types.Stop{
+				BackgroundID: "dual-stack-port-forward",
+			},
+		},
+		// deleting the namespace removes the workloads and the service with it
+		&types.StepWrapper{
+			Step: &kubernetes.DeleteKubernetesResource{
+				ResourceType:      kubernetes.TypeString(kubernetes.Namespace),
+				ResourceName:      workloadNamespace,
+				ResourceNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	)
+
+	return types.NewScenario(name, steps...).WithPreconditions(
+		&types.StepWrapper{
+			Step: &kubernetes.SkipUnlessDualStack{},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	)
+}
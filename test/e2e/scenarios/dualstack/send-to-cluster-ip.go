@@ -0,0 +1,100 @@
+package dualstack
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const requestTimeout = 2 * time.Minute
+
+var ErrNoAddressForFamily = fmt.Errorf("no address of the ip family")
+
+// SendToServiceClusterIP makes Requests HTTP requests from the pod to the Service's ClusterIP of IPFamily,
+// addressed by IP rather than by name so the address family isn't left to the resolver
+type SendToServiceClusterIP struct {
+	KubeConfigFilePath string
+	PodNamespace       string
+	PodName            string
+	ServiceNamespace   string
+	ServiceName        string
+	IPFamily           string
+	Requests           int
+}
+
+func (s *SendToServiceClusterIP) Run() error {
+	config, err := k8s.BuildConfig(s.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	svc, err := clientset.CoreV1().Services(s.ServiceNamespace).Get(ctx, s.ServiceName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting service \"%s\": %w", s.ServiceName, err)
+	}
+
+	clusterIP, err := addressOfFamily(svc.Spec.ClusterIPs, v1.IPFamily(s.IPFamily))
+	if err != nil {
+		return fmt.Errorf("error getting %s cluster ip of service \"%s\": %w", s.IPFamily, s.ServiceName, err)
+	}
+
+	// -g stops curl reading the brackets around an IPv6 address as a glob
+	command := fmt.Sprintf("curl -g -s -m 5 -o /dev/null http://%s", net.JoinHostPort(clusterIP, fmt.Sprint(k8s.AgnhostHTTPPort)))
+	for i := 0; i < s.Requests; i++ {
+		output, execErr := k8s.ExecPod(ctx, clientset, config, s.PodNamespace, s.PodName, command)
+		if execErr != nil {
+			return fmt.Errorf("error sending request %d from pod \"%s\" to %s: %s: %w", i, s.PodName, clusterIP, string(output), execErr)
+		}
+	}
+
+	log.Printf("sent %d requests from pod %s to %s cluster ip %s\n", s.Requests, s.PodName, s.IPFamily, clusterIP)
+	return nil
+}
+
+func (s *SendToServiceClusterIP) Prevalidate() error {
+	if s.IPFamily != string(v1.IPv4Protocol) && s.IPFamily != string(v1.IPv6Protocol) {
+		return fmt.Errorf("ip family \"%s\" is neither %s nor %s: %w", s.IPFamily, v1.IPv4Protocol, v1.IPv6Protocol, ErrNoAddressForFamily)
+	}
+	return nil
+}
+
+func (s *SendToServiceClusterIP) Stop() error {
+	return nil
+}
+
+// addressOfFamily returns the first of addresses in family
+func addressOfFamily(addresses []string, family v1.IPFamily) (string, error) {
+	for _, address := range addresses {
+		if familyOf(address) == family {
+			return address, nil
+		}
+	}
+	return "", fmt.Errorf("%s not among %v: %w", family, addresses, ErrNoAddressForFamily)
+}
+
+func familyOf(address string) v1.IPFamily {
+	ip := net.ParseIP(address)
+	switch {
+	case ip == nil:
+		return v1.IPFamilyUnknown
+	case ip.To4() != nil:
+		return v1.IPv4Protocol
+	default:
+		return v1.IPv6Protocol
+	}
+}
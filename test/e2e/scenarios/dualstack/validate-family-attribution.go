@@ -0,0 +1,112 @@
+package dualstack
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/microsoft/retina/test/retry"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	advForwardCountMetricName = "networkobservability_adv_forward_count"
+
+	metricRetryAttempts = 20
+	metricRetryDelay    = 5 * time.Second
+)
+
+var (
+	ErrNoIngressSeries  = fmt.Errorf("no ingress series for the pod address")
+	ErrWrongAttribution = fmt.Errorf("pod address attributed to another workload")
+)
+
+// ValidateFamilyAttribution checks the pod's own address of IPFamily has ingress flow series attributed to the pod,
+// so traffic through a dual-stack Service's ClusterIP of that family is recorded against the backend address of
+// the same family rather than the other one
+type ValidateFamilyAttribution struct {
+	PortForwardedRetinaPort string
+	KubeConfigFilePath      string
+	NamespaceName           string
+	PodName                 string
+	IPFamily                string
+}
+
+func (v *ValidateFamilyAttribution) Run() error {
+	config, err := k8s.BuildConfig(v.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	pod, err := clientset.CoreV1().Pods(v.NamespaceName).Get(context.Background(), v.PodName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting pod \"%s\": %w", v.PodName, err)
+	}
+
+	podIPs := make([]string, 0, len(pod.Status.PodIPs))
+	for _, podIP := range pod.Status.PodIPs {
+		podIPs = append(podIPs, podIP.IP)
+	}
+	podIP, err := addressOfFamily(podIPs, v1.IPFamily(v.IPFamily))
+	if err != nil {
+		return fmt.Errorf("error getting %s address of pod \"%s\": %w", v.IPFamily, v.PodName, err)
+	}
+
+	promAddress := fmt.Sprintf("http://localhost:%s/metrics", v.PortForwardedRetinaPort)
+
+	checkFn := func() error {
+		series, scrapeErr := prom.GetMetricsMatchingLabels(promAddress, advForwardCountMetricName, map[string]string{"ip": podIP})
+		if scrapeErr != nil {
+			return fmt.Errorf("failed to scrape %s: %w", advForwardCountMetricName, scrapeErr)
+		}
+
+		ingress := 0
+		for _, metric := range series {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+
+			if labels["namespace"] != v.NamespaceName || labels["podname"] != v.PodName {
+				return fmt.Errorf("series for %s is attributed to %s/%s instead of %s/%s: %w",
+					podIP, labels["namespace"], labels["podname"], v.NamespaceName, v.PodName, ErrWrongAttribution)
+			}
+			if labels["direction"] == "ingress" {
+				ingress++
+			}
+		}
+
+		if ingress == 0 {
+			log.Printf("no ingress %s series for %s/%s (%s) yet\n", advForwardCountMetricName, v.NamespaceName, v.PodName, podIP)
+			return ErrNoIngressSeries
+		}
+		return nil
+	}
+
+	retrier := retry.Retrier{Attempts: metricRetryAttempts, Delay: metricRetryDelay}
+	err = retrier.Do(context.Background(), checkFn)
+	if err != nil {
+		return fmt.Errorf("failed to verify prometheus metrics %s for %s: %w", advForwardCountMetricName, v.IPFamily, err)
+	}
+
+	log.Printf("%s series for %s are attributed to %s/%s\n", v.IPFamily, podIP, v.NamespaceName, v.PodName)
+	return nil
+}
+
+func (v *ValidateFamilyAttribution) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateFamilyAttribution) Stop() error {
+	return nil
+}
@@ -0,0 +1,71 @@
+package encryption
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	ciliumConfigMapName      = "cilium-config"
+	ciliumConfigMapNamespace = "kube-system"
+	wireGuardConfigKey       = "enable-wireguard"
+
+	detectTimeout = 30 * time.Second
+)
+
+// wireGuardState is filled in by DetectWireGuardEncryption and read by the validators after it,
+// so a cluster without encryption runs the scenario without asserting on it
+type wireGuardState struct {
+	enabled bool
+}
+
+// DetectWireGuardEncryption checks whether the cluster's Cilium datapath encrypts pod traffic with WireGuard.
+// Clusters without Cilium, or with encryption off, are reported as unencrypted rather than failing
+type DetectWireGuardEncryption struct {
+	KubeConfigFilePath string
+
+	encryption *wireGuardState
+}
+
+func (d *DetectWireGuardEncryption) Run() error {
+	config, err := k8s.BuildConfig(d.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), detectTimeout)
+	defer cancel()
+
+	configMap, err := clientset.CoreV1().ConfigMaps(ciliumConfigMapNamespace).Get(ctx, ciliumConfigMapName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		log.Printf("no %s/%s config map, the datapath is not encrypted with WireGuard\n", ciliumConfigMapNamespace, ciliumConfigMapName)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error getting %s/%s config map: %w", ciliumConfigMapNamespace, ciliumConfigMapName, err)
+	}
+
+	d.encryption.enabled = configMap.Data[wireGuardConfigKey] == "true"
+	log.Printf("WireGuard encryption enabled: %t\n", d.encryption.enabled)
+	return nil
+}
+
+func (d *DetectWireGuardEncryption) Prevalidate() error {
+	return nil
+}
+
+func (d *DetectWireGuardEncryption) Stop() error {
+	return nil
+}
@@ -0,0 +1,157 @@
+package encryption
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+)
+
+const (
+	workloadNamespace = "retina-encryption"
+
+	sleepDelay = 5 * time.Second
+	requests   = 5
+)
+
+// ValidateWireGuardFlowMetrics sends traffic between two pods, which the agnhost anti-affinity places on
+// separate nodes where possible so it crosses the encrypted tunnel, and validates each node's agent still
+// attributes the traffic to its pod. On clusters without WireGuard encryption the traffic is sent but the
+// validation is skipped
+func ValidateWireGuardFlowMetrics() *types.Scenario {
+	name := "WireGuard Encrypted Flow Metrics"
+	clientName := "agnhost-encryption-client"
+	serverName := "agnhost-encryption-server"
+	serviceName := "encryption-server"
+	encryption := &wireGuardState{}
+
+	steps := []*types.StepWrapper{
+		{
+			Step: &DetectWireGuardEncryption{
+				encryption: encryption,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateNamespace{
+				NamespaceName: workloadNamespace,
+				Observe:       true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      serverName,
+				AgnhostNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      clientName,
+				AgnhostNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostService{
+				ServiceName:      serviceName,
+				ServiceNamespace: workloadNamespace,
+				AgnhostName:      serverName,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	for i := 0; i < requests; i++ {
+		steps = append(steps,
+			&types.StepWrapper{
+				Step: &kubernetes.ExecInPod{
+					PodName:      clientName + "-0",
+					PodNamespace: workloadNamespace,
+					Command:      fmt.Sprintf("curl -s -m 5 http://%s.%s.svc.cluster.local:%d", serviceName, workloadNamespace, kubernetes.AgnhostHTTPPort),
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+				},
+			},
+			&types.StepWrapper{
+				Step: &types.Sleep{
+					Duration: sleepDelay,
+				},
+			},
+		)
+	}
+
+	// each end of the traffic is checked on the agent of its own node
+	ends := []struct {
+		agnhostName string
+		direction   string
+	}{
+		{agnhostName: clientName, direction: "egress"},
+		{agnhostName: serverName, direction: "ingress"},
+	}
+	for _, end := range ends {
+		portForwardID := "encryption-port-forward-" + end.agnhostName
+		steps = append(steps,
+			&types.StepWrapper{
+				Step: &kubernetes.PortForward{
+					Namespace:                          "kube-system",
+					LabelSelector:                      "k8s-app=retina",
+					LocalPort:                          strconv.Itoa(common.RetinaPort),
+					RemotePort:                         strconv.Itoa(common.RetinaPort),
+					Endpoint:                           "metrics",
+					OptionalLabelAffinity:              "app=" + end.agnhostName,
+					OptionalLabelAffinityAllNamespaces: true,
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+					RunInBackgroundWithID:     portForwardID,
+				},
+			},
+			&types.StepWrapper{
+				Step: &ValidateEncryptedFlowAttribution{
+					PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+					NamespaceName:           workloadNamespace,
+					PodName:                 end.agnhostName + "-0",
+					Direction:               end.direction,
+					encryption:              encryption,
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+				},
+			},
+			&types.StepWrapper{
+				Step: &types.Stop{
+					BackgroundID: portForwardID,
+				},
+			},
+		)
+	}
+
+	steps = append(steps, &types.StepWrapper{
+		Step: &kubernetes.DeleteKubernetesResource{
+			ResourceType:      kubernetes.TypeString(kubernetes.Namespace),
+			ResourceName:      workloadNamespace,
+			ResourceNamespace: workloadNamespace,
+		},
+		Opts: &types.StepOptions{
+			SkipSavingParametersToJob: true,
+		},
+	})
+
+	return types.NewScenario(name, steps...)
+}
@@ -0,0 +1,78 @@
+package encryption
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/microsoft/retina/test/retry"
+)
+
+const (
+	advForwardCountMetricName = "networkobservability_adv_forward_count"
+
+	metricRetryAttempts = 20
+	metricRetryDelay    = 5 * time.Second
+)
+
+var ErrNoEncryptedFlow = fmt.Errorf("no flow series attributed to the pod")
+
+// ValidateEncryptedFlowAttribution checks the agent on the pod's node attributes the pod's traffic in Direction
+// to the pod, even though the traffic leaves the node encrypted. It passes without checking when
+// DetectWireGuardEncryption found the datapath unencrypted
+type ValidateEncryptedFlowAttribution struct {
+	PortForwardedRetinaPort string
+	NamespaceName           string
+	PodName                 string
+	Direction               string
+
+	encryption *wireGuardState
+}
+
+func (v *ValidateEncryptedFlowAttribution) Run() error {
+	if !v.encryption.enabled {
+		log.Printf("datapath is not encrypted with WireGuard, skipping validation of %s/%s\n", v.NamespaceName, v.PodName)
+		return nil
+	}
+
+	promAddress := fmt.Sprintf("http://localhost:%s/metrics", v.PortForwardedRetinaPort)
+	matchLabels := map[string]string{
+		"namespace": v.NamespaceName,
+		"podname":   v.PodName,
+		"direction": v.Direction,
+	}
+
+	checkFn := func() error {
+		series, err := prom.GetMetricsMatchingLabels(promAddress, advForwardCountMetricName, matchLabels)
+		if err != nil {
+			return fmt.Errorf("failed to scrape %s: %w", advForwardCountMetricName, err)
+		}
+
+		for _, metric := range series {
+			if metric.GetCounter().GetValue() > 0 {
+				return nil
+			}
+		}
+		log.Printf("no %s %s series for %s/%s yet\n", v.Direction, advForwardCountMetricName, v.NamespaceName, v.PodName)
+		return ErrNoEncryptedFlow
+	}
+
+	retrier := retry.Retrier{Attempts: metricRetryAttempts, Delay: metricRetryDelay}
+	err := retrier.Do(context.Background(), checkFn)
+	if err != nil {
+		return fmt.Errorf("failed to verify prometheus metrics %s: %w", advForwardCountMetricName, err)
+	}
+
+	log.Printf("encrypted %s traffic of %s/%s is attributed to the pod\n", v.Direction, v.NamespaceName, v.PodName)
+	return nil
+}
+
+func (v *ValidateEncryptedFlowAttribution) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateEncryptedFlowAttribution) Stop() error {
+	return nil
+}
@@ -0,0 +1,117 @@
+package initcontainer
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+)
+
+const (
+	workloadNamespace = "retina-init-container"
+
+	// initRequests is how many lookups and requests the init container makes, a second apart
+	initRequests = 20
+)
+
+// ValidateInitContainerTrafficMetrics gives a client pod an init container that looks up and requests a
+// Service before the pod's agnhost container starts, and validates the DNS and flow metrics of that traffic
+// are attributed to the pod. The agnhost container only serves its hostname, so all of the client's
+// traffic comes from the init container
+func ValidateInitContainerTrafficMetrics() *types.Scenario {
+	name := "Init Container Traffic Metrics"
+	clientName := "agnhost-init-client"
+	serverName := "agnhost-init-server"
+	serviceName := "init-server"
+	serviceHost := fmt.Sprintf("%s.%s.svc.cluster.local", serviceName, workloadNamespace)
+	// the trailing dot stops the resolver trying the search domains first
+	initCommand := fmt.Sprintf("for i in $(seq %d); do nslookup %s.; curl -s -m 5 -o /dev/null http://%s:%d; sleep 1; done; true",
+		initRequests, serviceHost, serviceHost, kubernetes.AgnhostHTTPPort)
+
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.CreateNamespace{
+				NamespaceName: workloadNamespace,
+				Observe:       true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      serverName,
+				AgnhostNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostService{
+				ServiceName:      serviceName,
+				ServiceNamespace: workloadNamespace,
+				AgnhostName:      serverName,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		// waits for the pod to be ready, so the init container is done by the next step
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      clientName,
+				AgnhostNamespace: workloadNamespace,
+				InitCommand:      []string{"sh", "-c", initCommand},
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.PortForward{
+				Namespace:                          "kube-system",
+				LabelSelector:                      "k8s-app=retina",
+				LocalPort:                          strconv.Itoa(common.RetinaPort),
+				RemotePort:                         strconv.Itoa(common.RetinaPort),
+				Endpoint:                           "metrics",
+				OptionalLabelAffinity:              "app=" + clientName,
+				OptionalLabelAffinityAllNamespaces: true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "init-container-port-forward",
+			},
+		},
+		{
+			Step: &ValidateInitContainerTraffic{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				NamespaceName:           workloadNamespace,
+				PodName:                 clientName + "-0",
+				Query:                   serviceHost + ".",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &types.Stop{
+				BackgroundID: "init-container-port-forward",
+			},
+		},
+		{
+			Step: &kubernetes.DeleteKubernetesResource{
+				ResourceType:      kubernetes.TypeString(kubernetes.Namespace),
+				ResourceName:      workloadNamespace,
+				ResourceNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	return types.NewScenario(name, steps...)
+}
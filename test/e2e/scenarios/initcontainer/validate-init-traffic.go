@@ -0,0 +1,92 @@
+package initcontainer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/microsoft/retina/test/retry"
+	promclient "github.com/prometheus/client_model/go"
+)
+
+const (
+	advDNSRequestCountMetricName = "networkobservability_adv_dns_request_count"
+	advForwardCountMetricName    = "networkobservability_adv_forward_count"
+
+	metricRetryAttempts = 20
+	metricRetryDelay    = 5 * time.Second
+)
+
+var (
+	ErrNoInitDNSRequest = fmt.Errorf("no dns request series for the init container's lookups")
+	ErrNoInitFlow       = fmt.Errorf("no egress flow series for the init container's requests")
+)
+
+// ValidateInitContainerTraffic checks the lookups of Query and the requests made by the pod's init container
+// are attributed to the pod
+type ValidateInitContainerTraffic struct {
+	PortForwardedRetinaPort string
+	NamespaceName           string
+	PodName                 string
+	Query                   string
+}
+
+func (v *ValidateInitContainerTraffic) Run() error {
+	promAddress := fmt.Sprintf("http://localhost:%s/metrics", v.PortForwardedRetinaPort)
+
+	checkFn := func() error {
+		requests, err := prom.GetMetricsMatchingLabels(promAddress, advDNSRequestCountMetricName, map[string]string{
+			"namespace": v.NamespaceName,
+			"podname":   v.PodName,
+			"query":     v.Query,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to scrape %s: %w", advDNSRequestCountMetricName, err)
+		}
+		if sumCounters(requests) == 0 {
+			log.Printf("no %s series for %s from %s/%s yet\n", advDNSRequestCountMetricName, v.Query, v.NamespaceName, v.PodName)
+			return ErrNoInitDNSRequest
+		}
+
+		flows, err := prom.GetMetricsMatchingLabels(promAddress, advForwardCountMetricName, map[string]string{
+			"namespace": v.NamespaceName,
+			"podname":   v.PodName,
+			"direction": "egress",
+		})
+		if err != nil {
+			return fmt.Errorf("failed to scrape %s: %w", advForwardCountMetricName, err)
+		}
+		if sumCounters(flows) == 0 {
+			log.Printf("no egress %s series for %s/%s yet\n", advForwardCountMetricName, v.NamespaceName, v.PodName)
+			return ErrNoInitFlow
+		}
+		return nil
+	}
+
+	retrier := retry.Retrier{Attempts: metricRetryAttempts, Delay: metricRetryDelay}
+	err := retrier.Do(context.Background(), checkFn)
+	if err != nil {
+		return fmt.Errorf("failed to verify init container traffic metrics: %w", err)
+	}
+
+	log.Printf("init container traffic of %s/%s is attributed to the pod\n", v.NamespaceName, v.PodName)
+	return nil
+}
+
+func (v *ValidateInitContainerTraffic) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateInitContainerTraffic) Stop() error {
+	return nil
+}
+
+func sumCounters(series []*promclient.Metric) float64 {
+	var total float64
+	for _, metric := range series {
+		total += metric.GetCounter().GetValue()
+	}
+	return total
+}
@@ -0,0 +1,47 @@
+package kernel
+
+import (
+	"github.com/microsoft/retina/test/e2e/framework/types"
+)
+
+// MinimumKernelVersion is the oldest kernel on which Retina is expected to run with all of its linux plugins,
+// matching the minimum the agent checks at startup
+const MinimumKernelVersion = "5.4"
+
+// ValidateKernelVersionDetection validates that the agent on every linux node reports the node's kernel at
+// startup, running its plugins on supported kernels and not on those older than MinimumKernelVersion. Run it
+// against a mixed-kernel node pool to exercise both paths.
+func ValidateKernelVersionDetection() *types.Scenario {
+	name := "Kernel Version Detection"
+	steps := []*types.StepWrapper{
+		{
+			Step: &ValidateRetinaKernelDetection{
+				RetinaDaemonSetNamespace: "kube-system",
+				MinimumKernelVersion:     MinimumKernelVersion,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+	return types.NewScenario(name, steps...)
+}
+
+// ValidateUnsupportedKernelError validates that the agent on nodes older than MinimumKernelVersion reports
+// the unsupported kernel at startup rather than running without its eBPF plugins unnoticed, and that agents
+// on newer nodes don't. It needs an old-kernel node pool to exercise the failing path.
+func ValidateUnsupportedKernelError() *types.Scenario {
+	name := "Unsupported Kernel Startup Error"
+	steps := []*types.StepWrapper{
+		{
+			Step: &ValidateUnsupportedKernelStartupError{
+				RetinaDaemonSetNamespace: "kube-system",
+				MinimumKernelVersion:     MinimumKernelVersion,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+	return types.NewScenario(name, steps...)
+}
@@ -0,0 +1,223 @@
+package kernel
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	defaultTimeout = 5 * time.Minute
+
+	// logged by the agent at startup with the kernel release it detected on a supported kernel, otherwise it
+	// exits logging unsupportedKernelLog with the release
+	kernelDetectedLog = "detected kernel version"
+)
+
+var (
+	ErrNoRetinaPodsFound        = fmt.Errorf("no linux retina pods found")
+	ErrKernelVersionMismatch    = fmt.Errorf("kernel version detected by agent does not match node")
+	ErrKernelDetectionFailed    = fmt.Errorf("agent did not report the kernel version it detected")
+	ErrAgentNotReady            = fmt.Errorf("agent is not ready on a supported kernel")
+	ErrAgentRunningOnOldKernel  = fmt.Errorf("agent is running on a kernel older than the minimum supported version")
+	ErrKernelSupportMisdetected = fmt.Errorf("agent disagrees on whether the kernel is supported")
+	ErrInvalidKernelVersion     = fmt.Errorf("invalid kernel version")
+)
+
+// ValidateRetinaKernelDetection checks that each linux retina agent reported at startup the kernel release of
+// its node, that agents on supported kernels are ready, and that agents on kernels older than the minimum
+// reported them as unsupported and aren't running their eBPF plugins
+type ValidateRetinaKernelDetection struct {
+	KubeConfigFilePath       string
+	RetinaDaemonSetNamespace string
+	MinimumKernelVersion     string
+}
+
+func (v *ValidateRetinaKernelDetection) Run() error {
+	config, err := k8s.BuildConfig(v.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	// not filtered on phase, an agent exiting on an old kernel may not be running
+	pods, err := clientset.CoreV1().Pods(v.RetinaDaemonSetNamespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "k8s-app=retina",
+	})
+	if err != nil {
+		return fmt.Errorf("error listing retina pods: %w", err)
+	}
+
+	kernels := make(map[string][]string)
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Spec.NodeSelector["kubernetes.io/os"] == "windows" {
+			continue
+		}
+
+		var kernel string
+		kernel, err = v.validatePod(ctx, clientset, pod)
+		if err != nil {
+			return err
+		}
+		kernels[kernel] = append(kernels[kernel], pod.Spec.NodeName)
+	}
+
+	if len(kernels) == 0 {
+		return fmt.Errorf("in namespace \"%s\": %w", v.RetinaDaemonSetNamespace, ErrNoRetinaPodsFound)
+	}
+
+	for kernel, nodes := range kernels {
+		log.Printf("kernel \"%s\" detected on nodes %v\n", kernel, nodes)
+	}
+	return nil
+}
+
+func (v *ValidateRetinaKernelDetection) validatePod(ctx context.Context, clientset *kubernetes.Clientset, pod *v1.Pod) (string, error) {
+	node, err := clientset.CoreV1().Nodes().Get(ctx, pod.Spec.NodeName, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("error getting node \"%s\": %w", pod.Spec.NodeName, err)
+	}
+	nodeKernel := node.Status.NodeInfo.KernelVersion
+
+	supported, err := kernelAtLeast(nodeKernel, v.MinimumKernelVersion)
+	if err != nil {
+		return "", err
+	}
+
+	logs, err := readAgentLogs(ctx, clientset, pod)
+	if err != nil {
+		return "", err
+	}
+	line, reportedSupported, found := findKernelLog(logs)
+	if !found {
+		return "", fmt.Errorf("pod \"%s\" on node \"%s\": %w", pod.Name, node.Name, ErrKernelDetectionFailed)
+	}
+	if !bytes.Contains(line, []byte(nodeKernel)) {
+		return "", fmt.Errorf("pod \"%s\" logged \"%s\", node \"%s\" reports \"%s\": %w", pod.Name, line, node.Name, nodeKernel, ErrKernelVersionMismatch)
+	}
+	if reportedSupported != supported {
+		return "", fmt.Errorf("pod \"%s\" logged \"%s\" for kernel \"%s\" with minimum \"%s\": %w", pod.Name, line, nodeKernel, v.MinimumKernelVersion, ErrKernelSupportMisdetected)
+	}
+
+	// the eBPF plugins only run in a ready agent, so an old kernel must leave it unready rather than half running
+	ready := retinaContainerReady(pod)
+	if supported && !ready {
+		return "", fmt.Errorf("pod \"%s\" on kernel \"%s\": %w", pod.Name, nodeKernel, ErrAgentNotReady)
+	}
+	if !supported && ready {
+		return "", fmt.Errorf("pod \"%s\" on kernel \"%s\": %w", pod.Name, nodeKernel, ErrAgentRunningOnOldKernel)
+	}
+
+	log.Printf("retina pod \"%s\" detected kernel \"%s\" of node \"%s\", supported: %t\n", pod.Name, nodeKernel, node.Name, supported)
+	return nodeKernel, nil
+}
+
+// readAgentLogs returns the logs of the agent's current run, preceded by its previous run's when it restarted,
+// as an agent exiting at startup logs why before it's restarted
+func readAgentLogs(ctx context.Context, clientset *kubernetes.Clientset, pod *v1.Pod) ([]byte, error) {
+	logs, err := k8s.ReadPodLogs(ctx, clientset, pod.Namespace, pod.Name)
+	if err != nil {
+		return nil, fmt.Errorf("error reading logs of retina pod \"%s\": %w", pod.Name, err)
+	}
+
+	for i := range pod.Status.ContainerStatuses {
+		status := &pod.Status.ContainerStatuses[i]
+		if status.Name != retinaContainerName || status.RestartCount == 0 {
+			continue
+		}
+
+		previous, err := k8s.ReadPreviousPodLogs(ctx, clientset, pod.Namespace, pod.Name, retinaContainerName)
+		if err != nil {
+			return nil, fmt.Errorf("error reading previous logs of retina pod \"%s\": %w", pod.Name, err)
+		}
+		logs = append(previous, logs...)
+	}
+	return logs, nil
+}
+
+// findKernelLog returns the last line in which the agent reported the kernel it detected, and whether it
+// reported it as supported
+func findKernelLog(logs []byte) (line []byte, supported, found bool) {
+	lines := bytes.Split(logs, []byte("\n"))
+	for i := len(lines) - 1; i >= 0; i-- {
+		if bytes.Contains(lines[i], []byte(kernelDetectedLog)) {
+			return lines[i], true, true
+		}
+		if bytes.Contains(bytes.ToLower(lines[i]), []byte(unsupportedKernelLog)) {
+			return lines[i], false, true
+		}
+	}
+	return nil, false, false
+}
+
+func retinaContainerReady(pod *v1.Pod) bool {
+	for i := range pod.Status.ContainerStatuses {
+		if pod.Status.ContainerStatuses[i].Name == retinaContainerName {
+			return pod.Status.ContainerStatuses[i].Ready
+		}
+	}
+	return false
+}
+
+// kernelAtLeast compares the major and minor versions of a kernel release string such as "5.15.0-1057-azure"
+func kernelAtLeast(kernel, minimum string) (bool, error) {
+	major, minor, err := parseKernelVersion(kernel)
+	if err != nil {
+		return false, err
+	}
+	minMajor, minMinor, err := parseKernelVersion(minimum)
+	if err != nil {
+		return false, err
+	}
+	if major != minMajor {
+		return major > minMajor, nil
+	}
+	return minor >= minMinor, nil
+}
+
+func parseKernelVersion(kernel string) (major, minor int, err error) {
+	parts := strings.SplitN(kernel, ".", 3)
+	if len(parts) < 2 {
+		return 0, 0, fmt.Errorf("kernel version \"%s\": %w", kernel, ErrInvalidKernelVersion)
+	}
+	major, err = strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("kernel version \"%s\": %w", kernel, ErrInvalidKernelVersion)
+	}
+	// the minor version may carry a suffix when there is no patch version, e.g. "6.8-rc1"
+	digits := strings.IndexFunc(parts[1], func(r rune) bool { return r < '0' || r > '9' })
+	if digits == -1 {
+		digits = len(parts[1])
+	}
+	minor, err = strconv.Atoi(parts[1][:digits])
+	if err != nil {
+		return 0, 0, fmt.Errorf("kernel version \"%s\": %w", kernel, ErrInvalidKernelVersion)
+	}
+	return major, minor, nil
+}
+
+func (v *ValidateRetinaKernelDetection) Prevalidate() error {
+	_, _, err := parseKernelVersion(v.MinimumKernelVersion)
+	return err
+}
+
+func (v *ValidateRetinaKernelDetection) Stop() error {
+	return nil
+}
@@ -0,0 +1,129 @@
+package kernel
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	retinaContainerName = "retina"
+
+	// logged by the agent when it exits at startup on a kernel older than the minimum
+	unsupportedKernelLog = "unsupported kernel"
+)
+
+var (
+	ErrNoUnsupportedKernelError = fmt.Errorf("agent on an unsupported kernel did not report it")
+	ErrUnexpectedUnsupported    = fmt.Errorf("agent on a supported kernel reported it as unsupported")
+)
+
+// ValidateUnsupportedKernelStartupError checks that the agent on every linux node older than MinimumKernelVersion
+// reported the unsupported kernel in its startup logs, from the current run or the one before a restart, instead
+// of starting silently. Agents on nodes at or above the minimum must not report it
+type ValidateUnsupportedKernelStartupError struct {
+	KubeConfigFilePath       string
+	RetinaDaemonSetNamespace string
+	MinimumKernelVersion     string
+}
+
+func (v *ValidateUnsupportedKernelStartupError) Run() error {
+	config, err := k8s.BuildConfig(v.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	// not filtered on phase, an agent failing fast may not be running
+	pods, err := clientset.CoreV1().Pods(v.RetinaDaemonSetNamespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "k8s-app=retina",
+	})
+	if err != nil {
+		return fmt.Errorf("error listing retina pods: %w", err)
+	}
+
+	unsupported := 0
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Spec.NodeSelector["kubernetes.io/os"] == "windows" {
+			continue
+		}
+
+		var node *v1.Node
+		node, err = clientset.CoreV1().Nodes().Get(ctx, pod.Spec.NodeName, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("error getting node \"%s\": %w", pod.Spec.NodeName, err)
+		}
+
+		nodeKernel := node.Status.NodeInfo.KernelVersion
+		var supported bool
+		supported, err = kernelAtLeast(nodeKernel, v.MinimumKernelVersion)
+		if err != nil {
+			return err
+		}
+		if supported {
+			err = v.validateSupportedPod(ctx, clientset, pod, nodeKernel)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		unsupported++
+		err = v.validatePod(ctx, clientset, pod, nodeKernel)
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Printf("%d of %d retina pods run on kernels older than \"%s\"\n", unsupported, len(pods.Items), v.MinimumKernelVersion)
+	return nil
+}
+
+func (v *ValidateUnsupportedKernelStartupError) validatePod(ctx context.Context, clientset *kubernetes.Clientset, pod *v1.Pod, nodeKernel string) error {
+	// a container that exited on the unsupported kernel logged it in its previous run
+	logs, err := readAgentLogs(ctx, clientset, pod)
+	if err != nil {
+		return err
+	}
+	if !bytes.Contains(bytes.ToLower(logs), []byte(unsupportedKernelLog)) {
+		return fmt.Errorf("pod \"%s\" on node \"%s\" with kernel \"%s\": %w", pod.Name, pod.Spec.NodeName, nodeKernel, ErrNoUnsupportedKernelError)
+	}
+
+	log.Printf("retina pod \"%s\" reported unsupported kernel \"%s\"\n", pod.Name, nodeKernel)
+	return nil
+}
+
+func (v *ValidateUnsupportedKernelStartupError) validateSupportedPod(ctx context.Context, clientset *kubernetes.Clientset, pod *v1.Pod, nodeKernel string) error {
+	logs, err := readAgentLogs(ctx, clientset, pod)
+	if err != nil {
+		return err
+	}
+	if bytes.Contains(bytes.ToLower(logs), []byte(unsupportedKernelLog)) {
+		return fmt.Errorf("pod \"%s\" on node \"%s\" with kernel \"%s\": %w", pod.Name, pod.Spec.NodeName, nodeKernel, ErrUnexpectedUnsupported)
+	}
+	return nil
+}
+
+func (v *ValidateUnsupportedKernelStartupError) Prevalidate() error {
+	_, _, err := parseKernelVersion(v.MinimumKernelVersion)
+	return err
+}
+
+func (v *ValidateUnsupportedKernelStartupError) Stop() error {
+	return nil
+}
@@ -0,0 +1,120 @@
+package longnames
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+)
+
+const (
+	advDNSRequestCountMetricName = "networkobservability_adv_dns_request_count"
+
+	// MaxNamespaceNameLength is the DNS label limit on namespace names
+	MaxNamespaceNameLength = 63
+	// MaxStatefulSetNameLength is the longest StatefulSet name whose pods still get created,
+	// the controller-revision-hash label appends a hash to the name and must fit in 63 characters
+	MaxStatefulSetNameLength = 52
+)
+
+// padName extends prefix with "x" up to length characters
+func padName(prefix string, length int) string {
+	return prefix + strings.Repeat("x", length-len(prefix))
+}
+
+// ValidateLongNameMetrics runs a workload with the longest names Kubernetes allows for its namespace and
+// StatefulSet, generates DNS traffic from it, and validates the advanced metrics carry both names in full
+func ValidateLongNameMetrics() *types.Scenario {
+	name := "Long Pod and Namespace Name Metrics"
+	namespace := padName("retina-long-namespace-", MaxNamespaceNameLength)
+	agnhostName := padName("agnhost-long-name-", MaxStatefulSetNameLength)
+	podName := agnhostName + "-0"
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.CreateNamespace{
+				NamespaceName: namespace,
+				Observe:       true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      agnhostName,
+				AgnhostNamespace: namespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.ExecInPod{
+				PodName:      podName,
+				PodNamespace: namespace,
+				Command:      "nslookup kubernetes.default",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.PortForward{
+				Namespace:             "kube-system",
+				LabelSelector:         "k8s-app=retina",
+				LocalPort:             strconv.Itoa(common.RetinaPort),
+				RemotePort:            strconv.Itoa(common.RetinaPort),
+				Endpoint:              "metrics",
+				OptionalLabelAffinity: "app=" + agnhostName, // port forward to a pod on a node that also has this pod with this label
+
+				OptionalLabelAffinityAllNamespaces: true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "long-names-port-forward",
+			},
+		},
+		{
+			// the agent exports the lookup some time after it was made
+			Step: &prom.WaitForMetric{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				MetricName:              advDNSRequestCountMetricName,
+				Labels: map[string]string{
+					"namespace": namespace,
+					"podname":   podName,
+				},
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &ValidateLongNameLabelIntegrity{
+				NamespaceName: namespace,
+				PodName:       podName,
+				WorkloadName:  agnhostName,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &types.Stop{
+				BackgroundID: "long-names-port-forward",
+			},
+		},
+		{
+			Step: &kubernetes.DeleteKubernetesResource{
+				ResourceType:      kubernetes.TypeString(kubernetes.Namespace),
+				ResourceName:      namespace,
+				ResourceNamespace: namespace,
+			}, Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+	return types.NewScenario(name, steps...)
+}
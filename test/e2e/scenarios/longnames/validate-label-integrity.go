@@ -0,0 +1,99 @@
+package longnames
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+)
+
+var (
+	ErrNoSeriesForNamespace = fmt.Errorf("no series found for namespace")
+	ErrTruncatedLabel       = fmt.Errorf("label value is a truncated name")
+	ErrMalformedLabel       = fmt.Errorf("label value is malformed")
+
+	// advanced metrics carrying pod attribution labels
+	attributedMetricNames = []string{
+		advDNSRequestCountMetricName,
+		"networkobservability_adv_dns_response_count",
+	}
+)
+
+// ValidateLongNameLabelIntegrity checks the long named workload shows up in the attributed series, and
+// that every such series carries its namespace, pod and workload names in full: no series may hold a
+// truncated prefix of a name, and the values must be exactly the names rather than containing them
+type ValidateLongNameLabelIntegrity struct {
+	NamespaceName string
+	PodName       string
+	WorkloadName  string
+}
+
+func (v *ValidateLongNameLabelIntegrity) Run() error {
+	metricsEndpoint := fmt.Sprintf("http://localhost:%d/metrics", common.RetinaPort)
+
+	found := 0
+	for _, metricName := range attributedMetricNames {
+		series, err := prom.GetMetricsMatchingLabels(metricsEndpoint, metricName, map[string]string{})
+		if err != nil {
+			return fmt.Errorf("failed to scrape %s: %w", metricName, err)
+		}
+
+		for _, metric := range series {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+
+			err = checkName("namespace", labels["namespace"], v.NamespaceName)
+			if err != nil {
+				return fmt.Errorf("%s: %w", metricName, err)
+			}
+			if labels["namespace"] != v.NamespaceName {
+				continue
+			}
+
+			err = checkName("podname", labels["podname"], v.PodName)
+			if err != nil {
+				return fmt.Errorf("%s: %w", metricName, err)
+			}
+			err = checkName("workload_name", labels["workload_name"], v.WorkloadName)
+			if err != nil {
+				return fmt.Errorf("%s: %w", metricName, err)
+			}
+			if labels["podname"] == v.PodName && labels["workload_name"] == v.WorkloadName {
+				found++
+			}
+		}
+	}
+
+	if found == 0 {
+		return fmt.Errorf("namespace %s: %w", v.NamespaceName, ErrNoSeriesForNamespace)
+	}
+	log.Printf("found %d series attributed to %s/%s with untruncated labels\n", found, v.NamespaceName, v.PodName)
+
+	return nil
+}
+
+// checkName fails if got is a truncated prefix of want or wraps want in extra characters
+func checkName(label, got, want string) error {
+	if got == want || got == "" {
+		return nil
+	}
+	if strings.HasPrefix(want, got) {
+		return fmt.Errorf("%s %q is a truncation of %q: %w", label, got, want, ErrTruncatedLabel)
+	}
+	if strings.Contains(got, want) {
+		return fmt.Errorf("%s %q wraps %q: %w", label, got, want, ErrMalformedLabel)
+	}
+	return nil
+}
+
+func (v *ValidateLongNameLabelIntegrity) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateLongNameLabelIntegrity) Stop() error {
+	return nil
+}
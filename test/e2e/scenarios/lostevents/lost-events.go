@@ -0,0 +1,127 @@
+package lostevents
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/microsoft/retina/test/retry"
+)
+
+const (
+	lostEventsMetricName = "controlplane_networkobservability_lost_events_counter"
+
+	metricRetryAttempts = 12
+	metricRetryDelay    = 5 * time.Second
+)
+
+var (
+	ErrBaselineNotCaptured = fmt.Errorf("lost events baseline was not captured")
+	ErrNoLostEventsSeries  = fmt.Errorf("no lost events series exported")
+	ErrNoEventsLost        = fmt.Errorf("lost events counter did not increase")
+)
+
+// lostEventsBaseline carries the lost events count read before the overload to the step validating it
+type lostEventsBaseline struct {
+	lost     float64
+	captured bool
+}
+
+// lostEvents adds up the events lost across all of the counter's series and returns them by "type/reason"
+func lostEvents(portForwardedRetinaPort string) (float64, map[string]float64, error) {
+	promAddress := fmt.Sprintf("http://localhost:%s/metrics", portForwardedRetinaPort)
+	series, err := prom.GetMetricsMatchingLabels(promAddress, lostEventsMetricName, map[string]string{})
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to scrape %s: %w", lostEventsMetricName, err)
+	}
+
+	total := 0.0
+	bySource := make(map[string]float64, len(series))
+	for _, metric := range series {
+		labels := map[string]string{}
+		for _, label := range metric.GetLabel() {
+			labels[label.GetName()] = label.GetValue()
+		}
+		bySource[labels["type"]+"/"+labels["reason"]] += metric.GetCounter().GetValue()
+		total += metric.GetCounter().GetValue()
+	}
+	return total, bySource, nil
+}
+
+// CaptureLostEvents records the events the agent lost before the overload. The counter has no series
+// until an event is first lost, which counts as none lost
+type CaptureLostEvents struct {
+	PortForwardedRetinaPort string
+
+	baseline *lostEventsBaseline
+}
+
+func (c *CaptureLostEvents) Run() error {
+	lost, _, err := lostEvents(c.PortForwardedRetinaPort)
+	if err != nil {
+		return err
+	}
+
+	c.baseline.lost = lost
+	c.baseline.captured = true
+	log.Printf("%.0f events lost before the overload\n", lost)
+	return nil
+}
+
+func (c *CaptureLostEvents) Prevalidate() error {
+	return nil
+}
+
+func (c *CaptureLostEvents) Stop() error {
+	return nil
+}
+
+// ValidateLostEventsIncreased checks the agent exports the lost events counter and that it grew past the
+// baseline, so the events the overloaded agent couldn't keep up with are accounted for rather than silently gone
+type ValidateLostEventsIncreased struct {
+	PortForwardedRetinaPort string
+
+	baseline *lostEventsBaseline
+}
+
+func (v *ValidateLostEventsIncreased) Run() error {
+	if !v.baseline.captured {
+		return ErrBaselineNotCaptured
+	}
+
+	checkFn := func() error {
+		lost, bySource, err := lostEvents(v.PortForwardedRetinaPort)
+		if err != nil {
+			return err
+		}
+
+		if len(bySource) == 0 {
+			log.Printf("no %s series yet\n", lostEventsMetricName)
+			return ErrNoLostEventsSeries
+		}
+		if lost <= v.baseline.lost {
+			log.Printf("%.0f events lost, no more than the %.0f before the overload\n", lost, v.baseline.lost)
+			return ErrNoEventsLost
+		}
+
+		log.Printf("%.0f events lost during the overload, by type/reason: %v\n", lost-v.baseline.lost, bySource)
+		return nil
+	}
+
+	retrier := retry.Retrier{Attempts: metricRetryAttempts, Delay: metricRetryDelay}
+	err := retrier.Do(context.Background(), checkFn)
+	if err != nil {
+		return fmt.Errorf("failed to verify prometheus metrics %s: %w", lostEventsMetricName, err)
+	}
+	return nil
+}
+
+func (v *ValidateLostEventsIncreased) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateLostEventsIncreased) Stop() error {
+	return nil
+}
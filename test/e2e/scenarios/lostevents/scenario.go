@@ -0,0 +1,185 @@
+package lostevents
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	"github.com/microsoft/retina/test/e2e/framework/generic"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+	"github.com/microsoft/retina/test/e2e/scenarios/connstorm"
+)
+
+const (
+	workloadNamespace = "retina-lost-events"
+
+	// throttles the agent so it falls behind reading the perf buffers under the storm
+	overloadCPULimit = "50m"
+
+	overloadConnections = 20000
+	overloadParallel    = 100
+
+	// lets the flows of the pods' setup reach the metrics before the baseline is read
+	settleDelay = 15 * time.Second
+)
+
+// ValidateLostEventsUnderOverload limits the agent's CPU, floods it with short lived connections and validates
+// the lost events counter grows, then restores the agent's resources, even if the scenario fails.
+func ValidateLostEventsUnderOverload(kubeConfigFilePath, chartPath, valuesFilePath string) *types.Scenario {
+	name := "Lost Events Under Overload"
+	clientName := "agnhost-lost-events-client"
+	serverName := "agnhost-lost-events-server"
+	baseline := &lostEventsBaseline{}
+
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.UpgradeRetinaHelmChart{
+				Namespace:          "kube-system",
+				ReleaseName:        "retina",
+				KubeConfigFilePath: kubeConfigFilePath,
+				ChartPath:          chartPath,
+				TagEnv:             generic.DefaultTagEnv,
+				ValuesFile:         valuesFilePath,
+				// the request can't be above the limit
+				SetValues: []string{
+					"resources.limits.cpu=" + overloadCPULimit,
+					"resources.requests.cpu=" + overloadCPULimit,
+				},
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.EnsureStableCluster{
+				PodNamespace:  "kube-system",
+				LabelSelector: "k8s-app=retina",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateNamespace{
+				NamespaceName: workloadNamespace,
+				Observe:       true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      serverName,
+				AgnhostNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      clientName,
+				AgnhostNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.PortForward{
+				Namespace:                          "kube-system",
+				LabelSelector:                      "k8s-app=retina",
+				LocalPort:                          strconv.Itoa(common.RetinaPort),
+				RemotePort:                         strconv.Itoa(common.RetinaPort),
+				Endpoint:                           "metrics",
+				OptionalLabelAffinity:              "app=" + clientName,
+				OptionalLabelAffinityAllNamespaces: true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "lost-events-port-forward",
+			},
+		},
+		{
+			Step: &types.Sleep{
+				Duration: settleDelay,
+			},
+		},
+		{
+			Step: &CaptureLostEvents{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				baseline:                baseline,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &connstorm.SendConnectionStorm{
+				PodNamespace:         workloadNamespace,
+				PodName:              clientName + "-0",
+				DestinationNamespace: workloadNamespace,
+				DestinationPodName:   serverName + "-0",
+				Connections:          overloadConnections,
+				Parallel:             overloadParallel,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &ValidateLostEventsIncreased{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				baseline:                baseline,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &types.Stop{
+				BackgroundID: "lost-events-port-forward",
+			},
+		},
+		{
+			Step: &kubernetes.DeleteKubernetesResource{
+				ResourceType:      kubernetes.TypeString(kubernetes.Namespace),
+				ResourceName:      workloadNamespace,
+				ResourceNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	// restore the agent's resources for any scenarios that follow
+	cleanup := []*types.StepWrapper{
+		{
+			Step: &kubernetes.UpgradeRetinaHelmChart{
+				Namespace:          "kube-system",
+				ReleaseName:        "retina",
+				KubeConfigFilePath: kubeConfigFilePath,
+				ChartPath:          chartPath,
+				TagEnv:             generic.DefaultTagEnv,
+				ValuesFile:         valuesFilePath,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.EnsureStableCluster{
+				PodNamespace:  "kube-system",
+				LabelSelector: "k8s-app=retina",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	return types.NewScenario(name, steps...).WithCleanup(cleanup...)
+}
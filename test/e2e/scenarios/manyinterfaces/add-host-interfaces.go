@@ -0,0 +1,167 @@
+package manyinterfaces
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	defaultTimeout = 10 * time.Minute
+
+	// short enough for the 15 character limit of interface names with an index and a veth peer suffix
+	interfacePrefix = "rte2e"
+)
+
+var (
+	ErrNoRetinaPodOnNode = fmt.Errorf("no retina pod found on node")
+	ErrInterfaceMissing  = fmt.Errorf("interface missing from node")
+)
+
+// AddHostInterfaces adds InterfacesPerKind bridges, dummy interfaces and veth pairs to the host network namespace
+// of the node running PodName, the way overlays and bridges pile up interfaces next to the pods' veths, and checks
+// the node lists every one of them. The interfaces are added through the host network retina pod on that node,
+// run it in the background so Stop removes them again.
+type AddHostInterfaces struct {
+	KubeConfigFilePath       string
+	RetinaDaemonSetNamespace string
+	PodNamespace             string
+	PodName                  string
+	InterfacesPerKind        int
+
+	// local properties
+	retinaPodName string
+	added         []string
+}
+
+func (a *AddHostInterfaces) Run() error {
+	config, clientset, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	pod, err := clientset.CoreV1().Pods(a.PodNamespace).Get(ctx, a.PodName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting pod \"%s\": %w", a.PodName, err)
+	}
+
+	retinaPods, err := clientset.CoreV1().Pods(a.RetinaDaemonSetNamespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "k8s-app=retina",
+		FieldSelector: "spec.nodeName=" + pod.Spec.NodeName,
+	})
+	if err != nil {
+		return fmt.Errorf("error listing retina pods on node %s: %w", pod.Spec.NodeName, err)
+	}
+	if len(retinaPods.Items) == 0 {
+		return fmt.Errorf("node %s: %w", pod.Spec.NodeName, ErrNoRetinaPodOnNode)
+	}
+	a.retinaPodName = retinaPods.Items[0].Name
+
+	before, err := a.links(ctx, clientset, config)
+	if err != nil {
+		return err
+	}
+
+	expected := []string{}
+	for i := 0; i < a.InterfacesPerKind; i++ {
+		bridge := fmt.Sprintf("%sbr%d", interfacePrefix, i)
+		dummy := fmt.Sprintf("%sdm%d", interfacePrefix, i)
+		veth := fmt.Sprintf("%sve%d", interfacePrefix, i)
+
+		for name, command := range map[string]string{
+			bridge: fmt.Sprintf("ip link add %s up type bridge", bridge),
+			dummy:  fmt.Sprintf("ip link add %s up type dummy", dummy),
+			veth:   fmt.Sprintf("ip link add %s up type veth peer name %sp", veth, veth),
+		} {
+			_, err = k8s.ExecPod(ctx, clientset, config, a.RetinaDaemonSetNamespace, a.retinaPodName, command)
+			if err != nil {
+				return fmt.Errorf("error adding interface %s through retina pod \"%s\": %w", name, a.retinaPodName, err)
+			}
+			a.added = append(a.added, name)
+		}
+		expected = append(expected, bridge, dummy, veth, veth+"p")
+	}
+
+	after, err := a.links(ctx, clientset, config)
+	if err != nil {
+		return err
+	}
+	for _, name := range expected {
+		if !after[name] {
+			return fmt.Errorf("interface %s on node %s: %w", name, pod.Spec.NodeName, ErrInterfaceMissing)
+		}
+	}
+
+	log.Printf("node %s went from %d to %d interfaces\n", pod.Spec.NodeName, len(before), len(after))
+	return nil
+}
+
+// links enumerates the interfaces of the node's host network namespace
+func (a *AddHostInterfaces) links(ctx context.Context, clientset *kubernetes.Clientset, config *rest.Config) (map[string]bool, error) {
+	output, err := k8s.ExecPod(ctx, clientset, config, a.RetinaDaemonSetNamespace, a.retinaPodName, "ip -o link show")
+	if err != nil {
+		return nil, fmt.Errorf("error listing interfaces through retina pod \"%s\": %w", a.retinaPodName, err)
+	}
+
+	// each line reads "<index>: <name>[@<peer>]: <flags> ..."
+	links := map[string]bool{}
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		name, _, _ := strings.Cut(strings.TrimSuffix(fields[1], ":"), "@")
+		links[name] = true
+	}
+	return links, nil
+}
+
+func (a *AddHostInterfaces) client() (*rest.Config, *kubernetes.Clientset, error) {
+	config, err := k8s.BuildConfig(a.KubeConfigFilePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+	return config, clientset, nil
+}
+
+func (a *AddHostInterfaces) Prevalidate() error {
+	return nil
+}
+
+func (a *AddHostInterfaces) Stop() error {
+	if a.retinaPodName == "" {
+		return nil
+	}
+
+	config, clientset, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	// deleting one end of a veth pair deletes its peer too
+	for _, name := range a.added {
+		_, err = k8s.ExecPod(ctx, clientset, config, a.RetinaDaemonSetNamespace, a.retinaPodName, "ip link del "+name)
+		if err != nil {
+			return fmt.Errorf("error removing interface %s through retina pod \"%s\": %w", name, a.retinaPodName, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package manyinterfaces
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+	"github.com/microsoft/retina/test/e2e/scenarios/cardinality"
+)
+
+const (
+	workloadNamespace = "retina-many-interfaces"
+
+	// pod veths on the one node, within the default pod limit of an AKS node with Azure CNI
+	podsOnNode = 20
+
+	// bridges, dummies and veth pairs each, on top of the pods' veths
+	hostInterfacesPerKind = 10
+
+	// an idle agent uses a fraction of this, one busy watching interfaces would use more
+	cpuWindow   = time.Minute
+	maxCPUCores = 0.5
+)
+
+// ValidateManyInterfacesMetrics packs pods onto one node and adds dozens of bridges, dummy interfaces and veth
+// pairs next to their veths, then validates the agent on that node records traffic for every pod, so it attached
+// to all their veths, while staying within maxCPUCores and up
+func ValidateManyInterfacesMetrics() *types.Scenario {
+	name := "Many Interfaces Flow Metrics"
+	clientName := "agnhost-interfaces-client"
+	serverName := "agnhost-interfaces-server"
+
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.CreateNamespace{
+				NamespaceName: workloadNamespace,
+				Observe:       true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      serverName,
+				AgnhostNamespace: workloadNamespace,
+				Replicas:         podsOnNode,
+				SingleNode:       true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      clientName,
+				AgnhostNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &AddHostInterfaces{
+				RetinaDaemonSetNamespace: "kube-system",
+				PodNamespace:             workloadNamespace,
+				PodName:                  serverName + "-0",
+				InterfacesPerKind:        hostInterfacesPerKind,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "many-interfaces-host-interfaces",
+			},
+		},
+		{
+			Step: &kubernetes.PortForward{
+				Namespace:                          "kube-system",
+				LabelSelector:                      "k8s-app=retina",
+				LocalPort:                          strconv.Itoa(common.RetinaPort),
+				RemotePort:                         strconv.Itoa(common.RetinaPort),
+				Endpoint:                           "metrics",
+				OptionalLabelAffinity:              "app=" + serverName,
+				OptionalLabelAffinityAllNamespaces: true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "many-interfaces-port-forward",
+			},
+		},
+		{
+			Step: &cardinality.SendRequestsToEachPod{
+				PodNamespace:        workloadNamespace,
+				ClientPodName:       clientName + "-0",
+				ServerLabelSelector: "app=" + serverName,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &ValidateInterfaceCoverage{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				NamespaceName:           workloadNamespace,
+				LabelSelector:           "app=" + serverName,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &ValidateAgentCPU{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				Window:                  cpuWindow,
+				MaxCores:                maxCPUCores,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &types.Stop{
+				BackgroundID: "many-interfaces-port-forward",
+			},
+		},
+		{
+			Step: &types.Stop{
+				BackgroundID: "many-interfaces-host-interfaces",
+			},
+		},
+		{
+			Step: &kubernetes.EnsureNoRestarts{
+				PodNamespace:  "kube-system",
+				LabelSelector: "k8s-app=retina",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		// deleting the namespace removes both workloads with it
+		{
+			Step: &kubernetes.DeleteKubernetesResource{
+				ResourceType:      kubernetes.TypeString(kubernetes.Namespace),
+				ResourceName:      workloadNamespace,
+				ResourceNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	return types.NewScenario(name, steps...)
+}
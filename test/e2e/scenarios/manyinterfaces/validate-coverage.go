@@ -0,0 +1,107 @@
+package manyinterfaces
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/microsoft/retina/test/retry"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	advForwardCountMetricName = "networkobservability_adv_forward_count"
+
+	metricRetryAttempts = 20
+	metricRetryDelay    = 5 * time.Second
+)
+
+var (
+	ErrNoPods         = fmt.Errorf("no pods found")
+	ErrPodsNotCovered = fmt.Errorf("pods without ingress series")
+)
+
+// ValidateInterfaceCoverage checks the agent behind PortForwardedRetinaPort recorded ingress traffic for every pod
+// matching LabelSelector, which it can only do once it has attached to the pod's veth, so no veth among the node's
+// many interfaces was missed
+type ValidateInterfaceCoverage struct {
+	PortForwardedRetinaPort string
+	KubeConfigFilePath      string
+	NamespaceName           string
+	LabelSelector           string
+}
+
+func (v *ValidateInterfaceCoverage) Run() error {
+	config, err := k8s.BuildConfig(v.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	pods, err := clientset.CoreV1().Pods(v.NamespaceName).List(ctx, metav1.ListOptions{LabelSelector: v.LabelSelector})
+	if err != nil {
+		return fmt.Errorf("error listing pods: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return fmt.Errorf("label \"%s\" in namespace \"%s\": %w", v.LabelSelector, v.NamespaceName, ErrNoPods)
+	}
+
+	promAddress := fmt.Sprintf("http://localhost:%s/metrics", v.PortForwardedRetinaPort)
+
+	var missing []string
+	checkFn := func() error {
+		series, scrapeErr := prom.GetMetricsMatchingLabels(promAddress, advForwardCountMetricName, map[string]string{"direction": "ingress"})
+		if scrapeErr != nil {
+			return fmt.Errorf("failed to scrape %s: %w", advForwardCountMetricName, scrapeErr)
+		}
+
+		covered := map[string]bool{}
+		for _, metric := range series {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "ip" {
+					covered[label.GetValue()] = true
+				}
+			}
+		}
+
+		missing = nil
+		for i := range pods.Items {
+			if !covered[pods.Items[i].Status.PodIP] {
+				missing = append(missing, pods.Items[i].Name)
+			}
+		}
+		if len(missing) > 0 {
+			log.Printf("%d of %d pods have no ingress series yet: %v\n", len(missing), len(pods.Items), missing)
+			return ErrPodsNotCovered
+		}
+		return nil
+	}
+
+	retrier := retry.Retrier{Attempts: metricRetryAttempts, Delay: metricRetryDelay}
+	err = retrier.Do(context.Background(), checkFn)
+	if err != nil {
+		return fmt.Errorf("pods %v: %w", missing, err)
+	}
+
+	log.Printf("all %d pods matching \"%s\" have ingress series\n", len(pods.Items), v.LabelSelector)
+	return nil
+}
+
+func (v *ValidateInterfaceCoverage) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateInterfaceCoverage) Stop() error {
+	return nil
+}
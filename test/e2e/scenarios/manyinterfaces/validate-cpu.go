@@ -0,0 +1,71 @@
+package manyinterfaces
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+)
+
+const processCPUMetricName = "process_cpu_seconds_total"
+
+var (
+	ErrExcessiveCPU     = fmt.Errorf("agent used more CPU than its bound")
+	ErrNoCPUMetric      = fmt.Errorf("agent exports no process CPU time")
+	ErrCPUTimeDecreased = fmt.Errorf("agent process CPU time went down")
+)
+
+// ValidateAgentCPU checks the agent behind PortForwardedRetinaPort averaged at most MaxCores of CPU over Window,
+// from the CPU time its process reports at either end of it, so watching many interfaces doesn't keep it busy
+type ValidateAgentCPU struct {
+	PortForwardedRetinaPort string
+	Window                  time.Duration
+	MaxCores                float64
+}
+
+func (v *ValidateAgentCPU) Run() error {
+	promAddress := fmt.Sprintf("http://localhost:%s/metrics", v.PortForwardedRetinaPort)
+
+	start, err := processCPUSeconds(promAddress)
+	if err != nil {
+		return err
+	}
+	time.Sleep(v.Window)
+	end, err := processCPUSeconds(promAddress)
+	if err != nil {
+		return err
+	}
+
+	// a restarted agent starts its CPU time over
+	if end < start {
+		return fmt.Errorf("from %.2fs to %.2fs: %w", start, end, ErrCPUTimeDecreased)
+	}
+
+	cores := (end - start) / v.Window.Seconds()
+	if cores > v.MaxCores {
+		return fmt.Errorf("%.3f cores over %s, more than %.3f: %w", cores, v.Window, v.MaxCores, ErrExcessiveCPU)
+	}
+
+	log.Printf("agent used %.3f cores over %s, within %.3f\n", cores, v.Window, v.MaxCores)
+	return nil
+}
+
+func processCPUSeconds(promAddress string) (float64, error) {
+	series, err := prom.GetMetricsMatchingLabels(promAddress, processCPUMetricName, map[string]string{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to scrape %s: %w", processCPUMetricName, err)
+	}
+	if len(series) == 0 {
+		return 0, ErrNoCPUMetric
+	}
+	return series[0].GetCounter().GetValue(), nil
+}
+
+func (v *ValidateAgentCPU) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateAgentCPU) Stop() error {
+	return nil
+}
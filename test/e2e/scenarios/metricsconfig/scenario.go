@@ -0,0 +1,334 @@
+package metricsconfig
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	"github.com/microsoft/retina/test/e2e/framework/generic"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+)
+
+const (
+	sleepDelay = 5 * time.Second
+
+	advForwardCountMetricName = "networkobservability_adv_forward_count"
+
+	// the sample MetricsConfiguration excludes kube-system, so the workload runs elsewhere
+	workloadNamespace = "retina-metricsconfig"
+
+	// the namespace scoped MetricsConfiguration only includes ScopedNamespace
+	ScopedNamespace   = "retina-metricsconfig-scoped"
+	unscopedNamespace = "retina-metricsconfig-unscoped"
+
+	// metrics from the unscoped namespace's node are read through a second port forward
+	unscopedNodeLocalPort = common.RetinaPort + 3
+)
+
+// ValidateMetricsConfigurationDeletion applies a MetricsConfiguration, validates the advanced metrics it
+// configures show up, deletes it and validates those metrics go away while the agent and operator keep
+// running without restarts. MetricsConfiguration is only reconciled with annotations disabled, so Retina
+// is upgraded for the scenario and restored afterwards, even if it fails.
+func ValidateMetricsConfigurationDeletion(kubeConfigFilePath, chartPath, valuesFilePath, metricsConfigFilePath string) *types.Scenario {
+	name := "MetricsConfiguration Deletion"
+	agnhostName := "agnhost-metricsconfig"
+	podName := agnhostName + "-0"
+
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.ApplyYAML{
+				YAMLFilePath: metricsConfigFilePath,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+	steps = append(steps, createWorkload(workloadNamespace, agnhostName)...)
+
+	steps = append(steps, generateTraffic(workloadNamespace, podName))
+
+	steps = append(steps,
+		&types.StepWrapper{
+			Step: &kubernetes.PortForward{
+				Namespace:                          "kube-system",
+				LabelSelector:                      "k8s-app=retina",
+				LocalPort:                          strconv.Itoa(common.RetinaPort),
+				RemotePort:                         strconv.Itoa(common.RetinaPort),
+				Endpoint:                           "metrics",
+				OptionalLabelAffinity:              "app=" + agnhostName,
+				OptionalLabelAffinityAllNamespaces: true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "metricsconfig-port-forward",
+			},
+		},
+		waitForAdvancedMetric(podName),
+		&types.StepWrapper{
+			Step: &kubernetes.DeleteYAML{
+				YAMLFilePath: metricsConfigFilePath,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	)
+
+	// traffic after the deletion must not bring the advanced series back either, given the time the agent takes
+	// to export it
+	steps = append(steps,
+		generateTraffic(workloadNamespace, podName),
+		&types.StepWrapper{
+			Step: &types.Sleep{
+				Duration: sleepDelay,
+			},
+		},
+	)
+
+	steps = append(steps,
+		&types.StepWrapper{
+			Step: &ValidateAdvancedMetricPresence{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				MetricName:              advForwardCountMetricName,
+				PodName:                 podName,
+				ExpectPresent:           false,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		&types.StepWrapper{
+			Step: &types.Stop{
+				BackgroundID: "metricsconfig-port-forward",
+			},
+		},
+		&types.StepWrapper{
+			Step: &kubernetes.EnsureNoRestarts{
+				PodNamespace:  "kube-system",
+				LabelSelector: "control-plane=retina-operator",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		&types.StepWrapper{
+			Step: &kubernetes.EnsureNoRestarts{
+				PodNamespace:  "kube-system",
+				LabelSelector: "k8s-app=retina",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		deleteNamespace(workloadNamespace),
+	)
+
+	return types.NewScenario(name, steps...).
+		WithSetup(disableAnnotations(kubeConfigFilePath, chartPath, valuesFilePath)...).
+		WithCleanup(restoreAnnotations(kubeConfigFilePath, chartPath, valuesFilePath)...)
+}
+
+// ValidateNamespaceScopedMetricsConfiguration applies a MetricsConfiguration including only ScopedNamespace,
+// sends the same traffic from pods in that namespace and another, and validates the configured advanced
+// metrics only have series for the pod in ScopedNamespace. MetricsConfiguration is cluster scoped, its
+// spec.namespaces is what scopes it. metricsConfigFilePath must include only ScopedNamespace.
+//
+// Like ValidateMetricsConfigurationDeletion, it reinstalls Retina with annotations disabled, and back afterwards.
+func ValidateNamespaceScopedMetricsConfiguration(kubeConfigFilePath, chartPath, valuesFilePath, metricsConfigFilePath string) *types.Scenario {
+	name := "Namespace Scoped MetricsConfiguration"
+	scopedName := "agnhost-metricsconfig-scoped"
+	unscopedName := "agnhost-metricsconfig-unscoped"
+
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.ApplyYAML{
+				YAMLFilePath: metricsConfigFilePath,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+	steps = append(steps, createWorkload(ScopedNamespace, scopedName)...)
+	steps = append(steps, createWorkload(unscopedNamespace, unscopedName)...)
+
+	steps = append(steps,
+		generateTraffic(ScopedNamespace, scopedName+"-0"),
+		generateTraffic(unscopedNamespace, unscopedName+"-0"),
+	)
+
+	steps = append(steps,
+		&types.StepWrapper{
+			Step: &kubernetes.PortForward{
+				Namespace:                          "kube-system",
+				LabelSelector:                      "k8s-app=retina",
+				LocalPort:                          strconv.Itoa(common.RetinaPort),
+				RemotePort:                         strconv.Itoa(common.RetinaPort),
+				Endpoint:                           "metrics",
+				OptionalLabelAffinity:              "app=" + scopedName,
+				OptionalLabelAffinityAllNamespaces: true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "metricsconfig-scoped-port-forward",
+			},
+		},
+		&types.StepWrapper{
+			Step: &kubernetes.PortForward{
+				Namespace:                          "kube-system",
+				LabelSelector:                      "k8s-app=retina",
+				LocalPort:                          strconv.Itoa(unscopedNodeLocalPort),
+				RemotePort:                         strconv.Itoa(common.RetinaPort),
+				Endpoint:                           "metrics",
+				OptionalLabelAffinity:              "app=" + unscopedName,
+				OptionalLabelAffinityAllNamespaces: true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "metricsconfig-unscoped-port-forward",
+			},
+		},
+		// the scoped pod's series showing up confirms the configuration is in effect before checking the other
+		waitForAdvancedMetric(scopedName+"-0"),
+		&types.StepWrapper{
+			Step: &ValidateAdvancedMetricPresence{
+				PortForwardedRetinaPort: strconv.Itoa(unscopedNodeLocalPort),
+				MetricName:              advForwardCountMetricName,
+				PodName:                 unscopedName + "-0",
+				ExpectPresent:           false,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		&types.StepWrapper{
+			Step: &types.Stop{
+				BackgroundID: "metricsconfig-unscoped-port-forward",
+			},
+		},
+		&types.StepWrapper{
+			Step: &types.Stop{
+				BackgroundID: "metricsconfig-scoped-port-forward",
+			},
+		},
+		&types.StepWrapper{
+			Step: &kubernetes.DeleteYAML{
+				YAMLFilePath: metricsConfigFilePath,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		deleteNamespace(ScopedNamespace),
+		deleteNamespace(unscopedNamespace),
+	)
+
+	return types.NewScenario(name, steps...).
+		WithSetup(disableAnnotations(kubeConfigFilePath, chartPath, valuesFilePath)...).
+		WithCleanup(restoreAnnotations(kubeConfigFilePath, chartPath, valuesFilePath)...)
+}
+
+// disableAnnotations upgrades Retina with annotations disabled, so MetricsConfiguration is reconciled
+func disableAnnotations(kubeConfigFilePath, chartPath, valuesFilePath string) []*types.StepWrapper {
+	return upgradeRetina(kubeConfigFilePath, chartPath, valuesFilePath, []string{"enableAnnotations=false"})
+}
+
+// restoreAnnotations upgrades Retina back to the values file alone
+func restoreAnnotations(kubeConfigFilePath, chartPath, valuesFilePath string) []*types.StepWrapper {
+	return upgradeRetina(kubeConfigFilePath, chartPath, valuesFilePath, nil)
+}
+
+func upgradeRetina(kubeConfigFilePath, chartPath, valuesFilePath string, setValues []string) []*types.StepWrapper {
+	return []*types.StepWrapper{
+		{
+			Step: &kubernetes.UpgradeRetinaHelmChart{
+				Namespace:          "kube-system",
+				ReleaseName:        "retina",
+				KubeConfigFilePath: kubeConfigFilePath,
+				ChartPath:          chartPath,
+				TagEnv:             generic.DefaultTagEnv,
+				ValuesFile:         valuesFilePath,
+				SetValues:          setValues,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.EnsureStableCluster{
+				PodNamespace:  "kube-system",
+				LabelSelector: "k8s-app=retina",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+}
+
+func createWorkload(namespace, agnhostName string) []*types.StepWrapper {
+	return []*types.StepWrapper{
+		{
+			Step: &kubernetes.CreateNamespace{
+				NamespaceName: namespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      agnhostName,
+				AgnhostNamespace: namespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+}
+
+func generateTraffic(namespace, podName string) *types.StepWrapper {
+	return &types.StepWrapper{
+		Step: &kubernetes.ExecInPod{
+			PodName:      podName,
+			PodNamespace: namespace,
+			Command:      "curl -s -m 5 bing.com",
+		},
+		Opts: &types.StepOptions{
+			SkipSavingParametersToJob: true,
+		},
+	}
+}
+
+// waitForAdvancedMetric waits on the port forwarded agent to export the pod's series of the advanced metric the
+// MetricsConfiguration configures, MetricsConfiguration source labels being exported with the source_ prefix
+func waitForAdvancedMetric(podName string) *types.StepWrapper {
+	return &types.StepWrapper{
+		Step: &prom.WaitForMetric{
+			PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+			MetricName:              advForwardCountMetricName,
+			Labels:                  map[string]string{"source_podname": podName},
+		},
+		Opts: &types.StepOptions{
+			SkipSavingParametersToJob: true,
+		},
+	}
+}
+
+// deleteNamespace removes the namespace along with the workload in it
+func deleteNamespace(namespace string) *types.StepWrapper {
+	return &types.StepWrapper{
+		Step: &kubernetes.DeleteKubernetesResource{
+			ResourceType:      kubernetes.TypeString(kubernetes.Namespace),
+			ResourceName:      namespace,
+			ResourceNamespace: namespace,
+		},
+		Opts: &types.StepOptions{
+			SkipSavingParametersToJob: true,
+		},
+	}
+}
@@ -0,0 +1,70 @@
+package metricsconfig
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/microsoft/retina/test/retry"
+)
+
+const (
+	metricRetryAttempts = 24
+	metricRetryDelay    = 5 * time.Second
+)
+
+var (
+	ErrAdvancedMetricMissing  = fmt.Errorf("advanced metric is missing")
+	ErrAdvancedMetricOrphaned = fmt.Errorf("advanced metric is still exported")
+)
+
+// ValidateAdvancedMetricPresence waits until the pod's series of an advanced metric are exported when
+// ExpectPresent is set, or until none are left when it isn't
+type ValidateAdvancedMetricPresence struct {
+	PortForwardedRetinaPort string
+	MetricName              string
+	PodName                 string
+	ExpectPresent           bool
+}
+
+func (v *ValidateAdvancedMetricPresence) Run() error {
+	promAddress := fmt.Sprintf("http://localhost:%s/metrics", v.PortForwardedRetinaPort)
+	// MetricsConfiguration source labels are exported with the source_ prefix
+	labels := map[string]string{"source_podname": v.PodName}
+
+	checkFn := func() error {
+		series, err := prom.GetMetricsMatchingLabels(promAddress, v.MetricName, labels)
+		if err != nil {
+			return fmt.Errorf("failed to scrape %s: %w", v.MetricName, err)
+		}
+
+		switch {
+		case v.ExpectPresent && len(series) == 0:
+			log.Printf("no %s series for pod %s yet\n", v.MetricName, v.PodName)
+			return ErrAdvancedMetricMissing
+		case !v.ExpectPresent && len(series) > 0:
+			log.Printf("%d %s series for pod %s still exported\n", len(series), v.MetricName, v.PodName)
+			return ErrAdvancedMetricOrphaned
+		}
+		return nil
+	}
+
+	retrier := retry.Retrier{Attempts: metricRetryAttempts, Delay: metricRetryDelay}
+	err := retrier.Do(context.Background(), checkFn)
+	if err != nil {
+		return fmt.Errorf("failed to verify presence of %s for pod %s is %t: %w", v.MetricName, v.PodName, v.ExpectPresent, err)
+	}
+
+	log.Printf("presence of %s for pod %s is %t as expected\n", v.MetricName, v.PodName, v.ExpectPresent)
+	return nil
+}
+
+func (v *ValidateAdvancedMetricPresence) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateAdvancedMetricPresence) Stop() error {
+	return nil
+}
@@ -0,0 +1,135 @@
+package metricsendpoint
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+)
+
+// loose enough for a CI cluster, catching an endpoint that slows down by an order of magnitude
+const (
+	scrapeIterations = 20
+	maxScrapeP95     = 2 * time.Second
+	maxScrapeBytes   = 20 << 20
+)
+
+// ValidateMetricsEndpointHead validates an agent's metrics endpoint handles HEAD requests
+func ValidateMetricsEndpointHead() *types.Scenario {
+	name := "Metrics Endpoint HEAD Request"
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.PortForward{
+				Namespace:     "kube-system",
+				LabelSelector: "k8s-app=retina",
+				LocalPort:     strconv.Itoa(common.RetinaPort),
+				RemotePort:    strconv.Itoa(common.RetinaPort),
+				Endpoint:      "metrics",
+				// any agent will do, so pick one on a node running an agent
+				OptionalLabelAffinity: "k8s-app=retina",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "metrics-head-port-forward",
+			},
+		},
+		{
+			Step: &ValidateMetricsHeadRequest{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &types.Stop{
+				BackgroundID: "metrics-head-port-forward",
+			},
+		},
+	}
+
+	return types.NewScenario(name, steps...)
+}
+
+// ValidateMetricsEndpointContentNegotiation validates an agent's metrics endpoint negotiates the text and
+// OpenMetrics exposition formats with the Accept header of a scrape
+func ValidateMetricsEndpointContentNegotiation() *types.Scenario {
+	name := "Metrics Endpoint Content Negotiation"
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.PortForward{
+				Namespace:     "kube-system",
+				LabelSelector: "k8s-app=retina",
+				LocalPort:     strconv.Itoa(common.RetinaPort),
+				RemotePort:    strconv.Itoa(common.RetinaPort),
+				Endpoint:      "metrics",
+				// any agent will do, so pick one on a node running an agent
+				OptionalLabelAffinity: "k8s-app=retina",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "metrics-negotiation-port-forward",
+			},
+		},
+		{
+			Step: &ValidateMetricsContentNegotiation{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &types.Stop{
+				BackgroundID: "metrics-negotiation-port-forward",
+			},
+		},
+	}
+
+	return types.NewScenario(name, steps...)
+}
+
+// ValidateMetricsScrapePerformance benchmarks scraping an agent's metrics endpoint, failing if the p95 latency of a
+// scrape or the size of the payload regress past loose thresholds. The measured numbers go into the job's report
+func ValidateMetricsScrapePerformance() *types.Scenario {
+	name := "Metrics Endpoint Scrape Performance"
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.PortForward{
+				Namespace:     "kube-system",
+				LabelSelector: "k8s-app=retina",
+				LocalPort:     strconv.Itoa(common.RetinaPort),
+				RemotePort:    strconv.Itoa(common.RetinaPort),
+				Endpoint:      "metrics",
+				// any agent will do, so pick one on a node running an agent
+				OptionalLabelAffinity: "k8s-app=retina",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "metrics-benchmark-port-forward",
+			},
+		},
+		{
+			Step: &prom.BenchmarkMetricsScrape{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				Iterations:              scrapeIterations,
+				Warmup:                  true,
+				MaxP95:                  maxScrapeP95,
+				MaxBytes:                maxScrapeBytes,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &types.Stop{
+				BackgroundID: "metrics-benchmark-port-forward",
+			},
+		},
+	}
+
+	return types.NewScenario(name, steps...)
+}
@@ -0,0 +1,46 @@
+package metricsendpoint
+
+import (
+	"fmt"
+	"log"
+
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/prometheus/common/expfmt"
+)
+
+const (
+	textAccept = "text/plain;version=0.0.4"
+	// what Prometheus sends when scraping with OpenMetrics enabled, falling back to the text format
+	openMetricsAccept = "application/openmetrics-text;version=1.0.0,text/plain;version=0.0.4;q=0.5"
+)
+
+// ValidateMetricsContentNegotiation checks the agent's metrics endpoint answers a scraper asking for the text
+// format with it, and one asking for OpenMetrics with either OpenMetrics, ending in its "# EOF" line, or the
+// text format it falls back to. The agent doesn't enable OpenMetrics, so it answers with the text format today
+type ValidateMetricsContentNegotiation struct {
+	PortForwardedRetinaPort string
+}
+
+func (v *ValidateMetricsContentNegotiation) Run() error {
+	promAddress := fmt.Sprintf("http://localhost:%s/metrics", v.PortForwardedRetinaPort)
+
+	_, err := prom.CheckMetricsEndpointFormat(promAddress, textAccept, expfmt.TypeTextPlain)
+	if err != nil {
+		return fmt.Errorf("failed to verify text format from %s: %w", promAddress, err)
+	}
+
+	format, err := prom.CheckMetricsEndpointFormat(promAddress, openMetricsAccept, expfmt.TypeOpenMetrics, expfmt.TypeTextPlain)
+	if err != nil {
+		return fmt.Errorf("failed to verify OpenMetrics negotiation with %s: %w", promAddress, err)
+	}
+	log.Printf("OpenMetrics scrape of %s negotiated %s\n", promAddress, expfmt.NewFormat(format))
+	return nil
+}
+
+func (v *ValidateMetricsContentNegotiation) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateMetricsContentNegotiation) Stop() error {
+	return nil
+}
@@ -0,0 +1,31 @@
+package metricsendpoint
+
+import (
+	"fmt"
+
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+)
+
+// ValidateMetricsHeadRequest checks the agent's metrics endpoint answers a HEAD request with a 200,
+// a prometheus content type and no body, rather than only implementing GET
+type ValidateMetricsHeadRequest struct {
+	PortForwardedRetinaPort string
+}
+
+func (v *ValidateMetricsHeadRequest) Run() error {
+	promAddress := fmt.Sprintf("http://localhost:%s/metrics", v.PortForwardedRetinaPort)
+
+	err := prom.CheckMetricsEndpointHead(promAddress)
+	if err != nil {
+		return fmt.Errorf("failed to verify HEAD request to %s: %w", promAddress, err)
+	}
+	return nil
+}
+
+func (v *ValidateMetricsHeadRequest) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateMetricsHeadRequest) Stop() error {
+	return nil
+}
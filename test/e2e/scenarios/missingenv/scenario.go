@@ -0,0 +1,63 @@
+package missingenv
+
+import (
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+)
+
+const (
+	// NodeNameEnv is the downward API env var the agent scopes its pod cache to its node with
+	NodeNameEnv = "NODE_NAME"
+
+	// logged by the agent when it starts without NodeNameEnv while watching only its own node's pods
+	missingNodeNameMessage = "failed to get node name from environment variable"
+)
+
+// ValidateMissingNodeNameEnv removes NODE_NAME from the retina DaemonSet and validates the agent rolled out
+// without it fails at startup with a log naming the missing env var, rather than running with metrics for
+// pods it can't scope to its node, then puts the env var back.
+//
+// The agent only reads NODE_NAME with pod level metrics in local context, as in the advanced metrics profile.
+func ValidateMissingNodeNameEnv() *types.Scenario {
+	name := "Missing Node Name Env"
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.RemoveDaemonSetEnv{
+				DaemonSetNamespace: "kube-system",
+				DaemonSetName:      "retina-agent",
+				ContainerName:      retinaContainerName,
+				EnvName:            NodeNameEnv,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "missing-node-name-env",
+			},
+		},
+		{
+			Step: &ValidateAgentMissingEnvFailure{
+				RetinaDaemonSetNamespace: "kube-system",
+				EnvName:                  NodeNameEnv,
+				ExpectedMessage:          missingNodeNameMessage,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &types.Stop{
+				BackgroundID: "missing-node-name-env",
+			},
+		},
+		{
+			Step: &kubernetes.EnsureStableCluster{
+				PodNamespace:  "kube-system",
+				LabelSelector: "k8s-app=retina",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	return types.NewScenario(name, steps...)
+}
@@ -0,0 +1,129 @@
+package missingenv
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/retry"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	retinaContainerName = "retina"
+
+	missingEnvRetryAttempts = 60
+	missingEnvRetryDelay    = 5 * time.Second
+)
+
+var (
+	ErrAgentNotRolledOut     = fmt.Errorf("no retina pod without the env var yet")
+	ErrAgentDidNotFail       = fmt.Errorf("retina agent without the env var has not failed")
+	ErrMissingEnvNotLogged   = fmt.Errorf("retina agent failed without logging the missing env var")
+	ErrAgentReadyWithoutEnv  = fmt.Errorf("retina agent without the env var is ready")
+	ErrMissingEnvNotReported = fmt.Errorf("retina agent did not fail clearly without the env var")
+)
+
+// ValidateAgentMissingEnvFailure checks a retina agent rolled out without the EnvName env var fails at startup,
+// restarting with a log that contains ExpectedMessage, rather than becoming ready and serving metrics it can't
+// attribute to the right node
+type ValidateAgentMissingEnvFailure struct {
+	KubeConfigFilePath       string
+	RetinaDaemonSetNamespace string
+	EnvName                  string
+	ExpectedMessage          string
+}
+
+func (v *ValidateAgentMissingEnvFailure) Run() error {
+	config, err := k8s.BuildConfig(v.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx := context.Background()
+	checkFn := func() error {
+		return v.checkAgents(ctx, clientset)
+	}
+
+	retrier := retry.Retrier{Attempts: missingEnvRetryAttempts, Delay: missingEnvRetryDelay}
+	err = retrier.Do(ctx, checkFn)
+	if err != nil {
+		return fmt.Errorf("env var %s: %w: %w", v.EnvName, ErrMissingEnvNotReported, err)
+	}
+	return nil
+}
+
+func (v *ValidateAgentMissingEnvFailure) checkAgents(ctx context.Context, clientset *kubernetes.Clientset) error {
+	pods, err := clientset.CoreV1().Pods(v.RetinaDaemonSetNamespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "k8s-app=retina",
+	})
+	if err != nil {
+		return fmt.Errorf("error listing retina pods: %w", err)
+	}
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if hasEnv(pod, v.EnvName) {
+			continue
+		}
+
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.Name != retinaContainerName {
+				continue
+			}
+			if status.Ready {
+				return fmt.Errorf("retina pod %s: %w", pod.Name, ErrAgentReadyWithoutEnv)
+			}
+			if status.RestartCount == 0 || status.LastTerminationState.Terminated == nil {
+				log.Printf("retina pod %s without %s has not restarted yet\n", pod.Name, v.EnvName)
+				return ErrAgentDidNotFail
+			}
+
+			logs, err := k8s.ReadPreviousPodLogs(ctx, clientset, pod.Namespace, pod.Name, retinaContainerName)
+			if err != nil {
+				return fmt.Errorf("error reading previous logs of retina pod %s: %w", pod.Name, err)
+			}
+			if !strings.Contains(string(logs), v.ExpectedMessage) {
+				return fmt.Errorf("retina pod %s restarted %d times: %w", pod.Name, status.RestartCount, ErrMissingEnvNotLogged)
+			}
+
+			log.Printf("retina pod %s without %s failed with \"%s\" and restarted %d times\n", pod.Name, v.EnvName, v.ExpectedMessage, status.RestartCount)
+			return nil
+		}
+	}
+
+	log.Printf("no retina pod without %s is running yet\n", v.EnvName)
+	return ErrAgentNotRolledOut
+}
+
+func hasEnv(pod *corev1.Pod, name string) bool {
+	for i := range pod.Spec.Containers {
+		if pod.Spec.Containers[i].Name != retinaContainerName {
+			continue
+		}
+		for _, env := range pod.Spec.Containers[i].Env {
+			if env.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (v *ValidateAgentMissingEnvFailure) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateAgentMissingEnvFailure) Stop() error {
+	return nil
+}
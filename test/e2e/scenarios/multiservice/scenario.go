@@ -0,0 +1,137 @@
+package multiservice
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+)
+
+const workloadNamespace = "retina-multi-service"
+
+// ValidateMultiServiceMetrics puts one server pod behind two Services, sends traffic through each in turn
+// and validates the server answered it and its flow metrics stay attributed to the pod after each
+func ValidateMultiServiceMetrics() *types.Scenario {
+	name := "Multi Service Flow Metrics"
+	clientName := "agnhost-multi-svc-client"
+	serverName := "agnhost-multi-svc-server"
+	serviceNames := []string{"multi-svc-a", "multi-svc-b"}
+
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.CreateNamespace{
+				NamespaceName: workloadNamespace,
+				Observe:       true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      serverName,
+				AgnhostNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      clientName,
+				AgnhostNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	for _, serviceName := range serviceNames {
+		steps = append(steps, &types.StepWrapper{
+			Step: &kubernetes.CreateAgnhostService{
+				ServiceName:      serviceName,
+				ServiceNamespace: workloadNamespace,
+				AgnhostName:      serverName,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		})
+	}
+
+	steps = append(steps, &types.StepWrapper{
+		Step: &kubernetes.PortForward{
+			Namespace:                          "kube-system",
+			LabelSelector:                      "k8s-app=retina",
+			LocalPort:                          strconv.Itoa(common.RetinaPort),
+			RemotePort:                         strconv.Itoa(common.RetinaPort),
+			Endpoint:                           "metrics",
+			OptionalLabelAffinity:              "app=" + serverName,
+			OptionalLabelAffinityAllNamespaces: true,
+		},
+		Opts: &types.StepOptions{
+			SkipSavingParametersToJob: true,
+			RunInBackgroundWithID:     "multi-svc-port-forward",
+		},
+	})
+
+	for _, serviceName := range serviceNames {
+		request := &kubernetes.ExecInPod{
+			PodName:      clientName + "-0",
+			PodNamespace: workloadNamespace,
+			Command:      fmt.Sprintf("curl -s -m 5 http://%s.%s.svc.cluster.local:%d", serviceName, workloadNamespace, kubernetes.AgnhostHTTPPort),
+		}
+		steps = append(steps,
+			&types.StepWrapper{
+				Step: request,
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+				},
+			},
+			&types.StepWrapper{
+				Step: &ValidateResponder{
+					ServiceName: serviceName,
+					PodName:     serverName + "-0",
+					request:     request,
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+				},
+			},
+			&types.StepWrapper{
+				Step: &ValidateMultiServiceAttribution{
+					PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+					NamespaceName:           workloadNamespace,
+					PodName:                 serverName + "-0",
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+				},
+			},
+		)
+	}
+
+	steps = append(steps,
+		&types.StepWrapper{
+			Step: &types.Stop{
+				BackgroundID: "multi-svc-port-forward",
+			},
+		},
+		// deleting the namespace removes the workloads and both services with it
+		&types.StepWrapper{
+			Step: &kubernetes.DeleteKubernetesResource{
+				ResourceType:      kubernetes.TypeString(kubernetes.Namespace),
+				ResourceName:      workloadNamespace,
+				ResourceNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	)
+
+	return types.NewScenario(name, steps...)
+}
@@ -0,0 +1,90 @@
+package multiservice
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/microsoft/retina/test/retry"
+)
+
+const (
+	advForwardCountMetricName = "networkobservability_adv_forward_count"
+
+	metricRetryAttempts = 20
+	metricRetryDelay    = 5 * time.Second
+)
+
+var (
+	ErrNoIngressSeries      = fmt.Errorf("no ingress series attributed to the pod")
+	ErrAmbiguousAttribution = fmt.Errorf("pod IP attributed to another workload")
+)
+
+// ValidateMultiServiceAttribution checks the traffic a pod received through one of its Services is recorded
+// against the pod itself, and that every series for the pod's IP names the same pod and namespace whichever
+// Service the traffic came through. Advanced local context metrics carry no Service label, so the Service
+// path can't be told apart in them
+type ValidateMultiServiceAttribution struct {
+	PortForwardedRetinaPort string
+	KubeConfigFilePath      string
+	NamespaceName           string
+	PodName                 string
+}
+
+func (v *ValidateMultiServiceAttribution) Run() error {
+	podIP, err := k8s.GetPodIP(v.KubeConfigFilePath, v.NamespaceName, v.PodName)
+	if err != nil {
+		return fmt.Errorf("failed to get pod IP: %w", err)
+	}
+
+	promAddress := fmt.Sprintf("http://localhost:%s/metrics", v.PortForwardedRetinaPort)
+
+	checkFn := func() error {
+		series, scrapeErr := prom.GetMetricsMatchingLabels(promAddress, advForwardCountMetricName, map[string]string{"ip": podIP})
+		if scrapeErr != nil {
+			return fmt.Errorf("failed to scrape %s: %w", advForwardCountMetricName, scrapeErr)
+		}
+
+		ingress := 0
+		for _, metric := range series {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+
+			if labels["namespace"] != v.NamespaceName || labels["podname"] != v.PodName {
+				return fmt.Errorf("series for %s is attributed to %s/%s instead of %s/%s: %w",
+					podIP, labels["namespace"], labels["podname"], v.NamespaceName, v.PodName, ErrAmbiguousAttribution)
+			}
+			if labels["direction"] == "ingress" {
+				ingress++
+			}
+		}
+
+		if ingress == 0 {
+			log.Printf("no ingress %s series for %s/%s (%s) yet\n", advForwardCountMetricName, v.NamespaceName, v.PodName, podIP)
+			return ErrNoIngressSeries
+		}
+		return nil
+	}
+
+	retrier := retry.Retrier{Attempts: metricRetryAttempts, Delay: metricRetryDelay}
+	err = retrier.Do(context.Background(), checkFn)
+	if err != nil {
+		return fmt.Errorf("failed to verify prometheus metrics %s: %w", advForwardCountMetricName, err)
+	}
+
+	log.Printf("series for %s are attributed to %s/%s\n", podIP, v.NamespaceName, v.PodName)
+	return nil
+}
+
+func (v *ValidateMultiServiceAttribution) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateMultiServiceAttribution) Stop() error {
+	return nil
+}
@@ -0,0 +1,39 @@
+package multiservice
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+)
+
+var ErrUnexpectedResponder = fmt.Errorf("request answered by an unexpected pod")
+
+// ValidateResponder checks the request ExecInPod sent through a Service was answered by PodName. The server
+// serves its hostname, which is its pod name, so the request's stdout names the pod behind the Service
+type ValidateResponder struct {
+	ServiceName string
+	PodName     string
+
+	request *k8s.ExecInPod
+}
+
+func (v *ValidateResponder) Run() error {
+	responder := strings.TrimSpace(v.request.Stdout())
+	if responder != v.PodName {
+		return fmt.Errorf("service \"%s\" answered by \"%s\", stderr \"%s\", expected \"%s\": %w",
+			v.ServiceName, responder, strings.TrimSpace(v.request.Stderr()), v.PodName, ErrUnexpectedResponder)
+	}
+
+	log.Printf("service \"%s\" was answered by pod \"%s\"\n", v.ServiceName, responder)
+	return nil
+}
+
+func (v *ValidateResponder) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateResponder) Stop() error {
+	return nil
+}
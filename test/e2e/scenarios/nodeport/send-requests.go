@@ -0,0 +1,79 @@
+package nodeport
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const defaultTimeout = 5 * time.Minute
+
+var ErrNoHostIP = fmt.Errorf("pod has no host IP")
+
+// SendNodePortRequests makes Requests HTTP requests from the client pod to NodePort on the node of the server
+// pod, each of which must be answered. The client is spread onto another node than the server when the cluster
+// has one, so the requests leave the client's node for the server's, where they are NATed to the server pod
+type SendNodePortRequests struct {
+	KubeConfigFilePath string
+	PodNamespace       string
+	ClientPodName      string
+	ServerPodName      string
+	NodePort           int
+	Requests           int
+}
+
+func (s *SendNodePortRequests) Run() error {
+	config, err := k8s.BuildConfig(s.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	client, err := clientset.CoreV1().Pods(s.PodNamespace).Get(ctx, s.ClientPodName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting client pod \"%s\": %w", s.ClientPodName, err)
+	}
+
+	server, err := clientset.CoreV1().Pods(s.PodNamespace).Get(ctx, s.ServerPodName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting server pod \"%s\": %w", s.ServerPodName, err)
+	}
+	if server.Status.HostIP == "" {
+		return fmt.Errorf("server pod \"%s\": %w", s.ServerPodName, ErrNoHostIP)
+	}
+
+	if client.Spec.NodeName == server.Spec.NodeName {
+		log.Printf("client and server pods are both on node %s, requests won't cross nodes\n", server.Spec.NodeName)
+	}
+
+	request := fmt.Sprintf("curl -s -m 3 -o /dev/null http://%s:%d", server.Status.HostIP, s.NodePort)
+	for i := 0; i < s.Requests; i++ {
+		_, err = k8s.ExecPod(ctx, clientset, config, s.PodNamespace, s.ClientPodName, request)
+		if err != nil {
+			return fmt.Errorf("request %d from %s to node port %s:%d: %w", i, s.ClientPodName, server.Status.HostIP, s.NodePort, err)
+		}
+	}
+
+	log.Printf("sent %d requests from %s to node port %s:%d\n", s.Requests, s.ClientPodName, server.Status.HostIP, s.NodePort)
+	return nil
+}
+
+func (s *SendNodePortRequests) Prevalidate() error {
+	return nil
+}
+
+func (s *SendNodePortRequests) Stop() error {
+	return nil
+}
@@ -0,0 +1,102 @@
+package nodeport
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/microsoft/retina/test/retry"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	advForwardCountMetricName = "networkobservability_adv_forward_count"
+
+	metricRetryAttempts = 20
+	metricRetryDelay    = 5 * time.Second
+)
+
+var (
+	ErrNoIngressSeries       = fmt.Errorf("no ingress series attributed to the pod")
+	ErrNodeIPAttributedToPod = fmt.Errorf("node IP attributed to the pod")
+)
+
+// ValidateNodePortAttribution checks the traffic a pod received through a NodePort is recorded against the
+// pod's own IP once it's been NATed from the node's address, and that the node's IP, which the client addressed,
+// is never attributed to the pod
+type ValidateNodePortAttribution struct {
+	PortForwardedRetinaPort string
+	KubeConfigFilePath      string
+	NamespaceName           string
+	PodName                 string
+}
+
+func (v *ValidateNodePortAttribution) Run() error {
+	config, err := k8s.BuildConfig(v.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	pod, err := clientset.CoreV1().Pods(v.NamespaceName).Get(context.Background(), v.PodName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting pod \"%s\": %w", v.PodName, err)
+	}
+	podIP, hostIP := pod.Status.PodIP, pod.Status.HostIP
+
+	promAddress := fmt.Sprintf("http://localhost:%s/metrics", v.PortForwardedRetinaPort)
+
+	checkFn := func() error {
+		series, scrapeErr := prom.GetMetricsMatchingLabels(promAddress, advForwardCountMetricName, map[string]string{
+			"direction": "ingress",
+			"ip":        podIP,
+			"namespace": v.NamespaceName,
+			"podname":   v.PodName,
+		})
+		if scrapeErr != nil {
+			return fmt.Errorf("failed to scrape %s: %w", advForwardCountMetricName, scrapeErr)
+		}
+		if len(series) == 0 {
+			log.Printf("no ingress %s series for %s/%s (%s) yet\n", advForwardCountMetricName, v.NamespaceName, v.PodName, podIP)
+			return ErrNoIngressSeries
+		}
+		return nil
+	}
+
+	retrier := retry.Retrier{Attempts: metricRetryAttempts, Delay: metricRetryDelay}
+	err = retrier.Do(context.Background(), checkFn)
+	if err != nil {
+		return fmt.Errorf("failed to verify prometheus metrics %s: %w", advForwardCountMetricName, err)
+	}
+
+	// by now the agent has seen the NATed traffic, so any series naming the pod under the node's IP has been exported too
+	nodeSeries, err := prom.GetMetricsMatchingLabels(promAddress, advForwardCountMetricName, map[string]string{
+		"ip":      hostIP,
+		"podname": v.PodName,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to scrape %s: %w", advForwardCountMetricName, err)
+	}
+	if len(nodeSeries) > 0 {
+		return fmt.Errorf("%d series for node IP %s name %s: %w", len(nodeSeries), hostIP, v.PodName, ErrNodeIPAttributedToPod)
+	}
+
+	log.Printf("node port traffic through %s is attributed to %s/%s (%s)\n", hostIP, v.NamespaceName, v.PodName, podIP)
+	return nil
+}
+
+func (v *ValidateNodePortAttribution) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateNodePortAttribution) Stop() error {
+	return nil
+}
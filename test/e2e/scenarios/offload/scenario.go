@@ -0,0 +1,141 @@
+package offload
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+)
+
+const (
+	workloadNamespace = "retina-offload"
+
+	uploads = 3
+
+	// lets the flows of the pods' setup reach the metrics before the baseline is read
+	settleDelay = 15 * time.Second
+)
+
+// ValidateOffloadedFlowMetrics uploads a large file from a client pod to a server pod, a bulk TCP stream the
+// client's stack sends as segmentation offloaded packets, and validates the agent counts the client's egress
+// packets and bytes as its interface sent them, one packet per offloaded packet rather than per wire segment
+func ValidateOffloadedFlowMetrics() *types.Scenario {
+	name := "Offloaded Flow Metrics"
+	clientName := "agnhost-offload-client"
+	serverName := "agnhost-offload-server"
+	counters := &offloadCounters{}
+
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.CreateNamespace{
+				NamespaceName: workloadNamespace,
+				Observe:       true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: types.NewParallel(
+				&types.StepWrapper{
+					// netexec rather than serve-hostname, for its upload endpoint
+					Step: &kubernetes.CreateAgnhostStatefulSet{
+						AgnhostName:      serverName,
+						AgnhostNamespace: workloadNamespace,
+						Args:             []string{"netexec", "--http-port", strconv.Itoa(kubernetes.AgnhostHTTPPort)},
+					},
+					Opts: &types.StepOptions{
+						SkipSavingParametersToJob: true,
+					},
+				},
+				&types.StepWrapper{
+					Step: &kubernetes.CreateAgnhostStatefulSet{
+						AgnhostName:      clientName,
+						AgnhostNamespace: workloadNamespace,
+					},
+					Opts: &types.StepOptions{
+						SkipSavingParametersToJob: true,
+					},
+				},
+			),
+		},
+		{
+			Step: &kubernetes.PortForward{
+				Namespace:                          "kube-system",
+				LabelSelector:                      "k8s-app=retina",
+				LocalPort:                          strconv.Itoa(common.RetinaPort),
+				RemotePort:                         strconv.Itoa(common.RetinaPort),
+				Endpoint:                           "metrics",
+				OptionalLabelAffinity:              "app=" + clientName,
+				OptionalLabelAffinityAllNamespaces: true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "offload-port-forward",
+			},
+		},
+		{
+			Step: &types.Sleep{
+				Duration: settleDelay,
+			},
+		},
+		{
+			Step: &CaptureOffloadCounters{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				PodNamespace:            workloadNamespace,
+				PodName:                 clientName + "-0",
+				counters:                counters,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &UploadFile{
+				PodNamespace:         workloadNamespace,
+				PodName:              clientName + "-0",
+				DestinationNamespace: workloadNamespace,
+				DestinationPodName:   serverName + "-0",
+				FilePath:             agnhostBinary,
+				Uploads:              uploads,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &ValidateOffloadedCounts{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				PodNamespace:            workloadNamespace,
+				PodName:                 clientName + "-0",
+				counters:                counters,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	// deleting the namespace removes both workloads with it
+	cleanup := []*types.StepWrapper{
+		{
+			Step: &types.Stop{
+				BackgroundID: "offload-port-forward",
+			},
+		},
+		{
+			Step: &kubernetes.DeleteKubernetesResource{
+				ResourceType:      kubernetes.TypeString(kubernetes.Namespace),
+				ResourceName:      workloadNamespace,
+				ResourceNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	return types.NewScenario(name, steps...).WithCleanup(cleanup...)
+}
@@ -0,0 +1,71 @@
+package offload
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	uploadTimeout = 5 * time.Minute
+
+	// the agnhost binary, tens of megabytes the image already has
+	agnhostBinary = "/agnhost"
+)
+
+// UploadFile uploads the file at FilePath in the pod to the destination agnhost's netexec /upload endpoint Uploads
+// times. Each upload is a single bulk TCP stream, which the pod's stack hands to its interface in segments of many
+// MSS each for the interface to offload
+type UploadFile struct {
+	KubeConfigFilePath   string
+	PodNamespace         string
+	PodName              string
+	DestinationNamespace string
+	DestinationPodName   string
+	FilePath             string
+	Uploads              int
+}
+
+func (u *UploadFile) Run() error {
+	config, err := k8s.BuildConfig(u.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), uploadTimeout)
+	defer cancel()
+
+	destination, err := clientset.CoreV1().Pods(u.DestinationNamespace).Get(ctx, u.DestinationPodName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting destination pod \"%s\": %w", u.DestinationPodName, err)
+	}
+
+	command := fmt.Sprintf("curl -s -f -m 60 -o /dev/null -F file=@%s http://%s:%d/upload", u.FilePath, destination.Status.PodIP, k8s.AgnhostHTTPPort)
+	for i := 0; i < u.Uploads; i++ {
+		output, execErr := k8s.ExecPod(ctx, clientset, config, u.PodNamespace, u.PodName, command)
+		if execErr != nil {
+			return fmt.Errorf("error uploading %s %d from pod \"%s\": %s: %w", u.FilePath, i, u.PodName, string(output), execErr)
+		}
+	}
+
+	log.Printf("uploaded %s %d times from pod %s to %s\n", u.FilePath, u.Uploads, u.PodName, destination.Status.PodIP)
+	return nil
+}
+
+func (u *UploadFile) Prevalidate() error {
+	return nil
+}
+
+func (u *UploadFile) Stop() error {
+	return nil
+}
@@ -0,0 +1,232 @@
+package offload
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/microsoft/retina/test/retry"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	advForwardCountMetricName = "networkobservability_adv_forward_count"
+	advForwardBytesMetricName = "networkobservability_adv_forward_bytes"
+
+	// the pod's interface counts one Ethernet header per packet it sends, offloaded or not
+	ethernetHeaderBytes = 14
+
+	// the interface counters are read for the same traffic, so only a percent is allowed for stray packets
+	tolerancePercent = 5
+
+	metricRetryAttempts = 12
+	metricRetryDelay    = 5 * time.Second
+)
+
+var (
+	ErrCountersNotCaptured   = fmt.Errorf("offload counters baseline was not captured")
+	ErrUnexpectedCounters    = fmt.Errorf("unexpected interface counters")
+	ErrTrafficNotOffloaded   = fmt.Errorf("traffic wasn't offloaded")
+	ErrPacketsCountedOnWire  = fmt.Errorf("offloaded packets counted once per segment on the wire")
+	ErrInaccurateFlowMetrics = fmt.Errorf("flow metrics outside tolerance of the interface counters")
+)
+
+// offloadCounters carries what the pod's interface and the agent counted of the pod's egress before the
+// traffic to the step validating them
+type offloadCounters struct {
+	interfacePackets float64
+	interfaceBytes   float64
+	retinaPackets    float64
+	retinaBytes      float64
+	captured         bool
+}
+
+// readInterfaceCounters reads the packets and bytes the pod's eth0 has sent, and its MTU
+func readInterfaceCounters(ctx context.Context, clientset *kubernetes.Clientset, config *rest.Config, namespace, podName string) (packets, bytes, mtu float64, err error) {
+	command := "cat /sys/class/net/eth0/statistics/tx_packets /sys/class/net/eth0/statistics/tx_bytes /sys/class/net/eth0/mtu"
+	output, err := k8s.ExecPod(ctx, clientset, config, namespace, podName, command)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("error reading interface counters of pod \"%s\": %s: %w", podName, string(output), err)
+	}
+
+	fields := strings.Fields(string(output))
+	if len(fields) != 3 {
+		return 0, 0, 0, fmt.Errorf("counters \"%s\" of pod \"%s\": %w", string(output), podName, ErrUnexpectedCounters)
+	}
+	values := make([]float64, len(fields))
+	for i, field := range fields {
+		values[i], err = strconv.ParseFloat(field, 64)
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("error parsing counter \"%s\" of pod \"%s\": %w", field, podName, err)
+		}
+	}
+	return values[0], values[1], values[2], nil
+}
+
+// readRetinaCounters adds up the pod's egress forward count and bytes series, one per local context label set
+func readRetinaCounters(portForwardedRetinaPort, podName string) (packets, bytes float64, err error) {
+	packets, err = sumSeries(portForwardedRetinaPort, advForwardCountMetricName, podName)
+	if err != nil {
+		return 0, 0, err
+	}
+	bytes, err = sumSeries(portForwardedRetinaPort, advForwardBytesMetricName, podName)
+	if err != nil {
+		return 0, 0, err
+	}
+	return packets, bytes, nil
+}
+
+func sumSeries(portForwardedRetinaPort, metricName, podName string) (float64, error) {
+	promAddress := fmt.Sprintf("http://localhost:%s/metrics", portForwardedRetinaPort)
+	series, err := prom.GetMetricsMatchingLabels(promAddress, metricName, map[string]string{
+		"podname":   podName,
+		"direction": "egress",
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to scrape %s: %w", metricName, err)
+	}
+
+	total := 0.0
+	for _, metric := range series {
+		total += metric.GetGauge().GetValue() + metric.GetCounter().GetValue()
+	}
+	return total, nil
+}
+
+func withinTolerance(value, minimum, maximum float64) bool {
+	slack := maximum * tolerancePercent / 100
+	return value >= minimum-slack && value <= maximum+slack
+}
+
+// CaptureOffloadCounters records the pod's interface counters and the agent's egress counts for it before the traffic is sent
+type CaptureOffloadCounters struct {
+	KubeConfigFilePath      string
+	PortForwardedRetinaPort string
+	PodNamespace            string
+	PodName                 string
+
+	counters *offloadCounters
+}
+
+func (c *CaptureOffloadCounters) Run() error {
+	config, err := k8s.BuildConfig(c.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	c.counters.interfacePackets, c.counters.interfaceBytes, _, err = readInterfaceCounters(context.Background(), clientset, config, c.PodNamespace, c.PodName)
+	if err != nil {
+		return err
+	}
+	c.counters.retinaPackets, c.counters.retinaBytes, err = readRetinaCounters(c.PortForwardedRetinaPort, c.PodName)
+	if err != nil {
+		return err
+	}
+
+	c.counters.captured = true
+	log.Printf("pod %s sent %.0f packets, %.0f bytes before traffic, of which the agent counted %.0f packets, %.0f bytes\n",
+		c.PodName, c.counters.interfacePackets, c.counters.interfaceBytes, c.counters.retinaPackets, c.counters.retinaBytes)
+	return nil
+}
+
+func (c *CaptureOffloadCounters) Prevalidate() error {
+	return nil
+}
+
+func (c *CaptureOffloadCounters) Stop() error {
+	return nil
+}
+
+// ValidateOffloadedCounts validates the agent counts offloaded egress of the pod as the packets the pod's stack
+// handed to its interface, rather than the segments they are cut into on the wire. The agent's programs see
+// packets before segmentation, as the interface's own counters do, so its egress packet and byte counts must
+// grow by what the interface sent since the baseline, within tolerancePercent.
+//
+// The traffic must have been offloaded for that to mean anything: the interface's packets must average more than
+// its MTU, and the agent must count fewer packets than the fewest MTU sized segments the bytes fit in
+type ValidateOffloadedCounts struct {
+	KubeConfigFilePath      string
+	PortForwardedRetinaPort string
+	PodNamespace            string
+	PodName                 string
+
+	counters *offloadCounters
+}
+
+func (v *ValidateOffloadedCounts) Run() error {
+	if !v.counters.captured {
+		return ErrCountersNotCaptured
+	}
+
+	config, err := k8s.BuildConfig(v.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	// the traffic is over, so the interface counters are final
+	interfacePackets, interfaceBytes, mtu, err := readInterfaceCounters(context.Background(), clientset, config, v.PodNamespace, v.PodName)
+	if err != nil {
+		return err
+	}
+	sentPackets := interfacePackets - v.counters.interfacePackets
+	sentBytes := interfaceBytes - v.counters.interfaceBytes
+	if sentPackets <= 0 || sentBytes/sentPackets <= mtu {
+		return fmt.Errorf("pod %s sent %.0f packets, %.0f bytes, none larger than its %.0f MTU on average: %w", v.PodName, sentPackets, sentBytes, mtu, ErrTrafficNotOffloaded)
+	}
+	wireSegments := sentBytes / (mtu + ethernetHeaderBytes)
+	log.Printf("pod %s sent %.0f packets of %.0f bytes on average, at least %.0f segments on the wire\n", v.PodName, sentPackets, sentBytes/sentPackets, wireSegments)
+
+	checkFn := func() error {
+		retinaPackets, retinaBytes, readErr := readRetinaCounters(v.PortForwardedRetinaPort, v.PodName)
+		if readErr != nil {
+			return readErr
+		}
+
+		countedPackets := retinaPackets - v.counters.retinaPackets
+		countedBytes := retinaBytes - v.counters.retinaBytes
+		log.Printf("agent counted %.0f packets, %.0f bytes of the %.0f packets, %.0f bytes pod %s sent\n",
+			countedPackets, countedBytes, sentPackets, sentBytes, v.PodName)
+
+		if !withinTolerance(countedBytes, sentBytes-ethernetHeaderBytes*sentPackets, sentBytes) {
+			return fmt.Errorf("counted %.0f of %.0f bytes: %w", countedBytes, sentBytes, ErrInaccurateFlowMetrics)
+		}
+		if countedPackets >= wireSegments {
+			return fmt.Errorf("counted %.0f packets for at least %.0f segments: %w", countedPackets, wireSegments, ErrPacketsCountedOnWire)
+		}
+		if !withinTolerance(countedPackets, sentPackets, sentPackets) {
+			return fmt.Errorf("counted %.0f of %.0f packets: %w", countedPackets, sentPackets, ErrInaccurateFlowMetrics)
+		}
+		return nil
+	}
+
+	retrier := retry.Retrier{Attempts: metricRetryAttempts, Delay: metricRetryDelay}
+	err = retrier.Do(context.Background(), checkFn)
+	if err != nil {
+		return fmt.Errorf("failed to verify offloaded flow metrics of pod %s: %w", v.PodName, err)
+	}
+	return nil
+}
+
+func (v *ValidateOffloadedCounts) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateOffloadedCounts) Stop() error {
+	return nil
+}
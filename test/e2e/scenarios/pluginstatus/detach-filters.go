@@ -0,0 +1,117 @@
+package pluginstatus
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const defaultTimeout = 2 * time.Minute
+
+var (
+	ErrNoRetinaPodOnNode = fmt.Errorf("no retina pod found on node")
+	ErrHostVethNotFound  = fmt.Errorf("host side veth of pod not found")
+)
+
+// DetachPacketParserFilters removes the packetparser bpf filters from the host side veth of PodName, the way anything
+// else with access to the node's tc could, so the plugin stops seeing the pod's packets without being told. The
+// filters are removed through the host network retina pod on that node. They aren't put back: deleting the pod
+// removes the veth, and the agent lets go of it then.
+type DetachPacketParserFilters struct {
+	KubeConfigFilePath       string
+	RetinaDaemonSetNamespace string
+	PodNamespace             string
+	PodName                  string
+}
+
+func (d *DetachPacketParserFilters) Run() error {
+	config, err := k8s.BuildConfig(d.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	pod, err := clientset.CoreV1().Pods(d.PodNamespace).Get(ctx, d.PodName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting pod \"%s\": %w", d.PodName, err)
+	}
+
+	retinaPods, err := clientset.CoreV1().Pods(d.RetinaDaemonSetNamespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "k8s-app=retina",
+		FieldSelector: "spec.nodeName=" + pod.Spec.NodeName,
+	})
+	if err != nil {
+		return fmt.Errorf("error listing retina pods on node %s: %w", pod.Spec.NodeName, err)
+	}
+	if len(retinaPods.Items) == 0 {
+		return fmt.Errorf("node %s: %w", pod.Spec.NodeName, ErrNoRetinaPodOnNode)
+	}
+	retinaPodName := retinaPods.Items[0].Name
+
+	// the pod's eth0 links to its veth peer on the host
+	output, err := k8s.ExecPod(ctx, clientset, config, d.PodNamespace, d.PodName, "cat /sys/class/net/eth0/iflink")
+	if err != nil {
+		return fmt.Errorf("error getting the host interface index of pod \"%s\": %w", d.PodName, err)
+	}
+	ifindex := strings.TrimSpace(string(output))
+
+	output, err = k8s.ExecPod(ctx, clientset, config, d.RetinaDaemonSetNamespace, retinaPodName, "ip -o link show")
+	if err != nil {
+		return fmt.Errorf("error listing interfaces through retina pod \"%s\": %w", retinaPodName, err)
+	}
+	veth, err := interfaceName(string(output), ifindex)
+	if err != nil {
+		return fmt.Errorf("pod \"%s\": %w", d.PodName, err)
+	}
+
+	for _, direction := range []string{"ingress", "egress"} {
+		_, err = k8s.ExecPod(ctx, clientset, config, d.RetinaDaemonSetNamespace, retinaPodName, fmt.Sprintf("tc filter del dev %s %s", veth, direction))
+		if err != nil {
+			return fmt.Errorf("error removing %s filters of %s through retina pod \"%s\": %w", direction, veth, retinaPodName, err)
+		}
+	}
+	log.Printf("removed the bpf filters of pod %s from %s on node %s\n", d.PodName, veth, pod.Spec.NodeName)
+
+	return nil
+}
+
+func (d *DetachPacketParserFilters) Prevalidate() error {
+	return nil
+}
+
+func (d *DetachPacketParserFilters) Stop() error {
+	return nil
+}
+
+// interfaceName finds the name of the interface with the index in the output of "ip -o link show",
+// whose lines start with "<index>: <name>[@<peer>]:"
+func interfaceName(links, ifindex string) (string, error) {
+	if _, err := strconv.Atoi(ifindex); err != nil {
+		return "", fmt.Errorf("invalid interface index \"%s\": %w", ifindex, err)
+	}
+
+	for _, line := range strings.Split(links, "\n") {
+		index, rest, found := strings.Cut(strings.TrimSpace(line), ": ")
+		if !found || index != ifindex {
+			continue
+		}
+		name, _, _ := strings.Cut(rest, ":")
+		name, _, _ = strings.Cut(name, "@")
+		return name, nil
+	}
+	return "", fmt.Errorf("interface index %s: %w", ifindex, ErrHostVethNotFound)
+}
@@ -0,0 +1,193 @@
+package pluginstatus
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	"github.com/microsoft/retina/test/e2e/framework/generic"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+)
+
+const (
+	workloadNamespace = "retina-plugin-status"
+	packetParser      = "packetparser"
+)
+
+// plugins are those of the chart's default, plus packetparser for its filters to be detached
+var plugins = []string{"dropreason", "packetforward", "linuxutil", "dns", packetParser}
+
+// ValidatePluginStatusMetrics upgrades Retina with packetparser enabled and validates every plugin reports itself
+// healthy, then detaches packetparser's filters from a pod's veth and validates packetparser reports itself
+// unhealthy, until the pod is deleted
+func ValidatePluginStatusMetrics(kubeConfigFilePath, chartPath, valuesFilePath string) *types.Scenario {
+	name := "Plugin Status Metrics"
+	agnhostName := "agnhost-plugin-status"
+	podName := agnhostName + "-0"
+
+	quoted := make([]string, 0, len(plugins))
+	for _, plugin := range plugins {
+		quoted = append(quoted, strconv.Quote(plugin))
+	}
+	// commas separate values in helm's --set syntax, unless escaped
+	enabledPlugins := "enabledPlugin_linux=[" + strings.Join(quoted, `\,`) + "]"
+
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.UpgradeRetinaHelmChart{
+				Namespace:          "kube-system",
+				ReleaseName:        "retina",
+				KubeConfigFilePath: kubeConfigFilePath,
+				ChartPath:          chartPath,
+				TagEnv:             generic.DefaultTagEnv,
+				ValuesFile:         valuesFilePath,
+				SetValues:          []string{enabledPlugins},
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.EnsureStableCluster{
+				PodNamespace:  "kube-system",
+				LabelSelector: "k8s-app=retina",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateNamespace{
+				NamespaceName: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      agnhostName,
+				AgnhostNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.WaitForPodReady{
+				PodNamespace: workloadNamespace,
+				PodSelector:  podName,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.PortForward{
+				Namespace:                          "kube-system",
+				LabelSelector:                      "k8s-app=retina",
+				LocalPort:                          strconv.Itoa(common.RetinaPort),
+				RemotePort:                         strconv.Itoa(common.RetinaPort),
+				Endpoint:                           "metrics",
+				OptionalLabelAffinity:              "app=" + agnhostName,
+				OptionalLabelAffinityAllNamespaces: true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "plugin-status-port-forward",
+			},
+		},
+		{
+			Step: &ValidatePluginStatus{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				Healthy:                 plugins,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &DetachPacketParserFilters{
+				RetinaDaemonSetNamespace: "kube-system",
+				PodNamespace:             workloadNamespace,
+				PodName:                  podName,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &ValidatePluginStatus{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				Unhealthy:               []string{packetParser},
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		// the agent lets go of the veth with the pod, and packetparser is healthy again
+		{
+			Step: &kubernetes.DeleteKubernetesResource{
+				ResourceType:      kubernetes.TypeString(kubernetes.StatefulSet),
+				ResourceName:      agnhostName,
+				ResourceNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &ValidatePluginStatus{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				Healthy:                 []string{packetParser},
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	cleanup := []*types.StepWrapper{
+		{
+			Step: &types.Stop{
+				BackgroundID: "plugin-status-port-forward",
+			},
+		},
+		{
+			Step: &kubernetes.DeleteKubernetesResource{
+				ResourceType:      kubernetes.TypeString(kubernetes.Namespace),
+				ResourceName:      workloadNamespace,
+				ResourceNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		// restore the default plugins for any scenarios that follow
+		{
+			Step: &kubernetes.UpgradeRetinaHelmChart{
+				Namespace:          "kube-system",
+				ReleaseName:        "retina",
+				KubeConfigFilePath: kubeConfigFilePath,
+				ChartPath:          chartPath,
+				TagEnv:             generic.DefaultTagEnv,
+				ValuesFile:         valuesFilePath,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.EnsureStableCluster{
+				PodNamespace:  "kube-system",
+				LabelSelector: "k8s-app=retina",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	return types.NewScenario(name, steps...).WithCleanup(cleanup...)
+}
@@ -0,0 +1,83 @@
+package pluginstatus
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/microsoft/retina/test/retry"
+)
+
+const (
+	pluginStatusMetricName = "controlplane_networkobservability_plugin_status"
+
+	// the agent checks the plugins every metrics interval
+	statusRetryAttempts = 12
+	statusRetryDelay    = 5 * time.Second
+)
+
+var ErrUnexpectedPluginStatus = fmt.Errorf("unexpected plugin status")
+
+// ValidatePluginStatus checks the agent reports a status for every plugin of Healthy and Unhealthy, and waits
+// for the plugins of Healthy to report 1 and those of Unhealthy to report 0
+type ValidatePluginStatus struct {
+	PortForwardedRetinaPort string
+	Healthy                 []string
+	Unhealthy               []string
+}
+
+func (v *ValidatePluginStatus) Run() error {
+	promAddress := fmt.Sprintf("http://localhost:%s/metrics", v.PortForwardedRetinaPort)
+
+	expected := map[string]float64{}
+	for _, plugin := range v.Healthy {
+		expected[plugin] = 1
+	}
+	for _, plugin := range v.Unhealthy {
+		expected[plugin] = 0
+	}
+
+	for plugin := range expected {
+		err := prom.CheckMetric(promAddress, pluginStatusMetricName, map[string]string{"plugin": plugin})
+		if err != nil {
+			return fmt.Errorf("failed to find %s of plugin %s: %w", pluginStatusMetricName, plugin, err)
+		}
+	}
+
+	checkFn := func() error {
+		for plugin, status := range expected {
+			series, err := prom.GetMetricsMatchingLabels(promAddress, pluginStatusMetricName, map[string]string{"plugin": plugin})
+			if err != nil {
+				return fmt.Errorf("failed to scrape %s: %w", pluginStatusMetricName, err)
+			}
+			if len(series) == 0 {
+				return fmt.Errorf("no status for plugin %s: %w", plugin, ErrUnexpectedPluginStatus)
+			}
+
+			actual := series[0].GetGauge().GetValue()
+			if actual != status {
+				log.Printf("plugin %s has status %.0f, waiting for %.0f\n", plugin, actual, status)
+				return fmt.Errorf("plugin %s has status %.0f, expected %.0f: %w", plugin, actual, status, ErrUnexpectedPluginStatus)
+			}
+		}
+		return nil
+	}
+
+	retrier := retry.Retrier{Attempts: statusRetryAttempts, Delay: statusRetryDelay}
+	err := retrier.Do(context.Background(), checkFn)
+	if err != nil {
+		return fmt.Errorf("failed to verify prometheus metrics %s: %w", pluginStatusMetricName, err)
+	}
+	log.Printf("%d plugins report the expected status\n", len(expected))
+	return nil
+}
+
+func (v *ValidatePluginStatus) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidatePluginStatus) Stop() error {
+	return nil
+}
@@ -0,0 +1,151 @@
+package pmtud
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const defaultTimeout = 2 * time.Minute
+
+var (
+	ErrNoRetinaPodOnNode = fmt.Errorf("no retina pod found on node")
+	ErrNoRouteDevice     = fmt.Errorf("no device in route to pod")
+)
+
+func newClient(kubeConfigFilePath string) (*rest.Config, *kubernetes.Clientset, error) {
+	config, err := k8s.BuildConfig(kubeConfigFilePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+	return config, clientset, nil
+}
+
+// retinaPodOnNodeOf returns the retina pod on the node running the given pod, along with that pod
+func retinaPodOnNodeOf(ctx context.Context, clientset *kubernetes.Clientset, retinaNamespace, podNamespace, podName string) (string, string, error) {
+	pod, err := clientset.CoreV1().Pods(podNamespace).Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		return "", "", fmt.Errorf("error getting pod \"%s\": %w", podName, err)
+	}
+
+	retinaPods, err := clientset.CoreV1().Pods(retinaNamespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "k8s-app=retina",
+		FieldSelector: "spec.nodeName=" + pod.Spec.NodeName,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("error listing retina pods on node %s: %w", pod.Spec.NodeName, err)
+	}
+	if len(retinaPods.Items) == 0 {
+		return "", "", fmt.Errorf("node %s: %w", pod.Spec.NodeName, ErrNoRetinaPodOnNode)
+	}
+	return retinaPods.Items[0].Name, pod.Status.PodIP, nil
+}
+
+// LowerPodPathMTU lowers the MTU of the host side of PodName's veth to MTU, so packets forwarded to the pod
+// that are larger than it, and don't allow fragmenting, are answered by the node with an ICMP fragmentation
+// needed message. The MTU is changed through the host network retina pod on the pod's node, run it in the
+// background so Stop restores it
+type LowerPodPathMTU struct {
+	KubeConfigFilePath       string
+	RetinaDaemonSetNamespace string
+	PodNamespace             string
+	PodName                  string
+	MTU                      int
+
+	// local properties
+	retinaPodName string
+	device        string
+	originalMTU   string
+}
+
+func (l *LowerPodPathMTU) Run() error {
+	config, clientset, err := newClient(l.KubeConfigFilePath)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	retinaPodName, podIP, err := retinaPodOnNodeOf(ctx, clientset, l.RetinaDaemonSetNamespace, l.PodNamespace, l.PodName)
+	if err != nil {
+		return err
+	}
+
+	route, err := k8s.ExecPod(ctx, clientset, config, l.RetinaDaemonSetNamespace, retinaPodName, "ip -o route get "+podIP)
+	if err != nil {
+		return fmt.Errorf("error getting route to pod \"%s\": %s: %w", l.PodName, string(route), err)
+	}
+	device := routeDevice(string(route))
+	if device == "" {
+		return fmt.Errorf("route \"%s\" to pod \"%s\": %w", strings.TrimSpace(string(route)), l.PodName, ErrNoRouteDevice)
+	}
+
+	mtu, err := k8s.ExecPod(ctx, clientset, config, l.RetinaDaemonSetNamespace, retinaPodName, "cat /sys/class/net/"+device+"/mtu")
+	if err != nil {
+		return fmt.Errorf("error reading mtu of %s: %s: %w", device, string(mtu), err)
+	}
+
+	output, err := k8s.ExecPod(ctx, clientset, config, l.RetinaDaemonSetNamespace, retinaPodName, l.setMTU(device, strconv.Itoa(l.MTU)))
+	if err != nil {
+		return fmt.Errorf("error lowering mtu of %s through retina pod \"%s\": %s: %w", device, retinaPodName, string(output), err)
+	}
+
+	l.retinaPodName = retinaPodName
+	l.device = device
+	l.originalMTU = strings.TrimSpace(string(mtu))
+	log.Printf("lowered mtu of %s, the path to pod %s, from %s to %d\n", device, l.PodName, l.originalMTU, l.MTU)
+	return nil
+}
+
+func (l *LowerPodPathMTU) setMTU(device, mtu string) string {
+	return fmt.Sprintf("ip link set dev %s mtu %s", device, mtu)
+}
+
+// routeDevice returns the device of a route printed by "ip -o route get"
+func routeDevice(route string) string {
+	fields := strings.Fields(route)
+	for i := 0; i < len(fields)-1; i++ {
+		if fields[i] == "dev" {
+			return fields[i+1]
+		}
+	}
+	return ""
+}
+
+func (l *LowerPodPathMTU) Prevalidate() error {
+	return nil
+}
+
+func (l *LowerPodPathMTU) Stop() error {
+	if l.retinaPodName == "" {
+		return nil
+	}
+
+	config, clientset, err := newClient(l.KubeConfigFilePath)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	output, err := k8s.ExecPod(ctx, clientset, config, l.RetinaDaemonSetNamespace, l.retinaPodName, l.setMTU(l.device, l.originalMTU))
+	if err != nil {
+		return fmt.Errorf("error restoring mtu of %s through retina pod \"%s\": %s: %w", l.device, l.retinaPodName, string(output), err)
+	}
+	return nil
+}
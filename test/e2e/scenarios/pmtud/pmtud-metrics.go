@@ -0,0 +1,274 @@
+package pmtud
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/microsoft/retina/test/retry"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	advForwardBytesMetricName = "networkobservability_adv_forward_bytes"
+
+	metricRetryAttempts = 12
+	metricRetryDelay    = 5 * time.Second
+)
+
+var (
+	ErrBaselineNotCaptured  = fmt.Errorf("path mtu discovery baseline was not captured")
+	ErrNoResponse           = fmt.Errorf("no response was downloaded")
+	ErrUnexpectedSNMP       = fmt.Errorf("unexpected /proc/net/snmp contents")
+	ErrNoFragmentationICMP  = fmt.Errorf("node sent no ICMP destination unreachable message")
+	ErrIncompleteFlowMetric = fmt.Errorf("flow metrics don't cover the response")
+)
+
+// pmtudState carries the counters before the large response, and its size, between the scenario's steps
+type pmtudState struct {
+	destUnreachables float64
+	ingressBytes     float64
+	captured         bool
+
+	downloadedBytes float64
+}
+
+// readDestUnreachables reads how many ICMP destination unreachable messages, which include fragmentation
+// needed, the node of the host network retina pod has sent
+func readDestUnreachables(ctx context.Context, clientset *kubernetes.Clientset, config *rest.Config, namespace, retinaPodName string) (float64, error) {
+	output, err := k8s.ExecPod(ctx, clientset, config, namespace, retinaPodName, "cat /proc/net/snmp")
+	if err != nil {
+		return 0, fmt.Errorf("error reading /proc/net/snmp through retina pod \"%s\": %s: %w", retinaPodName, string(output), err)
+	}
+	return parseSNMPCounter(string(output), "Icmp:", "OutDestUnreachs")
+}
+
+// parseSNMPCounter returns a counter of /proc/net/snmp, where each protocol has a line of counter names
+// followed by a line of their values
+func parseSNMPCounter(snmp, protocol, counter string) (float64, error) {
+	var names []string
+	for _, line := range strings.Split(snmp, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] != protocol {
+			continue
+		}
+		if names == nil {
+			names = fields
+			continue
+		}
+		for i, name := range names {
+			if name == counter && i < len(fields) {
+				value, err := strconv.ParseFloat(fields[i], 64)
+				if err != nil {
+					return 0, fmt.Errorf("error parsing %s %s: %w", protocol, counter, err)
+				}
+				return value, nil
+			}
+		}
+		break
+	}
+	return 0, fmt.Errorf("no %s %s counter: %w", protocol, counter, ErrUnexpectedSNMP)
+}
+
+// ingressBytes adds up the pod's ingress byte series, one per remote context label set
+func ingressBytes(portForwardedRetinaPort, namespace, podName string) (float64, error) {
+	promAddress := fmt.Sprintf("http://localhost:%s/metrics", portForwardedRetinaPort)
+	series, err := prom.GetMetricsMatchingLabels(promAddress, advForwardBytesMetricName, map[string]string{
+		"namespace": namespace,
+		"podname":   podName,
+		"direction": "ingress",
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to scrape %s: %w", advForwardBytesMetricName, err)
+	}
+
+	total := 0.0
+	for _, metric := range series {
+		total += metric.GetCounter().GetValue() + metric.GetGauge().GetValue()
+	}
+	return total, nil
+}
+
+// CapturePMTUDBaseline records the ICMP destination unreachable messages the pod's node has sent and the
+// pod's ingress bytes before the large response
+type CapturePMTUDBaseline struct {
+	KubeConfigFilePath       string
+	RetinaDaemonSetNamespace string
+	PortForwardedRetinaPort  string
+	PodNamespace             string
+	PodName                  string
+
+	state *pmtudState
+}
+
+func (c *CapturePMTUDBaseline) Run() error {
+	config, clientset, err := newClient(c.KubeConfigFilePath)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	retinaPodName, _, err := retinaPodOnNodeOf(ctx, clientset, c.RetinaDaemonSetNamespace, c.PodNamespace, c.PodName)
+	if err != nil {
+		return err
+	}
+
+	c.state.destUnreachables, err = readDestUnreachables(ctx, clientset, config, c.RetinaDaemonSetNamespace, retinaPodName)
+	if err != nil {
+		return err
+	}
+	c.state.ingressBytes, err = ingressBytes(c.PortForwardedRetinaPort, c.PodNamespace, c.PodName)
+	if err != nil {
+		return err
+	}
+
+	c.state.captured = true
+	log.Printf("node of %s sent %.0f ICMP destination unreachables, pod received %.0f bytes before the large response\n",
+		c.PodName, c.state.destUnreachables, c.state.ingressBytes)
+	return nil
+}
+
+func (c *CapturePMTUDBaseline) Prevalidate() error {
+	return nil
+}
+
+func (c *CapturePMTUDBaseline) Stop() error {
+	return nil
+}
+
+// DownloadLargeResponse has the client pod fetch Command's output from the server pod, running agnhost netexec,
+// over HTTP. The response is sent in segments as large as the client's MSS allows, so it only arrives once the
+// server has lowered its path MTU to the client
+type DownloadLargeResponse struct {
+	KubeConfigFilePath string
+	PodNamespace       string
+	ClientPodName      string
+	ServerPodName      string
+	Command            string
+
+	state *pmtudState
+}
+
+func (d *DownloadLargeResponse) Run() error {
+	config, clientset, err := newClient(d.KubeConfigFilePath)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	server, err := clientset.CoreV1().Pods(d.PodNamespace).Get(ctx, d.ServerPodName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting server pod \"%s\": %w", d.ServerPodName, err)
+	}
+
+	// curl's arguments can't be quoted, so the command's spaces are escaped in the query
+	request := fmt.Sprintf("curl -s -f -m 60 -o /dev/null -w %%{size_download} http://%s:%d/shell?cmd=%s",
+		server.Status.PodIP, k8s.AgnhostHTTPPort, strings.ReplaceAll(d.Command, " ", "%20"))
+	output, err := k8s.ExecPod(ctx, clientset, config, d.PodNamespace, d.ClientPodName, request)
+	if err != nil {
+		return fmt.Errorf("error downloading from %s to %s: %s: %w", d.ServerPodName, d.ClientPodName, string(output), err)
+	}
+
+	downloaded, err := strconv.ParseFloat(strings.TrimSpace(string(output)), 64)
+	if err != nil {
+		return fmt.Errorf("error parsing downloaded size \"%s\": %w", string(output), err)
+	}
+	if downloaded == 0 {
+		return fmt.Errorf("from %s to %s: %w", d.ServerPodName, d.ClientPodName, ErrNoResponse)
+	}
+
+	d.state.downloadedBytes = downloaded
+	log.Printf("%s downloaded %.0f bytes from %s\n", d.ClientPodName, downloaded, d.ServerPodName)
+	return nil
+}
+
+func (d *DownloadLargeResponse) Prevalidate() error {
+	return nil
+}
+
+func (d *DownloadLargeResponse) Stop() error {
+	return nil
+}
+
+// ValidatePMTUDFlow checks path MTU discovery took place for the large response and the agent still recorded
+// the response in full. The fragmentation needed message is ICMP, which packetparser doesn't parse into flows,
+// so it's validated from the node's ICMP counters instead. The response, resent in smaller segments, has to
+// grow the client's ingress bytes by at least its size
+type ValidatePMTUDFlow struct {
+	KubeConfigFilePath       string
+	RetinaDaemonSetNamespace string
+	PortForwardedRetinaPort  string
+	PodNamespace             string
+	PodName                  string
+
+	state *pmtudState
+}
+
+func (v *ValidatePMTUDFlow) Run() error {
+	if !v.state.captured {
+		return ErrBaselineNotCaptured
+	}
+
+	config, clientset, err := newClient(v.KubeConfigFilePath)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	retinaPodName, _, err := retinaPodOnNodeOf(ctx, clientset, v.RetinaDaemonSetNamespace, v.PodNamespace, v.PodName)
+	if err != nil {
+		return err
+	}
+
+	destUnreachables, err := readDestUnreachables(ctx, clientset, config, v.RetinaDaemonSetNamespace, retinaPodName)
+	if err != nil {
+		return err
+	}
+	sent := destUnreachables - v.state.destUnreachables
+	if sent <= 0 {
+		return fmt.Errorf("node of %s during the large response: %w", v.PodName, ErrNoFragmentationICMP)
+	}
+	log.Printf("node of %s sent %.0f ICMP destination unreachables during the large response\n", v.PodName, sent)
+
+	checkFn := func() error {
+		received, readErr := ingressBytes(v.PortForwardedRetinaPort, v.PodNamespace, v.PodName)
+		if readErr != nil {
+			return readErr
+		}
+
+		counted := received - v.state.ingressBytes
+		if counted < v.state.downloadedBytes {
+			return fmt.Errorf("counted %.0f ingress bytes for a %.0f byte response: %w", counted, v.state.downloadedBytes, ErrIncompleteFlowMetric)
+		}
+		log.Printf("counted %.0f ingress bytes for %s's %.0f byte response\n", counted, v.PodName, v.state.downloadedBytes)
+		return nil
+	}
+
+	retrier := retry.Retrier{Attempts: metricRetryAttempts, Delay: metricRetryDelay}
+	err = retrier.Do(context.Background(), checkFn)
+	if err != nil {
+		return fmt.Errorf("failed to verify flow metrics of pod %s: %w", v.PodName, err)
+	}
+	return nil
+}
+
+func (v *ValidatePMTUDFlow) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidatePMTUDFlow) Stop() error {
+	return nil
+}
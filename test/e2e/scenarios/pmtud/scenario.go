@@ -0,0 +1,190 @@
+package pmtud
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+)
+
+const (
+	workloadNamespace = "retina-pmtud"
+
+	// below the 1500 byte MTU the client's MSS is derived from, so full sized segments no longer fit
+	loweredMTU = 1200
+
+	// about 1.3MB of output, spread over hundreds of segments
+	responseCommand = "seq 1 200000"
+
+	// lets the flows of the pods' setup reach the metrics before the baseline is read
+	settleDelay = 15 * time.Second
+
+	// the local port the server's netexec is forwarded to, apart from the agent's
+	serverLocalPort = "10080"
+)
+
+// ValidatePMTUDMetrics lowers the MTU on the path to a client pod and has it download a response larger than
+// that MTU from a server pod, so the server only gets the response through after path MTU discovery. It validates
+// the client's node sent the ICMP fragmentation needed message and the client's ingress bytes cover the response.
+//
+// It expects a CNI routing pod traffic through the node's stack: those redirecting it in eBPF don't send the
+// ICMP message.
+func ValidatePMTUDMetrics() *types.Scenario {
+	name := "Path MTU Discovery Metrics"
+	clientName := "agnhost-pmtud-client"
+	serverName := "agnhost-pmtud-server"
+	state := &pmtudState{}
+
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.CreateNamespace{
+				NamespaceName: workloadNamespace,
+				Observe:       true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      serverName,
+				AgnhostNamespace: workloadNamespace,
+				Args:             []string{"netexec", "--http-port", strconv.Itoa(kubernetes.AgnhostHTTPPort)},
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      clientName,
+				AgnhostNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.PortForward{
+				Namespace:     workloadNamespace,
+				LabelSelector: "app=" + serverName,
+				LocalPort:     serverLocalPort,
+				RemotePort:    strconv.Itoa(kubernetes.AgnhostHTTPPort),
+				Endpoint:      "hostname",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "pmtud-server-port-forward",
+			},
+		},
+		{
+			// the server runs the response command through netexec's /shell, which answers in JSON
+			Step: &kubernetes.ValidateHTTPJSONPath{
+				LocalPort:     serverLocalPort,
+				Endpoint:      "shell?cmd=hostname",
+				JSONPath:      "{.output}",
+				ExpectedValue: serverName + "-0",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &types.Stop{
+				BackgroundID: "pmtud-server-port-forward",
+			},
+		},
+		{
+			Step: &kubernetes.PortForward{
+				Namespace:                          "kube-system",
+				LabelSelector:                      "k8s-app=retina",
+				LocalPort:                          strconv.Itoa(common.RetinaPort),
+				RemotePort:                         strconv.Itoa(common.RetinaPort),
+				Endpoint:                           "metrics",
+				OptionalLabelAffinity:              "app=" + clientName,
+				OptionalLabelAffinityAllNamespaces: true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "pmtud-port-forward",
+			},
+		},
+		{
+			Step: &types.Sleep{
+				Duration: settleDelay,
+			},
+		},
+		{
+			Step: &LowerPodPathMTU{
+				RetinaDaemonSetNamespace: "kube-system",
+				PodNamespace:             workloadNamespace,
+				PodName:                  clientName + "-0",
+				MTU:                      loweredMTU,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "pmtud-path-mtu",
+			},
+		},
+		{
+			Step: &CapturePMTUDBaseline{
+				RetinaDaemonSetNamespace: "kube-system",
+				PortForwardedRetinaPort:  strconv.Itoa(common.RetinaPort),
+				PodNamespace:             workloadNamespace,
+				PodName:                  clientName + "-0",
+				state:                    state,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &DownloadLargeResponse{
+				PodNamespace:  workloadNamespace,
+				ClientPodName: clientName + "-0",
+				ServerPodName: serverName + "-0",
+				Command:       responseCommand,
+				state:         state,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &ValidatePMTUDFlow{
+				RetinaDaemonSetNamespace: "kube-system",
+				PortForwardedRetinaPort:  strconv.Itoa(common.RetinaPort),
+				PodNamespace:             workloadNamespace,
+				PodName:                  clientName + "-0",
+				state:                    state,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &types.Stop{
+				BackgroundID: "pmtud-port-forward",
+			},
+		},
+		{
+			Step: &types.Stop{
+				BackgroundID: "pmtud-path-mtu",
+			},
+		},
+		{
+			Step: &kubernetes.DeleteKubernetesResource{
+				ResourceType:      kubernetes.TypeString(kubernetes.Namespace),
+				ResourceName:      workloadNamespace,
+				ResourceNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	return types.NewScenario(name, steps...)
+}
@@ -0,0 +1,146 @@
+package policyflip
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/microsoft/retina/test/retry"
+)
+
+const (
+	advForwardCountMetricName = "networkobservability_adv_forward_count"
+	advDropCountMetricName    = "networkobservability_adv_drop_count"
+
+	metricRetryAttempts = 20
+	metricRetryDelay    = 5 * time.Second
+)
+
+var (
+	ErrSnapshotNotCaptured = fmt.Errorf("policy phase snapshot was not captured")
+	ErrNoPhaseTraffic      = fmt.Errorf("traffic of the phase not recorded yet")
+	ErrWrongPhaseMetric    = fmt.Errorf("traffic recorded under the wrong metric for the phase")
+)
+
+// phaseSnapshot carries the client's counters at the start of a phase to the step validating the phase
+type phaseSnapshot struct {
+	forwarded float64
+	dropped   float64
+	captured  bool
+}
+
+// clientCounters returns the packets forwarded to the client, which stop once its replies are dropped,
+// and the packets dropped for the client in any direction
+func clientCounters(portForwardedRetinaPort, namespace, podName string) (forwarded, dropped float64, err error) {
+	promAddress := fmt.Sprintf("http://localhost:%s/metrics", portForwardedRetinaPort)
+	pod := map[string]string{"namespace": namespace, "podname": podName}
+
+	forwardSeries, err := prom.GetMetricsMatchingLabels(promAddress, advForwardCountMetricName, map[string]string{
+		"namespace": namespace,
+		"podname":   podName,
+		"direction": "ingress",
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to scrape %s: %w", advForwardCountMetricName, err)
+	}
+	for _, metric := range forwardSeries {
+		forwarded += metric.GetCounter().GetValue() + metric.GetGauge().GetValue()
+	}
+
+	dropSeries, err := prom.GetMetricsMatchingLabels(promAddress, advDropCountMetricName, pod)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to scrape %s: %w", advDropCountMetricName, err)
+	}
+	for _, metric := range dropSeries {
+		dropped += metric.GetCounter().GetValue() + metric.GetGauge().GetValue()
+	}
+
+	return forwarded, dropped, nil
+}
+
+// CapturePolicyPhase records the client's forward and drop counts at the start of a phase
+type CapturePolicyPhase struct {
+	PortForwardedRetinaPort string
+	PodNamespace            string
+	ClientPodName           string
+
+	snapshot *phaseSnapshot
+}
+
+func (c *CapturePolicyPhase) Run() error {
+	forwarded, dropped, err := clientCounters(c.PortForwardedRetinaPort, c.PodNamespace, c.ClientPodName)
+	if err != nil {
+		return err
+	}
+
+	*c.snapshot = phaseSnapshot{forwarded: forwarded, dropped: dropped, captured: true}
+	log.Printf("%s at start of phase: %.0f ingress packets forwarded, %.0f dropped\n", c.ClientPodName, forwarded, dropped)
+	return nil
+}
+
+func (c *CapturePolicyPhase) Prevalidate() error {
+	return nil
+}
+
+func (c *CapturePolicyPhase) Stop() error {
+	return nil
+}
+
+// ValidatePolicyPhase checks the client's traffic since the phase started was recorded only as forwarded while
+// the policy allowed it, or only as dropped once ExpectDropped is set and the policy denies it
+type ValidatePolicyPhase struct {
+	PortForwardedRetinaPort string
+	PodNamespace            string
+	ClientPodName           string
+	ExpectDropped           bool
+
+	snapshot *phaseSnapshot
+}
+
+func (v *ValidatePolicyPhase) Run() error {
+	if !v.snapshot.captured {
+		return ErrSnapshotNotCaptured
+	}
+
+	checkFn := func() error {
+		forwarded, dropped, err := clientCounters(v.PortForwardedRetinaPort, v.PodNamespace, v.ClientPodName)
+		if err != nil {
+			return err
+		}
+		newForwarded := forwarded - v.snapshot.forwarded
+		newDropped := dropped - v.snapshot.dropped
+
+		grown, unchanged := newForwarded, newDropped
+		if v.ExpectDropped {
+			grown, unchanged = newDropped, newForwarded
+		}
+		if grown <= 0 {
+			log.Printf("%s: %.0f packets forwarded and %.0f dropped since the phase started\n", v.ClientPodName, newForwarded, newDropped)
+			return ErrNoPhaseTraffic
+		}
+		if unchanged != 0 {
+			return fmt.Errorf("%s: %.0f packets forwarded and %.0f dropped since the phase started, expected dropped: %t: %w",
+				v.ClientPodName, newForwarded, newDropped, v.ExpectDropped, ErrWrongPhaseMetric)
+		}
+
+		log.Printf("%s: %.0f packets forwarded and %.0f dropped since the phase started\n", v.ClientPodName, newForwarded, newDropped)
+		return nil
+	}
+
+	retrier := retry.Retrier{Attempts: metricRetryAttempts, Delay: metricRetryDelay}
+	err := retrier.Do(context.Background(), checkFn)
+	if err != nil {
+		return fmt.Errorf("failed to verify metrics of the policy phase: %w", err)
+	}
+	return nil
+}
+
+func (v *ValidatePolicyPhase) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidatePolicyPhase) Stop() error {
+	return nil
+}
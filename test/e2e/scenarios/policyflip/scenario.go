@@ -0,0 +1,200 @@
+package policyflip
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+)
+
+const (
+	workloadNamespace = "retina-policy-flip"
+	requestsPerPhase  = 5
+
+	// lets the flows before a phase boundary reach the metrics before the phase's snapshot is read
+	settleDelay = 15 * time.Second
+)
+
+// ValidatePolicyFlipMetrics sends traffic from a client pod while a NetworkPolicy allows it, then denies
+// the client all traffic and sends it again, and validates the client's traffic is recorded only as forwarded
+// before the policy takes effect and only as dropped after
+func ValidatePolicyFlipMetrics() *types.Scenario {
+	name := "Policy Flip Flow And Drop Metrics"
+	clientName := "agnhost-policy-flip-client"
+	serverName := "agnhost-policy-flip-server"
+	allowed := &phaseSnapshot{}
+	denied := &phaseSnapshot{}
+
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.CreateNamespace{
+				NamespaceName: workloadNamespace,
+				Observe:       true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		// traffic within the namespace is allowed until the deny all policy is created
+		{
+			Step: &kubernetes.CreateAllowFromNamespaceNetworkPolicy{
+				NetworkPolicyName:      "allow-from-own-namespace",
+				NetworkPolicyNamespace: workloadNamespace,
+				AllowFromNamespace:     workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      serverName,
+				AgnhostNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      clientName,
+				AgnhostNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.PortForward{
+				Namespace:                          "kube-system",
+				LabelSelector:                      "k8s-app=retina",
+				LocalPort:                          strconv.Itoa(common.RetinaPort),
+				RemotePort:                         strconv.Itoa(common.RetinaPort),
+				Endpoint:                           "metrics",
+				OptionalLabelAffinity:              "app=" + clientName,
+				OptionalLabelAffinityAllNamespaces: true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "policy-flip-port-forward",
+			},
+		},
+		{
+			Step: &types.Sleep{
+				Duration: settleDelay,
+			},
+		},
+		{
+			Step: &CapturePolicyPhase{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				PodNamespace:            workloadNamespace,
+				ClientPodName:           clientName + "-0",
+				snapshot:                allowed,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &SendRequests{
+				PodNamespace:  workloadNamespace,
+				ClientPodName: clientName + "-0",
+				ServerPodName: serverName + "-0",
+				Requests:      requestsPerPhase,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &ValidatePolicyPhase{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				PodNamespace:            workloadNamespace,
+				ClientPodName:           clientName + "-0",
+				snapshot:                allowed,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateDenyAllNetworkPolicy{
+				NetworkPolicyNamespace: workloadNamespace,
+				DenyAllLabelSelector:   "app=" + clientName,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &WaitForPolicyEnforced{
+				PodNamespace:  workloadNamespace,
+				ClientPodName: clientName + "-0",
+				ServerPodName: serverName + "-0",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &types.Sleep{
+				Duration: settleDelay,
+			},
+		},
+		{
+			Step: &CapturePolicyPhase{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				PodNamespace:            workloadNamespace,
+				ClientPodName:           clientName + "-0",
+				snapshot:                denied,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &SendRequests{
+				PodNamespace:  workloadNamespace,
+				ClientPodName: clientName + "-0",
+				ServerPodName: serverName + "-0",
+				Requests:      requestsPerPhase,
+				ExpectBlocked: true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &ValidatePolicyPhase{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				PodNamespace:            workloadNamespace,
+				ClientPodName:           clientName + "-0",
+				ExpectDropped:           true,
+				snapshot:                denied,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &types.Stop{
+				BackgroundID: "policy-flip-port-forward",
+			},
+		},
+		// deleting the namespace removes the workloads and both policies with it
+		{
+			Step: &kubernetes.DeleteKubernetesResource{
+				ResourceType:      kubernetes.TypeString(kubernetes.Namespace),
+				ResourceName:      workloadNamespace,
+				ResourceNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	return types.NewScenario(name, steps...)
+}
@@ -0,0 +1,151 @@
+package policyflip
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/retry"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	defaultTimeout = 5 * time.Minute
+
+	enforceRetryAttempts = 30
+	enforceRetryDelay    = 2 * time.Second
+)
+
+var (
+	ErrRequestAllowed = fmt.Errorf("request was answered")
+	ErrRequestBlocked = fmt.Errorf("request was not answered")
+)
+
+// requestSender runs curl against the server pod's IP from the client pod
+type requestSender struct {
+	clientset *kubernetes.Clientset
+	config    *rest.Config
+	namespace string
+	client    string
+	request   string
+}
+
+func newRequestSender(ctx context.Context, kubeConfigFilePath, namespace, clientPodName, serverPodName string) (*requestSender, error) {
+	config, err := k8s.BuildConfig(kubeConfigFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	server, err := clientset.CoreV1().Pods(namespace).Get(ctx, serverPodName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error getting server pod \"%s\": %w", serverPodName, err)
+	}
+
+	return &requestSender{
+		clientset: clientset,
+		config:    config,
+		namespace: namespace,
+		client:    clientPodName,
+		request:   fmt.Sprintf("curl -s -m 3 -o /dev/null http://%s:%d", server.Status.PodIP, k8s.AgnhostHTTPPort),
+	}, nil
+}
+
+func (r *requestSender) send(ctx context.Context) error {
+	_, err := k8s.ExecPod(ctx, r.clientset, r.config, r.namespace, r.client, r.request)
+	return err //nolint:wrapcheck // callers only care whether the request was answered
+}
+
+// SendRequests makes Requests HTTP requests from the client pod to the server pod's IP, each of which must be
+// answered, or when ExpectBlocked is set, each of which must go unanswered
+type SendRequests struct {
+	KubeConfigFilePath string
+	PodNamespace       string
+	ClientPodName      string
+	ServerPodName      string
+	Requests           int
+	ExpectBlocked      bool
+}
+
+func (s *SendRequests) Run() error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	sender, err := newRequestSender(ctx, s.KubeConfigFilePath, s.PodNamespace, s.ClientPodName, s.ServerPodName)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < s.Requests; i++ {
+		sendErr := sender.send(ctx)
+		if s.ExpectBlocked && sendErr == nil {
+			return fmt.Errorf("request %d from %s to %s: %w", i, s.ClientPodName, s.ServerPodName, ErrRequestAllowed)
+		}
+		if !s.ExpectBlocked && sendErr != nil {
+			return fmt.Errorf("request %d from %s to %s: %w: %w", i, s.ClientPodName, s.ServerPodName, ErrRequestBlocked, sendErr)
+		}
+	}
+
+	log.Printf("sent %d requests from %s to %s, blocked: %t\n", s.Requests, s.ClientPodName, s.ServerPodName, s.ExpectBlocked)
+	return nil
+}
+
+func (s *SendRequests) Prevalidate() error {
+	return nil
+}
+
+func (s *SendRequests) Stop() error {
+	return nil
+}
+
+// WaitForPolicyEnforced retries a request from the client pod to the server pod until it goes unanswered,
+// since a NetworkPolicy takes effect a little after it's created. This marks the boundary after which
+// the client's traffic should only count as dropped
+type WaitForPolicyEnforced struct {
+	KubeConfigFilePath string
+	PodNamespace       string
+	ClientPodName      string
+	ServerPodName      string
+}
+
+func (w *WaitForPolicyEnforced) Run() error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	sender, err := newRequestSender(ctx, w.KubeConfigFilePath, w.PodNamespace, w.ClientPodName, w.ServerPodName)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	retrier := retry.Retrier{Attempts: enforceRetryAttempts, Delay: enforceRetryDelay}
+	err = retrier.Do(ctx, func() error {
+		if sender.send(ctx) == nil {
+			log.Printf("request from %s to %s is still answered\n", w.ClientPodName, w.ServerPodName)
+			return ErrRequestAllowed
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("policy was not enforced on %s: %w", w.ClientPodName, err)
+	}
+
+	log.Printf("policy enforced on %s after %s\n", w.ClientPodName, time.Since(start).Round(time.Second))
+	return nil
+}
+
+func (w *WaitForPolicyEnforced) Prevalidate() error {
+	return nil
+}
+
+func (w *WaitForPolicyEnforced) Stop() error {
+	return nil
+}
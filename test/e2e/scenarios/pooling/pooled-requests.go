@@ -0,0 +1,98 @@
+package pooling
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/retry"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	defaultTimeout = 2 * time.Minute
+
+	// kube-proxy may pin every pooled connection to the same backend, so the batch is resent until it spreads
+	spreadAttempts = 5
+	spreadDelay    = 2 * time.Second
+)
+
+var ErrPoolNotSpread = fmt.Errorf("pooled connections all reached the same backend")
+
+// servedBackends carries the backend pods that answered the pooled requests to the steps validating them
+type servedBackends struct {
+	requests map[string]int
+}
+
+func (s *servedBackends) served(podName string) bool {
+	return s.requests[podName] > 0
+}
+
+// SendPooledRequests sends Requests HTTP requests to URL over a pool of at most PoolSize keep-alive
+// connections, using curl's parallel transfers. The backends are agnhost serve-hostname pods, so each
+// response names the pod that served it, and the step requires at least two backends to have answered
+type SendPooledRequests struct {
+	KubeConfigFilePath string
+	PodNamespace       string
+	PodName            string
+	URL                string
+	Requests           int
+	PoolSize           int
+	backends           *servedBackends
+}
+
+func (s *SendPooledRequests) Run() error {
+	config, err := k8s.BuildConfig(s.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	// every response is followed by a comma, as serve-hostname doesn't end the hostname with a newline
+	command := fmt.Sprintf("curl -s -m 10 --parallel --parallel-max %d -w , %s", s.PoolSize, strings.TrimSpace(strings.Repeat(s.URL+" ", s.Requests)))
+
+	sendFn := func() error {
+		output, execErr := k8s.ExecPod(ctx, clientset, config, s.PodNamespace, s.PodName, command)
+		if execErr != nil {
+			return fmt.Errorf("error sending pooled requests from pod \"%s\": %w", s.PodName, execErr)
+		}
+
+		for _, hostname := range strings.Split(string(output), ",") {
+			hostname = strings.TrimSpace(hostname)
+			if hostname != "" {
+				s.backends.requests[hostname]++
+			}
+		}
+
+		log.Printf("pooled requests were served by %v\n", s.backends.requests)
+		if len(s.backends.requests) < 2 {
+			return ErrPoolNotSpread
+		}
+		return nil
+	}
+
+	retrier := retry.Retrier{Attempts: spreadAttempts, Delay: spreadDelay}
+	err = retrier.Do(ctx, sendFn)
+	if err != nil {
+		return fmt.Errorf("failed to spread pooled requests to %s: %w", s.URL, err)
+	}
+	return nil
+}
+
+func (s *SendPooledRequests) Prevalidate() error {
+	return nil
+}
+
+func (s *SendPooledRequests) Stop() error {
+	return nil
+}
@@ -0,0 +1,137 @@
+package pooling
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+)
+
+const (
+	workloadNamespace = "retina-pooling"
+
+	backendReplicas = 3
+	pooledRequests  = 30
+	poolSize        = 6
+)
+
+// ValidatePooledBackendFlowMetrics sends requests over a client side connection pool to a Service with
+// several backend pods, and validates the flows of every backend the pool reached are attributed to that
+// backend by the agent on its node
+func ValidatePooledBackendFlowMetrics() *types.Scenario {
+	name := "Pooled Backend Flow Metrics"
+	clientName := "agnhost-pool-client"
+	backendName := "agnhost-pool-backend"
+	serviceName := "pool-backend"
+	backends := &servedBackends{requests: make(map[string]int)}
+
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.CreateNamespace{
+				NamespaceName: workloadNamespace,
+				Observe:       true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      backendName,
+				AgnhostNamespace: workloadNamespace,
+				Replicas:         backendReplicas,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostService{
+				ServiceName:      serviceName,
+				ServiceNamespace: workloadNamespace,
+				AgnhostName:      backendName,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      clientName,
+				AgnhostNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &SendPooledRequests{
+				PodNamespace: workloadNamespace,
+				PodName:      clientName + "-0",
+				URL:          fmt.Sprintf("http://%s.%s.svc.cluster.local:%d", serviceName, workloadNamespace, kubernetes.AgnhostHTTPPort),
+				Requests:     pooledRequests,
+				PoolSize:     poolSize,
+				backends:     backends,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	// each backend's flows are read from the agent on its own node
+	for i := 0; i < backendReplicas; i++ {
+		podName := fmt.Sprintf("%s-%d", backendName, i)
+		backgroundID := "pool-backend-port-forward-" + strconv.Itoa(i)
+
+		steps = append(steps,
+			&types.StepWrapper{
+				Step: &kubernetes.PortForward{
+					Namespace:                          "kube-system",
+					LabelSelector:                      "k8s-app=retina",
+					LocalPort:                          strconv.Itoa(common.RetinaPort),
+					RemotePort:                         strconv.Itoa(common.RetinaPort),
+					Endpoint:                           "metrics",
+					OptionalLabelAffinity:              "statefulset.kubernetes.io/pod-name=" + podName,
+					OptionalLabelAffinityAllNamespaces: true,
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+					RunInBackgroundWithID:     backgroundID,
+				},
+			},
+			&types.StepWrapper{
+				Step: &ValidatePooledBackendFlow{
+					PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+					NamespaceName:           workloadNamespace,
+					PodName:                 podName,
+					backends:                backends,
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+				},
+			},
+			&types.StepWrapper{
+				Step: &types.Stop{
+					BackgroundID: backgroundID,
+				},
+			},
+		)
+	}
+
+	// deleting the namespace removes the workloads and the service with it
+	steps = append(steps, &types.StepWrapper{
+		Step: &kubernetes.DeleteKubernetesResource{
+			ResourceType:      kubernetes.TypeString(kubernetes.Namespace),
+			ResourceName:      workloadNamespace,
+			ResourceNamespace: workloadNamespace,
+		},
+		Opts: &types.StepOptions{
+			SkipSavingParametersToJob: true,
+		},
+	})
+
+	return types.NewScenario(name, steps...)
+}
@@ -0,0 +1,72 @@
+package pooling
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/microsoft/retina/test/retry"
+)
+
+const (
+	advForwardCountMetricName = "networkobservability_adv_forward_count"
+
+	metricRetryAttempts = 20
+	metricRetryDelay    = 5 * time.Second
+)
+
+var ErrNoBackendFlow = fmt.Errorf("no ingress series attributed to the backend pod")
+
+// ValidatePooledBackendFlow checks a backend pod that served pooled requests has its ingress flows
+// attributed to it by the agent on its node. Backends the pool didn't reach are skipped
+type ValidatePooledBackendFlow struct {
+	PortForwardedRetinaPort string
+	NamespaceName           string
+	PodName                 string
+	backends                *servedBackends
+}
+
+func (v *ValidatePooledBackendFlow) Run() error {
+	if !v.backends.served(v.PodName) {
+		log.Printf("backend %s served no pooled requests, skipping\n", v.PodName)
+		return nil
+	}
+
+	promAddress := fmt.Sprintf("http://localhost:%s/metrics", v.PortForwardedRetinaPort)
+	labels := map[string]string{
+		"direction": "ingress",
+		"namespace": v.NamespaceName,
+		"podname":   v.PodName,
+	}
+
+	checkFn := func() error {
+		series, err := prom.GetMetricsMatchingLabels(promAddress, advForwardCountMetricName, labels)
+		if err != nil {
+			return fmt.Errorf("failed to scrape %s: %w", advForwardCountMetricName, err)
+		}
+		if len(series) == 0 {
+			log.Printf("no %s series matching %+v yet\n", advForwardCountMetricName, labels)
+			return ErrNoBackendFlow
+		}
+		return nil
+	}
+
+	retrier := retry.Retrier{Attempts: metricRetryAttempts, Delay: metricRetryDelay}
+	err := retrier.Do(context.Background(), checkFn)
+	if err != nil {
+		return fmt.Errorf("failed to verify prometheus metrics %s: %w", advForwardCountMetricName, err)
+	}
+
+	log.Printf("backend %s served %d pooled requests and has matching flows\n", v.PodName, v.backends.requests[v.PodName])
+	return nil
+}
+
+func (v *ValidatePooledBackendFlow) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidatePooledBackendFlow) Stop() error {
+	return nil
+}
@@ -0,0 +1,97 @@
+package portconflict
+
+import (
+	"fmt"
+
+	"github.com/microsoft/retina/test/e2e/framework/generic"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+)
+
+const (
+	// OccupiedPort is the port Retina is moved to while something else already holds it on a node
+	OccupiedPort = 10094
+
+	occupierName = "retina-port-occupier"
+)
+
+// ValidateMetricsPortInUse occupies a port on one node, upgrades Retina to serve its metrics on that
+// port, and validates the agent on that node fails clearly rather than silently, then restores the default port
+func ValidateMetricsPortInUse(kubeConfigFilePath, chartPath, valuesFilePath string) *types.Scenario {
+	name := "Metrics Port In Use"
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.CreateHostPortOccupier{
+				OccupierName:      occupierName,
+				OccupierNamespace: "kube-system",
+				Port:              OccupiedPort,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			// the rollout can't complete while the occupier holds the port, so don't wait on it
+			Step: &kubernetes.UpgradeRetinaHelmChart{
+				Namespace:          "kube-system",
+				ReleaseName:        "retina",
+				KubeConfigFilePath: kubeConfigFilePath,
+				ChartPath:          chartPath,
+				TagEnv:             generic.DefaultTagEnv,
+				ValuesFile:         valuesFilePath,
+				SetValues: []string{
+					fmt.Sprintf("retinaPort=%d", OccupiedPort),
+					fmt.Sprintf("apiServer.port=%d", OccupiedPort),
+				},
+				SkipWait: true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &ValidateAgentPortConflict{
+				RetinaDaemonSetNamespace: "kube-system",
+				OccupierName:             occupierName,
+				OccupierNamespace:        "kube-system",
+				Port:                     OccupiedPort,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.DeleteKubernetesResource{
+				ResourceType:      kubernetes.TypeString(kubernetes.Deployment),
+				ResourceName:      occupierName,
+				ResourceNamespace: "kube-system",
+			}, Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.UpgradeRetinaHelmChart{
+				Namespace:          "kube-system",
+				ReleaseName:        "retina",
+				KubeConfigFilePath: kubeConfigFilePath,
+				ChartPath:          chartPath,
+				TagEnv:             generic.DefaultTagEnv,
+				ValuesFile:         valuesFilePath,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.EnsureStableCluster{
+				PodNamespace:  "kube-system",
+				LabelSelector: "k8s-app=retina",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	return types.NewScenario(name, steps...)
+}
@@ -0,0 +1,140 @@
+package portconflict
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/retry"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	retinaContainerName = "retina"
+
+	// logged by the agent's http server when it can't bind its port
+	portInUseMessage = "address already in use"
+
+	portConflictRetryAttempts = 60
+	portConflictRetryDelay    = 5 * time.Second
+)
+
+var (
+	ErrOccupierNotFound      = fmt.Errorf("host port occupier pod not found")
+	ErrAgentNotUpgraded      = fmt.Errorf("retina pod on the occupied node is not using the occupied port yet")
+	ErrAgentDidNotFail       = fmt.Errorf("retina agent has not failed on the occupied port")
+	ErrPortConflictNotLogged = fmt.Errorf("retina agent failed without logging the port conflict")
+)
+
+// ValidateAgentPortConflict checks that the retina agent on the node where the occupier holds Port
+// fails outright, restarting with a log that names the port conflict, instead of running without
+// serving metrics
+type ValidateAgentPortConflict struct {
+	KubeConfigFilePath       string
+	RetinaDaemonSetNamespace string
+	OccupierName             string
+	OccupierNamespace        string
+	Port                     int
+}
+
+func (v *ValidateAgentPortConflict) Run() error {
+	config, err := k8s.BuildConfig(v.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx := context.Background()
+
+	occupiers, err := clientset.CoreV1().Pods(v.OccupierNamespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=" + v.OccupierName,
+	})
+	if err != nil {
+		return fmt.Errorf("error listing host port occupier pods: %w", err)
+	}
+	if len(occupiers.Items) == 0 || occupiers.Items[0].Spec.NodeName == "" {
+		return fmt.Errorf("no scheduled pod with label app=%s: %w", v.OccupierName, ErrOccupierNotFound)
+	}
+	nodeName := occupiers.Items[0].Spec.NodeName
+
+	checkFn := func() error {
+		return v.checkAgentOnNode(ctx, clientset, nodeName)
+	}
+
+	retrier := retry.Retrier{Attempts: portConflictRetryAttempts, Delay: portConflictRetryDelay}
+	err = retrier.Do(ctx, checkFn)
+	if err != nil {
+		return fmt.Errorf("retina agent on node %s did not fail clearly on occupied port %d: %w", nodeName, v.Port, err)
+	}
+
+	return nil
+}
+
+func (v *ValidateAgentPortConflict) checkAgentOnNode(ctx context.Context, clientset *kubernetes.Clientset, nodeName string) error {
+	pods, err := clientset.CoreV1().Pods(v.RetinaDaemonSetNamespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "k8s-app=retina",
+		FieldSelector: "spec.nodeName=" + nodeName,
+	})
+	if err != nil {
+		return fmt.Errorf("error listing retina pods on node %s: %w", nodeName, err)
+	}
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !usesPort(pod, v.Port) {
+			continue
+		}
+
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.Name != retinaContainerName {
+				continue
+			}
+			if status.RestartCount == 0 || status.LastTerminationState.Terminated == nil {
+				log.Printf("retina pod %s on node %s has not restarted yet\n", pod.Name, nodeName)
+				return ErrAgentDidNotFail
+			}
+
+			logs, err := k8s.ReadPreviousPodLogs(ctx, clientset, pod.Namespace, pod.Name, retinaContainerName)
+			if err != nil {
+				return fmt.Errorf("error reading previous logs of retina pod %s: %w", pod.Name, err)
+			}
+			if !strings.Contains(string(logs), portInUseMessage) {
+				return fmt.Errorf("retina pod %s restarted %d times: %w", pod.Name, status.RestartCount, ErrPortConflictNotLogged)
+			}
+
+			log.Printf("retina pod %s on node %s failed with \"%s\" for port %d and restarted %d times\n", pod.Name, nodeName, portInUseMessage, v.Port, status.RestartCount)
+			return nil
+		}
+	}
+
+	log.Printf("no retina pod on node %s is configured with port %d yet\n", nodeName, v.Port)
+	return ErrAgentNotUpgraded
+}
+
+func usesPort(pod *corev1.Pod, port int) bool {
+	for i := range pod.Spec.Containers {
+		for _, p := range pod.Spec.Containers[i].Ports {
+			if int(p.ContainerPort) == port {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (v *ValidateAgentPortConflict) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateAgentPortConflict) Stop() error {
+	return nil
+}
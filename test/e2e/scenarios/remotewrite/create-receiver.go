@@ -0,0 +1,193 @@
+package remotewrite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	prometheusImage = "quay.io/prometheus/prometheus:v2.54.1"
+	prometheusPort  = 9090
+
+	receiverName = "remote-write-receiver"
+	senderName   = "remote-write-sender"
+
+	createTimeout = 5 * time.Minute
+)
+
+// CreateRemoteWriteReceiver deploys a Prometheus that accepts remote writes, reachable in-cluster
+// at remote-write-receiver.<ReceiverNamespace>.svc.cluster.local, whose HTTP API is used to check what arrived
+type CreateRemoteWriteReceiver struct {
+	ReceiverNamespace  string
+	KubeConfigFilePath string
+}
+
+func (c *CreateRemoteWriteReceiver) Run() error {
+	config, err := k8s.BuildConfig(c.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), createTimeout)
+	defer cancel()
+
+	resources := []runtime.Object{
+		prometheusDeployment(receiverName, c.ReceiverNamespace, []string{
+			"--config.file=/etc/prometheus/prometheus.yml",
+			"--storage.tsdb.path=/prometheus",
+			"--web.enable-remote-write-receiver",
+		}, false),
+		c.getService(),
+	}
+
+	for i := range resources {
+		err = k8s.CreateResource(ctx, resources[i], clientset)
+		if err != nil {
+			return fmt.Errorf("error creating remote write receiver component: %w", err)
+		}
+	}
+
+	err = k8s.WaitForPodsRunning(ctx, clientset, c.ReceiverNamespace, "app="+receiverName)
+	if err != nil {
+		return fmt.Errorf("error waiting for remote write receiver pod to be ready: %w", err)
+	}
+
+	return nil
+}
+
+func (c *CreateRemoteWriteReceiver) Prevalidate() error {
+	return nil
+}
+
+func (c *CreateRemoteWriteReceiver) Stop() error {
+	return nil
+}
+
+func (c *CreateRemoteWriteReceiver) getService() *v1.Service {
+	return &v1.Service{
+		TypeMeta: metaV1.TypeMeta{
+			Kind:       "Service",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      receiverName,
+			Namespace: c.ReceiverNamespace,
+		},
+		Spec: v1.ServiceSpec{
+			Selector: map[string]string{
+				"app": receiverName,
+			},
+			Ports: []v1.ServicePort{
+				{
+					Port:       prometheusPort,
+					Protocol:   v1.ProtocolTCP,
+					TargetPort: intstr.FromInt(prometheusPort),
+				},
+			},
+		},
+	}
+}
+
+// prometheusDeployment runs a single Prometheus with args, with the ConfigMap of the same name mounted
+// over the image's config when mountConfig is set
+func prometheusDeployment(name, namespace string, args []string, mountConfig bool) *appsv1.Deployment {
+	reps := int32(1)
+
+	deployment := &appsv1.Deployment{
+		TypeMeta: metaV1.TypeMeta{
+			Kind:       "Deployment",
+			APIVersion: "apps/v1",
+		},
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &reps,
+			Selector: &metaV1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app": name,
+				},
+			},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metaV1.ObjectMeta{
+					Labels: map[string]string{
+						"app": name,
+					},
+				},
+				Spec: v1.PodSpec{
+					NodeSelector: map[string]string{
+						"kubernetes.io/os": "linux",
+					},
+					Containers: []v1.Container{
+						{
+							Name:  "prometheus",
+							Image: prometheusImage,
+							Args:  args,
+							Resources: v1.ResourceRequirements{
+								Requests: v1.ResourceList{
+									"memory": resource.MustParse("200Mi"),
+								},
+								Limits: v1.ResourceList{
+									"memory": resource.MustParse("200Mi"),
+								},
+							},
+							Ports: []v1.ContainerPort{
+								{
+									ContainerPort: prometheusPort,
+								},
+							},
+							ReadinessProbe: &v1.Probe{
+								ProbeHandler: v1.ProbeHandler{
+									HTTPGet: &v1.HTTPGetAction{
+										Path: "/-/ready",
+										Port: intstr.FromInt(prometheusPort),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if mountConfig {
+		podSpec := &deployment.Spec.Template.Spec
+		podSpec.Containers[0].VolumeMounts = []v1.VolumeMount{
+			{
+				Name:      "config",
+				MountPath: "/etc/prometheus",
+			},
+		}
+		podSpec.Volumes = []v1.Volume{
+			{
+				Name: "config",
+				VolumeSource: v1.VolumeSource{
+					ConfigMap: &v1.ConfigMapVolumeSource{
+						LocalObjectReference: v1.LocalObjectReference{
+							Name: name,
+						},
+					},
+				},
+			},
+		}
+	}
+
+	return deployment
+}
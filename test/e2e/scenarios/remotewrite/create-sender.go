@@ -0,0 +1,129 @@
+package remotewrite
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	v1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	scrapeJobName  = "retina"
+	scrapeInterval = "10s"
+)
+
+var ErrNoRetinaPods = fmt.Errorf("no retina agent pods to scrape")
+
+// remoteWriteTargets is shared by the steps of a scenario, so the validator knows which agents were scraped
+type remoteWriteTargets struct {
+	instances []string
+}
+
+// CreateRemoteWriteSender deploys a Prometheus agent that scrapes the metrics endpoint of every Retina agent
+// and remote writes the samples to the receiver made by CreateRemoteWriteReceiver in the same namespace
+type CreateRemoteWriteSender struct {
+	SenderNamespace    string
+	KubeConfigFilePath string
+
+	targets *remoteWriteTargets
+}
+
+func (c *CreateRemoteWriteSender) Run() error {
+	config, err := k8s.BuildConfig(c.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), createTimeout)
+	defer cancel()
+
+	// the agents are on the host network, so each is scraped at its node's address
+	pods, err := clientset.CoreV1().Pods("kube-system").List(ctx, metaV1.ListOptions{LabelSelector: "k8s-app=retina"})
+	if err != nil {
+		return fmt.Errorf("error listing retina pods: %w", err)
+	}
+	c.targets.instances = nil
+	for i := range pods.Items {
+		if pods.Items[i].Status.PodIP != "" {
+			c.targets.instances = append(c.targets.instances, net.JoinHostPort(pods.Items[i].Status.PodIP, strconv.Itoa(common.RetinaPort)))
+		}
+	}
+	if len(c.targets.instances) == 0 {
+		return ErrNoRetinaPods
+	}
+
+	resources := []runtime.Object{
+		c.getConfigMap(),
+		prometheusDeployment(senderName, c.SenderNamespace, []string{
+			"--config.file=/etc/prometheus/prometheus.yml",
+			"--enable-feature=agent",
+			"--storage.agent.path=/prometheus",
+		}, true),
+	}
+
+	for i := range resources {
+		err = k8s.CreateResource(ctx, resources[i], clientset)
+		if err != nil {
+			return fmt.Errorf("error creating remote write sender component: %w", err)
+		}
+	}
+
+	err = k8s.WaitForPodsRunning(ctx, clientset, c.SenderNamespace, "app="+senderName)
+	if err != nil {
+		return fmt.Errorf("error waiting for remote write sender pod to be ready: %w", err)
+	}
+
+	return nil
+}
+
+func (c *CreateRemoteWriteSender) Prevalidate() error {
+	return nil
+}
+
+func (c *CreateRemoteWriteSender) Stop() error {
+	return nil
+}
+
+func (c *CreateRemoteWriteSender) getConfigMap() *v1.ConfigMap {
+	targets := make([]string, 0, len(c.targets.instances))
+	for _, instance := range c.targets.instances {
+		targets = append(targets, strconv.Quote(instance))
+	}
+
+	prometheusConfig := fmt.Sprintf(`global:
+  scrape_interval: %s
+scrape_configs:
+  - job_name: %s
+    static_configs:
+      - targets: [%s]
+remote_write:
+  - url: http://%s.%s.svc.cluster.local:%d/api/v1/write
+`, scrapeInterval, scrapeJobName, strings.Join(targets, ", "), receiverName, c.SenderNamespace, prometheusPort)
+
+	return &v1.ConfigMap{
+		TypeMeta: metaV1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      senderName,
+			Namespace: c.SenderNamespace,
+		},
+		Data: map[string]string{
+			"prometheus.yml": prometheusConfig,
+		},
+	}
+}
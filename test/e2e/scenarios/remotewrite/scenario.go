@@ -0,0 +1,91 @@
+package remotewrite
+
+import (
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+)
+
+const (
+	workloadNamespace = "retina-remote-write"
+
+	forwardCountMetricName = "networkobservability_forward_count"
+)
+
+// ValidateRemoteWriteMetrics scrapes every Retina agent with a Prometheus agent that remote writes to a
+// receiving Prometheus, generates traffic, and validates the agents' forward counts arrive at the receiver.
+// Retina itself only serves metrics to be scraped, so this covers the path through a remote write pipeline
+func ValidateRemoteWriteMetrics() *types.Scenario {
+	name := "Remote Write Metrics"
+	clientName := "agnhost-remote-write-client"
+	targets := &remoteWriteTargets{}
+
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.CreateNamespace{
+				NamespaceName: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &CreateRemoteWriteReceiver{
+				ReceiverNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &CreateRemoteWriteSender{
+				SenderNamespace: workloadNamespace,
+				targets:         targets,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      clientName,
+				AgnhostNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.ExecInPod{
+				PodName:      clientName + "-0",
+				PodNamespace: workloadNamespace,
+				Command:      "curl -s -m 5 bing.com",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &ValidateReceivedSeries{
+				ReceiverNamespace: workloadNamespace,
+				ClientPodName:     clientName + "-0",
+				MetricName:        forwardCountMetricName,
+				targets:           targets,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.DeleteKubernetesResource{
+				ResourceType:      kubernetes.TypeString(kubernetes.Namespace),
+				ResourceName:      workloadNamespace,
+				ResourceNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	return types.NewScenario(name, steps...)
+}
@@ -0,0 +1,94 @@
+package remotewrite
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/microsoft/retina/test/retry"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	metricRetryAttempts = 20
+	metricRetryDelay    = 5 * time.Second
+)
+
+var ErrSeriesNotReceived = fmt.Errorf("series not received from every retina agent")
+
+// ValidateReceivedSeries checks the receiver stores series of MetricName scraped from each Retina agent,
+// which only get there by remote write. The receiver's API is queried from inside the cluster, by the
+// client pod, as the job's port forward parameters are taken by the agents' metrics endpoint
+type ValidateReceivedSeries struct {
+	KubeConfigFilePath string
+	ReceiverNamespace  string
+	ClientPodName      string
+	MetricName         string
+
+	targets *remoteWriteTargets
+}
+
+func (v *ValidateReceivedSeries) Run() error {
+	config, err := k8s.BuildConfig(v.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	apiAddress := fmt.Sprintf("http://%s.%s.svc.cluster.local:%d", receiverName, v.ReceiverNamespace, prometheusPort)
+	query := "curl -s -m 5 " + prom.SeriesQueryURL(apiAddress, v.MetricName, map[string]string{
+		"job": scrapeJobName,
+	})
+
+	checkFn := func() error {
+		output, execErr := k8s.ExecPod(context.Background(), clientset, config, v.ReceiverNamespace, v.ClientPodName, query)
+		if execErr != nil {
+			return fmt.Errorf("failed to query received series of %s: %s: %w", v.MetricName, string(output), execErr)
+		}
+		series, parseErr := prom.ParseSeriesResponse(output)
+		if parseErr != nil {
+			return fmt.Errorf("failed to query received series of %s: %w", v.MetricName, parseErr)
+		}
+
+		received := map[string]bool{}
+		for _, labels := range series {
+			received[labels["instance"]] = true
+		}
+
+		missing := []string{}
+		for _, instance := range v.targets.instances {
+			if !received[instance] {
+				missing = append(missing, instance)
+			}
+		}
+		if len(missing) > 0 {
+			log.Printf("no %s series received from %v yet\n", v.MetricName, missing)
+			return fmt.Errorf("missing %v: %w", missing, ErrSeriesNotReceived)
+		}
+		return nil
+	}
+
+	retrier := retry.Retrier{Attempts: metricRetryAttempts, Delay: metricRetryDelay}
+	err = retrier.Do(context.Background(), checkFn)
+	if err != nil {
+		return fmt.Errorf("failed to verify remote written series of %s: %w", v.MetricName, err)
+	}
+
+	log.Printf("%s series of all %d retina agents were remote written to the receiver\n", v.MetricName, len(v.targets.instances))
+	return nil
+}
+
+func (v *ValidateReceivedSeries) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateReceivedSeries) Stop() error {
+	return nil
+}
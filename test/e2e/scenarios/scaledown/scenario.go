@@ -0,0 +1,145 @@
+package scaledown
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+)
+
+const (
+	workloadNamespace = "retina-scale-down"
+
+	serverReplicas = 2
+	trafficDelay   = 10 * time.Second
+)
+
+// ValidateScaleDownFlowMetrics sends requests to the highest ordinal pod of a StatefulSet while scaling the
+// StatefulSet down, so the requests keep going while the pod terminates and after it's gone, and validates
+// the traffic is attributed to that pod only, by the agent on the node it ran on
+func ValidateScaleDownFlowMetrics() *types.Scenario {
+	name := "StatefulSet Scale Down Flow Metrics"
+	clientName := "agnhost-scale-down-client"
+	serverName := "agnhost-scale-down-server"
+	terminatedPodName := serverName + "-" + strconv.Itoa(serverReplicas-1)
+	target := &requestTarget{}
+
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.CreateNamespace{
+				NamespaceName: workloadNamespace,
+				Observe:       true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      serverName,
+				AgnhostNamespace: workloadNamespace,
+				Replicas:         serverReplicas,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      clientName,
+				AgnhostNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		// the affinity pod has to be running to find its node, so forward before it's terminated
+		{
+			Step: &kubernetes.PortForward{
+				Namespace:                          "kube-system",
+				LabelSelector:                      "k8s-app=retina",
+				LocalPort:                          strconv.Itoa(common.RetinaPort),
+				RemotePort:                         strconv.Itoa(common.RetinaPort),
+				Endpoint:                           "metrics",
+				OptionalLabelAffinity:              "statefulset.kubernetes.io/pod-name=" + terminatedPodName,
+				OptionalLabelAffinityAllNamespaces: true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "scale-down-port-forward",
+			},
+		},
+		{
+			Step: &SendRequestsInBackground{
+				PodNamespace:         workloadNamespace,
+				PodName:              clientName + "-0",
+				DestinationNamespace: workloadNamespace,
+				DestinationPodName:   terminatedPodName,
+				target:               target,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "scale-down-requests",
+			},
+		},
+		{
+			Step: &types.Sleep{
+				Duration: trafficDelay,
+			},
+		},
+		{
+			// waits for the terminated pod to be gone
+			Step: &kubernetes.ScaleWorkload{
+				ResourceType:      kubernetes.TypeString(kubernetes.StatefulSet),
+				ResourceName:      serverName,
+				ResourceNamespace: workloadNamespace,
+				Replicas:          serverReplicas - 1,
+				WaitForReady:      true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &types.Sleep{
+				Duration: trafficDelay,
+			},
+		},
+		{
+			Step: &types.Stop{
+				BackgroundID: "scale-down-requests",
+			},
+		},
+		{
+			Step: &ValidateTerminatedPodAttribution{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				NamespaceName:           workloadNamespace,
+				PodName:                 terminatedPodName,
+				StatefulSetName:         serverName,
+				target:                  target,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &types.Stop{
+				BackgroundID: "scale-down-port-forward",
+			},
+		},
+		{
+			Step: &kubernetes.DeleteKubernetesResource{
+				ResourceType:      kubernetes.TypeString(kubernetes.Namespace),
+				ResourceName:      workloadNamespace,
+				ResourceNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	return types.NewScenario(name, steps...)
+}
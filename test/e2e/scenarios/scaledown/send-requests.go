@@ -0,0 +1,94 @@
+package scaledown
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s "k8s.io/client-go/kubernetes"
+)
+
+const requestInterval = 500 * time.Millisecond
+
+// requestTarget is filled in by SendRequestsInBackground, so validators after it know the address
+// the requests went to after the destination pod is gone
+type requestTarget struct {
+	ip string
+}
+
+// SendRequestsInBackground sends an HTTP request from the pod to the destination pod's IP every
+// requestInterval until stopped, whether or not the destination still answers
+type SendRequestsInBackground struct {
+	KubeConfigFilePath   string
+	PodNamespace         string
+	PodName              string
+	DestinationNamespace string
+	DestinationPodName   string
+
+	target *requestTarget
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (s *SendRequestsInBackground) Run() error {
+	config, err := kubernetes.BuildConfig(s.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := k8s.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	destination, err := clientset.CoreV1().Pods(s.DestinationNamespace).Get(context.Background(), s.DestinationPodName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting destination pod \"%s\": %w", s.DestinationPodName, err)
+	}
+	s.target.ip = destination.Status.PodIP
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	command := fmt.Sprintf("curl -s -m 1 http://%s:%d", s.target.ip, kubernetes.AgnhostHTTPPort)
+
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(requestInterval)
+		defer ticker.Stop()
+
+		answered, unanswered := 0, 0
+		for {
+			select {
+			case <-ctx.Done():
+				log.Printf("pod \"%s\" sent %d requests to %s, %d answered\n", s.PodName, answered+unanswered, s.target.ip, answered)
+				return
+			case <-ticker.C:
+				_, execErr := kubernetes.ExecPod(ctx, clientset, config, s.PodNamespace, s.PodName, command)
+				if execErr != nil {
+					unanswered++
+				} else {
+					answered++
+				}
+			}
+		}
+	}()
+
+	log.Printf("sending requests from pod \"%s\" to pod \"%s\" at %s\n", s.PodName, s.DestinationPodName, s.target.ip)
+	return nil
+}
+
+func (s *SendRequestsInBackground) Prevalidate() error {
+	return nil
+}
+
+func (s *SendRequestsInBackground) Stop() error {
+	if s.cancel != nil {
+		s.cancel()
+		<-s.done
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package scaledown
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/microsoft/retina/test/retry"
+)
+
+const (
+	advForwardCountMetricName = "networkobservability_adv_forward_count"
+
+	metricRetryAttempts = 20
+	metricRetryDelay    = 5 * time.Second
+)
+
+var (
+	ErrNoTerminatedPodFlow   = fmt.Errorf("no ingress series attributed to the terminated pod")
+	ErrTerminatedPodMisnamed = fmt.Errorf("traffic to the terminated pod attributed to another workload")
+)
+
+// ValidateTerminatedPodAttribution checks the traffic the pod received up to its termination by a scale down
+// is attributed to the pod and, where workload labels are reported, its StatefulSet, and that nothing sent to its IP, before or after it was gone,
+// is attributed to any other pod or workload
+type ValidateTerminatedPodAttribution struct {
+	PortForwardedRetinaPort string
+	NamespaceName           string
+	PodName                 string
+	StatefulSetName         string
+
+	target *requestTarget
+}
+
+func (v *ValidateTerminatedPodAttribution) Run() error {
+	promAddress := fmt.Sprintf("http://localhost:%s/metrics", v.PortForwardedRetinaPort)
+
+	checkFn := func() error {
+		series, err := prom.GetMetricsMatchingLabels(promAddress, advForwardCountMetricName, map[string]string{
+			"ip": v.target.ip,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to scrape %s: %w", advForwardCountMetricName, err)
+		}
+
+		found := false
+		for _, metric := range series {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+
+			// series of the ip with no pod are traffic after the agent forgot the pod, which is fine,
+			// but a series naming anything else means the ip was resolved to the wrong endpoint
+			if labels["podname"] == "" {
+				continue
+			}
+			if labels["namespace"] != v.NamespaceName || labels["podname"] != v.PodName {
+				return fmt.Errorf("series %v of %s: %w", labels, v.target.ip, ErrTerminatedPodMisnamed)
+			}
+			// workload labels are only there when the metrics configuration asks for them
+			if kind, ok := labels["workload_kind"]; ok && (kind != "StatefulSet" || labels["workload_name"] != v.StatefulSetName) {
+				return fmt.Errorf("series %v of %s: %w", labels, v.target.ip, ErrTerminatedPodMisnamed)
+			}
+			if labels["direction"] == "ingress" && metric.GetCounter().GetValue() > 0 {
+				found = true
+			}
+		}
+
+		if !found {
+			log.Printf("no ingress %s series for %s/%s at %s yet\n", advForwardCountMetricName, v.NamespaceName, v.PodName, v.target.ip)
+			return ErrNoTerminatedPodFlow
+		}
+		return nil
+	}
+
+	retrier := retry.Retrier{Attempts: metricRetryAttempts, Delay: metricRetryDelay}
+	err := retrier.Do(context.Background(), checkFn)
+	if err != nil {
+		return fmt.Errorf("failed to verify prometheus metrics %s: %w", advForwardCountMetricName, err)
+	}
+
+	log.Printf("traffic to terminated pod %s/%s is attributed to it and StatefulSet %s only\n", v.NamespaceName, v.PodName, v.StatefulSetName)
+	return nil
+}
+
+func (v *ValidateTerminatedPodAttribution) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateTerminatedPodAttribution) Stop() error {
+	return nil
+}
@@ -0,0 +1,154 @@
+package scrapeboundary
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+)
+
+const (
+	workloadNamespace = "retina-scrape-boundary"
+
+	// a 40 second flow of echo requests between the same two pods
+	flowPackets          = 200
+	flowPacketsPerSecond = 5
+
+	// scrapes spread over the first 30 seconds, so all of them land while the flow runs
+	flowScrapes      = 6
+	flowScrapeDelay  = 5 * time.Second
+	minFlowIncreases = 3
+)
+
+// ValidateScrapeBoundaryMetrics runs a long flow from a client pod and scrapes the agent while it is in progress,
+// validating the client's egress byte count only ever grows across scrapes, grows during the flow, and adds up to
+// what the client's interface sent once the flow is over
+func ValidateScrapeBoundaryMetrics() *types.Scenario {
+	name := "Scrape Boundary Flow Metrics"
+	clientName := "agnhost-boundary-client"
+	serverName := "agnhost-boundary-server"
+	bytes := &flowBytes{}
+
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.CreateNamespace{
+				NamespaceName: workloadNamespace,
+				Observe:       true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: types.NewParallel(
+				&types.StepWrapper{
+					Step: &kubernetes.CreateAgnhostStatefulSet{
+						AgnhostName:      serverName,
+						AgnhostNamespace: workloadNamespace,
+					},
+					Opts: &types.StepOptions{
+						SkipSavingParametersToJob: true,
+					},
+				},
+				&types.StepWrapper{
+					Step: &kubernetes.CreateAgnhostStatefulSet{
+						AgnhostName:      clientName,
+						AgnhostNamespace: workloadNamespace,
+					},
+					Opts: &types.StepOptions{
+						SkipSavingParametersToJob: true,
+					},
+				},
+			),
+		},
+		{
+			Step: &kubernetes.PortForward{
+				Namespace:                          "kube-system",
+				LabelSelector:                      "k8s-app=retina",
+				LocalPort:                          strconv.Itoa(common.RetinaPort),
+				RemotePort:                         strconv.Itoa(common.RetinaPort),
+				Endpoint:                           "metrics",
+				OptionalLabelAffinity:              "app=" + clientName,
+				OptionalLabelAffinityAllNamespaces: true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "scrape-boundary-port-forward",
+			},
+		},
+		{
+			Step: &CaptureFlowBytes{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				PodNamespace:            workloadNamespace,
+				PodName:                 clientName + "-0",
+				bytes:                   bytes,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		// scrape while the flow is still going
+		{
+			Step: types.NewParallel(
+				&types.StepWrapper{
+					Step: &kubernetes.SendICMPEchoRequests{
+						PodNamespace:         workloadNamespace,
+						PodName:              clientName + "-0",
+						DestinationNamespace: workloadNamespace,
+						DestinationPodName:   serverName + "-0",
+						PacketCount:          flowPackets,
+						PacketsPerSecond:     flowPacketsPerSecond,
+					},
+					Opts: &types.StepOptions{
+						SkipSavingParametersToJob: true,
+					},
+				},
+				&types.StepWrapper{
+					Step: &ValidateBytesAcrossScrapes{
+						PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+						PodName:                 clientName + "-0",
+						Scrapes:                 flowScrapes,
+						Interval:                flowScrapeDelay,
+						MinIncreases:            minFlowIncreases,
+						bytes:                   bytes,
+					},
+					Opts: &types.StepOptions{
+						SkipSavingParametersToJob: true,
+					},
+				},
+			),
+		},
+		{
+			Step: &ValidateBytesMatchTransfer{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				PodNamespace:            workloadNamespace,
+				PodName:                 clientName + "-0",
+				Packets:                 flowPackets,
+				bytes:                   bytes,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &types.Stop{
+				BackgroundID: "scrape-boundary-port-forward",
+			},
+		},
+		// deleting the namespace removes both workloads with it
+		{
+			Step: &kubernetes.DeleteKubernetesResource{
+				ResourceType:      kubernetes.TypeString(kubernetes.Namespace),
+				ResourceName:      workloadNamespace,
+				ResourceNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	return types.NewScenario(name, steps...)
+}
@@ -0,0 +1,244 @@
+package scrapeboundary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/microsoft/retina/test/retry"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	advForwardBytesMetricName = "networkobservability_adv_forward_bytes"
+
+	// the pod's interface counts its frames, the agent may count from the IP header
+	ethernetHeaderBytes = 14
+
+	// the interface also sends the pod's other traffic, e.g. a DNS lookup
+	tolerancePercent = 5
+
+	metricRetryAttempts = 12
+	metricRetryDelay    = 5 * time.Second
+)
+
+var (
+	ErrBaselineNotCaptured  = fmt.Errorf("byte counter baseline was not captured")
+	ErrUnexpectedCounters   = fmt.Errorf("unexpected interface counters")
+	ErrByteCountDecreased   = fmt.Errorf("byte counter went down between scrapes")
+	ErrNoProgressDuringFlow = fmt.Errorf("byte counter didn't grow while the flow ran")
+	ErrInaccurateByteCount  = fmt.Errorf("byte counter outside tolerance of the interface counters")
+)
+
+// flowBytes carries the bytes the pod's interface and the agent counted from the baseline to the checks
+// after it, along with the last value the agent reported, which no later scrape may go below
+type flowBytes struct {
+	interfaceBytes float64
+	retinaBytes    float64
+	lastScraped    float64
+	captured       bool
+}
+
+// scrape reads the agent's egress byte count of the pod, failing if it went below the last one read
+func (f *flowBytes) scrape(portForwardedRetinaPort, podName string) (float64, error) {
+	promAddress := fmt.Sprintf("http://localhost:%s/metrics", portForwardedRetinaPort)
+	series, err := prom.GetMetricsMatchingLabels(promAddress, advForwardBytesMetricName, map[string]string{
+		"podname":   podName,
+		"direction": "egress",
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to scrape %s: %w", advForwardBytesMetricName, err)
+	}
+
+	total := 0.0
+	for _, metric := range series {
+		total += metric.GetGauge().GetValue() + metric.GetCounter().GetValue()
+	}
+
+	if total < f.lastScraped {
+		return 0, fmt.Errorf("egress bytes of %s went from %.0f to %.0f: %w", podName, f.lastScraped, total, ErrByteCountDecreased)
+	}
+	f.lastScraped = total
+	return total, nil
+}
+
+// readInterfaceBytes reads the bytes the pod's eth0 has sent
+func readInterfaceBytes(kubeConfigFilePath, namespace, podName string) (float64, error) {
+	config, err := k8s.BuildConfig(kubeConfigFilePath)
+	if err != nil {
+		return 0, fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return 0, fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	output, err := k8s.ExecPod(context.Background(), clientset, config, namespace, podName, "cat /sys/class/net/eth0/statistics/tx_bytes")
+	if err != nil {
+		return 0, fmt.Errorf("error reading interface counters of pod \"%s\": %s: %w", podName, string(output), err)
+	}
+
+	bytes, err := strconv.ParseFloat(strings.TrimSpace(string(output)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("tx_bytes \"%s\" of pod \"%s\": %w", string(output), podName, ErrUnexpectedCounters)
+	}
+	return bytes, nil
+}
+
+// CaptureFlowBytes records the bytes the pod's interface sent and the agent's egress byte count for it before the flow starts
+type CaptureFlowBytes struct {
+	KubeConfigFilePath      string
+	PortForwardedRetinaPort string
+	PodNamespace            string
+	PodName                 string
+
+	bytes *flowBytes
+}
+
+func (c *CaptureFlowBytes) Run() error {
+	var err error
+	c.bytes.interfaceBytes, err = readInterfaceBytes(c.KubeConfigFilePath, c.PodNamespace, c.PodName)
+	if err != nil {
+		return err
+	}
+	c.bytes.retinaBytes, err = c.bytes.scrape(c.PortForwardedRetinaPort, c.PodName)
+	if err != nil {
+		return err
+	}
+
+	c.bytes.captured = true
+	log.Printf("pod %s sent %.0f bytes before the flow, of which the agent counted %.0f\n", c.PodName, c.bytes.interfaceBytes, c.bytes.retinaBytes)
+	return nil
+}
+
+func (c *CaptureFlowBytes) Prevalidate() error {
+	return nil
+}
+
+func (c *CaptureFlowBytes) Stop() error {
+	return nil
+}
+
+// ValidateBytesAcrossScrapes scrapes the agent's egress byte count of the pod Scrapes times, Interval apart, while
+// a flow from the pod is still running. Every scrape must return at least the one before, and the count must grow
+// between at least MinIncreases of them, so the flow's partial progress is exported as it goes rather than at its end
+type ValidateBytesAcrossScrapes struct {
+	PortForwardedRetinaPort string
+	PodName                 string
+	Scrapes                 int
+	Interval                time.Duration
+	MinIncreases            int
+
+	bytes *flowBytes
+}
+
+func (v *ValidateBytesAcrossScrapes) Run() error {
+	if !v.bytes.captured {
+		return ErrBaselineNotCaptured
+	}
+
+	increases := 0
+	previous := v.bytes.lastScraped
+	for i := 0; i < v.Scrapes; i++ {
+		time.Sleep(v.Interval)
+
+		current, err := v.bytes.scrape(v.PortForwardedRetinaPort, v.PodName)
+		if err != nil {
+			return fmt.Errorf("scrape %d of %d: %w", i+1, v.Scrapes, err)
+		}
+		if current > previous {
+			increases++
+		}
+		log.Printf("scrape %d of %d: agent counted %.0f egress bytes of %s since the baseline\n", i+1, v.Scrapes, current-v.bytes.retinaBytes, v.PodName)
+		previous = current
+	}
+
+	if increases < v.MinIncreases {
+		return fmt.Errorf("egress bytes of %s grew between %d of %d scrapes, expected at least %d: %w", v.PodName, increases, v.Scrapes, v.MinIncreases, ErrNoProgressDuringFlow)
+	}
+	return nil
+}
+
+func (v *ValidateBytesAcrossScrapes) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateBytesAcrossScrapes) Stop() error {
+	return nil
+}
+
+// ValidateBytesMatchTransfer waits for the agent's egress byte count of the pod to grow by what the pod's interface
+// sent since the baseline, within tolerancePercent, once the flow is over. The bytes the agent counts may leave out
+// the Ethernet header, so anything from the IP bytes to the frame bytes of the flow's packets is accepted. No scrape
+// may go below one before it
+type ValidateBytesMatchTransfer struct {
+	KubeConfigFilePath      string
+	PortForwardedRetinaPort string
+	PodNamespace            string
+	PodName                 string
+	Packets                 int
+
+	bytes *flowBytes
+}
+
+func (v *ValidateBytesMatchTransfer) Run() error {
+	if !v.bytes.captured {
+		return ErrBaselineNotCaptured
+	}
+
+	interfaceBytes, err := readInterfaceBytes(v.KubeConfigFilePath, v.PodNamespace, v.PodName)
+	if err != nil {
+		return err
+	}
+	sentBytes := interfaceBytes - v.bytes.interfaceBytes
+	minimum := sentBytes - float64(ethernetHeaderBytes*v.Packets)
+	slack := sentBytes * tolerancePercent / 100
+
+	var counted float64
+	var decreased error
+	checkFn := func() error {
+		retinaBytes, scrapeErr := v.bytes.scrape(v.PortForwardedRetinaPort, v.PodName)
+		if errors.Is(scrapeErr, ErrByteCountDecreased) {
+			// no later scrape makes up for it
+			decreased = scrapeErr
+			return nil
+		}
+		if scrapeErr != nil {
+			return scrapeErr
+		}
+
+		counted = retinaBytes - v.bytes.retinaBytes
+		if counted < minimum-slack || counted > sentBytes+slack {
+			log.Printf("agent counted %.0f of the %.0f bytes pod %s sent\n", counted, sentBytes, v.PodName)
+			return fmt.Errorf("counted %.0f of %.0f bytes: %w", counted, sentBytes, ErrInaccurateByteCount)
+		}
+		return nil
+	}
+
+	retrier := retry.Retrier{Attempts: metricRetryAttempts, Delay: metricRetryDelay}
+	err = retrier.Do(context.Background(), checkFn)
+	if err != nil {
+		return fmt.Errorf("failed to verify prometheus metrics %s: %w", advForwardBytesMetricName, err)
+	}
+	if decreased != nil {
+		return decreased
+	}
+
+	log.Printf("agent counted %.0f of the %.0f bytes pod %s sent across scrapes\n", counted, sentBytes, v.PodName)
+	return nil
+}
+
+func (v *ValidateBytesMatchTransfer) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateBytesMatchTransfer) Stop() error {
+	return nil
+}
@@ -0,0 +1,128 @@
+package sidecar
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+)
+
+const (
+	workloadNamespace = "retina-sidecar"
+	sidecarPort       = 8081
+	localhostRequests = 20
+
+	// lets flows reach the metrics before they're read, so the baseline and the final count both include
+	// everything sent before them
+	settleDelay = 15 * time.Second
+)
+
+// ValidateIntraPodTrafficMetrics runs a pod with a sidecar container, sends HTTP requests between the two
+// over localhost, and validates the traffic is consistently left out of the pod's flow metrics
+func ValidateIntraPodTrafficMetrics() *types.Scenario {
+	name := "Intra Pod Localhost Traffic Metrics"
+	agnhostName := "agnhost-sidecar"
+	podName := agnhostName + "-0"
+	baseline := &podForwardBaseline{}
+
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.CreateNamespace{
+				NamespaceName: workloadNamespace,
+				Observe:       true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      agnhostName,
+				AgnhostNamespace: workloadNamespace,
+				SidecarArgs:      []string{"serve-hostname", "--http", "--port", strconv.Itoa(sidecarPort)},
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.PortForward{
+				Namespace:                          "kube-system",
+				LabelSelector:                      "k8s-app=retina",
+				LocalPort:                          strconv.Itoa(common.RetinaPort),
+				RemotePort:                         strconv.Itoa(common.RetinaPort),
+				Endpoint:                           "metrics",
+				OptionalLabelAffinity:              "app=" + agnhostName,
+				OptionalLabelAffinityAllNamespaces: true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "sidecar-port-forward",
+			},
+		},
+		{
+			Step: &types.Sleep{
+				Duration: settleDelay,
+			},
+		},
+		{
+			Step: &CaptureIntraPodBaseline{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				PodNamespace:            workloadNamespace,
+				PodName:                 podName,
+				baseline:                baseline,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &SendLocalhostRequests{
+				PodNamespace:  workloadNamespace,
+				PodName:       podName,
+				ContainerName: agnhostName,
+				Port:          sidecarPort,
+				Requests:      localhostRequests,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &types.Sleep{
+				Duration: settleDelay,
+			},
+		},
+		{
+			Step: &ValidateIntraPodTrafficExcluded{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				PodNamespace:            workloadNamespace,
+				PodName:                 podName,
+				Requests:                localhostRequests,
+				baseline:                baseline,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &types.Stop{
+				BackgroundID: "sidecar-port-forward",
+			},
+		},
+		{
+			Step: &kubernetes.DeleteKubernetesResource{
+				ResourceType:      kubernetes.TypeString(kubernetes.Namespace),
+				ResourceName:      workloadNamespace,
+				ResourceNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	return types.NewScenario(name, steps...)
+}
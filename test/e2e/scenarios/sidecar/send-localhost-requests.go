@@ -0,0 +1,58 @@
+package sidecar
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"k8s.io/client-go/kubernetes"
+)
+
+const requestTimeout = 5 * time.Minute
+
+// SendLocalhostRequests makes Requests HTTP requests over localhost from ContainerName to Port, served by
+// another container of the same pod, so the traffic never leaves the pod's network namespace
+type SendLocalhostRequests struct {
+	KubeConfigFilePath string
+	PodNamespace       string
+	PodName            string
+	ContainerName      string
+	Port               int
+	Requests           int
+}
+
+func (s *SendLocalhostRequests) Run() error {
+	config, err := k8s.BuildConfig(s.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	command := fmt.Sprintf("curl -s -m 5 -o /dev/null http://localhost:%d", s.Port)
+	for i := 0; i < s.Requests; i++ {
+		output, execErr := k8s.ExecPodContainer(ctx, clientset, config, s.PodNamespace, s.PodName, s.ContainerName, command)
+		if execErr != nil {
+			return fmt.Errorf("error sending request %d over localhost in pod \"%s\": %s: %w", i, s.PodName, string(output), execErr)
+		}
+	}
+
+	log.Printf("sent %d requests from container %s to localhost:%d in pod %s\n", s.Requests, s.ContainerName, s.Port, s.PodName)
+	return nil
+}
+
+func (s *SendLocalhostRequests) Prevalidate() error {
+	return nil
+}
+
+func (s *SendLocalhostRequests) Stop() error {
+	return nil
+}
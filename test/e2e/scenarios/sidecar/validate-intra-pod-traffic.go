@@ -0,0 +1,112 @@
+package sidecar
+
+import (
+	"fmt"
+	"log"
+	"net"
+
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+)
+
+const advForwardCountMetricName = "networkobservability_adv_forward_count"
+
+var (
+	ErrBaselineNotCaptured = fmt.Errorf("intra pod traffic baseline was not captured")
+	ErrIntraPodTraffic     = fmt.Errorf("localhost traffic within the pod was counted")
+)
+
+// podForwardBaseline carries the pod's forwarded packet count before the localhost traffic to the step validating it
+type podForwardBaseline struct {
+	packets  float64
+	captured bool
+}
+
+// podForwardCount returns the packets forwarded to and from the pod, failing if any series is for a loopback address
+func podForwardCount(portForwardedRetinaPort, namespace, podName string) (float64, error) {
+	promAddress := fmt.Sprintf("http://localhost:%s/metrics", portForwardedRetinaPort)
+	series, err := prom.GetMetricsMatchingLabels(promAddress, advForwardCountMetricName, map[string]string{
+		"namespace": namespace,
+		"podname":   podName,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to scrape %s: %w", advForwardCountMetricName, err)
+	}
+
+	total := 0.0
+	for _, metric := range series {
+		for _, label := range metric.GetLabel() {
+			if label.GetName() == "ip" && net.ParseIP(label.GetValue()).IsLoopback() {
+				return 0, fmt.Errorf("series for %s/%s has loopback address %s: %w", namespace, podName, label.GetValue(), ErrIntraPodTraffic)
+			}
+		}
+		total += metric.GetCounter().GetValue() + metric.GetGauge().GetValue()
+	}
+	return total, nil
+}
+
+// CaptureIntraPodBaseline records the pod's forwarded packet count before the localhost traffic
+type CaptureIntraPodBaseline struct {
+	PortForwardedRetinaPort string
+	PodNamespace            string
+	PodName                 string
+
+	baseline *podForwardBaseline
+}
+
+func (c *CaptureIntraPodBaseline) Run() error {
+	packets, err := podForwardCount(c.PortForwardedRetinaPort, c.PodNamespace, c.PodName)
+	if err != nil {
+		return err
+	}
+
+	*c.baseline = podForwardBaseline{packets: packets, captured: true}
+	log.Printf("%.0f packets forwarded for %s before the localhost traffic\n", packets, c.PodName)
+	return nil
+}
+
+func (c *CaptureIntraPodBaseline) Prevalidate() error {
+	return nil
+}
+
+func (c *CaptureIntraPodBaseline) Stop() error {
+	return nil
+}
+
+// ValidateIntraPodTrafficExcluded checks the localhost traffic between the pod's containers wasn't counted.
+// It never reaches the pod's veth, where the agent observes packets, so by design the pod's flow metrics don't
+// grow with it. Each request is several packets, so fewer new packets than Requests can't be the requests
+type ValidateIntraPodTrafficExcluded struct {
+	PortForwardedRetinaPort string
+	PodNamespace            string
+	PodName                 string
+	Requests                int
+
+	baseline *podForwardBaseline
+}
+
+func (v *ValidateIntraPodTrafficExcluded) Run() error {
+	if !v.baseline.captured {
+		return ErrBaselineNotCaptured
+	}
+
+	packets, err := podForwardCount(v.PortForwardedRetinaPort, v.PodNamespace, v.PodName)
+	if err != nil {
+		return err
+	}
+
+	grown := packets - v.baseline.packets
+	if grown >= float64(v.Requests) {
+		return fmt.Errorf("%.0f packets forwarded for %s during %d localhost requests: %w", grown, v.PodName, v.Requests, ErrIntraPodTraffic)
+	}
+
+	log.Printf("%.0f packets forwarded for %s during %d localhost requests\n", grown, v.PodName, v.Requests)
+	return nil
+}
+
+func (v *ValidateIntraPodTrafficExcluded) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateIntraPodTrafficExcluded) Stop() error {
+	return nil
+}
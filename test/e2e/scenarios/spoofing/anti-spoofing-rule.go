@@ -0,0 +1,111 @@
+package spoofing
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	defaultTimeout = 2 * time.Minute
+
+	antiSpoofingComment = "retina-e2e-anti-spoofing"
+)
+
+var ErrNoRetinaPodOnNode = fmt.Errorf("no retina pod found on node")
+
+// InstallAntiSpoofingRule drops forwarded packets sourced from SpoofedSourceIP on the node running PodName,
+// standing in for a CNI's anti-spoofing filter. The rule is added through the host network retina pod on
+// that node, run it in the background so Stop removes the rule again.
+type InstallAntiSpoofingRule struct {
+	KubeConfigFilePath       string
+	RetinaDaemonSetNamespace string
+	PodNamespace             string
+	PodName                  string
+	SpoofedSourceIP          string
+
+	// local properties
+	retinaPodName string
+}
+
+func (a *InstallAntiSpoofingRule) Run() error {
+	config, clientset, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	pod, err := clientset.CoreV1().Pods(a.PodNamespace).Get(ctx, a.PodName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting pod \"%s\": %w", a.PodName, err)
+	}
+
+	retinaPods, err := clientset.CoreV1().Pods(a.RetinaDaemonSetNamespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "k8s-app=retina",
+		FieldSelector: "spec.nodeName=" + pod.Spec.NodeName,
+	})
+	if err != nil {
+		return fmt.Errorf("error listing retina pods on node %s: %w", pod.Spec.NodeName, err)
+	}
+	if len(retinaPods.Items) == 0 {
+		return fmt.Errorf("node %s: %w", pod.Spec.NodeName, ErrNoRetinaPodOnNode)
+	}
+	a.retinaPodName = retinaPods.Items[0].Name
+
+	_, err = k8s.ExecPod(ctx, clientset, config, a.RetinaDaemonSetNamespace, a.retinaPodName, a.rule("-I"))
+	if err != nil {
+		return fmt.Errorf("error installing anti-spoofing rule through retina pod \"%s\": %w", a.retinaPodName, err)
+	}
+	log.Printf("dropping forwarded packets from %s on node %s\n", a.SpoofedSourceIP, pod.Spec.NodeName)
+
+	return nil
+}
+
+func (a *InstallAntiSpoofingRule) rule(op string) string {
+	return fmt.Sprintf("iptables -w %s FORWARD -s %s/32 -m comment --comment %s -j DROP", op, a.SpoofedSourceIP, antiSpoofingComment)
+}
+
+func (a *InstallAntiSpoofingRule) client() (*rest.Config, *kubernetes.Clientset, error) {
+	config, err := k8s.BuildConfig(a.KubeConfigFilePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+	return config, clientset, nil
+}
+
+func (a *InstallAntiSpoofingRule) Prevalidate() error {
+	return nil
+}
+
+func (a *InstallAntiSpoofingRule) Stop() error {
+	if a.retinaPodName == "" {
+		return nil
+	}
+
+	config, clientset, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	_, err = k8s.ExecPod(ctx, clientset, config, a.RetinaDaemonSetNamespace, a.retinaPodName, a.rule("-D"))
+	if err != nil {
+		return fmt.Errorf("error removing anti-spoofing rule through retina pod \"%s\": %w", a.retinaPodName, err)
+	}
+	return nil
+}
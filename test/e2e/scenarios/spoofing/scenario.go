@@ -0,0 +1,106 @@
+package spoofing
+
+import (
+	"strconv"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+	"github.com/microsoft/retina/test/e2e/scenarios/drop"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// SpoofedSourceIP is never allocated to a pod, taken from TEST-NET-1
+	SpoofedSourceIP = "192.0.2.55"
+
+	// AntiSpoofingDropReason is how Retina's drop taxonomy reports an anti-spoofing drop:
+	// the filter is an iptables rule, there's no dedicated anti-spoofing reason
+	AntiSpoofingDropReason = drop.IPTableRuleDrop
+)
+
+// ValidateSpoofedSourceDropMetric sends traffic with a spoofed source IP from a pod, drops it with an
+// anti-spoofing rule on the pod's node, and validates the drop metric records it with the matching reason
+func ValidateSpoofedSourceDropMetric() *types.Scenario {
+	name := "Spoofed Source Drop Metrics"
+	agnhostName := "agnhost-spoof"
+	podName := agnhostName + "-0"
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      agnhostName,
+				AgnhostNamespace: "kube-system",
+				AddCapabilities:  []v1.Capability{"NET_ADMIN"},
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &InstallAntiSpoofingRule{
+				RetinaDaemonSetNamespace: "kube-system",
+				PodNamespace:             "kube-system",
+				PodName:                  podName,
+				SpoofedSourceIP:          SpoofedSourceIP,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "anti-spoofing-rule",
+			},
+		},
+		{
+			Step: &SendSpoofedTraffic{
+				PodNamespace:    "kube-system",
+				PodName:         podName,
+				SpoofedSourceIP: SpoofedSourceIP,
+				Destination:     "bing.com",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.PortForward{
+				Namespace:             "kube-system",
+				LabelSelector:         "k8s-app=retina",
+				LocalPort:             strconv.Itoa(common.RetinaPort),
+				RemotePort:            strconv.Itoa(common.RetinaPort),
+				Endpoint:              "metrics",
+				OptionalLabelAffinity: "app=" + agnhostName, // port forward to a pod on a node that also has this pod with this label, assuming same namespace
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "spoofing-port-forward",
+			},
+		},
+		{
+			Step: &ValidateAntiSpoofingDropMetric{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				Direction:               "unknown",
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &types.Stop{
+				BackgroundID: "spoofing-port-forward",
+			},
+		},
+		{
+			Step: &types.Stop{
+				BackgroundID: "anti-spoofing-rule",
+			},
+		},
+		{
+			Step: &kubernetes.DeleteKubernetesResource{
+				ResourceType:      kubernetes.TypeString(kubernetes.StatefulSet),
+				ResourceName:      agnhostName,
+				ResourceNamespace: "kube-system",
+			}, Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+	return types.NewScenario(name, steps...)
+}
@@ -0,0 +1,61 @@
+package spoofing
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"k8s.io/client-go/kubernetes"
+)
+
+const spoofedRequests = 3
+
+// SendSpoofedTraffic assigns SpoofedSourceIP to the pod's interface and sends requests from it,
+// so they leave the pod with a source address the cluster never allocated. The pod requires NET_ADMIN.
+type SendSpoofedTraffic struct {
+	KubeConfigFilePath string
+	PodNamespace       string
+	PodName            string
+	SpoofedSourceIP    string
+	Destination        string
+}
+
+func (s *SendSpoofedTraffic) Run() error {
+	config, err := k8s.BuildConfig(s.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	_, err = k8s.ExecPod(ctx, clientset, config, s.PodNamespace, s.PodName, fmt.Sprintf("ip addr add %s/32 dev eth0", s.SpoofedSourceIP))
+	if err != nil {
+		return fmt.Errorf("error adding spoofed address to pod \"%s\": %w", s.PodName, err)
+	}
+
+	// the requests are expected to be dropped, only the attempt matters
+	request := fmt.Sprintf("curl -s -m 5 --interface %s %s", s.SpoofedSourceIP, s.Destination)
+	for attempt := 0; attempt < spoofedRequests; attempt++ {
+		_, err = k8s.ExecPod(ctx, clientset, config, s.PodNamespace, s.PodName, request)
+		if err != nil {
+			log.Printf("request from spoofed source %s in pod \"%s\" failed as expected: %v\n", s.SpoofedSourceIP, s.PodName, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *SendSpoofedTraffic) Prevalidate() error {
+	return nil
+}
+
+func (s *SendSpoofedTraffic) Stop() error {
+	return nil
+}
@@ -0,0 +1,42 @@
+package spoofing
+
+import (
+	"fmt"
+	"log"
+
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+)
+
+var dropCountMetricName = "networkobservability_drop_count"
+
+// ValidateAntiSpoofingDropMetric checks the drop counter records the spoofed packets under the reason
+// the anti-spoofing filter maps to in Retina's drop taxonomy
+type ValidateAntiSpoofingDropMetric struct {
+	PortForwardedRetinaPort string
+	Direction               string
+}
+
+func (v *ValidateAntiSpoofingDropMetric) Run() error {
+	promAddress := fmt.Sprintf("http://localhost:%s/metrics", v.PortForwardedRetinaPort)
+
+	metric := map[string]string{
+		"direction": v.Direction,
+		"reason":    AntiSpoofingDropReason,
+	}
+
+	err := prom.CheckMetric(promAddress, dropCountMetricName, metric)
+	if err != nil {
+		return fmt.Errorf("failed to verify prometheus metrics %s: %w", dropCountMetricName, err)
+	}
+
+	log.Printf("found metrics matching %+v\n", metric)
+	return nil
+}
+
+func (v *ValidateAntiSpoofingDropMetric) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateAntiSpoofingDropMetric) Stop() error {
+	return nil
+}
@@ -17,7 +17,7 @@ const (
 
 func ValidateTCPMetrics() *types.Scenario {
 	Name := "Flow Metrics"
-	Steps := []*types.StepWrapper{
+	Setup := []*types.StepWrapper{
 		{
 			Step: &kubernetes.CreateKapingerDeployment{
 				KapingerNamespace: "kube-system",
@@ -30,6 +30,8 @@ func ValidateTCPMetrics() *types.Scenario {
 				AgnhostNamespace: "kube-system",
 			},
 		},
+	}
+	Steps := []*types.StepWrapper{
 		{
 			Step: &kubernetes.ExecInPod{
 				PodName:      "agnhost-a-0",
@@ -81,6 +83,8 @@ func ValidateTCPMetrics() *types.Scenario {
 				SkipSavingParametersToJob: true,
 			},
 		},
+	}
+	Cleanup := []*types.StepWrapper{
 		{
 			Step: &types.Stop{
 				BackgroundID: "drop-flow-forward",
@@ -97,5 +101,5 @@ func ValidateTCPMetrics() *types.Scenario {
 		},
 	}
 
-	return types.NewScenario(Name, Steps...)
+	return types.NewScenario(Name, Steps...).WithSetup(Setup...).WithCleanup(Cleanup...)
 }
@@ -0,0 +1,219 @@
+package tcpoptions
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/microsoft/retina/test/retry"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	advForwardCountMetricName = "networkobservability_adv_forward_count"
+	advForwardBytesMetricName = "networkobservability_adv_forward_bytes"
+
+	// the pod's interface counts its frames, the agent may count from the IP header
+	ethernetHeaderBytes = 14
+
+	// the interface counters are read for the same traffic, so only a percent is allowed for stray packets
+	tolerancePercent = 5
+
+	metricRetryAttempts = 12
+	metricRetryDelay    = 5 * time.Second
+)
+
+var (
+	ErrCountersNotCaptured   = fmt.Errorf("flow counters baseline was not captured")
+	ErrUnexpectedCounters    = fmt.Errorf("unexpected interface counters")
+	ErrInaccurateFlowMetrics = fmt.Errorf("flow metrics outside tolerance of the interface counters")
+)
+
+// flowCounters carries what the pod's interface and the agent counted of the pod's egress before the
+// traffic to the step validating them
+type flowCounters struct {
+	interfacePackets float64
+	interfaceBytes   float64
+	retinaPackets    float64
+	retinaBytes      float64
+	captured         bool
+}
+
+// readInterfaceCounters reads the packets and bytes the pod's eth0 has sent
+func readInterfaceCounters(ctx context.Context, clientset *kubernetes.Clientset, config *rest.Config, namespace, podName string) (packets, bytes float64, err error) {
+	command := "cat /sys/class/net/eth0/statistics/tx_packets /sys/class/net/eth0/statistics/tx_bytes"
+	output, err := k8s.ExecPod(ctx, clientset, config, namespace, podName, command)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error reading interface counters of pod \"%s\": %s: %w", podName, string(output), err)
+	}
+
+	fields := strings.Fields(string(output))
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("counters \"%s\" of pod \"%s\": %w", string(output), podName, ErrUnexpectedCounters)
+	}
+	packets, err = strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error parsing tx_packets of pod \"%s\": %w", podName, err)
+	}
+	bytes, err = strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error parsing tx_bytes of pod \"%s\": %w", podName, err)
+	}
+	return packets, bytes, nil
+}
+
+// readRetinaCounters adds up the pod's egress forward count and bytes series, one per local context label set
+func readRetinaCounters(portForwardedRetinaPort, podName string) (packets, bytes float64, err error) {
+	packets, err = sumSeries(portForwardedRetinaPort, advForwardCountMetricName, podName)
+	if err != nil {
+		return 0, 0, err
+	}
+	bytes, err = sumSeries(portForwardedRetinaPort, advForwardBytesMetricName, podName)
+	if err != nil {
+		return 0, 0, err
+	}
+	return packets, bytes, nil
+}
+
+func sumSeries(portForwardedRetinaPort, metricName, podName string) (float64, error) {
+	promAddress := fmt.Sprintf("http://localhost:%s/metrics", portForwardedRetinaPort)
+	series, err := prom.GetMetricsMatchingLabels(promAddress, metricName, map[string]string{
+		"podname":   podName,
+		"direction": "egress",
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to scrape %s: %w", metricName, err)
+	}
+
+	total := 0.0
+	for _, metric := range series {
+		total += metric.GetGauge().GetValue() + metric.GetCounter().GetValue()
+	}
+	return total, nil
+}
+
+func withinTolerance(value, minimum, maximum float64) bool {
+	slack := maximum * tolerancePercent / 100
+	return value >= minimum-slack && value <= maximum+slack
+}
+
+// CaptureFlowCounters records the pod's interface counters and the agent's egress counts for it before the traffic is sent
+type CaptureFlowCounters struct {
+	KubeConfigFilePath      string
+	PortForwardedRetinaPort string
+	PodNamespace            string
+	PodName                 string
+
+	counters *flowCounters
+}
+
+func (c *CaptureFlowCounters) Run() error {
+	config, err := k8s.BuildConfig(c.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	c.counters.interfacePackets, c.counters.interfaceBytes, err = readInterfaceCounters(context.Background(), clientset, config, c.PodNamespace, c.PodName)
+	if err != nil {
+		return err
+	}
+	c.counters.retinaPackets, c.counters.retinaBytes, err = readRetinaCounters(c.PortForwardedRetinaPort, c.PodName)
+	if err != nil {
+		return err
+	}
+
+	c.counters.captured = true
+	log.Printf("pod %s sent %.0f packets, %.0f bytes before traffic, of which the agent counted %.0f packets, %.0f bytes\n",
+		c.PodName, c.counters.interfacePackets, c.counters.interfaceBytes, c.counters.retinaPackets, c.counters.retinaBytes)
+	return nil
+}
+
+func (c *CaptureFlowCounters) Prevalidate() error {
+	return nil
+}
+
+func (c *CaptureFlowCounters) Stop() error {
+	return nil
+}
+
+// ValidateFlowAccuracy waits for the agent's egress packet and byte counts of the pod to grow by what the pod's
+// interface sent since the baseline, within tolerancePercent. The bytes the agent counts may leave out the
+// Ethernet header, so they are accepted anywhere from the IP bytes to the frame bytes the interface sent
+type ValidateFlowAccuracy struct {
+	KubeConfigFilePath      string
+	PortForwardedRetinaPort string
+	PodNamespace            string
+	PodName                 string
+
+	counters *flowCounters
+}
+
+func (v *ValidateFlowAccuracy) Run() error {
+	if !v.counters.captured {
+		return ErrCountersNotCaptured
+	}
+
+	config, err := k8s.BuildConfig(v.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	// the traffic is over, so the interface counters are final
+	interfacePackets, interfaceBytes, err := readInterfaceCounters(context.Background(), clientset, config, v.PodNamespace, v.PodName)
+	if err != nil {
+		return err
+	}
+	sentPackets := interfacePackets - v.counters.interfacePackets
+	sentBytes := interfaceBytes - v.counters.interfaceBytes
+
+	checkFn := func() error {
+		retinaPackets, retinaBytes, readErr := readRetinaCounters(v.PortForwardedRetinaPort, v.PodName)
+		if readErr != nil {
+			return readErr
+		}
+
+		countedPackets := retinaPackets - v.counters.retinaPackets
+		countedBytes := retinaBytes - v.counters.retinaBytes
+		log.Printf("agent counted %.0f packets, %.0f bytes of the %.0f packets, %.0f bytes pod %s sent\n",
+			countedPackets, countedBytes, sentPackets, sentBytes, v.PodName)
+
+		if !withinTolerance(countedPackets, sentPackets, sentPackets) {
+			return fmt.Errorf("counted %.0f of %.0f packets: %w", countedPackets, sentPackets, ErrInaccurateFlowMetrics)
+		}
+		if !withinTolerance(countedBytes, sentBytes-ethernetHeaderBytes*sentPackets, sentBytes) {
+			return fmt.Errorf("counted %.0f of %.0f bytes: %w", countedBytes, sentBytes, ErrInaccurateFlowMetrics)
+		}
+		return nil
+	}
+
+	retrier := retry.Retrier{Attempts: metricRetryAttempts, Delay: metricRetryDelay}
+	err = retrier.Do(context.Background(), checkFn)
+	if err != nil {
+		return fmt.Errorf("failed to verify flow metrics of pod %s: %w", v.PodName, err)
+	}
+	return nil
+}
+
+func (v *ValidateFlowAccuracy) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateFlowAccuracy) Stop() error {
+	return nil
+}
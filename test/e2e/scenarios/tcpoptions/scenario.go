@@ -0,0 +1,168 @@
+package tcpoptions
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	workloadNamespace = "retina-tcp-options"
+
+	requests = 50
+
+	// lets the flows of the pods' setup reach the metrics before the baseline is read
+	settleDelay = 15 * time.Second
+)
+
+// tcpOptionsClient is a client pod and the sysctls that decide which TCP options its connections carry
+type tcpOptionsClient struct {
+	name    string
+	sysctls []v1.Sysctl
+}
+
+// ValidateTCPOptionsFlowMetrics sends HTTP requests over fresh connections from a client with timestamps,
+// SACK and window scaling on, as they are by default, and from one with all three off, so the TCP headers
+// of the two differ in length. For each, it validates the agent's egress packet and byte counts match what
+// the client's interface sent.
+//
+// The sysctls turning the options off are outside the kubelet's safe set, so the cluster's kubelets have to
+// allow them with --allowed-unsafe-sysctls for the client without options to start.
+func ValidateTCPOptionsFlowMetrics() *types.Scenario {
+	name := "TCP Options Flow Metrics"
+	serverName := "agnhost-tcp-options-server"
+	clients := []tcpOptionsClient{
+		{
+			name: "agnhost-tcp-options-on",
+		},
+		{
+			name: "agnhost-tcp-options-off",
+			sysctls: []v1.Sysctl{
+				{Name: "net.ipv4.tcp_timestamps", Value: "0"},
+				{Name: "net.ipv4.tcp_sack", Value: "0"},
+				{Name: "net.ipv4.tcp_window_scaling", Value: "0"},
+			},
+		},
+	}
+
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.CreateNamespace{
+				NamespaceName: workloadNamespace,
+				Observe:       true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      serverName,
+				AgnhostNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	for _, client := range clients {
+		steps = append(steps, clientSteps(client, serverName)...)
+	}
+
+	steps = append(steps, &types.StepWrapper{
+		Step: &kubernetes.DeleteKubernetesResource{
+			ResourceType:      kubernetes.TypeString(kubernetes.Namespace),
+			ResourceName:      workloadNamespace,
+			ResourceNamespace: workloadNamespace,
+		},
+		Opts: &types.StepOptions{
+			SkipSavingParametersToJob: true,
+		},
+	})
+
+	return types.NewScenario(name, steps...)
+}
+
+// clientSteps creates the client and validates the agent on its node counts its requests to the server
+func clientSteps(client tcpOptionsClient, serverName string) []*types.StepWrapper {
+	podName := client.name + "-0"
+	portForwardID := client.name + "-port-forward"
+	counters := &flowCounters{}
+
+	return []*types.StepWrapper{
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      client.name,
+				AgnhostNamespace: workloadNamespace,
+				Sysctls:          client.sysctls,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.PortForward{
+				Namespace:                          "kube-system",
+				LabelSelector:                      "k8s-app=retina",
+				LocalPort:                          strconv.Itoa(common.RetinaPort),
+				RemotePort:                         strconv.Itoa(common.RetinaPort),
+				Endpoint:                           "metrics",
+				OptionalLabelAffinity:              "app=" + client.name,
+				OptionalLabelAffinityAllNamespaces: true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     portForwardID,
+			},
+		},
+		{
+			Step: &types.Sleep{
+				Duration: settleDelay,
+			},
+		},
+		{
+			Step: &CaptureFlowCounters{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				PodNamespace:            workloadNamespace,
+				PodName:                 podName,
+				counters:                counters,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &SendHTTPRequests{
+				PodNamespace:         workloadNamespace,
+				PodName:              podName,
+				DestinationNamespace: workloadNamespace,
+				DestinationPodName:   serverName + "-0",
+				Requests:             requests,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &ValidateFlowAccuracy{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				PodNamespace:            workloadNamespace,
+				PodName:                 podName,
+				counters:                counters,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &types.Stop{
+				BackgroundID: portForwardID,
+			},
+		},
+	}
+}
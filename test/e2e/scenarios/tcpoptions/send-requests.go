@@ -0,0 +1,64 @@
+package tcpoptions
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const requestTimeout = 5 * time.Minute
+
+// SendHTTPRequests makes Requests HTTP requests from the pod to the destination agnhost, each with its own
+// curl and so its own connection, so every request's handshake negotiates the TCP options afresh
+type SendHTTPRequests struct {
+	KubeConfigFilePath   string
+	PodNamespace         string
+	PodName              string
+	DestinationNamespace string
+	DestinationPodName   string
+	Requests             int
+}
+
+func (s *SendHTTPRequests) Run() error {
+	config, err := k8s.BuildConfig(s.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	destination, err := clientset.CoreV1().Pods(s.DestinationNamespace).Get(ctx, s.DestinationPodName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting destination pod \"%s\": %w", s.DestinationPodName, err)
+	}
+
+	command := fmt.Sprintf("curl -s -m 5 -o /dev/null http://%s:%d", destination.Status.PodIP, k8s.AgnhostHTTPPort)
+	for i := 0; i < s.Requests; i++ {
+		output, execErr := k8s.ExecPod(ctx, clientset, config, s.PodNamespace, s.PodName, command)
+		if execErr != nil {
+			return fmt.Errorf("error sending request %d from pod \"%s\": %s: %w", i, s.PodName, string(output), execErr)
+		}
+	}
+
+	log.Printf("sent %d requests from pod %s to %s\n", s.Requests, s.PodName, destination.Status.PodIP)
+	return nil
+}
+
+func (s *SendHTTPRequests) Prevalidate() error {
+	return nil
+}
+
+func (s *SendHTTPRequests) Stop() error {
+	return nil
+}
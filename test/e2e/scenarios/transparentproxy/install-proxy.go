@@ -0,0 +1,169 @@
+package transparentproxy
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	k8s "github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	defaultTimeout = 5 * time.Minute
+
+	proxyComment = "retina-e2e-transparent-proxy"
+)
+
+var ErrNoRetinaPodOnNode = fmt.Errorf("no retina pod found on node")
+
+// InstallTransparentProxy runs a host network listener on ProxyPort of the node running PodName and redirects the
+// pod's TCP traffic to DestinationPort there, the way a node local egress proxy intercepts traffic. The listener is
+// agnhost netexec, which answers in place of the destination. The redirect is added through the host network retina
+// pod on that node, run it in the background so Stop removes the redirect again.
+type InstallTransparentProxy struct {
+	KubeConfigFilePath       string
+	RetinaDaemonSetNamespace string
+	PodNamespace             string
+	PodName                  string
+	ProxyName                string
+	DestinationPort          int
+	ProxyPort                int
+
+	// local properties
+	podIP         string
+	nodeIP        string
+	retinaPodName string
+}
+
+func (i *InstallTransparentProxy) Run() error {
+	config, clientset, err := i.client()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	pod, err := clientset.CoreV1().Pods(i.PodNamespace).Get(ctx, i.PodName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting pod \"%s\": %w", i.PodName, err)
+	}
+	i.podIP = pod.Status.PodIP
+	i.nodeIP = pod.Status.HostIP
+
+	_, err = clientset.CoreV1().Pods(i.PodNamespace).Create(ctx, i.proxyPod(pod.Spec.NodeName), metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("error creating transparent proxy pod on node %s: %w", pod.Spec.NodeName, err)
+	}
+	err = k8s.WaitForPodsRunning(ctx, clientset, i.PodNamespace, "app="+i.ProxyName)
+	if err != nil {
+		return fmt.Errorf("error waiting for transparent proxy pod to be ready: %w", err)
+	}
+
+	retinaPods, err := clientset.CoreV1().Pods(i.RetinaDaemonSetNamespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "k8s-app=retina",
+		FieldSelector: "spec.nodeName=" + pod.Spec.NodeName,
+	})
+	if err != nil {
+		return fmt.Errorf("error listing retina pods on node %s: %w", pod.Spec.NodeName, err)
+	}
+	if len(retinaPods.Items) == 0 {
+		return fmt.Errorf("node %s: %w", pod.Spec.NodeName, ErrNoRetinaPodOnNode)
+	}
+	i.retinaPodName = retinaPods.Items[0].Name
+
+	_, err = k8s.ExecPod(ctx, clientset, config, i.RetinaDaemonSetNamespace, i.retinaPodName, i.rule("-I"))
+	if err != nil {
+		return fmt.Errorf("error installing transparent proxy redirect through retina pod \"%s\": %w", i.retinaPodName, err)
+	}
+	log.Printf("redirecting tcp port %d traffic of %s to %s:%d on node %s\n", i.DestinationPort, i.podIP, i.nodeIP, i.ProxyPort, pod.Spec.NodeName)
+
+	return nil
+}
+
+// rule DNATs to the node's own address rather than using REDIRECT, which needs an address on the pod's host interface
+func (i *InstallTransparentProxy) rule(op string) string {
+	return fmt.Sprintf("iptables -w -t nat %s PREROUTING -s %s/32 -p tcp --dport %d -m comment --comment %s -j DNAT --to-destination %s:%d",
+		op, i.podIP, i.DestinationPort, proxyComment, i.nodeIP, i.ProxyPort)
+}
+
+func (i *InstallTransparentProxy) proxyPod(nodeName string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      i.ProxyName,
+			Namespace: i.PodNamespace,
+			Labels: map[string]string{
+				"app": i.ProxyName,
+			},
+		},
+		Spec: v1.PodSpec{
+			HostNetwork: true,
+			NodeName:    nodeName,
+			Containers: []v1.Container{
+				{
+					Name:  i.ProxyName,
+					Image: "acnpublic.azurecr.io/agnhost:2.40",
+					Resources: v1.ResourceRequirements{
+						Requests: v1.ResourceList{
+							"memory": resource.MustParse("20Mi"),
+						},
+						Limits: v1.ResourceList{
+							"memory": resource.MustParse("20Mi"),
+						},
+					},
+					Command: []string{
+						"/agnhost",
+					},
+					Args: []string{
+						"netexec",
+						"--http-port=" + strconv.Itoa(i.ProxyPort),
+					},
+				},
+			},
+		},
+	}
+}
+
+func (i *InstallTransparentProxy) client() (*rest.Config, *kubernetes.Clientset, error) {
+	config, err := k8s.BuildConfig(i.KubeConfigFilePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+	return config, clientset, nil
+}
+
+func (i *InstallTransparentProxy) Prevalidate() error {
+	return nil
+}
+
+func (i *InstallTransparentProxy) Stop() error {
+	if i.retinaPodName == "" {
+		return nil
+	}
+
+	config, clientset, err := i.client()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	_, err = k8s.ExecPod(ctx, clientset, config, i.RetinaDaemonSetNamespace, i.retinaPodName, i.rule("-D"))
+	if err != nil {
+		return fmt.Errorf("error removing transparent proxy redirect through retina pod \"%s\": %w", i.retinaPodName, err)
+	}
+	return nil
+}
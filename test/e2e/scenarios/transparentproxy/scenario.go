@@ -0,0 +1,131 @@
+package transparentproxy
+
+import (
+	"strconv"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+)
+
+const (
+	workloadNamespace = "retina-transparent-proxy"
+
+	proxyPort = 15001
+	requests  = 5
+)
+
+// ValidateTransparentProxyFlowMetrics redirects a pod's HTTP requests to a proxy on its node and validates the
+// flows are attributed to the pod despite the redirect. On datapaths where pod traffic doesn't pass through the
+// node's netfilter the requests aren't redirected and the validation is skipped
+func ValidateTransparentProxyFlowMetrics() *types.Scenario {
+	name := "Transparent Proxy Flow Metrics"
+	clientName := "agnhost-proxy-client"
+	serverName := "agnhost-proxy-server"
+	proxy := &proxyState{}
+
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.CreateNamespace{
+				NamespaceName: workloadNamespace,
+				Observe:       true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      serverName,
+				AgnhostNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      clientName,
+				AgnhostNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &InstallTransparentProxy{
+				RetinaDaemonSetNamespace: "kube-system",
+				PodNamespace:             workloadNamespace,
+				PodName:                  clientName + "-0",
+				ProxyName:                "transparent-proxy",
+				DestinationPort:          kubernetes.AgnhostHTTPPort,
+				ProxyPort:                proxyPort,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "transparent-proxy",
+			},
+		},
+		{
+			Step: &SendProxiedRequests{
+				PodNamespace:         workloadNamespace,
+				PodName:              clientName + "-0",
+				DestinationNamespace: workloadNamespace,
+				DestinationPodName:   serverName + "-0",
+				Requests:             requests,
+				proxy:                proxy,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.PortForward{
+				Namespace:                          "kube-system",
+				LabelSelector:                      "k8s-app=retina",
+				LocalPort:                          strconv.Itoa(common.RetinaPort),
+				RemotePort:                         strconv.Itoa(common.RetinaPort),
+				Endpoint:                           "metrics",
+				OptionalLabelAffinity:              "app=" + clientName,
+				OptionalLabelAffinityAllNamespaces: true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+				RunInBackgroundWithID:     "transparent-proxy-port-forward",
+			},
+		},
+		{
+			Step: &ValidateProxiedFlowAttribution{
+				PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+				NamespaceName:           workloadNamespace,
+				PodName:                 clientName + "-0",
+				proxy:                   proxy,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &types.Stop{
+				BackgroundID: "transparent-proxy-port-forward",
+			},
+		},
+		{
+			Step: &types.Stop{
+				BackgroundID: "transparent-proxy",
+			},
+		},
+		{
+			Step: &kubernetes.DeleteKubernetesResource{
+				ResourceType:      kubernetes.TypeString(kubernetes.Namespace),
+				ResourceName:      workloadNamespace,
+				ResourceNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	return types.NewScenario(name, steps...)
+}
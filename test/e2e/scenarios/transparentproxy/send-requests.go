@@ -0,0 +1,85 @@
+package transparentproxy
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s "k8s.io/client-go/kubernetes"
+)
+
+var ErrPartiallyProxied = fmt.Errorf("only some requests were answered by the transparent proxy")
+
+// proxyState is filled in by SendProxiedRequests and read by the validators after it, so a datapath
+// whose pod traffic bypasses the node's netfilter runs the scenario without asserting on it
+type proxyState struct {
+	redirected bool
+}
+
+// SendProxiedRequests sends Requests HTTP requests from the pod to the destination pod and tells from the answers
+// whether the transparent proxy intercepted them: the destination answers with its pod name, netexec in the
+// proxy with its node's hostname
+type SendProxiedRequests struct {
+	KubeConfigFilePath   string
+	PodNamespace         string
+	PodName              string
+	DestinationNamespace string
+	DestinationPodName   string
+	Requests             int
+
+	proxy *proxyState
+}
+
+func (s *SendProxiedRequests) Run() error {
+	config, err := kubernetes.BuildConfig(s.KubeConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("error building kubeconfig: %w", err)
+	}
+
+	clientset, err := k8s.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	destination, err := clientset.CoreV1().Pods(s.DestinationNamespace).Get(ctx, s.DestinationPodName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting destination pod \"%s\": %w", s.DestinationPodName, err)
+	}
+
+	command := fmt.Sprintf("curl -s -m 5 http://%s:%d/hostname", destination.Status.PodIP, kubernetes.AgnhostHTTPPort)
+	proxied := 0
+	for i := 0; i < s.Requests; i++ {
+		output, execErr := kubernetes.ExecPod(ctx, clientset, config, s.PodNamespace, s.PodName, command)
+		if execErr != nil {
+			return fmt.Errorf("error sending request from pod \"%s\": %w", s.PodName, execErr)
+		}
+		if strings.TrimSpace(string(output)) != s.DestinationPodName {
+			proxied++
+		}
+	}
+
+	switch proxied {
+	case 0:
+		log.Printf("requests from pod \"%s\" reached pod \"%s\" directly, the datapath bypasses the redirect\n", s.PodName, s.DestinationPodName)
+	case s.Requests:
+		s.proxy.redirected = true
+		log.Printf("requests from pod \"%s\" to pod \"%s\" were answered by the transparent proxy\n", s.PodName, s.DestinationPodName)
+	default:
+		return fmt.Errorf("%d of %d requests from pod \"%s\": %w", proxied, s.Requests, s.PodName, ErrPartiallyProxied)
+	}
+	return nil
+}
+
+func (s *SendProxiedRequests) Prevalidate() error {
+	return nil
+}
+
+func (s *SendProxiedRequests) Stop() error {
+	return nil
+}
@@ -0,0 +1,87 @@
+package transparentproxy
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/microsoft/retina/test/retry"
+)
+
+const (
+	advForwardCountMetricName = "networkobservability_adv_forward_count"
+
+	metricRetryAttempts = 20
+	metricRetryDelay    = 5 * time.Second
+)
+
+var ErrNoOriginFlow = fmt.Errorf("no flow series attributed to the originating pod")
+
+// the redirected requests and the proxy's answers are both attributed to the originating pod
+var attributedDirections = []string{"egress", "ingress"}
+
+// ValidateProxiedFlowAttribution checks the pod's traffic is attributed to the pod in both directions, even though
+// the node redirected it to a proxy rather than the destination it was sent to. It passes without checking when
+// SendProxiedRequests found the requests weren't redirected
+type ValidateProxiedFlowAttribution struct {
+	PortForwardedRetinaPort string
+	NamespaceName           string
+	PodName                 string
+
+	proxy *proxyState
+}
+
+func (v *ValidateProxiedFlowAttribution) Run() error {
+	if !v.proxy.redirected {
+		log.Printf("traffic of %s/%s wasn't redirected, skipping validation\n", v.NamespaceName, v.PodName)
+		return nil
+	}
+
+	promAddress := fmt.Sprintf("http://localhost:%s/metrics", v.PortForwardedRetinaPort)
+
+	checkFn := func() error {
+		series, err := prom.GetMetricsMatchingLabels(promAddress, advForwardCountMetricName, map[string]string{
+			"namespace": v.NamespaceName,
+			"podname":   v.PodName,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to scrape %s: %w", advForwardCountMetricName, err)
+		}
+
+		directions := map[string]bool{}
+		for _, metric := range series {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "direction" && metric.GetCounter().GetValue() > 0 {
+					directions[label.GetValue()] = true
+				}
+			}
+		}
+
+		for _, direction := range attributedDirections {
+			if !directions[direction] {
+				log.Printf("no %s %s series for %s/%s yet\n", direction, advForwardCountMetricName, v.NamespaceName, v.PodName)
+				return ErrNoOriginFlow
+			}
+		}
+		return nil
+	}
+
+	retrier := retry.Retrier{Attempts: metricRetryAttempts, Delay: metricRetryDelay}
+	err := retrier.Do(context.Background(), checkFn)
+	if err != nil {
+		return fmt.Errorf("failed to verify prometheus metrics %s: %w", advForwardCountMetricName, err)
+	}
+
+	log.Printf("proxied traffic of %s/%s is attributed to the pod\n", v.NamespaceName, v.PodName)
+	return nil
+}
+
+func (v *ValidateProxiedFlowAttribution) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateProxiedFlowAttribution) Stop() error {
+	return nil
+}
@@ -0,0 +1,138 @@
+package udp
+
+import (
+	"strconv"
+
+	"github.com/microsoft/retina/test/e2e/common"
+	"github.com/microsoft/retina/test/e2e/framework/kubernetes"
+	"github.com/microsoft/retina/test/e2e/framework/types"
+)
+
+const (
+	workloadNamespace = "retina-one-way-udp"
+
+	receiverUDPPort = 9000
+	datagrams       = 5
+)
+
+// ValidateOneWayUDPMetrics sends UDP datagrams that are never answered from one pod to another and validates
+// the sender's traffic is counted as egress only and the receiver's as ingress only
+func ValidateOneWayUDPMetrics() *types.Scenario {
+	name := "One Way UDP Flow Metrics"
+	senderName := "agnhost-udp-sender"
+	receiverName := "agnhost-udp-receiver"
+
+	// netexec sends the datagrams from its /dial endpoint, and receives them on its udp port without
+	// answering payloads it doesn't know
+	netexecArgs := []string{
+		"netexec",
+		"--http-port=" + strconv.Itoa(kubernetes.AgnhostNetexecHTTPPort),
+		"--udp-port=" + strconv.Itoa(receiverUDPPort),
+	}
+
+	steps := []*types.StepWrapper{
+		{
+			Step: &kubernetes.CreateNamespace{
+				NamespaceName: workloadNamespace,
+				Observe:       true,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      receiverName,
+				AgnhostNamespace: workloadNamespace,
+				Args:             netexecArgs,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.CreateAgnhostStatefulSet{
+				AgnhostName:      senderName,
+				AgnhostNamespace: workloadNamespace,
+				Args:             netexecArgs,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+		{
+			Step: &kubernetes.SendOneWayUDP{
+				PodNamespace:         workloadNamespace,
+				PodName:              senderName + "-0",
+				DestinationNamespace: workloadNamespace,
+				DestinationPodName:   receiverName + "-0",
+				DestinationPort:      receiverUDPPort,
+				Datagrams:            datagrams,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	// each end of the traffic is checked on the agent of its own node
+	ends := []struct {
+		agnhostName string
+		direction   string
+	}{
+		{agnhostName: senderName, direction: "egress"},
+		{agnhostName: receiverName, direction: "ingress"},
+	}
+	for _, end := range ends {
+		portForwardID := "udp-port-forward-" + end.agnhostName
+		steps = append(steps,
+			&types.StepWrapper{
+				Step: &kubernetes.PortForward{
+					Namespace:                          "kube-system",
+					LabelSelector:                      "k8s-app=retina",
+					LocalPort:                          strconv.Itoa(common.RetinaPort),
+					RemotePort:                         strconv.Itoa(common.RetinaPort),
+					Endpoint:                           "metrics",
+					OptionalLabelAffinity:              "app=" + end.agnhostName,
+					OptionalLabelAffinityAllNamespaces: true,
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+					RunInBackgroundWithID:     portForwardID,
+				},
+			},
+			&types.StepWrapper{
+				Step: &ValidateOneWayFlow{
+					PortForwardedRetinaPort: strconv.Itoa(common.RetinaPort),
+					NamespaceName:           workloadNamespace,
+					PodName:                 end.agnhostName + "-0",
+					Direction:               end.direction,
+				},
+				Opts: &types.StepOptions{
+					SkipSavingParametersToJob: true,
+				},
+			},
+			&types.StepWrapper{
+				Step: &types.Stop{
+					BackgroundID: portForwardID,
+				},
+			},
+		)
+	}
+
+	// deletes the workloads even when a validation fails
+	cleanup := []*types.StepWrapper{
+		{
+			Step: &kubernetes.DeleteKubernetesResource{
+				ResourceType:      kubernetes.TypeString(kubernetes.Namespace),
+				ResourceName:      workloadNamespace,
+				ResourceNamespace: workloadNamespace,
+			},
+			Opts: &types.StepOptions{
+				SkipSavingParametersToJob: true,
+			},
+		},
+	}
+
+	return types.NewScenario(name, steps...).WithCleanup(cleanup...)
+}
@@ -0,0 +1,83 @@
+package udp
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	prom "github.com/microsoft/retina/test/e2e/framework/prometheus"
+	"github.com/microsoft/retina/test/retry"
+)
+
+const (
+	advForwardCountMetricName = "networkobservability_adv_forward_count"
+
+	metricRetryAttempts = 20
+	metricRetryDelay    = 5 * time.Second
+)
+
+var (
+	ErrNoOneWayFlow        = fmt.Errorf("no flow series for the one way traffic")
+	ErrFabricatedDirection = fmt.Errorf("flow series reported for a direction with no traffic")
+)
+
+// ValidateOneWayFlow checks the pod's one way traffic is counted in Direction only. The pod sends or receives
+// nothing else, so any series of the opposite direction would be a response the agent made up
+type ValidateOneWayFlow struct {
+	PortForwardedRetinaPort string
+	NamespaceName           string
+	PodName                 string
+	Direction               string
+}
+
+func (v *ValidateOneWayFlow) Run() error {
+	promAddress := fmt.Sprintf("http://localhost:%s/metrics", v.PortForwardedRetinaPort)
+
+	checkFn := func() error {
+		series, err := prom.GetMetricsMatchingLabels(promAddress, advForwardCountMetricName, map[string]string{
+			"namespace": v.NamespaceName,
+			"podname":   v.PodName,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to scrape %s: %w", advForwardCountMetricName, err)
+		}
+
+		counts := map[string]float64{}
+		for _, metric := range series {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "direction" {
+					counts[label.GetValue()] += metric.GetCounter().GetValue()
+				}
+			}
+		}
+
+		if counts[v.Direction] == 0 {
+			log.Printf("no %s %s series for %s/%s yet\n", v.Direction, advForwardCountMetricName, v.NamespaceName, v.PodName)
+			return ErrNoOneWayFlow
+		}
+		for direction, count := range counts {
+			if direction != v.Direction && count > 0 {
+				return fmt.Errorf("%s/%s has %v %s packets: %w", v.NamespaceName, v.PodName, count, direction, ErrFabricatedDirection)
+			}
+		}
+		return nil
+	}
+
+	retrier := retry.Retrier{Attempts: metricRetryAttempts, Delay: metricRetryDelay}
+	err := retrier.Do(context.Background(), checkFn)
+	if err != nil {
+		return fmt.Errorf("failed to verify prometheus metrics %s: %w", advForwardCountMetricName, err)
+	}
+
+	log.Printf("one way traffic of %s/%s is counted as %s only\n", v.NamespaceName, v.PodName, v.Direction)
+	return nil
+}
+
+func (v *ValidateOneWayFlow) Prevalidate() error {
+	return nil
+}
+
+func (v *ValidateOneWayFlow) Stop() error {
+	return nil
+}
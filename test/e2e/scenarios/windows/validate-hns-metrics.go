@@ -14,7 +14,6 @@ import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kubernetes "k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
 )
 
 const (
@@ -38,7 +37,7 @@ type ValidateHNSMetric struct {
 }
 
 func (v *ValidateHNSMetric) Run() error {
-	config, err := clientcmd.BuildConfigFromFlags("", v.KubeConfigFilePath)
+	config, err := k8s.BuildConfig(v.KubeConfigFilePath)
 	if err != nil {
 		return fmt.Errorf("error building kubeconfig: %w", err)
 	}